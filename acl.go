@@ -0,0 +1,165 @@
+package kafka
+
+// PatternType designates how the name of a resource is matched by an ACL.
+//
+// See https://github.com/apache/kafka/blob/trunk/clients/src/main/java/org/apache/kafka/common/resource/PatternType.java
+type PatternType int8
+
+const (
+	PatternTypeUnknown PatternType = 0
+
+	// PatternTypeAny is only valid in filters, it matches ACLs of any
+	// pattern type.
+	PatternTypeAny PatternType = 1
+
+	// PatternTypeMatch is only valid in filters, it matches the ACLs that
+	// apply to the resource name, whatever their pattern type is.
+	PatternTypeMatch PatternType = 2
+
+	// PatternTypeLiteral designates ACLs that apply to the resource with the
+	// exact name of the ACL, "*" being the wildcard resource name.
+	PatternTypeLiteral PatternType = 3
+
+	// PatternTypePrefixed designates ACLs that apply to all the resources
+	// whose name starts with the name of the ACL.
+	PatternTypePrefixed PatternType = 4
+)
+
+// ACLOperationType is the operation that an ACL allows or denies.
+//
+// See https://github.com/apache/kafka/blob/trunk/clients/src/main/java/org/apache/kafka/common/acl/AclOperation.java
+type ACLOperationType int8
+
+const (
+	ACLOperationTypeUnknown ACLOperationType = 0
+
+	// ACLOperationTypeAny is only valid in filters, it matches ACLs of any
+	// operation.
+	ACLOperationTypeAny ACLOperationType = 1
+
+	ACLOperationTypeAll             ACLOperationType = 2
+	ACLOperationTypeRead            ACLOperationType = 3
+	ACLOperationTypeWrite           ACLOperationType = 4
+	ACLOperationTypeCreate          ACLOperationType = 5
+	ACLOperationTypeDelete          ACLOperationType = 6
+	ACLOperationTypeAlter           ACLOperationType = 7
+	ACLOperationTypeDescribe        ACLOperationType = 8
+	ACLOperationTypeClusterAction   ACLOperationType = 9
+	ACLOperationTypeDescribeConfigs ACLOperationType = 10
+	ACLOperationTypeAlterConfigs    ACLOperationType = 11
+	ACLOperationTypeIdempotentWrite ACLOperationType = 12
+)
+
+// ACLPermissionType designates whether an ACL allows or denies an operation.
+//
+// See https://github.com/apache/kafka/blob/trunk/clients/src/main/java/org/apache/kafka/common/acl/AclPermissionType.java
+type ACLPermissionType int8
+
+const (
+	ACLPermissionTypeUnknown ACLPermissionType = 0
+
+	// ACLPermissionTypeAny is only valid in filters, it matches ACLs of any
+	// permission type.
+	ACLPermissionTypeAny ACLPermissionType = 1
+
+	ACLPermissionTypeDeny  ACLPermissionType = 2
+	ACLPermissionTypeAllow ACLPermissionType = 3
+)
+
+// ACLEntry is an ACL binding, it allows or denies a principal to perform an
+// operation on resources from a host.
+type ACLEntry struct {
+	// ResourceType is the type of the resource, for example
+	// ResourceTypeTopic, ResourceTypeGroup, ResourceTypeCluster, or
+	// ResourceTypeTransactionalID.
+	ResourceType ResourceType
+
+	// ResourceName is the name of the resource, "kafka-cluster" for the
+	// cluster resource.
+	ResourceName string
+
+	// ResourcePatternType is the way the resource name is matched, it must be
+	// PatternTypeLiteral or PatternTypePrefixed.
+	ResourcePatternType PatternType
+
+	// Principal is the principal that the ACL applies to, for example
+	// "User:alice".
+	Principal string
+
+	// Host is the host that the ACL applies to, "*" for all hosts.
+	Host string
+
+	Operation      ACLOperationType
+	PermissionType ACLPermissionType
+}
+
+// ACLFilter selects ACL bindings. Fields set to their zero value, or to the
+// Any constant of their type, match all the ACLs.
+type ACLFilter struct {
+	ResourceTypeFilter        ResourceType
+	ResourceNameFilter        string
+	ResourcePatternTypeFilter PatternType
+	PrincipalFilter           string
+	HostFilter                string
+	Operation                 ACLOperationType
+	PermissionType            ACLPermissionType
+}
+
+// aclFilterV1 is the encoding of ACL filters shared by the DescribeAcls and
+// DeleteAcls requests.
+type aclFilterV1 struct {
+	ResourceTypeFilter int8
+	ResourceNameFilter *string
+	PatternTypeFilter  int8
+	PrincipalFilter    *string
+	HostFilter         *string
+	Operation          int8
+	PermissionType     int8
+}
+
+func makeACLFilterV1(f ACLFilter) aclFilterV1 {
+	filter := aclFilterV1{
+		ResourceTypeFilter: int8(f.ResourceTypeFilter),
+		ResourceNameFilter: emptyToNullable(f.ResourceNameFilter),
+		PatternTypeFilter:  int8(f.ResourcePatternTypeFilter),
+		PrincipalFilter:    emptyToNullable(f.PrincipalFilter),
+		HostFilter:         emptyToNullable(f.HostFilter),
+		Operation:          int8(f.Operation),
+		PermissionType:     int8(f.PermissionType),
+	}
+	// Brokers reject filters with unknown values, the zero values are turned
+	// into wildcards.
+	if filter.ResourceTypeFilter == int8(ResourceTypeUnknown) {
+		filter.ResourceTypeFilter = int8(ResourceTypeAny)
+	}
+	if filter.PatternTypeFilter == int8(PatternTypeUnknown) {
+		filter.PatternTypeFilter = int8(PatternTypeAny)
+	}
+	if filter.Operation == int8(ACLOperationTypeUnknown) {
+		filter.Operation = int8(ACLOperationTypeAny)
+	}
+	if filter.PermissionType == int8(ACLPermissionTypeUnknown) {
+		filter.PermissionType = int8(ACLPermissionTypeAny)
+	}
+	return filter
+}
+
+func (t aclFilterV1) size() int32 {
+	return sizeofInt8(t.ResourceTypeFilter) +
+		sizeofNullableString(t.ResourceNameFilter) +
+		sizeofInt8(t.PatternTypeFilter) +
+		sizeofNullableString(t.PrincipalFilter) +
+		sizeofNullableString(t.HostFilter) +
+		sizeofInt8(t.Operation) +
+		sizeofInt8(t.PermissionType)
+}
+
+func (t aclFilterV1) writeTo(wb *writeBuffer) {
+	wb.writeInt8(t.ResourceTypeFilter)
+	wb.writeNullableString(t.ResourceNameFilter)
+	wb.writeInt8(t.PatternTypeFilter)
+	wb.writeNullableString(t.PrincipalFilter)
+	wb.writeNullableString(t.HostFilter)
+	wb.writeInt8(t.Operation)
+	wb.writeInt8(t.PermissionType)
+}
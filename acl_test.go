@@ -0,0 +1,220 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestMakeACLFilterV1(t *testing.T) {
+	wildcard := makeACLFilterV1(ACLFilter{})
+	if wildcard.ResourceTypeFilter != int8(ResourceTypeAny) {
+		t.Errorf("expected resource type %d, got %d", ResourceTypeAny, wildcard.ResourceTypeFilter)
+	}
+	if wildcard.PatternTypeFilter != int8(PatternTypeAny) {
+		t.Errorf("expected pattern type %d, got %d", PatternTypeAny, wildcard.PatternTypeFilter)
+	}
+	if wildcard.Operation != int8(ACLOperationTypeAny) {
+		t.Errorf("expected operation %d, got %d", ACLOperationTypeAny, wildcard.Operation)
+	}
+	if wildcard.PermissionType != int8(ACLPermissionTypeAny) {
+		t.Errorf("expected permission type %d, got %d", ACLPermissionTypeAny, wildcard.PermissionType)
+	}
+	if wildcard.ResourceNameFilter != nil || wildcard.PrincipalFilter != nil || wildcard.HostFilter != nil {
+		t.Error("expected null string filters")
+	}
+
+	f := makeACLFilterV1(ACLFilter{
+		ResourceTypeFilter:        ResourceTypeTopic,
+		ResourceNameFilter:        "a",
+		ResourcePatternTypeFilter: PatternTypeMatch,
+		PrincipalFilter:           "User:b",
+		Operation:                 ACLOperationTypeRead,
+		PermissionType:            ACLPermissionTypeAllow,
+	})
+	if f.ResourceTypeFilter != int8(ResourceTypeTopic) || f.PatternTypeFilter != int8(PatternTypeMatch) ||
+		f.Operation != int8(ACLOperationTypeRead) || f.PermissionType != int8(ACLPermissionTypeAllow) {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+	if f.ResourceNameFilter == nil || *f.ResourceNameFilter != "a" {
+		t.Error("expected resource name filter to be set")
+	}
+	if f.HostFilter != nil {
+		t.Error("expected null host filter")
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	f.writeTo(w)
+
+	if int32(b.Len()) != f.size() {
+		t.Errorf("expected %d bytes, got %d", f.size(), b.Len())
+	}
+}
+
+func TestCreateACLsResponseV1(t *testing.T) {
+	item := createACLsResponseV1{
+		ThrottleTimeMS: 1,
+		Results: []createACLsResponseResultV1{
+			{},
+			{ErrorCode: int16(InvalidRequest), ErrorMessage: "a"},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found createACLsResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDescribeACLsResponseV1(t *testing.T) {
+	item := describeACLsResponseV1{
+		ThrottleTimeMS: 1,
+		Resources: []describeACLsResponseResourceV1{
+			{
+				ResourceType: int8(ResourceTypeTopic),
+				ResourceName: "a",
+				PatternType:  int8(PatternTypeLiteral),
+				ACLs: []describeACLsResponseACLV1{
+					{
+						Principal:      "User:b",
+						Host:           "*",
+						Operation:      int8(ACLOperationTypeRead),
+						PermissionType: int8(ACLPermissionTypeAllow),
+					},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found describeACLsResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDeleteACLsResponseV1(t *testing.T) {
+	item := deleteACLsResponseV1{
+		ThrottleTimeMS: 1,
+		FilterResults: []deleteACLsResponseFilterResultV1{
+			{
+				MatchingACLs: []deleteACLsResponseMatchingACLV1{
+					{
+						ResourceType:   int8(ResourceTypeGroup),
+						ResourceName:   "a",
+						PatternType:    int8(PatternTypePrefixed),
+						Principal:      "User:b",
+						Host:           "*",
+						Operation:      int8(ACLOperationTypeRead),
+						PermissionType: int8(ACLPermissionTypeDeny),
+					},
+				},
+			},
+			{ErrorCode: int16(SecurityDisabled), ErrorMessage: "c"},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found deleteACLsResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func testClientACLs(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.0.0") {
+		t.Skip("acl management requires kafka 2.0.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	acl := ACLEntry{
+		ResourceType:        ResourceTypeTopic,
+		ResourceName:        topic,
+		ResourcePatternType: PatternTypeLiteral,
+		Principal:           "User:alice",
+		Host:                "*",
+		Operation:           ACLOperationTypeRead,
+		PermissionType:      ACLPermissionTypeAllow,
+	}
+
+	created, err := c.CreateACLs(ctx, CreateACLsRequest{ACLs: []ACLEntry{acl}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := created.Errors[0]; err != nil {
+		if err == SecurityDisabled {
+			t.Skip("the brokers are not configured with an authorizer")
+		}
+		t.Fatal(err)
+	}
+
+	filter := ACLFilter{
+		ResourceTypeFilter: ResourceTypeTopic,
+		ResourceNameFilter: topic,
+	}
+
+	described, err := c.DescribeACLs(ctx, DescribeACLsRequest{Filter: filter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(described.ACLs, []ACLEntry{acl}) {
+		t.Errorf("expected %+v, got %+v", []ACLEntry{acl}, described.ACLs)
+	}
+
+	deleted, err := c.DeleteACLs(ctx, DeleteACLsRequest{Filters: []ACLFilter{filter}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := deleted.Results[0].Error; err != nil {
+		t.Fatal(err)
+	}
+	if matches := deleted.Results[0].MatchingACLs; len(matches) != 1 || matches[0].ACL != acl {
+		t.Errorf("expected %+v to be deleted, got %+v", acl, matches)
+	}
+}
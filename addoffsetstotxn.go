@@ -0,0 +1,132 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// AddOffsetsToTxnRequest represents a request sent to a kafka cluster to add
+// the offsets of a consumer group to the ongoing transaction of a producer.
+type AddOffsetsToTxnRequest struct {
+	// TransactionalID, ProducerID, and ProducerEpoch identify the
+	// transactional producer, as returned by InitProducerID.
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int
+
+	// GroupID is the ID of the consumer group whose offsets are committed as
+	// part of the transaction.
+	GroupID string
+}
+
+// AddOffsetsToTxnResponse represents the response to an AddOffsetsToTxnRequest.
+type AddOffsetsToTxnResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+}
+
+// AddOffsetsToTxn adds the offsets of a consumer group to the ongoing
+// transaction of a producer, which must be done before committing the offsets
+// with TxnOffsetCommit. The request is sent to the coordinator of the
+// transactional id. The API was introduced in kafka 0.11.
+func (c *Client) AddOffsetsToTxn(ctx context.Context, req AddOffsetsToTxnRequest) (*AddOffsetsToTxnResponse, error) {
+	conn, err := c.transactionCoordinator(ctx, req.TransactionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.addOffsetsToTxn(addOffsetsToTxnRequestV0{
+		TransactionalID: req.TransactionalID,
+		ProducerID:      req.ProducerID,
+		ProducerEpoch:   int16(req.ProducerEpoch),
+		GroupID:         req.GroupID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddOffsetsToTxnResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+	}, nil
+}
+
+// addOffsetsToTxn adds the offsets of a group to a transaction, the broker
+// must be the coordinator of the transactional id.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_AddOffsetsToTxn
+func (c *Conn) addOffsetsToTxn(request addOffsetsToTxnRequestV0) (txnResponseV0, error) {
+	var response txnResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(addOffsetsToTxn, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return txnResponseV0{}, err
+	}
+	if response.ErrorCode != 0 {
+		return txnResponseV0{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AddOffsetsToTxn
+type addOffsetsToTxnRequestV0 struct {
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int16
+	GroupID         string
+}
+
+func (t addOffsetsToTxnRequestV0) size() int32 {
+	return sizeofString(t.TransactionalID) +
+		sizeofInt64(t.ProducerID) +
+		sizeofInt16(t.ProducerEpoch) +
+		sizeofString(t.GroupID)
+}
+
+func (t addOffsetsToTxnRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TransactionalID)
+	wb.writeInt64(t.ProducerID)
+	wb.writeInt16(t.ProducerEpoch)
+	wb.writeString(t.GroupID)
+}
+
+// txnResponseV0 is the response to the AddOffsetsToTxn and EndTxn requests,
+// which share the same layout.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_AddOffsetsToTxn
+type txnResponseV0 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+}
+
+func (t txnResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode)
+}
+
+func (t txnResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+}
+
+func (t *txnResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,153 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// AddPartitionsToTxnRequest represents a request sent to a kafka cluster to
+// add partitions to the ongoing transaction of a producer.
+type AddPartitionsToTxnRequest struct {
+	// TransactionalID, ProducerID, and ProducerEpoch identify the
+	// transactional producer, as returned by InitProducerID.
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int
+
+	// Topics holds the partitions to add to the transaction, indexed by topic
+	// name.
+	Topics map[string][]int
+}
+
+// AddPartitionsToTxnResponse represents the response to an
+// AddPartitionsToTxnRequest.
+type AddPartitionsToTxnResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+
+	// Topics holds the result of adding each partition to the transaction,
+	// indexed by topic name.
+	Topics map[string][]AddPartitionsToTxnPartition
+}
+
+// AddPartitionsToTxnPartition carries the result of adding a partition to a
+// transaction.
+type AddPartitionsToTxnPartition struct {
+	Partition int
+
+	// Error is set to a non-nil value if the partition could not be added to
+	// the transaction, for example InvalidProducerEpoch if the producer was
+	// fenced by a newer instance.
+	Error error
+}
+
+// AddPartitionsToTxn adds partitions to the ongoing transaction of a producer,
+// which must be done before producing records to the partitions within the
+// transaction. The request is sent to the coordinator of the transactional id.
+// The API was introduced in kafka 0.11.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) AddPartitionsToTxn(ctx context.Context, req AddPartitionsToTxnRequest) (*AddPartitionsToTxnResponse, error) {
+	request := addPartitionsToTxnRequestV0{
+		TransactionalID: req.TransactionalID,
+		ProducerID:      req.ProducerID,
+		ProducerEpoch:   int16(req.ProducerEpoch),
+		Topics:          make([]offsetFetchRequestV1Topic, 0, len(req.Topics)),
+	}
+
+	for topic, partitions := range req.Topics {
+		t := offsetFetchRequestV1Topic{
+			Topic:      topic,
+			Partitions: make([]int32, len(partitions)),
+		}
+		for i, p := range partitions {
+			t.Partitions[i] = int32(p)
+		}
+		request.Topics = append(request.Topics, t)
+	}
+
+	sort.Slice(request.Topics, func(i, j int) bool {
+		return request.Topics[i].Topic < request.Topics[j].Topic
+	})
+
+	conn, err := c.transactionCoordinator(ctx, req.TransactionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.addPartitionsToTxn(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AddPartitionsToTxnResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make(map[string][]AddPartitionsToTxnPartition, len(response.Topics)),
+	}
+
+	for _, t := range response.Topics {
+		partitions := make([]AddPartitionsToTxnPartition, len(t.PartitionResponses))
+		for i, p := range t.PartitionResponses {
+			partitions[i] = AddPartitionsToTxnPartition{Partition: int(p.Partition)}
+			if p.ErrorCode != 0 {
+				partitions[i].Error = Error(p.ErrorCode)
+			}
+		}
+		res.Topics[t.Topic] = partitions
+	}
+
+	return res, nil
+}
+
+// addPartitionsToTxn adds partitions to a transaction, the broker must be the
+// coordinator of the transactional id. The response has the same layout than
+// the response to OffsetCommit v5.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_AddPartitionsToTxn
+func (c *Conn) addPartitionsToTxn(request addPartitionsToTxnRequestV0) (offsetCommitResponseV5, error) {
+	var response offsetCommitResponseV5
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(addPartitionsToTxn, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return offsetCommitResponseV5{}, err
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AddPartitionsToTxn
+type addPartitionsToTxnRequestV0 struct {
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int16
+
+	// Topics have the same layout than in OffsetFetch v1.
+	Topics []offsetFetchRequestV1Topic
+}
+
+func (t addPartitionsToTxnRequestV0) size() int32 {
+	return sizeofString(t.TransactionalID) +
+		sizeofInt64(t.ProducerID) +
+		sizeofInt16(t.ProducerEpoch) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t addPartitionsToTxnRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TransactionalID)
+	wb.writeInt64(t.ProducerID)
+	wb.writeInt16(t.ProducerEpoch)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
@@ -0,0 +1,259 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+)
+
+// AlterClientQuotasRequest represents a request sent to a kafka cluster to set
+// or remove client quotas.
+type AlterClientQuotasRequest struct {
+	// Entries holds the quota changes to apply to each entity.
+	Entries []AlterClientQuotasRequestEntry
+
+	// When ValidateOnly is true, the broker validates the request without
+	// applying the changes.
+	ValidateOnly bool
+}
+
+// AlterClientQuotasRequestEntry designates an entity and the changes to apply
+// to its quotas.
+type AlterClientQuotasRequestEntry struct {
+	Entity []ClientQuotaEntityComponent
+	Ops    []AlterClientQuotasRequestOp
+}
+
+// AlterClientQuotasRequestOp is a change to a quota of an entity.
+type AlterClientQuotasRequestOp struct {
+	// Key is the quota key, for example ClientQuotaProducerByteRate.
+	Key string
+
+	// Value is the new value of the quota, it is ignored when Remove is true.
+	Value float64
+
+	// When Remove is true, the quota is removed from the entity.
+	Remove bool
+}
+
+// AlterClientQuotasResponse represents the response to an
+// AlterClientQuotasRequest.
+type AlterClientQuotasResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// Entries holds the result of altering the quotas of each entity, in the
+	// order they appeared in the request.
+	Entries []AlterClientQuotasResponseEntry
+}
+
+// AlterClientQuotasResponseEntry carries the result of altering the quotas of
+// an entity.
+type AlterClientQuotasResponseEntry struct {
+	Entity []ClientQuotaEntityComponent
+
+	// Error is set to a non-nil value if the quotas of the entity could not
+	// be altered.
+	Error error
+
+	// ErrorMessage holds the message sent by the broker alongside Error.
+	ErrorMessage string
+}
+
+// AlterClientQuotas sends an AlterClientQuotas request to the kafka cluster.
+// The API was introduced in kafka 2.6 (KIP-546).
+//
+// Errors that apply to a single entity are reported on the entity and do not
+// cause the method to fail.
+func (c *Client) AlterClientQuotas(ctx context.Context, req AlterClientQuotasRequest) (*AlterClientQuotasResponse, error) {
+	request := alterClientQuotasRequestV0{
+		Entries:      make([]alterClientQuotasRequestEntryV0, len(req.Entries)),
+		ValidateOnly: req.ValidateOnly,
+	}
+
+	for i, entry := range req.Entries {
+		request.Entries[i] = alterClientQuotasRequestEntryV0{
+			Entity: makeClientQuotaEntityV0(entry.Entity),
+			Ops:    make([]alterClientQuotasRequestOpV0, len(entry.Ops)),
+		}
+		for j, op := range entry.Ops {
+			request.Entries[i].Ops[j] = alterClientQuotasRequestOpV0{
+				Key:    op.Key,
+				Value:  op.Value,
+				Remove: op.Remove,
+			}
+		}
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.alterClientQuotas(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Entries) != len(req.Entries) {
+		return nil, fmt.Errorf("expected %d entries in the alter client quotas response, got %d", len(req.Entries), len(response.Entries))
+	}
+
+	res := &AlterClientQuotasResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Entries:  make([]AlterClientQuotasResponseEntry, len(response.Entries)),
+	}
+
+	for i, entry := range response.Entries {
+		res.Entries[i] = AlterClientQuotasResponseEntry{
+			Entity:       makeClientQuotaEntity(entry.Entity),
+			ErrorMessage: entry.ErrorMessage,
+		}
+		if entry.ErrorCode != 0 {
+			res.Entries[i].Error = Error(entry.ErrorCode)
+		}
+	}
+
+	return res, nil
+}
+
+// alterClientQuotas applies the quota changes of the request.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterClientQuotas
+func (c *Conn) alterClientQuotas(request alterClientQuotasRequestV0) (alterClientQuotasResponseV0, error) {
+	var response alterClientQuotasResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(alterClientQuotas, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return alterClientQuotasResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+type alterClientQuotasRequestOpV0 struct {
+	Key    string
+	Value  float64
+	Remove bool
+}
+
+func (t alterClientQuotasRequestOpV0) size() int32 {
+	return sizeofString(t.Key) +
+		sizeofFloat64(t.Value) +
+		sizeofBool(t.Remove)
+}
+
+func (t alterClientQuotasRequestOpV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Key)
+	wb.writeFloat64(t.Value)
+	wb.writeBool(t.Remove)
+}
+
+type alterClientQuotasRequestEntryV0 struct {
+	Entity []clientQuotaEntityComponentV0
+	Ops    []alterClientQuotasRequestOpV0
+}
+
+func (t alterClientQuotasRequestEntryV0) size() int32 {
+	return sizeofArray(len(t.Entity), func(i int) int32 { return t.Entity[i].size() }) +
+		sizeofArray(len(t.Ops), func(i int) int32 { return t.Ops[i].size() })
+}
+
+func (t alterClientQuotasRequestEntryV0) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Entity), func(i int) { t.Entity[i].writeTo(wb) })
+	wb.writeArray(len(t.Ops), func(i int) { t.Ops[i].writeTo(wb) })
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterClientQuotas
+type alterClientQuotasRequestV0 struct {
+	Entries      []alterClientQuotasRequestEntryV0
+	ValidateOnly bool
+}
+
+func (t alterClientQuotasRequestV0) size() int32 {
+	return sizeofArray(len(t.Entries), func(i int) int32 { return t.Entries[i].size() }) +
+		sizeofBool(t.ValidateOnly)
+}
+
+func (t alterClientQuotasRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Entries), func(i int) { t.Entries[i].writeTo(wb) })
+	wb.writeBool(t.ValidateOnly)
+}
+
+type alterClientQuotasResponseEntryV0 struct {
+	ErrorCode    int16
+	ErrorMessage string
+	Entity       []clientQuotaEntityComponentV0
+}
+
+func (t alterClientQuotasResponseEntryV0) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage) +
+		sizeofArray(len(t.Entity), func(i int) int32 { return t.Entity[i].size() })
+}
+
+func (t alterClientQuotasResponseEntryV0) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+	wb.writeArray(len(t.Entity), func(i int) { t.Entity[i].writeTo(wb) })
+}
+
+func (t *alterClientQuotasResponseEntryV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readClientQuotaEntityV0(r, remain, &t.Entity); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterClientQuotas
+type alterClientQuotasResponseV0 struct {
+	ThrottleTimeMS int32
+	Entries        []alterClientQuotasResponseEntryV0
+}
+
+func (t alterClientQuotasResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Entries), func(i int) int32 { return t.Entries[i].size() })
+}
+
+func (t alterClientQuotasResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Entries), func(i int) { t.Entries[i].writeTo(wb) })
+}
+
+func (t *alterClientQuotasResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var entry alterClientQuotasResponseEntryV0
+		if fnRemain, fnErr = (&entry).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Entries = append(t.Entries, entry)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestAlterClientQuotasResponseV0(t *testing.T) {
+	ip := "127.0.0.1"
+
+	item := alterClientQuotasResponseV0{
+		ThrottleTimeMS: 1,
+		Entries: []alterClientQuotasResponseEntryV0{
+			{
+				ErrorCode:    int16(InvalidRequest),
+				ErrorMessage: "a",
+				Entity:       []clientQuotaEntityComponentV0{{EntityType: "ip", EntityName: &ip}},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found alterClientQuotasResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestAlterClientQuotasRequestV0Size(t *testing.T) {
+	item := alterClientQuotasRequestV0{
+		Entries: []alterClientQuotasRequestEntryV0{
+			{
+				Entity: makeClientQuotaEntityV0([]ClientQuotaEntityComponent{{EntityType: ClientQuotaEntityTypeUser, Default: true}}),
+				Ops: []alterClientQuotasRequestOpV0{
+					{Key: ClientQuotaConsumerByteRate, Value: 2048},
+					{Key: ClientQuotaProducerByteRate, Remove: true},
+				},
+			},
+		},
+		ValidateOnly: true,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+}
+
+func testClientClientQuotas(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.6.0") {
+		t.Skip("client quotas require kafka 2.6.0 or newer")
+		return
+	}
+
+	entity := []ClientQuotaEntityComponent{
+		{EntityType: ClientQuotaEntityTypeClientID, EntityName: makeGroupID()},
+	}
+
+	alter := func(op AlterClientQuotasRequestOp) {
+		res, err := c.AlterClientQuotas(ctx, AlterClientQuotasRequest{
+			Entries: []AlterClientQuotasRequestEntry{{Entity: entity, Ops: []AlterClientQuotasRequestOp{op}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := res.Entries[0].Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	describe := func() []DescribeClientQuotasResponseEntry {
+		res, err := c.DescribeClientQuotas(ctx, DescribeClientQuotasRequest{
+			Components: []DescribeClientQuotasRequestComponent{{
+				EntityType: ClientQuotaEntityTypeClientID,
+				MatchType:  ClientQuotaMatchExact,
+				Match:      entity[0].EntityName,
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Entries
+	}
+
+	alter(AlterClientQuotasRequestOp{Key: ClientQuotaProducerByteRate, Value: 1024})
+
+	expected := []DescribeClientQuotasResponseEntry{{
+		Entity: entity,
+		Values: map[string]float64{ClientQuotaProducerByteRate: 1024},
+	}}
+	if entries := describe(); !reflect.DeepEqual(entries, expected) {
+		t.Errorf("expected %+v, got %+v", expected, entries)
+	}
+
+	alter(AlterClientQuotasRequestOp{Key: ClientQuotaProducerByteRate, Remove: true})
+
+	if entries := describe(); len(entries) != 0 {
+		t.Errorf("expected no quotas after removal, got %+v", entries)
+	}
+}
@@ -0,0 +1,279 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+)
+
+// AlterConfigsRequest represents a request sent to a kafka cluster to replace
+// the configuration of topics or brokers.
+//
+// Note that the configuration entries of a resource are all replaced by the
+// request, entries that are omitted revert to their default values.
+type AlterConfigsRequest struct {
+	// Resources holds the list of resources to update.
+	Resources []AlterConfigRequestResource
+
+	// When ValidateOnly is true, the brokers validate the request without
+	// applying the changes.
+	ValidateOnly bool
+}
+
+// AlterConfigRequestResource designates a resource and its new configuration.
+type AlterConfigRequestResource struct {
+	// ResourceType is the type of the resource, valid values are
+	// ResourceTypeTopic and ResourceTypeBroker.
+	ResourceType ResourceType
+
+	// ResourceName is the topic name, or the broker ID for broker resources.
+	ResourceName string
+
+	// Configs holds the configuration entries of the resource.
+	Configs []ConfigEntry
+}
+
+// AlterConfigsResponse represents the response to an AlterConfigsRequest.
+type AlterConfigsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// brokers.
+	Throttle time.Duration
+
+	// Resources holds the result of altering each resource, in the order they
+	// appeared in the request.
+	Resources []AlterConfigResponseResource
+}
+
+// AlterConfigResponseResource carries the result of altering the configuration
+// of a resource.
+type AlterConfigResponseResource struct {
+	ResourceType ResourceType
+	ResourceName string
+
+	// Error is set to a non-nil value if altering the resource failed.
+	Error error
+
+	// ErrorMessage holds the message sent by the broker alongside Error.
+	ErrorMessage string
+}
+
+// AlterConfigs sends an AlterConfigs request to the kafka cluster.
+//
+// Broker resources are sent to the brokers they name, other resources are
+// sent to any broker. Errors that apply to a single resource are reported on
+// the resource and do not cause the method to fail.
+func (c *Client) AlterConfigs(ctx context.Context, req AlterConfigsRequest) (*AlterConfigsResponse, error) {
+	routes, err := configResourceRoutes(len(req.Resources), func(i int) (ResourceType, string) {
+		return req.Resources[i].ResourceType, req.Resources[i].ResourceName
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AlterConfigsResponse{
+		Resources: make([]AlterConfigResponseResource, len(req.Resources)),
+	}
+
+	for broker, indexes := range routes {
+		request := alterConfigsRequestV0{
+			Resources:    make([]alterConfigsRequestResourceV0, len(indexes)),
+			ValidateOnly: req.ValidateOnly,
+		}
+		for i, index := range indexes {
+			r := req.Resources[index]
+			request.Resources[i] = alterConfigsRequestResourceV0{
+				ResourceType:  int8(r.ResourceType),
+				ResourceName:  r.ResourceName,
+				ConfigEntries: make([]alterConfigsRequestConfigEntryV0, len(r.Configs)),
+			}
+			for j, e := range r.Configs {
+				request.Resources[i].ConfigEntries[j] = alterConfigsRequestConfigEntryV0{
+					ConfigName:  e.ConfigName,
+					ConfigValue: e.ConfigValue,
+				}
+			}
+		}
+
+		conn, err := c.connectBrokerOrAny(ctx, broker)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := conn.alterConfigs(request)
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if throttle := duration(response.ThrottleTimeMS); throttle > res.Throttle {
+			res.Throttle = throttle
+		}
+
+		if len(response.Resources) != len(indexes) {
+			return nil, fmt.Errorf("expected %d resources in the alter configs response, got %d", len(indexes), len(response.Resources))
+		}
+
+		for i, r := range response.Resources {
+			res.Resources[indexes[i]] = r.toAlterConfigResponseResource()
+		}
+	}
+
+	return res, nil
+}
+
+// alterConfigs replaces the configuration of the requested resources.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterConfigs
+func (c *Conn) alterConfigs(request alterConfigsRequestV0) (alterConfigsResponseV0, error) {
+	var response alterConfigsResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(alterConfigs, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return alterConfigsResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+type alterConfigsRequestConfigEntryV0 struct {
+	ConfigName  string
+	ConfigValue string
+}
+
+func (t alterConfigsRequestConfigEntryV0) size() int32 {
+	return sizeofString(t.ConfigName) +
+		sizeofString(t.ConfigValue)
+}
+
+func (t alterConfigsRequestConfigEntryV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.ConfigName)
+	wb.writeString(t.ConfigValue)
+}
+
+type alterConfigsRequestResourceV0 struct {
+	ResourceType  int8
+	ResourceName  string
+	ConfigEntries []alterConfigsRequestConfigEntryV0
+}
+
+func (t alterConfigsRequestResourceV0) size() int32 {
+	return sizeofInt8(t.ResourceType) +
+		sizeofString(t.ResourceName) +
+		sizeofArray(len(t.ConfigEntries), func(i int) int32 { return t.ConfigEntries[i].size() })
+}
+
+func (t alterConfigsRequestResourceV0) writeTo(wb *writeBuffer) {
+	wb.writeInt8(t.ResourceType)
+	wb.writeString(t.ResourceName)
+	wb.writeArray(len(t.ConfigEntries), func(i int) { t.ConfigEntries[i].writeTo(wb) })
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterConfigs
+type alterConfigsRequestV0 struct {
+	Resources    []alterConfigsRequestResourceV0
+	ValidateOnly bool
+}
+
+func (t alterConfigsRequestV0) size() int32 {
+	return sizeofArray(len(t.Resources), func(i int) int32 { return t.Resources[i].size() }) +
+		sizeofBool(t.ValidateOnly)
+}
+
+func (t alterConfigsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Resources), func(i int) { t.Resources[i].writeTo(wb) })
+	wb.writeBool(t.ValidateOnly)
+}
+
+type alterConfigsResponseResourceV0 struct {
+	ErrorCode    int16
+	ErrorMessage string
+	ResourceType int8
+	ResourceName string
+}
+
+func (t alterConfigsResponseResourceV0) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage) +
+		sizeofInt8(t.ResourceType) +
+		sizeofString(t.ResourceName)
+}
+
+func (t alterConfigsResponseResourceV0) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+	wb.writeInt8(t.ResourceType)
+	wb.writeString(t.ResourceName)
+}
+
+func (t *alterConfigsResponseResourceV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.ResourceType); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ResourceName); err != nil {
+		return
+	}
+	return
+}
+
+func (t alterConfigsResponseResourceV0) toAlterConfigResponseResource() AlterConfigResponseResource {
+	res := AlterConfigResponseResource{
+		ResourceType: ResourceType(t.ResourceType),
+		ResourceName: t.ResourceName,
+		ErrorMessage: t.ErrorMessage,
+	}
+	if t.ErrorCode != 0 {
+		res.Error = Error(t.ErrorCode)
+	}
+	return res
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterConfigs
+type alterConfigsResponseV0 struct {
+	ThrottleTimeMS int32
+	Resources      []alterConfigsResponseResourceV0
+}
+
+func (t alterConfigsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Resources), func(i int) int32 { return t.Resources[i].size() })
+}
+
+func (t alterConfigsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Resources), func(i int) { t.Resources[i].writeTo(wb) })
+}
+
+func (t *alterConfigsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var resource alterConfigsResponseResourceV0
+		if fnRemain, fnErr = (&resource).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Resources = append(t.Resources, resource)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestAlterConfigsResponseV0(t *testing.T) {
+	item := alterConfigsResponseV0{
+		ThrottleTimeMS: 1,
+		Resources: []alterConfigsResponseResourceV0{
+			{
+				ErrorCode:    2,
+				ErrorMessage: "a",
+				ResourceType: int8(ResourceTypeTopic),
+				ResourceName: "b",
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found alterConfigsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func testClientAlterConfigs(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("1.1.0") {
+		t.Skip("describe configs v1 requires kafka 1.1.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	alter := func(validateOnly bool) {
+		res, err := c.AlterConfigs(ctx, AlterConfigsRequest{
+			Resources: []AlterConfigRequestResource{{
+				ResourceType: ResourceTypeTopic,
+				ResourceName: topic,
+				Configs: []ConfigEntry{
+					{ConfigName: "retention.ms", ConfigValue: "7200000"},
+				},
+			}},
+			ValidateOnly: validateOnly,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := res.Resources[0].Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	retention := func() string {
+		res, err := c.DescribeConfigs(ctx, DescribeConfigsRequest{
+			Resources: []DescribeConfigRequestResource{{
+				ResourceType: ResourceTypeTopic,
+				ResourceName: topic,
+				ConfigNames:  []string{"retention.ms"},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Resources[0].ConfigEntries) != 1 {
+			t.Fatalf("expected one config entry, got %+v", res.Resources[0].ConfigEntries)
+		}
+		return res.Resources[0].ConfigEntries[0].ConfigValue
+	}
+
+	alter(true)
+	if v := retention(); v == "7200000" {
+		t.Error("validate only request must not change the configuration")
+	}
+
+	alter(false)
+	if v := retention(); v != "7200000" {
+		t.Errorf("expected retention.ms to be 7200000, got %s", v)
+	}
+}
@@ -0,0 +1,349 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"time"
+)
+
+// AlterPartitionReassignmentsRequest represents a request sent to a kafka
+// cluster to move the replicas of partitions to other brokers.
+type AlterPartitionReassignmentsRequest struct {
+	// Topics holds the partitions to reassign, indexed by topic name.
+	Topics map[string][]AlterPartitionReassignmentsRequestPartition
+
+	// Timeout is how long the controller waits for the reassignments to be
+	// started, it defaults to the time remaining until the deadline of the
+	// context.
+	Timeout time.Duration
+}
+
+// AlterPartitionReassignmentsRequestPartition designates a partition and the
+// brokers that its replicas are reassigned to.
+type AlterPartitionReassignmentsRequestPartition struct {
+	Partition int
+
+	// Replicas holds the IDs of the brokers that the replicas of the partition
+	// are assigned to, the first broker is the preferred leader. When nil,
+	// the reassignment of the partition that is in progress is cancelled.
+	Replicas []int
+}
+
+// AlterPartitionReassignmentsResponse represents the response to an
+// AlterPartitionReassignmentsRequest.
+type AlterPartitionReassignmentsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// controller.
+	Throttle time.Duration
+
+	// Topics holds the result of reassigning each partition, indexed by topic
+	// name.
+	Topics map[string][]AlterPartitionReassignmentsResponsePartition
+}
+
+// AlterPartitionReassignmentsResponsePartition carries the result of
+// reassigning a partition.
+type AlterPartitionReassignmentsResponsePartition struct {
+	Partition int
+
+	// Error is set to a non-nil value if the reassignment failed, for example
+	// NoReassignmentInProgress when cancelling a reassignment that does not
+	// exist.
+	Error error
+
+	// ErrorMessage holds the message sent by the controller alongside Error.
+	ErrorMessage string
+}
+
+// AlterPartitionReassignments sends an AlterPartitionReassignments request to
+// the controller of the kafka cluster. The API was introduced in kafka 2.4
+// (KIP-455).
+//
+// The method returns once the reassignments are started, their progress can
+// be tracked with ListPartitionReassignments. Errors that apply to a single
+// partition are reported on the partition and do not cause the method to
+// fail.
+func (c *Client) AlterPartitionReassignments(ctx context.Context, req AlterPartitionReassignmentsRequest) (*AlterPartitionReassignmentsResponse, error) {
+	request := alterPartitionReassignmentsRequestV0{
+		Topics:  make([]alterPartitionReassignmentsRequestTopicV0, 0, len(req.Topics)),
+		Timeout: protocolTimeout(ctx, req.Timeout),
+	}
+
+	for topic, partitions := range req.Topics {
+		t := alterPartitionReassignmentsRequestTopicV0{
+			Name:       topic,
+			Partitions: make([]alterPartitionReassignmentsRequestPartitionV0, len(partitions)),
+		}
+		for i, p := range partitions {
+			t.Partitions[i] = alterPartitionReassignmentsRequestPartitionV0{
+				PartitionIndex: int32(p.Partition),
+			}
+			if p.Replicas != nil {
+				t.Partitions[i].Replicas = make([]int32, len(p.Replicas))
+				for j, id := range p.Replicas {
+					t.Partitions[i].Replicas[j] = int32(id)
+				}
+			}
+		}
+		request.Topics = append(request.Topics, t)
+	}
+
+	sort.Slice(request.Topics, func(i, j int) bool {
+		return request.Topics[i].Name < request.Topics[j].Name
+	})
+
+	conn, err := c.connectController(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.alterPartitionReassignments(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AlterPartitionReassignmentsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make(map[string][]AlterPartitionReassignmentsResponsePartition, len(response.Responses)),
+	}
+
+	for _, t := range response.Responses {
+		partitions := make([]AlterPartitionReassignmentsResponsePartition, len(t.Partitions))
+		for i, p := range t.Partitions {
+			partitions[i] = AlterPartitionReassignmentsResponsePartition{
+				Partition:    int(p.PartitionIndex),
+				ErrorMessage: p.ErrorMessage,
+			}
+			if p.ErrorCode != 0 {
+				partitions[i].Error = Error(p.ErrorCode)
+			}
+		}
+		res.Topics[t.Name] = partitions
+	}
+
+	return res, nil
+}
+
+// alterPartitionReassignments starts or cancels the reassignment of the
+// requested partitions, the broker must be the controller of the cluster.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterPartitionReassignments
+func (c *Conn) alterPartitionReassignments(request alterPartitionReassignmentsRequestV0) (alterPartitionReassignmentsResponseV0, error) {
+	var response alterPartitionReassignmentsResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			if request.Timeout == 0 {
+				now := time.Now()
+				deadline = adjustDeadlineForRTT(deadline, now, defaultRTT)
+				request.Timeout = milliseconds(deadlineToTimeout(deadline, now))
+			}
+			return c.writeFlexibleRequest(alterPartitionReassignments, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return alterPartitionReassignmentsResponseV0{}, err
+	}
+	if response.ErrorCode != 0 {
+		return alterPartitionReassignmentsResponseV0{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+type alterPartitionReassignmentsRequestPartitionV0 struct {
+	PartitionIndex int32
+
+	// Replicas is nullable, a nil value cancels the reassignment.
+	Replicas []int32
+}
+
+func (t alterPartitionReassignmentsRequestPartitionV0) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofCompactInt32Array(t.Replicas) +
+		sizeofTaggedFields()
+}
+
+func (t alterPartitionReassignmentsRequestPartitionV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	if t.Replicas == nil {
+		wb.writeCompactArrayLen(-1)
+	} else {
+		wb.writeCompactInt32Array(t.Replicas)
+	}
+	wb.writeTaggedFields()
+}
+
+type alterPartitionReassignmentsRequestTopicV0 struct {
+	Name       string
+	Partitions []alterPartitionReassignmentsRequestPartitionV0
+}
+
+func (t alterPartitionReassignmentsRequestTopicV0) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofCompactArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t alterPartitionReassignmentsRequestTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeCompactArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterPartitionReassignments
+type alterPartitionReassignmentsRequestV0 struct {
+	// Timeout ms to wait for the reassignments to be started.
+	Timeout int32
+
+	Topics []alterPartitionReassignmentsRequestTopicV0
+}
+
+func (t alterPartitionReassignmentsRequestV0) size() int32 {
+	return sizeofInt32(t.Timeout) +
+		sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t alterPartitionReassignmentsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.Timeout)
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+type alterPartitionReassignmentsResponsePartitionV0 struct {
+	PartitionIndex int32
+	ErrorCode      int16
+	ErrorMessage   string
+}
+
+func (t alterPartitionReassignmentsResponsePartitionV0) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofTaggedFields()
+}
+
+func (t alterPartitionReassignmentsResponsePartitionV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeTaggedFields()
+}
+
+func (t *alterPartitionReassignmentsResponsePartitionV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.PartitionIndex); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+type alterPartitionReassignmentsResponseTopicV0 struct {
+	Name       string
+	Partitions []alterPartitionReassignmentsResponsePartitionV0
+}
+
+func (t alterPartitionReassignmentsResponseTopicV0) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofCompactArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t alterPartitionReassignmentsResponseTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeCompactArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *alterPartitionReassignmentsResponseTopicV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.Name); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition alterPartitionReassignmentsResponsePartitionV0
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterPartitionReassignments
+type alterPartitionReassignmentsResponseV0 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	ErrorMessage   string
+	Responses      []alterPartitionReassignmentsResponseTopicV0
+}
+
+func (t alterPartitionReassignmentsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofCompactArray(len(t.Responses), func(i int) int32 { return t.Responses[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t alterPartitionReassignmentsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeCompactArray(len(t.Responses), func(i int) { t.Responses[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *alterPartitionReassignmentsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic alterPartitionReassignmentsResponseTopicV0
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Responses = append(t.Responses, topic)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
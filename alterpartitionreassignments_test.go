@@ -0,0 +1,118 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestAlterPartitionReassignmentsResponseV0(t *testing.T) {
+	item := alterPartitionReassignmentsResponseV0{
+		ThrottleTimeMS: 1,
+		Responses: []alterPartitionReassignmentsResponseTopicV0{
+			{
+				Name: "a",
+				Partitions: []alterPartitionReassignmentsResponsePartitionV0{
+					{PartitionIndex: 0},
+					{PartitionIndex: 1, ErrorCode: int16(NoReassignmentInProgress), ErrorMessage: "b"},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		t.FailNow()
+	}
+
+	var found alterPartitionReassignmentsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestAlterPartitionReassignmentsRequestV0Cancel(t *testing.T) {
+	item := alterPartitionReassignmentsRequestPartitionV0{PartitionIndex: 1}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	// partition index, null compact array, no tagged fields
+	expected := []byte{0, 0, 0, 1, 0, 0}
+	if !bytes.Equal(expected, b.Bytes()) {
+		t.Errorf("expected %v, got %v", expected, b.Bytes())
+	}
+}
+
+func testClientPartitionReassignments(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.4.0") {
+		t.Skip("partition reassignments require kafka 2.4.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	conn, err := Dial("tcp", "localhost:9092")
+	if err != nil {
+		t.Fatal(err)
+	}
+	partitions, err := conn.ReadPartitions(topic)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reassigning the partition to its current replicas completes right away.
+	replicas := make([]int, len(partitions[0].Replicas))
+	for i, b := range partitions[0].Replicas {
+		replicas[i] = b.ID
+	}
+
+	res, err := c.AlterPartitionReassignments(ctx, AlterPartitionReassignmentsRequest{
+		Topics: map[string][]AlterPartitionReassignmentsRequestPartition{
+			topic: {{Partition: 0, Replicas: replicas}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Topics[topic][0].Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.ListPartitionReassignments(ctx, ListPartitionReassignmentsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = c.AlterPartitionReassignments(ctx, AlterPartitionReassignmentsRequest{
+		Topics: map[string][]AlterPartitionReassignmentsRequestPartition{
+			topic: {{Partition: 0, Replicas: nil}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Topics[topic][0].Error; err != NoReassignmentInProgress {
+		t.Errorf("expected %v when cancelling a completed reassignment, got %v", NoReassignmentInProgress, err)
+	}
+}
@@ -0,0 +1,266 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"time"
+)
+
+// AlterReplicaLogDirsRequest represents a request sent to a kafka broker to
+// move replicas between its log directories.
+type AlterReplicaLogDirsRequest struct {
+	// BrokerID is the ID of the broker hosting the replicas.
+	BrokerID int
+
+	// Dirs holds the replicas to move, indexed by the absolute path of the
+	// log directory they are moved to, then by topic name.
+	Dirs map[string]map[string][]int
+}
+
+// AlterReplicaLogDirsResponse represents the response to an
+// AlterReplicaLogDirsRequest.
+type AlterReplicaLogDirsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// Topics holds the result of moving each replica, indexed by topic name.
+	Topics map[string][]AlterReplicaLogDirsResponsePartition
+}
+
+// AlterReplicaLogDirsResponsePartition carries the result of moving a replica.
+type AlterReplicaLogDirsResponsePartition struct {
+	Partition int
+
+	// Error is set to a non-nil value if the replica could not be moved, for
+	// example ReplicaNotAvailable if the broker does not host the replica, or
+	// KafkaStorageError if the log directory is offline.
+	Error error
+}
+
+// AlterReplicaLogDirs sends an AlterReplicaLogDirs request to the broker
+// identified by the request. The API was introduced in kafka 1.0.
+//
+// The method returns once the replicas start moving, their progress can be
+// tracked with DescribeLogDirs. Errors that apply to a single replica are
+// reported on the partition and do not cause the method to fail.
+func (c *Client) AlterReplicaLogDirs(ctx context.Context, req AlterReplicaLogDirsRequest) (*AlterReplicaLogDirsResponse, error) {
+	request := alterReplicaLogDirsRequestV1{
+		Dirs: make([]alterReplicaLogDirsRequestDirV1, 0, len(req.Dirs)),
+	}
+
+	for path, topics := range req.Dirs {
+		dir := alterReplicaLogDirsRequestDirV1{
+			Path:   path,
+			Topics: make([]alterReplicaLogDirsRequestTopicV1, 0, len(topics)),
+		}
+		for topic, partitions := range topics {
+			t := alterReplicaLogDirsRequestTopicV1{
+				Name:       topic,
+				Partitions: make([]int32, len(partitions)),
+			}
+			for i, p := range partitions {
+				t.Partitions[i] = int32(p)
+			}
+			dir.Topics = append(dir.Topics, t)
+		}
+		sort.Slice(dir.Topics, func(i, j int) bool {
+			return dir.Topics[i].Name < dir.Topics[j].Name
+		})
+		request.Dirs = append(request.Dirs, dir)
+	}
+
+	sort.Slice(request.Dirs, func(i, j int) bool {
+		return request.Dirs[i].Path < request.Dirs[j].Path
+	})
+
+	conn, err := c.connectBroker(ctx, req.BrokerID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.alterReplicaLogDirs(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AlterReplicaLogDirsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make(map[string][]AlterReplicaLogDirsResponsePartition, len(response.Results)),
+	}
+
+	for _, t := range response.Results {
+		for _, p := range t.Partitions {
+			partition := AlterReplicaLogDirsResponsePartition{Partition: int(p.PartitionIndex)}
+			if p.ErrorCode != 0 {
+				partition.Error = Error(p.ErrorCode)
+			}
+			res.Topics[t.TopicName] = append(res.Topics[t.TopicName], partition)
+		}
+	}
+
+	return res, nil
+}
+
+// alterReplicaLogDirs moves replicas hosted by the broker to other log
+// directories.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterReplicaLogDirs
+func (c *Conn) alterReplicaLogDirs(request alterReplicaLogDirsRequestV1) (alterReplicaLogDirsResponseV1, error) {
+	var response alterReplicaLogDirsResponseV1
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(alterReplicaLogDirs, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return alterReplicaLogDirsResponseV1{}, err
+	}
+
+	return response, nil
+}
+
+type alterReplicaLogDirsRequestTopicV1 struct {
+	Name       string
+	Partitions []int32
+}
+
+func (t alterReplicaLogDirsRequestTopicV1) size() int32 {
+	return sizeofString(t.Name) +
+		sizeofInt32Array(t.Partitions)
+}
+
+func (t alterReplicaLogDirsRequestTopicV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Name)
+	wb.writeInt32Array(t.Partitions)
+}
+
+type alterReplicaLogDirsRequestDirV1 struct {
+	Path   string
+	Topics []alterReplicaLogDirsRequestTopicV1
+}
+
+func (t alterReplicaLogDirsRequestDirV1) size() int32 {
+	return sizeofString(t.Path) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t alterReplicaLogDirsRequestDirV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Path)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterReplicaLogDirs
+type alterReplicaLogDirsRequestV1 struct {
+	Dirs []alterReplicaLogDirsRequestDirV1
+}
+
+func (t alterReplicaLogDirsRequestV1) size() int32 {
+	return sizeofArray(len(t.Dirs), func(i int) int32 { return t.Dirs[i].size() })
+}
+
+func (t alterReplicaLogDirsRequestV1) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Dirs), func(i int) { t.Dirs[i].writeTo(wb) })
+}
+
+type alterReplicaLogDirsResponsePartitionV1 struct {
+	PartitionIndex int32
+	ErrorCode      int16
+}
+
+func (t alterReplicaLogDirsResponsePartitionV1) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofInt16(t.ErrorCode)
+}
+
+func (t alterReplicaLogDirsResponsePartitionV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeInt16(t.ErrorCode)
+}
+
+func (t *alterReplicaLogDirsResponsePartitionV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.PartitionIndex); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	return
+}
+
+type alterReplicaLogDirsResponseTopicV1 struct {
+	TopicName  string
+	Partitions []alterReplicaLogDirsResponsePartitionV1
+}
+
+func (t alterReplicaLogDirsResponseTopicV1) size() int32 {
+	return sizeofString(t.TopicName) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t alterReplicaLogDirsResponseTopicV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TopicName)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+func (t *alterReplicaLogDirsResponseTopicV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.TopicName); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition alterReplicaLogDirsResponsePartitionV1
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterReplicaLogDirs
+type alterReplicaLogDirsResponseV1 struct {
+	ThrottleTimeMS int32
+	Results        []alterReplicaLogDirsResponseTopicV1
+}
+
+func (t alterReplicaLogDirsResponseV1) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Results), func(i int) int32 { return t.Results[i].size() })
+}
+
+func (t alterReplicaLogDirsResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Results), func(i int) { t.Results[i].writeTo(wb) })
+}
+
+func (t *alterReplicaLogDirsResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic alterReplicaLogDirsResponseTopicV1
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Results = append(t.Results, topic)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestAlterReplicaLogDirsResponseV1(t *testing.T) {
+	item := alterReplicaLogDirsResponseV1{
+		ThrottleTimeMS: 1,
+		Results: []alterReplicaLogDirsResponseTopicV1{
+			{
+				TopicName: "a",
+				Partitions: []alterReplicaLogDirsResponsePartitionV1{
+					{PartitionIndex: 0},
+					{PartitionIndex: 1, ErrorCode: int16(KafkaStorageError)},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found alterReplicaLogDirsResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func testClientAlterReplicaLogDirs(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("1.0.0") {
+		t.Skip("alter replica log dirs requires kafka 1.0.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	logDirs, err := c.DescribeLogDirs(ctx, DescribeLogDirsRequest{
+		Topics: map[string][]int{topic: {0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Moving the replica to the log directory it is already stored in is a
+	// no-op that the broker accepts.
+	for id, b := range logDirs.Brokers {
+		for path, logDir := range b.LogDirs {
+			if len(logDir.Topics[topic]) == 0 {
+				continue
+			}
+
+			res, err := c.AlterReplicaLogDirs(ctx, AlterReplicaLogDirsRequest{
+				BrokerID: id,
+				Dirs: map[string]map[string][]int{
+					path: {topic: {0}},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := res.Topics[topic][0].Error; err != nil {
+				t.Error(err)
+			}
+			return
+		}
+	}
+
+	t.Fatalf("no replica of %s found in the log directories of the brokers", topic)
+}
@@ -0,0 +1,327 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// AlterUserScramCredentialsRequest represents a request sent to a kafka
+// cluster to create, update, or delete the SCRAM credentials of users.
+type AlterUserScramCredentialsRequest struct {
+	// Deletions holds the credentials to delete.
+	Deletions []UserScramCredentialsDeletion
+
+	// Upsertions holds the credentials to create or update.
+	Upsertions []UserScramCredentialsUpsertion
+}
+
+// UserScramCredentialsDeletion designates the SCRAM credentials of a user to
+// delete.
+type UserScramCredentialsDeletion struct {
+	Name      string
+	Mechanism ScramMechanism
+}
+
+// UserScramCredentialsUpsertion holds the SCRAM credentials of a user to
+// create or update.
+type UserScramCredentialsUpsertion struct {
+	Name      string
+	Mechanism ScramMechanism
+
+	// Iterations is the number of iterations of the SCRAM hash function,
+	// kafka requires at least 4096.
+	Iterations int
+
+	// Password is the new password of the user, it is never sent to kafka,
+	// only the salted password derived from it is.
+	Password string
+
+	// Salt is the salt of the credentials, a random salt is generated when it
+	// is empty.
+	Salt []byte
+}
+
+// AlterUserScramCredentialsResponse represents the response to an
+// AlterUserScramCredentialsRequest.
+type AlterUserScramCredentialsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// Users holds the result of altering the credentials of each user of the
+	// request.
+	Users []AlterUserScramCredentialsResponseUser
+}
+
+// AlterUserScramCredentialsResponseUser carries the result of altering the
+// SCRAM credentials of a user.
+type AlterUserScramCredentialsResponseUser struct {
+	User string
+
+	// Error is set to a non-nil value if the credentials of the user could
+	// not be altered.
+	Error error
+
+	// ErrorMessage holds the message sent by the broker alongside Error.
+	ErrorMessage string
+}
+
+// AlterUserScramCredentials sends an AlterUserScramCredentials request to the
+// controller of the kafka cluster. The API was introduced in kafka 2.7
+// (KIP-554).
+//
+// The salted passwords of the upsertions are computed before being sent, the
+// passwords never leave the client. Errors that apply to a single user are
+// reported on the user and do not cause the method to fail.
+func (c *Client) AlterUserScramCredentials(ctx context.Context, req AlterUserScramCredentialsRequest) (*AlterUserScramCredentialsResponse, error) {
+	request := alterUserScramCredentialsRequestV0{
+		Deletions:  make([]alterUserScramCredentialsRequestDeletionV0, len(req.Deletions)),
+		Upsertions: make([]alterUserScramCredentialsRequestUpsertionV0, len(req.Upsertions)),
+	}
+
+	for i, d := range req.Deletions {
+		request.Deletions[i] = alterUserScramCredentialsRequestDeletionV0{
+			Name:      d.Name,
+			Mechanism: int8(d.Mechanism),
+		}
+	}
+
+	for i, u := range req.Upsertions {
+		upsertion, err := makeAlterUserScramCredentialsRequestUpsertionV0(u)
+		if err != nil {
+			return nil, err
+		}
+		request.Upsertions[i] = upsertion
+	}
+
+	conn, err := c.connectController(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.alterUserScramCredentials(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AlterUserScramCredentialsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Users:    make([]AlterUserScramCredentialsResponseUser, len(response.Results)),
+	}
+
+	for i, r := range response.Results {
+		res.Users[i] = AlterUserScramCredentialsResponseUser{
+			User:         r.User,
+			ErrorMessage: r.ErrorMessage,
+		}
+		if r.ErrorCode != 0 {
+			res.Users[i].Error = Error(r.ErrorCode)
+		}
+	}
+
+	return res, nil
+}
+
+func makeAlterUserScramCredentialsRequestUpsertionV0(u UserScramCredentialsUpsertion) (alterUserScramCredentialsRequestUpsertionV0, error) {
+	var algo scram.Algorithm
+
+	switch u.Mechanism {
+	case ScramMechanismSHA256:
+		algo = scram.SHA256
+	case ScramMechanismSHA512:
+		algo = scram.SHA512
+	default:
+		return alterUserScramCredentialsRequestUpsertionV0{}, fmt.Errorf("unsupported scram mechanism for user %s: %d", u.Name, u.Mechanism)
+	}
+
+	salt := u.Salt
+	if len(salt) == 0 {
+		salt = make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return alterUserScramCredentialsRequestUpsertionV0{}, err
+		}
+	}
+
+	saltedPassword, err := scram.SaltedPassword(algo, u.Password, salt, u.Iterations)
+	if err != nil {
+		return alterUserScramCredentialsRequestUpsertionV0{}, fmt.Errorf("unable to salt the password of user %s: %v", u.Name, err)
+	}
+
+	return alterUserScramCredentialsRequestUpsertionV0{
+		Name:           u.Name,
+		Mechanism:      int8(u.Mechanism),
+		Iterations:     int32(u.Iterations),
+		Salt:           salt,
+		SaltedPassword: saltedPassword,
+	}, nil
+}
+
+// alterUserScramCredentials applies the deletions and upsertions of SCRAM
+// credentials of the request.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterUserScramCredentials
+func (c *Conn) alterUserScramCredentials(request alterUserScramCredentialsRequestV0) (alterUserScramCredentialsResponseV0, error) {
+	var response alterUserScramCredentialsResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(alterUserScramCredentials, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return alterUserScramCredentialsResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+type alterUserScramCredentialsRequestDeletionV0 struct {
+	Name      string
+	Mechanism int8
+}
+
+func (t alterUserScramCredentialsRequestDeletionV0) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofInt8(t.Mechanism) +
+		sizeofTaggedFields()
+}
+
+func (t alterUserScramCredentialsRequestDeletionV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeInt8(t.Mechanism)
+	wb.writeTaggedFields()
+}
+
+type alterUserScramCredentialsRequestUpsertionV0 struct {
+	Name           string
+	Mechanism      int8
+	Iterations     int32
+	Salt           []byte
+	SaltedPassword []byte
+}
+
+func (t alterUserScramCredentialsRequestUpsertionV0) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofInt8(t.Mechanism) +
+		sizeofInt32(t.Iterations) +
+		sizeofCompactBytes(t.Salt) +
+		sizeofCompactBytes(t.SaltedPassword) +
+		sizeofTaggedFields()
+}
+
+func (t alterUserScramCredentialsRequestUpsertionV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeInt8(t.Mechanism)
+	wb.writeInt32(t.Iterations)
+	wb.writeCompactBytes(t.Salt)
+	wb.writeCompactBytes(t.SaltedPassword)
+	wb.writeTaggedFields()
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterUserScramCredentials
+type alterUserScramCredentialsRequestV0 struct {
+	Deletions  []alterUserScramCredentialsRequestDeletionV0
+	Upsertions []alterUserScramCredentialsRequestUpsertionV0
+}
+
+func (t alterUserScramCredentialsRequestV0) size() int32 {
+	return sizeofCompactArray(len(t.Deletions), func(i int) int32 { return t.Deletions[i].size() }) +
+		sizeofCompactArray(len(t.Upsertions), func(i int) int32 { return t.Upsertions[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t alterUserScramCredentialsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactArray(len(t.Deletions), func(i int) { t.Deletions[i].writeTo(wb) })
+	wb.writeCompactArray(len(t.Upsertions), func(i int) { t.Upsertions[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+type alterUserScramCredentialsResponseResultV0 struct {
+	User         string
+	ErrorCode    int16
+	ErrorMessage string
+}
+
+func (t alterUserScramCredentialsResponseResultV0) size() int32 {
+	return sizeofCompactString(t.User) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofTaggedFields()
+}
+
+func (t alterUserScramCredentialsResponseResultV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.User)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeTaggedFields()
+}
+
+func (t *alterUserScramCredentialsResponseResultV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.User); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_AlterUserScramCredentials
+type alterUserScramCredentialsResponseV0 struct {
+	ThrottleTimeMS int32
+	Results        []alterUserScramCredentialsResponseResultV0
+}
+
+func (t alterUserScramCredentialsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofCompactArray(len(t.Results), func(i int) int32 { return t.Results[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t alterUserScramCredentialsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeCompactArray(len(t.Results), func(i int) { t.Results[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *alterUserScramCredentialsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var result alterUserScramCredentialsResponseResultV0
+		if fnRemain, fnErr = (&result).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Results = append(t.Results, result)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
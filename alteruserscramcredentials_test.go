@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestAlterUserScramCredentialsResponseV0(t *testing.T) {
+	item := alterUserScramCredentialsResponseV0{
+		ThrottleTimeMS: 1,
+		Results: []alterUserScramCredentialsResponseResultV0{
+			{User: "a"},
+			{User: "b", ErrorCode: int16(UnsupportedSASLMechanism), ErrorMessage: "c"},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found alterUserScramCredentialsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestMakeAlterUserScramCredentialsRequestUpsertionV0(t *testing.T) {
+	upsertion, err := makeAlterUserScramCredentialsRequestUpsertionV0(UserScramCredentialsUpsertion{
+		Name:       "a",
+		Mechanism:  ScramMechanismSHA512,
+		Iterations: 4096,
+		Password:   "b",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(upsertion.Salt) == 0 {
+		t.Error("expected a random salt to be generated")
+	}
+	if len(upsertion.SaltedPassword) != 64 {
+		t.Errorf("expected a 64 bytes salted password, got %d", len(upsertion.SaltedPassword))
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	upsertion.writeTo(w)
+
+	if int32(b.Len()) != upsertion.size() {
+		t.Errorf("expected %d bytes, got %d", upsertion.size(), b.Len())
+	}
+
+	if _, err := makeAlterUserScramCredentialsRequestUpsertionV0(UserScramCredentialsUpsertion{
+		Name:       "a",
+		Iterations: 4096,
+		Password:   "b",
+	}); err == nil {
+		t.Error("expected an error for an unknown scram mechanism")
+	}
+}
+
+func testClientUserScramCredentials(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.7.0") {
+		t.Skip("user scram credentials require kafka 2.7.0 or newer")
+		return
+	}
+
+	user := makeGroupID()
+
+	altered, err := c.AlterUserScramCredentials(ctx, AlterUserScramCredentialsRequest{
+		Upsertions: []UserScramCredentialsUpsertion{{
+			Name:       user,
+			Mechanism:  ScramMechanismSHA256,
+			Iterations: 4096,
+			Password:   "secret",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := altered.Users[0].Error; err != nil {
+		t.Fatal(err)
+	}
+
+	described, err := c.DescribeUserScramCredentials(ctx, DescribeUserScramCredentialsRequest{Users: []string{user}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ScramCredentialInfo{{Mechanism: ScramMechanismSHA256, Iterations: 4096}}
+	if u := described.Users[0]; u.Error != nil || !reflect.DeepEqual(u.Credentials, expected) {
+		t.Errorf("expected %+v, got %+v", expected, u)
+	}
+
+	altered, err = c.AlterUserScramCredentials(ctx, AlterUserScramCredentialsRequest{
+		Deletions: []UserScramCredentialsDeletion{{Name: user, Mechanism: ScramMechanismSHA256}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := altered.Users[0].Error; err != nil {
+		t.Fatal(err)
+	}
+}
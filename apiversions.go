@@ -0,0 +1,181 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// ApiVersionsResponse represents the response to a Client.ApiVersions call.
+type ApiVersionsResponse struct {
+	// Brokers holds the API versions supported by each broker of the cluster,
+	// sorted by broker ID.
+	Brokers []ApiVersionsBroker
+}
+
+// ApiVersionsBroker carries the API versions supported by a broker.
+type ApiVersionsBroker struct {
+	Broker Broker
+
+	// ApiKeys holds the versions of each API supported by the broker, sorted
+	// by API key.
+	ApiKeys []ApiKeyVersions
+
+	// Error is set to a non-nil value if the API versions of the broker could
+	// not be retrieved.
+	Error error
+}
+
+// ApiKeyVersions describes the versions of an API supported by a broker.
+type ApiKeyVersions struct {
+	ApiKey int
+
+	// Name is the name of the API, for example "Fetch".
+	Name string
+
+	// MinVersion and MaxVersion are the bounds of the range of versions that
+	// the broker supports.
+	MinVersion int
+	MaxVersion int
+
+	// Version is the version that the client selects when sending requests
+	// of this API to the broker. It is -1 if the package does not use the
+	// API, or if none of the versions it implements are supported by the
+	// broker.
+	Version int
+}
+
+// clientApiVersions lists, for each API used by the package, the versions of
+// the requests that it is able to send, sorted in increasing order.
+var clientApiVersions = map[apiKey][]apiVersion{
+	produce:                      {v2, v3, v7, v8},
+	fetch:                        {v2, v5, v7, v10, v11},
+	listOffsets:                  {v1, v2},
+	metadata:                     {v1, v10},
+	offsetCommit:                 {v2, v5},
+	offsetFetch:                  {v1, v5},
+	findCoordinator:              {v0, v1, v4},
+	joinGroup:                    {v1, v5},
+	heartbeat:                    {v0, v3},
+	leaveGroup:                   {v0, v3},
+	syncGroup:                    {v0, v3},
+	describeGroups:               {v0},
+	listGroups:                   {v1, v4},
+	saslHandshake:                {v0, v1},
+	apiVersions:                  {v0},
+	createTopics:                 {v0, v5},
+	deleteTopics:                 {v0, v6},
+	deleteRecords:                {v0},
+	initProducerId:               {v0},
+	offsetForLeaderEpoch:         {v2},
+	addPartitionsToTxn:           {v0},
+	addOffsetsToTxn:              {v0},
+	endTxn:                       {v0},
+	txnOffsetCommit:              {v0},
+	describeAcls:                 {v1},
+	createAcls:                   {v1},
+	deleteAcls:                   {v1},
+	describeConfigs:              {v1},
+	alterConfigs:                 {v0},
+	alterReplicaLogDirs:          {v1},
+	describeLogDirs:              {v1, v4},
+	saslAuthenticate:             {v0},
+	createPartitions:             {v0},
+	createDelegationToken:        {v1},
+	renewDelegationToken:         {v1},
+	expireDelegationToken:        {v1},
+	describeDelegationToken:      {v1},
+	deleteGroups:                 {v0},
+	electLeaders:                 {v1},
+	incrementalAlterConfigs:      {v0},
+	alterPartitionReassignments:  {v0},
+	listPartitionReassignments:   {v0},
+	offsetDelete:                 {v0},
+	describeClientQuotas:         {v0},
+	alterClientQuotas:            {v0},
+	describeUserScramCredentials: {v0},
+	alterUserScramCredentials:    {v0},
+	describeCluster:              {v0},
+	describeProducers:            {v0},
+	describeTransactions:         {v0},
+	listTransactions:             {v0},
+}
+
+// ApiVersions retrieves the versions of the APIs supported by each broker of
+// the cluster, and the version that the client selects for each of them. The
+// requests to the brokers are sent concurrently.
+//
+// Errors that apply to a single broker are reported on the broker and do not
+// cause the method to fail.
+func (c *Client) ApiVersions(ctx context.Context) (*ApiVersionsResponse, error) {
+	brokers, err := c.brokerList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ApiVersionsResponse{
+		Brokers: make([]ApiVersionsBroker, len(brokers)),
+	}
+
+	wg := sync.WaitGroup{}
+
+	for i, b := range brokers {
+		wg.Add(1)
+		go func(broker *ApiVersionsBroker, b Broker) {
+			defer wg.Done()
+
+			broker.Broker = b
+
+			versions, err := c.brokerApiVersions(ctx, b)
+			if err != nil {
+				broker.Error = err
+				return
+			}
+
+			broker.ApiKeys = make([]ApiKeyVersions, len(versions))
+			for j, v := range versions {
+				broker.ApiKeys[j] = ApiKeyVersions{
+					ApiKey:     int(v.ApiKey),
+					Name:       apiKey(v.ApiKey).String(),
+					MinVersion: int(v.MinVersion),
+					MaxVersion: int(v.MaxVersion),
+					Version:    int(selectApiVersion(v, clientApiVersions[apiKey(v.ApiKey)])),
+				}
+			}
+
+			sort.Slice(broker.ApiKeys, func(i, j int) bool {
+				return broker.ApiKeys[i].ApiKey < broker.ApiKeys[j].ApiKey
+			})
+		}(&res.Brokers[i], b)
+	}
+
+	wg.Wait()
+
+	sort.Slice(res.Brokers, func(i, j int) bool {
+		return res.Brokers[i].Broker.ID < res.Brokers[j].Broker.ID
+	})
+
+	return res, nil
+}
+
+// brokerApiVersions retrieves the versions of the APIs supported by the broker
+// b.
+func (c *Client) brokerApiVersions(ctx context.Context, b Broker) ([]ApiVersion, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.ApiVersions()
+}
+
+// selectApiVersion returns the highest of the sorted versions that is within
+// the range supported by the broker, or -1 if there are none.
+func selectApiVersion(v ApiVersion, sortedSupportedVersions []apiVersion) apiVersion {
+	for i := len(sortedSupportedVersions) - 1; i >= 0; i-- {
+		if s := sortedSupportedVersions[i]; s >= apiVersion(v.MinVersion) && s <= apiVersion(v.MaxVersion) {
+			return s
+		}
+	}
+	return -1
+}
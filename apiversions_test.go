@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestSelectApiVersion(t *testing.T) {
+	tests := []struct {
+		broker   ApiVersion
+		versions []apiVersion
+		expected apiVersion
+	}{
+		{broker: ApiVersion{MinVersion: 0, MaxVersion: 12}, versions: []apiVersion{v2, v5, v10}, expected: v10},
+		{broker: ApiVersion{MinVersion: 0, MaxVersion: 7}, versions: []apiVersion{v2, v5, v10}, expected: v5},
+		{broker: ApiVersion{MinVersion: 3, MaxVersion: 4}, versions: []apiVersion{v2, v5, v10}, expected: -1},
+		{broker: ApiVersion{MinVersion: 0, MaxVersion: 4}, versions: nil, expected: -1},
+	}
+
+	for _, test := range tests {
+		if version := selectApiVersion(test.broker, test.versions); version != test.expected {
+			t.Errorf("expected version %d to be selected for %v among %v, got %d", test.expected, test.broker, test.versions, version)
+		}
+	}
+}
+
+func TestClientApiVersionsSorted(t *testing.T) {
+	for key, versions := range clientApiVersions {
+		if !sort.SliceIsSorted(versions, func(i, j int) bool { return versions[i] < versions[j] }) {
+			t.Errorf("the versions of %s are not sorted: %v", key, versions)
+		}
+	}
+}
+
+func testClientApiVersions(t *testing.T, ctx context.Context, c *Client) {
+	res, err := c.ApiVersions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Brokers) == 0 {
+		t.Fatal("expected the API versions of at least one broker")
+	}
+
+	for _, b := range res.Brokers {
+		if b.Error != nil {
+			t.Errorf("retrieving the API versions of broker %d failed: %v", b.Broker.ID, b.Error)
+			continue
+		}
+
+		found := false
+		for _, k := range b.ApiKeys {
+			if k.MinVersion > k.MaxVersion {
+				t.Errorf("broker %d reported an invalid version range for %s: [%d:%d]", b.Broker.ID, k.Name, k.MinVersion, k.MaxVersion)
+			}
+			if k.ApiKey == int(metadata) {
+				found = true
+				if k.Name != "Metadata" || k.Version != int(v1) {
+					t.Errorf("expected Metadata to be selected at v1 on broker %d, got %s at v%d", b.Broker.ID, k.Name, k.Version)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("broker %d did not report the versions of the Metadata API", b.Broker.ID)
+		}
+	}
+}
@@ -233,7 +233,8 @@ func (b CRC32Balancer) Balance(msg Message, partitions ...int) (partition int) {
 // functionally equivalent to the default Java partitioner.  That's because the
 // Java partitioner will use a round robin balancer instead of random on nil
 // keys.  We choose librdkafka's implementation because it arguably has a larger
-// install base.
+// install base.  JavaMurmur2Balancer distributes the messages with nil keys
+// like the Java client.
 type Murmur2Balancer struct {
 	Consistent bool
 	random     randomBalancer
@@ -250,6 +251,171 @@ func (b Murmur2Balancer) Balance(msg Message, partitions ...int) (partition int)
 	return partitions[idx]
 }
 
+// BatchObserver is an optional interface implemented by balancers which need to
+// know when the batches of messages that they routed to partitions are
+// complete, for example to stop routing messages to a partition once its batch
+// is full.
+type BatchObserver interface {
+	// BatchCompleted is called by the Writer when the batch of messages of
+	// partition is complete and about to be written, because it is full, its
+	// batch timeout expired, or the writer was flushed.
+	//
+	// Unlike Balance, the method is called from the goroutines writing the
+	// batches of each partition, it may be called concurrently with Balance
+	// and must be safe to use concurrently by multiple goroutines.
+	BatchCompleted(partition int)
+}
+
+// PartitionBalancer is an optional interface implemented by balancers which
+// need the metadata of the partitions to route messages, for example to pick a
+// partition whose leader is in the same rack as the program. The Writer calls
+// BalancePartitions instead of Balance when its balancer implements it.
+type PartitionBalancer interface {
+	Balancer
+
+	// BalancePartitions receives a message and the partitions available to
+	// write it, sorted by ID, and returns the ID of the partition that the
+	// message should be routed to. The partitions carry their leader,
+	// replicas, and in-sync replicas, the racks of the brokers are set when
+	// the cluster is configured with them.
+	//
+	// The partitions must not be modified, the Writer passes the same slice
+	// to the calls for messages written to the same topic.
+	BalancePartitions(msg Message, partitions []Partition) (partition int)
+}
+
+// StickyBalancer is a Balancer which routes messages with a nil key to the same
+// partition until the batch of this partition is complete, then picks another
+// partition at random.  This is the sticky partitioner of the Java client
+// (KIP-480): compared to distributing keyless messages in a round robin
+// fashion, which leaves roughly one message per partition in each batch, it
+// produces fewer and larger batches, which improves throughput and compression.
+//
+// StickyBalancer relies on the Writer reporting completed batches through the
+// BatchObserver interface.  Like Balance, the completed batches are identified
+// by partition only, so a balancer shared by the topics of a Writer without a
+// topic moves on to another partition when the batch of the partition of any
+// topic completes.
+//
+// StickyBalancer holds state and must be used by pointer.
+type StickyBalancer struct {
+	// KeyBalancer is the balancer used to route the messages with a key, it
+	// defaults to a Hash balancer.
+	KeyBalancer Balancer
+
+	// StickyMessages, if greater than zero, caps the number of messages with a
+	// nil key routed to the same partition, regardless of completed batches.
+	StickyMessages int
+
+	hash   Hash
+	sticky stickyPartition
+}
+
+// Balance satisfies the Balancer interface.
+func (b *StickyBalancer) Balance(msg Message, partitions ...int) int {
+	if msg.Key != nil {
+		if b.KeyBalancer != nil {
+			return b.KeyBalancer.Balance(msg, partitions...)
+		}
+		return b.hash.Balance(msg, partitions...)
+	}
+	return b.sticky.balance(partitions, b.StickyMessages)
+}
+
+// BatchCompleted satisfies the BatchObserver interface.
+func (b *StickyBalancer) BatchCompleted(partition int) {
+	b.sticky.batchCompleted(partition)
+}
+
+// JavaMurmur2Balancer is a Balancer that routes messages to partitions like
+// the default partitioner of the Java client.  Messages with a key are hashed
+// with the Murmur2 hash function like Murmur2Balancer does, including messages
+// with an empty key.  Messages with a nil key are distributed like the sticky
+// partitioner that the Java client uses since kafka 2.4, see StickyBalancer.
+//
+// Unlike Murmur2Balancer, the balancer holds state and must be used by pointer.
+type JavaMurmur2Balancer struct {
+	// StickyMessages, if greater than zero, caps the number of messages with a
+	// nil key routed to the same partition, regardless of completed batches.
+	// The balancer only moves on to another partition after StickyMessages
+	// messages when it is not used by a Writer, which reports the completed
+	// batches.
+	StickyMessages int
+
+	sticky stickyPartition
+}
+
+// Balance satisfies the Balancer interface.
+func (b *JavaMurmur2Balancer) Balance(msg Message, partitions ...int) (partition int) {
+	if msg.Key != nil {
+		idx := (murmur2(msg.Key) & 0x7fffffff) % uint32(len(partitions))
+		return partitions[idx]
+	}
+	return b.sticky.balance(partitions, b.StickyMessages)
+}
+
+// BatchCompleted satisfies the BatchObserver interface.
+func (b *JavaMurmur2Balancer) BatchCompleted(partition int) {
+	b.sticky.batchCompleted(partition)
+}
+
+// stickyPartition is the state of the sticky balancers, which route the
+// messages with a nil key to the same partition until its batch is completed.
+type stickyPartition struct {
+	random randomBalancer
+
+	mutex     sync.Mutex
+	partition int
+	count     int
+	completed bool
+}
+
+func (s *stickyPartition) balance(partitions []int, stickyMessages int) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.count == 0 || s.completed || (stickyMessages > 0 && s.count >= stickyMessages) || !containsPartition(partitions, s.partition) {
+		s.partition, s.count, s.completed = s.next(partitions), 0, false
+	}
+
+	s.count++
+	return s.partition
+}
+
+// next picks the partition that the messages with a nil key stick to, which is
+// a different partition than the previous one when there are several of them.
+func (s *stickyPartition) next(partitions []int) int {
+	if s.count == 0 || len(partitions) == 1 {
+		return s.random.Balance(Message{}, partitions...)
+	}
+
+	others := make([]int, 0, len(partitions)-1)
+	for _, p := range partitions {
+		if p != s.partition {
+			others = append(others, p)
+		}
+	}
+	return s.random.Balance(Message{}, others...)
+}
+
+func (s *stickyPartition) batchCompleted(partition int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.count != 0 && s.partition == partition {
+		s.completed = true
+	}
+}
+
+func containsPartition(partitions []int, partition int) bool {
+	for _, p := range partitions {
+		if p == partition {
+			return true
+		}
+	}
+	return false
+}
+
 // Go port of the Java library's murmur2 function.
 // https://github.com/apache/kafka/blob/1.0/clients/src/main/java/org/apache/kafka/common/utils/Utils.java#L353
 func murmur2(data []byte) uint32 {
@@ -159,6 +159,27 @@ func TestMurmur2(t *testing.T) {
 		{Key: nil, JavaMurmur2Result: 0x106e08d9},
 	}
 
+	// These tests are taken from the testMurmur2 test of the Java library, the
+	// results are the signed ints returned by Utils.murmur2.
+	// https://github.com/apache/kafka/blob/trunk/clients/src/test/java/org/apache/kafka/common/utils/UtilsTest.java
+	javaTestCases := []struct {
+		Key    []byte
+		Result int32
+	}{
+		{Key: []byte("21"), Result: -973932308},
+		{Key: []byte("foobar"), Result: -790332482},
+		{Key: []byte("a-little-bit-long-string"), Result: -985981536},
+		{Key: []byte("a-little-bit-longer-string"), Result: -1486304829},
+		{Key: []byte("lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8"), Result: -58897971},
+		{Key: []byte{'a', 'b', 'c'}, Result: 479470107},
+	}
+	for _, test := range javaTestCases {
+		testCases = append(testCases, struct {
+			Key               []byte
+			JavaMurmur2Result uint32
+		}{Key: test.Key, JavaMurmur2Result: uint32(test.Result)})
+	}
+
 	for _, test := range testCases {
 		t.Run(fmt.Sprintf("key:%s", test.Key), func(t *testing.T) {
 			got := murmur2(test.Key)
@@ -277,3 +298,99 @@ func TestMurmur2Balancer(t *testing.T) {
 		}
 	})
 }
+
+func TestJavaMurmur2Balancer(t *testing.T) {
+	partitions := []int{0, 1, 2, 3, 4, 5, 6}
+
+	t.Run("keys", func(t *testing.T) {
+		// The DefaultPartitioner of the Java client routes a message with a
+		// key to Utils.toPositive(Utils.murmur2(key)) % numPartitions, the
+		// hashes are those of the testMurmur2 test of the Java library.
+		testCases := []struct {
+			Key  []byte
+			Hash int32
+		}{
+			{Key: []byte("21"), Hash: -973932308},
+			{Key: []byte("foobar"), Hash: -790332482},
+			{Key: []byte("a-little-bit-long-string"), Hash: -985981536},
+			{Key: []byte{'a', 'b', 'c'}, Hash: 479470107},
+			{Key: []byte{}, Hash: 0x106e08d9},
+		}
+
+		b := &JavaMurmur2Balancer{}
+		for _, test := range testCases {
+			expected := int(test.Hash&0x7fffffff) % len(partitions)
+			if partition := b.Balance(Message{Key: test.Key}, partitions...); partition != expected {
+				t.Errorf("key %q: expected partition %d; got %d", test.Key, expected, partition)
+			}
+		}
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		b := &JavaMurmur2Balancer{StickyMessages: 3}
+
+		first := b.Balance(Message{}, partitions...)
+		for i := 1; i < 3; i++ {
+			if p := b.Balance(Message{}, partitions...); p != first {
+				t.Fatalf("message %d: expected nil keys to stick to partition %d, got %d", i, first, p)
+			}
+		}
+
+		// The messages with a key do not count towards the sticky messages.
+		b.Balance(Message{Key: []byte("foobar")}, partitions...)
+
+		second := b.Balance(Message{}, partitions...)
+		if second == first {
+			t.Errorf("expected nil keys to move away from partition %d after 3 messages", first)
+		}
+		if p := b.Balance(Message{}, partitions...); p != second {
+			t.Errorf("expected nil keys to stick to partition %d, got %d", second, p)
+		}
+
+		// A partition which disappeared is not stuck to.
+		remaining := []int{}
+		for _, p := range partitions {
+			if p != second {
+				remaining = append(remaining, p)
+			}
+		}
+		if p := b.Balance(Message{}, remaining...); p == second {
+			t.Errorf("nil keys were routed to partition %d which is not available", p)
+		}
+	})
+}
+
+func TestStickyBalancer(t *testing.T) {
+	partitions := []int{0, 1, 2, 3}
+
+	b := &StickyBalancer{}
+	first := b.Balance(Message{}, partitions...)
+
+	// Completing the batch of another partition does not move the messages
+	// with a nil key away from their partition.
+	b.BatchCompleted((first + 1) % len(partitions))
+	for i := 0; i < 10; i++ {
+		if p := b.Balance(Message{}, partitions...); p != first {
+			t.Fatalf("message %d: expected nil keys to stick to partition %d, got %d", i, first, p)
+		}
+	}
+
+	b.BatchCompleted(first)
+	second := b.Balance(Message{}, partitions...)
+	if second == first {
+		t.Errorf("expected nil keys to move away from partition %d after its batch completed", first)
+	}
+	if p := b.Balance(Message{}, partitions...); p != second {
+		t.Errorf("expected nil keys to stick to partition %d, got %d", second, p)
+	}
+
+	key := []byte("foobar")
+	if p, expected := b.Balance(Message{Key: key}, partitions...), (&Hash{}).Balance(Message{Key: key}, partitions...); p != expected {
+		t.Errorf("expected messages with a key to be hashed to partition %d, got %d", expected, p)
+	}
+
+	b = &StickyBalancer{KeyBalancer: BalancerFunc(func(Message, ...int) int { return 3 })}
+	if p := b.Balance(Message{Key: key}, partitions...); p != 3 {
+		t.Errorf("expected messages with a key to be routed by the key balancer, got partition %d", p)
+	}
+}
@@ -27,6 +27,9 @@ type Batch struct {
 	partition     int
 	offset        int64
 	highWaterMark int64
+	lastStable    int64
+	logStart      int64
+	readReplica   int
 	err           error
 }
 
@@ -177,11 +180,11 @@ func (batch *Batch) ReadMessage() (Message, error) {
 
 	offset, timestamp, headers, err = batch.readMessage(
 		func(r *bufio.Reader, size int, nbytes int) (remain int, err error) {
-			msg.Key, remain, err = readNewBytes(r, size, nbytes)
+			msg.Key, remain, err = readNewNullableBytes(r, size, nbytes)
 			return
 		},
 		func(r *bufio.Reader, size int, nbytes int) (remain int, err error) {
-			msg.Value, remain, err = readNewBytes(r, size, nbytes)
+			msg.Value, remain, err = readNewNullableBytes(r, size, nbytes)
 			return
 		},
 	)
@@ -191,11 +194,11 @@ func (batch *Batch) ReadMessage() (Message, error) {
 		}
 		offset, timestamp, headers, err = batch.readMessage(
 			func(r *bufio.Reader, size int, nbytes int) (remain int, err error) {
-				msg.Key, remain, err = readNewBytes(r, size, nbytes)
+				msg.Key, remain, err = readNewNullableBytes(r, size, nbytes)
 				return
 			},
 			func(r *bufio.Reader, size int, nbytes int) (remain int, err error) {
-				msg.Value, remain, err = readNewBytes(r, size, nbytes)
+				msg.Value, remain, err = readNewNullableBytes(r, size, nbytes)
 				return
 			},
 		)
@@ -256,6 +259,13 @@ func (batch *Batch) readMessage(
 		batch.err = dontExpectEOF(err)
 	}
 
+	// Control batches and batches of aborted transactions are skipped by the
+	// message set reader, the batch offset must still move past them or the
+	// next fetch would return them again.
+	if skipped := batch.msgs.lastSkippedOffset(); skipped >= batch.offset {
+		batch.offset = skipped + 1
+	}
+
 	return
 }
 
@@ -3,15 +3,31 @@ package kafka
 import (
 	"bytes"
 	"sync"
+	"sync/atomic"
+)
+
+const (
+	// minBufferSize is the initial capacity of the buffers allocated by the
+	// pool, and the lower bound of the size that the pool adapts to.
+	minBufferSize = 65536
+
+	// maxPooledBufferSize is the capacity above which buffers are not returned
+	// to the pool, so a few giant batches don't pin their memory forever.
+	maxPooledBufferSize = 16 * 1024 * 1024
 )
 
 var bufferPool = sync.Pool{
 	New: func() interface{} { return newBuffer() },
 }
 
+// bufferSize is a moving average of the sizes of the buffers released to the
+// pool, new buffers are allocated with this capacity so they rarely have to
+// grow while compressing or decompressing batches.
+var bufferSize int64 = minBufferSize
+
 func newBuffer() *bytes.Buffer {
 	b := new(bytes.Buffer)
-	b.Grow(65536)
+	b.Grow(int(atomic.LoadInt64(&bufferSize)))
 	return b
 }
 
@@ -21,7 +37,28 @@ func acquireBuffer() *bytes.Buffer {
 
 func releaseBuffer(b *bytes.Buffer) {
 	if b != nil {
+		observeBufferSize(b.Len())
+		if b.Cap() > maxPooledBufferSize {
+			return
+		}
 		b.Reset()
 		bufferPool.Put(b)
 	}
 }
+
+// observeBufferSize moves the size of new buffers an eighth of the way toward
+// n, keeping it between minBufferSize and maxPooledBufferSize.
+func observeBufferSize(n int) {
+	if n < minBufferSize {
+		n = minBufferSize
+	} else if n > maxPooledBufferSize {
+		n = maxPooledBufferSize
+	}
+	for {
+		old := atomic.LoadInt64(&bufferSize)
+		size := old + (int64(n)-old)/8
+		if size == old || atomic.CompareAndSwapInt64(&bufferSize, old, size) {
+			return
+		}
+	}
+}
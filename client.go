@@ -3,6 +3,12 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 )
 
 // Client is a new and experimental API for kafka-go. It is expected that this API will grow over time,
@@ -12,8 +18,22 @@ import (
 // N.B Client is currently experimental! Therefore, it is subject to change, including breaking changes
 // between MINOR and PATCH releases.
 type Client struct {
-	brokers []string
-	dialer  *Dialer
+	brokers      []string
+	dialer       *Dialer
+	retries      int
+	retryBackoff time.Duration
+	observer     Observer
+	rackID       string
+
+	// readReplicas holds the preferred read replicas suggested by the
+	// brokers in response to Fetch.
+	readReplicas *readReplicaCache
+
+	// fetchSessions holds the fetch sessions of MultiFetch.
+	fetchSessions *fetchSessions
+
+	// pin is set on the clients returned by To.
+	pin *brokerPin
 }
 
 // Configuration for Client
@@ -26,6 +46,27 @@ type ClientConfig struct {
 	Brokers []string
 	// Dialer used for connecting to the Cluster
 	Dialer *Dialer
+
+	// Retries is the number of times that Produce and Fetch retry a request
+	// which failed with a retriable error, like LeaderNotAvailable or
+	// NotLeaderForPartition. The leaders of the partitions are looked up
+	// again before each retry, and retries stop when the context of the
+	// request is done. Zero disables retries.
+	Retries int
+
+	// RetryBackoff is the time to wait before the first retry, the delay
+	// grows with each attempt up to ten times its value. Defaults to 100ms.
+	RetryBackoff time.Duration
+
+	// Observer optionally receives the measurements of the requests sent by
+	// the client once its connections are established, including the
+	// ApiVersions requests sent to negotiate the versions of the other APIs.
+	// Requests are not measured when it is nil.
+	Observer Observer
+
+	// RackID is the rack of the client, it is the default value of
+	// FetchRequest.RackID.
+	RackID string
 }
 
 // A ConsumerGroup and Topic as these are both strings
@@ -59,21 +100,54 @@ func NewClientWith(config ClientConfig) *Client {
 	if d == nil {
 		d = DefaultDialer
 	}
+	retryBackoff := config.RetryBackoff
+	if retryBackoff == 0 {
+		retryBackoff = defaultRetryBackoff
+	}
 
 	return &Client{
-		brokers: b,
-		dialer:  d,
+		brokers:       b,
+		dialer:        d,
+		retries:       config.Retries,
+		retryBackoff:  retryBackoff,
+		observer:      config.Observer,
+		rackID:        config.RackID,
+		readReplicas:  &readReplicaCache{},
+		fetchSessions: &fetchSessions{},
 	}
 }
 
+const defaultRetryBackoff = 100 * time.Millisecond
+
+// To returns a copy of the client which sends the requests that any broker
+// can serve, like Metadata or DescribeConfigs, to the broker identified by
+// brokerID. The address of the broker is looked up in the cluster metadata on
+// the first request, and cached for the lifetime of the returned client.
+//
+// Requests that must be served by a specific broker, like the leader of a
+// partition, the coordinator of a group, or the controller of the cluster,
+// fail with a *BrokerMismatchError when it is not the broker that the client
+// is pinned to, instead of being sent to that broker. Methods that send
+// requests to all the brokers of the cluster, like DescribeLogDirs or
+// ListGroups, only send them to the pinned broker.
+func (c *Client) To(brokerID int) *Client {
+	pinned := *c
+	pinned.pin = &brokerPin{id: brokerID}
+	return &pinned
+}
+
+// brokerPin is the broker that a client returned by To is pinned to.
+type brokerPin struct {
+	id     int
+	mutex  sync.Mutex
+	broker *Broker
+}
+
 // ConsumerOffsets returns a map[int]int64 of partition to committed offset for a consumer group id and topic
+//
+// Use OffsetFetch to get the metadata committed alongside the offsets.
 func (c *Client) ConsumerOffsets(ctx context.Context, tg TopicAndGroup) (map[int]int64, error) {
-	address, err := c.lookupCoordinator(tg.GroupId)
-	if err != nil {
-		return nil, err
-	}
-
-	conn, err := c.coordinator(ctx, address)
+	conn, err := c.groupCoordinator(ctx, tg.GroupId)
 	if err != nil {
 		return nil, err
 	}
@@ -124,42 +198,352 @@ func (c *Client) ConsumerOffsets(ctx context.Context, tg TopicAndGroup) (map[int
 	return offsetsByPartition, nil
 }
 
-// connect returns a connection to ANY broker
-func (c *Client) connect() (conn *Conn, err error) {
+// connect returns a connection to ANY broker, or to the broker that the
+// client is pinned to.
+func (c *Client) connect(ctx context.Context) (*Conn, error) {
+	if c.pin == nil {
+		return c.connectBootstrap(ctx)
+	}
+	b, err := c.resolvePin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.dialBroker(ctx, b)
+}
+
+// connectBootstrap returns a connection to the first bootstrap broker that
+// accepts it.
+func (c *Client) connectBootstrap(ctx context.Context) (conn *Conn, err error) {
 	for _, broker := range c.brokers {
-		if conn, err = c.dialer.Dial("tcp", broker); err == nil {
+		if conn, err = c.dial(ctx, broker); err == nil {
 			return
 		}
 	}
 	return // err will be non-nil
 }
 
-// coordinator returns a connection to a coordinator
-func (c *Client) coordinator(ctx context.Context, address string) (*Conn, error) {
+// dial opens a connection to the broker at address. The deadline of ctx, if
+// any, is applied to the connection so calls made on it honor the context.
+func (c *Client) dial(ctx context.Context, address string) (*Conn, error) {
 	conn, err := c.dialer.DialContext(ctx, "tcp", address)
 	if err != nil {
-		return nil, fmt.Errorf("unable to connect to coordinator, %v", address)
+		return nil, err
+	}
+	c.observe(conn, address)
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
 	}
-
 	return conn, nil
 }
 
-// lookupCoordinator scans the brokers and looks up the address of the
-// coordinator for the groupId.
-func (c *Client) lookupCoordinator(groupId string) (string, error) {
-	conn, err := c.connect()
+// observe sets the observer of the client on a connection that it dialed to
+// address.
+func (c *Client) observe(conn *Conn, address string) {
+	conn.observer, conn.observerAddr = c.observer, address
+}
+
+// resolvePin returns the broker that the client is pinned to, looking up its
+// address in the cluster metadata the first time it is called.
+func (c *Client) resolvePin(ctx context.Context) (Broker, error) {
+	c.pin.mutex.Lock()
+	defer c.pin.mutex.Unlock()
+
+	if c.pin.broker != nil {
+		return *c.pin.broker, nil
+	}
+
+	conn, err := c.connectBootstrap(ctx)
 	if err != nil {
-		return "", fmt.Errorf("unable to find coordinator to any connect for group, %v: %v\n", groupId, err)
+		return Broker{}, err
 	}
 	defer conn.Close()
 
-	out, err := conn.findCoordinator(findCoordinatorRequestV0{
-		CoordinatorKey: groupId,
+	brokers, err := conn.Brokers()
+	if err != nil {
+		return Broker{}, err
+	}
+
+	for _, b := range brokers {
+		if b.ID == c.pin.id {
+			c.pin.broker = &b
+			return b, nil
+		}
+	}
+	return Broker{}, fmt.Errorf("broker %d not found in the cluster metadata", c.pin.id)
+}
+
+// checkPin returns a *BrokerMismatchError if the client is pinned to a broker
+// other than the one identified by id.
+func (c *Client) checkPin(id int) error {
+	if c.pin != nil && c.pin.id != id {
+		return &BrokerMismatchError{Pinned: c.pin.id, Required: id}
+	}
+	return nil
+}
+
+// connectBroker returns a connection to the broker identified by id, using
+// the cluster metadata to resolve its address.
+func (c *Client) connectBroker(ctx context.Context, id int) (*Conn, error) {
+	if err := c.checkPin(id); err != nil {
+		return nil, err
+	}
+
+	brokers, err := c.brokerList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range brokers {
+		if b.ID == id {
+			return c.dialBroker(ctx, b)
+		}
+	}
+	return nil, fmt.Errorf("broker %d not found in the cluster metadata", id)
+}
+
+// brokerList returns the list of brokers of the cluster, loaded from the
+// metadata served by any broker. It only holds the pinned broker when the
+// client is pinned to one.
+func (c *Client) brokerList(ctx context.Context) ([]Broker, error) {
+	if c.pin != nil {
+		b, err := c.resolvePin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []Broker{b}, nil
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.Brokers()
+}
+
+// dialBroker opens a connection to the broker b.
+func (c *Client) dialBroker(ctx context.Context, b Broker) (*Conn, error) {
+	if err := c.checkPin(b.ID); err != nil {
+		return nil, err
+	}
+	return c.dial(ctx, net.JoinHostPort(b.Host, strconv.Itoa(b.Port)))
+}
+
+// connectController returns a connection to the controller of the cluster.
+func (c *Client) connectController(ctx context.Context) (*Conn, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	controller, err := conn.Controller()
+	conn.Close()
+	if err != nil {
+		return nil, err
+	}
+	return c.dialBroker(ctx, controller)
+}
+
+// connectBrokerOrAny returns a connection to the broker identified by id, or
+// to any broker if id is negative.
+func (c *Client) connectBrokerOrAny(ctx context.Context, id int) (*Conn, error) {
+	if id < 0 {
+		return c.connect(ctx)
+	}
+	return c.connectBroker(ctx, id)
+}
+
+// groupCoordinator returns a connection to the coordinator of the group.
+func (c *Client) groupCoordinator(ctx context.Context, groupID string) (*Conn, error) {
+	b, err := c.FindCoordinator(ctx, groupID, CoordinatorKeyTypeGroup)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find coordinator for group, %v: %v", groupID, err)
+	}
+	return c.dialBroker(ctx, b)
+}
+
+// transactionCoordinator returns a connection to the coordinator of the
+// transactionalID.
+func (c *Client) transactionCoordinator(ctx context.Context, transactionalID string) (*Conn, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := conn.findCoordinatorV1(findCoordinatorRequestV1{
+		CoordinatorKey:  transactionalID,
+		CoordinatorType: int8(CoordinatorKeyTypeTransaction),
+	})
+	conn.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to find coordinator for transactional id, %v: %v", transactionalID, err)
+	}
+
+	return c.dialBroker(ctx, Broker{
+		ID:   int(res.Coordinator.NodeID),
+		Host: res.Coordinator.Host,
+		Port: int(res.Coordinator.Port),
+	})
+}
+
+// groupCoordinators looks up the coordinators of the groups, and returns the
+// group IDs indexed by the broker that coordinates them. Groups for which the
+// lookup failed with a kafka error are reported in the map of errors instead.
+func (c *Client) groupCoordinators(ctx context.Context, groupIDs []string) (map[Broker][]string, map[string]error, error) {
+	return c.keyCoordinators(ctx, CoordinatorKeyTypeGroup, groupIDs)
+}
+
+// transactionCoordinators looks up the coordinators of the transactional IDs,
+// and returns the IDs indexed by the broker that coordinates them. IDs for
+// which the lookup failed with a kafka error are reported in the map of errors
+// instead.
+func (c *Client) transactionCoordinators(ctx context.Context, transactionalIDs []string) (map[Broker][]string, map[string]error, error) {
+	return c.keyCoordinators(ctx, CoordinatorKeyTypeTransaction, transactionalIDs)
+}
+
+// keyCoordinators looks up the coordinators of keys of the given type, using
+// a single request when the brokers support batched lookups.
+func (c *Client) keyCoordinators(ctx context.Context, keyType CoordinatorKeyType, keys []string) (map[Broker][]string, map[string]error, error) {
+	res, err := c.FindCoordinators(ctx, FindCoordinatorsRequest{
+		Keys:    keys,
+		KeyType: keyType,
 	})
 	if err != nil {
-		return "", fmt.Errorf("unable to find coordinator for group, %v: %v", groupId, err)
+		return nil, nil, fmt.Errorf("unable to find coordinators: %v", err)
+	}
+
+	coordinators := make(map[Broker][]string)
+	errs := make(map[string]error)
+
+	for _, coordinator := range res.Coordinators {
+		if coordinator.Error != nil {
+			errs[coordinator.Key] = coordinator.Error
+			continue
+		}
+		b := coordinator.Coordinator
+		coordinators[b] = append(coordinators[b], coordinator.Key)
+	}
+
+	return coordinators, errs, nil
+}
+
+// topicPartition identifies a partition of a topic.
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// partitionLeaders returns the leaders of the partitions of the topics,
+// partitions that currently have no leader are omitted.
+func (c *Client) partitionLeaders(ctx context.Context, topics []string) (map[topicPartition]Broker, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topics...)
+	if err != nil {
+		return nil, err
+	}
+
+	leaders := make(map[topicPartition]Broker, len(partitions))
+	for _, p := range partitions {
+		if p.Leader.Host != "" {
+			leaders[topicPartition{topic: p.Topic, partition: p.ID}] = p.Leader
+		}
+	}
+	return leaders, nil
+}
+
+// topicPartitions returns the partitions of the topics, looking up all the
+// partitions of the topics that have none listed.
+func (c *Client) topicPartitions(ctx context.Context, topics map[string][]int) (map[string][]int, error) {
+	partitions := make(map[string][]int, len(topics))
+	missing := []string{}
+
+	for topic, p := range topics {
+		if len(p) == 0 {
+			missing = append(missing, topic)
+		} else {
+			partitions[topic] = p
+		}
+	}
+
+	if len(missing) == 0 {
+		return partitions, nil
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	metadata, err := conn.ReadPartitions(missing...)
+	if err != nil {
+		return nil, err
 	}
 
-	address := fmt.Sprintf("%v:%v", out.Coordinator.Host, out.Coordinator.Port)
-	return address, nil
+	for _, p := range metadata {
+		partitions[p.Topic] = append(partitions[p.Topic], p.ID)
+	}
+
+	for _, topic := range missing {
+		if _, ok := partitions[topic]; !ok {
+			return nil, fmt.Errorf("topic %s has no partitions", topic)
+		}
+		sort.Ints(partitions[topic])
+	}
+
+	return partitions, nil
+}
+
+// protocolTimeout returns the timeout in milliseconds sent to the brokers with
+// requests that carry one. An explicit timeout is shortened so the response
+// can arrive before the deadline of ctx. A zero timeout is left for the
+// connection to derive from the deadline of ctx.
+func protocolTimeout(ctx context.Context, timeout time.Duration) int32 {
+	if timeout <= 0 {
+		return 0
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		now := time.Now()
+		if remain := adjustDeadlineForRTT(deadline, now, defaultRTT).Sub(now); remain < timeout {
+			timeout = remain
+		}
+	}
+	return milliseconds(timeout)
+}
+
+// retry reports whether a request that failed at attempt, counting from zero,
+// should be sent again. It waits for the retry backoff before returning true,
+// and returns false if the retries are exhausted or ctx is done.
+func (c *Client) retry(ctx context.Context, attempt int) bool {
+	if attempt >= c.retries {
+		return false
+	}
+	return sleep(ctx, backoff(attempt+1, c.retryBackoff, 10*c.retryBackoff))
+}
+
+// retryError wraps err in a RetryError if the request that failed with it was
+// retried, attempt is the index of the last attempt.
+func retryError(attempt int, err error) error {
+	if attempt == 0 {
+		return err
+	}
+	return &RetryError{Attempts: attempt + 1, Err: err}
+}
+
+// isRetriable returns true if a request that failed with err may succeed when
+// sent again after looking up the leaders of the partitions.
+func isRetriable(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case Error:
+		return e.Temporary()
+	case net.Error:
+		return true
+	}
+	return err == io.EOF || err == io.ErrUnexpectedEOF
 }
@@ -2,6 +2,9 @@ package kafka
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net"
 	"testing"
 	"time"
 )
@@ -16,6 +19,178 @@ func TestClient(t *testing.T) {
 			scenario: "retrieve committed offsets for a consumer group and topic",
 			function: testConsumerGroupFetchOffsets,
 		},
+		{
+			scenario: "describe the configuration of a topic",
+			function: testClientDescribeConfigs,
+		},
+		{
+			scenario: "alter the configuration of a topic",
+			function: testClientAlterConfigs,
+		},
+		{
+			scenario: "incrementally alter the configuration of a topic",
+			function: testClientIncrementalAlterConfigs,
+		},
+		{
+			scenario: "add partitions to a topic",
+			function: testClientCreatePartitions,
+		},
+		{
+			scenario: "list the consumer groups of the cluster",
+			function: testClientListGroups,
+		},
+		{
+			scenario: "describe the members of a consumer group",
+			function: testClientDescribeGroups,
+		},
+		{
+			scenario: "delete consumer groups",
+			function: testClientDeleteGroups,
+		},
+		{
+			scenario: "delete the committed offsets of a consumer group",
+			function: testClientOffsetDelete,
+		},
+		{
+			scenario: "delete the records of partitions",
+			function: testClientDeleteRecords,
+		},
+		{
+			scenario: "elect the leaders of partitions",
+			function: testClientElectLeaders,
+		},
+		{
+			scenario: "reassign the replicas of partitions",
+			function: testClientPartitionReassignments,
+		},
+		{
+			scenario: "describe the cluster",
+			function: testClientDescribeCluster,
+		},
+		{
+			scenario: "describe the log directories of the brokers",
+			function: testClientDescribeLogDirs,
+		},
+		{
+			scenario: "move replicas between log directories",
+			function: testClientAlterReplicaLogDirs,
+		},
+		{
+			scenario: "create, describe, and delete acls",
+			function: testClientACLs,
+		},
+		{
+			scenario: "set and remove client quotas",
+			function: testClientClientQuotas,
+		},
+		{
+			scenario: "create, describe, and delete user scram credentials",
+			function: testClientUserScramCredentials,
+		},
+		{
+			scenario: "fetch the committed offsets of a consumer group",
+			function: testClientOffsetFetch,
+		},
+		{
+			scenario: "commit offsets for a consumer group with no active members",
+			function: testClientOffsetCommit,
+		},
+		{
+			scenario: "list the offsets of partitions",
+			function: testClientListOffsets,
+		},
+		{
+			scenario: "look up the end offset of a leader epoch",
+			function: testClientOffsetForLeaderEpoch,
+		},
+		{
+			scenario: "produce records to a partition",
+			function: testClientProduce,
+		},
+		{
+			scenario: "produce records to multiple partitions",
+			function: testClientProduceBatches,
+		},
+		{
+			scenario: "produce records with an idempotent producer",
+			function: testClientProduceIdempotent,
+		},
+		{
+			scenario: "produce records in a transaction and abort it",
+			function: testClientTransaction,
+		},
+		{
+			scenario: "fetch records with the read_committed isolation level",
+			function: testClientFetch,
+		},
+		{
+			scenario: "fetch records from multiple partitions",
+			function: testClientMultiFetch,
+		},
+		{
+			scenario: "retrieve the API versions supported by the brokers",
+			function: testClientApiVersions,
+		},
+		{
+			scenario: "wait for topics to be ready after creating them",
+			function: testClientWaitForTopics,
+		},
+		{
+			scenario: "validate the creation of topics",
+			function: testClientCreateTopics,
+		},
+		{
+			scenario: "retrieve the metadata of topics including their ids",
+			function: testClientMetadata,
+		},
+		{
+			scenario: "delete topics by id",
+			function: testClientDeleteTopics,
+		},
+		{
+			scenario: "describe the producers of a partition with an open transaction",
+			function: testClientDescribeProducers,
+		},
+		{
+			scenario: "list the ongoing transactions of the cluster",
+			function: testClientListTransactions,
+		},
+		{
+			scenario: "describe the state of transactions",
+			function: testClientDescribeTransactions,
+		},
+		{
+			scenario: "find the coordinators of groups and transactional ids",
+			function: testClientFindCoordinator,
+		},
+		{
+			scenario: "join, sync, heartbeat, and leave a group",
+			function: testClientGroupMembership,
+		},
+		{
+			scenario: "pin requests to a broker",
+			function: testClientTo,
+		},
+		{
+			scenario: "reset the offsets of a consumer group",
+			function: testClientResetConsumerGroupOffsets,
+		},
+		{
+			scenario: "compute the lag of a consumer group",
+			function: testClientConsumerLag,
+		},
+		{
+			scenario: "watch the changes of the cluster metadata",
+			function: testClientWatchMetadata,
+		},
+		{
+			scenario: "observe the requests sent by the client",
+			function: testClientObserver,
+		},
+		{
+			scenario: "fetch records from multiple partitions within fetch sessions",
+			function: testClientMultiFetchSession,
+		},
 	}
 
 	for _, test := range tests {
@@ -32,6 +207,130 @@ func TestClient(t *testing.T) {
 	}
 }
 
+func TestIsRetriable(t *testing.T) {
+	tests := []struct {
+		err       error
+		retriable bool
+	}{
+		{err: nil, retriable: false},
+		{err: LeaderNotAvailable, retriable: true},
+		{err: NotLeaderForPartition, retriable: true},
+		{err: OffsetOutOfRange, retriable: false},
+		{err: TopicAuthorizationFailed, retriable: false},
+		{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, retriable: true},
+		{err: io.ErrUnexpectedEOF, retriable: true},
+		{err: context.Canceled, retriable: false},
+		{err: errNoMessagesToProduce, retriable: false},
+		{err: &BrokerMismatchError{Pinned: 1, Required: 2}, retriable: false},
+	}
+
+	for _, test := range tests {
+		if retriable := isRetriable(test.err); retriable != test.retriable {
+			t.Errorf("%v: expected retriable to be %t, got %t", test.err, test.retriable, retriable)
+		}
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	c := NewClientWith(ClientConfig{
+		Brokers:      []string{"localhost:9092"},
+		Retries:      2,
+		RetryBackoff: time.Millisecond,
+	})
+	ctx := context.Background()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if !c.retry(ctx, attempt) {
+			t.Errorf("attempt %d should have been retried", attempt)
+		}
+	}
+	if c.retry(ctx, 2) {
+		t.Error("the request was retried after exhausting the retries")
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if c.retry(canceled, 0) {
+		t.Error("the request was retried after the context was canceled")
+	}
+
+	if err := retryError(0, LeaderNotAvailable); err != LeaderNotAvailable {
+		t.Errorf("expected the error of a single attempt to be returned as is, got %v", err)
+	}
+	err := retryError(2, LeaderNotAvailable)
+	if !errors.Is(err, LeaderNotAvailable) {
+		t.Errorf("expected %v to wrap LeaderNotAvailable", err)
+	}
+	if e, ok := err.(*RetryError); !ok || e.Attempts != 3 {
+		t.Errorf("expected a RetryError after 3 attempts, got %#v", err)
+	}
+}
+
+func TestClientTo(t *testing.T) {
+	c := NewClient("localhost:9092")
+	pinned := c.To(1)
+
+	if c.pin != nil {
+		t.Error("pinning a client to a broker modified the original client")
+	}
+
+	_, err := pinned.dialBroker(context.Background(), Broker{ID: 2, Host: "localhost", Port: 9092})
+	if e, ok := err.(*BrokerMismatchError); !ok || e.Pinned != 1 || e.Required != 2 {
+		t.Errorf("expected a broker mismatch error, got %v", err)
+	}
+
+	_, err = pinned.connectBroker(context.Background(), 2)
+	if _, ok := err.(*BrokerMismatchError); !ok {
+		t.Errorf("expected a broker mismatch error, got %v", err)
+	}
+}
+
+func testClientTo(t *testing.T, ctx context.Context, c *Client) {
+	brokers, err := c.brokerList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := brokers[0]
+
+	pinned := c.To(b.ID)
+
+	res, err := pinned.DescribeLogDirs(ctx, DescribeLogDirsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Brokers) != 1 {
+		t.Fatalf("expected the log directories of 1 broker, got %d", len(res.Brokers))
+	}
+	if broker, ok := res.Brokers[b.ID]; !ok || broker.Error != nil {
+		t.Errorf("describing the log directories of broker %d failed: %+v", b.ID, broker)
+	}
+
+	if _, err := c.To(-2).DescribeLogDirs(ctx, DescribeLogDirsRequest{}); err == nil {
+		t.Error("expected pinning the client to an unknown broker to fail")
+	}
+}
+
+func TestProtocolTimeout(t *testing.T) {
+	if timeout := protocolTimeout(context.Background(), 0); timeout != 0 {
+		t.Errorf("expected a zero timeout to be left unset, got %dms", timeout)
+	}
+	if timeout := protocolTimeout(context.Background(), time.Minute); timeout != 60000 {
+		t.Errorf("expected a timeout of 60000ms, got %dms", timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if timeout := protocolTimeout(ctx, 5*time.Second); timeout != 5000 {
+		t.Errorf("expected a timeout of 5000ms, got %dms", timeout)
+	}
+	// The timeout is capped to leave the response time to arrive before the
+	// deadline of the context.
+	if timeout := protocolTimeout(ctx, time.Minute); timeout <= 0 || timeout > 9000 {
+		t.Errorf("expected a timeout of at most 9000ms, got %dms", timeout)
+	}
+}
+
 func testConsumerGroupFetchOffsets(t *testing.T, ctx context.Context, c *Client) {
 	const totalMessages = 144
 	const partitions = 12
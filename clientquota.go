@@ -0,0 +1,98 @@
+package kafka
+
+import "bufio"
+
+// ClientQuotaEntityType is the type of an entity that client quotas apply to.
+type ClientQuotaEntityType string
+
+const (
+	ClientQuotaEntityTypeUser     ClientQuotaEntityType = "user"
+	ClientQuotaEntityTypeClientID ClientQuotaEntityType = "client-id"
+	ClientQuotaEntityTypeIP       ClientQuotaEntityType = "ip"
+)
+
+// Keys of the client quotas supported by kafka.
+const (
+	ClientQuotaProducerByteRate       = "producer_byte_rate"
+	ClientQuotaConsumerByteRate       = "consumer_byte_rate"
+	ClientQuotaRequestPercentage      = "request_percentage"
+	ClientQuotaConnectionCreationRate = "connection_creation_rate"
+)
+
+// ClientQuotaEntityComponent is a component of the entity that a client quota
+// applies to. Quotas may apply to a single component, for example a user, or
+// to a combination of components, for example a user and a client-id.
+type ClientQuotaEntityComponent struct {
+	EntityType ClientQuotaEntityType
+
+	// EntityName is the name of the entity, it is empty when Default is true.
+	EntityName string
+
+	// Default is true when the component designates the default entity of
+	// its type, which applies to the entities with no quota of their own.
+	Default bool
+}
+
+type clientQuotaEntityComponentV0 struct {
+	EntityType string
+
+	// EntityName is null for the default entity.
+	EntityName *string
+}
+
+func makeClientQuotaEntityV0(entity []ClientQuotaEntityComponent) []clientQuotaEntityComponentV0 {
+	components := make([]clientQuotaEntityComponentV0, len(entity))
+	for i, c := range entity {
+		components[i].EntityType = string(c.EntityType)
+		if !c.Default {
+			name := c.EntityName
+			components[i].EntityName = &name
+		}
+	}
+	return components
+}
+
+func makeClientQuotaEntity(components []clientQuotaEntityComponentV0) []ClientQuotaEntityComponent {
+	entity := make([]ClientQuotaEntityComponent, len(components))
+	for i, c := range components {
+		entity[i].EntityType = ClientQuotaEntityType(c.EntityType)
+		if c.EntityName == nil {
+			entity[i].Default = true
+		} else {
+			entity[i].EntityName = *c.EntityName
+		}
+	}
+	return entity
+}
+
+func (t clientQuotaEntityComponentV0) size() int32 {
+	return sizeofString(t.EntityType) +
+		sizeofNullableString(t.EntityName)
+}
+
+func (t clientQuotaEntityComponentV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.EntityType)
+	wb.writeNullableString(t.EntityName)
+}
+
+func (t *clientQuotaEntityComponentV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.EntityType); err != nil {
+		return
+	}
+	if remain, err = readNullableString(r, remain, &t.EntityName); err != nil {
+		return
+	}
+	return
+}
+
+func readClientQuotaEntityV0(r *bufio.Reader, size int, entity *[]clientQuotaEntityComponentV0) (remain int, err error) {
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var component clientQuotaEntityComponentV0
+		if fnRemain, fnErr = (&component).readFrom(r, size); fnErr != nil {
+			return
+		}
+		*entity = append(*entity, component)
+		return
+	}
+	return readArrayWith(r, size, fn)
+}
@@ -6,6 +6,7 @@ type commit struct {
 	topic     string
 	partition int
 	offset    int64
+	metadata  string
 }
 
 // makeCommit builds a commit value from a message, the resulting commit takes
@@ -31,6 +32,18 @@ func makeCommits(msgs ...Message) []commit {
 	return commits
 }
 
+// makeCommitsWithMetadata generates the commits of a list of messages like
+// makeCommits does, the metadata string is committed with each offset.
+func makeCommitsWithMetadata(metadata string, msgs ...Message) []commit {
+	commits := makeCommits(msgs...)
+
+	for i := range commits {
+		commits[i].metadata = metadata
+	}
+
+	return commits
+}
+
 // commitRequest is the data type exchanged between the CommitMessages method
 // and internals of the reader's implementation.
 type commitRequest struct {
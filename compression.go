@@ -2,8 +2,10 @@ package kafka
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 )
 
 const (
@@ -13,23 +15,62 @@ const (
 var (
 	errUnknownCodec = errors.New("the compression code is invalid or its codec has not been imported")
 
-	codecs      = make(map[int8]CompressionCodec)
-	codecsMutex sync.RWMutex
+	// codecs holds a map[int8]CompressionCodec which is replaced when a codec
+	// is registered, so the lookups made for each batch do not take a lock.
+	codecs      atomic.Value
+	codecsMutex sync.Mutex
 )
 
-// RegisterCompressionCodec registers a compression codec so it can be used by a Writer.
+// RegisterCompressionCodec registers a compression codec so it can be used by a
+// Writer, and by the Reader to decompress the batches with the code of the
+// codec in their attributes. A codec registered with the same code before is
+// replaced, see AddCompressionCodec to register codecs without replacing them.
+//
+// The function panics if the code of the codec does not fit in the attributes
+// of a batch.
 func RegisterCompressionCodec(codec CompressionCodec) {
+	if err := registerCodec(codec, true); err != nil {
+		panic(err)
+	}
+}
+
+// AddCompressionCodec registers a compression codec like
+// RegisterCompressionCodec, but fails if a codec was registered with the same
+// code already. Programs use it to register codecs for the codes of the
+// compression types that the Kafka protocol does not define, which must fit
+// in the 3 bits of the attributes of the batches reserved for the compression
+// type, so only codes 5 to 7 are free.
+func AddCompressionCodec(codec CompressionCodec) error {
+	return registerCodec(codec, false)
+}
+
+func registerCodec(codec CompressionCodec, replace bool) error {
 	code := codec.Code()
+	if code <= 0 || code > compressionCodecMask {
+		return fmt.Errorf("kafka compression codec %s has code %d, which does not fit in the attributes of a batch", codec.Name(), code)
+	}
+
 	codecsMutex.Lock()
-	codecs[code] = codec
-	codecsMutex.Unlock()
+	defer codecsMutex.Unlock()
+
+	current, _ := codecs.Load().(map[int8]CompressionCodec)
+	if c, ok := current[code]; ok && !replace {
+		return fmt.Errorf("kafka compression code %d is already registered to codec %s", code, c.Name())
+	}
+
+	updated := make(map[int8]CompressionCodec, len(current)+1)
+	for k, c := range current {
+		updated[k] = c
+	}
+	updated[code] = codec
+	codecs.Store(updated)
+	return nil
 }
 
 // resolveCodec looks up a codec by Code()
 func resolveCodec(code int8) (codec CompressionCodec, err error) {
-	codecsMutex.RLock()
-	codec = codecs[code]
-	codecsMutex.RUnlock()
+	registered, _ := codecs.Load().(map[int8]CompressionCodec)
+	codec = registered[code]
 
 	if codec == nil {
 		err = errUnknownCodec
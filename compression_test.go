@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -35,6 +36,194 @@ func TestCompression(t *testing.T) {
 	}
 }
 
+func TestCompressionLevel(t *testing.T) {
+	// The payload is made of random words so the quality of the compression
+	// depends on the level.
+	prng := rand.New(rand.NewSource(0))
+	words := []string{"kafka", "message", "topic", "partition", "offset", "broker", "replica", "leader"}
+	payload := new(bytes.Buffer)
+	for payload.Len() < 100e3 {
+		payload.WriteString(words[prng.Intn(len(words))])
+		payload.WriteString(strconv.Itoa(prng.Intn(100)))
+	}
+
+	tests := []struct {
+		scenario string
+		fast     kafka.CompressionCodec
+		best     kafka.CompressionCodec
+	}{
+		{
+			scenario: "gzip",
+			fast:     gzip.NewCompressionCodecLevel(gzip.BestSpeed),
+			best:     gzip.NewCompressionCodecLevel(gzip.BestCompression),
+		},
+		{
+			scenario: "lz4",
+			fast:     lz4.NewCompressionCodecLevel(0),
+			best:     lz4.NewCompressionCodecLevel(9),
+		},
+		{
+			scenario: "zstd",
+			fast:     zstd.NewCompressionCodecWith(1),
+			best:     zstd.NewCompressionCodecWith(10),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			var sizes [2]int
+
+			// Compressing twice with each codec makes them reuse the
+			// compressors that they pooled, which must keep their level.
+			for i, codec := range []kafka.CompressionCodec{test.fast, test.best} {
+				for j := 0; j < 2; j++ {
+					b, err := compress(codec, payload.Bytes())
+					if err != nil {
+						t.Fatal(err)
+					}
+					d, err := decompress(codec, b)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.Equal(d, payload.Bytes()) {
+						t.Fatal("the payload was not decompressed to its original value")
+					}
+					sizes[i] = len(b)
+				}
+			}
+
+			if sizes[1] >= sizes[0] {
+				t.Errorf("expected the best compression level to produce less than %d bytes, got %d", sizes[0], sizes[1])
+			}
+		})
+	}
+}
+
+func TestCompressionDictionary(t *testing.T) {
+	dict, err := ioutil.ReadFile(filepath.Join("testdata", "zstd-json.dict"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := zstd.NewCompressionCodecWithDictionary(zstd.DefaultCompressionLevel, []byte("not a dictionary")); err == nil {
+		t.Error("expected an invalid dictionary to be rejected")
+	}
+
+	codec, err := zstd.NewCompressionCodecWithDictionary(zstd.DefaultCompressionLevel, dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := zstd.NewCompressionCodec()
+
+	msg := []byte(`{"type":"click","user_id":4242,"session":"s-001234","properties":{"path":"/products/42","referrer":"https://example.com/search","locale":"en-US"},"timestamp":"2020-06-12T12:34:56Z"}`)
+
+	// Compressing twice makes the codec reuse the compressors and
+	// decompressors that it pooled, which must keep their dictionary.
+	var withDict []byte
+	for i := 0; i < 2; i++ {
+		if withDict, err = compress(codec, msg); err != nil {
+			t.Fatal(err)
+		}
+		d, err := decompress(codec, withDict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(d, msg) {
+			t.Fatal("the message was not decompressed to its original value")
+		}
+	}
+
+	withoutDict, err := compress(plain, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withDict) >= len(withoutDict) {
+		t.Errorf("expected the dictionary to compress the message to less than %d bytes, got %d", len(withoutDict), len(withDict))
+	}
+
+	if _, err := decompress(plain, withDict); err == nil {
+		t.Error("expected the message to only be decompressed with the dictionary")
+	}
+	if d, err := decompress(codec, withoutDict); err != nil || !bytes.Equal(d, msg) {
+		t.Errorf("expected the codec to decompress messages compressed without dictionary, got %q, %v", d, err)
+	}
+}
+
+func TestLZ4BlockSize(t *testing.T) {
+	payload := bytes.Repeat([]byte("kafka message topic partition offset "), 10e3)
+	reader := lz4.NewCompressionCodec()
+
+	tests := []struct {
+		blockSize int
+		id        byte
+	}{
+		{blockSize: 0, id: 7},
+		{blockSize: lz4.BlockSize64KB, id: 4},
+		{blockSize: lz4.BlockSize256KB, id: 5},
+		{blockSize: lz4.BlockSize1MB, id: 6},
+		{blockSize: lz4.BlockSize4MB, id: 7},
+	}
+
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.blockSize), func(t *testing.T) {
+			codec := lz4.CompressionCodec{Level: 9, BlockSize: test.blockSize}
+
+			b, err := compress(codec, payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The block size is encoded in bits 4 to 6 of the byte following
+			// the magic number and flags of the frame.
+			if id := (b[5] >> 4) & 0x7; id != test.id {
+				t.Errorf("expected the frame to have block size id %d, got %d", test.id, id)
+			}
+
+			// The frames are decompressed whatever the block size of the
+			// codec reading them.
+			d, err := decompress(reader, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(d, payload) {
+				t.Error("the payload was not decompressed to its original value")
+			}
+		})
+	}
+
+	if _, err := compress(lz4.CompressionCodec{BlockSize: 1000}, payload); err == nil {
+		t.Error("expected compressing with an invalid block size to fail")
+	}
+}
+
+// customCodec is a codec using one of the compression codes that the Kafka
+// protocol does not define.
+type customCodec struct {
+	kafka.CompressionCodec
+	code int8
+}
+
+func (c customCodec) Code() int8 { return c.code }
+
+func TestAddCompressionCodec(t *testing.T) {
+	codec := customCodec{CompressionCodec: gzip.NewCompressionCodec(), code: 7}
+
+	if err := kafka.AddCompressionCodec(codec); err != nil {
+		t.Fatal(err)
+	}
+	if err := kafka.AddCompressionCodec(codec); err == nil {
+		t.Error("expected registering a codec twice to fail")
+	}
+	if err := kafka.AddCompressionCodec(gzip.NewCompressionCodec()); err == nil {
+		t.Error("expected registering over the gzip codec to fail")
+	}
+	if err := kafka.AddCompressionCodec(customCodec{CompressionCodec: codec, code: 8}); err == nil {
+		t.Error("expected a code which does not fit in the attributes of a batch to be rejected")
+	}
+
+	// Registering a codec replaces the codec with the same code.
+	kafka.RegisterCompressionCodec(codec)
+}
+
 func compress(codec kafka.CompressionCodec, src []byte) ([]byte, error) {
 	b := new(bytes.Buffer)
 	r := bytes.NewReader(src)
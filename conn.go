@@ -18,6 +18,7 @@ import (
 var (
 	errInvalidWriteTopic     = errors.New("writes must NOT set Topic on kafka.Message")
 	errInvalidWritePartition = errors.New("writes must NOT set Partition on kafka.Message")
+	errUnsupportedHeaders    = errors.New("writes of kafka.Message with Headers require kafka 0.11 or above")
 )
 
 // Broker carries the metadata associated with a kafka broker.
@@ -35,6 +36,12 @@ type Partition struct {
 	Replicas []Broker
 	Isr      []Broker
 	ID       int
+
+	// LeaderEpoch is the epoch of the leader of the partition, and
+	// OfflineReplicas the replicas hosted on offline log directories. They
+	// are only set by Client.Metadata.
+	LeaderEpoch     int
+	OfflineReplicas []Broker
 }
 
 // Conn represents a connection to a kafka broker.
@@ -81,6 +88,12 @@ type Conn struct {
 	apiVersions atomic.Value // apiVersionMap
 
 	transactionalID *string
+
+	// observer receives the measurements of the requests sent on the
+	// connection, observerAddr is the broker address reported to it. They
+	// are set by Client right after dialing the connection.
+	observer     Observer
+	observerAddr string
 }
 
 type apiVersionMap map[apiKey]ApiVersion
@@ -129,6 +142,17 @@ type ReadBatchConfig struct {
 	// For backward compatibility, when this field is left zero, kafka-go will
 	// infer the max wait from the connection's read deadline.
 	MaxWait time.Duration
+
+	// RackID is the rack of the client. Since kafka 2.4, brokers configured
+	// with a replica.selector.class use it to suggest a preferred read
+	// replica (KIP-392).
+	RackID string
+
+	// DecompressionConcurrency is the maximum number of record batches read
+	// ahead and decompressed concurrently while the messages of the batch are
+	// read, they are still returned in offset order. Batches are read one at
+	// a time when it is lower than 2.
+	DecompressionConcurrency int
 }
 
 type IsolationLevel int8
@@ -303,7 +327,8 @@ func (c *Conn) DeleteTopics(topics ...string) error {
 	return err
 }
 
-// describeGroups retrieves the specified groups
+// describeGroups retrieves the specified groups. Errors that apply to a single
+// group are reported by the ErrorCode of the group.
 //
 // See http://kafka.apache.org/protocol.html#The_Messages_DescribeGroups
 func (c *Conn) describeGroups(request describeGroupsRequestV0) (describeGroupsResponseV0, error) {
@@ -322,11 +347,6 @@ func (c *Conn) describeGroups(request describeGroupsRequestV0) (describeGroupsRe
 	if err != nil {
 		return describeGroupsResponseV0{}, err
 	}
-	for _, group := range response.Groups {
-		if group.ErrorCode != 0 {
-			return describeGroupsResponseV0{}, Error(group.ErrorCode)
-		}
-	}
 
 	return response, nil
 }
@@ -791,12 +811,14 @@ func (c *Conn) ReadBatchWith(cfg ReadBatchConfig) *Batch {
 		return &Batch{err: dontExpectEOF(err)}
 	}
 
-	fetchVersion, err := c.negotiateVersion(fetch, v2, v5, v10)
+	fetchVersion, err := c.negotiateVersion(fetch, v2, v5, v10, v11)
 	if err != nil {
 		return &Batch{err: dontExpectEOF(err)}
 	}
 
-	id, err := c.doRequest(&c.rdeadline, func(deadline time.Time, id int32) error {
+	o := c.observeRequest()
+
+	id, err := c.doRequest(&c.rdeadline, o.write(func(deadline time.Time, id int32) error {
 		now := time.Now()
 		var timeout time.Duration
 		if cfg.MaxWait > 0 {
@@ -813,7 +835,7 @@ func (c *Conn) ReadBatchWith(cfg ReadBatchConfig) *Batch {
 		// truncated messages.
 		adjustedDeadline = deadline
 		switch fetchVersion {
-		case v10:
+		case v11, v10:
 			return c.wb.writeFetchRequestV10(
 				id,
 				c.clientID,
@@ -824,6 +846,8 @@ func (c *Conn) ReadBatchWith(cfg ReadBatchConfig) *Batch {
 				cfg.MaxBytes+int(c.fetchMinSize),
 				timeout,
 				int8(cfg.IsolationLevel),
+				fetchVersion,
+				cfg.RackID,
 			)
 		case v5:
 			return c.wb.writeFetchRequestV5(
@@ -849,25 +873,34 @@ func (c *Conn) ReadBatchWith(cfg ReadBatchConfig) *Batch {
 				timeout,
 			)
 		}
-	})
+	}))
 	if err != nil {
+		o.observe(err)
 		return &Batch{err: dontExpectEOF(err)}
 	}
 
 	_, size, lock, err := c.waitResponse(&c.rdeadline, id)
 	if err != nil {
+		o.observe(err)
 		return &Batch{err: dontExpectEOF(err)}
 	}
+	o.received(size)
 
 	var throttle int32
 	var highWaterMark int64
+	var lastStableOffset int64 = -1
+	var logStartOffset int64 = -1
+	var preferredReadReplica int32 = -1
+	var abortedTransactions []abortedTransaction
 	var remain int
 
 	switch fetchVersion {
+	case v11:
+		throttle, highWaterMark, lastStableOffset, logStartOffset, abortedTransactions, preferredReadReplica, remain, err = readFetchResponseHeaderV11(&c.rbuf, size)
 	case v10:
-		throttle, highWaterMark, remain, err = readFetchResponseHeaderV10(&c.rbuf, size)
+		throttle, highWaterMark, lastStableOffset, logStartOffset, abortedTransactions, remain, err = readFetchResponseHeaderV10(&c.rbuf, size)
 	case v5:
-		throttle, highWaterMark, remain, err = readFetchResponseHeaderV5(&c.rbuf, size)
+		throttle, highWaterMark, lastStableOffset, logStartOffset, abortedTransactions, remain, err = readFetchResponseHeaderV5(&c.rbuf, size)
 	default:
 		throttle, highWaterMark, remain, err = readFetchResponseHeaderV2(&c.rbuf, size)
 	}
@@ -883,9 +916,14 @@ func (c *Conn) ReadBatchWith(cfg ReadBatchConfig) *Batch {
 			msgs, err = newMessageSetReader(&c.rbuf, remain)
 		}
 	}
+	if err == nil && msgs.version == 2 {
+		msgs.v2.setAbortedTransactions(abortedTransactions)
+		msgs.v2.concurrency = cfg.DecompressionConcurrency
+	}
 	if err == errShortRead {
 		err = checkTimeoutErr(adjustedDeadline)
 	}
+	o.observe(err)
 	return &Batch{
 		conn:          c,
 		msgs:          msgs,
@@ -896,6 +934,9 @@ func (c *Conn) ReadBatchWith(cfg ReadBatchConfig) *Batch {
 		partition:     int(c.partition), // partition is copied to Batch to prevent race with Batch.close
 		offset:        offset,
 		highWaterMark: highWaterMark,
+		lastStable:    lastStableOffset,
+		logStart:      logStartOffset,
+		readReplica:   int(preferredReadReplica),
 		// there shouldn't be a short read on initially setting up the batch.
 		// as such, any io.EOF is re-mapped to an io.ErrUnexpectedEOF so that we
 		// don't accidentally signal that we successfully reached the end of the
@@ -970,6 +1011,49 @@ func (c *Conn) readOffset(t int64) (offset int64, err error) {
 	return
 }
 
+// readLastStableOffset returns the last stable offset of the partition used by
+// the connection, the offset below which all transactions are complete and
+// records are visible to ReadCommitted readers. Brokers which don't support
+// ListOffsets v2 predate transactions, their last stable offset is the last
+// offset of the partition.
+func (c *Conn) readLastStableOffset() (int64, error) {
+	version, err := c.negotiateVersion(listOffsets, v1, v2)
+	if err != nil {
+		return -1, err
+	}
+	if version < v2 {
+		return c.readOffset(LastOffset)
+	}
+
+	var response listOffsetResponseV2
+	err = c.readOperation(
+		func(deadline time.Time, id int32) error {
+			request := listOffsetRequestV2{ReplicaID: -1, IsolationLevel: int8(ReadCommitted)}
+			request.Topics = []listOffsetRequestTopicV1{{
+				TopicName:  c.topic,
+				Partitions: []listOffsetRequestPartitionV1{{Partition: c.partition, Time: LastOffset}},
+			}}
+			return c.writeRequest(listOffsets, v2, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize((&response).readFrom(&c.rbuf, size))
+		},
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, t := range response.Topics {
+		for _, p := range t.PartitionOffsets {
+			if p.ErrorCode != 0 {
+				return -1, Error(p.ErrorCode)
+			}
+			return p.Offset, nil
+		}
+	}
+	return -1, UnknownTopicOrPartition
+}
+
 // ReadPartitions returns the list of available partitions for the given list of
 // topics.
 //
@@ -1065,8 +1149,14 @@ func (c *Conn) WriteMessages(msgs ...Message) (int, error) {
 // operation, it either fully succeeds or fails.
 //
 // If the compression codec is not nil, the messages will be compressed.
+//
+// Brokers older than kafka 0.11 cannot store the headers of messages, writing
+// messages with headers to them fails instead of dropping the headers.
 func (c *Conn) WriteCompressedMessages(codec CompressionCodec, msgs ...Message) (nbytes int, err error) {
-	nbytes, _, _, _, err = c.writeCompressedMessages(codec, msgs...)
+	if err = checkWritePartitions(msgs); err != nil {
+		return
+	}
+	nbytes, _, _, _, _, err = c.writeCompressedMessages(codec, msgs...)
 	return
 }
 
@@ -1077,26 +1167,41 @@ func (c *Conn) WriteCompressedMessages(codec CompressionCodec, msgs ...Message)
 //
 // If the compression codec is not nil, the messages will be compressed.
 func (c *Conn) WriteCompressedMessagesAt(codec CompressionCodec, msgs ...Message) (nbytes int, partition int32, offset int64, appendTime time.Time, err error) {
-	return c.writeCompressedMessages(codec, msgs...)
+	if err = checkWritePartitions(msgs); err != nil {
+		return
+	}
+	nbytes, partition, offset, appendTime, _, err = c.writeCompressedMessages(codec, msgs...)
+	return
 }
 
-func (c *Conn) writeCompressedMessages(codec CompressionCodec, msgs ...Message) (nbytes int, partition int32, offset int64, appendTime time.Time, err error) {
+// checkWritePartitions returns an error if one of msgs has its Partition set,
+// users may believe it selects the partition that the message is written to.
+func checkWritePartitions(msgs []Message) error {
+	for _, msg := range msgs {
+		if msg.Partition != 0 {
+			return errInvalidWritePartition
+		}
+	}
+	return nil
+}
+
+// writeCompressedMessages is the implementation of WriteCompressedMessagesAt,
+// it also returns the time that the broker asks the client to wait for before
+// sending it another request because the client exceeded its quota. The
+// Partition of the messages is ignored, the Writer writes the messages that it
+// routed to the partition of the connection with their partition still set.
+func (c *Conn) writeCompressedMessages(codec CompressionCodec, msgs ...Message) (nbytes int, partition int32, offset int64, appendTime time.Time, throttle time.Duration, err error) {
 	if len(msgs) == 0 {
 		return
 	}
 
 	writeTime := time.Now()
 	for i, msg := range msgs {
-		// users may believe they can set the Topic and/or Partition
-		// on the kafka message.
+		// users may believe they can set the Topic on the kafka message.
 		if msg.Topic != "" && msg.Topic != c.topic {
 			err = errInvalidWriteTopic
 			return
 		}
-		if msg.Partition != 0 {
-			err = errInvalidWritePartition
-			return
-		}
 
 		if msg.Time.IsZero() {
 			msgs[i].Time = writeTime
@@ -1110,6 +1215,16 @@ func (c *Conn) writeCompressedMessages(codec CompressionCodec, msgs ...Message)
 		return
 	}
 
+	// the message sets of the v2 requests have no room for headers.
+	if produceVersion == v2 {
+		for _, msg := range msgs {
+			if len(msg.Headers) != 0 {
+				err = errUnsupportedHeaders
+				return
+			}
+		}
+	}
+
 	err = c.writeOperation(
 		func(deadline time.Time, id int32) error {
 			now := time.Now()
@@ -1210,9 +1325,15 @@ func (c *Conn) writeCompressedMessages(codec CompressionCodec, msgs ...Message)
 					return size, err
 				}
 
-				// The response is trailed by the throttle time, also skipping
-				// since it's not interesting here.
-				return discardInt32(r, size)
+				// The response is trailed by the throttle time. Up to v5 the
+				// broker applies it itself by delaying the response (KIP-219),
+				// so it is only reported to the caller for v7 responses.
+				var throttleTimeMS int32
+				size, err = readInt32(r, size, &throttleTimeMS)
+				if produceVersion == v7 {
+					throttle = time.Duration(throttleTimeMS) * time.Millisecond
+				}
+				return size, err
 			}))
 		},
 	)
@@ -1250,6 +1371,23 @@ func (c *Conn) writeRequest(apiKey apiKey, apiVersion apiVersion, correlationID
 	return c.wbuf.Flush()
 }
 
+// writeFlexibleRequest writes a request using a flexible version of the kafka
+// protocol, the request header of those versions is terminated by tagged
+// fields. The matching response headers are also terminated by tagged fields,
+// which readFlexibleResponseHeader takes care of.
+func (c *Conn) writeFlexibleRequest(apiKey apiKey, apiVersion apiVersion, correlationID int32, req request) error {
+	hdr := c.requestHeader(apiKey, apiVersion, correlationID)
+	hdr.Size = (hdr.size() + sizeofTaggedFields() + req.size()) - 4
+	hdr.writeTo(&c.wb)
+	c.wb.writeTaggedFields()
+	req.writeTo(&c.wb)
+	return c.wbuf.Flush()
+}
+
+func (c *Conn) readFlexibleResponseHeader(size int) (int, error) {
+	return readTaggedFields(&c.rbuf, size)
+}
+
 func (c *Conn) readResponse(size int, res interface{}) error {
 	size, err := read(&c.rbuf, size, res)
 	switch err.(type) {
@@ -1303,7 +1441,12 @@ func (c *Conn) concurrency() int {
 	return int(atomic.LoadInt32(&c.inflight))
 }
 
-func (c *Conn) do(d *connDeadline, write func(time.Time, int32) error, read func(time.Time, int) error) error {
+func (c *Conn) do(d *connDeadline, write func(time.Time, int32) error, read func(time.Time, int) error) (err error) {
+	if o := c.observeRequest(); o != nil {
+		write, read = o.write(write), o.read(read)
+		defer func() { o.observe(err) }()
+	}
+
 	id, err := c.doRequest(d, write)
 	if err != nil {
 		return err
@@ -1399,7 +1542,7 @@ func (c *Conn) requestHeader(apiKey apiKey, apiVersion apiVersion, correlationID
 	}
 }
 
-func (c *Conn) ApiVersions() ([]ApiVersion, error) {
+func (c *Conn) ApiVersions() (_ []ApiVersion, err error) {
 	deadline := &c.rdeadline
 
 	if deadline.deadline().IsZero() {
@@ -1411,7 +1554,10 @@ func (c *Conn) ApiVersions() ([]ApiVersion, error) {
 		deadline = &c.wdeadline
 	}
 
-	id, err := c.doRequest(deadline, func(_ time.Time, id int32) error {
+	o := c.observeRequest()
+	defer func() { o.observe(err) }()
+
+	id, err := c.doRequest(deadline, o.write(func(_ time.Time, id int32) error {
 		h := requestHeader{
 			ApiKey:        int16(apiVersions),
 			ApiVersion:    int16(v0),
@@ -1421,7 +1567,7 @@ func (c *Conn) ApiVersions() ([]ApiVersion, error) {
 		h.Size = (h.size() - 4)
 		h.writeTo(&c.wb)
 		return c.wbuf.Flush()
-	})
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -1431,6 +1577,7 @@ func (c *Conn) ApiVersions() ([]ApiVersion, error) {
 		return nil, err
 	}
 	defer lock.Unlock()
+	o.received(size)
 
 	var errorCode int16
 	if size, err = readInt16(&c.rbuf, size, &errorCode); err != nil {
@@ -7,6 +7,7 @@ import (
 	"io"
 	"math/rand"
 	"net"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -167,6 +168,12 @@ func TestConn(t *testing.T) {
 			function: testConnWriteBatchReadSequentially,
 		},
 
+		{
+			scenario:   "writing and reading messages with repeated headers should preserve them",
+			function:   testConnWriteReadHeaders,
+			minVersion: "0.11.0",
+		},
+
 		{
 			scenario: "writing and reading messages concurrently should preserve the order",
 			function: testConnWriteReadConcurrently,
@@ -519,6 +526,29 @@ func testConnWriteBatchReadSequentially(t *testing.T, conn *Conn) {
 	}
 }
 
+func testConnWriteReadHeaders(t *testing.T, conn *Conn) {
+	msgs := makeTestSequence(3)
+	msgs[0].Headers = []Header{{Key: "hop", Value: []byte("a")}, {Key: "hop", Value: []byte("b")}}
+	msgs[2].Headers = []Header{{Key: "hop", Value: []byte("c")}, {Key: "trace", Value: []byte("1")}, {Key: "hop", Value: []byte("d")}}
+
+	if _, err := conn.WriteMessages(msgs...); err != nil {
+		t.Fatal(err)
+	}
+
+	batch := conn.ReadBatch(1, 10e6)
+	defer batch.Close()
+
+	for i := range msgs {
+		msg, err := batch.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(headerPairs(msgs[i].Headers), headerPairs(msg.Headers)) {
+			t.Errorf("bad message headers at offset %d: %+v != %+v", i, msg.Headers, msgs[i].Headers)
+		}
+	}
+}
+
 func testConnReadWatermarkFromBatch(t *testing.T, conn *Conn) {
 	if _, err := conn.WriteMessages(makeTestSequence(10)...); err != nil {
 		t.Fatal(err)
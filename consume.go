@@ -0,0 +1,339 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Consume reads messages from the reader and passes them to handler, calling
+// it from one goroutine per partition: the messages of a partition are handled
+// in order, one call at a time, while the partitions are handled concurrently.
+// Each call receives the messages of the partition fetched since the previous
+// call returned. With a GroupTopicPattern, the partitions of each topic are
+// handled separately, the topic of a call is the Topic of its messages.
+//
+// When the reader is part of a consumer group, the messages are committed once
+// the handler returns nil. When the handler returns an error, it is called
+// again with the same messages, up to ConsumeMaxAttempts times with a backoff
+// between ConsumeBackoffMin and ConsumeBackoffMax. After that, the partition is
+// paused and the other partitions keep being handled. Resuming the partition
+// with Resume calls the handler again with the messages that failed.
+//
+// When partitions are revoked by a rebalance of the group, the reader waits for
+// the handlers running on them to return and commits their messages before
+// the partitions are assigned to other members, up to the rebalance timeout.
+// The messages of the revoked partitions which were not handled yet are
+// dropped, the members which the partitions are assigned to read them again.
+//
+// Partitions whose handler falls behind by more than QueueCapacity messages
+// are paused until it catches up, they are listed by Paused meanwhile.
+//
+// Consume returns when ctx is cancelled or when fetching messages fails, after
+// the running handlers returned. The handlers are called with ctx, messages
+// which were handled but failed to be committed because ctx was cancelled are
+// delivered again. The program must not call the other methods which read
+// messages while Consume runs.
+func (r *Reader) Consume(ctx context.Context, handler func(ctx context.Context, partition int, msgs []Message) error) error {
+	c := &consumer{
+		reader:     r,
+		handler:    handler,
+		ctx:        ctx,
+		partitions: make(map[topicPartition]*partitionConsumer),
+		revoked:    make(map[topicPartition]int64),
+	}
+
+	r.mutex.Lock()
+	if r.consumer != nil {
+		r.mutex.Unlock()
+		return errors.New("kafka.(*Reader).Consume: the reader is already consumed by another call")
+	}
+	r.consumer = c
+	r.mutex.Unlock()
+
+	defer func() {
+		r.mutex.Lock()
+		r.consumer = nil
+		r.mutex.Unlock()
+		c.stop()
+	}()
+
+	for {
+		msgs, version, err := r.fetchMessages(ctx, r.config.QueueCapacity)
+		if err != nil {
+			return err
+		}
+		c.dispatch(version, msgs)
+	}
+}
+
+// consumer dispatches the messages read by Consume to the goroutines handling
+// each partition.
+type consumer struct {
+	reader  *Reader
+	handler func(context.Context, int, []Message) error
+	ctx     context.Context
+	join    sync.WaitGroup
+
+	// revoked holds, for each partition revoked from the reader, the version
+	// of the reader when it was revoked. The messages of versions up to that
+	// one were fetched before the partition was revoked and are dropped.
+	mutex      sync.Mutex
+	partitions map[topicPartition]*partitionConsumer
+	revoked    map[topicPartition]int64
+}
+
+// partitionConsumer is the goroutine handling the messages of a partition,
+// its context is cancelled when the partition is revoked or Consume returns.
+type partitionConsumer struct {
+	topicPartition
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
+
+	// pending holds the messages waiting to be handled and size their count,
+	// the partition is throttled when size reaches the queue capacity of the
+	// reader, and failed when the handler exhausted its attempts. Both pause
+	// the partition. These fields are synchronized on the consumer mutex.
+	pending   []consumeBatch
+	size      int
+	throttled bool
+	failed    bool
+}
+
+type consumeBatch struct {
+	version int64
+	msgs    []Message
+}
+
+// dispatch appends msgs, fetched by the given version of the reader, to the
+// pending messages of their partitions.
+func (c *consumer) dispatch(version int64, msgs []Message) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for len(msgs) != 0 {
+		tp := topicPartition{topic: msgs[0].Topic, partition: msgs[0].Partition}
+		n := 1
+		for n < len(msgs) && msgs[n].Topic == tp.topic && msgs[n].Partition == tp.partition {
+			n++
+		}
+		batch := msgs[:n]
+		msgs = msgs[n:]
+
+		if version <= c.revoked[tp] {
+			continue
+		}
+
+		p := c.partitions[tp]
+		if p == nil {
+			p = c.start(tp)
+		}
+		p.pending = append(p.pending, consumeBatch{version: version, msgs: batch})
+		p.size += len(batch)
+
+		// the partition is not throttled when the program paused it, so
+		// resuming it when the handler catches up doesn't undo the pause.
+		if p.size >= c.reader.config.QueueCapacity && !p.throttled && !p.failed && !c.reader.paused.isPaused(tp) {
+			p.throttled = true
+			c.reader.paused.pause(tp)
+		}
+
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// start starts the goroutine handling the partition, it must be called with
+// the mutex held.
+func (c *consumer) start(tp topicPartition) *partitionConsumer {
+	ctx, cancel := context.WithCancel(c.ctx)
+	p := &partitionConsumer{
+		topicPartition: tp,
+		ctx:            ctx,
+		cancel:         cancel,
+		wake:           make(chan struct{}, 1),
+		done:           make(chan struct{}),
+	}
+	c.partitions[tp] = p
+
+	c.join.Add(1)
+	go c.run(p)
+	return p
+}
+
+func (c *consumer) run(p *partitionConsumer) {
+	defer c.join.Done()
+	defer close(p.done)
+
+	for {
+		batch, ok := c.next(p)
+		if !ok {
+			return
+		}
+		if !c.handle(p, batch) {
+			return
+		}
+	}
+}
+
+// next waits for pending messages of the partition and returns those fetched
+// by the same version of the reader as the first one.
+func (c *consumer) next(p *partitionConsumer) (consumeBatch, bool) {
+	for {
+		c.mutex.Lock()
+		if len(p.pending) != 0 {
+			batch := p.pending[0]
+			n := 1
+			for n < len(p.pending) && p.pending[n].version == batch.version {
+				n++
+			}
+			if n > 1 {
+				msgs := make([]Message, 0, p.size)
+				for _, b := range p.pending[:n] {
+					msgs = append(msgs, b.msgs...)
+				}
+				batch.msgs = msgs
+			}
+			p.pending = p.pending[n:]
+			p.size -= len(batch.msgs)
+
+			if p.throttled && p.size < c.reader.config.QueueCapacity {
+				p.throttled = false
+				c.reader.resume(p.topicPartition)
+			}
+			c.mutex.Unlock()
+			return batch, true
+		}
+		c.mutex.Unlock()
+
+		select {
+		case <-p.wake:
+		case <-p.ctx.Done():
+			return consumeBatch{}, false
+		}
+	}
+}
+
+// handle calls the handler with the batch until it succeeds and commits the
+// messages, it returns false if the partition was stopped first.
+func (c *consumer) handle(p *partitionConsumer, batch consumeBatch) bool {
+	r := c.reader
+
+	for attempt := 0; c.current(p, batch); attempt++ {
+		if attempt != 0 && !sleep(p.ctx, backoff(attempt, r.config.ConsumeBackoffMin, r.config.ConsumeBackoffMax)) {
+			return false
+		}
+
+		err := c.handler(c.ctx, p.partition, batch.msgs)
+		if err == nil {
+			if r.useConsumerGroup() {
+				if err := r.CommitMessages(c.ctx, batch.msgs...); err != nil {
+					r.withErrorLogger(func(log Logger) {
+						log.Printf("failed to commit the messages handled on partition %d of %s: %s", p.partition, p.topic, err)
+					})
+				}
+			}
+			return true
+		}
+
+		r.stats.errors.observe(1)
+		r.withErrorLogger(func(log Logger) {
+			log.Printf("the handler of partition %d of %s failed on %d messages from offset %d: %s", p.partition, p.topic, len(batch.msgs), batch.msgs[0].Offset, err)
+		})
+
+		if attempt+1 < r.config.ConsumeMaxAttempts {
+			continue
+		}
+
+		c.mutex.Lock()
+		p.throttled, p.failed = false, true
+		r.paused.pause(p.topicPartition)
+		c.mutex.Unlock()
+
+		r.withErrorLogger(func(log Logger) {
+			log.Printf("paused partition %d of %s after %d failed attempts, resume it to retry", p.partition, p.topic, attempt+1)
+		})
+
+		if !r.paused.wait(p.ctx, p.topicPartition) || p.ctx.Err() != nil {
+			return false
+		}
+
+		c.mutex.Lock()
+		p.failed = false
+		c.mutex.Unlock()
+		attempt = -1
+	}
+
+	// the batch was fetched before the partition was revoked or the offset
+	// of the reader was changed.
+	return true
+}
+
+// current reports whether the batch is still valid: its partition was not
+// revoked since it was fetched, and the offset of the reader was not changed.
+func (c *consumer) current(p *partitionConsumer, batch consumeBatch) bool {
+	c.mutex.Lock()
+	revoked := batch.version <= c.revoked[p.topicPartition]
+	c.mutex.Unlock()
+
+	if revoked {
+		return false
+	}
+
+	r := c.reader
+	r.mutex.Lock()
+	version := r.version
+	r.mutex.Unlock()
+
+	return batch.version == version || r.isAssigned(readerMessage{version: batch.version, topic: p.topic, partition: p.partition})
+}
+
+// drain stops the handling of the partitions revoked at the given version of
+// the reader, waiting for the handlers running on them to return.
+func (c *consumer) drain(partitions []topicPartition, version int64) {
+	var done []chan struct{}
+
+	c.mutex.Lock()
+	for _, tp := range partitions {
+		c.revoked[tp] = version
+		if p := c.partitions[tp]; p != nil {
+			delete(c.partitions, tp)
+			c.release(p)
+			done = append(done, p.done)
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, ch := range done {
+		<-ch
+	}
+}
+
+// stop stops the handling of all the partitions, waiting for the running
+// handlers to return.
+func (c *consumer) stop() {
+	c.mutex.Lock()
+	for tp, p := range c.partitions {
+		delete(c.partitions, tp)
+		c.release(p)
+	}
+	c.mutex.Unlock()
+
+	c.join.Wait()
+}
+
+// release drops the pending messages of the partition, resumes it if it was
+// paused by the consumer and cancels its context. It must be called with the
+// mutex held.
+func (c *consumer) release(p *partitionConsumer) {
+	p.pending, p.size = nil, 0
+	if p.throttled || p.failed {
+		p.throttled, p.failed = false, false
+		c.reader.resume(p.topicPartition)
+	}
+	p.cancel()
+}
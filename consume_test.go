@@ -0,0 +1,224 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newConsumeTestReader(queueCapacity int) *Reader {
+	return &Reader{
+		config: ReaderConfig{
+			Topic:              "a",
+			QueueCapacity:      queueCapacity,
+			ConsumeMaxAttempts: 2,
+			ConsumeBackoffMin:  time.Millisecond,
+			ConsumeBackoffMax:  time.Millisecond,
+		},
+		msgs:    make(chan readerMessage, 10),
+		resumed: make(chan struct{}, 1),
+		version: 1,
+		stats:   &readerStats{},
+	}
+}
+
+type consumeCall struct {
+	partition int
+	offsets   []int64
+}
+
+func makeConsumeCall(partition int, msgs []Message) consumeCall {
+	call := consumeCall{partition: partition}
+	for _, m := range msgs {
+		call.offsets = append(call.offsets, m.Offset)
+	}
+	return call
+}
+
+func expectConsumeCall(t *testing.T, calls <-chan consumeCall, expected consumeCall) {
+	t.Helper()
+	select {
+	case call := <-calls:
+		if !reflect.DeepEqual(expected, call) {
+			t.Errorf("expected the handler to be called with %+v, got %+v", expected, call)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the handler was not called with %+v", expected)
+	}
+}
+
+func TestReaderConsume(t *testing.T) {
+	r := newConsumeTestReader(10)
+	calls := make(chan consumeCall, 10)
+	release := make(chan struct{})
+	failing := int32(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- r.Consume(ctx, func(ctx context.Context, partition int, msgs []Message) error {
+			calls <- makeConsumeCall(partition, msgs)
+			switch partition {
+			case 0:
+				if msgs[0].Offset == 0 {
+					<-release
+				}
+			case 2:
+				if atomic.LoadInt32(&failing) != 0 {
+					return errors.New("failed")
+				}
+			}
+			return nil
+		})
+	}()
+
+	send := func(partition int, offset int64) {
+		r.msgs <- readerMessage{version: 1, partition: partition, message: Message{Partition: partition, Offset: offset}}
+	}
+
+	// partition 1 is handled while the handler of partition 0 is blocked.
+	send(0, 0)
+	expectConsumeCall(t, calls, consumeCall{partition: 0, offsets: []int64{0}})
+	send(1, 0)
+	send(1, 1)
+	expectConsumeCall(t, calls, consumeCall{partition: 1, offsets: []int64{0, 1}})
+
+	// the messages fetched while the handler is running are passed to the
+	// next call.
+	send(0, 1)
+	send(0, 2)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	expectConsumeCall(t, calls, consumeCall{partition: 0, offsets: []int64{1, 2}})
+
+	// the partition is paused once the handler exhausted its attempts, and
+	// resuming it retries the same messages.
+	send(2, 0)
+	expectConsumeCall(t, calls, consumeCall{partition: 2, offsets: []int64{0}})
+	expectConsumeCall(t, calls, consumeCall{partition: 2, offsets: []int64{0}})
+	for deadline := time.Now().Add(time.Second); !r.paused.isPaused(topicPartition{partition: 2}); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the partition was not paused after the attempts of the handler failed")
+		}
+	}
+	atomic.StoreInt32(&failing, 0)
+	r.Resume(2)
+	expectConsumeCall(t, calls, consumeCall{partition: 2, offsets: []int64{0}})
+
+	cancel()
+	select {
+	case err := <-consumed:
+		if err != context.Canceled {
+			t.Errorf("expected Consume to return the error of the context, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Consume did not return after its context was cancelled")
+	}
+	if n := r.stats.errors.snapshot(); n != 2 {
+		t.Errorf("expected the 2 failures of the handler to be counted, got %d", n)
+	}
+}
+
+func TestConsumerDrain(t *testing.T) {
+	r := newConsumeTestReader(2)
+	calls := make(chan consumeCall, 10)
+	release := make(chan struct{})
+
+	c := &consumer{
+		reader: r,
+		ctx:    context.Background(),
+		handler: func(ctx context.Context, partition int, msgs []Message) error {
+			calls <- makeConsumeCall(partition, msgs)
+			if msgs[0].Offset == 0 {
+				<-release
+			}
+			return nil
+		},
+		partitions: make(map[topicPartition]*partitionConsumer),
+		revoked:    make(map[topicPartition]int64),
+	}
+	defer c.stop()
+
+	c.dispatch(1, []Message{{Offset: 0}})
+	expectConsumeCall(t, calls, consumeCall{offsets: []int64{0}})
+
+	// the partition is throttled while the handler falls behind.
+	c.dispatch(1, []Message{{Offset: 1}, {Offset: 2}})
+	if !r.paused.isPaused(topicPartition{partition: 0}) {
+		t.Error("expected the partition to be paused while its handler is behind")
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		c.drain([]topicPartition{{partition: 0}}, 1)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatal("the partition was drained while its handler was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-drained
+
+	if r.paused.isPaused(topicPartition{partition: 0}) {
+		t.Error("the partition stayed paused after it was drained")
+	}
+
+	// the messages of the revoked partition are dropped, until it is assigned
+	// again to a new version of the reader.
+	c.dispatch(1, []Message{{Offset: 3}})
+	r.mutex.Lock()
+	r.version = 2
+	r.mutex.Unlock()
+	c.dispatch(2, []Message{{Offset: 4}})
+	expectConsumeCall(t, calls, consumeCall{offsets: []int64{4}})
+
+	select {
+	case call := <-calls:
+		t.Errorf("unexpected call of the handler with %+v", call)
+	default:
+	}
+}
+
+func TestConsumerTopics(t *testing.T) {
+	r := newConsumeTestReader(10)
+	topics := make(chan string, 10)
+	release := make(chan struct{})
+
+	c := &consumer{
+		reader: r,
+		ctx:    context.Background(),
+		handler: func(ctx context.Context, partition int, msgs []Message) error {
+			topics <- msgs[0].Topic
+			if msgs[0].Topic == "a" {
+				<-release
+			}
+			return nil
+		},
+		partitions: make(map[topicPartition]*partitionConsumer),
+		revoked:    make(map[topicPartition]int64),
+	}
+	defer c.stop()
+	defer close(release)
+
+	// the partitions of different topics with the same number are handled
+	// separately.
+	c.dispatch(1, []Message{{Topic: "a", Partition: 0}, {Topic: "b", Partition: 0}})
+
+	found := map[string]bool{}
+	for len(found) != 2 {
+		select {
+		case topic := <-topics:
+			found[topic] = true
+		case <-time.After(time.Second):
+			t.Fatalf("expected the partitions of topics a and b to be handled, got %v", found)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -55,6 +57,10 @@ const (
 	// defaultPartitionWatchTime contains the amount of time the kafka-go will wait to
 	// query the brokers looking for partition changes.
 	defaultPartitionWatchTime = 5 * time.Second
+
+	// defaultTopicRefreshInterval contains the amount of time the kafka-go will
+	// wait to list the topics matching the topic pattern of a group.
+	defaultTopicRefreshInterval = time.Minute
 )
 
 // ConsumerGroupConfig is a configuration object used to create new instances of
@@ -76,6 +82,20 @@ type ConsumerGroupConfig struct {
 	// for more complex use cases.
 	Topics []string
 
+	// TopicPattern optionally selects the topics consumed by the group by
+	// matching their names, instead of listing them in Topics. The topics of
+	// the cluster are listed every TopicRefreshInterval, and the group is
+	// rebalanced when topics matching the pattern are created or deleted.
+	// Internal topics, whose names begin with two underscores, are never
+	// matched.
+	TopicPattern *regexp.Regexp
+
+	// TopicRefreshInterval indicates how often the topics matching the
+	// TopicPattern are listed.
+	//
+	// Default: 1m
+	TopicRefreshInterval time.Duration
+
 	// GroupBalancers is the priority-ordered list of client-side consumer group
 	// balancing strategies that will be offered to the coordinator.  The first
 	// strategy that all group members support will be chosen by the leader.
@@ -106,6 +126,18 @@ type ConsumerGroupConfig struct {
 	// Default: 30s
 	SessionTimeout time.Duration
 
+	// GroupInstanceID optionally makes the consumer a static member of the
+	// group, identified by this ID across restarts.  The coordinator keeps the
+	// partitions of a static member assigned to it when it disconnects, and
+	// only rebalances the group if the member does not rejoin within the
+	// SessionTimeout.  Static members do not leave the group when they are
+	// closed.
+	//
+	// The ID must be unique within the group: when another member joins with
+	// the same ID, the coordinator fences this one and the group stops with a
+	// *GroupInstanceFencedError.  Static membership requires kafka 2.3+.
+	GroupInstanceID string
+
 	// RebalanceTimeout optionally sets the length of time the coordinator will wait
 	// for members to join as part of a rebalance.  For kafka servers under higher
 	// load, it may be useful to set this value higher.
@@ -156,10 +188,14 @@ func (config *ConsumerGroupConfig) Validate() error {
 		return errors.New("cannot create a consumer group with an empty list of broker addresses")
 	}
 
-	if len(config.Topics) == 0 {
+	if len(config.Topics) == 0 && config.TopicPattern == nil {
 		return errors.New("cannot create a consumer group without a topic")
 	}
 
+	if len(config.Topics) != 0 && config.TopicPattern != nil {
+		return errors.New("cannot create a consumer group with both a list of topics and a topic pattern")
+	}
+
 	if config.ID == "" {
 		return errors.New("cannot create a consumer group without an ID")
 	}
@@ -187,6 +223,10 @@ func (config *ConsumerGroupConfig) Validate() error {
 		config.PartitionWatchInterval = defaultPartitionWatchTime
 	}
 
+	if config.TopicRefreshInterval == 0 {
+		config.TopicRefreshInterval = defaultTopicRefreshInterval
+	}
+
 	if config.RebalanceTimeout == 0 {
 		config.RebalanceTimeout = defaultRebalanceTimeout
 	}
@@ -223,6 +263,10 @@ func (config *ConsumerGroupConfig) Validate() error {
 		return errors.New(fmt.Sprintf("PartitionWachInterval out of bounds %d", config.PartitionWatchInterval))
 	}
 
+	if config.TopicRefreshInterval < 0 {
+		return errors.New(fmt.Sprintf("TopicRefreshInterval out of bounds %d", config.TopicRefreshInterval))
+	}
+
 	if config.StartOffset == 0 {
 		config.StartOffset = FirstOffset
 	}
@@ -241,6 +285,9 @@ func (config *ConsumerGroupConfig) Validate() error {
 // PartitionAssignment represents the starting state of a partition that has
 // been assigned to a consumer.
 type PartitionAssignment struct {
+	// Topic is the topic of the partition.
+	Topic string
+
 	// ID is the partition ID.
 	ID int
 
@@ -250,6 +297,14 @@ type PartitionAssignment struct {
 	// is the first time the partition have been assigned to a member of the
 	// group.
 	Offset int64
+
+	// Metadata is the metadata string committed alongside Offset, it is empty
+	// when none was committed.
+	Metadata string
+}
+
+func (a PartitionAssignment) topicPartition() topicPartition {
+	return topicPartition{topic: a.Topic, partition: a.ID}
 }
 
 // genCtx adapts the done channel of the generation to a context.Context.  This
@@ -299,8 +354,28 @@ type Generation struct {
 	// assignments are grouped by topic.
 	Assignments map[string][]PartitionAssignment
 
+	// Protocol is the rebalance protocol of the GroupBalancer selected by the
+	// group for this Generation.
+	Protocol RebalanceProtocol
+
+	// Revoked holds topic => partitions that were assigned to the member in
+	// the previous generation and are not part of Assignments anymore, it is
+	// only set when Protocol is CooperativeRebalance.  The partitions that are
+	// assigned in both generations were consumed during the rebalance and
+	// should keep on being consumed.
+	//
+	// When Revoked is not empty, the program must stop consuming those
+	// partitions, commit their offsets, then end the generation, for example
+	// by returning from a function passed to Start.  The member rejoins the
+	// group and the partitions are assigned to other members in the next
+	// generation.
+	Revoked map[string][]int
+
 	conn coordinator
 
+	// instanceID is the GroupInstanceID of static members, empty otherwise.
+	instanceID string
+
 	once sync.Once
 	done chan struct{}
 	wg   sync.WaitGroup
@@ -310,6 +385,25 @@ type Generation struct {
 	logError        func(func(Logger))
 }
 
+// assignments returns the partitions assigned to the generation, sorted by
+// topic and partition.
+func (g *Generation) assignments() []PartitionAssignment {
+	var assignments []PartitionAssignment
+	for topic, partitions := range g.Assignments {
+		for _, assignment := range partitions {
+			assignment.Topic = topic
+			assignments = append(assignments, assignment)
+		}
+	}
+	sort.Slice(assignments, func(i, j int) bool {
+		if assignments[i].Topic != assignments[j].Topic {
+			return assignments[i].Topic < assignments[j].Topic
+		}
+		return assignments[i].ID < assignments[j].ID
+	})
+	return assignments
+}
+
 // close stops the generation and waits for all functions launched via Start to
 // terminate.
 func (g *Generation) close() {
@@ -349,17 +443,33 @@ func (g *Generation) Start(fn func(ctx context.Context)) {
 // consumer group coordinator.  This can be used to reset the consumer to
 // explicit offsets.
 func (g *Generation) CommitOffsets(offsets map[string]map[int]int64) error {
+	commits := make(map[string][]OffsetCommit, len(offsets))
+	for topic, partitions := range offsets {
+		for partition, offset := range partitions {
+			commits[topic] = append(commits[topic], OffsetCommit{Partition: partition, Offset: offset})
+		}
+	}
+	return g.CommitOffsetsWithMetadata(commits)
+}
+
+// CommitOffsetsWithMetadata commits the provided offsets like CommitOffsets,
+// the metadata string of each commit is stored alongside its offset and
+// returned in PartitionAssignment.Metadata when the partition is assigned
+// again.  The commit fails with an *OffsetMetadataTooLargeError if a metadata
+// string exceeds the limit of the brokers.
+func (g *Generation) CommitOffsetsWithMetadata(offsets map[string][]OffsetCommit) error {
 	if len(offsets) == 0 {
 		return nil
 	}
 
 	topics := make([]offsetCommitRequestV2Topic, 0, len(offsets))
-	for topic, partitions := range offsets {
+	for topic, commits := range offsets {
 		t := offsetCommitRequestV2Topic{Topic: topic}
-		for partition, offset := range partitions {
+		for _, commit := range commits {
 			t.Partitions = append(t.Partitions, offsetCommitRequestV2Partition{
-				Partition: int32(partition),
-				Offset:    offset,
+				Partition: int32(commit.Partition),
+				Offset:    commit.Offset,
+				Metadata:  commit.Metadata,
 			})
 		}
 		topics = append(topics, t)
@@ -374,6 +484,9 @@ func (g *Generation) CommitOffsets(offsets map[string]map[int]int64) error {
 	}
 
 	_, err := g.conn.offsetCommit(request)
+	if err == OffsetMetadataTooLarge {
+		err = offsetMetadataTooLarge(request)
+	}
 	if err == nil {
 		// if logging is enabled, print out the partitions that were committed.
 		g.log(func(l Logger) {
@@ -391,6 +504,20 @@ func (g *Generation) CommitOffsets(offsets map[string]map[int]int64) error {
 	return err
 }
 
+// offsetMetadataTooLarge returns the error of a commit request rejected because
+// of the size of its metadata, reporting its largest metadata.
+func offsetMetadataTooLarge(request offsetCommitRequestV2) error {
+	err := &OffsetMetadataTooLargeError{GroupID: request.GroupID, Size: -1}
+	for _, t := range request.Topics {
+		for _, p := range t.Partitions {
+			if len(p.Metadata) > err.Size {
+				err.Topic, err.Partition, err.Size = t.Topic, int(p.Partition), len(p.Metadata)
+			}
+		}
+	}
+	return err
+}
+
 // heartbeatLoop checks in with the consumer group coordinator at the provided
 // interval.  It exits if it ever encounters an error, which would signal the
 // end of the generation.
@@ -411,12 +538,7 @@ func (g *Generation) heartbeatLoop(interval time.Duration) {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				_, err := g.conn.heartbeat(heartbeatRequestV0{
-					GroupID:      g.GroupID,
-					GenerationID: g.ID,
-					MemberID:     g.MemberID,
-				})
-				if err != nil {
+				if err := g.heartbeat(); err != nil {
 					return
 				}
 			}
@@ -424,6 +546,30 @@ func (g *Generation) heartbeatLoop(interval time.Duration) {
 	})
 }
 
+// heartbeat sends a heartbeat to the coordinator, static members use version 3
+// of the API to send their group instance ID.
+func (g *Generation) heartbeat() error {
+	if g.instanceID == "" {
+		_, err := g.conn.heartbeat(heartbeatRequestV0{
+			GroupID:      g.GroupID,
+			GenerationID: g.ID,
+			MemberID:     g.MemberID,
+		})
+		return err
+	}
+
+	response, err := g.conn.heartbeatV3(heartbeatRequestV3{
+		GroupID:         g.GroupID,
+		GenerationID:    g.ID,
+		MemberID:        g.MemberID,
+		GroupInstanceID: &g.instanceID,
+	})
+	if err == nil && response.ErrorCode != 0 {
+		err = Error(response.ErrorCode)
+	}
+	return err
+}
+
 // partitionWatcher queries kafka and watches for partition changes, triggering
 // a rebalance if changes are found. Similar to heartbeat it's okay to return on
 // error here as if you are unable to ask a broker for basic metadata you're in
@@ -480,6 +626,82 @@ func (g *Generation) partitionWatcher(interval time.Duration, topic string) {
 	})
 }
 
+// topicWatcher lists the topics matching the pattern at the provided interval,
+// and exits to trigger a rebalance when they differ from the topics of the
+// generation. Errors are handled like in partitionWatcher.
+func (g *Generation) topicWatcher(interval time.Duration, pattern *regexp.Regexp, topics []string) {
+	g.Start(func(ctx context.Context) {
+		g.log(func(l Logger) {
+			l.Printf("started topic watcher for group, %v, pattern %v [%v]", g.GroupID, pattern, interval)
+		})
+		defer g.log(func(l Logger) {
+			l.Printf("stopped topic watcher for group, %v, pattern %v", g.GroupID, pattern)
+		})
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				matched, err := matchTopics(g.conn, pattern)
+				if err != nil {
+					g.logError(func(l Logger) {
+						l.Printf("Problem listing topics while checking for changes, %v", err)
+					})
+					if _, ok := err.(Error); ok {
+						continue
+					}
+					return
+				}
+				if !stringsEqual(matched, topics) {
+					g.log(func(l Logger) {
+						l.Printf("Topic changes found, reblancing group: %v.", g.GroupID)
+					})
+					return
+				}
+			}
+		}
+	})
+}
+
+// matchTopics returns the sorted list of the topics of the cluster whose names
+// match the pattern, excluding the internal topics.
+func matchTopics(conn coordinator, pattern *regexp.Regexp) ([]string, error) {
+	partitions, err := conn.ReadPartitions()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make(map[string]struct{})
+	for _, p := range partitions {
+		if !strings.HasPrefix(p.Topic, "__") && pattern.MatchString(p.Topic) {
+			matched[p.Topic] = struct{}{}
+		}
+	}
+
+	topics := make([]string, 0, len(matched))
+	for topic := range matched {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 var _ coordinator = &Conn{}
 
 // coordinator is a subset of the functionality in Conn in order to facilitate
@@ -492,6 +714,9 @@ type coordinator interface {
 	syncGroup(syncGroupRequestV0) (syncGroupResponseV0, error)
 	leaveGroup(leaveGroupRequestV0) (leaveGroupResponseV0, error)
 	heartbeat(heartbeatRequestV0) (heartbeatResponseV0, error)
+	joinGroupV5(joinGroupRequestV5) (joinGroupResponseV5, error)
+	syncGroupV3(syncGroupRequestV3) (syncGroupResponseV3, error)
+	heartbeatV3(heartbeatRequestV3) (heartbeatResponseV3, error)
 	offsetFetch(offsetFetchRequestV1) (offsetFetchResponseV1, error)
 	offsetCommit(offsetCommitRequestV2) (offsetCommitResponseV2, error)
 	ReadPartitions(...string) ([]Partition, error)
@@ -508,9 +733,11 @@ func NewConsumerGroup(config ConsumerGroupConfig) (*ConsumerGroup, error) {
 
 	cg := &ConsumerGroup{
 		config: config,
+		topics: config.Topics,
 		next:   make(chan *Generation),
 		errs:   make(chan error),
 		done:   make(chan struct{}),
+		failed: make(chan struct{}),
 	}
 	cg.wg.Add(1)
 	go func() {
@@ -530,6 +757,25 @@ type ConsumerGroup struct {
 	next   chan *Generation
 	errs   chan error
 
+	// topics is the list of topics consumed by the current generation, it
+	// only changes when the group has a topic pattern.
+	topics []string
+
+	// protocol is the rebalance protocol of the balancer selected when the
+	// member last joined the group.  owned holds the partitions assigned to
+	// the member in the generation ownedGeneration, they are sent to the
+	// leader when the member rejoins: in the owned partitions of the metadata
+	// when the protocol is cooperative, and in the user data of the sticky
+	// balancers.
+	protocol        RebalanceProtocol
+	owned           map[string][]int32
+	ownedGeneration int32
+
+	// failed is closed when the group stopped with the fatal error held
+	// in err, which Next returns from then on.
+	failed chan struct{}
+	err    error
+
 	closeOnce sync.Once
 	wg        sync.WaitGroup
 	done      chan struct{}
@@ -537,6 +783,7 @@ type ConsumerGroup struct {
 
 // Close terminates the current generation by causing this member to leave and
 // releases all local resources used to participate in the consumer group.
+// Static members do not leave the group, see GroupInstanceID.
 // Close will also end the current generation if it is still active.
 func (cg *ConsumerGroup) Close() error {
 	cg.closeOnce.Do(func() {
@@ -554,12 +801,16 @@ func (cg *ConsumerGroup) Close() error {
 // here.
 //
 // If the ConsumerGroup has been closed, then Next will return ErrGroupClosed.
+// If the member was fenced by the coordinator, then Next will return a
+// *GroupInstanceFencedError.
 func (cg *ConsumerGroup) Next(ctx context.Context) (*Generation, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	case <-cg.done:
 		return nil, ErrGroupClosed
+	case <-cg.failed:
+		return nil, cg.err
 	case err := <-cg.errs:
 		return nil, err
 	case next := <-cg.next:
@@ -596,14 +847,31 @@ func (cg *ConsumerGroup) run() {
 			// to join the group will then be subject to the rebalance
 			// timeout, so the broker will be responsible for throttling
 			// this loop.
+		case FencedInstanceID:
+			// another member joined with the same group instance ID, so
+			// rejoining would fence it in turn.  stop the group for good
+			// instead of having both members take the partitions from
+			// each other.
+			cg.err = &GroupInstanceFencedError{
+				GroupID:         cg.config.ID,
+				GroupInstanceID: cg.config.GroupInstanceID,
+				MemberID:        memberID,
+			}
+			cg.withErrorLogger(func(log Logger) {
+				log.Printf("%v", cg.err)
+			})
+			close(cg.failed)
+			return
 		default:
 			// leave the group and report the error if we had gotten far
 			// enough so as to have a member ID.  also clear the member id
 			// so we don't attempt to use it again.  in order to avoid
 			// a tight error loop, backoff before the next attempt to join
-			// the group.
+			// the group.  the partitions of the member are lost with its
+			// member id.
 			_ = cg.leaveGroup(memberID)
 			memberID = ""
+			cg.owned = nil
 			backoff = time.After(cg.config.JoinGroupBackoff)
 		}
 		// ensure that we exit cleanly in case the CG is done and no one is
@@ -641,10 +909,22 @@ func (cg *ConsumerGroup) nextGeneration(memberID string) (string, error) {
 	}
 	defer conn.Close()
 
+	if cg.config.TopicPattern != nil {
+		if cg.topics, err = matchTopics(conn, cg.config.TopicPattern); err != nil {
+			cg.withErrorLogger(func(log Logger) {
+				log.Printf("Failed to list the topics matching %v for group %s: %v", cg.config.TopicPattern, cg.config.ID, err)
+			})
+			return memberID, err
+		}
+	}
+
 	var generationID int32
 	var groupAssignments GroupMemberAssignments
 	var assignments map[string][]int32
 
+	// joinGroup replaces the protocol of the previous generation.
+	previousProtocol := cg.protocol
+
 	// join group.  this will join the group and prepare assignments if our
 	// consumer is elected leader.  it may also change or assign the member ID.
 	memberID, generationID, groupAssignments, err = cg.joinGroup(conn, memberID)
@@ -667,8 +947,17 @@ func (cg *ConsumerGroup) nextGeneration(memberID string) (string, error) {
 		return memberID, err
 	}
 
+	// the members following the cooperative protocol keep the partitions that
+	// are still assigned to them, and revoke the others.  members of an eager
+	// generation already revoked all their partitions.
+	var revoked map[string][]int
+	if cg.protocol == CooperativeRebalance && previousProtocol == CooperativeRebalance {
+		revoked = revokedPartitions(cg.owned, assignments)
+	}
+	cg.owned, cg.ownedGeneration = assignments, generationID
+
 	// fetch initial offsets.
-	var offsets map[string]map[int]int64
+	var offsets map[string]map[int]PartitionAssignment
 	offsets, err = cg.fetchOffsets(conn, assignments)
 	if err != nil {
 		cg.withErrorLogger(func(log Logger) {
@@ -683,7 +972,10 @@ func (cg *ConsumerGroup) nextGeneration(memberID string) (string, error) {
 		GroupID:         cg.config.ID,
 		MemberID:        memberID,
 		Assignments:     cg.makeAssignments(assignments, offsets),
+		Protocol:        cg.protocol,
+		Revoked:         revoked,
 		conn:            conn,
+		instanceID:      cg.config.GroupInstanceID,
 		done:            make(chan struct{}),
 		retentionMillis: int64(cg.config.RetentionTime / time.Millisecond),
 		log:             cg.withLogger,
@@ -695,10 +987,13 @@ func (cg *ConsumerGroup) nextGeneration(memberID string) (string, error) {
 	// complete.
 	gen.heartbeatLoop(cg.config.HeartbeatInterval)
 	if cg.config.WatchPartitionChanges {
-		for _, topic := range cg.config.Topics {
+		for _, topic := range cg.topics {
 			gen.partitionWatcher(cg.config.PartitionWatchInterval, topic)
 		}
 	}
+	if cg.config.TopicPattern != nil {
+		gen.topicWatcher(cg.config.TopicRefreshInterval, cg.config.TopicPattern, cg.topics)
+	}
 
 	// make this generation available for retrieval.  if the CG is closed before
 	// we can send it on the channel, exit.  that case is required b/c the next
@@ -779,7 +1074,7 @@ func (cg *ConsumerGroup) joinGroup(conn coordinator, memberID string) (string, i
 		return "", 0, nil, err
 	}
 
-	response, err := conn.joinGroup(request)
+	response, err := cg.sendJoinGroup(conn, request)
 	if err == nil && response.ErrorCode != 0 {
 		err = Error(response.ErrorCode)
 	}
@@ -790,6 +1085,11 @@ func (cg *ConsumerGroup) joinGroup(conn coordinator, memberID string) (string, i
 	memberID = response.MemberID
 	generationID := response.GenerationID
 
+	cg.protocol = EagerRebalance
+	if balancer, ok := findGroupBalancer(response.GroupProtocol, cg.config.GroupBalancers); ok {
+		cg.protocol = rebalanceProtocolOf(balancer)
+	}
+
 	cg.withLogger(func(l Logger) {
 		l.Printf("joined group %s as member %s in generation %d", cg.config.ID, memberID, generationID)
 	})
@@ -830,23 +1130,77 @@ func (cg *ConsumerGroup) makeJoinGroupRequestV1(memberID string) (joinGroupReque
 	}
 
 	for _, balancer := range cg.config.GroupBalancers {
-		userData, err := balancer.UserData()
+		var userData []byte
+		var err error
+		if b, ok := balancer.(assignmentUserData); ok {
+			userData, err = b.userDataWithAssignments(cg.owned, cg.ownedGeneration)
+		} else {
+			userData, err = balancer.UserData()
+		}
 		if err != nil {
 			return joinGroupRequestV1{}, fmt.Errorf("unable to construct protocol metadata for member, %v: %v", balancer.ProtocolName(), err)
 		}
+		metadata := groupMetadata{
+			Version:  1,
+			Topics:   cg.topics,
+			UserData: userData,
+		}
+		if rebalanceProtocolOf(balancer) == CooperativeRebalance {
+			if cg.protocol == CooperativeRebalance {
+				metadata.OwnedPartitions = cg.owned
+			}
+			if metadata.OwnedPartitions == nil {
+				metadata.OwnedPartitions = map[string][]int32{}
+			}
+		}
 		request.GroupProtocols = append(request.GroupProtocols, joinGroupRequestGroupProtocolV1{
-			ProtocolName: balancer.ProtocolName(),
-			ProtocolMetadata: groupMetadata{
-				Version:  1,
-				Topics:   cg.config.Topics,
-				UserData: userData,
-			}.bytes(),
+			ProtocolName:     balancer.ProtocolName(),
+			ProtocolMetadata: metadata.bytes(),
 		})
 	}
 
 	return request, nil
 }
 
+// sendJoinGroup sends the joinGroup request, static members use version 5 of
+// the API to send their group instance ID.  The response is converted to
+// version 1 so the rest of the handshake is the same for both kinds of members.
+func (cg *ConsumerGroup) sendJoinGroup(conn coordinator, request joinGroupRequestV1) (joinGroupResponseV1, error) {
+	if cg.config.GroupInstanceID == "" {
+		return conn.joinGroup(request)
+	}
+
+	response, err := conn.joinGroupV5(joinGroupRequestV5{
+		GroupID:          request.GroupID,
+		SessionTimeout:   request.SessionTimeout,
+		RebalanceTimeout: request.RebalanceTimeout,
+		MemberID:         request.MemberID,
+		GroupInstanceID:  &cg.config.GroupInstanceID,
+		ProtocolType:     request.ProtocolType,
+		GroupProtocols:   request.GroupProtocols,
+	})
+	if err != nil {
+		return joinGroupResponseV1{}, err
+	}
+
+	members := make([]joinGroupResponseMemberV1, len(response.Members))
+	for i, member := range response.Members {
+		members[i] = joinGroupResponseMemberV1{
+			MemberID:       member.MemberID,
+			MemberMetadata: member.MemberMetadata,
+		}
+	}
+
+	return joinGroupResponseV1{
+		ErrorCode:     response.ErrorCode,
+		GenerationID:  response.GenerationID,
+		GroupProtocol: response.GroupProtocol,
+		LeaderID:      response.LeaderID,
+		MemberID:      response.MemberID,
+		Members:       members,
+	}, nil
+}
+
 // assignTopicPartitions uses the selected GroupBalancer to assign members to
 // their various partitions
 func (cg *ConsumerGroup) assignTopicPartitions(conn coordinator, group joinGroupResponseV1) (GroupMemberAssignments, error) {
@@ -888,7 +1242,66 @@ func (cg *ConsumerGroup) assignTopicPartitions(conn coordinator, group joinGroup
 		}
 	})
 
-	return balancer.AssignGroups(members, partitions), nil
+	assignments := balancer.AssignGroups(members, partitions)
+	if rebalanceProtocolOf(balancer) == CooperativeRebalance {
+		withholdOwnedPartitions(members, assignments)
+	}
+	return assignments, nil
+}
+
+// withholdOwnedPartitions removes from the assignments the partitions which
+// are owned by another member than the one they are assigned to.  Their owners
+// revoke them and rejoin the group, they are assigned in the next generation
+// once nobody consumes them anymore.
+func withholdOwnedPartitions(members []GroupMember, assignments GroupMemberAssignments) {
+	owners := make(map[string]map[int]string)
+	for _, member := range members {
+		for topic, partitions := range member.OwnedPartitions {
+			if owners[topic] == nil {
+				owners[topic] = make(map[int]string)
+			}
+			for _, partition := range partitions {
+				owners[topic][partition] = member.ID
+			}
+		}
+	}
+
+	for memberID, topics := range assignments {
+		for topic, partitions := range topics {
+			kept := partitions[:0]
+			for _, partition := range partitions {
+				if owner, ok := owners[topic][partition]; !ok || owner == memberID {
+					kept = append(kept, partition)
+				}
+			}
+			topics[topic] = kept
+		}
+	}
+}
+
+// revokedPartitions returns the partitions which are owned and not assigned.
+func revokedPartitions(owned, assigned map[string][]int32) map[string][]int {
+	var revoked map[string][]int
+	for topic, partitions := range owned {
+		for _, partition := range partitions {
+			if !containsInt32(assigned[topic], partition) {
+				if revoked == nil {
+					revoked = make(map[string][]int)
+				}
+				revoked[topic] = append(revoked[topic], int(partition))
+			}
+		}
+	}
+	return revoked
+}
+
+func containsInt32(values []int32, value int32) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 // makeMemberProtocolMetadata maps encoded member metadata ([]byte) into []GroupMember
@@ -901,10 +1314,23 @@ func (cg *ConsumerGroup) makeMemberProtocolMetadata(in []joinGroupResponseMember
 			return nil, fmt.Errorf("unable to read metadata for member, %v: %v", item.MemberID, err)
 		}
 
+		var owned map[string][]int
+		if len(metadata.OwnedPartitions) != 0 {
+			owned = make(map[string][]int, len(metadata.OwnedPartitions))
+			for topic, partitions := range metadata.OwnedPartitions {
+				ids := make([]int, len(partitions))
+				for i, partition := range partitions {
+					ids[i] = int(partition)
+				}
+				owned[topic] = ids
+			}
+		}
+
 		members = append(members, GroupMember{
-			ID:       item.MemberID,
-			Topics:   metadata.Topics,
-			UserData: metadata.UserData,
+			ID:              item.MemberID,
+			Topics:          metadata.Topics,
+			UserData:        metadata.UserData,
+			OwnedPartitions: owned,
 		})
 	}
 	return members, nil
@@ -922,7 +1348,7 @@ func (cg *ConsumerGroup) makeMemberProtocolMetadata(in []joinGroupResponseMember
 //  * GroupAuthorizationFailed:
 func (cg *ConsumerGroup) syncGroup(conn coordinator, memberID string, generationID int32, memberAssignments GroupMemberAssignments) (map[string][]int32, error) {
 	request := cg.makeSyncGroupRequestV0(memberID, generationID, memberAssignments)
-	response, err := conn.syncGroup(request)
+	response, err := cg.sendSyncGroup(conn, request)
 	if err == nil && response.ErrorCode != 0 {
 		err = Error(response.ErrorCode)
 	}
@@ -949,6 +1375,30 @@ func (cg *ConsumerGroup) syncGroup(conn coordinator, memberID string, generation
 	return assignments.Topics, nil
 }
 
+// sendSyncGroup sends the syncGroup request, static members use version 3 of
+// the API to send their group instance ID.
+func (cg *ConsumerGroup) sendSyncGroup(conn coordinator, request syncGroupRequestV0) (syncGroupResponseV0, error) {
+	if cg.config.GroupInstanceID == "" {
+		return conn.syncGroup(request)
+	}
+
+	response, err := conn.syncGroupV3(syncGroupRequestV3{
+		GroupID:          request.GroupID,
+		GenerationID:     request.GenerationID,
+		MemberID:         request.MemberID,
+		GroupInstanceID:  &cg.config.GroupInstanceID,
+		GroupAssignments: request.GroupAssignments,
+	})
+	if err != nil {
+		return syncGroupResponseV0{}, err
+	}
+
+	return syncGroupResponseV0{
+		ErrorCode:         response.ErrorCode,
+		MemberAssignments: response.MemberAssignments,
+	}, nil
+}
+
 func (cg *ConsumerGroup) makeSyncGroupRequestV0(memberID string, generationID int32, memberAssignments GroupMemberAssignments) syncGroupRequestV0 {
 	request := syncGroupRequestV0{
 		GroupID:      cg.config.ID,
@@ -985,12 +1435,12 @@ func (cg *ConsumerGroup) makeSyncGroupRequestV0(memberID string, generationID in
 	return request
 }
 
-func (cg *ConsumerGroup) fetchOffsets(conn coordinator, subs map[string][]int32) (map[string]map[int]int64, error) {
+func (cg *ConsumerGroup) fetchOffsets(conn coordinator, subs map[string][]int32) (map[string]map[int]PartitionAssignment, error) {
 	req := offsetFetchRequestV1{
 		GroupID: cg.config.ID,
-		Topics:  make([]offsetFetchRequestV1Topic, 0, len(cg.config.Topics)),
+		Topics:  make([]offsetFetchRequestV1Topic, 0, len(cg.topics)),
 	}
-	for _, topic := range cg.config.Topics {
+	for _, topic := range cg.topics {
 		req.Topics = append(req.Topics, offsetFetchRequestV1Topic{
 			Topic:      topic,
 			Partitions: subs[topic],
@@ -1001,9 +1451,9 @@ func (cg *ConsumerGroup) fetchOffsets(conn coordinator, subs map[string][]int32)
 		return nil, err
 	}
 
-	offsetsByTopic := make(map[string]map[int]int64)
+	offsetsByTopic := make(map[string]map[int]PartitionAssignment)
 	for _, res := range offsets.Responses {
-		offsetsByPartition := map[int]int64{}
+		offsetsByPartition := map[int]PartitionAssignment{}
 		offsetsByTopic[res.Topic] = offsetsByPartition
 		for _, pr := range res.PartitionResponses {
 			for _, partition := range subs[res.Topic] {
@@ -1012,7 +1462,12 @@ func (cg *ConsumerGroup) fetchOffsets(conn coordinator, subs map[string][]int32)
 					if offset < 0 {
 						offset = cg.config.StartOffset
 					}
-					offsetsByPartition[int(partition)] = offset
+					offsetsByPartition[int(partition)] = PartitionAssignment{
+						Topic:    res.Topic,
+						ID:       int(partition),
+						Offset:   offset,
+						Metadata: pr.Metadata,
+					}
 				}
 			}
 		}
@@ -1021,24 +1476,21 @@ func (cg *ConsumerGroup) fetchOffsets(conn coordinator, subs map[string][]int32)
 	return offsetsByTopic, nil
 }
 
-func (cg *ConsumerGroup) makeAssignments(assignments map[string][]int32, offsets map[string]map[int]int64) map[string][]PartitionAssignment {
+func (cg *ConsumerGroup) makeAssignments(assignments map[string][]int32, offsets map[string]map[int]PartitionAssignment) map[string][]PartitionAssignment {
 	topicAssignments := make(map[string][]PartitionAssignment)
-	for _, topic := range cg.config.Topics {
+	for _, topic := range cg.topics {
 		topicPartitions := assignments[topic]
 		topicAssignments[topic] = make([]PartitionAssignment, 0, len(topicPartitions))
 		for _, partition := range topicPartitions {
-			var offset int64
-			partitionOffsets, ok := offsets[topic]
-			if ok {
-				offset, ok = partitionOffsets[int(partition)]
-			}
+			assignment, ok := offsets[topic][int(partition)]
 			if !ok {
-				offset = cg.config.StartOffset
+				assignment = PartitionAssignment{
+					Topic:  topic,
+					ID:     int(partition),
+					Offset: cg.config.StartOffset,
+				}
 			}
-			topicAssignments[topic] = append(topicAssignments[topic], PartitionAssignment{
-				ID:     int(partition),
-				Offset: offset,
-			})
+			topicAssignments[topic] = append(topicAssignments[topic], assignment)
 		}
 	}
 	return topicAssignments
@@ -1050,6 +1502,12 @@ func (cg *ConsumerGroup) leaveGroup(memberID string) error {
 		return nil
 	}
 
+	// static members keep their partitions until the session timeout expires,
+	// leaving the group would trigger the rebalance that they exist to avoid.
+	if cg.config.GroupInstanceID != "" {
+		return nil
+	}
+
 	cg.withLogger(func(log Logger) {
 		log.Printf("Leaving group %s, member %s", cg.config.ID, memberID)
 	})
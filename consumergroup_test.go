@@ -1,13 +1,19 @@
 package kafka
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -21,6 +27,9 @@ type mockCoordinator struct {
 	syncGroupFunc       func(syncGroupRequestV0) (syncGroupResponseV0, error)
 	leaveGroupFunc      func(leaveGroupRequestV0) (leaveGroupResponseV0, error)
 	heartbeatFunc       func(heartbeatRequestV0) (heartbeatResponseV0, error)
+	joinGroupV5Func     func(joinGroupRequestV5) (joinGroupResponseV5, error)
+	syncGroupV3Func     func(syncGroupRequestV3) (syncGroupResponseV3, error)
+	heartbeatV3Func     func(heartbeatRequestV3) (heartbeatResponseV3, error)
 	offsetFetchFunc     func(offsetFetchRequestV1) (offsetFetchResponseV1, error)
 	offsetCommitFunc    func(offsetCommitRequestV2) (offsetCommitResponseV2, error)
 	readPartitionsFunc  func(...string) ([]Partition, error)
@@ -68,6 +77,27 @@ func (c mockCoordinator) heartbeat(req heartbeatRequestV0) (heartbeatResponseV0,
 	return c.heartbeatFunc(req)
 }
 
+func (c mockCoordinator) joinGroupV5(req joinGroupRequestV5) (joinGroupResponseV5, error) {
+	if c.joinGroupV5Func == nil {
+		return joinGroupResponseV5{}, errors.New("no joinGroupV5 behavior specified")
+	}
+	return c.joinGroupV5Func(req)
+}
+
+func (c mockCoordinator) syncGroupV3(req syncGroupRequestV3) (syncGroupResponseV3, error) {
+	if c.syncGroupV3Func == nil {
+		return syncGroupResponseV3{}, errors.New("no syncGroupV3 behavior specified")
+	}
+	return c.syncGroupV3Func(req)
+}
+
+func (c mockCoordinator) heartbeatV3(req heartbeatRequestV3) (heartbeatResponseV3, error) {
+	if c.heartbeatV3Func == nil {
+		return heartbeatResponseV3{}, errors.New("no heartbeatV3 behavior specified")
+	}
+	return c.heartbeatV3Func(req)
+}
+
 func (c mockCoordinator) offsetFetch(req offsetFetchRequestV1) (offsetFetchResponseV1, error) {
 	if c.offsetFetchFunc == nil {
 		return offsetFetchResponseV1{}, errors.New("no offsetFetch behavior specified")
@@ -106,6 +136,9 @@ func TestValidateConsumerGroupConfig(t *testing.T) {
 		{config: ConsumerGroupConfig{Brokers: []string{"broker1"}, Topics: []string{"t1"}, ID: "group1", HeartbeatInterval: 2, SessionTimeout: 2, RebalanceTimeout: 2, PartitionWatchInterval: -1}, errorOccured: true},
 		{config: ConsumerGroupConfig{Brokers: []string{"broker1"}, Topics: []string{"t1"}, ID: "group1", HeartbeatInterval: 2, SessionTimeout: 2, RebalanceTimeout: 2, PartitionWatchInterval: 1, JoinGroupBackoff: -1}, errorOccured: true},
 		{config: ConsumerGroupConfig{Brokers: []string{"broker1"}, Topics: []string{"t1"}, ID: "group1", HeartbeatInterval: 2, SessionTimeout: 2, RebalanceTimeout: 2, PartitionWatchInterval: 1, JoinGroupBackoff: 1}, errorOccured: false},
+		{config: ConsumerGroupConfig{Brokers: []string{"broker1"}, Topics: []string{"t1"}, TopicPattern: regexp.MustCompile("^t"), ID: "group1"}, errorOccured: true},
+		{config: ConsumerGroupConfig{Brokers: []string{"broker1"}, TopicPattern: regexp.MustCompile("^t"), ID: "group1", TopicRefreshInterval: -1}, errorOccured: true},
+		{config: ConsumerGroupConfig{Brokers: []string{"broker1"}, TopicPattern: regexp.MustCompile("^t"), ID: "group1"}, errorOccured: false},
 	}
 	for _, test := range tests {
 		err := test.config.Validate()
@@ -594,6 +627,565 @@ func TestConsumerGroupErrors(t *testing.T) {
 	}
 }
 
+func TestConsumerGroupStaticMember(t *testing.T) {
+	const instanceID = "instance-1"
+
+	checkInstanceID := func(t *testing.T, req string, id *string) {
+		if id == nil || *id != instanceID {
+			t.Errorf("%s request sent without the group instance id", req)
+		}
+	}
+
+	heartbeats := make(chan struct{}, 1)
+	mc := mockCoordinator{
+		findCoordinatorFunc: func(findCoordinatorRequestV0) (findCoordinatorResponseV0, error) {
+			return findCoordinatorResponseV0{}, nil
+		},
+		joinGroupV5Func: func(req joinGroupRequestV5) (joinGroupResponseV5, error) {
+			checkInstanceID(t, "joinGroup", req.GroupInstanceID)
+			return joinGroupResponseV5{
+				GenerationID:  1,
+				GroupProtocol: RangeGroupBalancer{}.ProtocolName(),
+				LeaderID:      "abc",
+				MemberID:      "abc",
+				Members: []joinGroupResponseMemberV5{{
+					MemberID:        "abc",
+					GroupInstanceID: req.GroupInstanceID,
+					MemberMetadata:  req.GroupProtocols[0].ProtocolMetadata,
+				}},
+			}, nil
+		},
+		syncGroupV3Func: func(req syncGroupRequestV3) (syncGroupResponseV3, error) {
+			checkInstanceID(t, "syncGroup", req.GroupInstanceID)
+			if len(req.GroupAssignments) != 1 {
+				t.Errorf("expected the leader to send 1 assignment, got %d", len(req.GroupAssignments))
+				return syncGroupResponseV3{}, nil
+			}
+			return syncGroupResponseV3{MemberAssignments: req.GroupAssignments[0].MemberAssignments}, nil
+		},
+		heartbeatV3Func: func(req heartbeatRequestV3) (heartbeatResponseV3, error) {
+			checkInstanceID(t, "heartbeat", req.GroupInstanceID)
+			select {
+			case heartbeats <- struct{}{}:
+			default:
+			}
+			return heartbeatResponseV3{}, nil
+		},
+		leaveGroupFunc: func(leaveGroupRequestV0) (leaveGroupResponseV0, error) {
+			t.Error("static members must not leave the group")
+			return leaveGroupResponseV0{}, nil
+		},
+		offsetFetchFunc: func(offsetFetchRequestV1) (offsetFetchResponseV1, error) {
+			return offsetFetchResponseV1{}, nil
+		},
+		readPartitionsFunc: func(...string) ([]Partition, error) {
+			return []Partition{{Topic: "test", ID: 0}, {Topic: "test", ID: 1}}, nil
+		},
+	}
+
+	group, err := NewConsumerGroup(ConsumerGroupConfig{
+		ID:                makeGroupID(),
+		Topics:            []string{"test"},
+		Brokers:           []string{"no-such-broker"},
+		GroupInstanceID:   instanceID,
+		HeartbeatInterval: 10 * time.Millisecond,
+		connect: func(*Dialer, ...string) (coordinator, error) {
+			return mc, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer group.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	gen, err := group.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gen.MemberID != "abc" {
+		t.Errorf("expected member id abc, got %q", gen.MemberID)
+	}
+	if n := len(gen.Assignments["test"]); n != 2 {
+		t.Errorf("expected 2 partitions to be assigned, got %d", n)
+	}
+
+	select {
+	case <-heartbeats:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for a heartbeat")
+	}
+}
+
+func TestConsumerGroupAssignmentsMetadata(t *testing.T) {
+	mc := mockCoordinator{
+		offsetFetchFunc: func(offsetFetchRequestV1) (offsetFetchResponseV1, error) {
+			return offsetFetchResponseV1{
+				Responses: []offsetFetchResponseV1Response{{
+					Topic: "test",
+					PartitionResponses: []offsetFetchResponseV1PartitionResponse{
+						{Partition: 0, Offset: 42, Metadata: "host-1:checkpoint-7"},
+						{Partition: 1, Offset: -1},
+					},
+				}},
+			}, nil
+		},
+	}
+
+	cg := &ConsumerGroup{
+		config: ConsumerGroupConfig{StartOffset: LastOffset},
+		topics: []string{"test"},
+	}
+	assignments := map[string][]int32{"test": {0, 1, 2}}
+
+	offsets, err := cg.fetchOffsets(mc, assignments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]PartitionAssignment{
+		"test": {
+			{Topic: "test", ID: 0, Offset: 42, Metadata: "host-1:checkpoint-7"},
+			{Topic: "test", ID: 1, Offset: LastOffset},
+			{Topic: "test", ID: 2, Offset: LastOffset},
+		},
+	}
+	if found := cg.makeAssignments(assignments, offsets); !reflect.DeepEqual(expected, found) {
+		t.Errorf("unexpected assignments\nexpected: %v\nfound:    %v", expected, found)
+	}
+}
+
+func TestConsumerGroupStickyUserData(t *testing.T) {
+	var generationID int32
+	userData := make(chan []byte, 2)
+	mc := mockCoordinator{
+		findCoordinatorFunc: func(findCoordinatorRequestV0) (findCoordinatorResponseV0, error) {
+			return findCoordinatorResponseV0{}, nil
+		},
+		joinGroupFunc: func(req joinGroupRequestV1) (joinGroupResponseV1, error) {
+			metadata := req.GroupProtocols[0].ProtocolMetadata
+			var m groupMetadata
+			if _, err := (&m).readFrom(bufio.NewReader(bytes.NewReader(metadata)), len(metadata)); err != nil {
+				t.Error(err)
+			}
+			select {
+			case userData <- m.UserData:
+			default:
+			}
+			return joinGroupResponseV1{
+				GenerationID:  atomic.AddInt32(&generationID, 1),
+				GroupProtocol: StickyGroupBalancer{}.ProtocolName(),
+				LeaderID:      "abc",
+				MemberID:      "abc",
+				Members: []joinGroupResponseMemberV1{{
+					MemberID:       "abc",
+					MemberMetadata: metadata,
+				}},
+			}, nil
+		},
+		syncGroupFunc: func(req syncGroupRequestV0) (syncGroupResponseV0, error) {
+			return syncGroupResponseV0{MemberAssignments: req.GroupAssignments[0].MemberAssignments}, nil
+		},
+		heartbeatFunc: func(heartbeatRequestV0) (heartbeatResponseV0, error) {
+			return heartbeatResponseV0{}, RebalanceInProgress
+		},
+		leaveGroupFunc: func(leaveGroupRequestV0) (leaveGroupResponseV0, error) {
+			return leaveGroupResponseV0{}, nil
+		},
+		offsetFetchFunc: func(offsetFetchRequestV1) (offsetFetchResponseV1, error) {
+			return offsetFetchResponseV1{}, nil
+		},
+		readPartitionsFunc: func(...string) ([]Partition, error) {
+			return []Partition{{Topic: "test", ID: 0}, {Topic: "test", ID: 1}}, nil
+		},
+	}
+
+	group, err := NewConsumerGroup(ConsumerGroupConfig{
+		ID:                makeGroupID(),
+		Topics:            []string{"test"},
+		Brokers:           []string{"no-such-broker"},
+		GroupBalancers:    []GroupBalancer{StickyGroupBalancer{}},
+		HeartbeatInterval: 10 * time.Millisecond,
+		connect: func(*Dialer, ...string) (coordinator, error) {
+			return mc, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer group.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the member has no assignment when it joins the group for the first
+	// time, and sends the one of the first generation when it rejoins.
+	expected := [][]byte{nil, stickyAssignorUserData{
+		Topics:     map[string][]int32{"test": {0, 1}},
+		Generation: 1,
+	}.bytes()}
+
+	for i, want := range expected {
+		if _, err := group.Next(ctx); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case found := <-userData:
+			if !bytes.Equal(want, found) {
+				t.Errorf("join %d: unexpected user data\nexpected: %v\nfound:    %v", i+1, want, found)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the member to join")
+		}
+	}
+}
+
+func TestConsumerGroupFencedInstance(t *testing.T) {
+	var joins int32
+	mc := mockCoordinator{
+		findCoordinatorFunc: func(findCoordinatorRequestV0) (findCoordinatorResponseV0, error) {
+			return findCoordinatorResponseV0{}, nil
+		},
+		joinGroupV5Func: func(joinGroupRequestV5) (joinGroupResponseV5, error) {
+			atomic.AddInt32(&joins, 1)
+			return joinGroupResponseV5{ErrorCode: int16(FencedInstanceID)}, nil
+		},
+	}
+
+	group, err := NewConsumerGroup(ConsumerGroupConfig{
+		ID:               "group-1",
+		Topics:           []string{"test"},
+		Brokers:          []string{"no-such-broker"},
+		GroupInstanceID:  "instance-1",
+		JoinGroupBackoff: time.Millisecond,
+		connect: func(*Dialer, ...string) (coordinator, error) {
+			return mc, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer group.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		_, err := group.Next(ctx)
+		fenced, ok := err.(*GroupInstanceFencedError)
+		if !ok {
+			t.Fatalf("expected a *GroupInstanceFencedError, got %v", err)
+		}
+		if fenced.GroupID != "group-1" || fenced.GroupInstanceID != "instance-1" {
+			t.Errorf("wrong error: %+v", fenced)
+		}
+		if fenced.Unwrap() != FencedInstanceID {
+			t.Errorf("expected the error to wrap FencedInstanceID")
+		}
+	}
+
+	if n := atomic.LoadInt32(&joins); n != 1 {
+		t.Errorf("expected the fenced member to join the group once, got %d", n)
+	}
+}
+
+// cooperativeTestGroup is an in-memory coordinator of a single consumer group,
+// which completes a rebalance once all the members of the group rejoined.
+type cooperativeTestGroup struct {
+	mutex      sync.Mutex
+	cond       *sync.Cond
+	partitions []Partition
+	stopped    bool
+
+	lastMember  int
+	members     map[string]bool
+	joining     map[string]joinGroupRequestV1
+	round       int
+	responses   map[string]joinGroupResponseV1
+	generation  int32
+	leader      string
+	rebalancing bool
+	synced      map[string][]byte
+}
+
+func newCooperativeTestGroup(partitions []Partition) *cooperativeTestGroup {
+	g := &cooperativeTestGroup{
+		partitions: partitions,
+		members:    make(map[string]bool),
+		joining:    make(map[string]joinGroupRequestV1),
+	}
+	g.cond = sync.NewCond(&g.mutex)
+	return g
+}
+
+func (g *cooperativeTestGroup) coordinator() coordinator {
+	return mockCoordinator{
+		findCoordinatorFunc: func(findCoordinatorRequestV0) (findCoordinatorResponseV0, error) {
+			return findCoordinatorResponseV0{}, nil
+		},
+		joinGroupFunc:  g.joinGroup,
+		syncGroupFunc:  g.syncGroup,
+		heartbeatFunc:  g.heartbeat,
+		leaveGroupFunc: g.leaveGroup,
+		offsetFetchFunc: func(offsetFetchRequestV1) (offsetFetchResponseV1, error) {
+			return offsetFetchResponseV1{}, nil
+		},
+		readPartitionsFunc: func(...string) ([]Partition, error) {
+			return g.partitions, nil
+		},
+	}
+}
+
+func (g *cooperativeTestGroup) stop() {
+	g.mutex.Lock()
+	g.stopped = true
+	g.cond.Broadcast()
+	g.mutex.Unlock()
+}
+
+func (g *cooperativeTestGroup) joinGroup(req joinGroupRequestV1) (joinGroupResponseV1, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if req.MemberID == "" {
+		g.lastMember++
+		req.MemberID = fmt.Sprintf("member-%d", g.lastMember)
+	}
+	g.members[req.MemberID] = true
+	g.joining[req.MemberID] = req
+	g.rebalancing = true
+
+	round := g.round
+	g.completeRebalance()
+	for g.round == round && !g.stopped {
+		g.cond.Wait()
+	}
+	if g.stopped {
+		return joinGroupResponseV1{}, errors.New("coordinator stopped")
+	}
+	return g.responses[req.MemberID], nil
+}
+
+// completeRebalance starts the next generation once all the members joined.
+func (g *cooperativeTestGroup) completeRebalance() {
+	if len(g.joining) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(g.members))
+	for id := range g.members {
+		if _, ok := g.joining[id]; !ok {
+			return
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	protocol := g.joining[ids[0]].GroupProtocols[0].ProtocolName
+	members := make([]joinGroupResponseMemberV1, len(ids))
+	for i, id := range ids {
+		members[i] = joinGroupResponseMemberV1{
+			MemberID:       id,
+			MemberMetadata: g.joining[id].GroupProtocols[0].ProtocolMetadata,
+		}
+	}
+
+	g.generation++
+	g.leader = ids[0]
+	g.responses = make(map[string]joinGroupResponseV1, len(ids))
+	for _, id := range ids {
+		response := joinGroupResponseV1{
+			GenerationID:  g.generation,
+			GroupProtocol: protocol,
+			LeaderID:      g.leader,
+			MemberID:      id,
+		}
+		if id == g.leader {
+			response.Members = members
+		}
+		g.responses[id] = response
+	}
+
+	g.joining = make(map[string]joinGroupRequestV1)
+	g.synced = nil
+	g.rebalancing = false
+	g.round++
+	g.cond.Broadcast()
+}
+
+func (g *cooperativeTestGroup) syncGroup(req syncGroupRequestV0) (syncGroupResponseV0, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if req.MemberID == g.leader && req.GenerationID == g.generation {
+		g.synced = make(map[string][]byte)
+		for _, assignment := range req.GroupAssignments {
+			g.synced[assignment.MemberID] = assignment.MemberAssignments
+		}
+		g.cond.Broadcast()
+	}
+	for g.synced == nil && req.GenerationID == g.generation && !g.stopped {
+		g.cond.Wait()
+	}
+	if req.GenerationID != g.generation || g.stopped {
+		return syncGroupResponseV0{ErrorCode: int16(RebalanceInProgress)}, nil
+	}
+	return syncGroupResponseV0{MemberAssignments: g.synced[req.MemberID]}, nil
+}
+
+func (g *cooperativeTestGroup) heartbeat(req heartbeatRequestV0) (heartbeatResponseV0, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.rebalancing || req.GenerationID != g.generation {
+		return heartbeatResponseV0{}, RebalanceInProgress
+	}
+	return heartbeatResponseV0{}, nil
+}
+
+func (g *cooperativeTestGroup) leaveGroup(req leaveGroupRequestV0) (leaveGroupResponseV0, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	delete(g.members, req.MemberID)
+	delete(g.joining, req.MemberID)
+	g.rebalancing = true
+	g.completeRebalance()
+	return leaveGroupResponseV0{}, nil
+}
+
+func TestConsumerGroupCooperativeRebalance(t *testing.T) {
+	partitions := make([]Partition, 6)
+	for i := range partitions {
+		partitions[i] = Partition{Topic: "test", ID: i}
+	}
+	testGroup := newCooperativeTestGroup(partitions)
+
+	type generation struct {
+		id       int32
+		assigned []int
+		revoked  []int
+	}
+
+	var mutex sync.Mutex
+	generations := make([][]generation, 3)
+	groups := make([]*ConsumerGroup, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := range groups {
+		group, err := NewConsumerGroup(ConsumerGroupConfig{
+			ID:                "group",
+			Topics:            []string{"test"},
+			Brokers:           []string{"no-such-broker"},
+			GroupBalancers:    []GroupBalancer{CooperativeStickyGroupBalancer{}},
+			HeartbeatInterval: 10 * time.Millisecond,
+			JoinGroupBackoff:  10 * time.Millisecond,
+			connect: func(*Dialer, ...string) (coordinator, error) {
+				return testGroup.coordinator(), nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer group.Close()
+		groups[i] = group
+
+		go func(i int) {
+			for {
+				gen, err := group.Next(ctx)
+				if err != nil {
+					if err == ErrGroupClosed || ctx.Err() != nil {
+						return
+					}
+					continue
+				}
+				if gen.Protocol != CooperativeRebalance {
+					t.Errorf("expected the cooperative protocol, got %v", gen.Protocol)
+				}
+
+				g := generation{id: gen.ID, revoked: gen.Revoked["test"]}
+				for _, assignment := range gen.Assignments["test"] {
+					g.assigned = append(g.assigned, assignment.ID)
+				}
+				mutex.Lock()
+				generations[i] = append(generations[i], g)
+				mutex.Unlock()
+
+				if len(g.revoked) != 0 {
+					// rejoin the group once the revoked partitions are
+					// released.
+					gen.Start(func(context.Context) {})
+				}
+			}
+		}(i)
+	}
+	// stop the coordinator first so no member is left waiting on a rebalance
+	// when the groups are closed.
+	defer testGroup.stop()
+
+	// balanced waits for the members to share the partitions evenly in the
+	// same generation, and returns the index of the generation of each member.
+	balanced := func(members []int, size int) []int {
+		for {
+			mutex.Lock()
+			last := make([]int, len(members))
+			ok := true
+			for i, m := range members {
+				last[i] = len(generations[m]) - 1
+				if last[i] < 0 {
+					ok = false
+					break
+				}
+				g := generations[m][last[i]]
+				if len(g.assigned) != size || len(g.revoked) != 0 || g.id != generations[members[0]][last[0]].id {
+					ok = false
+				}
+			}
+			mutex.Unlock()
+			if ok {
+				return last
+			}
+
+			select {
+			case <-ctx.Done():
+				t.Fatalf("the partitions were never balanced between members %v", members)
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	start := balanced([]int{0, 1, 2}, 2)
+
+	mutex.Lock()
+	retained := [][]int{
+		generations[0][start[0]].assigned,
+		generations[1][start[1]].assigned,
+	}
+	mutex.Unlock()
+
+	groups[2].Close()
+	balanced([]int{0, 1}, 3)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for m, partitions := range retained {
+		for _, g := range generations[m][start[m]:] {
+			if len(g.revoked) != 0 {
+				t.Errorf("member %d: partitions %v revoked in generation %d", m, g.revoked, g.id)
+			}
+			for _, partition := range partitions {
+				if !containsPartition(g.assigned, partition) {
+					t.Errorf("member %d: partition %d not assigned in generation %d", m, partition, g.id)
+				}
+			}
+		}
+	}
+}
+
 // todo : test for multi-topic?
 
 func TestGenerationExitsOnPartitionChange(t *testing.T) {
@@ -656,3 +1248,72 @@ func TestGenerationExitsOnPartitionChange(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchTopics(t *testing.T) {
+	conn := mockCoordinator{
+		readPartitionsFunc: func(topics ...string) ([]Partition, error) {
+			if len(topics) != 0 {
+				t.Errorf("expected the partitions of all topics to be listed, got %v", topics)
+			}
+			return []Partition{
+				{Topic: "events.b", ID: 0},
+				{Topic: "events.a", ID: 0},
+				{Topic: "events.a", ID: 1},
+				{Topic: "metrics.a", ID: 0},
+				{Topic: "__consumer_offsets", ID: 0},
+			}, nil
+		},
+	}
+
+	topics, err := matchTopics(conn, regexp.MustCompile(`^(events\..*|__.*)$`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(topics, []string{"events.a", "events.b"}) {
+		t.Errorf("expected the events topics to be matched, got %v", topics)
+	}
+}
+
+func TestGenerationExitsOnTopicChange(t *testing.T) {
+	var mutex sync.Mutex
+	partitions := []Partition{{Topic: "events.a", ID: 0}, {Topic: "other", ID: 0}}
+
+	conn := mockCoordinator{
+		readPartitionsFunc: func(...string) ([]Partition, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return partitions, nil
+		},
+	}
+
+	gen := Generation{
+		conn:     conn,
+		done:     make(chan struct{}),
+		log:      func(func(Logger)) {},
+		logError: func(func(Logger)) {},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		gen.topicWatcher(10*time.Millisecond, regexp.MustCompile(`^events\.`), []string{"events.a"})
+		gen.wg.Wait()
+		close(done)
+	}()
+
+	// topics which do not match the pattern don't trigger a rebalance.
+	select {
+	case <-done:
+		t.Fatal("expected the topic watcher to keep running while the matched topics don't change")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mutex.Lock()
+	partitions = append(partitions, Partition{Topic: "events.b", ID: 0})
+	mutex.Unlock()
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the topic watcher to exit")
+	case <-done:
+	}
+}
@@ -0,0 +1,199 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+)
+
+// ConsumerLagRequest represents a request to compute the lag of a consumer
+// group.
+type ConsumerLagRequest struct {
+	// GroupID is the ID of the group to compute the lag of.
+	GroupID string
+
+	// Topics lists the topics to compute the lag of, all their partitions are
+	// included. The lag is computed on the topics that the group has committed
+	// offsets for when it is empty.
+	Topics []string
+
+	// Baseline is FirstOffset or LastOffset, it is the offset that the lag of
+	// partitions on which the group never committed an offset is measured
+	// from. It defaults to FirstOffset, which is where a Reader starts
+	// consuming partitions with no committed offset unless configured
+	// otherwise.
+	Baseline int64
+}
+
+// ConsumerLagResponse represents the response to a ConsumerLagRequest.
+type ConsumerLagResponse struct {
+	// Topics holds the lag of each partition, indexed by topic name and
+	// sorted by partition.
+	Topics map[string][]ConsumerLagPartition
+}
+
+// ConsumerLagPartition carries the lag of a consumer group on a partition.
+type ConsumerLagPartition struct {
+	Partition int
+
+	// CommittedOffset is the offset committed by the group, it is -1 when
+	// the group has no committed offset on the partition.
+	CommittedOffset int64
+
+	// EndOffset is the offset of the next message written to the partition,
+	// it is -1 if it could not be looked up.
+	EndOffset int64
+
+	// Lag is the number of messages between the committed offset and the end
+	// of the partition, or between the baseline and the end of the partition
+	// when NoCommit is true.
+	Lag int64
+
+	// NoCommit is true when the group has no committed offset on the
+	// partition.
+	NoCommit bool
+
+	// Error is set to a non-nil value if the committed or end offset of the
+	// partition could not be looked up, Lag is zero in that case.
+	Error error
+}
+
+// ConsumerLag computes the lag of a consumer group on the partitions of
+// topics by combining the offsets committed by the group with the end offsets
+// of the partitions. The end offsets are looked up with one request per
+// partition leader. The method requires kafka 2.1 or above.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) ConsumerLag(ctx context.Context, req ConsumerLagRequest) (*ConsumerLagResponse, error) {
+	baseline := req.Baseline
+	switch baseline {
+	case 0:
+		baseline = FirstOffset
+	case FirstOffset, LastOffset:
+	default:
+		return nil, fmt.Errorf("the baseline of the consumer lag must be FirstOffset or LastOffset, got %d", baseline)
+	}
+
+	fetch := OffsetFetchRequest{GroupID: req.GroupID}
+	topics := make(map[string][]int, len(req.Topics))
+	for _, topic := range req.Topics {
+		topics[topic] = nil
+	}
+
+	var partitions map[string][]int
+	var err error
+
+	if len(topics) != 0 {
+		if partitions, err = c.topicPartitions(ctx, topics); err != nil {
+			return nil, err
+		}
+		fetch.Topics = partitions
+	}
+
+	committed, err := c.OffsetFetch(ctx, fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(topics) == 0 {
+		// The group's committed offsets name the topics to look at, the
+		// partitions it never committed on are filled in from the metadata.
+		for topic := range committed.Topics {
+			topics[topic] = nil
+		}
+		if len(topics) == 0 {
+			return &ConsumerLagResponse{Topics: map[string][]ConsumerLagPartition{}}, nil
+		}
+		if partitions, err = c.topicPartitions(ctx, topics); err != nil {
+			return nil, err
+		}
+	}
+
+	commits := make(map[topicPartition]OffsetFetchPartition)
+	for topic, offsets := range committed.Topics {
+		for _, p := range offsets {
+			commits[topicPartition{topic: topic, partition: p.Partition}] = p
+		}
+	}
+
+	ends := ListOffsetsRequest{Topics: make(map[string][]OffsetRequest, len(partitions))}
+	starts := ListOffsetsRequest{Topics: make(map[string][]OffsetRequest)}
+
+	for topic, ids := range partitions {
+		for _, id := range ids {
+			ends.Topics[topic] = append(ends.Topics[topic], LastOffsetOf(id))
+
+			commit, ok := commits[topicPartition{topic: topic, partition: id}]
+			if baseline == FirstOffset && (!ok || commit.CommittedOffset < 0) {
+				starts.Topics[topic] = append(starts.Topics[topic], FirstOffsetOf(id))
+			}
+		}
+	}
+
+	endOffsets, err := c.ListOffsets(ctx, ends)
+	if err != nil {
+		return nil, err
+	}
+
+	startOffsets := make(map[topicPartition]ListOffsetsResponsePartition)
+	if len(starts.Topics) != 0 {
+		found, err := c.ListOffsets(ctx, starts)
+		if err != nil {
+			return nil, err
+		}
+		for topic, offsets := range found.Topics {
+			for _, p := range offsets {
+				startOffsets[topicPartition{topic: topic, partition: p.Partition}] = p
+			}
+		}
+	}
+
+	res := &ConsumerLagResponse{
+		Topics: make(map[string][]ConsumerLagPartition, len(endOffsets.Topics)),
+	}
+
+	for topic, offsets := range endOffsets.Topics {
+		lags := make([]ConsumerLagPartition, len(offsets))
+
+		for i, end := range offsets {
+			key := topicPartition{topic: topic, partition: end.Partition}
+			lag := ConsumerLagPartition{
+				Partition:       end.Partition,
+				CommittedOffset: -1,
+				EndOffset:       end.Offset,
+			}
+
+			commit, ok := commits[key]
+			if ok {
+				lag.CommittedOffset = commit.CommittedOffset
+			}
+			lag.NoCommit = !ok || (commit.Error == nil && commit.CommittedOffset < 0)
+
+			switch {
+			case ok && commit.Error != nil:
+				lag.Error = commit.Error
+			case end.Error != nil:
+				lag.EndOffset, lag.Error = -1, end.Error
+			case !lag.NoCommit:
+				lag.Lag = lag.EndOffset - lag.CommittedOffset
+			case baseline == FirstOffset:
+				if start := startOffsets[key]; start.Error != nil {
+					lag.Error = start.Error
+				} else {
+					lag.Lag = lag.EndOffset - start.Offset
+				}
+			}
+
+			// The committed offset may be past the end of a partition which
+			// was truncated, the group has nothing left to consume then.
+			if lag.Lag < 0 {
+				lag.Lag = 0
+			}
+
+			lags[i] = lag
+		}
+		res.Topics[topic] = lags
+	}
+
+	return res, nil
+}
@@ -0,0 +1,78 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func testClientConsumerLag(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.1.0") {
+		t.Skip("computing the consumer lag requires kafka 2.1.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	groupID := makeGroupID()
+	createTopic(t, topic, 2)
+
+	w := NewWriter(WriterConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     topic,
+		Balancer:  &RoundRobin{},
+		BatchSize: 1,
+	})
+	if err := w.WriteMessages(ctx, makeTestSequence(4)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	commit, err := c.OffsetCommit(ctx, OffsetCommitRequest{
+		GroupID:      groupID,
+		GenerationID: -1,
+		Topics:       map[string][]OffsetCommit{topic: {{Partition: 0, Offset: 1}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := commit.Topics[topic][0].Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		req         ConsumerLagRequest
+		noCommitLag int64
+	}{
+		{req: ConsumerLagRequest{GroupID: groupID, Topics: []string{topic}}, noCommitLag: 2},
+		{req: ConsumerLagRequest{GroupID: groupID, Topics: []string{topic}, Baseline: LastOffset}, noCommitLag: 0},
+		{req: ConsumerLagRequest{GroupID: groupID}, noCommitLag: 2},
+	}
+
+	for _, test := range tests {
+		res, err := c.ConsumerLag(ctx, test.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		partitions := res.Topics[topic]
+		if len(partitions) != 2 {
+			t.Fatalf("expected the lag of 2 partitions, got %d", len(partitions))
+		}
+		for _, p := range partitions {
+			if p.Error != nil {
+				t.Errorf("computing the lag of partition %d failed: %v", p.Partition, p.Error)
+			}
+			if p.EndOffset != 2 {
+				t.Errorf("expected end offset 2 on partition %d, got %d", p.Partition, p.EndOffset)
+			}
+		}
+
+		if p := partitions[0]; p.NoCommit || p.CommittedOffset != 1 || p.Lag != 1 {
+			t.Errorf("expected a lag of 1 from committed offset 1 on partition 0, got %+v", p)
+		}
+		if p := partitions[1]; !p.NoCommit || p.CommittedOffset != -1 || p.Lag != test.noCommitLag {
+			t.Errorf("expected a lag of %d with no committed offset on partition 1, got %+v", test.noCommitLag, p)
+		}
+	}
+}
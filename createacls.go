@@ -0,0 +1,204 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+)
+
+// CreateACLsRequest represents a request sent to a kafka cluster to create
+// ACL bindings.
+type CreateACLsRequest struct {
+	// ACLs holds the bindings to create.
+	ACLs []ACLEntry
+}
+
+// CreateACLsResponse represents the response to a CreateACLsRequest.
+type CreateACLsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// Errors holds the result of creating each binding, in the order they
+	// appeared in the request. The value is nil if the binding was created.
+	Errors []error
+}
+
+// CreateACLs sends a CreateAcls request to the kafka cluster. The brokers must
+// be configured with an authorizer. Prefixed patterns require kafka 2.0
+// (KIP-290), which is the minimum version supported by this method.
+//
+// Errors that apply to a single binding are reported in the Errors field of
+// the response and do not cause the method to fail.
+func (c *Client) CreateACLs(ctx context.Context, req CreateACLsRequest) (*CreateACLsResponse, error) {
+	request := createACLsRequestV1{
+		Creations: make([]createACLsRequestCreationV1, len(req.ACLs)),
+	}
+
+	for i, acl := range req.ACLs {
+		request.Creations[i] = createACLsRequestCreationV1{
+			ResourceType:        int8(acl.ResourceType),
+			ResourceName:        acl.ResourceName,
+			ResourcePatternType: int8(acl.ResourcePatternType),
+			Principal:           acl.Principal,
+			Host:                acl.Host,
+			Operation:           int8(acl.Operation),
+			PermissionType:      int8(acl.PermissionType),
+		}
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.createACLs(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Results) != len(req.ACLs) {
+		return nil, fmt.Errorf("expected %d results in the create acls response, got %d", len(req.ACLs), len(response.Results))
+	}
+
+	res := &CreateACLsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Errors:   make([]error, len(response.Results)),
+	}
+
+	for i, r := range response.Results {
+		if r.ErrorCode != 0 {
+			res.Errors[i] = Error(r.ErrorCode)
+		}
+	}
+
+	return res, nil
+}
+
+// createACLs creates the requested ACL bindings.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_CreateAcls
+func (c *Conn) createACLs(request createACLsRequestV1) (createACLsResponseV1, error) {
+	var response createACLsResponseV1
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(createAcls, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return createACLsResponseV1{}, err
+	}
+
+	return response, nil
+}
+
+type createACLsRequestCreationV1 struct {
+	ResourceType        int8
+	ResourceName        string
+	ResourcePatternType int8
+	Principal           string
+	Host                string
+	Operation           int8
+	PermissionType      int8
+}
+
+func (t createACLsRequestCreationV1) size() int32 {
+	return sizeofInt8(t.ResourceType) +
+		sizeofString(t.ResourceName) +
+		sizeofInt8(t.ResourcePatternType) +
+		sizeofString(t.Principal) +
+		sizeofString(t.Host) +
+		sizeofInt8(t.Operation) +
+		sizeofInt8(t.PermissionType)
+}
+
+func (t createACLsRequestCreationV1) writeTo(wb *writeBuffer) {
+	wb.writeInt8(t.ResourceType)
+	wb.writeString(t.ResourceName)
+	wb.writeInt8(t.ResourcePatternType)
+	wb.writeString(t.Principal)
+	wb.writeString(t.Host)
+	wb.writeInt8(t.Operation)
+	wb.writeInt8(t.PermissionType)
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_CreateAcls
+type createACLsRequestV1 struct {
+	Creations []createACLsRequestCreationV1
+}
+
+func (t createACLsRequestV1) size() int32 {
+	return sizeofArray(len(t.Creations), func(i int) int32 { return t.Creations[i].size() })
+}
+
+func (t createACLsRequestV1) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Creations), func(i int) { t.Creations[i].writeTo(wb) })
+}
+
+type createACLsResponseResultV1 struct {
+	ErrorCode    int16
+	ErrorMessage string
+}
+
+func (t createACLsResponseResultV1) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage)
+}
+
+func (t createACLsResponseResultV1) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+}
+
+func (t *createACLsResponseResultV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_CreateAcls
+type createACLsResponseV1 struct {
+	ThrottleTimeMS int32
+	Results        []createACLsResponseResultV1
+}
+
+func (t createACLsResponseV1) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Results), func(i int) int32 { return t.Results[i].size() })
+}
+
+func (t createACLsResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Results), func(i int) { t.Results[i].writeTo(wb) })
+}
+
+func (t *createACLsResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var result createACLsResponseResultV1
+		if fnRemain, fnErr = (&result).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Results = append(t.Results, result)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,179 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// CreateDelegationTokenRequest represents a request sent to a kafka cluster to
+// create a delegation token for the authenticated principal.
+type CreateDelegationTokenRequest struct {
+	// Renewers holds the principals allowed to renew the token, for example
+	// "User:bob".
+	Renewers []string
+
+	// MaxLifetime is the duration past which the token cannot be renewed,
+	// the broker default (delegation.token.max.lifetime.ms) applies when it
+	// is zero.
+	MaxLifetime time.Duration
+}
+
+// CreateDelegationTokenResponse represents the response to a
+// CreateDelegationTokenRequest.
+type CreateDelegationTokenResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	Token DelegationToken
+}
+
+// CreateDelegationToken sends a CreateDelegationToken request to the kafka
+// cluster. The API was introduced in kafka 1.1 (KIP-48), tokens may only be
+// created by clients that did not authenticate with a token themselves.
+func (c *Client) CreateDelegationToken(ctx context.Context, req CreateDelegationTokenRequest) (*CreateDelegationTokenResponse, error) {
+	renewers, err := makeDelegationTokenPrincipalsV1(req.Renewers)
+	if err != nil {
+		return nil, err
+	}
+
+	request := createDelegationTokenRequestV1{
+		Renewers:      renewers,
+		MaxLifetimeMS: -1,
+	}
+	if req.MaxLifetime > 0 {
+		request.MaxLifetimeMS = int64(req.MaxLifetime / time.Millisecond)
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.createDelegationToken(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateDelegationTokenResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Token: DelegationToken{
+			TokenID:    response.TokenID,
+			HMAC:       response.HMAC,
+			Owner:      response.Owner.String(),
+			Renewers:   req.Renewers,
+			IssueTime:  timestampToTime(response.IssueTimestampMS),
+			ExpiryTime: timestampToTime(response.ExpiryTimestampMS),
+			MaxTime:    timestampToTime(response.MaxTimestampMS),
+		},
+	}, nil
+}
+
+// createDelegationToken creates a delegation token owned by the principal
+// that the connection is authenticated as.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_CreateDelegationToken
+func (c *Conn) createDelegationToken(request createDelegationTokenRequestV1) (createDelegationTokenResponseV1, error) {
+	var response createDelegationTokenResponseV1
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(createDelegationToken, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return createDelegationTokenResponseV1{}, err
+	}
+	if response.ErrorCode != 0 {
+		return createDelegationTokenResponseV1{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_CreateDelegationToken
+type createDelegationTokenRequestV1 struct {
+	Renewers []delegationTokenPrincipalV1
+
+	// MaxLifetimeMS is -1 to use the broker default.
+	MaxLifetimeMS int64
+}
+
+func (t createDelegationTokenRequestV1) size() int32 {
+	return sizeofArray(len(t.Renewers), func(i int) int32 { return t.Renewers[i].size() }) +
+		sizeofInt64(t.MaxLifetimeMS)
+}
+
+func (t createDelegationTokenRequestV1) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Renewers), func(i int) { t.Renewers[i].writeTo(wb) })
+	wb.writeInt64(t.MaxLifetimeMS)
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_CreateDelegationToken
+type createDelegationTokenResponseV1 struct {
+	ErrorCode         int16
+	Owner             delegationTokenPrincipalV1
+	IssueTimestampMS  int64
+	ExpiryTimestampMS int64
+	MaxTimestampMS    int64
+	TokenID           string
+	HMAC              []byte
+	ThrottleTimeMS    int32
+}
+
+func (t createDelegationTokenResponseV1) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		t.Owner.size() +
+		sizeofInt64(t.IssueTimestampMS) +
+		sizeofInt64(t.ExpiryTimestampMS) +
+		sizeofInt64(t.MaxTimestampMS) +
+		sizeofString(t.TokenID) +
+		sizeofBytes(t.HMAC) +
+		sizeofInt32(t.ThrottleTimeMS)
+}
+
+func (t createDelegationTokenResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	t.Owner.writeTo(wb)
+	wb.writeInt64(t.IssueTimestampMS)
+	wb.writeInt64(t.ExpiryTimestampMS)
+	wb.writeInt64(t.MaxTimestampMS)
+	wb.writeString(t.TokenID)
+	wb.writeBytes(t.HMAC)
+	wb.writeInt32(t.ThrottleTimeMS)
+}
+
+func (t *createDelegationTokenResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = (&t.Owner).readFrom(r, remain); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.IssueTimestampMS); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.ExpiryTimestampMS); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.MaxTimestampMS); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.TokenID); err != nil {
+		return
+	}
+	if remain, err = readBytes(r, remain, &t.HMAC); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,263 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// CreatePartitionsRequest represents a request sent to a kafka cluster to
+// increase the number of partitions of existing topics.
+type CreatePartitionsRequest struct {
+	// Topics holds the list of topics to add partitions to.
+	Topics []TopicPartitionsConfig
+
+	// When ValidateOnly is true, the controller validates the request without
+	// creating the partitions.
+	ValidateOnly bool
+
+	// Timeout is how long the controller waits for the partitions to be
+	// created, it defaults to the time remaining until the deadline of the
+	// context.
+	Timeout time.Duration
+}
+
+// TopicPartitionsConfig describes the partitions to add to a topic.
+type TopicPartitionsConfig struct {
+	// Topic name
+	Topic string
+
+	// Count is the total number of partitions that the topic should have
+	// after the request (not the number of partitions to add).
+	Count int
+
+	// Assignments optionally holds the list of broker IDs that the replicas of
+	// each new partition are assigned to, the first broker of a list is the
+	// preferred leader. When set, there must be one entry per new partition.
+	Assignments [][]int
+}
+
+// CreatePartitionsResponse represents the response to a
+// CreatePartitionsRequest.
+type CreatePartitionsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// controller.
+	Throttle time.Duration
+
+	// Topics holds the result for each topic of the request.
+	Topics []CreatePartitionsResponseTopic
+}
+
+// CreatePartitionsResponseTopic carries the result of creating the partitions
+// of a topic.
+type CreatePartitionsResponseTopic struct {
+	Topic string
+
+	// Error is set to a non-nil value if the partitions could not be created,
+	// for example InvalidPartitionNumber if the count is lower than the
+	// current number of partitions.
+	Error error
+
+	// ErrorMessage holds the message sent by the broker alongside Error.
+	ErrorMessage string
+}
+
+// CreatePartitions sends a CreatePartitions request to the controller of the
+// kafka cluster. The API was introduced in kafka 1.0.
+//
+// Errors that apply to a single topic are reported on the topic and do not
+// cause the method to fail.
+func (c *Client) CreatePartitions(ctx context.Context, req CreatePartitionsRequest) (*CreatePartitionsResponse, error) {
+	request := createPartitionsRequestV0{
+		Topics:       make([]createPartitionsRequestTopicV0, len(req.Topics)),
+		Timeout:      protocolTimeout(ctx, req.Timeout),
+		ValidateOnly: req.ValidateOnly,
+	}
+
+	for i, t := range req.Topics {
+		topic := createPartitionsRequestTopicV0{
+			Topic: t.Topic,
+			Count: int32(t.Count),
+		}
+		if t.Assignments != nil {
+			topic.Assignments = make([][]int32, len(t.Assignments))
+			for j, brokers := range t.Assignments {
+				topic.Assignments[j] = make([]int32, len(brokers))
+				for k, id := range brokers {
+					topic.Assignments[j][k] = int32(id)
+				}
+			}
+		}
+		request.Topics[i] = topic
+	}
+
+	conn, err := c.connectController(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.createPartitions(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CreatePartitionsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make([]CreatePartitionsResponseTopic, len(response.TopicErrors)),
+	}
+
+	for i, t := range response.TopicErrors {
+		res.Topics[i] = CreatePartitionsResponseTopic{
+			Topic:        t.Topic,
+			ErrorMessage: t.ErrorMessage,
+		}
+		if t.ErrorCode != 0 {
+			res.Topics[i].Error = Error(t.ErrorCode)
+		}
+	}
+
+	return res, nil
+}
+
+// createPartitions creates new partitions for the requested topics.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_CreatePartitions
+func (c *Conn) createPartitions(request createPartitionsRequestV0) (createPartitionsResponseV0, error) {
+	var response createPartitionsResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			if request.Timeout == 0 {
+				now := time.Now()
+				deadline = adjustDeadlineForRTT(deadline, now, defaultRTT)
+				request.Timeout = milliseconds(deadlineToTimeout(deadline, now))
+			}
+			return c.writeRequest(createPartitions, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return createPartitionsResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+type createPartitionsRequestTopicV0 struct {
+	Topic string
+	Count int32
+
+	// Assignments is nullable, the controller assigns the replicas of the
+	// new partitions when it is nil.
+	Assignments [][]int32
+}
+
+func (t createPartitionsRequestTopicV0) size() int32 {
+	return sizeofString(t.Topic) +
+		sizeofInt32(t.Count) +
+		sizeofArray(len(t.Assignments), func(i int) int32 { return sizeofInt32Array(t.Assignments[i]) })
+}
+
+func (t createPartitionsRequestTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Topic)
+	wb.writeInt32(t.Count)
+	if t.Assignments == nil {
+		wb.writeArrayLen(-1)
+	} else {
+		wb.writeArray(len(t.Assignments), func(i int) { wb.writeInt32Array(t.Assignments[i]) })
+	}
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_CreatePartitions
+type createPartitionsRequestV0 struct {
+	Topics []createPartitionsRequestTopicV0
+
+	// Timeout ms to wait for the partitions to be created on the controller
+	// node.
+	Timeout int32
+
+	ValidateOnly bool
+}
+
+func (t createPartitionsRequestV0) size() int32 {
+	return sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofInt32(t.Timeout) +
+		sizeofBool(t.ValidateOnly)
+}
+
+func (t createPartitionsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeInt32(t.Timeout)
+	wb.writeBool(t.ValidateOnly)
+}
+
+type createPartitionsResponseTopicErrorV0 struct {
+	Topic        string
+	ErrorCode    int16
+	ErrorMessage string
+}
+
+func (t createPartitionsResponseTopicErrorV0) size() int32 {
+	return sizeofString(t.Topic) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage)
+}
+
+func (t createPartitionsResponseTopicErrorV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Topic)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+}
+
+func (t *createPartitionsResponseTopicErrorV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.Topic); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_CreatePartitions
+type createPartitionsResponseV0 struct {
+	ThrottleTimeMS int32
+	TopicErrors    []createPartitionsResponseTopicErrorV0
+}
+
+func (t createPartitionsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.TopicErrors), func(i int) int32 { return t.TopicErrors[i].size() })
+}
+
+func (t createPartitionsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.TopicErrors), func(i int) { t.TopicErrors[i].writeTo(wb) })
+}
+
+func (t *createPartitionsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic createPartitionsResponseTopicErrorV0
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.TopicErrors = append(t.TopicErrors, topic)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
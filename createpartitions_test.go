@@ -0,0 +1,103 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestCreatePartitionsResponseV0(t *testing.T) {
+	item := createPartitionsResponseV0{
+		ThrottleTimeMS: 1,
+		TopicErrors: []createPartitionsResponseTopicErrorV0{
+			{
+				Topic:        "a",
+				ErrorCode:    int16(InvalidPartitionNumber),
+				ErrorMessage: "b",
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found createPartitionsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestCreatePartitionsRequestV0Size(t *testing.T) {
+	for _, item := range []createPartitionsRequestV0{
+		{Topics: []createPartitionsRequestTopicV0{{Topic: "a", Count: 2}}},
+		{Topics: []createPartitionsRequestTopicV0{{Topic: "a", Count: 3, Assignments: [][]int32{{1, 2}, {2, 3}}}}},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func testClientCreatePartitions(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("1.0.0") {
+		t.Skip("create partitions requires kafka 1.0.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	res, err := c.CreatePartitions(ctx, CreatePartitionsRequest{
+		Topics: []TopicPartitionsConfig{{Topic: topic, Count: 6}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Topics[0].Error; err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := Dial("tcp", "localhost:9092")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partitions) != 6 {
+		t.Errorf("expected 6 partitions, got %d", len(partitions))
+	}
+
+	// Shrinking the topic is not supported by kafka.
+	res, err = c.CreatePartitions(ctx, CreatePartitionsRequest{
+		Topics: []TopicPartitionsConfig{{Topic: topic, Count: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Topics[0].Error != InvalidPartitionNumber {
+		t.Errorf("expected %v, got %v", InvalidPartitionNumber, res.Topics[0].Error)
+	}
+}
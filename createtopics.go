@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"bufio"
+	"context"
 	"time"
 )
 
@@ -34,47 +35,85 @@ func (t createTopicsRequestV0ConfigEntry) writeTo(wb *writeBuffer) {
 
 type ReplicaAssignment struct {
 	Partition int
-	Replicas  int
+
+	// Deprecated: Replicas is not sent to the brokers, the number of replicas
+	// of the partition is the length of Brokers.
+	Replicas int
+
+	// Brokers holds the IDs of the brokers that the replicas of the partition
+	// are assigned to, the first broker is the preferred leader.
+	Brokers []int
 }
 
 func (a ReplicaAssignment) toCreateTopicsRequestV0ReplicaAssignment() createTopicsRequestV0ReplicaAssignment {
+	brokers := make([]int32, len(a.Brokers))
+	for i, id := range a.Brokers {
+		brokers[i] = int32(id)
+	}
 	return createTopicsRequestV0ReplicaAssignment{
 		Partition: int32(a.Partition),
-		Replicas:  int32(a.Replicas),
+		Replicas:  brokers,
 	}
 }
 
 type createTopicsRequestV0ReplicaAssignment struct {
 	Partition int32
-	Replicas  int32
+	Replicas  []int32
 }
 
 func (t createTopicsRequestV0ReplicaAssignment) size() int32 {
 	return sizeofInt32(t.Partition) +
-		sizeofInt32(t.Replicas)
+		sizeofInt32Array(t.Replicas)
 }
 
 func (t createTopicsRequestV0ReplicaAssignment) writeTo(wb *writeBuffer) {
 	wb.writeInt32(t.Partition)
-	wb.writeInt32(t.Replicas)
+	wb.writeInt32Array(t.Replicas)
 }
 
 type TopicConfig struct {
 	// Topic name
 	Topic string
 
-	// NumPartitions created. -1 indicates unset.
+	// NumPartitions created. -1 indicates unset, in which case the brokers
+	// use their num.partitions setting.
 	NumPartitions int
 
-	// ReplicationFactor for the topic. -1 indicates unset.
+	// ReplicationFactor for the topic. -1 indicates unset, in which case the
+	// brokers use their default.replication.factor setting.
 	ReplicationFactor int
 
 	// ReplicaAssignments among kafka brokers for this topic partitions. If this
-	// is set num_partitions and replication_factor must be unset.
+	// is set num_partitions and replication_factor must be unset, they are
+	// sent as unset when left to zero.
 	ReplicaAssignments []ReplicaAssignment
 
 	// ConfigEntries holds topic level configuration for topic to be set.
 	ConfigEntries []ConfigEntry
+
+	// When UseDefaults is true, NumPartitions and ReplicationFactor are sent
+	// as unset when left to zero.
+	//
+	// Leaving the number of partitions or replication factor unset without
+	// assigning the replicas requires kafka 2.4 or above (KIP-464), which is
+	// only supported by Client.CreateTopics.
+	UseDefaults bool
+}
+
+// counts returns the number of partitions and the replication factor to send
+// to the brokers, replacing zero values with -1 when the brokers are expected
+// to pick them.
+func (t TopicConfig) counts() (numPartitions int32, replicationFactor int16) {
+	numPartitions, replicationFactor = int32(t.NumPartitions), int16(t.ReplicationFactor)
+	if t.UseDefaults || len(t.ReplicaAssignments) != 0 {
+		if numPartitions == 0 {
+			numPartitions = -1
+		}
+		if replicationFactor == 0 {
+			replicationFactor = -1
+		}
+	}
+	return
 }
 
 func (t TopicConfig) toCreateTopicsRequestV0Topic() createTopicsRequestV0Topic {
@@ -91,10 +130,12 @@ func (t TopicConfig) toCreateTopicsRequestV0Topic() createTopicsRequestV0Topic {
 			c.toCreateTopicsRequestV0ConfigEntry())
 	}
 
+	numPartitions, replicationFactor := t.counts()
+
 	return createTopicsRequestV0Topic{
 		Topic:              t.Topic,
-		NumPartitions:      int32(t.NumPartitions),
-		ReplicationFactor:  int16(t.ReplicationFactor),
+		NumPartitions:      numPartitions,
+		ReplicationFactor:  replicationFactor,
 		ReplicaAssignments: requestV0ReplicaAssignments,
 		ConfigEntries:      requestV0ConfigEntries,
 	}
@@ -265,3 +306,423 @@ func (c *Conn) CreateTopics(topics ...TopicConfig) error {
 		return err
 	}
 }
+
+// CreateTopicsRequest represents a request sent to a kafka cluster to create
+// topics.
+type CreateTopicsRequest struct {
+	// Topics holds the configuration of the topics to create.
+	Topics []TopicConfig
+
+	// When ValidateOnly is true, the controller validates the request without
+	// creating the topics.
+	ValidateOnly bool
+
+	// Timeout is how long the controller waits for the topics to be created
+	// before responding. Creating many topics on a large cluster may take
+	// longer than produce or fetch requests. When zero, the timeout is the
+	// time remaining until the deadline of the context.
+	Timeout time.Duration
+}
+
+// CreateTopicsResponse represents the response to a CreateTopicsRequest.
+type CreateTopicsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// controller.
+	Throttle time.Duration
+
+	// Topics holds the result for each topic of the request.
+	Topics []CreateTopicsResponseTopic
+}
+
+// CreateTopicsResponseTopic carries the result of creating a topic.
+type CreateTopicsResponseTopic struct {
+	Topic string
+
+	// Error is set to a non-nil value if the topic could not be created, for
+	// example TopicAlreadyExists or InvalidReplicationFactor.
+	Error error
+
+	// ErrorMessage holds the message sent by the broker alongside Error, it
+	// usually details which constraint the request violated.
+	ErrorMessage string
+
+	// NumPartitions and ReplicationFactor are the number of partitions and
+	// replication factor that the topic was, or would be when ValidateOnly
+	// is set, created with. They are -1 if the topic could not be created.
+	NumPartitions     int
+	ReplicationFactor int
+
+	// Configs holds the configuration of the topic, including the defaults
+	// applied by the brokers.
+	Configs []CreateTopicsResponseConfig
+}
+
+// CreateTopicsResponseConfig is a configuration entry of a created topic.
+type CreateTopicsResponseConfig struct {
+	Name         string
+	Value        string
+	ReadOnly     bool
+	ConfigSource ConfigSource
+	IsSensitive  bool
+}
+
+// CreateTopics sends a CreateTopics request to the controller of the kafka
+// cluster. Unlike Conn.CreateTopics, the method returns the configuration of
+// the topics and does not ignore TopicAlreadyExists errors. The method
+// requires kafka 2.4 or above.
+//
+// Errors that apply to a single topic are reported on the topic and do not
+// cause the method to fail.
+func (c *Client) CreateTopics(ctx context.Context, req CreateTopicsRequest) (*CreateTopicsResponse, error) {
+	request := createTopicsRequestV5{
+		Topics:       make([]createTopicsRequestTopicV5, len(req.Topics)),
+		Timeout:      protocolTimeout(ctx, req.Timeout),
+		ValidateOnly: req.ValidateOnly,
+	}
+
+	for i, t := range req.Topics {
+		request.Topics[i] = t.toCreateTopicsRequestTopicV5()
+	}
+
+	conn, err := c.connectController(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.createTopicsV5(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CreateTopicsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make([]CreateTopicsResponseTopic, len(response.Topics)),
+	}
+
+	for i, t := range response.Topics {
+		topic := CreateTopicsResponseTopic{
+			Topic:             t.Topic,
+			ErrorMessage:      t.ErrorMessage,
+			NumPartitions:     int(t.NumPartitions),
+			ReplicationFactor: int(t.ReplicationFactor),
+		}
+		if t.ErrorCode != 0 {
+			topic.Error = Error(t.ErrorCode)
+		}
+		if t.Configs != nil {
+			topic.Configs = make([]CreateTopicsResponseConfig, len(t.Configs))
+			for j, e := range t.Configs {
+				topic.Configs[j] = CreateTopicsResponseConfig{
+					Name:         e.Name,
+					Value:        e.Value,
+					ReadOnly:     e.ReadOnly,
+					ConfigSource: ConfigSource(e.ConfigSource),
+					IsSensitive:  e.IsSensitive,
+				}
+			}
+		}
+		res.Topics[i] = topic
+	}
+
+	return res, nil
+}
+
+func (t TopicConfig) toCreateTopicsRequestTopicV5() createTopicsRequestTopicV5 {
+	numPartitions, replicationFactor := t.counts()
+
+	topic := createTopicsRequestTopicV5{
+		Topic:             t.Topic,
+		NumPartitions:     numPartitions,
+		ReplicationFactor: replicationFactor,
+		Assignments:       make([]createTopicsRequestAssignmentV5, len(t.ReplicaAssignments)),
+		Configs:           make([]createTopicsRequestConfigV5, len(t.ConfigEntries)),
+	}
+	for i, a := range t.ReplicaAssignments {
+		brokers := make([]int32, len(a.Brokers))
+		for j, id := range a.Brokers {
+			brokers[j] = int32(id)
+		}
+		topic.Assignments[i] = createTopicsRequestAssignmentV5{
+			Partition: int32(a.Partition),
+			BrokerIDs: brokers,
+		}
+	}
+	for i, e := range t.ConfigEntries {
+		topic.Configs[i] = createTopicsRequestConfigV5{
+			Name:  e.ConfigName,
+			Value: e.ConfigValue,
+		}
+	}
+	return topic
+}
+
+// createTopicsV5 creates the requested topics. Unlike createTopics, errors on
+// topics do not cause the method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_CreateTopics
+func (c *Conn) createTopicsV5(request createTopicsRequestV5) (createTopicsResponseV5, error) {
+	var response createTopicsResponseV5
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			if request.Timeout == 0 {
+				now := time.Now()
+				deadline = adjustDeadlineForRTT(deadline, now, defaultRTT)
+				request.Timeout = milliseconds(deadlineToTimeout(deadline, now))
+			}
+			return c.writeFlexibleRequest(createTopics, v5, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return createTopicsResponseV5{}, err
+	}
+
+	return response, nil
+}
+
+type createTopicsRequestAssignmentV5 struct {
+	Partition int32
+	BrokerIDs []int32
+}
+
+func (t createTopicsRequestAssignmentV5) size() int32 {
+	return sizeofInt32(t.Partition) +
+		sizeofCompactInt32Array(t.BrokerIDs) +
+		sizeofTaggedFields()
+}
+
+func (t createTopicsRequestAssignmentV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.Partition)
+	wb.writeCompactInt32Array(t.BrokerIDs)
+	wb.writeTaggedFields()
+}
+
+type createTopicsRequestConfigV5 struct {
+	Name  string
+	Value string
+}
+
+func (t createTopicsRequestConfigV5) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofCompactString(t.Value) +
+		sizeofTaggedFields()
+}
+
+func (t createTopicsRequestConfigV5) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeCompactString(t.Value)
+	wb.writeTaggedFields()
+}
+
+type createTopicsRequestTopicV5 struct {
+	Topic             string
+	NumPartitions     int32
+	ReplicationFactor int16
+	Assignments       []createTopicsRequestAssignmentV5
+	Configs           []createTopicsRequestConfigV5
+}
+
+func (t createTopicsRequestTopicV5) size() int32 {
+	return sizeofCompactString(t.Topic) +
+		sizeofInt32(t.NumPartitions) +
+		sizeofInt16(t.ReplicationFactor) +
+		sizeofCompactArray(len(t.Assignments), func(i int) int32 { return t.Assignments[i].size() }) +
+		sizeofCompactArray(len(t.Configs), func(i int) int32 { return t.Configs[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t createTopicsRequestTopicV5) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Topic)
+	wb.writeInt32(t.NumPartitions)
+	wb.writeInt16(t.ReplicationFactor)
+	wb.writeCompactArray(len(t.Assignments), func(i int) { t.Assignments[i].writeTo(wb) })
+	wb.writeCompactArray(len(t.Configs), func(i int) { t.Configs[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+// createTopicsRequestV5 has the same content than createTopicsRequestV0 with
+// the validate only flag, encoded with the flexible format introduced in
+// kafka 2.4.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_CreateTopics
+type createTopicsRequestV5 struct {
+	Topics       []createTopicsRequestTopicV5
+	Timeout      int32
+	ValidateOnly bool
+}
+
+func (t createTopicsRequestV5) size() int32 {
+	return sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofInt32(t.Timeout) +
+		sizeofBool(t.ValidateOnly) +
+		sizeofTaggedFields()
+}
+
+func (t createTopicsRequestV5) writeTo(wb *writeBuffer) {
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeInt32(t.Timeout)
+	wb.writeBool(t.ValidateOnly)
+	wb.writeTaggedFields()
+}
+
+type createTopicsResponseConfigV5 struct {
+	Name         string
+	Value        string
+	ReadOnly     bool
+	ConfigSource int8
+	IsSensitive  bool
+}
+
+func (t createTopicsResponseConfigV5) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofCompactString(t.Value) +
+		sizeofBool(t.ReadOnly) +
+		sizeofInt8(t.ConfigSource) +
+		sizeofBool(t.IsSensitive) +
+		sizeofTaggedFields()
+}
+
+func (t createTopicsResponseConfigV5) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeCompactString(t.Value)
+	wb.writeBool(t.ReadOnly)
+	wb.writeInt8(t.ConfigSource)
+	wb.writeBool(t.IsSensitive)
+	wb.writeTaggedFields()
+}
+
+func (t *createTopicsResponseConfigV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.Name); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.Value); err != nil {
+		return
+	}
+	if remain, err = readBool(r, remain, &t.ReadOnly); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.ConfigSource); err != nil {
+		return
+	}
+	if remain, err = readBool(r, remain, &t.IsSensitive); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+type createTopicsResponseTopicV5 struct {
+	Topic             string
+	ErrorCode         int16
+	ErrorMessage      string
+	NumPartitions     int32
+	ReplicationFactor int16
+	Configs           []createTopicsResponseConfigV5
+}
+
+func (t createTopicsResponseTopicV5) size() int32 {
+	return sizeofCompactString(t.Topic) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofInt32(t.NumPartitions) +
+		sizeofInt16(t.ReplicationFactor) +
+		sizeofCompactArray(len(t.Configs), func(i int) int32 { return t.Configs[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t createTopicsResponseTopicV5) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Topic)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeInt32(t.NumPartitions)
+	wb.writeInt16(t.ReplicationFactor)
+	wb.writeCompactArray(len(t.Configs), func(i int) { t.Configs[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *createTopicsResponseTopicV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.Topic); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.NumPartitions); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ReplicationFactor); err != nil {
+		return
+	}
+
+	// The configs are null when the client is not authorized to describe
+	// the configuration of the topic.
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var config createTopicsResponseConfigV5
+		if fnRemain, fnErr = (&config).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Configs = append(t.Configs, config)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_CreateTopics
+type createTopicsResponseV5 struct {
+	ThrottleTimeMS int32
+	Topics         []createTopicsResponseTopicV5
+}
+
+func (t createTopicsResponseV5) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t createTopicsResponseV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *createTopicsResponseV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic createTopicsResponseTopicV5
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
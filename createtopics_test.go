@@ -3,8 +3,11 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
 )
 
 func TestCreateTopicsResponseV0(t *testing.T) {
@@ -36,3 +39,246 @@ func TestCreateTopicsResponseV0(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestCreateTopicsResponseV5(t *testing.T) {
+	item := createTopicsResponseV5{
+		ThrottleTimeMS: 1,
+		Topics: []createTopicsResponseTopicV5{
+			{
+				Topic:             "a",
+				NumPartitions:     3,
+				ReplicationFactor: 1,
+				Configs: []createTopicsResponseConfigV5{
+					{Name: "cleanup.policy", Value: "delete", ConfigSource: int8(ConfigSourceDefaultConfig)},
+					{Name: "retention.ms", Value: "1000", ConfigSource: int8(ConfigSourceDynamicTopicConfig), IsSensitive: true},
+				},
+			},
+			{
+				Topic:             "b",
+				ErrorCode:         int16(InvalidReplicationFactor),
+				ErrorMessage:      "Replication factor: 3 larger than available brokers: 1.",
+				NumPartitions:     -1,
+				ReplicationFactor: -1,
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		t.FailNow()
+	}
+
+	var found createTopicsResponseV5
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestCreateTopicsRequestV5Size(t *testing.T) {
+	for _, item := range []createTopicsRequestV5{
+		{},
+		{
+			Topics: []createTopicsRequestTopicV5{
+				{
+					Topic:             "a",
+					NumPartitions:     -1,
+					ReplicationFactor: -1,
+					Assignments:       []createTopicsRequestAssignmentV5{{Partition: 0, BrokerIDs: []int32{1, 2}}},
+					Configs:           []createTopicsRequestConfigV5{{Name: "retention.ms", Value: "1000"}},
+				},
+			},
+			Timeout:      1000,
+			ValidateOnly: true,
+		},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func TestCreateTopicsRequestV0Size(t *testing.T) {
+	for _, item := range []createTopicsRequestV0{
+		{},
+		{
+			Topics: []createTopicsRequestV0Topic{
+				(TopicConfig{
+					Topic:              "a",
+					ReplicaAssignments: []ReplicaAssignment{{Partition: 0, Brokers: []int{1, 2}}},
+					ConfigEntries:      []ConfigEntry{{ConfigName: "retention.ms", ConfigValue: "1000"}},
+				}).toCreateTopicsRequestV0Topic(),
+			},
+			Timeout: 1000,
+		},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func TestTopicConfigCounts(t *testing.T) {
+	tests := []struct {
+		config            TopicConfig
+		numPartitions     int32
+		replicationFactor int16
+	}{
+		{config: TopicConfig{NumPartitions: 3, ReplicationFactor: 2}, numPartitions: 3, replicationFactor: 2},
+		{config: TopicConfig{NumPartitions: -1, ReplicationFactor: -1}, numPartitions: -1, replicationFactor: -1},
+		{config: TopicConfig{}, numPartitions: 0, replicationFactor: 0},
+		{config: TopicConfig{UseDefaults: true}, numPartitions: -1, replicationFactor: -1},
+		{config: TopicConfig{NumPartitions: 3, UseDefaults: true}, numPartitions: 3, replicationFactor: -1},
+		{
+			config:            TopicConfig{ReplicaAssignments: []ReplicaAssignment{{Partition: 0, Brokers: []int{1}}}},
+			numPartitions:     -1,
+			replicationFactor: -1,
+		},
+	}
+
+	for _, test := range tests {
+		v0 := test.config.toCreateTopicsRequestV0Topic()
+		if v0.NumPartitions != test.numPartitions || v0.ReplicationFactor != test.replicationFactor {
+			t.Errorf("%+v: expected %d partitions and replication factor %d in v0, got %d and %d",
+				test.config, test.numPartitions, test.replicationFactor, v0.NumPartitions, v0.ReplicationFactor)
+		}
+		v5 := test.config.toCreateTopicsRequestTopicV5()
+		if v5.NumPartitions != test.numPartitions || v5.ReplicationFactor != test.replicationFactor {
+			t.Errorf("%+v: expected %d partitions and replication factor %d in v5, got %d and %d",
+				test.config, test.numPartitions, test.replicationFactor, v5.NumPartitions, v5.ReplicationFactor)
+		}
+	}
+}
+
+func testClientCreateTopics(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.4.0") {
+		t.Skip("the flexible version of create topics requires kafka 2.4.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	res, err := c.CreateTopics(ctx, CreateTopicsRequest{
+		Topics: []TopicConfig{
+			{
+				Topic:             topic,
+				NumPartitions:     2,
+				ReplicationFactor: 1,
+				ConfigEntries:     []ConfigEntry{{ConfigName: "retention.ms", ConfigValue: "3600000"}},
+			},
+			{
+				Topic:             makeTopic(),
+				NumPartitions:     1,
+				ReplicationFactor: 100,
+			},
+		},
+		ValidateOnly: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Topics) != 2 {
+		t.Fatalf("expected results for 2 topics, got %d", len(res.Topics))
+	}
+
+	valid := res.Topics[0]
+	if valid.Error != nil {
+		t.Fatalf("validating topic %s failed: %v", valid.Topic, valid.Error)
+	}
+	if valid.NumPartitions != 2 || valid.ReplicationFactor != 1 {
+		t.Errorf("expected 2 partitions with replication factor 1, got %d and %d", valid.NumPartitions, valid.ReplicationFactor)
+	}
+	found := false
+	for _, config := range valid.Configs {
+		if config.Name == "retention.ms" {
+			found = true
+			if config.Value != "3600000" {
+				t.Errorf("expected retention.ms to be 3600000, got %s", config.Value)
+			}
+		}
+	}
+	if !found {
+		t.Error("retention.ms not found in the configuration of the topic")
+	}
+
+	invalid := res.Topics[1]
+	if invalid.Error != InvalidReplicationFactor {
+		t.Errorf("expected InvalidReplicationFactor, got %v", invalid.Error)
+	}
+	if invalid.ErrorMessage == "" {
+		t.Error("expected an error message explaining the invalid replication factor")
+	}
+
+	// Looking up the metadata of the topic could auto-create it, validating
+	// its creation again reports TopicAlreadyExists if the first request did
+	// create it.
+	res, err = c.CreateTopics(ctx, CreateTopicsRequest{
+		Topics:       []TopicConfig{{Topic: topic, NumPartitions: 2, ReplicationFactor: 1}},
+		ValidateOnly: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Topics[0].Error; err != nil {
+		t.Errorf("topic %s was created by a validate only request: %v", topic, err)
+	}
+
+	brokers, err := c.brokerList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = c.CreateTopics(ctx, CreateTopicsRequest{
+		Topics: []TopicConfig{
+			{Topic: makeTopic(), UseDefaults: true},
+			{
+				Topic: makeTopic(),
+				ReplicaAssignments: []ReplicaAssignment{
+					{Partition: 0, Brokers: []int{brokers[0].ID}},
+					{Partition: 1, Brokers: []int{brokers[0].ID}},
+				},
+			},
+		},
+		ValidateOnly: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaults := res.Topics[0]
+	if defaults.Error != nil {
+		t.Fatalf("validating topic %s with the defaults of the brokers failed: %v (%s)", defaults.Topic, defaults.Error, defaults.ErrorMessage)
+	}
+	if defaults.NumPartitions <= 0 || defaults.ReplicationFactor <= 0 {
+		t.Errorf("expected the brokers to pick the number of partitions and replication factor, got %d and %d", defaults.NumPartitions, defaults.ReplicationFactor)
+	}
+
+	assigned := res.Topics[1]
+	if assigned.Error != nil {
+		t.Fatalf("validating topic %s with assigned replicas failed: %v (%s)", assigned.Topic, assigned.Error, assigned.ErrorMessage)
+	}
+	if assigned.NumPartitions != 2 || assigned.ReplicationFactor != 1 {
+		t.Errorf("expected 2 partitions with replication factor 1, got %d and %d", assigned.NumPartitions, assigned.ReplicationFactor)
+	}
+}
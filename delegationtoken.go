@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DelegationToken is a delegation token, it lets clients authenticate on
+// behalf of the owner of the token. Clients authenticate with a token using
+// scram.TokenMechanism, with the token ID and HMAC.
+type DelegationToken struct {
+	TokenID string
+	HMAC    []byte
+
+	// Owner is the principal that the token acts on behalf of, for example
+	// "User:alice".
+	Owner string
+
+	// Renewers holds the principals allowed to renew the token, in addition
+	// to the owner.
+	Renewers []string
+
+	IssueTime time.Time
+
+	// ExpiryTime is the time at which the token expires unless it is renewed.
+	ExpiryTime time.Time
+
+	// MaxTime is the time past which the token cannot be renewed.
+	MaxTime time.Time
+}
+
+type delegationTokenPrincipalV1 struct {
+	PrincipalType string
+	PrincipalName string
+}
+
+// makeDelegationTokenPrincipalV1 splits principal into its type and name,
+// principals are formatted as "<type>:<name>".
+func makeDelegationTokenPrincipalV1(principal string) (delegationTokenPrincipalV1, error) {
+	i := strings.IndexByte(principal, ':')
+	if i < 0 {
+		return delegationTokenPrincipalV1{}, fmt.Errorf("malformed principal %q, expected <type>:<name>", principal)
+	}
+	return delegationTokenPrincipalV1{
+		PrincipalType: principal[:i],
+		PrincipalName: principal[i+1:],
+	}, nil
+}
+
+func makeDelegationTokenPrincipalsV1(principals []string) ([]delegationTokenPrincipalV1, error) {
+	if principals == nil {
+		return nil, nil
+	}
+	result := make([]delegationTokenPrincipalV1, len(principals))
+	for i, p := range principals {
+		principal, err := makeDelegationTokenPrincipalV1(p)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = principal
+	}
+	return result, nil
+}
+
+func (t delegationTokenPrincipalV1) String() string {
+	return t.PrincipalType + ":" + t.PrincipalName
+}
+
+func (t delegationTokenPrincipalV1) size() int32 {
+	return sizeofString(t.PrincipalType) +
+		sizeofString(t.PrincipalName)
+}
+
+func (t delegationTokenPrincipalV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.PrincipalType)
+	wb.writeString(t.PrincipalName)
+}
+
+func (t *delegationTokenPrincipalV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.PrincipalType); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.PrincipalName); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMakeDelegationTokenPrincipalV1(t *testing.T) {
+	p, err := makeDelegationTokenPrincipalV1("User:CN=a,O=b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PrincipalType != "User" || p.PrincipalName != "CN=a,O=b" {
+		t.Errorf("unexpected principal: %+v", p)
+	}
+	if s := p.String(); s != "User:CN=a,O=b" {
+		t.Errorf("expected the principal to be formatted back to its original form, got %q", s)
+	}
+
+	if _, err := makeDelegationTokenPrincipalV1("alice"); err == nil {
+		t.Error("expected an error for a principal with no type")
+	}
+}
+
+func TestCreateDelegationTokenResponseV1(t *testing.T) {
+	item := createDelegationTokenResponseV1{
+		Owner:             delegationTokenPrincipalV1{PrincipalType: "User", PrincipalName: "a"},
+		IssueTimestampMS:  1,
+		ExpiryTimestampMS: 2,
+		MaxTimestampMS:    3,
+		TokenID:           "b",
+		HMAC:              []byte("c"),
+		ThrottleTimeMS:    4,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found createDelegationTokenResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDelegationTokenExpiryResponseV1(t *testing.T) {
+	item := delegationTokenExpiryResponseV1{
+		ErrorCode:         int16(DelegationTokenExpired),
+		ExpiryTimestampMS: 1,
+		ThrottleTimeMS:    2,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found delegationTokenExpiryResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDescribeDelegationTokenResponseV1(t *testing.T) {
+	item := describeDelegationTokenResponseV1{
+		Tokens: []describeDelegationTokenResponseTokenV1{
+			{
+				Owner:             delegationTokenPrincipalV1{PrincipalType: "User", PrincipalName: "a"},
+				IssueTimestampMS:  1,
+				ExpiryTimestampMS: 2,
+				MaxTimestampMS:    3,
+				TokenID:           "b",
+				HMAC:              []byte("c"),
+				Renewers: []delegationTokenPrincipalV1{
+					{PrincipalType: "User", PrincipalName: "d"},
+				},
+			},
+		},
+		ThrottleTimeMS: 4,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found describeDelegationTokenResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDescribeDelegationTokenRequestV1Size(t *testing.T) {
+	for _, item := range []describeDelegationTokenRequestV1{
+		{},
+		{Owners: []delegationTokenPrincipalV1{{PrincipalType: "User", PrincipalName: "a"}}},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
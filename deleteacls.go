@@ -0,0 +1,287 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeleteACLsRequest represents a request sent to a kafka cluster to delete the
+// ACL bindings matching filters.
+type DeleteACLsRequest struct {
+	// Filters holds the filters selecting the bindings to delete.
+	Filters []ACLFilter
+}
+
+// DeleteACLsResponse represents the response to a DeleteACLsRequest.
+type DeleteACLsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// Results holds the result of each filter, in the order they appeared in
+	// the request.
+	Results []DeleteACLsResult
+}
+
+// DeleteACLsResult carries the bindings matched by a filter of a
+// DeleteACLsRequest.
+type DeleteACLsResult struct {
+	// Error is set to a non-nil value if the filter could not be applied.
+	Error error
+
+	// MatchingACLs holds the bindings matched by the filter.
+	MatchingACLs []DeleteACLsMatchingACL
+}
+
+// DeleteACLsMatchingACL is a binding matched by a filter of a
+// DeleteACLsRequest.
+type DeleteACLsMatchingACL struct {
+	ACL ACLEntry
+
+	// Error is set to a non-nil value if the binding could not be deleted,
+	// the binding was removed otherwise.
+	Error error
+}
+
+// DeleteACLs sends a DeleteAcls request to the kafka cluster. The brokers must
+// be configured with an authorizer. The method requires kafka 2.0 or above.
+//
+// Errors that apply to a single filter or binding are reported on the results
+// and do not cause the method to fail.
+func (c *Client) DeleteACLs(ctx context.Context, req DeleteACLsRequest) (*DeleteACLsResponse, error) {
+	request := deleteACLsRequestV1{
+		Filters: make([]aclFilterV1, len(req.Filters)),
+	}
+
+	for i, f := range req.Filters {
+		request.Filters[i] = makeACLFilterV1(f)
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.deleteACLs(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.FilterResults) != len(req.Filters) {
+		return nil, fmt.Errorf("expected %d results in the delete acls response, got %d", len(req.Filters), len(response.FilterResults))
+	}
+
+	res := &DeleteACLsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Results:  make([]DeleteACLsResult, len(response.FilterResults)),
+	}
+
+	for i, r := range response.FilterResults {
+		result := DeleteACLsResult{
+			MatchingACLs: make([]DeleteACLsMatchingACL, len(r.MatchingACLs)),
+		}
+		if r.ErrorCode != 0 {
+			result.Error = Error(r.ErrorCode)
+		}
+		for j, acl := range r.MatchingACLs {
+			result.MatchingACLs[j] = DeleteACLsMatchingACL{
+				ACL: ACLEntry{
+					ResourceType:        ResourceType(acl.ResourceType),
+					ResourceName:        acl.ResourceName,
+					ResourcePatternType: PatternType(acl.PatternType),
+					Principal:           acl.Principal,
+					Host:                acl.Host,
+					Operation:           ACLOperationType(acl.Operation),
+					PermissionType:      ACLPermissionType(acl.PermissionType),
+				},
+			}
+			if acl.ErrorCode != 0 {
+				result.MatchingACLs[j].Error = Error(acl.ErrorCode)
+			}
+		}
+		res.Results[i] = result
+	}
+
+	return res, nil
+}
+
+// deleteACLs deletes the ACL bindings matching the filters of the request.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteAcls
+func (c *Conn) deleteACLs(request deleteACLsRequestV1) (deleteACLsResponseV1, error) {
+	var response deleteACLsResponseV1
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(deleteAcls, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return deleteACLsResponseV1{}, err
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteAcls
+type deleteACLsRequestV1 struct {
+	Filters []aclFilterV1
+}
+
+func (t deleteACLsRequestV1) size() int32 {
+	return sizeofArray(len(t.Filters), func(i int) int32 { return t.Filters[i].size() })
+}
+
+func (t deleteACLsRequestV1) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Filters), func(i int) { t.Filters[i].writeTo(wb) })
+}
+
+type deleteACLsResponseMatchingACLV1 struct {
+	ErrorCode      int16
+	ErrorMessage   string
+	ResourceType   int8
+	ResourceName   string
+	PatternType    int8
+	Principal      string
+	Host           string
+	Operation      int8
+	PermissionType int8
+}
+
+func (t deleteACLsResponseMatchingACLV1) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage) +
+		sizeofInt8(t.ResourceType) +
+		sizeofString(t.ResourceName) +
+		sizeofInt8(t.PatternType) +
+		sizeofString(t.Principal) +
+		sizeofString(t.Host) +
+		sizeofInt8(t.Operation) +
+		sizeofInt8(t.PermissionType)
+}
+
+func (t deleteACLsResponseMatchingACLV1) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+	wb.writeInt8(t.ResourceType)
+	wb.writeString(t.ResourceName)
+	wb.writeInt8(t.PatternType)
+	wb.writeString(t.Principal)
+	wb.writeString(t.Host)
+	wb.writeInt8(t.Operation)
+	wb.writeInt8(t.PermissionType)
+}
+
+func (t *deleteACLsResponseMatchingACLV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.ResourceType); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ResourceName); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.PatternType); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.Principal); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.Host); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.Operation); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.PermissionType); err != nil {
+		return
+	}
+	return
+}
+
+type deleteACLsResponseFilterResultV1 struct {
+	ErrorCode    int16
+	ErrorMessage string
+	MatchingACLs []deleteACLsResponseMatchingACLV1
+}
+
+func (t deleteACLsResponseFilterResultV1) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage) +
+		sizeofArray(len(t.MatchingACLs), func(i int) int32 { return t.MatchingACLs[i].size() })
+}
+
+func (t deleteACLsResponseFilterResultV1) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+	wb.writeArray(len(t.MatchingACLs), func(i int) { t.MatchingACLs[i].writeTo(wb) })
+}
+
+func (t *deleteACLsResponseFilterResultV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var acl deleteACLsResponseMatchingACLV1
+		if fnRemain, fnErr = (&acl).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.MatchingACLs = append(t.MatchingACLs, acl)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteAcls
+type deleteACLsResponseV1 struct {
+	ThrottleTimeMS int32
+	FilterResults  []deleteACLsResponseFilterResultV1
+}
+
+func (t deleteACLsResponseV1) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.FilterResults), func(i int) int32 { return t.FilterResults[i].size() })
+}
+
+func (t deleteACLsResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.FilterResults), func(i int) { t.FilterResults[i].writeTo(wb) })
+}
+
+func (t *deleteACLsResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var result deleteACLsResponseFilterResultV1
+		if fnRemain, fnErr = (&result).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.FilterResults = append(t.FilterResults, result)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
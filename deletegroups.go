@@ -0,0 +1,198 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DeleteGroupsRequest represents a request sent to a kafka cluster to delete
+// consumer groups.
+type DeleteGroupsRequest struct {
+	// GroupIDs holds the IDs of the groups to delete.
+	GroupIDs []string
+}
+
+// DeleteGroupsResponse represents the response to a DeleteGroupsRequest.
+type DeleteGroupsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinators.
+	Throttle time.Duration
+
+	// Errors holds the result of deleting each group, indexed by group ID. The
+	// value is nil if the group was deleted, or an error such as NonEmptyGroup
+	// if the group still has members, or GroupIdNotFound if it does not exist.
+	Errors map[string]error
+}
+
+// DeleteGroups deletes groups of the kafka cluster. Each group is deleted by
+// its coordinator, the requests to different coordinators are sent
+// concurrently. The API was introduced in kafka 1.1.
+//
+// Errors that apply to a single group are reported in the Errors field of the
+// response and do not cause the method to fail.
+func (c *Client) DeleteGroups(ctx context.Context, req DeleteGroupsRequest) (*DeleteGroupsResponse, error) {
+	coordinators, errs, err := c.groupCoordinators(ctx, req.GroupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DeleteGroupsResponse{
+		Errors: make(map[string]error, len(req.GroupIDs)),
+	}
+	for groupID, err := range errs {
+		res.Errors[groupID] = err
+	}
+
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for b, groupIDs := range coordinators {
+		wg.Add(1)
+		go func(b Broker, groupIDs []string) {
+			defer wg.Done()
+
+			response, err := c.deleteCoordinatorGroups(ctx, b, groupIDs)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				for _, groupID := range groupIDs {
+					res.Errors[groupID] = err
+				}
+				return
+			}
+
+			if throttle := duration(response.ThrottleTimeMS); throttle > res.Throttle {
+				res.Throttle = throttle
+			}
+
+			for _, r := range response.Results {
+				if r.ErrorCode != 0 {
+					res.Errors[r.GroupID] = Error(r.ErrorCode)
+				} else {
+					res.Errors[r.GroupID] = nil
+				}
+			}
+
+			for _, groupID := range groupIDs {
+				if _, ok := res.Errors[groupID]; !ok {
+					res.Errors[groupID] = fmt.Errorf("group %s missing from the delete groups response", groupID)
+				}
+			}
+		}(b, groupIDs)
+	}
+
+	wg.Wait()
+	return res, nil
+}
+
+// deleteCoordinatorGroups deletes the groups coordinated by the broker b.
+func (c *Client) deleteCoordinatorGroups(ctx context.Context, b Broker, groupIDs []string) (deleteGroupsResponseV0, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return deleteGroupsResponseV0{}, err
+	}
+	defer conn.Close()
+	return conn.deleteGroups(deleteGroupsRequestV0{GroupIDs: groupIDs})
+}
+
+// deleteGroups deletes the requested groups, the broker must be the
+// coordinator of the groups.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteGroups
+func (c *Conn) deleteGroups(request deleteGroupsRequestV0) (deleteGroupsResponseV0, error) {
+	var response deleteGroupsResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(deleteGroups, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return deleteGroupsResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteGroups
+type deleteGroupsRequestV0 struct {
+	GroupIDs []string
+}
+
+func (t deleteGroupsRequestV0) size() int32 {
+	return sizeofStringArray(t.GroupIDs)
+}
+
+func (t deleteGroupsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeStringArray(t.GroupIDs)
+}
+
+type deleteGroupsResponseResultV0 struct {
+	GroupID   string
+	ErrorCode int16
+}
+
+func (t deleteGroupsResponseResultV0) size() int32 {
+	return sizeofString(t.GroupID) +
+		sizeofInt16(t.ErrorCode)
+}
+
+func (t deleteGroupsResponseResultV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.GroupID)
+	wb.writeInt16(t.ErrorCode)
+}
+
+func (t *deleteGroupsResponseResultV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.GroupID); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteGroups
+type deleteGroupsResponseV0 struct {
+	ThrottleTimeMS int32
+	Results        []deleteGroupsResponseResultV0
+}
+
+func (t deleteGroupsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Results), func(i int) int32 { return t.Results[i].size() })
+}
+
+func (t deleteGroupsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Results), func(i int) { t.Results[i].writeTo(wb) })
+}
+
+func (t *deleteGroupsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var result deleteGroupsResponseResultV0
+		if fnRemain, fnErr = (&result).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Results = append(t.Results, result)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
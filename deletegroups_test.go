@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestDeleteGroupsResponseV0(t *testing.T) {
+	item := deleteGroupsResponseV0{
+		ThrottleTimeMS: 1,
+		Results: []deleteGroupsResponseResultV0{
+			{GroupID: "a"},
+			{GroupID: "b", ErrorCode: int16(NonEmptyGroup)},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found deleteGroupsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func testClientDeleteGroups(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("1.1.0") {
+		t.Skip("delete groups requires kafka 1.1.0 or newer")
+		return
+	}
+
+	conn, err := Dial("tcp", "localhost:9092")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	groupID := makeGroupID()
+	_, _, stop := createGroup(t, conn, groupID)
+
+	missingGroupID := makeGroupID()
+
+	res, err := c.DeleteGroups(ctx, DeleteGroupsRequest{
+		GroupIDs: []string{groupID, missingGroupID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Errors[groupID]; err != NonEmptyGroup {
+		t.Errorf("expected %v when deleting a group with members, got %v", NonEmptyGroup, err)
+	}
+	if err, ok := res.Errors[missingGroupID]; !ok || err == nil {
+		t.Errorf("expected an error when deleting a group that does not exist, got %v", err)
+	}
+
+	stop()
+
+	res, err = c.DeleteGroups(ctx, DeleteGroupsRequest{
+		GroupIDs: []string{groupID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := res.Errors[groupID]; !ok || err != nil {
+		t.Errorf("expected the group to be deleted, got %v", err)
+	}
+}
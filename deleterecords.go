@@ -0,0 +1,357 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeleteRecordsRequest represents a request sent to a kafka cluster to delete
+// the records of partitions up to an offset.
+type DeleteRecordsRequest struct {
+	// Topics holds the partitions to delete the records of, indexed by topic
+	// name.
+	Topics map[string][]DeleteRecordsRequestPartition
+
+	// Timeout is how long the leaders wait for the low watermarks of the
+	// partitions to be updated on the followers, it defaults to the time
+	// remaining until the deadline of the context.
+	Timeout time.Duration
+}
+
+// DeleteRecordsRequestPartition designates a partition and the offset up to
+// which records are deleted.
+type DeleteRecordsRequestPartition struct {
+	Partition int
+
+	// Offset is the offset up to which records are deleted (exclusive), use
+	// LastOffset (-1) to delete all records up to the high watermark.
+	Offset int64
+}
+
+// DeleteRecordsResponse represents the response to a DeleteRecordsRequest.
+type DeleteRecordsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// brokers.
+	Throttle time.Duration
+
+	// Topics holds the result of deleting the records of each partition,
+	// indexed by topic name.
+	Topics map[string][]DeleteRecordsResponsePartition
+}
+
+// DeleteRecordsResponsePartition carries the result of deleting the records of
+// a partition.
+type DeleteRecordsResponsePartition struct {
+	Partition int
+
+	// LowWatermark is the new first offset of the partition.
+	LowWatermark int64
+
+	// Error is set to a non-nil value if the records could not be deleted,
+	// for example OffsetOutOfRange if the offset is beyond the high watermark.
+	Error error
+}
+
+// DeleteRecords deletes the records of partitions up to the requested offsets.
+// Partitions are grouped by leader, the requests to different leaders are sent
+// concurrently. The API was introduced in kafka 0.11.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) DeleteRecords(ctx context.Context, req DeleteRecordsRequest) (*DeleteRecordsResponse, error) {
+	topics := make([]string, 0, len(req.Topics))
+	for topic := range req.Topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	leaders, err := c.partitionLeaders(ctx, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DeleteRecordsResponse{
+		Topics: make(map[string][]DeleteRecordsResponsePartition, len(req.Topics)),
+	}
+
+	requests := make(map[Broker]*deleteRecordsRequestV0)
+
+	for _, topic := range topics {
+		for _, p := range req.Topics[topic] {
+			leader, ok := leaders[topicPartition{topic: topic, partition: p.Partition}]
+			if !ok {
+				res.Topics[topic] = append(res.Topics[topic], DeleteRecordsResponsePartition{
+					Partition:    p.Partition,
+					LowWatermark: -1,
+					Error:        LeaderNotAvailable,
+				})
+				continue
+			}
+
+			request := requests[leader]
+			if request == nil {
+				request = &deleteRecordsRequestV0{Timeout: protocolTimeout(ctx, req.Timeout)}
+				requests[leader] = request
+			}
+			request.add(topic, int32(p.Partition), p.Offset)
+		}
+	}
+
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for b, request := range requests {
+		wg.Add(1)
+		go func(b Broker, request deleteRecordsRequestV0) {
+			defer wg.Done()
+
+			response, err := c.deleteLeaderRecords(ctx, b, request)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				for _, t := range request.Topics {
+					for _, p := range t.Partitions {
+						res.Topics[t.Name] = append(res.Topics[t.Name], DeleteRecordsResponsePartition{
+							Partition:    int(p.PartitionIndex),
+							LowWatermark: -1,
+							Error:        err,
+						})
+					}
+				}
+				return
+			}
+
+			if throttle := duration(response.ThrottleTimeMS); throttle > res.Throttle {
+				res.Throttle = throttle
+			}
+
+			for _, t := range response.Topics {
+				for _, p := range t.Partitions {
+					partition := DeleteRecordsResponsePartition{
+						Partition:    int(p.PartitionIndex),
+						LowWatermark: p.LowWatermark,
+					}
+					if p.ErrorCode != 0 {
+						partition.Error = Error(p.ErrorCode)
+					}
+					res.Topics[t.Name] = append(res.Topics[t.Name], partition)
+				}
+			}
+		}(b, *request)
+	}
+
+	wg.Wait()
+
+	for _, partitions := range res.Topics {
+		sort.Slice(partitions, func(i, j int) bool {
+			return partitions[i].Partition < partitions[j].Partition
+		})
+	}
+
+	return res, nil
+}
+
+// deleteLeaderRecords deletes the records of partitions led by the broker b.
+func (c *Client) deleteLeaderRecords(ctx context.Context, b Broker, request deleteRecordsRequestV0) (deleteRecordsResponseV0, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return deleteRecordsResponseV0{}, err
+	}
+	defer conn.Close()
+	return conn.deleteRecords(request)
+}
+
+// deleteRecords deletes the records of the requested partitions, the broker
+// must be the leader of the partitions.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteRecords
+func (c *Conn) deleteRecords(request deleteRecordsRequestV0) (deleteRecordsResponseV0, error) {
+	var response deleteRecordsResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			if request.Timeout == 0 {
+				now := time.Now()
+				deadline = adjustDeadlineForRTT(deadline, now, defaultRTT)
+				request.Timeout = milliseconds(deadlineToTimeout(deadline, now))
+			}
+			return c.writeRequest(deleteRecords, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return deleteRecordsResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+type deleteRecordsRequestPartitionV0 struct {
+	PartitionIndex int32
+	Offset         int64
+}
+
+func (t deleteRecordsRequestPartitionV0) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofInt64(t.Offset)
+}
+
+func (t deleteRecordsRequestPartitionV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeInt64(t.Offset)
+}
+
+type deleteRecordsRequestTopicV0 struct {
+	Name       string
+	Partitions []deleteRecordsRequestPartitionV0
+}
+
+func (t deleteRecordsRequestTopicV0) size() int32 {
+	return sizeofString(t.Name) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t deleteRecordsRequestTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Name)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteRecords
+type deleteRecordsRequestV0 struct {
+	Topics []deleteRecordsRequestTopicV0
+
+	// Timeout ms to wait for the records to be deleted on all the replicas.
+	Timeout int32
+}
+
+// add appends a partition to the request, topics are expected to be added in
+// order.
+func (t *deleteRecordsRequestV0) add(topic string, partition int32, offset int64) {
+	if n := len(t.Topics); n == 0 || t.Topics[n-1].Name != topic {
+		t.Topics = append(t.Topics, deleteRecordsRequestTopicV0{Name: topic})
+	}
+	last := &t.Topics[len(t.Topics)-1]
+	last.Partitions = append(last.Partitions, deleteRecordsRequestPartitionV0{
+		PartitionIndex: partition,
+		Offset:         offset,
+	})
+}
+
+func (t deleteRecordsRequestV0) size() int32 {
+	return sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofInt32(t.Timeout)
+}
+
+func (t deleteRecordsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeInt32(t.Timeout)
+}
+
+type deleteRecordsResponsePartitionV0 struct {
+	PartitionIndex int32
+	LowWatermark   int64
+	ErrorCode      int16
+}
+
+func (t deleteRecordsResponsePartitionV0) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofInt64(t.LowWatermark) +
+		sizeofInt16(t.ErrorCode)
+}
+
+func (t deleteRecordsResponsePartitionV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeInt64(t.LowWatermark)
+	wb.writeInt16(t.ErrorCode)
+}
+
+func (t *deleteRecordsResponsePartitionV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.PartitionIndex); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.LowWatermark); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	return
+}
+
+type deleteRecordsResponseTopicV0 struct {
+	Name       string
+	Partitions []deleteRecordsResponsePartitionV0
+}
+
+func (t deleteRecordsResponseTopicV0) size() int32 {
+	return sizeofString(t.Name) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t deleteRecordsResponseTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Name)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+func (t *deleteRecordsResponseTopicV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.Name); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition deleteRecordsResponsePartitionV0
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteRecords
+type deleteRecordsResponseV0 struct {
+	ThrottleTimeMS int32
+	Topics         []deleteRecordsResponseTopicV0
+}
+
+func (t deleteRecordsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t deleteRecordsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
+
+func (t *deleteRecordsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic deleteRecordsResponseTopicV0
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestDeleteRecordsResponseV0(t *testing.T) {
+	item := deleteRecordsResponseV0{
+		ThrottleTimeMS: 1,
+		Topics: []deleteRecordsResponseTopicV0{
+			{
+				Name: "a",
+				Partitions: []deleteRecordsResponsePartitionV0{
+					{PartitionIndex: 0, LowWatermark: 42},
+					{PartitionIndex: 1, LowWatermark: -1, ErrorCode: int16(OffsetOutOfRange)},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found deleteRecordsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDeleteRecordsRequestV0Add(t *testing.T) {
+	request := deleteRecordsRequestV0{}
+	request.add("a", 0, 1)
+	request.add("a", 1, 2)
+	request.add("b", 0, -1)
+
+	expected := deleteRecordsRequestV0{
+		Topics: []deleteRecordsRequestTopicV0{
+			{Name: "a", Partitions: []deleteRecordsRequestPartitionV0{{0, 1}, {1, 2}}},
+			{Name: "b", Partitions: []deleteRecordsRequestPartitionV0{{0, -1}}},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, request) {
+		t.Errorf("expected %+v, got %+v", expected, request)
+	}
+}
+
+func testClientDeleteRecords(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("0.11.0") {
+		t.Skip("delete records requires kafka 0.11.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 2)
+
+	// Write all the messages to partition 0.
+	w := NewWriter(WriterConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     topic,
+		Balancer:  BalancerFunc(func(Message, ...int) int { return 0 }),
+		BatchSize: 1,
+	})
+	if err := w.WriteMessages(ctx, makeTestSequence(10)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	res, err := c.DeleteRecords(ctx, DeleteRecordsRequest{
+		Topics: map[string][]DeleteRecordsRequestPartition{
+			topic: {
+				{Partition: 0, Offset: 5},
+				{Partition: 1, Offset: 100},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partitions := res.Topics[topic]
+	if len(partitions) != 2 {
+		t.Fatalf("expected results for 2 partitions, got %d", len(partitions))
+	}
+	if p := partitions[0]; p.Error != nil || p.LowWatermark != 5 {
+		t.Errorf("expected partition 0 to be truncated at offset 5, got %d (%v)", p.LowWatermark, p.Error)
+	}
+	if p := partitions[1]; p.Error != OffsetOutOfRange {
+		t.Errorf("expected %v for partition 1, got %v", OffsetOutOfRange, p.Error)
+	}
+}
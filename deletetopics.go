@@ -2,9 +2,99 @@ package kafka
 
 import (
 	"bufio"
+	"context"
 	"time"
 )
 
+// DeleteTopicsRequest represents a request sent to a kafka cluster to delete
+// topics.
+type DeleteTopicsRequest struct {
+	// Topics holds the names of the topics to delete.
+	Topics []string
+
+	// TopicIDs holds the IDs of the topics to delete. Deleting a topic by ID
+	// guarantees that a topic created again with the same name after its ID
+	// was looked up is not deleted.
+	TopicIDs []TopicID
+
+	// Timeout is how long the controller waits for the topics to be deleted,
+	// it defaults to the time remaining until the deadline of the context.
+	Timeout time.Duration
+}
+
+// DeleteTopicsResponse represents the response to a DeleteTopicsRequest.
+type DeleteTopicsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// controller.
+	Throttle time.Duration
+
+	// Topics holds the result for each topic of the request.
+	Topics []DeleteTopicsResponseTopic
+}
+
+// DeleteTopicsResponseTopic carries the result of deleting a topic.
+type DeleteTopicsResponseTopic struct {
+	// Name and ID identify the deleted topic, the name is empty if the topic
+	// was designated by an ID that does not exist.
+	Name string
+	ID   TopicID
+
+	// Error is set to a non-nil value if the topic could not be deleted, for
+	// example UnknownTopicOrPartition, or UnknownTopicID if the topic was
+	// designated by an ID.
+	Error error
+
+	// ErrorMessage holds the message sent by the broker alongside Error.
+	ErrorMessage string
+}
+
+// DeleteTopics sends a DeleteTopics request to the controller of the kafka
+// cluster. The method requires kafka 2.8 or above.
+//
+// Errors that apply to a single topic are reported on the topic and do not
+// cause the method to fail.
+func (c *Client) DeleteTopics(ctx context.Context, req DeleteTopicsRequest) (*DeleteTopicsResponse, error) {
+	request := deleteTopicsRequestV6{
+		Topics:  make([]deleteTopicsRequestTopicV6, 0, len(req.Topics)+len(req.TopicIDs)),
+		Timeout: protocolTimeout(ctx, req.Timeout),
+	}
+	for i := range req.Topics {
+		request.Topics = append(request.Topics, deleteTopicsRequestTopicV6{Name: &req.Topics[i]})
+	}
+	for _, id := range req.TopicIDs {
+		request.Topics = append(request.Topics, deleteTopicsRequestTopicV6{TopicID: id})
+	}
+
+	conn, err := c.connectController(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.deleteTopicsV6(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DeleteTopicsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make([]DeleteTopicsResponseTopic, len(response.Responses)),
+	}
+
+	for i, t := range response.Responses {
+		res.Topics[i] = DeleteTopicsResponseTopic{
+			Name:         t.Name,
+			ID:           t.TopicID,
+			ErrorMessage: t.ErrorMessage,
+		}
+		if t.ErrorCode != 0 {
+			res.Topics[i].Error = Error(t.ErrorCode)
+		}
+	}
+
+	return res, nil
+}
+
 // See http://kafka.apache.org/protocol.html#The_Messages_DeleteTopics
 type deleteTopicsRequestV0 struct {
 	// Topics holds the topic names
@@ -112,3 +202,153 @@ func (c *Conn) deleteTopics(request deleteTopicsRequestV0) (deleteTopicsResponse
 	}
 	return response, nil
 }
+
+// deleteTopicsV6 deletes the topics designated by their names or IDs. Unlike
+// deleteTopics, errors on topics do not cause the method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteTopics
+func (c *Conn) deleteTopicsV6(request deleteTopicsRequestV6) (deleteTopicsResponseV6, error) {
+	var response deleteTopicsResponseV6
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			if request.Timeout == 0 {
+				now := time.Now()
+				deadline = adjustDeadlineForRTT(deadline, now, defaultRTT)
+				request.Timeout = milliseconds(deadlineToTimeout(deadline, now))
+			}
+			return c.writeFlexibleRequest(deleteTopics, v6, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return deleteTopicsResponseV6{}, err
+	}
+
+	return response, nil
+}
+
+type deleteTopicsRequestTopicV6 struct {
+	// Name is null when the topic is designated by its ID.
+	Name    *string
+	TopicID [16]byte
+}
+
+func (t deleteTopicsRequestTopicV6) size() int32 {
+	return sizeofCompactNullableString(t.Name) +
+		sizeofUUID(t.TopicID) +
+		sizeofTaggedFields()
+}
+
+func (t deleteTopicsRequestTopicV6) writeTo(wb *writeBuffer) {
+	wb.writeCompactNullableString(t.Name)
+	wb.writeUUID(t.TopicID)
+	wb.writeTaggedFields()
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteTopics
+type deleteTopicsRequestV6 struct {
+	Topics  []deleteTopicsRequestTopicV6
+	Timeout int32
+}
+
+func (t deleteTopicsRequestV6) size() int32 {
+	return sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofInt32(t.Timeout) +
+		sizeofTaggedFields()
+}
+
+func (t deleteTopicsRequestV6) writeTo(wb *writeBuffer) {
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeInt32(t.Timeout)
+	wb.writeTaggedFields()
+}
+
+type deleteTopicsResponseTopicV6 struct {
+	Name         string
+	TopicID      [16]byte
+	ErrorCode    int16
+	ErrorMessage string
+}
+
+func (t deleteTopicsResponseTopicV6) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofUUID(t.TopicID) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofTaggedFields()
+}
+
+func (t deleteTopicsResponseTopicV6) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeUUID(t.TopicID)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeTaggedFields()
+}
+
+func (t *deleteTopicsResponseTopicV6) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.Name); err != nil {
+		return
+	}
+	if remain, err = readUUID(r, remain, &t.TopicID); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DeleteTopics
+type deleteTopicsResponseV6 struct {
+	ThrottleTimeMS int32
+	Responses      []deleteTopicsResponseTopicV6
+}
+
+func (t deleteTopicsResponseV6) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofCompactArray(len(t.Responses), func(i int) int32 { return t.Responses[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t deleteTopicsResponseV6) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeCompactArray(len(t.Responses), func(i int) { t.Responses[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *deleteTopicsResponseV6) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic deleteTopicsResponseTopicV6
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Responses = append(t.Responses, topic)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
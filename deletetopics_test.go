@@ -3,8 +3,11 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
 )
 
 func TestDeleteTopicsResponseV1(t *testing.T) {
@@ -33,3 +36,94 @@ func TestDeleteTopicsResponseV1(t *testing.T) {
 		t.Fatal("expected item and found to be the same")
 	}
 }
+
+func TestDeleteTopicsResponseV6(t *testing.T) {
+	item := deleteTopicsResponseV6{
+		ThrottleTimeMS: 1,
+		Responses: []deleteTopicsResponseTopicV6{
+			{Name: "a", TopicID: [16]byte{1, 2, 3}},
+			{TopicID: [16]byte{4, 5, 6}, ErrorCode: int16(UnknownTopicID), ErrorMessage: "b"},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Fatalf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found deleteTopicsResponseV6
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remain != 0 {
+		t.Fatalf("expected 0 remain, got %v", remain)
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Fatal("expected item and found to be the same")
+	}
+}
+
+func TestDeleteTopicsRequestV6Size(t *testing.T) {
+	name := "a"
+	item := deleteTopicsRequestV6{
+		Topics: []deleteTopicsRequestTopicV6{
+			{Name: &name},
+			{TopicID: [16]byte{1, 2, 3}},
+		},
+		Timeout: 1000,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Fatalf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+}
+
+func testClientDeleteTopics(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.8.0") {
+		t.Skip("deleting topics by id requires kafka 2.8.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	metadata, err := c.Metadata(ctx, MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := metadata.Topics[0].ID
+
+	res, err := c.DeleteTopics(ctx, DeleteTopicsRequest{
+		TopicIDs: []TopicID{id, {1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Topics) != 2 {
+		t.Fatalf("expected results for 2 topics, got %d", len(res.Topics))
+	}
+	for _, r := range res.Topics {
+		switch r.ID {
+		case id:
+			if r.Error != nil {
+				t.Errorf("deleting topic %s failed: %v", topic, r.Error)
+			}
+			if r.Name != topic {
+				t.Errorf("expected the name of the deleted topic to be %s, got %s", topic, r.Name)
+			}
+		default:
+			if r.Error != UnknownTopicID {
+				t.Errorf("expected UnknownTopicID deleting topic %s, got %v", r.ID, r.Error)
+			}
+		}
+	}
+}
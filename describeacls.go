@@ -0,0 +1,230 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// DescribeACLsRequest represents a request sent to a kafka cluster to list the
+// ACL bindings matching a filter.
+type DescribeACLsRequest struct {
+	Filter ACLFilter
+}
+
+// DescribeACLsResponse represents the response to a DescribeACLsRequest.
+type DescribeACLsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// ACLs holds the bindings matching the filter.
+	ACLs []ACLEntry
+}
+
+// DescribeACLs sends a DescribeAcls request to the kafka cluster. The brokers
+// must be configured with an authorizer. The method requires kafka 2.0 or
+// above.
+func (c *Client) DescribeACLs(ctx context.Context, req DescribeACLsRequest) (*DescribeACLsResponse, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.describeACLs(describeACLsRequestV1{
+		Filter: makeACLFilterV1(req.Filter),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DescribeACLsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+	}
+
+	for _, r := range response.Resources {
+		for _, acl := range r.ACLs {
+			res.ACLs = append(res.ACLs, ACLEntry{
+				ResourceType:        ResourceType(r.ResourceType),
+				ResourceName:        r.ResourceName,
+				ResourcePatternType: PatternType(r.PatternType),
+				Principal:           acl.Principal,
+				Host:                acl.Host,
+				Operation:           ACLOperationType(acl.Operation),
+				PermissionType:      ACLPermissionType(acl.PermissionType),
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// describeACLs lists the ACL bindings matching the filter of the request.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeAcls
+func (c *Conn) describeACLs(request describeACLsRequestV1) (describeACLsResponseV1, error) {
+	var response describeACLsResponseV1
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(describeAcls, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return describeACLsResponseV1{}, err
+	}
+	if response.ErrorCode != 0 {
+		return describeACLsResponseV1{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeAcls
+type describeACLsRequestV1 struct {
+	Filter aclFilterV1
+}
+
+func (t describeACLsRequestV1) size() int32 {
+	return t.Filter.size()
+}
+
+func (t describeACLsRequestV1) writeTo(wb *writeBuffer) {
+	t.Filter.writeTo(wb)
+}
+
+type describeACLsResponseACLV1 struct {
+	Principal      string
+	Host           string
+	Operation      int8
+	PermissionType int8
+}
+
+func (t describeACLsResponseACLV1) size() int32 {
+	return sizeofString(t.Principal) +
+		sizeofString(t.Host) +
+		sizeofInt8(t.Operation) +
+		sizeofInt8(t.PermissionType)
+}
+
+func (t describeACLsResponseACLV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Principal)
+	wb.writeString(t.Host)
+	wb.writeInt8(t.Operation)
+	wb.writeInt8(t.PermissionType)
+}
+
+func (t *describeACLsResponseACLV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.Principal); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.Host); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.Operation); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.PermissionType); err != nil {
+		return
+	}
+	return
+}
+
+type describeACLsResponseResourceV1 struct {
+	ResourceType int8
+	ResourceName string
+	PatternType  int8
+	ACLs         []describeACLsResponseACLV1
+}
+
+func (t describeACLsResponseResourceV1) size() int32 {
+	return sizeofInt8(t.ResourceType) +
+		sizeofString(t.ResourceName) +
+		sizeofInt8(t.PatternType) +
+		sizeofArray(len(t.ACLs), func(i int) int32 { return t.ACLs[i].size() })
+}
+
+func (t describeACLsResponseResourceV1) writeTo(wb *writeBuffer) {
+	wb.writeInt8(t.ResourceType)
+	wb.writeString(t.ResourceName)
+	wb.writeInt8(t.PatternType)
+	wb.writeArray(len(t.ACLs), func(i int) { t.ACLs[i].writeTo(wb) })
+}
+
+func (t *describeACLsResponseResourceV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt8(r, size, &t.ResourceType); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ResourceName); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.PatternType); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var acl describeACLsResponseACLV1
+		if fnRemain, fnErr = (&acl).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.ACLs = append(t.ACLs, acl)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeAcls
+type describeACLsResponseV1 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	ErrorMessage   string
+	Resources      []describeACLsResponseResourceV1
+}
+
+func (t describeACLsResponseV1) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage) +
+		sizeofArray(len(t.Resources), func(i int) int32 { return t.Resources[i].size() })
+}
+
+func (t describeACLsResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+	wb.writeArray(len(t.Resources), func(i int) { t.Resources[i].writeTo(wb) })
+}
+
+func (t *describeACLsResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var resource describeACLsResponseResourceV1
+		if fnRemain, fnErr = (&resource).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Resources = append(t.Resources, resource)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
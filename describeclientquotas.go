@@ -0,0 +1,289 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// ClientQuotaMatchType designates how a component of a DescribeClientQuotas
+// request matches entities.
+type ClientQuotaMatchType int8
+
+const (
+	// ClientQuotaMatchExact matches the entity with the name of the
+	// component.
+	ClientQuotaMatchExact ClientQuotaMatchType = 0
+
+	// ClientQuotaMatchDefault matches the default entity of the type of the
+	// component.
+	ClientQuotaMatchDefault ClientQuotaMatchType = 1
+
+	// ClientQuotaMatchAny matches all the entities of the type of the
+	// component, including the default entity.
+	ClientQuotaMatchAny ClientQuotaMatchType = 2
+)
+
+// DescribeClientQuotasRequest represents a request sent to a kafka cluster to
+// describe the client quotas matching a filter.
+type DescribeClientQuotasRequest struct {
+	// Components holds the filter applied to each type of entity component.
+	Components []DescribeClientQuotasRequestComponent
+
+	// When Strict is true, only the quotas of entities with no other
+	// components than the ones of the filter are returned.
+	Strict bool
+}
+
+// DescribeClientQuotasRequestComponent is a filter on a component of the
+// entities of client quotas.
+type DescribeClientQuotasRequestComponent struct {
+	EntityType ClientQuotaEntityType
+	MatchType  ClientQuotaMatchType
+
+	// Match is the name of the entity to match, it is only used with
+	// ClientQuotaMatchExact.
+	Match string
+}
+
+// DescribeClientQuotasResponse represents the response to a
+// DescribeClientQuotasRequest.
+type DescribeClientQuotasResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// Entries holds the quotas of each entity matching the filter.
+	Entries []DescribeClientQuotasResponseEntry
+}
+
+// DescribeClientQuotasResponseEntry carries the quotas of an entity.
+type DescribeClientQuotasResponseEntry struct {
+	// Entity holds the components of the entity that the quotas were matched
+	// on, default entities have their Default field set.
+	Entity []ClientQuotaEntityComponent
+
+	// Values holds the quota values indexed by key, for example
+	// ClientQuotaProducerByteRate.
+	Values map[string]float64
+}
+
+// DescribeClientQuotas sends a DescribeClientQuotas request to the kafka
+// cluster. The API was introduced in kafka 2.6 (KIP-546).
+func (c *Client) DescribeClientQuotas(ctx context.Context, req DescribeClientQuotasRequest) (*DescribeClientQuotasResponse, error) {
+	request := describeClientQuotasRequestV0{
+		Components: make([]describeClientQuotasRequestComponentV0, len(req.Components)),
+		Strict:     req.Strict,
+	}
+
+	for i, component := range req.Components {
+		request.Components[i] = describeClientQuotasRequestComponentV0{
+			EntityType: string(component.EntityType),
+			MatchType:  int8(component.MatchType),
+		}
+		if component.MatchType == ClientQuotaMatchExact {
+			match := component.Match
+			request.Components[i].Match = &match
+		}
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.describeClientQuotas(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DescribeClientQuotasResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Entries:  make([]DescribeClientQuotasResponseEntry, len(response.Entries)),
+	}
+
+	for i, entry := range response.Entries {
+		values := make(map[string]float64, len(entry.Values))
+		for _, v := range entry.Values {
+			values[v.Key] = v.Value
+		}
+		res.Entries[i] = DescribeClientQuotasResponseEntry{
+			Entity: makeClientQuotaEntity(entry.Entity),
+			Values: values,
+		}
+	}
+
+	return res, nil
+}
+
+// describeClientQuotas describes the client quotas matching the components of
+// the request.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeClientQuotas
+func (c *Conn) describeClientQuotas(request describeClientQuotasRequestV0) (describeClientQuotasResponseV0, error) {
+	var response describeClientQuotasResponseV0
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(describeClientQuotas, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return describeClientQuotasResponseV0{}, err
+	}
+	if response.ErrorCode != 0 {
+		return describeClientQuotasResponseV0{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+type describeClientQuotasRequestComponentV0 struct {
+	EntityType string
+	MatchType  int8
+
+	// Match is null unless the match type is exact.
+	Match *string
+}
+
+func (t describeClientQuotasRequestComponentV0) size() int32 {
+	return sizeofString(t.EntityType) +
+		sizeofInt8(t.MatchType) +
+		sizeofNullableString(t.Match)
+}
+
+func (t describeClientQuotasRequestComponentV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.EntityType)
+	wb.writeInt8(t.MatchType)
+	wb.writeNullableString(t.Match)
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeClientQuotas
+type describeClientQuotasRequestV0 struct {
+	Components []describeClientQuotasRequestComponentV0
+	Strict     bool
+}
+
+func (t describeClientQuotasRequestV0) size() int32 {
+	return sizeofArray(len(t.Components), func(i int) int32 { return t.Components[i].size() }) +
+		sizeofBool(t.Strict)
+}
+
+func (t describeClientQuotasRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Components), func(i int) { t.Components[i].writeTo(wb) })
+	wb.writeBool(t.Strict)
+}
+
+type describeClientQuotasResponseValueV0 struct {
+	Key   string
+	Value float64
+}
+
+func (t describeClientQuotasResponseValueV0) size() int32 {
+	return sizeofString(t.Key) +
+		sizeofFloat64(t.Value)
+}
+
+func (t describeClientQuotasResponseValueV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Key)
+	wb.writeFloat64(t.Value)
+}
+
+func (t *describeClientQuotasResponseValueV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.Key); err != nil {
+		return
+	}
+	if remain, err = readFloat64(r, remain, &t.Value); err != nil {
+		return
+	}
+	return
+}
+
+type describeClientQuotasResponseEntryV0 struct {
+	Entity []clientQuotaEntityComponentV0
+	Values []describeClientQuotasResponseValueV0
+}
+
+func (t describeClientQuotasResponseEntryV0) size() int32 {
+	return sizeofArray(len(t.Entity), func(i int) int32 { return t.Entity[i].size() }) +
+		sizeofArray(len(t.Values), func(i int) int32 { return t.Values[i].size() })
+}
+
+func (t describeClientQuotasResponseEntryV0) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Entity), func(i int) { t.Entity[i].writeTo(wb) })
+	wb.writeArray(len(t.Values), func(i int) { t.Values[i].writeTo(wb) })
+}
+
+func (t *describeClientQuotasResponseEntryV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readClientQuotaEntityV0(r, size, &t.Entity); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var value describeClientQuotasResponseValueV0
+		if fnRemain, fnErr = (&value).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Values = append(t.Values, value)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeClientQuotas
+type describeClientQuotasResponseV0 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	ErrorMessage   string
+
+	// Entries is null when ErrorCode is set.
+	Entries []describeClientQuotasResponseEntryV0
+}
+
+func (t describeClientQuotasResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage) +
+		sizeofArray(len(t.Entries), func(i int) int32 { return t.Entries[i].size() })
+}
+
+func (t describeClientQuotasResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+	wb.writeArray(len(t.Entries), func(i int) { t.Entries[i].writeTo(wb) })
+}
+
+func (t *describeClientQuotasResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var entry describeClientQuotasResponseEntryV0
+		if fnRemain, fnErr = (&entry).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Entries = append(t.Entries, entry)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDescribeClientQuotasResponseV0(t *testing.T) {
+	user := "a"
+
+	item := describeClientQuotasResponseV0{
+		ThrottleTimeMS: 1,
+		Entries: []describeClientQuotasResponseEntryV0{
+			{
+				Entity: []clientQuotaEntityComponentV0{
+					{EntityType: "user", EntityName: &user},
+					{EntityType: "client-id"},
+				},
+				Values: []describeClientQuotasResponseValueV0{
+					{Key: ClientQuotaProducerByteRate, Value: 1024},
+					{Key: ClientQuotaRequestPercentage, Value: 12.5},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found describeClientQuotasResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestClientQuotaEntity(t *testing.T) {
+	entity := []ClientQuotaEntityComponent{
+		{EntityType: ClientQuotaEntityTypeUser, EntityName: "a"},
+		{EntityType: ClientQuotaEntityTypeClientID, Default: true},
+	}
+
+	components := makeClientQuotaEntityV0(entity)
+	if components[0].EntityName == nil || *components[0].EntityName != "a" {
+		t.Error("expected the user component to have a name")
+	}
+	if components[1].EntityName != nil {
+		t.Error("expected the default client-id component to have a null name")
+	}
+
+	if found := makeClientQuotaEntity(components); !reflect.DeepEqual(entity, found) {
+		t.Errorf("expected %+v, got %+v", entity, found)
+	}
+}
@@ -0,0 +1,233 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// DescribeClusterRequest represents a request sent to a kafka cluster to
+// describe the cluster and its brokers.
+type DescribeClusterRequest struct {
+	// When IncludeClusterAuthorizedOperations is true, the response carries
+	// the operations that the client is authorized to perform on the cluster.
+	IncludeClusterAuthorizedOperations bool
+}
+
+// DescribeClusterResponse represents the response to a DescribeClusterRequest.
+type DescribeClusterResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// ClusterID is the unique identifier of the cluster.
+	ClusterID string
+
+	// Controller is the broker acting as controller of the cluster, its ID is
+	// -1 if the cluster has no controller.
+	Controller Broker
+
+	// Brokers holds the brokers of the cluster.
+	Brokers []Broker
+
+	// ClusterAuthorizedOperations is a bit field of the ACL operations that
+	// the client is authorized to perform on the cluster, where bit N is set
+	// if the operation of code N is allowed. It is only set when the request
+	// had IncludeClusterAuthorizedOperations set to true.
+	ClusterAuthorizedOperations int32
+}
+
+// DescribeCluster sends a DescribeCluster request to the kafka cluster. The
+// API was introduced in kafka 2.8 (KIP-700).
+func (c *Client) DescribeCluster(ctx context.Context, req DescribeClusterRequest) (*DescribeClusterResponse, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.describeCluster(describeClusterRequestV0{
+		IncludeClusterAuthorizedOperations: req.IncludeClusterAuthorizedOperations,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DescribeClusterResponse{
+		Throttle:                    duration(response.ThrottleTimeMS),
+		ClusterID:                   response.ClusterID,
+		Controller:                  Broker{ID: int(response.ControllerID)},
+		Brokers:                     make([]Broker, len(response.Brokers)),
+		ClusterAuthorizedOperations: response.ClusterAuthorizedOperations,
+	}
+
+	for i, b := range response.Brokers {
+		res.Brokers[i] = Broker{
+			Host: b.Host,
+			Port: int(b.Port),
+			ID:   int(b.BrokerID),
+			Rack: b.Rack,
+		}
+		if b.BrokerID == response.ControllerID {
+			res.Controller = res.Brokers[i]
+		}
+	}
+
+	return res, nil
+}
+
+// describeCluster describes the cluster that the broker is part of.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeCluster
+func (c *Conn) describeCluster(request describeClusterRequestV0) (describeClusterResponseV0, error) {
+	var response describeClusterResponseV0
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(describeCluster, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return describeClusterResponseV0{}, err
+	}
+	if response.ErrorCode != 0 {
+		return describeClusterResponseV0{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeCluster
+type describeClusterRequestV0 struct {
+	IncludeClusterAuthorizedOperations bool
+}
+
+func (t describeClusterRequestV0) size() int32 {
+	return sizeofBool(t.IncludeClusterAuthorizedOperations) +
+		sizeofTaggedFields()
+}
+
+func (t describeClusterRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeBool(t.IncludeClusterAuthorizedOperations)
+	wb.writeTaggedFields()
+}
+
+type describeClusterResponseBrokerV0 struct {
+	BrokerID int32
+	Host     string
+	Port     int32
+	Rack     string
+}
+
+func (t describeClusterResponseBrokerV0) size() int32 {
+	return sizeofInt32(t.BrokerID) +
+		sizeofCompactString(t.Host) +
+		sizeofInt32(t.Port) +
+		sizeofCompactString(t.Rack) +
+		sizeofTaggedFields()
+}
+
+func (t describeClusterResponseBrokerV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.BrokerID)
+	wb.writeCompactString(t.Host)
+	wb.writeInt32(t.Port)
+	wb.writeCompactString(t.Rack)
+	wb.writeTaggedFields()
+}
+
+func (t *describeClusterResponseBrokerV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.BrokerID); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.Host); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.Port); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.Rack); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeCluster
+type describeClusterResponseV0 struct {
+	ThrottleTimeMS              int32
+	ErrorCode                   int16
+	ErrorMessage                string
+	ClusterID                   string
+	ControllerID                int32
+	Brokers                     []describeClusterResponseBrokerV0
+	ClusterAuthorizedOperations int32
+}
+
+func (t describeClusterResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofCompactString(t.ClusterID) +
+		sizeofInt32(t.ControllerID) +
+		sizeofCompactArray(len(t.Brokers), func(i int) int32 { return t.Brokers[i].size() }) +
+		sizeofInt32(t.ClusterAuthorizedOperations) +
+		sizeofTaggedFields()
+}
+
+func (t describeClusterResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeCompactString(t.ClusterID)
+	wb.writeInt32(t.ControllerID)
+	wb.writeCompactArray(len(t.Brokers), func(i int) { t.Brokers[i].writeTo(wb) })
+	wb.writeInt32(t.ClusterAuthorizedOperations)
+	wb.writeTaggedFields()
+}
+
+func (t *describeClusterResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ClusterID); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.ControllerID); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var broker describeClusterResponseBrokerV0
+		if fnRemain, fnErr = (&broker).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Brokers = append(t.Brokers, broker)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.ClusterAuthorizedOperations); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestDescribeClusterResponseV0(t *testing.T) {
+	item := describeClusterResponseV0{
+		ThrottleTimeMS: 1,
+		ClusterID:      "a",
+		ControllerID:   2,
+		Brokers: []describeClusterResponseBrokerV0{
+			{BrokerID: 1, Host: "b", Port: 9092, Rack: "c"},
+			{BrokerID: 2, Host: "d", Port: 9092},
+		},
+		ClusterAuthorizedOperations: -2147483648,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		t.FailNow()
+	}
+
+	var found describeClusterResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func testClientDescribeCluster(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.8.0") {
+		t.Skip("describe cluster requires kafka 2.8.0 or newer")
+		return
+	}
+
+	res, err := c.DescribeCluster(ctx, DescribeClusterRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.ClusterID == "" {
+		t.Error("empty cluster id")
+	}
+	if len(res.Brokers) == 0 {
+		t.Error("no brokers in the cluster description")
+	}
+	if res.Controller.Host == "" {
+		t.Errorf("controller %d not found in the list of brokers", res.Controller.ID)
+	}
+}
@@ -0,0 +1,422 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+)
+
+// ConfigSource describes where the value of a configuration entry comes from.
+type ConfigSource int8
+
+const (
+	ConfigSourceUnknown                    ConfigSource = 0
+	ConfigSourceDynamicTopicConfig         ConfigSource = 1
+	ConfigSourceDynamicBrokerConfig        ConfigSource = 2
+	ConfigSourceDynamicDefaultBrokerConfig ConfigSource = 3
+	ConfigSourceStaticBrokerConfig         ConfigSource = 4
+	ConfigSourceDefaultConfig              ConfigSource = 5
+	ConfigSourceDynamicBrokerLoggerConfig  ConfigSource = 6
+)
+
+// DescribeConfigsRequest represents a request sent to a kafka cluster to
+// describe the configuration of topics, brokers, or broker loggers.
+type DescribeConfigsRequest struct {
+	// Resources holds the list of resources to describe.
+	Resources []DescribeConfigRequestResource
+
+	// IncludeSynonyms asks the brokers to return the synonyms of each
+	// configuration entry, ordered by precedence.
+	IncludeSynonyms bool
+}
+
+// DescribeConfigRequestResource designates a resource to describe.
+type DescribeConfigRequestResource struct {
+	// ResourceType is the type of the resource, valid values are
+	// ResourceTypeTopic, ResourceTypeBroker, and ResourceTypeBrokerLogger.
+	ResourceType ResourceType
+
+	// ResourceName is the topic name, or the broker ID for broker resources.
+	ResourceName string
+
+	// ConfigNames filters the configuration entries returned for the
+	// resource. A nil slice means that all entries are returned.
+	ConfigNames []string
+}
+
+// DescribeConfigsResponse represents the response to a DescribeConfigsRequest.
+type DescribeConfigsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// brokers.
+	Throttle time.Duration
+
+	// Resources holds the result of describing each resource, in the order
+	// they appeared in the request.
+	Resources []DescribeConfigResponseResource
+}
+
+// DescribeConfigResponseResource carries the configuration of a resource.
+type DescribeConfigResponseResource struct {
+	ResourceType ResourceType
+	ResourceName string
+
+	// Error is set to a non-nil value if describing the resource failed, the
+	// rest of the response is still valid.
+	Error error
+
+	// ErrorMessage holds the message sent by the broker alongside Error.
+	ErrorMessage string
+
+	ConfigEntries []DescribeConfigResponseConfigEntry
+}
+
+// DescribeConfigResponseConfigEntry is a configuration entry of a resource.
+type DescribeConfigResponseConfigEntry struct {
+	ConfigName  string
+	ConfigValue string
+	ReadOnly    bool
+	IsDefault   bool
+	IsSensitive bool
+
+	ConfigSource   ConfigSource
+	ConfigSynonyms []DescribeConfigResponseConfigSynonym
+}
+
+// DescribeConfigResponseConfigSynonym is a synonym of a configuration entry,
+// for example the broker default that a topic configuration falls back to.
+type DescribeConfigResponseConfigSynonym struct {
+	ConfigName   string
+	ConfigValue  string
+	ConfigSource ConfigSource
+}
+
+// DescribeConfigs sends a DescribeConfigs request to the kafka cluster.
+//
+// Broker and broker logger resources are sent to the brokers they name, other
+// resources are sent to any broker. Errors that apply to a single resource
+// are reported on the resource and do not cause the method to fail.
+func (c *Client) DescribeConfigs(ctx context.Context, req DescribeConfigsRequest) (*DescribeConfigsResponse, error) {
+	routes, err := configResourceRoutes(len(req.Resources), func(i int) (ResourceType, string) {
+		return req.Resources[i].ResourceType, req.Resources[i].ResourceName
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DescribeConfigsResponse{
+		Resources: make([]DescribeConfigResponseResource, len(req.Resources)),
+	}
+
+	for broker, indexes := range routes {
+		request := describeConfigsRequestV1{
+			Resources:       make([]describeConfigsRequestResourceV1, len(indexes)),
+			IncludeSynonyms: req.IncludeSynonyms,
+		}
+		for i, index := range indexes {
+			r := req.Resources[index]
+			request.Resources[i] = describeConfigsRequestResourceV1{
+				ResourceType: int8(r.ResourceType),
+				ResourceName: r.ResourceName,
+				ConfigNames:  r.ConfigNames,
+			}
+		}
+
+		conn, err := c.connectBrokerOrAny(ctx, broker)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := conn.describeConfigs(request)
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if throttle := duration(response.ThrottleTimeMS); throttle > res.Throttle {
+			res.Throttle = throttle
+		}
+
+		if len(response.Resources) != len(indexes) {
+			return nil, fmt.Errorf("expected %d resources in the describe configs response, got %d", len(indexes), len(response.Resources))
+		}
+
+		for i, r := range response.Resources {
+			res.Resources[indexes[i]] = r.toDescribeConfigResponseResource()
+		}
+	}
+
+	return res, nil
+}
+
+// describeConfigs describes the configuration of the requested resources.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeConfigs
+func (c *Conn) describeConfigs(request describeConfigsRequestV1) (describeConfigsResponseV1, error) {
+	var response describeConfigsResponseV1
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(describeConfigs, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return describeConfigsResponseV1{}, err
+	}
+
+	return response, nil
+}
+
+type describeConfigsRequestResourceV1 struct {
+	ResourceType int8
+	ResourceName string
+
+	// ConfigNames is nullable, a nil slice requests all config entries.
+	ConfigNames []string
+}
+
+func (t describeConfigsRequestResourceV1) size() int32 {
+	return sizeofInt8(t.ResourceType) +
+		sizeofString(t.ResourceName) +
+		sizeofStringArray(t.ConfigNames)
+}
+
+func (t describeConfigsRequestResourceV1) writeTo(wb *writeBuffer) {
+	wb.writeInt8(t.ResourceType)
+	wb.writeString(t.ResourceName)
+	if t.ConfigNames == nil {
+		wb.writeArrayLen(-1)
+	} else {
+		wb.writeStringArray(t.ConfigNames)
+	}
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeConfigs
+type describeConfigsRequestV1 struct {
+	Resources       []describeConfigsRequestResourceV1
+	IncludeSynonyms bool
+}
+
+func (t describeConfigsRequestV1) size() int32 {
+	return sizeofArray(len(t.Resources), func(i int) int32 { return t.Resources[i].size() }) +
+		sizeofBool(t.IncludeSynonyms)
+}
+
+func (t describeConfigsRequestV1) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Resources), func(i int) { t.Resources[i].writeTo(wb) })
+	wb.writeBool(t.IncludeSynonyms)
+}
+
+type describeConfigsResponseConfigSynonymV1 struct {
+	ConfigName   string
+	ConfigValue  string
+	ConfigSource int8
+}
+
+func (t describeConfigsResponseConfigSynonymV1) size() int32 {
+	return sizeofString(t.ConfigName) +
+		sizeofString(t.ConfigValue) +
+		sizeofInt8(t.ConfigSource)
+}
+
+func (t describeConfigsResponseConfigSynonymV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.ConfigName)
+	wb.writeString(t.ConfigValue)
+	wb.writeInt8(t.ConfigSource)
+}
+
+func (t *describeConfigsResponseConfigSynonymV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.ConfigName); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ConfigValue); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.ConfigSource); err != nil {
+		return
+	}
+	return
+}
+
+type describeConfigsResponseConfigEntryV1 struct {
+	ConfigName     string
+	ConfigValue    string
+	ReadOnly       bool
+	ConfigSource   int8
+	IsSensitive    bool
+	ConfigSynonyms []describeConfigsResponseConfigSynonymV1
+}
+
+func (t describeConfigsResponseConfigEntryV1) size() int32 {
+	return sizeofString(t.ConfigName) +
+		sizeofString(t.ConfigValue) +
+		sizeofBool(t.ReadOnly) +
+		sizeofInt8(t.ConfigSource) +
+		sizeofBool(t.IsSensitive) +
+		sizeofArray(len(t.ConfigSynonyms), func(i int) int32 { return t.ConfigSynonyms[i].size() })
+}
+
+func (t describeConfigsResponseConfigEntryV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.ConfigName)
+	wb.writeString(t.ConfigValue)
+	wb.writeBool(t.ReadOnly)
+	wb.writeInt8(t.ConfigSource)
+	wb.writeBool(t.IsSensitive)
+	wb.writeArray(len(t.ConfigSynonyms), func(i int) { t.ConfigSynonyms[i].writeTo(wb) })
+}
+
+func (t *describeConfigsResponseConfigEntryV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.ConfigName); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ConfigValue); err != nil {
+		return
+	}
+	if remain, err = readBool(r, remain, &t.ReadOnly); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.ConfigSource); err != nil {
+		return
+	}
+	if remain, err = readBool(r, remain, &t.IsSensitive); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var synonym describeConfigsResponseConfigSynonymV1
+		if fnRemain, fnErr = (&synonym).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.ConfigSynonyms = append(t.ConfigSynonyms, synonym)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+type describeConfigsResponseResourceV1 struct {
+	ErrorCode     int16
+	ErrorMessage  string
+	ResourceType  int8
+	ResourceName  string
+	ConfigEntries []describeConfigsResponseConfigEntryV1
+}
+
+func (t describeConfigsResponseResourceV1) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage) +
+		sizeofInt8(t.ResourceType) +
+		sizeofString(t.ResourceName) +
+		sizeofArray(len(t.ConfigEntries), func(i int) int32 { return t.ConfigEntries[i].size() })
+}
+
+func (t describeConfigsResponseResourceV1) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+	wb.writeInt8(t.ResourceType)
+	wb.writeString(t.ResourceName)
+	wb.writeArray(len(t.ConfigEntries), func(i int) { t.ConfigEntries[i].writeTo(wb) })
+}
+
+func (t *describeConfigsResponseResourceV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readInt8(r, remain, &t.ResourceType); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ResourceName); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var entry describeConfigsResponseConfigEntryV1
+		if fnRemain, fnErr = (&entry).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.ConfigEntries = append(t.ConfigEntries, entry)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+func (t describeConfigsResponseResourceV1) toDescribeConfigResponseResource() DescribeConfigResponseResource {
+	res := DescribeConfigResponseResource{
+		ResourceType:  ResourceType(t.ResourceType),
+		ResourceName:  t.ResourceName,
+		ErrorMessage:  t.ErrorMessage,
+		ConfigEntries: make([]DescribeConfigResponseConfigEntry, len(t.ConfigEntries)),
+	}
+	if t.ErrorCode != 0 {
+		res.Error = Error(t.ErrorCode)
+	}
+
+	for i, e := range t.ConfigEntries {
+		entry := DescribeConfigResponseConfigEntry{
+			ConfigName:   e.ConfigName,
+			ConfigValue:  e.ConfigValue,
+			ReadOnly:     e.ReadOnly,
+			IsDefault:    ConfigSource(e.ConfigSource) == ConfigSourceDefaultConfig,
+			IsSensitive:  e.IsSensitive,
+			ConfigSource: ConfigSource(e.ConfigSource),
+		}
+		for _, s := range e.ConfigSynonyms {
+			entry.ConfigSynonyms = append(entry.ConfigSynonyms, DescribeConfigResponseConfigSynonym{
+				ConfigName:   s.ConfigName,
+				ConfigValue:  s.ConfigValue,
+				ConfigSource: ConfigSource(s.ConfigSource),
+			})
+		}
+		res.ConfigEntries[i] = entry
+	}
+
+	return res
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeConfigs
+type describeConfigsResponseV1 struct {
+	ThrottleTimeMS int32
+	Resources      []describeConfigsResponseResourceV1
+}
+
+func (t describeConfigsResponseV1) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Resources), func(i int) int32 { return t.Resources[i].size() })
+}
+
+func (t describeConfigsResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Resources), func(i int) { t.Resources[i].writeTo(wb) })
+}
+
+func (t *describeConfigsResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var resource describeConfigsResponseResourceV1
+		if fnRemain, fnErr = (&resource).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Resources = append(t.Resources, resource)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestDescribeConfigsResponseV1(t *testing.T) {
+	item := describeConfigsResponseV1{
+		ThrottleTimeMS: 1,
+		Resources: []describeConfigsResponseResourceV1{
+			{
+				ErrorCode:    2,
+				ErrorMessage: "a",
+				ResourceType: int8(ResourceTypeTopic),
+				ResourceName: "b",
+				ConfigEntries: []describeConfigsResponseConfigEntryV1{
+					{
+						ConfigName:   "c",
+						ConfigValue:  "d",
+						ReadOnly:     true,
+						ConfigSource: int8(ConfigSourceDynamicTopicConfig),
+						IsSensitive:  true,
+						ConfigSynonyms: []describeConfigsResponseConfigSynonymV1{
+							{
+								ConfigName:   "e",
+								ConfigValue:  "f",
+								ConfigSource: int8(ConfigSourceDefaultConfig),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found describeConfigsResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDescribeConfigsRequestV1NullConfigNames(t *testing.T) {
+	item := describeConfigsRequestV1{
+		Resources: []describeConfigsRequestResourceV1{
+			{ResourceType: int8(ResourceTypeTopic), ResourceName: "a"},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Fatalf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+	// array length (4) + resource type (1) + resource name (2+1), then the
+	// null config names array.
+	if n := makeInt32(b.Bytes()[8:12]); n != -1 {
+		t.Fatalf("expected null config names array, got length %d", n)
+	}
+}
+
+func testClientDescribeConfigs(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("1.1.0") {
+		t.Skip("describe configs v1 requires kafka 1.1.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+
+	conn, err := Dial("tcp", "localhost:9092")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = conn.CreateTopics(TopicConfig{
+		Topic:             topic,
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+		ConfigEntries: []ConfigEntry{
+			{ConfigName: "retention.ms", ConfigValue: "3600000"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.DescribeConfigs(ctx, DescribeConfigsRequest{
+		Resources: []DescribeConfigRequestResource{
+			{
+				ResourceType: ResourceTypeTopic,
+				ResourceName: topic,
+				ConfigNames:  []string{"retention.ms", "cleanup.policy"},
+			},
+			{
+				ResourceType: ResourceTypeTopic,
+				ResourceName: "",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Resources) != 2 {
+		t.Fatalf("expected 2 resources, got %d", len(res.Resources))
+	}
+
+	if err := res.Resources[0].Error; err != nil {
+		t.Fatal(err)
+	}
+	if res.Resources[1].Error == nil {
+		t.Error("expected an error describing a topic with an empty name")
+	}
+
+	values := map[string]DescribeConfigResponseConfigEntry{}
+	for _, e := range res.Resources[0].ConfigEntries {
+		values[e.ConfigName] = e
+	}
+
+	if e := values["retention.ms"]; e.ConfigValue != "3600000" || e.ConfigSource != ConfigSourceDynamicTopicConfig {
+		t.Errorf("unexpected retention.ms entry: %+v", e)
+	}
+	if e := values["cleanup.policy"]; e.ConfigValue != "delete" || !e.IsDefault {
+		t.Errorf("unexpected cleanup.policy entry: %+v", e)
+	}
+}
@@ -0,0 +1,221 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// DescribeDelegationTokenRequest represents a request sent to a kafka cluster
+// to describe delegation tokens.
+type DescribeDelegationTokenRequest struct {
+	// Owners holds the principals whose tokens are described, for example
+	// "User:alice". All the tokens that the client is allowed to describe
+	// are returned when it is nil.
+	Owners []string
+}
+
+// DescribeDelegationTokenResponse represents the response to a
+// DescribeDelegationTokenRequest.
+type DescribeDelegationTokenResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	Tokens []DelegationToken
+}
+
+// DescribeDelegationToken sends a DescribeDelegationToken request to the kafka
+// cluster. The API was introduced in kafka 1.1 (KIP-48).
+func (c *Client) DescribeDelegationToken(ctx context.Context, req DescribeDelegationTokenRequest) (*DescribeDelegationTokenResponse, error) {
+	owners, err := makeDelegationTokenPrincipalsV1(req.Owners)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.describeDelegationToken(describeDelegationTokenRequestV1{
+		Owners: owners,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DescribeDelegationTokenResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Tokens:   make([]DelegationToken, len(response.Tokens)),
+	}
+
+	for i, t := range response.Tokens {
+		token := DelegationToken{
+			TokenID:    t.TokenID,
+			HMAC:       t.HMAC,
+			Owner:      t.Owner.String(),
+			Renewers:   make([]string, len(t.Renewers)),
+			IssueTime:  timestampToTime(t.IssueTimestampMS),
+			ExpiryTime: timestampToTime(t.ExpiryTimestampMS),
+			MaxTime:    timestampToTime(t.MaxTimestampMS),
+		}
+		for j, renewer := range t.Renewers {
+			token.Renewers[j] = renewer.String()
+		}
+		res.Tokens[i] = token
+	}
+
+	return res, nil
+}
+
+// describeDelegationToken describes the delegation tokens of the owners of the
+// request.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeDelegationToken
+func (c *Conn) describeDelegationToken(request describeDelegationTokenRequestV1) (describeDelegationTokenResponseV1, error) {
+	var response describeDelegationTokenResponseV1
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(describeDelegationToken, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return describeDelegationTokenResponseV1{}, err
+	}
+	if response.ErrorCode != 0 {
+		return describeDelegationTokenResponseV1{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeDelegationToken
+type describeDelegationTokenRequestV1 struct {
+	// Owners is nullable, all the tokens are described when it is nil.
+	Owners []delegationTokenPrincipalV1
+}
+
+func (t describeDelegationTokenRequestV1) size() int32 {
+	return sizeofArray(len(t.Owners), func(i int) int32 { return t.Owners[i].size() })
+}
+
+func (t describeDelegationTokenRequestV1) writeTo(wb *writeBuffer) {
+	if t.Owners == nil {
+		wb.writeArrayLen(-1)
+	} else {
+		wb.writeArray(len(t.Owners), func(i int) { t.Owners[i].writeTo(wb) })
+	}
+}
+
+type describeDelegationTokenResponseTokenV1 struct {
+	Owner             delegationTokenPrincipalV1
+	IssueTimestampMS  int64
+	ExpiryTimestampMS int64
+	MaxTimestampMS    int64
+	TokenID           string
+	HMAC              []byte
+	Renewers          []delegationTokenPrincipalV1
+}
+
+func (t describeDelegationTokenResponseTokenV1) size() int32 {
+	return t.Owner.size() +
+		sizeofInt64(t.IssueTimestampMS) +
+		sizeofInt64(t.ExpiryTimestampMS) +
+		sizeofInt64(t.MaxTimestampMS) +
+		sizeofString(t.TokenID) +
+		sizeofBytes(t.HMAC) +
+		sizeofArray(len(t.Renewers), func(i int) int32 { return t.Renewers[i].size() })
+}
+
+func (t describeDelegationTokenResponseTokenV1) writeTo(wb *writeBuffer) {
+	t.Owner.writeTo(wb)
+	wb.writeInt64(t.IssueTimestampMS)
+	wb.writeInt64(t.ExpiryTimestampMS)
+	wb.writeInt64(t.MaxTimestampMS)
+	wb.writeString(t.TokenID)
+	wb.writeBytes(t.HMAC)
+	wb.writeArray(len(t.Renewers), func(i int) { t.Renewers[i].writeTo(wb) })
+}
+
+func (t *describeDelegationTokenResponseTokenV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = (&t.Owner).readFrom(r, size); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.IssueTimestampMS); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.ExpiryTimestampMS); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.MaxTimestampMS); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.TokenID); err != nil {
+		return
+	}
+	if remain, err = readBytes(r, remain, &t.HMAC); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var renewer delegationTokenPrincipalV1
+		if fnRemain, fnErr = (&renewer).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Renewers = append(t.Renewers, renewer)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeDelegationToken
+type describeDelegationTokenResponseV1 struct {
+	ErrorCode      int16
+	Tokens         []describeDelegationTokenResponseTokenV1
+	ThrottleTimeMS int32
+}
+
+func (t describeDelegationTokenResponseV1) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofArray(len(t.Tokens), func(i int) int32 { return t.Tokens[i].size() }) +
+		sizeofInt32(t.ThrottleTimeMS)
+}
+
+func (t describeDelegationTokenResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeArray(len(t.Tokens), func(i int) { t.Tokens[i].writeTo(wb) })
+	wb.writeInt32(t.ThrottleTimeMS)
+}
+
+func (t *describeDelegationTokenResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var token describeDelegationTokenResponseTokenV1
+		if fnRemain, fnErr = (&token).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Tokens = append(t.Tokens, token)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	return
+}
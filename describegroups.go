@@ -1,6 +1,174 @@
 package kafka
 
-import "bufio"
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DescribeGroupsRequest represents a request sent to a kafka cluster to
+// describe consumer groups.
+type DescribeGroupsRequest struct {
+	// GroupIDs holds the IDs of the groups to describe.
+	GroupIDs []string
+}
+
+// DescribeGroupsResponse represents the response to a DescribeGroupsRequest.
+type DescribeGroupsResponse struct {
+	// Groups holds the description of each group, in the order they appeared
+	// in the request.
+	Groups []DescribeGroupsResponseGroup
+}
+
+// DescribeGroupsResponseGroup describes a group and its members.
+type DescribeGroupsResponseGroup struct {
+	GroupID string
+
+	// Error is set to a non-nil value if the group could not be described. A
+	// group that does not exist is reported with the state "Dead" and no
+	// error.
+	Error error
+
+	// GroupState is one of Dead, Empty, Stable, CompletingRebalance (or
+	// AwaitingSync), or PreparingRebalance.
+	GroupState string
+
+	// ProtocolType is the type of protocol of the group, "consumer" for
+	// consumer groups.
+	ProtocolType string
+
+	// Protocol is the name of the balancing protocol selected for the group
+	// (e.g. "range"), only set when the group is stable.
+	Protocol string
+
+	// Members holds the members of the group.
+	Members []DescribeGroupsResponseMember
+}
+
+// DescribeGroupsResponseMember describes a member of a group.
+type DescribeGroupsResponseMember struct {
+	MemberID   string
+	ClientID   string
+	ClientHost string
+
+	// MemberMetadata holds the subscription of the member, it is only decoded
+	// for consumer groups.
+	MemberMetadata DescribeGroupsResponseMemberMetadata
+
+	// MemberAssignments holds the partitions assigned to the member, it is
+	// only decoded for consumer groups. The assignments are empty while the
+	// group is rebalancing.
+	MemberAssignments DescribeGroupsResponseAssignments
+}
+
+// DescribeGroupsResponseMemberMetadata is the subscription that a member of a
+// consumer group sent when joining the group.
+type DescribeGroupsResponseMemberMetadata struct {
+	Version  int
+	Topics   []string
+	UserData []byte
+}
+
+// DescribeGroupsResponseAssignments holds the partitions assigned to a member
+// of a consumer group by the group leader.
+type DescribeGroupsResponseAssignments struct {
+	Version  int
+	Topics   []GroupMemberTopic
+	UserData []byte
+}
+
+// GroupMemberTopic is a topic and the partitions of that topic assigned to a
+// group member, partitions are sorted in ascending order.
+type GroupMemberTopic struct {
+	Topic      string
+	Partitions []int
+}
+
+// DescribeGroups describes the groups of the kafka cluster. Each group is
+// described by its coordinator, the requests to different coordinators are
+// sent concurrently.
+//
+// Errors that apply to a single group are reported on the group and do not
+// cause the method to fail.
+func (c *Client) DescribeGroups(ctx context.Context, req DescribeGroupsRequest) (*DescribeGroupsResponse, error) {
+	coordinators, errs, err := c.groupCoordinators(ctx, req.GroupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	mutex := sync.Mutex{}
+	groups := make(map[string]DescribeGroupsResponseGroup, len(req.GroupIDs))
+	wg := sync.WaitGroup{}
+
+	for b, groupIDs := range coordinators {
+		wg.Add(1)
+		go func(b Broker, groupIDs []string) {
+			defer wg.Done()
+
+			described, err := c.describeCoordinatorGroups(ctx, b, groupIDs)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				for _, groupID := range groupIDs {
+					groups[groupID] = DescribeGroupsResponseGroup{GroupID: groupID, Error: err}
+				}
+				return
+			}
+			for _, g := range described {
+				groups[g.GroupID] = g
+			}
+		}(b, groupIDs)
+	}
+
+	wg.Wait()
+
+	res := &DescribeGroupsResponse{
+		Groups: make([]DescribeGroupsResponseGroup, len(req.GroupIDs)),
+	}
+
+	for i, groupID := range req.GroupIDs {
+		if err := errs[groupID]; err != nil {
+			res.Groups[i] = DescribeGroupsResponseGroup{GroupID: groupID, Error: err}
+			continue
+		}
+		g, ok := groups[groupID]
+		if !ok {
+			g = DescribeGroupsResponseGroup{
+				GroupID: groupID,
+				Error:   fmt.Errorf("group %s missing from the describe groups response", groupID),
+			}
+		}
+		res.Groups[i] = g
+	}
+
+	return res, nil
+}
+
+// describeCoordinatorGroups describes the groups coordinated by the broker b.
+func (c *Client) describeCoordinatorGroups(ctx context.Context, b Broker, groupIDs []string) ([]DescribeGroupsResponseGroup, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.describeGroups(describeGroupsRequestV0{GroupIDs: groupIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]DescribeGroupsResponseGroup, len(response.Groups))
+	for i, g := range response.Groups {
+		if groups[i], err = g.toDescribeGroupsResponseGroup(); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
 
 // See http://kafka.apache.org/protocol.html#The_Messages_DescribeGroups
 type describeGroupsRequestV0 struct {
@@ -174,3 +342,91 @@ func (t *describeGroupsResponseV0) readFrom(r *bufio.Reader, sz int) (remain int
 
 	return
 }
+
+func (t describeGroupsResponseGroupV0) toDescribeGroupsResponseGroup() (DescribeGroupsResponseGroup, error) {
+	group := DescribeGroupsResponseGroup{
+		GroupID:      t.GroupID,
+		GroupState:   t.State,
+		ProtocolType: t.ProtocolType,
+		Protocol:     t.Protocol,
+		Members:      make([]DescribeGroupsResponseMember, len(t.Members)),
+	}
+	if t.ErrorCode != 0 {
+		group.Error = Error(t.ErrorCode)
+	}
+
+	for i, m := range t.Members {
+		member := DescribeGroupsResponseMember{
+			MemberID:   m.MemberID,
+			ClientID:   m.ClientID,
+			ClientHost: m.ClientHost,
+		}
+
+		// The metadata and assignments are opaque to the coordinator, only
+		// consumer groups are known to use the encoding of the consumer
+		// protocol.
+		if t.ProtocolType == "consumer" {
+			metadata, err := decodeGroupMetadata(m.MemberMetadata)
+			if err != nil {
+				return DescribeGroupsResponseGroup{}, fmt.Errorf("unable to read metadata for member, %v: %v", m.MemberID, err)
+			}
+			assignments, err := decodeGroupAssignment(m.MemberAssignments)
+			if err != nil {
+				return DescribeGroupsResponseGroup{}, fmt.Errorf("unable to read assignments for member, %v: %v", m.MemberID, err)
+			}
+			member.MemberMetadata = metadata
+			member.MemberAssignments = assignments
+		}
+
+		group.Members[i] = member
+	}
+
+	return group, nil
+}
+
+// decodeGroupMetadata decodes the metadata of a consumer group member, which
+// is empty while the member is joining the group.
+func decodeGroupMetadata(b []byte) (DescribeGroupsResponseMemberMetadata, error) {
+	subscription, err := DecodeGroupProtocolSubscription(b)
+	if err != nil {
+		return DescribeGroupsResponseMemberMetadata{}, err
+	}
+
+	return DescribeGroupsResponseMemberMetadata{
+		Version:  subscription.Version,
+		Topics:   subscription.Topics,
+		UserData: subscription.UserData,
+	}, nil
+}
+
+// decodeGroupAssignment decodes the assignments of a consumer group member,
+// which are empty while the group is rebalancing.
+func decodeGroupAssignment(b []byte) (DescribeGroupsResponseAssignments, error) {
+	if len(b) == 0 {
+		return DescribeGroupsResponseAssignments{}, nil
+	}
+
+	assignment, err := DecodeGroupProtocolAssignment(b)
+	if err != nil {
+		return DescribeGroupsResponseAssignments{}, err
+	}
+
+	res := DescribeGroupsResponseAssignments{
+		Version:  assignment.Version,
+		Topics:   make([]GroupMemberTopic, 0, len(assignment.Topics)),
+		UserData: assignment.UserData,
+	}
+
+	for topic, partitions := range assignment.Topics {
+		res.Topics = append(res.Topics, GroupMemberTopic{
+			Topic:      topic,
+			Partitions: partitions,
+		})
+	}
+
+	sort.Slice(res.Topics, func(i, j int) bool {
+		return res.Topics[i].Topic < res.Topics[j].Topic
+	})
+
+	return res, nil
+}
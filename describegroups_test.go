@@ -3,8 +3,10 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestDescribeGroupsResponseV0(t *testing.T) {
@@ -48,3 +50,115 @@ func TestDescribeGroupsResponseV0(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestDescribeGroupsResponseGroupV0Decode(t *testing.T) {
+	item := describeGroupsResponseGroupV0{
+		GroupID:      "a",
+		State:        "Stable",
+		ProtocolType: "consumer",
+		Protocol:     "range",
+		Members: []describeGroupsResponseMemberV0{
+			{
+				MemberID:   "b",
+				ClientID:   "c",
+				ClientHost: "d",
+				MemberMetadata: groupMetadata{
+					Version: 1,
+					Topics:  []string{"x", "y"},
+				}.bytes(),
+				MemberAssignments: groupAssignment{
+					Version: 1,
+					Topics: map[string][]int32{
+						"y": {2, 0},
+						"x": {1},
+					},
+				}.bytes(),
+			},
+			{
+				// members of a rebalancing group have no assignments yet
+				MemberID: "e",
+			},
+		},
+	}
+
+	found, err := item.toDescribeGroupsResponseGroup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := DescribeGroupsResponseGroup{
+		GroupID:      "a",
+		GroupState:   "Stable",
+		ProtocolType: "consumer",
+		Protocol:     "range",
+		Members: []DescribeGroupsResponseMember{
+			{
+				MemberID:   "b",
+				ClientID:   "c",
+				ClientHost: "d",
+				MemberMetadata: DescribeGroupsResponseMemberMetadata{
+					Version: 1,
+					Topics:  []string{"x", "y"},
+				},
+				MemberAssignments: DescribeGroupsResponseAssignments{
+					Version: 1,
+					Topics: []GroupMemberTopic{
+						{Topic: "x", Partitions: []int{1}},
+						{Topic: "y", Partitions: []int{0, 2}},
+					},
+				},
+			},
+			{
+				MemberID: "e",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, found) {
+		t.Errorf("expected %+v, got %+v", expected, found)
+	}
+}
+
+func testClientDescribeGroups(t *testing.T, ctx context.Context, c *Client) {
+	topic := makeTopic()
+	groupID := makeGroupID()
+	createTopic(t, topic, 2)
+
+	r := NewReader(ReaderConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   topic,
+		GroupID: groupID,
+		MaxWait: 100 * time.Millisecond,
+	})
+	defer r.Close()
+
+	// Wait for the reader to join the group and receive its assignments.
+	for {
+		res, err := c.DescribeGroups(ctx, DescribeGroupsRequest{
+			GroupIDs: []string{groupID},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g := res.Groups[0]
+		if g.Error != nil {
+			t.Fatal(g.Error)
+		}
+
+		if g.GroupState == "Stable" && len(g.Members) == 1 {
+			a := g.Members[0].MemberAssignments
+			expected := []GroupMemberTopic{{Topic: topic, Partitions: []int{0, 1}}}
+			if !reflect.DeepEqual(expected, a.Topics) {
+				t.Errorf("expected assignments %+v, got %+v", expected, a.Topics)
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("group %s never became stable: %v", groupID, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,718 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DescribeLogDirsRequest represents a request sent to a kafka cluster to
+// describe the log directories of brokers.
+type DescribeLogDirsRequest struct {
+	// BrokerIDs optionally restricts the request to the listed brokers, all
+	// the brokers of the cluster are described when it is nil.
+	BrokerIDs []int
+
+	// Topics optionally restricts the response to the listed partitions,
+	// indexed by topic name. All the partitions are described when it is nil.
+	Topics map[string][]int
+}
+
+// DescribeLogDirsResponse represents the response to a DescribeLogDirsRequest.
+type DescribeLogDirsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// brokers.
+	Throttle time.Duration
+
+	// Brokers holds the description of the log directories of each broker,
+	// indexed by broker ID.
+	Brokers map[int]DescribeLogDirsResponseBroker
+}
+
+// DescribeLogDirsResponseBroker describes the log directories of a broker.
+type DescribeLogDirsResponseBroker struct {
+	// Error is set to a non-nil value if the log directories of the broker
+	// could not be described.
+	Error error
+
+	// LogDirs holds the log directories of the broker, indexed by path.
+	LogDirs map[string]DescribeLogDirsResponseLogDir
+}
+
+// DescribeLogDirsResponseLogDir describes a log directory and the replicas
+// that it holds.
+type DescribeLogDirsResponseLogDir struct {
+	// Error is set to a non-nil value if the log directory is unusable, for
+	// example KafkaStorageError if the disk failed.
+	Error error
+
+	// TotalBytes and UsableBytes are the size and free space of the volume
+	// of the log directory. They are only known when the broker runs kafka
+	// 3.3 or above, and are -1 otherwise.
+	TotalBytes  int64
+	UsableBytes int64
+
+	// Topics holds the replicas stored in the log directory, indexed by topic
+	// name.
+	Topics map[string][]DescribeLogDirsResponsePartition
+}
+
+// DescribeLogDirsResponsePartition describes a replica stored in a log
+// directory.
+type DescribeLogDirsResponsePartition struct {
+	Partition int
+
+	// Size is the size of the log segments of the replica, in bytes.
+	Size int64
+
+	// OffsetLag is the lag of the replica behind the high watermark of the
+	// partition, or behind the current replica for future replicas.
+	OffsetLag int64
+
+	// IsFuture is true if the replica is being moved to the log directory by
+	// an AlterReplicaLogDirs request.
+	IsFuture bool
+}
+
+// DescribeLogDirs describes the log directories of brokers of the kafka
+// cluster. The requests to the brokers are sent concurrently. The API was
+// introduced in kafka 1.0.
+//
+// Errors that apply to a single broker are reported on the broker and do not
+// cause the method to fail.
+func (c *Client) DescribeLogDirs(ctx context.Context, req DescribeLogDirsRequest) (*DescribeLogDirsResponse, error) {
+	brokers, err := c.brokerList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.BrokerIDs != nil {
+		selected := make([]Broker, 0, len(req.BrokerIDs))
+
+		for _, id := range req.BrokerIDs {
+			found := false
+			for _, b := range brokers {
+				if b.ID == id {
+					selected = append(selected, b)
+					found = true
+					break
+				}
+			}
+			if !found {
+				selected = append(selected, Broker{ID: id})
+			}
+		}
+
+		brokers = selected
+	}
+
+	var topics []describeLogDirsRequestTopicV1
+	if req.Topics != nil {
+		topics = make([]describeLogDirsRequestTopicV1, 0, len(req.Topics))
+		for topic, partitions := range req.Topics {
+			t := describeLogDirsRequestTopicV1{
+				Topic:      topic,
+				Partitions: make([]int32, len(partitions)),
+			}
+			for i, p := range partitions {
+				t.Partitions[i] = int32(p)
+			}
+			topics = append(topics, t)
+		}
+		sort.Slice(topics, func(i, j int) bool {
+			return topics[i].Topic < topics[j].Topic
+		})
+	}
+
+	res := &DescribeLogDirsResponse{
+		Brokers: make(map[int]DescribeLogDirsResponseBroker, len(brokers)),
+	}
+
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for _, b := range brokers {
+		wg.Add(1)
+		go func(b Broker) {
+			defer wg.Done()
+
+			var broker DescribeLogDirsResponseBroker
+			var throttle time.Duration
+
+			if b.Host == "" {
+				broker.Error = BrokerNotAvailable
+			} else {
+				broker.LogDirs, throttle, broker.Error = c.describeBrokerLogDirs(ctx, b, topics)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if throttle > res.Throttle {
+				res.Throttle = throttle
+			}
+			res.Brokers[b.ID] = broker
+		}(b)
+	}
+
+	wg.Wait()
+	return res, nil
+}
+
+// describeBrokerLogDirs describes the log directories of the broker b, using
+// the most recent version of the API that the broker supports.
+func (c *Client) describeBrokerLogDirs(ctx context.Context, b Broker, topics []describeLogDirsRequestTopicV1) (map[string]DescribeLogDirsResponseLogDir, time.Duration, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+
+	version, err := conn.negotiateVersion(describeLogDirs, v1, v4)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if version == v4 {
+		request := describeLogDirsRequestV4{}
+		if topics != nil {
+			request.Topics = make([]describeLogDirsRequestTopicV4, len(topics))
+			for i, t := range topics {
+				request.Topics[i] = describeLogDirsRequestTopicV4(t)
+			}
+		}
+
+		response, err := conn.describeLogDirsV4(request)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		logDirs := make(map[string]DescribeLogDirsResponseLogDir, len(response.Results))
+		for _, r := range response.Results {
+			logDirs[r.LogDir] = r.toDescribeLogDirsResponseLogDir()
+		}
+		return logDirs, duration(response.ThrottleTimeMS), nil
+	}
+
+	response, err := conn.describeLogDirs(describeLogDirsRequestV1{Topics: topics})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	logDirs := make(map[string]DescribeLogDirsResponseLogDir, len(response.Results))
+	for _, r := range response.Results {
+		logDirs[r.LogDir] = r.toDescribeLogDirsResponseLogDir()
+	}
+	return logDirs, duration(response.ThrottleTimeMS), nil
+}
+
+// describeLogDirs describes the log directories of the broker.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeLogDirs
+func (c *Conn) describeLogDirs(request describeLogDirsRequestV1) (describeLogDirsResponseV1, error) {
+	var response describeLogDirsResponseV1
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(describeLogDirs, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return describeLogDirsResponseV1{}, err
+	}
+
+	return response, nil
+}
+
+// describeLogDirsV4 describes the log directories of the broker, including the
+// size of their volumes.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeLogDirs
+func (c *Conn) describeLogDirsV4(request describeLogDirsRequestV4) (describeLogDirsResponseV4, error) {
+	var response describeLogDirsResponseV4
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(describeLogDirs, v4, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return describeLogDirsResponseV4{}, err
+	}
+	if response.ErrorCode != 0 {
+		return describeLogDirsResponseV4{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+type describeLogDirsRequestTopicV1 struct {
+	Topic      string
+	Partitions []int32
+}
+
+func (t describeLogDirsRequestTopicV1) size() int32 {
+	return sizeofString(t.Topic) +
+		sizeofInt32Array(t.Partitions)
+}
+
+func (t describeLogDirsRequestTopicV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Topic)
+	wb.writeInt32Array(t.Partitions)
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeLogDirs
+type describeLogDirsRequestV1 struct {
+	// Topics is nullable, all the partitions are described when it is nil.
+	Topics []describeLogDirsRequestTopicV1
+}
+
+func (t describeLogDirsRequestV1) size() int32 {
+	return sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t describeLogDirsRequestV1) writeTo(wb *writeBuffer) {
+	if t.Topics == nil {
+		wb.writeArrayLen(-1)
+	} else {
+		wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	}
+}
+
+type describeLogDirsResponsePartitionV1 struct {
+	PartitionIndex int32
+	PartitionSize  int64
+	OffsetLag      int64
+	IsFutureKey    bool
+}
+
+func (t describeLogDirsResponsePartitionV1) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofInt64(t.PartitionSize) +
+		sizeofInt64(t.OffsetLag) +
+		sizeofBool(t.IsFutureKey)
+}
+
+func (t describeLogDirsResponsePartitionV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeInt64(t.PartitionSize)
+	wb.writeInt64(t.OffsetLag)
+	wb.writeBool(t.IsFutureKey)
+}
+
+func (t *describeLogDirsResponsePartitionV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.PartitionIndex); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.PartitionSize); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.OffsetLag); err != nil {
+		return
+	}
+	if remain, err = readBool(r, remain, &t.IsFutureKey); err != nil {
+		return
+	}
+	return
+}
+
+func (t describeLogDirsResponsePartitionV1) toDescribeLogDirsResponsePartition() DescribeLogDirsResponsePartition {
+	return DescribeLogDirsResponsePartition{
+		Partition: int(t.PartitionIndex),
+		Size:      t.PartitionSize,
+		OffsetLag: t.OffsetLag,
+		IsFuture:  t.IsFutureKey,
+	}
+}
+
+type describeLogDirsResponseTopicV1 struct {
+	Name       string
+	Partitions []describeLogDirsResponsePartitionV1
+}
+
+func (t describeLogDirsResponseTopicV1) size() int32 {
+	return sizeofString(t.Name) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t describeLogDirsResponseTopicV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Name)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+func (t *describeLogDirsResponseTopicV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.Name); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition describeLogDirsResponsePartitionV1
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+type describeLogDirsResponseResultV1 struct {
+	ErrorCode int16
+	LogDir    string
+	Topics    []describeLogDirsResponseTopicV1
+}
+
+func (t describeLogDirsResponseResultV1) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofString(t.LogDir) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t describeLogDirsResponseResultV1) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.LogDir)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
+
+func (t *describeLogDirsResponseResultV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.LogDir); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic describeLogDirsResponseTopicV1
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+func (t describeLogDirsResponseResultV1) toDescribeLogDirsResponseLogDir() DescribeLogDirsResponseLogDir {
+	logDir := DescribeLogDirsResponseLogDir{
+		TotalBytes:  -1,
+		UsableBytes: -1,
+		Topics:      make(map[string][]DescribeLogDirsResponsePartition, len(t.Topics)),
+	}
+	if t.ErrorCode != 0 {
+		logDir.Error = Error(t.ErrorCode)
+	}
+	for _, topic := range t.Topics {
+		partitions := make([]DescribeLogDirsResponsePartition, len(topic.Partitions))
+		for i, p := range topic.Partitions {
+			partitions[i] = p.toDescribeLogDirsResponsePartition()
+		}
+		logDir.Topics[topic.Name] = partitions
+	}
+	return logDir
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeLogDirs
+type describeLogDirsResponseV1 struct {
+	ThrottleTimeMS int32
+	Results        []describeLogDirsResponseResultV1
+}
+
+func (t describeLogDirsResponseV1) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Results), func(i int) int32 { return t.Results[i].size() })
+}
+
+func (t describeLogDirsResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Results), func(i int) { t.Results[i].writeTo(wb) })
+}
+
+func (t *describeLogDirsResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var result describeLogDirsResponseResultV1
+		if fnRemain, fnErr = (&result).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Results = append(t.Results, result)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+type describeLogDirsRequestTopicV4 struct {
+	Topic      string
+	Partitions []int32
+}
+
+func (t describeLogDirsRequestTopicV4) size() int32 {
+	return sizeofCompactString(t.Topic) +
+		sizeofCompactInt32Array(t.Partitions) +
+		sizeofTaggedFields()
+}
+
+func (t describeLogDirsRequestTopicV4) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Topic)
+	wb.writeCompactInt32Array(t.Partitions)
+	wb.writeTaggedFields()
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeLogDirs
+type describeLogDirsRequestV4 struct {
+	// Topics is nullable, all the partitions are described when it is nil.
+	Topics []describeLogDirsRequestTopicV4
+}
+
+func (t describeLogDirsRequestV4) size() int32 {
+	return sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeLogDirsRequestV4) writeTo(wb *writeBuffer) {
+	if t.Topics == nil {
+		wb.writeCompactArrayLen(-1)
+	} else {
+		wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	}
+	wb.writeTaggedFields()
+}
+
+type describeLogDirsResponsePartitionV4 struct {
+	PartitionIndex int32
+	PartitionSize  int64
+	OffsetLag      int64
+	IsFutureKey    bool
+}
+
+func (t describeLogDirsResponsePartitionV4) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofInt64(t.PartitionSize) +
+		sizeofInt64(t.OffsetLag) +
+		sizeofBool(t.IsFutureKey) +
+		sizeofTaggedFields()
+}
+
+func (t describeLogDirsResponsePartitionV4) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeInt64(t.PartitionSize)
+	wb.writeInt64(t.OffsetLag)
+	wb.writeBool(t.IsFutureKey)
+	wb.writeTaggedFields()
+}
+
+func (t *describeLogDirsResponsePartitionV4) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.PartitionIndex); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.PartitionSize); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.OffsetLag); err != nil {
+		return
+	}
+	if remain, err = readBool(r, remain, &t.IsFutureKey); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+func (t describeLogDirsResponsePartitionV4) toDescribeLogDirsResponsePartition() DescribeLogDirsResponsePartition {
+	return DescribeLogDirsResponsePartition{
+		Partition: int(t.PartitionIndex),
+		Size:      t.PartitionSize,
+		OffsetLag: t.OffsetLag,
+		IsFuture:  t.IsFutureKey,
+	}
+}
+
+type describeLogDirsResponseTopicV4 struct {
+	Name       string
+	Partitions []describeLogDirsResponsePartitionV4
+}
+
+func (t describeLogDirsResponseTopicV4) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofCompactArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeLogDirsResponseTopicV4) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeCompactArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *describeLogDirsResponseTopicV4) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.Name); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition describeLogDirsResponsePartitionV4
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+type describeLogDirsResponseResultV4 struct {
+	ErrorCode   int16
+	LogDir      string
+	Topics      []describeLogDirsResponseTopicV4
+	TotalBytes  int64
+	UsableBytes int64
+}
+
+func (t describeLogDirsResponseResultV4) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.LogDir) +
+		sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofInt64(t.TotalBytes) +
+		sizeofInt64(t.UsableBytes) +
+		sizeofTaggedFields()
+}
+
+func (t describeLogDirsResponseResultV4) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.LogDir)
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeInt64(t.TotalBytes)
+	wb.writeInt64(t.UsableBytes)
+	wb.writeTaggedFields()
+}
+
+func (t *describeLogDirsResponseResultV4) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.LogDir); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic describeLogDirsResponseTopicV4
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.TotalBytes); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.UsableBytes); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+func (t describeLogDirsResponseResultV4) toDescribeLogDirsResponseLogDir() DescribeLogDirsResponseLogDir {
+	logDir := DescribeLogDirsResponseLogDir{
+		TotalBytes:  t.TotalBytes,
+		UsableBytes: t.UsableBytes,
+		Topics:      make(map[string][]DescribeLogDirsResponsePartition, len(t.Topics)),
+	}
+	if t.ErrorCode != 0 {
+		logDir.Error = Error(t.ErrorCode)
+	}
+	for _, topic := range t.Topics {
+		partitions := make([]DescribeLogDirsResponsePartition, len(topic.Partitions))
+		for i, p := range topic.Partitions {
+			partitions[i] = p.toDescribeLogDirsResponsePartition()
+		}
+		logDir.Topics[topic.Name] = partitions
+	}
+	return logDir
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeLogDirs
+type describeLogDirsResponseV4 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	Results        []describeLogDirsResponseResultV4
+}
+
+func (t describeLogDirsResponseV4) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactArray(len(t.Results), func(i int) int32 { return t.Results[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeLogDirsResponseV4) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactArray(len(t.Results), func(i int) { t.Results[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *describeLogDirsResponseV4) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var result describeLogDirsResponseResultV4
+		if fnRemain, fnErr = (&result).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Results = append(t.Results, result)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,132 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestDescribeLogDirsResponseV1(t *testing.T) {
+	item := describeLogDirsResponseV1{
+		ThrottleTimeMS: 1,
+		Results: []describeLogDirsResponseResultV1{
+			{
+				LogDir: "/a",
+				Topics: []describeLogDirsResponseTopicV1{
+					{
+						Name: "b",
+						Partitions: []describeLogDirsResponsePartitionV1{
+							{PartitionIndex: 0, PartitionSize: 1024, OffsetLag: 0},
+							{PartitionIndex: 1, PartitionSize: 512, OffsetLag: 3, IsFutureKey: true},
+						},
+					},
+				},
+			},
+			{
+				ErrorCode: int16(KafkaStorageError),
+				LogDir:    "/c",
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found describeLogDirsResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDescribeLogDirsResponseV4(t *testing.T) {
+	item := describeLogDirsResponseV4{
+		ThrottleTimeMS: 1,
+		Results: []describeLogDirsResponseResultV4{
+			{
+				LogDir: "/a",
+				Topics: []describeLogDirsResponseTopicV4{
+					{
+						Name: "b",
+						Partitions: []describeLogDirsResponsePartitionV4{
+							{PartitionIndex: 0, PartitionSize: 1024, OffsetLag: 0},
+						},
+					},
+				},
+				TotalBytes:  1 << 40,
+				UsableBytes: 1 << 30,
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		t.FailNow()
+	}
+
+	var found describeLogDirsResponseV4
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func testClientDescribeLogDirs(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("1.0.0") {
+		t.Skip("describe log dirs requires kafka 1.0.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 2)
+
+	res, err := c.DescribeLogDirs(ctx, DescribeLogDirsRequest{
+		Topics: map[string][]int{topic: {0, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Brokers) == 0 {
+		t.Fatal("no brokers in the response")
+	}
+
+	partitions := 0
+	for id, b := range res.Brokers {
+		if b.Error != nil {
+			t.Errorf("describing the log dirs of broker %d failed: %v", id, b.Error)
+		}
+		for _, logDir := range b.LogDirs {
+			partitions += len(logDir.Topics[topic])
+		}
+	}
+	if partitions < 2 {
+		t.Errorf("expected at least 2 replicas of %s, got %d", topic, partitions)
+	}
+}
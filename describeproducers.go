@@ -0,0 +1,442 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DescribeProducersRequest represents a request sent to a kafka cluster to
+// describe the producers that recently wrote to partitions.
+type DescribeProducersRequest struct {
+	// Topics holds the partitions to describe the producers of, indexed by
+	// topic name.
+	Topics map[string][]int
+}
+
+// DescribeProducersResponse represents the response to a
+// DescribeProducersRequest.
+type DescribeProducersResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// brokers.
+	Throttle time.Duration
+
+	// Topics holds the producers of each partition, indexed by topic name.
+	Topics map[string][]DescribeProducersResponsePartition
+}
+
+// DescribeProducersResponsePartition carries the producers of a partition.
+type DescribeProducersResponsePartition struct {
+	Partition int
+
+	// Error is set to a non-nil value if the producers of the partition could
+	// not be described, for example NotLeaderForPartition.
+	Error error
+
+	// ErrorMessage holds the message sent by the broker alongside Error.
+	ErrorMessage string
+
+	// ActiveProducers holds the producers whose state is retained by the
+	// leader of the partition.
+	ActiveProducers []DescribeProducersProducer
+}
+
+// DescribeProducersProducer is the state of a producer on a partition.
+type DescribeProducersProducer struct {
+	ProducerID    int64
+	ProducerEpoch int
+
+	// LastSequence is the sequence number of the last record written by the
+	// producer, or -1 if the producer is not idempotent.
+	LastSequence int
+
+	// LastTimestamp is the time of the last record written by the producer.
+	LastTimestamp time.Time
+
+	// CoordinatorEpoch is the epoch of the transaction coordinator that last
+	// wrote a transaction marker for the producer.
+	CoordinatorEpoch int
+
+	// CurrentTxnStartOffset is the first offset of the ongoing transaction of
+	// the producer, or -1 if no transaction is ongoing. The last stable offset
+	// of the partition does not move past it until the transaction ends.
+	CurrentTxnStartOffset int64
+}
+
+// DescribeProducers describes the producers of partitions. Partitions are
+// grouped by leader, the requests to different leaders are sent concurrently.
+// The API was introduced in kafka 2.8 (KIP-664).
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) DescribeProducers(ctx context.Context, req DescribeProducersRequest) (*DescribeProducersResponse, error) {
+	topics := make([]string, 0, len(req.Topics))
+	for topic := range req.Topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	leaders, err := c.partitionLeaders(ctx, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DescribeProducersResponse{
+		Topics: make(map[string][]DescribeProducersResponsePartition, len(req.Topics)),
+	}
+
+	requests := make(map[Broker]*describeProducersRequestV0)
+
+	for _, topic := range topics {
+		for _, p := range req.Topics[topic] {
+			leader, ok := leaders[topicPartition{topic: topic, partition: p}]
+			if !ok {
+				res.Topics[topic] = append(res.Topics[topic], DescribeProducersResponsePartition{
+					Partition: p,
+					Error:     LeaderNotAvailable,
+				})
+				continue
+			}
+
+			request := requests[leader]
+			if request == nil {
+				request = &describeProducersRequestV0{}
+				requests[leader] = request
+			}
+			request.add(topic, int32(p))
+		}
+	}
+
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for b, request := range requests {
+		wg.Add(1)
+		go func(b Broker, request describeProducersRequestV0) {
+			defer wg.Done()
+
+			response, err := c.describeLeaderProducers(ctx, b, request)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				for _, t := range request.Topics {
+					for _, p := range t.PartitionIndexes {
+						res.Topics[t.Name] = append(res.Topics[t.Name], DescribeProducersResponsePartition{
+							Partition: int(p),
+							Error:     err,
+						})
+					}
+				}
+				return
+			}
+
+			if throttle := duration(response.ThrottleTimeMS); throttle > res.Throttle {
+				res.Throttle = throttle
+			}
+
+			for _, t := range response.Topics {
+				for _, p := range t.Partitions {
+					partition := DescribeProducersResponsePartition{
+						Partition:       int(p.PartitionIndex),
+						ErrorMessage:    p.ErrorMessage,
+						ActiveProducers: make([]DescribeProducersProducer, len(p.ActiveProducers)),
+					}
+					if p.ErrorCode != 0 {
+						partition.Error = Error(p.ErrorCode)
+					}
+					for i, producer := range p.ActiveProducers {
+						partition.ActiveProducers[i] = DescribeProducersProducer{
+							ProducerID:            producer.ProducerID,
+							ProducerEpoch:         int(producer.ProducerEpoch),
+							LastSequence:          int(producer.LastSequence),
+							CoordinatorEpoch:      int(producer.CoordinatorEpoch),
+							CurrentTxnStartOffset: producer.CurrentTxnStartOffset,
+						}
+						if producer.LastTimestamp >= 0 {
+							partition.ActiveProducers[i].LastTimestamp = timestampToTime(producer.LastTimestamp)
+						}
+					}
+					res.Topics[t.Name] = append(res.Topics[t.Name], partition)
+				}
+			}
+		}(b, *request)
+	}
+
+	wg.Wait()
+
+	for _, partitions := range res.Topics {
+		sort.Slice(partitions, func(i, j int) bool {
+			return partitions[i].Partition < partitions[j].Partition
+		})
+	}
+
+	return res, nil
+}
+
+// describeLeaderProducers describes the producers of partitions led by the
+// broker b.
+func (c *Client) describeLeaderProducers(ctx context.Context, b Broker, request describeProducersRequestV0) (describeProducersResponseV0, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return describeProducersResponseV0{}, err
+	}
+	defer conn.Close()
+	return conn.describeProducers(request)
+}
+
+// describeProducers describes the producers of the requested partitions, the
+// broker must be the leader of the partitions.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeProducers
+func (c *Conn) describeProducers(request describeProducersRequestV0) (describeProducersResponseV0, error) {
+	var response describeProducersResponseV0
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(describeProducers, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return describeProducersResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+type describeProducersRequestTopicV0 struct {
+	Name             string
+	PartitionIndexes []int32
+}
+
+func (t describeProducersRequestTopicV0) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofCompactInt32Array(t.PartitionIndexes) +
+		sizeofTaggedFields()
+}
+
+func (t describeProducersRequestTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeCompactInt32Array(t.PartitionIndexes)
+	wb.writeTaggedFields()
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeProducers
+type describeProducersRequestV0 struct {
+	Topics []describeProducersRequestTopicV0
+}
+
+// add appends a partition to the request, topics are expected to be added in
+// order.
+func (t *describeProducersRequestV0) add(topic string, partition int32) {
+	if n := len(t.Topics); n == 0 || t.Topics[n-1].Name != topic {
+		t.Topics = append(t.Topics, describeProducersRequestTopicV0{Name: topic})
+	}
+	last := &t.Topics[len(t.Topics)-1]
+	last.PartitionIndexes = append(last.PartitionIndexes, partition)
+}
+
+func (t describeProducersRequestV0) size() int32 {
+	return sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeProducersRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+type describeProducersResponseProducerV0 struct {
+	ProducerID            int64
+	ProducerEpoch         int32
+	LastSequence          int32
+	LastTimestamp         int64
+	CoordinatorEpoch      int32
+	CurrentTxnStartOffset int64
+}
+
+func (t describeProducersResponseProducerV0) size() int32 {
+	return sizeofInt64(t.ProducerID) +
+		sizeofInt32(t.ProducerEpoch) +
+		sizeofInt32(t.LastSequence) +
+		sizeofInt64(t.LastTimestamp) +
+		sizeofInt32(t.CoordinatorEpoch) +
+		sizeofInt64(t.CurrentTxnStartOffset) +
+		sizeofTaggedFields()
+}
+
+func (t describeProducersResponseProducerV0) writeTo(wb *writeBuffer) {
+	wb.writeInt64(t.ProducerID)
+	wb.writeInt32(t.ProducerEpoch)
+	wb.writeInt32(t.LastSequence)
+	wb.writeInt64(t.LastTimestamp)
+	wb.writeInt32(t.CoordinatorEpoch)
+	wb.writeInt64(t.CurrentTxnStartOffset)
+	wb.writeTaggedFields()
+}
+
+func (t *describeProducersResponseProducerV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt64(r, size, &t.ProducerID); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.ProducerEpoch); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.LastSequence); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.LastTimestamp); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.CoordinatorEpoch); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.CurrentTxnStartOffset); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+type describeProducersResponsePartitionV0 struct {
+	PartitionIndex  int32
+	ErrorCode       int16
+	ErrorMessage    string
+	ActiveProducers []describeProducersResponseProducerV0
+}
+
+func (t describeProducersResponsePartitionV0) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofCompactArray(len(t.ActiveProducers), func(i int) int32 { return t.ActiveProducers[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeProducersResponsePartitionV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeCompactArray(len(t.ActiveProducers), func(i int) { t.ActiveProducers[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *describeProducersResponsePartitionV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.PartitionIndex); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var producer describeProducersResponseProducerV0
+		if fnRemain, fnErr = (&producer).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.ActiveProducers = append(t.ActiveProducers, producer)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+type describeProducersResponseTopicV0 struct {
+	Name       string
+	Partitions []describeProducersResponsePartitionV0
+}
+
+func (t describeProducersResponseTopicV0) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofCompactArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeProducersResponseTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeCompactArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *describeProducersResponseTopicV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.Name); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition describeProducersResponsePartitionV0
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeProducers
+type describeProducersResponseV0 struct {
+	ThrottleTimeMS int32
+	Topics         []describeProducersResponseTopicV0
+}
+
+func (t describeProducersResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeProducersResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *describeProducersResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic describeProducersResponseTopicV0
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,150 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestDescribeProducersResponseV0(t *testing.T) {
+	item := describeProducersResponseV0{
+		ThrottleTimeMS: 1,
+		Topics: []describeProducersResponseTopicV0{
+			{
+				Name: "a",
+				Partitions: []describeProducersResponsePartitionV0{
+					{
+						PartitionIndex: 0,
+						ActiveProducers: []describeProducersResponseProducerV0{
+							{ProducerID: 1, ProducerEpoch: 2, LastSequence: 3, LastTimestamp: 4, CoordinatorEpoch: 5, CurrentTxnStartOffset: 6},
+							{ProducerID: 7, LastSequence: -1, LastTimestamp: -1, CurrentTxnStartOffset: -1},
+						},
+					},
+					{PartitionIndex: 1, ErrorCode: int16(NotLeaderForPartition), ErrorMessage: "b"},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		t.FailNow()
+	}
+
+	var found describeProducersResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDescribeProducersRequestV0Add(t *testing.T) {
+	request := describeProducersRequestV0{}
+	request.add("a", 0)
+	request.add("a", 1)
+	request.add("b", 0)
+
+	expected := describeProducersRequestV0{
+		Topics: []describeProducersRequestTopicV0{
+			{Name: "a", PartitionIndexes: []int32{0, 1}},
+			{Name: "b", PartitionIndexes: []int32{0}},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, request) {
+		t.Errorf("expected %+v, got %+v", expected, request)
+	}
+}
+
+func testClientDescribeProducers(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.8.0") {
+		t.Skip("describe producers requires kafka 2.8.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	transactionalID := makeTopic()
+	createTopic(t, topic, 1)
+
+	producer, err := c.InitProducerID(ctx, InitProducerIDRequest{
+		TransactionalID:    transactionalID,
+		TransactionTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.AddPartitionsToTxn(ctx, AddPartitionsToTxnRequest{
+		TransactionalID: transactionalID,
+		ProducerID:      producer.ProducerID,
+		ProducerEpoch:   producer.ProducerEpoch,
+		Topics:          map[string][]int{topic: {0}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	produced, err := c.Produce(ctx, ProduceRequest{
+		Topic:           topic,
+		Partition:       0,
+		Messages:        makeTestSequence(2),
+		TransactionalID: transactionalID,
+		ProducerID:      producer.ProducerID,
+		ProducerEpoch:   producer.ProducerEpoch,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if produced.Error != nil {
+		t.Fatalf("producing in the transaction failed: %v", produced.Error)
+	}
+
+	// The transaction is left open, which is what blocks the last stable
+	// offset of the partition.
+	res, err := c.DescribeProducers(ctx, DescribeProducersRequest{
+		Topics: map[string][]int{topic: {0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partitions := res.Topics[topic]
+	if len(partitions) != 1 {
+		t.Fatalf("expected the producers of 1 partition, got %d", len(partitions))
+	}
+	p := partitions[0]
+	if p.Error != nil {
+		t.Fatalf("describing the producers of partition %d failed: %v", p.Partition, p.Error)
+	}
+	if len(p.ActiveProducers) != 1 {
+		t.Fatalf("expected 1 active producer, got %d", len(p.ActiveProducers))
+	}
+
+	active := p.ActiveProducers[0]
+	if active.ProducerID != producer.ProducerID {
+		t.Errorf("expected producer id %d, got %d", producer.ProducerID, active.ProducerID)
+	}
+	if active.LastSequence != 1 {
+		t.Errorf("expected last sequence 1, got %d", active.LastSequence)
+	}
+	if active.CurrentTxnStartOffset != produced.BaseOffset {
+		t.Errorf("expected the transaction to start at offset %d, got %d", produced.BaseOffset, active.CurrentTxnStartOffset)
+	}
+}
@@ -0,0 +1,352 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DescribeTransactionsRequest represents a request sent to a kafka cluster to
+// describe transactions.
+type DescribeTransactionsRequest struct {
+	// TransactionalIDs holds the transactional IDs of the transactions to
+	// describe.
+	TransactionalIDs []string
+}
+
+// DescribeTransactionsResponse represents the response to a
+// DescribeTransactionsRequest.
+type DescribeTransactionsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinators.
+	Throttle time.Duration
+
+	// Transactions holds the description of each transaction, in the order
+	// they appeared in the request.
+	Transactions []DescribeTransactionsResponseTransaction
+}
+
+// DescribeTransactionsResponseTransaction describes the state of a
+// transaction.
+type DescribeTransactionsResponseTransaction struct {
+	TransactionalID string
+
+	// Error is set to a non-nil value if the transaction could not be
+	// described, for example TransactionalIDNotFound if the coordinator does
+	// not know about the transactional ID.
+	Error error
+
+	// TransactionState is one of Empty, Ongoing, PrepareCommit, PrepareAbort,
+	// CompleteCommit, CompleteAbort, Dead, or PrepareEpochFence.
+	TransactionState string
+
+	// TransactionTimeout is the timeout of the transaction configured by the
+	// producer.
+	TransactionTimeout time.Duration
+
+	// TransactionStartTime is the time at which the transaction started, it
+	// is zero if no transaction is in progress.
+	TransactionStartTime time.Time
+
+	ProducerID    int64
+	ProducerEpoch int
+
+	// Topics holds the partitions that were added to the transaction, indexed
+	// by topic name.
+	Topics map[string][]int
+}
+
+// DescribeTransactions describes the transactions of the kafka cluster. Each
+// transaction is described by its coordinator, the requests to different
+// coordinators are sent concurrently. The API was introduced in kafka 3.0
+// (KIP-664).
+//
+// Errors that apply to a single transaction are reported on the transaction and
+// do not cause the method to fail.
+func (c *Client) DescribeTransactions(ctx context.Context, req DescribeTransactionsRequest) (*DescribeTransactionsResponse, error) {
+	coordinators, errs, err := c.transactionCoordinators(ctx, req.TransactionalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	mutex := sync.Mutex{}
+	throttle := time.Duration(0)
+	transactions := make(map[string]DescribeTransactionsResponseTransaction, len(req.TransactionalIDs))
+	wg := sync.WaitGroup{}
+
+	for b, transactionalIDs := range coordinators {
+		wg.Add(1)
+		go func(b Broker, transactionalIDs []string) {
+			defer wg.Done()
+
+			response, err := c.describeCoordinatorTransactions(ctx, b, transactionalIDs)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				for _, transactionalID := range transactionalIDs {
+					transactions[transactionalID] = DescribeTransactionsResponseTransaction{TransactionalID: transactionalID, Error: err}
+				}
+				return
+			}
+			if t := duration(response.ThrottleTimeMS); t > throttle {
+				throttle = t
+			}
+			for _, t := range response.TransactionStates {
+				transactions[t.TransactionalID] = t.toDescribeTransactionsResponseTransaction()
+			}
+		}(b, transactionalIDs)
+	}
+
+	wg.Wait()
+
+	res := &DescribeTransactionsResponse{
+		Throttle:     throttle,
+		Transactions: make([]DescribeTransactionsResponseTransaction, len(req.TransactionalIDs)),
+	}
+
+	for i, transactionalID := range req.TransactionalIDs {
+		if err := errs[transactionalID]; err != nil {
+			res.Transactions[i] = DescribeTransactionsResponseTransaction{TransactionalID: transactionalID, Error: err}
+			continue
+		}
+		t, ok := transactions[transactionalID]
+		if !ok {
+			t = DescribeTransactionsResponseTransaction{
+				TransactionalID: transactionalID,
+				Error:           fmt.Errorf("transaction %s missing from the describe transactions response", transactionalID),
+			}
+		}
+		res.Transactions[i] = t
+	}
+
+	return res, nil
+}
+
+// describeCoordinatorTransactions describes the transactions coordinated by
+// the broker b.
+func (c *Client) describeCoordinatorTransactions(ctx context.Context, b Broker, transactionalIDs []string) (describeTransactionsResponseV0, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return describeTransactionsResponseV0{}, err
+	}
+	defer conn.Close()
+	return conn.describeTransactions(describeTransactionsRequestV0{TransactionalIDs: transactionalIDs})
+}
+
+// describeTransactions describes transactions, the broker must be the
+// coordinator of the transactions.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeTransactions
+func (c *Conn) describeTransactions(request describeTransactionsRequestV0) (describeTransactionsResponseV0, error) {
+	var response describeTransactionsResponseV0
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(describeTransactions, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return describeTransactionsResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeTransactions
+type describeTransactionsRequestV0 struct {
+	TransactionalIDs []string
+}
+
+func (t describeTransactionsRequestV0) size() int32 {
+	return sizeofCompactStringArray(t.TransactionalIDs) +
+		sizeofTaggedFields()
+}
+
+func (t describeTransactionsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactStringArray(t.TransactionalIDs)
+	wb.writeTaggedFields()
+}
+
+type describeTransactionsResponseTopicV0 struct {
+	Topic      string
+	Partitions []int32
+}
+
+func (t describeTransactionsResponseTopicV0) size() int32 {
+	return sizeofCompactString(t.Topic) +
+		sizeofCompactInt32Array(t.Partitions) +
+		sizeofTaggedFields()
+}
+
+func (t describeTransactionsResponseTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Topic)
+	wb.writeCompactInt32Array(t.Partitions)
+	wb.writeTaggedFields()
+}
+
+func (t *describeTransactionsResponseTopicV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.Topic); err != nil {
+		return
+	}
+	if remain, err = readCompactInt32Array(r, remain, &t.Partitions); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+type describeTransactionsResponseStateV0 struct {
+	ErrorCode              int16
+	TransactionalID        string
+	TransactionState       string
+	TransactionTimeoutMS   int32
+	TransactionStartTimeMS int64
+	ProducerID             int64
+	ProducerEpoch          int16
+	Topics                 []describeTransactionsResponseTopicV0
+}
+
+func (t describeTransactionsResponseStateV0) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.TransactionalID) +
+		sizeofCompactString(t.TransactionState) +
+		sizeofInt32(t.TransactionTimeoutMS) +
+		sizeofInt64(t.TransactionStartTimeMS) +
+		sizeofInt64(t.ProducerID) +
+		sizeofInt16(t.ProducerEpoch) +
+		sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeTransactionsResponseStateV0) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.TransactionalID)
+	wb.writeCompactString(t.TransactionState)
+	wb.writeInt32(t.TransactionTimeoutMS)
+	wb.writeInt64(t.TransactionStartTimeMS)
+	wb.writeInt64(t.ProducerID)
+	wb.writeInt16(t.ProducerEpoch)
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *describeTransactionsResponseStateV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.TransactionalID); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.TransactionState); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.TransactionTimeoutMS); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.TransactionStartTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.ProducerID); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ProducerEpoch); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic describeTransactionsResponseTopicV0
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+func (t describeTransactionsResponseStateV0) toDescribeTransactionsResponseTransaction() DescribeTransactionsResponseTransaction {
+	transaction := DescribeTransactionsResponseTransaction{
+		TransactionalID:    t.TransactionalID,
+		TransactionState:   t.TransactionState,
+		TransactionTimeout: duration(t.TransactionTimeoutMS),
+		ProducerID:         t.ProducerID,
+		ProducerEpoch:      int(t.ProducerEpoch),
+	}
+	if t.ErrorCode != 0 {
+		transaction.Error = Error(t.ErrorCode)
+	}
+	if t.TransactionStartTimeMS >= 0 {
+		transaction.TransactionStartTime = timestampToTime(t.TransactionStartTimeMS)
+	}
+	if len(t.Topics) != 0 {
+		transaction.Topics = make(map[string][]int, len(t.Topics))
+		for _, topic := range t.Topics {
+			partitions := make([]int, len(topic.Partitions))
+			for i, p := range topic.Partitions {
+				partitions[i] = int(p)
+			}
+			transaction.Topics[topic.Topic] = partitions
+		}
+	}
+	return transaction
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeTransactions
+type describeTransactionsResponseV0 struct {
+	ThrottleTimeMS    int32
+	TransactionStates []describeTransactionsResponseStateV0
+}
+
+func (t describeTransactionsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofCompactArray(len(t.TransactionStates), func(i int) int32 { return t.TransactionStates[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeTransactionsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeCompactArray(len(t.TransactionStates), func(i int) { t.TransactionStates[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *describeTransactionsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var state describeTransactionsResponseStateV0
+		if fnRemain, fnErr = (&state).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.TransactionStates = append(t.TransactionStates, state)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
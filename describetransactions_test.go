@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestDescribeTransactionsResponseV0(t *testing.T) {
+	item := describeTransactionsResponseV0{
+		ThrottleTimeMS: 1,
+		TransactionStates: []describeTransactionsResponseStateV0{
+			{
+				TransactionalID:        "a",
+				TransactionState:       "Ongoing",
+				TransactionTimeoutMS:   2,
+				TransactionStartTimeMS: 3,
+				ProducerID:             4,
+				ProducerEpoch:          5,
+				Topics: []describeTransactionsResponseTopicV0{
+					{Topic: "b", Partitions: []int32{0, 1}},
+				},
+			},
+			{
+				ErrorCode:              int16(TransactionalIDNotFound),
+				TransactionalID:        "c",
+				TransactionStartTimeMS: -1,
+				ProducerID:             -1,
+				ProducerEpoch:          -1,
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found describeTransactionsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDescribeTransactionsRequestV0Size(t *testing.T) {
+	for _, item := range []describeTransactionsRequestV0{
+		{},
+		{TransactionalIDs: []string{"a", "b"}},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func testClientDescribeTransactions(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("3.0.0") {
+		t.Skip("describing transactions requires kafka 3.0.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	transactionalID := makeTopic()
+	missingID := makeTopic()
+	createTopic(t, topic, 1)
+
+	producer := openTestTransaction(t, ctx, c, topic, transactionalID)
+
+	res, err := c.DescribeTransactions(ctx, DescribeTransactionsRequest{
+		TransactionalIDs: []string{transactionalID, missingID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(res.Transactions))
+	}
+
+	txn := res.Transactions[0]
+	if txn.Error != nil {
+		t.Fatalf("describing transaction %s failed: %v", txn.TransactionalID, txn.Error)
+	}
+	if txn.TransactionalID != transactionalID {
+		t.Errorf("expected transactional id %q, got %q", transactionalID, txn.TransactionalID)
+	}
+	if txn.TransactionState != "Ongoing" {
+		t.Errorf("expected the transaction to be ongoing, got %q", txn.TransactionState)
+	}
+	if txn.TransactionTimeout != 10*time.Second {
+		t.Errorf("expected a transaction timeout of 10s, got %s", txn.TransactionTimeout)
+	}
+	if txn.ProducerID != producer.ProducerID {
+		t.Errorf("expected producer id %d, got %d", producer.ProducerID, txn.ProducerID)
+	}
+	if !reflect.DeepEqual(txn.Topics, map[string][]int{topic: {0}}) {
+		t.Errorf("expected the transaction to hold partition 0 of %s, got %v", topic, txn.Topics)
+	}
+
+	if missing := res.Transactions[1]; missing.Error != TransactionalIDNotFound {
+		t.Errorf("expected %s to be missing, got %v", missingID, missing.Error)
+	}
+}
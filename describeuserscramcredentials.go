@@ -0,0 +1,309 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// ScramMechanism is the SCRAM mechanism of user credentials.
+type ScramMechanism int8
+
+const (
+	ScramMechanismUnknown ScramMechanism = 0
+	ScramMechanismSHA256  ScramMechanism = 1
+	ScramMechanismSHA512  ScramMechanism = 2
+)
+
+// DescribeUserScramCredentialsRequest represents a request sent to a kafka
+// cluster to describe the SCRAM credentials of users.
+type DescribeUserScramCredentialsRequest struct {
+	// Users holds the names of the users to describe, all the users with
+	// SCRAM credentials are described when it is nil.
+	Users []string
+}
+
+// DescribeUserScramCredentialsResponse represents the response to a
+// DescribeUserScramCredentialsRequest.
+type DescribeUserScramCredentialsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// Users holds the credentials of each user.
+	Users []DescribeUserScramCredentialsResponseUser
+}
+
+// DescribeUserScramCredentialsResponseUser carries the SCRAM credentials of a
+// user.
+type DescribeUserScramCredentialsResponseUser struct {
+	User string
+
+	// Error is set to a non-nil value if the credentials of the user could
+	// not be described, for example ResourceNotFound if the user has no
+	// SCRAM credentials.
+	Error error
+
+	// ErrorMessage holds the message sent by the broker alongside Error.
+	ErrorMessage string
+
+	// Credentials holds the SCRAM mechanisms that the user has credentials
+	// for, the passwords are never returned.
+	Credentials []ScramCredentialInfo
+}
+
+// ScramCredentialInfo describes the SCRAM credentials of a user for a
+// mechanism.
+type ScramCredentialInfo struct {
+	Mechanism  ScramMechanism
+	Iterations int
+}
+
+// DescribeUserScramCredentials sends a DescribeUserScramCredentials request to
+// the kafka cluster. The API was introduced in kafka 2.7 (KIP-554).
+//
+// Errors that apply to a single user are reported on the user and do not
+// cause the method to fail.
+func (c *Client) DescribeUserScramCredentials(ctx context.Context, req DescribeUserScramCredentialsRequest) (*DescribeUserScramCredentialsResponse, error) {
+	request := describeUserScramCredentialsRequestV0{}
+
+	if req.Users != nil {
+		request.Users = make([]describeUserScramCredentialsRequestUserV0, len(req.Users))
+		for i, user := range req.Users {
+			request.Users[i].Name = user
+		}
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.describeUserScramCredentials(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DescribeUserScramCredentialsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Users:    make([]DescribeUserScramCredentialsResponseUser, len(response.Results)),
+	}
+
+	for i, r := range response.Results {
+		user := DescribeUserScramCredentialsResponseUser{
+			User:         r.User,
+			ErrorMessage: r.ErrorMessage,
+			Credentials:  make([]ScramCredentialInfo, len(r.CredentialInfos)),
+		}
+		if r.ErrorCode != 0 {
+			user.Error = Error(r.ErrorCode)
+		}
+		for j, info := range r.CredentialInfos {
+			user.Credentials[j] = ScramCredentialInfo{
+				Mechanism:  ScramMechanism(info.Mechanism),
+				Iterations: int(info.Iterations),
+			}
+		}
+		res.Users[i] = user
+	}
+
+	return res, nil
+}
+
+// describeUserScramCredentials describes the SCRAM credentials of the users of
+// the request.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeUserScramCredentials
+func (c *Conn) describeUserScramCredentials(request describeUserScramCredentialsRequestV0) (describeUserScramCredentialsResponseV0, error) {
+	var response describeUserScramCredentialsResponseV0
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(describeUserScramCredentials, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return describeUserScramCredentialsResponseV0{}, err
+	}
+	if response.ErrorCode != 0 {
+		return describeUserScramCredentialsResponseV0{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+type describeUserScramCredentialsRequestUserV0 struct {
+	Name string
+}
+
+func (t describeUserScramCredentialsRequestUserV0) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofTaggedFields()
+}
+
+func (t describeUserScramCredentialsRequestUserV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeTaggedFields()
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeUserScramCredentials
+type describeUserScramCredentialsRequestV0 struct {
+	// Users is nullable, all the users are described when it is nil.
+	Users []describeUserScramCredentialsRequestUserV0
+}
+
+func (t describeUserScramCredentialsRequestV0) size() int32 {
+	return sizeofCompactArray(len(t.Users), func(i int) int32 { return t.Users[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeUserScramCredentialsRequestV0) writeTo(wb *writeBuffer) {
+	if t.Users == nil {
+		wb.writeCompactArrayLen(-1)
+	} else {
+		wb.writeCompactArray(len(t.Users), func(i int) { t.Users[i].writeTo(wb) })
+	}
+	wb.writeTaggedFields()
+}
+
+type describeUserScramCredentialsResponseCredentialInfoV0 struct {
+	Mechanism  int8
+	Iterations int32
+}
+
+func (t describeUserScramCredentialsResponseCredentialInfoV0) size() int32 {
+	return sizeofInt8(t.Mechanism) +
+		sizeofInt32(t.Iterations) +
+		sizeofTaggedFields()
+}
+
+func (t describeUserScramCredentialsResponseCredentialInfoV0) writeTo(wb *writeBuffer) {
+	wb.writeInt8(t.Mechanism)
+	wb.writeInt32(t.Iterations)
+	wb.writeTaggedFields()
+}
+
+func (t *describeUserScramCredentialsResponseCredentialInfoV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt8(r, size, &t.Mechanism); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.Iterations); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+type describeUserScramCredentialsResponseResultV0 struct {
+	User            string
+	ErrorCode       int16
+	ErrorMessage    string
+	CredentialInfos []describeUserScramCredentialsResponseCredentialInfoV0
+}
+
+func (t describeUserScramCredentialsResponseResultV0) size() int32 {
+	return sizeofCompactString(t.User) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofCompactArray(len(t.CredentialInfos), func(i int) int32 { return t.CredentialInfos[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeUserScramCredentialsResponseResultV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.User)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeCompactArray(len(t.CredentialInfos), func(i int) { t.CredentialInfos[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *describeUserScramCredentialsResponseResultV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.User); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var info describeUserScramCredentialsResponseCredentialInfoV0
+		if fnRemain, fnErr = (&info).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.CredentialInfos = append(t.CredentialInfos, info)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_DescribeUserScramCredentials
+type describeUserScramCredentialsResponseV0 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	ErrorMessage   string
+	Results        []describeUserScramCredentialsResponseResultV0
+}
+
+func (t describeUserScramCredentialsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofCompactArray(len(t.Results), func(i int) int32 { return t.Results[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t describeUserScramCredentialsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeCompactArray(len(t.Results), func(i int) { t.Results[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *describeUserScramCredentialsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var result describeUserScramCredentialsResponseResultV0
+		if fnRemain, fnErr = (&result).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Results = append(t.Results, result)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
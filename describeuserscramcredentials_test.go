@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDescribeUserScramCredentialsResponseV0(t *testing.T) {
+	item := describeUserScramCredentialsResponseV0{
+		ThrottleTimeMS: 1,
+		Results: []describeUserScramCredentialsResponseResultV0{
+			{
+				User: "a",
+				CredentialInfos: []describeUserScramCredentialsResponseCredentialInfoV0{
+					{Mechanism: int8(ScramMechanismSHA256), Iterations: 4096},
+					{Mechanism: int8(ScramMechanismSHA512), Iterations: 8192},
+				},
+			},
+			{
+				User:         "b",
+				ErrorCode:    int16(ResourceNotFound),
+				ErrorMessage: "c",
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found describeUserScramCredentialsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestDescribeUserScramCredentialsRequestV0Size(t *testing.T) {
+	for _, item := range []describeUserScramCredentialsRequestV0{
+		{},
+		{Users: []describeUserScramCredentialsRequestUserV0{{Name: "a"}, {Name: "b"}}},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
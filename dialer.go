@@ -150,6 +150,13 @@ func (d *Dialer) LookupLeader(ctx context.Context, network string, address strin
 
 // LookupPartition searches for the description of specified partition id.
 func (d *Dialer) LookupPartition(ctx context.Context, network string, address string, topic string, partition int) (Partition, error) {
+	return d.lookupPartition(ctx, network, address, topic, partition, true)
+}
+
+// lookupPartition is the implementation of LookupPartition, which keeps looking
+// for a topic or partition that does not exist when retryUnknown is true, and
+// fails with UnknownTopicOrPartition otherwise.
+func (d *Dialer) lookupPartition(ctx context.Context, network string, address string, topic string, partition int, retryUnknown bool) (Partition, error) {
 	c, err := d.DialContext(ctx, network, address)
 	if err != nil {
 		return Partition{}, err
@@ -170,7 +177,7 @@ func (d *Dialer) LookupPartition(ctx context.Context, network string, address st
 
 			partitions, err := c.ReadPartitions(topic)
 			if err != nil {
-				if isTemporary(err) {
+				if isTemporary(err) && (retryUnknown || !isError(err, UnknownTopicOrPartition)) {
 					continue
 				}
 				errch <- err
@@ -183,9 +190,12 @@ func (d *Dialer) LookupPartition(ctx context.Context, network string, address st
 					return
 				}
 			}
-		}
 
-		errch <- UnknownTopicOrPartition
+			if !retryUnknown {
+				errch <- UnknownTopicOrPartition
+				return
+			}
+		}
 	}()
 
 	var prt Partition
@@ -0,0 +1,308 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"time"
+)
+
+// ElectionType is the type of leader election triggered by an ElectLeaders
+// request.
+type ElectionType int8
+
+const (
+	// ElectionTypePreferred elects the preferred replica (the first replica
+	// of the replica list) as leader of the partitions.
+	ElectionTypePreferred ElectionType = 0
+
+	// ElectionTypeUnclean elects a replica that is not in the ISR as leader
+	// of partitions that have no in-sync replica available, at the risk of
+	// losing data.
+	ElectionTypeUnclean ElectionType = 1
+)
+
+// ElectLeadersRequest represents a request sent to a kafka cluster to elect
+// the leaders of partitions.
+type ElectLeadersRequest struct {
+	// ElectionType is the type of election to trigger, it defaults to
+	// ElectionTypePreferred.
+	ElectionType ElectionType
+
+	// Topics holds the partitions to elect the leaders of, indexed by topic
+	// name. When nil, the election is triggered for all the partitions of the
+	// cluster.
+	Topics map[string][]int
+
+	// Timeout is how long the controller waits for the leaders to be elected,
+	// it defaults to the time remaining until the deadline of the context.
+	Timeout time.Duration
+}
+
+// ElectLeadersResponse represents the response to an ElectLeadersRequest.
+type ElectLeadersResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// controller.
+	Throttle time.Duration
+
+	// Topics holds the result of the election of each partition, indexed by
+	// topic name.
+	Topics map[string][]ElectLeadersResponsePartition
+}
+
+// ElectLeadersResponsePartition carries the result of electing the leader of a
+// partition.
+type ElectLeadersResponsePartition struct {
+	Partition int
+
+	// Error is set to a non-nil value if the election did not happen, for
+	// example ElectionNotNeeded if the preferred replica already is the leader
+	// of the partition.
+	Error error
+
+	// ErrorMessage holds the message sent by the controller alongside Error.
+	ErrorMessage string
+}
+
+// ElectLeaders sends an ElectLeaders request to the controller of the kafka
+// cluster. Unclean elections were introduced in kafka 2.4 (KIP-460), which is
+// the minimum version supported by this method.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) ElectLeaders(ctx context.Context, req ElectLeadersRequest) (*ElectLeadersResponse, error) {
+	request := electLeadersRequestV1{
+		ElectionType: int8(req.ElectionType),
+		Timeout:      protocolTimeout(ctx, req.Timeout),
+	}
+
+	if req.Topics != nil {
+		request.TopicPartitions = make([]electLeadersRequestTopicV1, 0, len(req.Topics))
+		for topic, partitions := range req.Topics {
+			t := electLeadersRequestTopicV1{
+				Topic:        topic,
+				PartitionIDs: make([]int32, len(partitions)),
+			}
+			for i, p := range partitions {
+				t.PartitionIDs[i] = int32(p)
+			}
+			request.TopicPartitions = append(request.TopicPartitions, t)
+		}
+		sort.Slice(request.TopicPartitions, func(i, j int) bool {
+			return request.TopicPartitions[i].Topic < request.TopicPartitions[j].Topic
+		})
+	}
+
+	conn, err := c.connectController(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.electLeaders(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ElectLeadersResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make(map[string][]ElectLeadersResponsePartition, len(response.ReplicaElectionResults)),
+	}
+
+	for _, t := range response.ReplicaElectionResults {
+		partitions := make([]ElectLeadersResponsePartition, len(t.PartitionResults))
+		for i, p := range t.PartitionResults {
+			partitions[i] = ElectLeadersResponsePartition{
+				Partition:    int(p.PartitionID),
+				ErrorMessage: p.ErrorMessage,
+			}
+			if p.ErrorCode != 0 {
+				partitions[i].Error = Error(p.ErrorCode)
+			}
+		}
+		res.Topics[t.Topic] = partitions
+	}
+
+	return res, nil
+}
+
+// electLeaders triggers the election of the leaders of the requested
+// partitions, the broker must be the controller of the cluster.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_ElectLeaders
+func (c *Conn) electLeaders(request electLeadersRequestV1) (electLeadersResponseV1, error) {
+	var response electLeadersResponseV1
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			if request.Timeout == 0 {
+				now := time.Now()
+				deadline = adjustDeadlineForRTT(deadline, now, defaultRTT)
+				request.Timeout = milliseconds(deadlineToTimeout(deadline, now))
+			}
+			return c.writeRequest(electLeaders, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return electLeadersResponseV1{}, err
+	}
+	if response.ErrorCode != 0 {
+		return electLeadersResponseV1{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+type electLeadersRequestTopicV1 struct {
+	Topic        string
+	PartitionIDs []int32
+}
+
+func (t electLeadersRequestTopicV1) size() int32 {
+	return sizeofString(t.Topic) +
+		sizeofInt32Array(t.PartitionIDs)
+}
+
+func (t electLeadersRequestTopicV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Topic)
+	wb.writeInt32Array(t.PartitionIDs)
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_ElectLeaders
+type electLeadersRequestV1 struct {
+	ElectionType int8
+
+	// TopicPartitions is nullable, the election is triggered for all the
+	// partitions when it is nil.
+	TopicPartitions []electLeadersRequestTopicV1
+
+	// Timeout ms to wait for the election to complete.
+	Timeout int32
+}
+
+func (t electLeadersRequestV1) size() int32 {
+	return sizeofInt8(t.ElectionType) +
+		sizeofArray(len(t.TopicPartitions), func(i int) int32 { return t.TopicPartitions[i].size() }) +
+		sizeofInt32(t.Timeout)
+}
+
+func (t electLeadersRequestV1) writeTo(wb *writeBuffer) {
+	wb.writeInt8(t.ElectionType)
+	if t.TopicPartitions == nil {
+		wb.writeArrayLen(-1)
+	} else {
+		wb.writeArray(len(t.TopicPartitions), func(i int) { t.TopicPartitions[i].writeTo(wb) })
+	}
+	wb.writeInt32(t.Timeout)
+}
+
+type electLeadersResponsePartitionV1 struct {
+	PartitionID  int32
+	ErrorCode    int16
+	ErrorMessage string
+}
+
+func (t electLeadersResponsePartitionV1) size() int32 {
+	return sizeofInt32(t.PartitionID) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofString(t.ErrorMessage)
+}
+
+func (t electLeadersResponsePartitionV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionID)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeString(t.ErrorMessage)
+}
+
+func (t *electLeadersResponsePartitionV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.PartitionID); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	return
+}
+
+type electLeadersResponseTopicV1 struct {
+	Topic            string
+	PartitionResults []electLeadersResponsePartitionV1
+}
+
+func (t electLeadersResponseTopicV1) size() int32 {
+	return sizeofString(t.Topic) +
+		sizeofArray(len(t.PartitionResults), func(i int) int32 { return t.PartitionResults[i].size() })
+}
+
+func (t electLeadersResponseTopicV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Topic)
+	wb.writeArray(len(t.PartitionResults), func(i int) { t.PartitionResults[i].writeTo(wb) })
+}
+
+func (t *electLeadersResponseTopicV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.Topic); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition electLeadersResponsePartitionV1
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.PartitionResults = append(t.PartitionResults, partition)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_ElectLeaders
+type electLeadersResponseV1 struct {
+	ThrottleTimeMS         int32
+	ErrorCode              int16
+	ReplicaElectionResults []electLeadersResponseTopicV1
+}
+
+func (t electLeadersResponseV1) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofArray(len(t.ReplicaElectionResults), func(i int) int32 { return t.ReplicaElectionResults[i].size() })
+}
+
+func (t electLeadersResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeArray(len(t.ReplicaElectionResults), func(i int) { t.ReplicaElectionResults[i].writeTo(wb) })
+}
+
+func (t *electLeadersResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic electLeadersResponseTopicV1
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.ReplicaElectionResults = append(t.ReplicaElectionResults, topic)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
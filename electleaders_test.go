@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestElectLeadersResponseV1(t *testing.T) {
+	item := electLeadersResponseV1{
+		ThrottleTimeMS: 1,
+		ReplicaElectionResults: []electLeadersResponseTopicV1{
+			{
+				Topic: "a",
+				PartitionResults: []electLeadersResponsePartitionV1{
+					{PartitionID: 0},
+					{PartitionID: 1, ErrorCode: int16(ElectionNotNeeded), ErrorMessage: "b"},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found electLeadersResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestElectLeadersRequestV1AllPartitions(t *testing.T) {
+	item := electLeadersRequestV1{Timeout: 1}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	// election type, null array, timeout
+	expected := []byte{0, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 1}
+	if !bytes.Equal(expected, b.Bytes()) {
+		t.Errorf("expected %v, got %v", expected, b.Bytes())
+	}
+}
+
+func testClientElectLeaders(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.4.0") {
+		t.Skip("elect leaders requires kafka 2.4.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	res, err := c.ElectLeaders(ctx, ElectLeadersRequest{
+		Topics: map[string][]int{topic: {0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partitions := res.Topics[topic]
+	if len(partitions) != 1 {
+		t.Fatalf("expected results for 1 partition, got %d", len(partitions))
+	}
+	// The partition was just created, its preferred replica already is the
+	// leader.
+	if err := partitions[0].Error; err != ElectionNotNeeded {
+		t.Errorf("expected %v, got %v", ElectionNotNeeded, err)
+	}
+}
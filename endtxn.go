@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"context"
+	"time"
+)
+
+// EndTxnRequest represents a request sent to a kafka cluster to commit or
+// abort the ongoing transaction of a producer.
+type EndTxnRequest struct {
+	// TransactionalID, ProducerID, and ProducerEpoch identify the
+	// transactional producer, as returned by InitProducerID.
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int
+
+	// Committed is true to commit the transaction, false to abort it.
+	Committed bool
+}
+
+// EndTxnResponse represents the response to an EndTxnRequest.
+type EndTxnResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+}
+
+// EndTxn commits or aborts the ongoing transaction of a producer. The request
+// is sent to the coordinator of the transactional id, which writes the
+// transaction markers to the partitions of the transaction. The API was
+// introduced in kafka 0.11.
+func (c *Client) EndTxn(ctx context.Context, req EndTxnRequest) (*EndTxnResponse, error) {
+	conn, err := c.transactionCoordinator(ctx, req.TransactionalID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.endTxn(endTxnRequestV0{
+		TransactionalID: req.TransactionalID,
+		ProducerID:      req.ProducerID,
+		ProducerEpoch:   int16(req.ProducerEpoch),
+		Committed:       req.Committed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EndTxnResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+	}, nil
+}
+
+// endTxn commits or aborts a transaction, the broker must be the coordinator
+// of the transactional id. The response has the same layout than the response
+// to AddOffsetsToTxn v0.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_EndTxn
+func (c *Conn) endTxn(request endTxnRequestV0) (txnResponseV0, error) {
+	var response txnResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(endTxn, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return txnResponseV0{}, err
+	}
+	if response.ErrorCode != 0 {
+		return txnResponseV0{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_EndTxn
+type endTxnRequestV0 struct {
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int16
+	Committed       bool
+}
+
+func (t endTxnRequestV0) size() int32 {
+	return sizeofString(t.TransactionalID) +
+		sizeofInt64(t.ProducerID) +
+		sizeofInt16(t.ProducerEpoch) +
+		sizeofBool(t.Committed)
+}
+
+func (t endTxnRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TransactionalID)
+	wb.writeInt64(t.ProducerID)
+	wb.writeInt16(t.ProducerEpoch)
+	wb.writeBool(t.Committed)
+}
@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestTxnResponseV0(t *testing.T) {
+	item := txnResponseV0{
+		ThrottleTimeMS: 1,
+		ErrorCode:      int16(InvalidProducerEpoch),
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found txnResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestTxnRequestsV0Size(t *testing.T) {
+	for _, item := range []request{
+		endTxnRequestV0{TransactionalID: "a", ProducerID: 1, ProducerEpoch: 2, Committed: true},
+		addOffsetsToTxnRequestV0{TransactionalID: "a", ProducerID: 1, ProducerEpoch: 2, GroupID: "b"},
+		addPartitionsToTxnRequestV0{
+			TransactionalID: "a",
+			ProducerID:      1,
+			ProducerEpoch:   2,
+			Topics:          []offsetFetchRequestV1Topic{{Topic: "b", Partitions: []int32{0, 1}}},
+		},
+		txnOffsetCommitRequestV0{
+			TransactionalID: "a",
+			GroupID:         "b",
+			ProducerID:      1,
+			ProducerEpoch:   2,
+			Topics: []offsetCommitRequestV2Topic{{
+				Topic:      "c",
+				Partitions: []offsetCommitRequestV2Partition{{Partition: 0, Offset: 3, Metadata: "d"}},
+			}},
+		},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("%T: expected %d bytes, got %d", item, item.size(), b.Len())
+		}
+	}
+}
+
+func testClientTransaction(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("0.11.0") {
+		t.Skip("transactions require kafka 0.11.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	transactionalID := makeTopic()
+	createTopic(t, topic, 1)
+
+	producer, err := c.InitProducerID(ctx, InitProducerIDRequest{
+		TransactionalID:    transactionalID,
+		TransactionTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := c.AddPartitionsToTxn(ctx, AddPartitionsToTxnRequest{
+		TransactionalID: transactionalID,
+		ProducerID:      producer.ProducerID,
+		ProducerEpoch:   producer.ProducerEpoch,
+		Topics:          map[string][]int{topic: {0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range added.Topics[topic] {
+		if p.Error != nil {
+			t.Fatalf("adding partition %d to the transaction failed: %v", p.Partition, p.Error)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		res, err := c.Produce(ctx, ProduceRequest{
+			Topic:           topic,
+			Partition:       0,
+			Messages:        makeTestSequence(2),
+			TransactionalID: transactionalID,
+			ProducerID:      producer.ProducerID,
+			ProducerEpoch:   producer.ProducerEpoch,
+			BaseSequence:    2 * i,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Error != nil {
+			t.Fatalf("producing batch %d in the transaction failed: %v", i, res.Error)
+		}
+	}
+
+	// The last stable offset does not move past the first offset of an open
+	// transaction, nothing is visible to read_committed consumers yet.
+	conn, err := DialLeader(ctx, "tcp", "localhost:9092", topic, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	batch := conn.ReadBatchWith(ReadBatchConfig{
+		MinBytes:       1,
+		MaxBytes:       1e6,
+		MaxWait:        100 * time.Millisecond,
+		IsolationLevel: ReadCommitted,
+	})
+	if m, err := batch.ReadMessage(); err == nil {
+		t.Errorf("expected read_committed fetch to see no messages, got message at offset %d", m.Offset)
+	}
+	batch.Close()
+	conn.Close()
+
+	if _, err := c.EndTxn(ctx, EndTxnRequest{
+		TransactionalID: transactionalID,
+		ProducerID:      producer.ProducerID,
+		ProducerEpoch:   producer.ProducerEpoch,
+		Committed:       false,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Aborting the transaction writes a control record after the 4 records
+	// that were produced.
+	offsets, err := c.ListOffsets(ctx, ListOffsetsRequest{
+		Topics: map[string][]OffsetRequest{topic: {LastOffsetOf(0)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := offsets.Topics[topic]; len(p) != 1 || p[0].Offset != 5 {
+		t.Errorf("expected the abort marker to be written at offset 4, got %+v", p)
+	}
+}
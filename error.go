@@ -3,6 +3,7 @@ package kafka
 import (
 	"fmt"
 	"io"
+	"reflect"
 )
 
 // Error represents the different error codes that may be returned by kafka.
@@ -93,6 +94,20 @@ const (
 	PreferredLeaderNotAvailable        Error = 80
 	GroupMaxSizeReached                Error = 81
 	FencedInstanceID                   Error = 82
+	EligibleLeadersNotAvailable        Error = 83
+	ElectionNotNeeded                  Error = 84
+	NoReassignmentInProgress           Error = 85
+	GroupSubscribedToTopic             Error = 86
+	InvalidRecord                      Error = 87
+	UnstableOffsetCommit               Error = 88
+	ThrottlingQuotaExceeded            Error = 89
+	ProducerFenced                     Error = 90
+	ResourceNotFound                   Error = 91
+	DuplicateResource                  Error = 92
+	UnacceptableCredential             Error = 93
+	UnknownTopicID                     Error = 100
+	InconsistentTopicID                Error = 103
+	TransactionalIDNotFound            Error = 105
 )
 
 // Error satisfies the error interface.
@@ -130,7 +145,12 @@ func (e Error) Temporary() bool {
 		FencedLeaderEpoch,
 		UnknownLeaderEpoch,
 		OffsetNotAvailable,
-		PreferredLeaderNotAvailable:
+		PreferredLeaderNotAvailable,
+		EligibleLeadersNotAvailable,
+		UnstableOffsetCommit,
+		ThrottlingQuotaExceeded,
+		UnknownTopicID,
+		InconsistentTopicID:
 		return true
 
 	default:
@@ -293,6 +313,46 @@ func (e Error) Title() string {
 		return "Unknown Leader Epoch"
 	case UnsupportedCompressionType:
 		return "Unsupported Compression Type"
+	case StaleBrokerEpoch:
+		return "Stale Broker Epoch"
+	case OffsetNotAvailable:
+		return "Offset Not Available"
+	case MemberIDRequired:
+		return "Member ID Required"
+	case PreferredLeaderNotAvailable:
+		return "Preferred Leader Not Available"
+	case GroupMaxSizeReached:
+		return "Group Max Size Reached"
+	case FencedInstanceID:
+		return "Fenced Instance ID"
+	case EligibleLeadersNotAvailable:
+		return "Eligible Leaders Not Available"
+	case ElectionNotNeeded:
+		return "Election Not Needed"
+	case NoReassignmentInProgress:
+		return "No Reassignment In Progress"
+	case GroupSubscribedToTopic:
+		return "Group Subscribed To Topic"
+	case InvalidRecord:
+		return "Invalid Record"
+	case UnstableOffsetCommit:
+		return "Unstable Offset Commit"
+	case ThrottlingQuotaExceeded:
+		return "Throttling Quota Exceeded"
+	case ProducerFenced:
+		return "Producer Fenced"
+	case ResourceNotFound:
+		return "Resource Not Found"
+	case DuplicateResource:
+		return "Duplicate Resource"
+	case UnacceptableCredential:
+		return "Unacceptable Credential"
+	case UnknownTopicID:
+		return "Unknown Topic ID"
+	case InconsistentTopicID:
+		return "Inconsistent Topic ID"
+	case TransactionalIDNotFound:
+		return "Transactional ID Not Found"
 	}
 	return ""
 }
@@ -452,6 +512,46 @@ func (e Error) Description() string {
 		return "the leader epoch in the request is newer than the epoch on the broker"
 	case UnsupportedCompressionType:
 		return "the requesting client does not support the compression type of given partition"
+	case StaleBrokerEpoch:
+		return "the broker epoch has changed"
+	case OffsetNotAvailable:
+		return "the leader high watermark has not caught up from a recent leader election so the offsets cannot be guaranteed to be monotonically increasing"
+	case MemberIDRequired:
+		return "the group member needs to have a valid member id before actually entering a consumer group"
+	case PreferredLeaderNotAvailable:
+		return "the preferred leader was not available"
+	case GroupMaxSizeReached:
+		return "the consumer group has reached its max size"
+	case FencedInstanceID:
+		return "the broker rejected this static consumer since another consumer with the same group.instance.id has registered with a different member.id"
+	case EligibleLeadersNotAvailable:
+		return "eligible topic partition leaders are not available"
+	case ElectionNotNeeded:
+		return "leader election not needed for topic partition"
+	case NoReassignmentInProgress:
+		return "no partition reassignment is in progress"
+	case GroupSubscribedToTopic:
+		return "deleting offsets of a topic is forbidden while the consumer group is actively subscribed to it"
+	case InvalidRecord:
+		return "this record has failed the validation on broker and hence been rejected"
+	case UnstableOffsetCommit:
+		return "there are unstable offsets that need to be cleared"
+	case ThrottlingQuotaExceeded:
+		return "the throttling quota has been exceeded"
+	case ProducerFenced:
+		return "there is a newer producer with the same transactional id which fences the current one"
+	case ResourceNotFound:
+		return "a request illegally referred to a resource that does not exist"
+	case DuplicateResource:
+		return "a request illegally referred to the same resource twice"
+	case UnacceptableCredential:
+		return "requested credential would not meet criteria for acceptability"
+	case UnknownTopicID:
+		return "this server does not host this topic ID"
+	case InconsistentTopicID:
+		return "the log's topic ID did not match the topic ID in the request"
+	case TransactionalIDNotFound:
+		return "the transactional ID could not be found"
 	}
 	return ""
 }
@@ -493,11 +593,322 @@ func coalesceErrors(errs ...error) error {
 	return nil
 }
 
+// RetryError is returned by the methods of Client that gave up on a request
+// after retrying it, Err is the error of the last attempt.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("kafka request failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Cause returns the error of the last attempt.
+func (e *RetryError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// BrokerMismatchError is returned by the methods of a Client pinned to a
+// broker with Client.To when a request must be served by another broker.
+type BrokerMismatchError struct {
+	// Pinned is the ID of the broker that the client is pinned to.
+	Pinned int
+
+	// Required is the ID of the broker that must serve the request.
+	Required int
+}
+
+func (e *BrokerMismatchError) Error() string {
+	return fmt.Sprintf("kafka request must be served by broker %d but the client is pinned to broker %d", e.Required, e.Pinned)
+}
+
+// InterceptorError is returned by Writer.WriteMessages when one of the
+// interceptors of the writer failed, none of the messages are written then.
+//
+// An interceptor rejecting a specific message returns an InterceptorError with
+// Index set to the position of the message in the slice that it received, the
+// writer fills in the Interceptor field. Other errors returned by interceptors
+// are wrapped in an InterceptorError with an Index of -1.
+type InterceptorError struct {
+	// Interceptor is the position of the interceptor in the Interceptors
+	// field of WriterConfig.
+	Interceptor int
+
+	// Index is the position of the offending message, or -1 if the error is
+	// not caused by a specific message.
+	Index int
+
+	Err error
+}
+
+func (e *InterceptorError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("kafka writer interceptor %d failed: %v", e.Interceptor, e.Err)
+	}
+	return fmt.Sprintf("kafka writer interceptor %d rejected message %d: %v", e.Interceptor, e.Index, e.Err)
+}
+
+// Cause returns the error of the interceptor.
+func (e *InterceptorError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the error of the interceptor.
+func (e *InterceptorError) Unwrap() error {
+	return e.Err
+}
+
+// ReaderInterceptorError is returned by the methods of a Reader reading messages
+// when one of the interceptors of the reader failed on a message, and the
+// reader has no BadMessageHandler.
+type ReaderInterceptorError struct {
+	// Interceptor is the position of the interceptor in the Interceptors
+	// field of ReaderConfig.
+	Interceptor int
+
+	// Message is the message that the interceptor failed on, with the changes
+	// made by the interceptors which ran before it.
+	Message Message
+
+	Err error
+}
+
+func (e *ReaderInterceptorError) Error() string {
+	return fmt.Sprintf("kafka reader interceptor %d failed on the message at offset %d of partition %d of %s: %v", e.Interceptor, e.Message.Offset, e.Message.Partition, e.Message.Topic, e.Err)
+}
+
+// Cause returns the error of the interceptor.
+func (e *ReaderInterceptorError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the error of the interceptor.
+func (e *ReaderInterceptorError) Unwrap() error {
+	return e.Err
+}
+
+// TopicCreationError is the error of the messages of a Writer which failed to
+// create their topic, see WriterConfig.TopicAutoCreate. Err is for example
+// TopicAuthorizationFailed when the program is not allowed to create the
+// topic, or InvalidReplicationFactor when the configuration of the topic is
+// not valid.
+type TopicCreationError struct {
+	Topic string
+	Err   error
+
+	// ErrorMessage holds the message sent by the broker alongside Err, if any.
+	ErrorMessage string
+}
+
+func (e *TopicCreationError) Error() string {
+	if e.ErrorMessage != "" {
+		return fmt.Sprintf("kafka writer failed to create topic %s: %v: %s", e.Topic, e.Err, e.ErrorMessage)
+	}
+	return fmt.Sprintf("kafka writer failed to create topic %s: %v", e.Topic, e.Err)
+}
+
+// Cause returns the error of the creation of the topic.
+func (e *TopicCreationError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the error of the creation of the topic.
+func (e *TopicCreationError) Unwrap() error {
+	return e.Err
+}
+
+// PartitionNotFoundError is the error of the messages of a Writer which were
+// written to a partition that their topic does not have, see
+// WriterConfig.ExplicitPartitions.
+type PartitionNotFoundError struct {
+	Topic     string
+	Partition int
+
+	// Partitions is the number of partitions of the topic.
+	Partitions int
+}
+
+func (e *PartitionNotFoundError) Error() string {
+	return fmt.Sprintf("kafka writer cannot write to partition %d of topic %s which has %d partitions", e.Partition, e.Topic, e.Partitions)
+}
+
+// GroupInstanceFencedError is returned by ConsumerGroup.Next, and by the
+// methods of a Reader which is part of a group, when the coordinator fenced
+// the static member because another member joined with the same group instance
+// ID. The member stops participating in the group, it is not retried since
+// the two members would keep on fencing each other.
+type GroupInstanceFencedError struct {
+	GroupID         string
+	GroupInstanceID string
+
+	// MemberID is the member ID that the fenced member had in the group.
+	MemberID string
+}
+
+func (e *GroupInstanceFencedError) Error() string {
+	return fmt.Sprintf("kafka consumer group %s fenced member %s: another member joined with the group instance id %s", e.GroupID, e.MemberID, e.GroupInstanceID)
+}
+
+// Cause returns FencedInstanceID.
+func (e *GroupInstanceFencedError) Cause() error {
+	return FencedInstanceID
+}
+
+// Unwrap returns FencedInstanceID.
+func (e *GroupInstanceFencedError) Unwrap() error {
+	return FencedInstanceID
+}
+
+// OffsetMetadataTooLargeError is returned when committing the offsets of a
+// consumer group fails because a metadata string is larger than the limit of
+// the brokers, configured by offset.metadata.max.bytes (4096 bytes by
+// default). Topic, Partition and Size describe the largest metadata of the
+// commit, none of the offsets were committed.
+type OffsetMetadataTooLargeError struct {
+	GroupID   string
+	Topic     string
+	Partition int
+	Size      int
+}
+
+func (e *OffsetMetadataTooLargeError) Error() string {
+	return fmt.Sprintf("kafka consumer group %s failed to commit the offset of partition %d of topic %s with %d bytes of metadata, which exceeds the offset.metadata.max.bytes of the brokers", e.GroupID, e.Partition, e.Topic, e.Size)
+}
+
+// Cause returns OffsetMetadataTooLarge.
+func (e *OffsetMetadataTooLargeError) Cause() error {
+	return OffsetMetadataTooLarge
+}
+
+// Unwrap returns OffsetMetadataTooLarge.
+func (e *OffsetMetadataTooLargeError) Unwrap() error {
+	return OffsetMetadataTooLarge
+}
+
+// OffsetOutOfRangeError is returned by readers configured with the
+// OffsetResetError policy when the offset they read from is before the first
+// offset of a partition. First and Last are the range of offsets of the
+// partition when the error occurred.
+type OffsetOutOfRangeError struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	First     int64
+	Last      int64
+}
+
+func (e *OffsetOutOfRangeError) Error() string {
+	return fmt.Sprintf("kafka reader offset %d of partition %d of topic %s is out of range, the partition has offsets %d to %d", e.Offset, e.Partition, e.Topic, e.First, e.Last)
+}
+
+// Cause returns OffsetOutOfRange.
+func (e *OffsetOutOfRangeError) Cause() error {
+	return OffsetOutOfRange
+}
+
+// Unwrap returns OffsetOutOfRange.
+func (e *OffsetOutOfRangeError) Unwrap() error {
+	return OffsetOutOfRange
+}
+
+// WriteErrors is returned by Writer.WriteMessages when some of the messages
+// failed to be written. It holds the outcome of each message, at the index of
+// the message in the list passed to WriteMessages, or in the list returned by
+// the last interceptor when the writer has Interceptors: nil if the message
+// was written, the error of its last attempt otherwise.
+//
+// errors.Is reports whether one of the messages failed with the target error.
+type WriteErrors []error
+
+// Count returns the number of messages that failed to be written.
+func (err WriteErrors) Count() int {
+	n := 0
+	for _, e := range err {
+		if e != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the indexes of the messages that failed to be written.
+func (err WriteErrors) Failed() []int {
+	var failed []int
+	for i, e := range err {
+		if e != nil {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
+
+func (err WriteErrors) Error() string {
+	for _, e := range err {
+		if e != nil {
+			return fmt.Sprintf("kafka write errors (%d/%d), first error: %v", err.Count(), len(err), e)
+		}
+	}
+	return fmt.Sprintf("kafka write errors (0/%d)", len(err))
+}
+
+// Is reports whether one of the messages failed with target, or with an error
+// wrapping target.
+func (err WriteErrors) Is(target error) bool {
+	for _, e := range err {
+		if e != nil && isError(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isError reports whether err is target or wraps it, the errors are unwrapped
+// with their Unwrap or Cause methods.
+func isError(err error, target error) bool {
+	comparable := target != nil && reflect.TypeOf(target).Comparable()
+
+	for err != nil {
+		if comparable && err == target {
+			return true
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Cause() error }:
+			err = e.Cause()
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// MessageTooLargeError is returned by Writer.WriteMessages when one of the
+// messages is larger than WriterConfig.MaxMessageBytes. Message is the message
+// which is too large, Index its position in the messages passed to
+// WriteMessages, and Size its size in bytes. Remaining holds the messages that
+// followed it, which were not written.
 type MessageTooLargeError struct {
 	Message   Message
 	Remaining []Message
+	Index     int
+	Size      int
 }
 
 func (e MessageTooLargeError) Error() string {
-	return MessageSizeTooLarge.Error()
+	return fmt.Sprintf("%s: message %d has %d bytes", MessageSizeTooLarge.Error(), e.Index, e.Size)
+}
+
+func (e MessageTooLargeError) Cause() error {
+	return MessageSizeTooLarge
+}
+
+func (e MessageTooLargeError) Unwrap() error {
+	return MessageSizeTooLarge
 }
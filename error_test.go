@@ -1,7 +1,10 @@
 package kafka
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -85,6 +88,26 @@ func TestError(t *testing.T) {
 		FencedLeaderEpoch,
 		UnknownLeaderEpoch,
 		UnsupportedCompressionType,
+		StaleBrokerEpoch,
+		OffsetNotAvailable,
+		MemberIDRequired,
+		PreferredLeaderNotAvailable,
+		GroupMaxSizeReached,
+		FencedInstanceID,
+		EligibleLeadersNotAvailable,
+		ElectionNotNeeded,
+		NoReassignmentInProgress,
+		GroupSubscribedToTopic,
+		InvalidRecord,
+		UnstableOffsetCommit,
+		ThrottlingQuotaExceeded,
+		ProducerFenced,
+		ResourceNotFound,
+		DuplicateResource,
+		UnacceptableCredential,
+		UnknownTopicID,
+		InconsistentTopicID,
+		TransactionalIDNotFound,
 	}
 
 	for _, err := range errorCodes {
@@ -113,3 +136,40 @@ func TestError(t *testing.T) {
 		}
 	})
 }
+
+func TestWriteErrors(t *testing.T) {
+	errs := WriteErrors{
+		nil,
+		&writerError{err: LeaderNotAvailable},
+		nil,
+		context.Canceled,
+	}
+
+	if n := errs.Count(); n != 2 {
+		t.Errorf("expected 2 failed messages, got %d", n)
+	}
+	if failed := errs.Failed(); !reflect.DeepEqual(failed, []int{1, 3}) {
+		t.Errorf("expected messages 1 and 3 to have failed, got %v", failed)
+	}
+	if s := errs.Error(); s != "kafka write errors (2/4), first error: "+LeaderNotAvailable.Error() {
+		t.Errorf("unexpected error message: %s", s)
+	}
+
+	var err error = errs
+	if !errors.Is(err, LeaderNotAvailable) {
+		t.Error("expected the wrapped error of message 1 to be found")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Error("expected the error of message 3 to be found")
+	}
+	if errors.Is(err, NotEnoughReplicas) {
+		t.Error("no message failed with NotEnoughReplicas")
+	}
+	if errors.Is(err, MessageTooLargeError{}) {
+		t.Error("no message failed with a MessageTooLargeError")
+	}
+
+	if n := (WriteErrors{nil, nil}).Count(); n != 0 {
+		t.Errorf("expected no failed messages, got %d", n)
+	}
+}
@@ -21,3 +21,45 @@ func ExampleWriter() {
 
 	w.Close()
 }
+
+// rackBalancer routes the messages to the partitions whose leader is in the
+// same rack as the program, or to any partition if there are none.
+type rackBalancer struct {
+	rack       string
+	roundRobin kafka.RoundRobin
+}
+
+func (b *rackBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	return b.roundRobin.Balance(msg, partitions...)
+}
+
+func (b *rackBalancer) BalancePartitions(msg kafka.Message, partitions []kafka.Partition) int {
+	var local []int
+	for _, p := range partitions {
+		if p.Leader.Rack == b.rack {
+			local = append(local, p.ID)
+		}
+	}
+	if len(local) == 0 {
+		for _, p := range partitions {
+			local = append(local, p.ID)
+		}
+	}
+	return b.roundRobin.Balance(msg, local...)
+}
+
+func ExamplePartitionBalancer() {
+	w := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:  []string{"localhost:9092"},
+		Topic:    "Topic-1",
+		Balancer: &rackBalancer{rack: "us-east-1a"},
+	})
+
+	w.WriteMessages(context.Background(),
+		kafka.Message{
+			Value: []byte("Hello World!"),
+		},
+	)
+
+	w.Close()
+}
@@ -0,0 +1,103 @@
+package kafka
+
+import (
+	"context"
+	"time"
+)
+
+// ExpireDelegationTokenRequest represents a request sent to a kafka cluster to
+// change the expiry time of a delegation token, or to invalidate it.
+type ExpireDelegationTokenRequest struct {
+	// HMAC identifies the token to expire.
+	HMAC []byte
+
+	// ExpiryTimePeriod is the duration after which the token expires,
+	// counted from the time of the request. The token expires immediately
+	// when it is zero or negative.
+	ExpiryTimePeriod time.Duration
+}
+
+// ExpireDelegationTokenResponse represents the response to an
+// ExpireDelegationTokenRequest.
+type ExpireDelegationTokenResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// ExpiryTime is the new expiry time of the token.
+	ExpiryTime time.Time
+}
+
+// ExpireDelegationToken sends an ExpireDelegationToken request to the kafka
+// cluster. The API was introduced in kafka 1.1 (KIP-48).
+func (c *Client) ExpireDelegationToken(ctx context.Context, req ExpireDelegationTokenRequest) (*ExpireDelegationTokenResponse, error) {
+	request := expireDelegationTokenRequestV1{
+		HMAC:               req.HMAC,
+		ExpiryTimePeriodMS: -1,
+	}
+	if req.ExpiryTimePeriod > 0 {
+		request.ExpiryTimePeriodMS = int64(req.ExpiryTimePeriod / time.Millisecond)
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.expireDelegationToken(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExpireDelegationTokenResponse{
+		Throttle:   duration(response.ThrottleTimeMS),
+		ExpiryTime: timestampToTime(response.ExpiryTimestampMS),
+	}, nil
+}
+
+// expireDelegationToken changes the expiry time of the delegation token of the
+// request. The response has the same layout than the response to
+// RenewDelegationToken v1.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_ExpireDelegationToken
+func (c *Conn) expireDelegationToken(request expireDelegationTokenRequestV1) (delegationTokenExpiryResponseV1, error) {
+	var response delegationTokenExpiryResponseV1
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(expireDelegationToken, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return delegationTokenExpiryResponseV1{}, err
+	}
+	if response.ErrorCode != 0 {
+		return delegationTokenExpiryResponseV1{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_ExpireDelegationToken
+type expireDelegationTokenRequestV1 struct {
+	HMAC []byte
+
+	// ExpiryTimePeriodMS is negative to expire the token immediately.
+	ExpiryTimePeriodMS int64
+}
+
+func (t expireDelegationTokenRequestV1) size() int32 {
+	return sizeofBytes(t.HMAC) +
+		sizeofInt64(t.ExpiryTimePeriodMS)
+}
+
+func (t expireDelegationTokenRequestV1) writeTo(wb *writeBuffer) {
+	wb.writeBytes(t.HMAC)
+	wb.writeInt64(t.ExpiryTimePeriodMS)
+}
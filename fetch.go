@@ -1,5 +1,604 @@
 package kafka
 
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FetchRequest represents a request sent to a kafka cluster to fetch records
+// from a partition.
+type FetchRequest struct {
+	// Topic and Partition designate the partition to fetch the records from.
+	Topic     string
+	Partition int
+
+	// Offset is the offset of the first record to fetch.
+	Offset int64
+
+	// MinBytes and MaxBytes bound the size of the records returned by the
+	// broker, they default to 1 byte and 1MB.
+	MinBytes int
+	MaxBytes int
+
+	// MaxWait is the maximum amount of time the broker waits for MinBytes to
+	// be available, it defaults to 500ms.
+	MaxWait time.Duration
+
+	// IsolationLevel controls the visibility of transactional records.
+	// ReadUncommitted makes all records visible. With ReadCommitted only
+	// non-transactional and committed records are visible, the records of
+	// aborted transactions are dropped.
+	IsolationLevel IsolationLevel
+
+	// RackID is the rack of the client, brokers configured with a
+	// replica.selector.class use it to suggest a preferred read replica. It
+	// defaults to ClientConfig.RackID.
+	RackID string
+}
+
+// FetchResponse represents the response to a FetchRequest.
+type FetchResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// HighWatermark is the offset of the last committed record of the
+	// partition plus one.
+	HighWatermark int64
+
+	// LastStableOffset is the offset after which all transactions of the
+	// partition are still open, read_committed fetches do not return records
+	// past this offset. It is -1 if the broker does not report it.
+	LastStableOffset int64
+
+	// LogStartOffset is the offset of the first record of the partition, a
+	// requested offset lower than it was removed by retention or by a call to
+	// DeleteRecords. Unlike the other offsets, it is also set when Error is
+	// OffsetOutOfRange. It is -1 if the broker does not report it, which
+	// requires kafka 1.0 or above.
+	LogStartOffset int64
+
+	// PreferredReadReplica is the ID of the replica that the broker suggests
+	// fetching the partition from, it is -1 unless the broker has a
+	// replica.selector.class configured and the request has a RackID. It
+	// requires kafka 2.4 or above.
+	PreferredReadReplica int
+
+	// Messages holds the records that were fetched. Control records are never
+	// returned.
+	Messages []Message
+
+	// Error is set to a non-nil value if the records could not be fetched, for
+	// example OffsetOutOfRange if the offset is not in the partition.
+	Error error
+}
+
+const (
+	defaultFetchMaxBytes = 1e6
+	defaultFetchMaxWait  = 500 * time.Millisecond
+)
+
+// Fetch fetches records from a partition. The request is sent to the leader of
+// the partition, unless the request has a RackID and the leader suggested
+// fetching the partition from a replica in that rack (KIP-392). The suggestion
+// is followed for 5 minutes by the next requests for the partition, or until
+// fetching from the replica fails, after which requests go to the leader
+// again.
+//
+// Errors that apply to the partition are reported on the response and do not
+// cause the method to fail. The request is retried as configured by
+// ClientConfig.Retries when it fails with a retriable error.
+func (c *Client) Fetch(ctx context.Context, req FetchRequest) (*FetchResponse, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := c.fetch(ctx, req)
+		if err != nil {
+			if isRetriable(err) && c.retry(ctx, attempt) {
+				continue
+			}
+			return nil, retryError(attempt, err)
+		}
+		if isRetriable(res.Error) && c.retry(ctx, attempt) {
+			continue
+		}
+		return res, nil
+	}
+}
+
+// fetch sends a single fetch request to the leader of the partition, or to the
+// read replica that the leader last suggested when the request has a rack.
+func (c *Client) fetch(ctx context.Context, req FetchRequest) (*FetchResponse, error) {
+	if req.MinBytes == 0 {
+		req.MinBytes = 1
+	}
+	if req.MaxBytes == 0 {
+		req.MaxBytes = defaultFetchMaxBytes
+	}
+	if req.MaxWait == 0 {
+		req.MaxWait = defaultFetchMaxWait
+	}
+	if req.RackID == "" {
+		req.RackID = c.rackID
+	}
+
+	key := topicPartition{topic: req.Topic, partition: req.Partition}
+	followReplicas := req.RackID != "" && c.pin == nil
+
+	if followReplicas {
+		if replica, ok := c.readReplicas.lookup(key, time.Now()); ok {
+			res, err := c.fetchFrom(ctx, replica, req)
+			if err == nil && res.Error == nil {
+				return res, nil
+			}
+			// The replica may have left the cluster or fallen behind the
+			// offset, the leader is asked again which replica to use.
+			c.readReplicas.forget(key)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	leaders, err := c.partitionLeaders(ctx, []string{req.Topic})
+	if err != nil {
+		return nil, err
+	}
+
+	leader, ok := leaders[key]
+	if !ok {
+		return &FetchResponse{
+			HighWatermark:        -1,
+			LastStableOffset:     -1,
+			LogStartOffset:       -1,
+			PreferredReadReplica: -1,
+			Error:                LeaderNotAvailable,
+		}, nil
+	}
+
+	res, err := c.fetchFrom(ctx, leader, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if followReplicas && res.PreferredReadReplica >= 0 && res.PreferredReadReplica != leader.ID {
+		// Failing to resolve the replica only means that the next request is
+		// sent to the leader again.
+		if brokers, err := c.brokerList(ctx); err == nil {
+			for _, b := range brokers {
+				if b.ID == res.PreferredReadReplica {
+					c.readReplicas.prefer(key, b, time.Now().Add(readReplicaTTL))
+					break
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// fetchFrom sends a fetch request to the broker b, which must be a replica of
+// the partition.
+func (c *Client) fetchFrom(ctx context.Context, b Broker, req FetchRequest) (*FetchResponse, error) {
+	conn, err := c.dialer.DialPartition(ctx, "tcp", "", Partition{
+		Topic:  req.Topic,
+		ID:     req.Partition,
+		Leader: b,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	c.observe(conn, net.JoinHostPort(b.Host, strconv.Itoa(b.Port)))
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Seek(req.Offset, SeekAbsolute|SeekDontCheck); err != nil {
+		return nil, err
+	}
+
+	batch := conn.ReadBatchWith(ReadBatchConfig{
+		MinBytes:       req.MinBytes,
+		MaxBytes:       req.MaxBytes,
+		MaxWait:        req.MaxWait,
+		IsolationLevel: req.IsolationLevel,
+		RackID:         req.RackID,
+	})
+
+	res := &FetchResponse{
+		Throttle:             batch.Throttle(),
+		HighWatermark:        batch.HighWaterMark(),
+		LastStableOffset:     batch.lastStable,
+		LogStartOffset:       batch.logStart,
+		PreferredReadReplica: batch.readReplica,
+	}
+
+	for {
+		msg, err := batch.ReadMessage()
+		if err != nil {
+			break
+		}
+		res.Messages = append(res.Messages, msg)
+	}
+
+	switch err := batch.Close(); err.(type) {
+	case nil:
+	case Error:
+		res.HighWatermark, res.LastStableOffset, res.PreferredReadReplica, res.Error = -1, -1, -1, err
+	default:
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// readReplicaTTL is how long the read replica suggested by the leader of a
+// partition is used before the leader is asked again, it matches the default
+// of metadata.max.age.ms in the java client.
+const readReplicaTTL = 5 * time.Minute
+
+// readReplicaCache holds the read replicas suggested by the leaders of
+// partitions in response to fetch requests.
+type readReplicaCache struct {
+	mutex    sync.Mutex
+	replicas map[topicPartition]readReplica
+}
+
+type readReplica struct {
+	broker  Broker
+	expires time.Time
+}
+
+// lookup returns the read replica of the partition, if there is one which has
+// not expired at now.
+func (r *readReplicaCache) lookup(key topicPartition, now time.Time) (Broker, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	replica, ok := r.replicas[key]
+	if !ok {
+		return Broker{}, false
+	}
+	if !now.Before(replica.expires) {
+		delete(r.replicas, key)
+		return Broker{}, false
+	}
+	return replica.broker, true
+}
+
+func (r *readReplicaCache) prefer(key topicPartition, b Broker, expires time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.replicas == nil {
+		r.replicas = make(map[topicPartition]readReplica)
+	}
+	r.replicas[key] = readReplica{broker: b, expires: expires}
+}
+
+func (r *readReplicaCache) forget(key topicPartition) {
+	r.mutex.Lock()
+	delete(r.replicas, key)
+	r.mutex.Unlock()
+}
+
+// MultiFetchRequest represents a request sent to a kafka cluster to fetch
+// records from multiple partitions.
+type MultiFetchRequest struct {
+	// Topics holds the partitions to fetch records from, indexed by topic
+	// name.
+	Topics map[string][]MultiFetchRequestPartition
+
+	// MinBytes and MaxBytes bound the size of the records returned by each
+	// broker, they default to 1 byte and 50MB. PartitionMaxBytes bounds the
+	// size of the records returned for each partition, it defaults to 1MB.
+	MinBytes          int
+	MaxBytes          int
+	PartitionMaxBytes int
+
+	// MaxWait is the maximum amount of time the brokers wait for MinBytes to
+	// be available, it defaults to 500ms.
+	MaxWait time.Duration
+
+	// IsolationLevel controls the visibility of transactional records, see
+	// FetchRequest.
+	IsolationLevel IsolationLevel
+}
+
+// MultiFetchRequestPartition designates a partition and the offset of the
+// first record to fetch from it.
+type MultiFetchRequestPartition struct {
+	Partition int
+	Offset    int64
+}
+
+// MultiFetchResponse represents the response to a MultiFetchRequest.
+type MultiFetchResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// brokers.
+	Throttle time.Duration
+
+	// Topics holds the records fetched from each partition, indexed by topic
+	// name and sorted by partition.
+	Topics map[string][]MultiFetchResponsePartition
+}
+
+// MultiFetchResponsePartition carries the records fetched from a partition.
+type MultiFetchResponsePartition struct {
+	Partition int
+
+	// HighWatermark, LastStableOffset, and LogStartOffset describe the state
+	// of the partition, they are -1 if the partition could not be fetched.
+	HighWatermark    int64
+	LastStableOffset int64
+	LogStartOffset   int64
+
+	// Messages holds the records that were fetched. Control records are never
+	// returned.
+	Messages []Message
+
+	// Error is set to a non-nil value if the records could not be fetched.
+	Error error
+}
+
+const defaultMultiFetchMaxBytes = 50e6
+
+// MultiFetch fetches records from multiple partitions. Partitions are grouped
+// by leader, a single request is sent to each leader and the requests to
+// different leaders are sent concurrently. The method requires kafka 1.0 or
+// above.
+//
+// With kafka 1.1 or above, the requests are sent within fetch sessions
+// (KIP-227) kept by the client for each broker: after the first request to a
+// broker, the requests and responses only carry the partitions which changed,
+// which makes polling many idle partitions cheaper for the brokers. This is
+// transparent to the program, the response holds all the requested partitions
+// either way. Concurrent calls to MultiFetch share the sessions, the requests
+// to a broker whose session is in use are sent outside of it.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) MultiFetch(ctx context.Context, req MultiFetchRequest) (*MultiFetchResponse, error) {
+	topics := make([]string, 0, len(req.Topics))
+	for topic := range req.Topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	leaders, err := c.partitionLeaders(ctx, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	minBytes, maxBytes, partitionMaxBytes, maxWait := req.MinBytes, req.MaxBytes, req.PartitionMaxBytes, req.MaxWait
+	if minBytes == 0 {
+		minBytes = 1
+	}
+	if maxBytes == 0 {
+		maxBytes = defaultMultiFetchMaxBytes
+	}
+	if partitionMaxBytes == 0 {
+		partitionMaxBytes = defaultFetchMaxBytes
+	}
+	if maxWait == 0 {
+		maxWait = defaultFetchMaxWait
+	}
+
+	res := &MultiFetchResponse{
+		Topics: make(map[string][]MultiFetchResponsePartition, len(req.Topics)),
+	}
+
+	requests := make(map[Broker]*fetchRequestV5)
+
+	for _, topic := range topics {
+		for _, p := range req.Topics[topic] {
+			leader, ok := leaders[topicPartition{topic: topic, partition: p.Partition}]
+			if !ok {
+				res.Topics[topic] = append(res.Topics[topic], MultiFetchResponsePartition{
+					Partition:        p.Partition,
+					HighWatermark:    -1,
+					LastStableOffset: -1,
+					LogStartOffset:   -1,
+					Error:            LeaderNotAvailable,
+				})
+				continue
+			}
+
+			request := requests[leader]
+			if request == nil {
+				request = &fetchRequestV5{
+					ReplicaID:      -1,
+					MaxWaitTime:    milliseconds(maxWait),
+					MinBytes:       int32(minBytes),
+					MaxBytes:       int32(maxBytes),
+					IsolationLevel: int8(req.IsolationLevel),
+				}
+				requests[leader] = request
+			}
+			request.add(topic, int32(p.Partition), p.Offset, int32(partitionMaxBytes))
+		}
+	}
+
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for b, request := range requests {
+		wg.Add(1)
+		go func(b Broker, request fetchRequestV5) {
+			defer wg.Done()
+
+			response, err := c.leaderFetch(ctx, b, request)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				for _, t := range request.Topics {
+					for _, p := range t.Partitions {
+						res.Topics[t.TopicName] = append(res.Topics[t.TopicName], MultiFetchResponsePartition{
+							Partition:        int(p.Partition),
+							HighWatermark:    -1,
+							LastStableOffset: -1,
+							LogStartOffset:   -1,
+							Error:            err,
+						})
+					}
+				}
+				return
+			}
+
+			if throttle := duration(response.ThrottleTimeMS); throttle > res.Throttle {
+				res.Throttle = throttle
+			}
+
+			offsets := make(map[topicPartition]int64)
+			for _, t := range request.Topics {
+				for _, p := range t.Partitions {
+					offsets[topicPartition{topic: t.TopicName, partition: int(p.Partition)}] = p.FetchOffset
+				}
+			}
+
+			for _, t := range response.Topics {
+				for _, p := range t.Partitions {
+					partition := MultiFetchResponsePartition{
+						Partition:        int(p.Partition),
+						HighWatermark:    p.HighWatermark,
+						LastStableOffset: p.LastStableOffset,
+						LogStartOffset:   p.LogStartOffset,
+					}
+					if p.ErrorCode != 0 {
+						partition.Error = Error(p.ErrorCode)
+					} else {
+						offset := offsets[topicPartition{topic: t.TopicName, partition: int(p.Partition)}]
+						partition.Messages, partition.Error = readRecordSet(t.TopicName, int(p.Partition), offset, p.RecordSet, p.AbortedTransactions)
+					}
+					res.Topics[t.TopicName] = append(res.Topics[t.TopicName], partition)
+				}
+			}
+		}(b, *request)
+	}
+
+	wg.Wait()
+
+	for _, partitions := range res.Topics {
+		sort.Slice(partitions, func(i, j int) bool {
+			return partitions[i].Partition < partitions[j].Partition
+		})
+	}
+
+	return res, nil
+}
+
+// leaderFetch fetches records from partitions led by the broker b. The request
+// is sent within the fetch session of the broker when it supports them.
+func (c *Client) leaderFetch(ctx context.Context, b Broker, request fetchRequestV5) (fetchResponseV5, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return fetchResponseV5{}, err
+	}
+	defer conn.Close()
+
+	version, err := conn.negotiateVersion(fetch, v5, v7)
+	if err != nil {
+		return fetchResponseV5{}, err
+	}
+	if version < v7 {
+		return conn.fetchV5(request)
+	}
+
+	session := c.fetchSessions.acquire(b.ID)
+	if session == nil {
+		return conn.fetchV5(request)
+	}
+	defer c.fetchSessions.release(session)
+	return session.fetch(conn, request)
+}
+
+// fetchV5 fetches records from the requested partitions, the broker must be
+// the leader of the partitions. Unlike ReadBatch, the record sets are fully
+// buffered in the response and errors on partitions do not cause the method
+// to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_Fetch
+func (c *Conn) fetchV5(request fetchRequestV5) (fetchResponseV5, error) {
+	var response fetchResponseV5
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(fetch, v5, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return fetchResponseV5{}, err
+	}
+
+	return response, nil
+}
+
+// readRecordSet decodes the messages of a record set fetched from a partition.
+// Messages before offset, which the broker returns when offset is in the middle
+// of a batch, are dropped, and so is the batch truncated at the end of the
+// record set when the size limit of the partition was reached.
+func readRecordSet(topic string, partition int, offset int64, data []byte, aborted []abortedTransaction) ([]Message, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	msgs, err := newMessageSetReader(bufio.NewReader(bytes.NewReader(data)), len(data))
+	if err != nil {
+		if err == errShortRead {
+			err = nil
+		}
+		return nil, err
+	}
+	if msgs.version == 2 {
+		msgs.v2.setAbortedTransactions(aborted)
+	}
+
+	var messages []Message
+	for {
+		msg := Message{Topic: topic, Partition: partition}
+
+		off, timestamp, headers, err := msgs.readMessage(offset,
+			func(r *bufio.Reader, size int, nbytes int) (remain int, err error) {
+				msg.Key, remain, err = readNewNullableBytes(r, size, nbytes)
+				return
+			},
+			func(r *bufio.Reader, size int, nbytes int) (remain int, err error) {
+				msg.Value, remain, err = readNewNullableBytes(r, size, nbytes)
+				return
+			},
+		)
+		switch err {
+		case nil:
+		case errShortRead:
+			return messages, nil
+		default:
+			return messages, err
+		}
+
+		if off < offset {
+			continue
+		}
+
+		msg.Offset = off
+		msg.Time = timestampToTime(timestamp)
+		msg.Headers = headers
+		messages = append(messages, msg)
+	}
+}
+
 type fetchRequestV2 struct {
 	ReplicaID   int32
 	MaxWaitTime int32
@@ -97,3 +696,214 @@ func (p fetchResponsePartitionV2) writeTo(wb *writeBuffer) {
 	wb.writeInt32(p.MessageSetSize)
 	p.MessageSet.writeTo(wb)
 }
+
+type fetchRequestV5 struct {
+	ReplicaID      int32
+	MaxWaitTime    int32
+	MinBytes       int32
+	MaxBytes       int32
+	IsolationLevel int8
+	Topics         []fetchRequestTopicV5
+}
+
+func (r *fetchRequestV5) add(topic string, partition int32, offset int64, maxBytes int32) {
+	for i := range r.Topics {
+		if t := &r.Topics[i]; t.TopicName == topic {
+			t.Partitions = append(t.Partitions, fetchRequestPartitionV5{
+				Partition:   partition,
+				FetchOffset: offset,
+				MaxBytes:    maxBytes,
+			})
+			return
+		}
+	}
+	r.Topics = append(r.Topics, fetchRequestTopicV5{
+		TopicName: topic,
+		Partitions: []fetchRequestPartitionV5{{
+			Partition:   partition,
+			FetchOffset: offset,
+			MaxBytes:    maxBytes,
+		}},
+	})
+}
+
+func (r fetchRequestV5) size() int32 {
+	return 4 + 4 + 4 + 4 + 1 + sizeofArray(len(r.Topics), func(i int) int32 { return r.Topics[i].size() })
+}
+
+func (r fetchRequestV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(r.ReplicaID)
+	wb.writeInt32(r.MaxWaitTime)
+	wb.writeInt32(r.MinBytes)
+	wb.writeInt32(r.MaxBytes)
+	wb.writeInt8(r.IsolationLevel)
+	wb.writeArray(len(r.Topics), func(i int) { r.Topics[i].writeTo(wb) })
+}
+
+type fetchRequestTopicV5 struct {
+	TopicName  string
+	Partitions []fetchRequestPartitionV5
+}
+
+func (t fetchRequestTopicV5) size() int32 {
+	return sizeofString(t.TopicName) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t fetchRequestTopicV5) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TopicName)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+// fetchRequestPartitionV5 has the same layout than fetchRequestPartitionV2
+// with the log start offset, which is only used by followers.
+type fetchRequestPartitionV5 struct {
+	Partition      int32
+	FetchOffset    int64
+	LogStartOffset int64
+	MaxBytes       int32
+}
+
+func (p fetchRequestPartitionV5) size() int32 {
+	return 4 + 8 + 8 + 4
+}
+
+func (p fetchRequestPartitionV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(p.Partition)
+	wb.writeInt64(p.FetchOffset)
+	wb.writeInt64(p.LogStartOffset)
+	wb.writeInt32(p.MaxBytes)
+}
+
+type fetchResponseV5 struct {
+	ThrottleTimeMS int32
+	Topics         []fetchResponseTopicV5
+}
+
+func (r fetchResponseV5) size() int32 {
+	return 4 + sizeofArray(len(r.Topics), func(i int) int32 { return r.Topics[i].size() })
+}
+
+func (r fetchResponseV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(r.ThrottleTimeMS)
+	wb.writeArray(len(r.Topics), func(i int) { r.Topics[i].writeTo(wb) })
+}
+
+func (r *fetchResponseV5) readFrom(rd *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(rd, size, &r.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(rd *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic fetchResponseTopicV5
+		if fnRemain, fnErr = (&topic).readFrom(rd, size); fnErr != nil {
+			return
+		}
+		r.Topics = append(r.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(rd, remain, fn); err != nil {
+		return
+	}
+
+	return
+}
+
+type fetchResponseTopicV5 struct {
+	TopicName  string
+	Partitions []fetchResponsePartitionV5
+}
+
+func (t fetchResponseTopicV5) size() int32 {
+	return sizeofString(t.TopicName) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t fetchResponseTopicV5) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TopicName)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+func (t *fetchResponseTopicV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.TopicName); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition fetchResponsePartitionV5
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+
+	return
+}
+
+type fetchResponsePartitionV5 struct {
+	Partition           int32
+	ErrorCode           int16
+	HighWatermark       int64
+	LastStableOffset    int64
+	LogStartOffset      int64
+	AbortedTransactions []abortedTransaction
+	RecordSet           []byte
+}
+
+func (p fetchResponsePartitionV5) size() int32 {
+	return 4 + 2 + 8 + 8 + 8 +
+		sizeofArray(len(p.AbortedTransactions), func(int) int32 { return 8 + 8 }) +
+		sizeofBytes(p.RecordSet)
+}
+
+func (p fetchResponsePartitionV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(p.Partition)
+	wb.writeInt16(p.ErrorCode)
+	wb.writeInt64(p.HighWatermark)
+	wb.writeInt64(p.LastStableOffset)
+	wb.writeInt64(p.LogStartOffset)
+	wb.writeArray(len(p.AbortedTransactions), func(i int) {
+		wb.writeInt64(p.AbortedTransactions[i].ProducerID)
+		wb.writeInt64(p.AbortedTransactions[i].FirstOffset)
+	})
+	wb.writeBytes(p.RecordSet)
+}
+
+func (p *fetchResponsePartitionV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &p.Partition); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &p.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &p.HighWatermark); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &p.LastStableOffset); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &p.LogStartOffset); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var txn abortedTransaction
+		if fnRemain, fnErr = read(r, size, &txn); fnErr != nil {
+			return
+		}
+		p.AbortedTransactions = append(p.AbortedTransactions, txn)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readBytes(r, remain, &p.RecordSet); err != nil {
+		return
+	}
+
+	return
+}
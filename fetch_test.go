@@ -0,0 +1,320 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestFetchResponseV5(t *testing.T) {
+	item := fetchResponseV5{
+		ThrottleTimeMS: 1,
+		Topics: []fetchResponseTopicV5{
+			{
+				TopicName: "a",
+				Partitions: []fetchResponsePartitionV5{
+					{
+						Partition:           2,
+						HighWatermark:       3,
+						LastStableOffset:    4,
+						LogStartOffset:      5,
+						AbortedTransactions: []abortedTransaction{{ProducerID: 6, FirstOffset: 7}},
+						RecordSet:           []byte("b"),
+					},
+					{
+						Partition:        8,
+						ErrorCode:        int16(OffsetOutOfRange),
+						HighWatermark:    -1,
+						LastStableOffset: -1,
+						LogStartOffset:   -1,
+					},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found fetchResponseV5
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestFetchRequestV5Add(t *testing.T) {
+	var request fetchRequestV5
+	request.add("a", 0, 1, 10)
+	request.add("b", 2, 3, 10)
+	request.add("a", 4, 5, 10)
+
+	expected := []fetchRequestTopicV5{
+		{TopicName: "a", Partitions: []fetchRequestPartitionV5{{Partition: 0, FetchOffset: 1, MaxBytes: 10}, {Partition: 4, FetchOffset: 5, MaxBytes: 10}}},
+		{TopicName: "b", Partitions: []fetchRequestPartitionV5{{Partition: 2, FetchOffset: 3, MaxBytes: 10}}},
+	}
+	if !reflect.DeepEqual(request.Topics, expected) {
+		t.Errorf("expected %+v, got %+v", expected, request.Topics)
+	}
+
+	b := bytes.NewBuffer(nil)
+	request.writeTo(&writeBuffer{w: b})
+	if int32(b.Len()) != request.size() {
+		t.Errorf("expected %d bytes, got %d", request.size(), b.Len())
+	}
+}
+
+func TestReadReplicaCache(t *testing.T) {
+	var cache readReplicaCache
+	now := time.Now()
+	key := topicPartition{topic: "a", partition: 1}
+	replica := Broker{Host: "localhost", Port: 9093, ID: 2}
+
+	if _, ok := cache.lookup(key, now); ok {
+		t.Error("found a read replica in an empty cache")
+	}
+
+	cache.prefer(key, replica, now.Add(time.Minute))
+
+	if b, ok := cache.lookup(key, now); !ok || b != replica {
+		t.Errorf("expected read replica %+v, got %+v (found=%t)", replica, b, ok)
+	}
+	if _, ok := cache.lookup(topicPartition{topic: "a", partition: 2}, now); ok {
+		t.Error("found a read replica for another partition")
+	}
+	if _, ok := cache.lookup(key, now.Add(time.Minute)); ok {
+		t.Error("found a read replica after it expired")
+	}
+	if _, ok := cache.lookup(key, now); ok {
+		t.Error("an expired read replica was not removed from the cache")
+	}
+
+	cache.prefer(key, replica, now.Add(time.Minute))
+	cache.forget(key)
+
+	if _, ok := cache.lookup(key, now); ok {
+		t.Error("found a read replica after forgetting it")
+	}
+}
+
+func TestReadRecordSet(t *testing.T) {
+	now := time.Now()
+	data := append(
+		makeRecordBatchAt(t, 0, -1, 0, Message{Value: []byte("a"), Time: now}, Message{Value: []byte("b"), Time: now}),
+		makeRecordBatchAt(t, 2, -1, 0, Message{Key: []byte("k"), Value: []byte("c"), Time: now})...,
+	)
+
+	// the second batch is truncated, like brokers do when reaching the size
+	// limit of the partition
+	messages, err := readRecordSet("topic", 1, 1, data[:len(data)-2], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if m := messages[0]; m.Topic != "topic" || m.Partition != 1 || m.Offset != 1 || string(m.Value) != "b" {
+		t.Errorf("unexpected message: %+v", m)
+	}
+
+	messages, err = readRecordSet("topic", 1, 0, data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+	if m := messages[2]; m.Offset != 2 || string(m.Key) != "k" || string(m.Value) != "c" {
+		t.Errorf("unexpected message: %+v", m)
+	}
+}
+
+func testClientFetch(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("0.11.0") {
+		t.Skip("transactions require kafka 0.11.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	transactionalID := makeTopic()
+	createTopic(t, topic, 1)
+
+	produce := func(req ProduceRequest) {
+		req.Topic, req.Messages = topic, makeTestSequence(2)
+		res, err := c.Produce(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+	}
+
+	produce(ProduceRequest{})
+
+	producer, err := c.InitProducerID(ctx, InitProducerIDRequest{
+		TransactionalID:    transactionalID,
+		TransactionTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AddPartitionsToTxn(ctx, AddPartitionsToTxnRequest{
+		TransactionalID: transactionalID,
+		ProducerID:      producer.ProducerID,
+		ProducerEpoch:   producer.ProducerEpoch,
+		Topics:          map[string][]int{topic: {0}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	produce(ProduceRequest{
+		TransactionalID: transactionalID,
+		ProducerID:      producer.ProducerID,
+		ProducerEpoch:   producer.ProducerEpoch,
+	})
+	if _, err := c.EndTxn(ctx, EndTxnRequest{
+		TransactionalID: transactionalID,
+		ProducerID:      producer.ProducerID,
+		ProducerEpoch:   producer.ProducerEpoch,
+		Committed:       false,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	produce(ProduceRequest{})
+
+	// The records are at offsets 0-1 and 5-6, the aborted transaction holds
+	// the offsets 2-3 and its abort marker is at offset 4.
+	for _, test := range []struct {
+		isolationLevel IsolationLevel
+		offsets        []int64
+	}{
+		{isolationLevel: ReadUncommitted, offsets: []int64{0, 1, 2, 3, 5, 6}},
+		{isolationLevel: ReadCommitted, offsets: []int64{0, 1, 5, 6}},
+	} {
+		res, err := c.Fetch(ctx, FetchRequest{
+			Topic:          topic,
+			Partition:      0,
+			Offset:         0,
+			MaxWait:        100 * time.Millisecond,
+			IsolationLevel: test.isolationLevel,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		if res.HighWatermark != 7 {
+			t.Errorf("expected high watermark 7, got %d", res.HighWatermark)
+		}
+		if ktesting.KafkaIsAtLeast("1.0.0") && res.LogStartOffset != 0 {
+			t.Errorf("expected log start offset 0, got %d", res.LogStartOffset)
+		}
+		if res.PreferredReadReplica != -1 {
+			t.Errorf("expected no preferred read replica without a rack, got %d", res.PreferredReadReplica)
+		}
+
+		offsets := make([]int64, len(res.Messages))
+		for i, m := range res.Messages {
+			offsets[i] = m.Offset
+		}
+		if len(offsets) != len(test.offsets) {
+			t.Errorf("isolation level %d: expected messages at offsets %v, got %v", test.isolationLevel, test.offsets, offsets)
+			continue
+		}
+		for i := range offsets {
+			if offsets[i] != test.offsets[i] {
+				t.Errorf("isolation level %d: expected messages at offsets %v, got %v", test.isolationLevel, test.offsets, offsets)
+				break
+			}
+		}
+	}
+}
+
+func testClientMultiFetch(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("1.0.0") {
+		t.Skip("fetching multiple partitions requires kafka 1.0.0 or newer")
+		return
+	}
+
+	topics := []string{makeTopic(), makeTopic()}
+	for _, topic := range topics {
+		createTopic(t, topic, 2)
+		for partition := 0; partition < 2; partition++ {
+			res, err := c.Produce(ctx, ProduceRequest{
+				Topic:     topic,
+				Partition: partition,
+				Messages:  makeTestSequence(3),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+		}
+	}
+
+	res, err := c.MultiFetch(ctx, MultiFetchRequest{
+		Topics: map[string][]MultiFetchRequestPartition{
+			topics[0]: {{Partition: 0, Offset: 0}, {Partition: 1, Offset: 1}},
+			topics[1]: {{Partition: 0, Offset: 2}, {Partition: 1, Offset: 10}},
+		},
+		MaxWait: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]int{
+		topics[0]: {3, 2},
+		topics[1]: {1, 0},
+	}
+
+	for topic, counts := range expected {
+		partitions := res.Topics[topic]
+		if len(partitions) != 2 {
+			t.Fatalf("expected 2 partitions for topic %s, got %d", topic, len(partitions))
+		}
+		for i, p := range partitions {
+			if p.Partition != i {
+				t.Errorf("expected partition %d, got %d", i, p.Partition)
+			}
+			if topic == topics[1] && p.Partition == 1 {
+				if p.Error != OffsetOutOfRange {
+					t.Errorf("expected OffsetOutOfRange on partition %d of %s, got %v", p.Partition, topic, p.Error)
+				}
+				continue
+			}
+			if p.Error != nil {
+				t.Errorf("fetching partition %d of %s failed: %v", p.Partition, topic, p.Error)
+			}
+			if p.HighWatermark != 3 {
+				t.Errorf("expected high watermark 3 on partition %d of %s, got %d", p.Partition, topic, p.HighWatermark)
+			}
+			if len(p.Messages) != counts[i] {
+				t.Errorf("expected %d messages on partition %d of %s, got %d", counts[i], p.Partition, topic, len(p.Messages))
+			}
+		}
+	}
+}
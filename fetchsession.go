@@ -0,0 +1,361 @@
+package kafka
+
+import (
+	"bufio"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fetchSessions holds the fetch sessions (KIP-227) that MultiFetch opened on
+// the brokers of the cluster, indexed by broker ID.
+//
+// A fetch session lets the broker remember the partitions that the client is
+// fetching from. Once it was created by a full fetch request, the requests of
+// the session only carry the partitions that were added or whose offset
+// changed, and the partitions that were removed. The responses only carry the
+// partitions that have records or whose state changed, which saves both the
+// client and the broker from encoding and decoding the idle partitions.
+type fetchSessions struct {
+	mutex    sync.Mutex
+	sessions map[int]*fetchSession
+}
+
+// acquire returns the fetch session of the broker, or nil if another request
+// is already using it. Requests of a session are sequenced by its epoch, so
+// concurrent requests to the same broker are sent outside of the session.
+func (s *fetchSessions) acquire(brokerID int) *fetchSession {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.sessions == nil {
+		s.sessions = make(map[int]*fetchSession)
+	}
+
+	session := s.sessions[brokerID]
+	if session == nil {
+		session = &fetchSession{}
+		s.sessions[brokerID] = session
+	}
+	if session.busy {
+		return nil
+	}
+	session.busy = true
+	return session
+}
+
+func (s *fetchSessions) release(session *fetchSession) {
+	s.mutex.Lock()
+	session.busy = false
+	s.mutex.Unlock()
+}
+
+// fetchSession is the client side state of a fetch session. The session is
+// not created yet when id is zero, the next request is then a full fetch which
+// asks the broker to create one.
+type fetchSession struct {
+	busy       bool
+	id         int32
+	epoch      int32
+	partitions map[topicPartition]fetchSessionPartition
+}
+
+// fetchSessionPartition is the state of a partition of a fetch session, it
+// holds the values last sent to the broker and the state of the partition last
+// received from it, which the broker omits from the responses until it
+// changes.
+type fetchSessionPartition struct {
+	offset           int64
+	maxBytes         int32
+	highWatermark    int64
+	lastStableOffset int64
+	logStartOffset   int64
+}
+
+// reset drops the session, the next request creates a new one.
+func (s *fetchSession) reset() {
+	s.id, s.epoch, s.partitions = 0, 0, nil
+}
+
+// request builds the fetch request of the session for the partitions of req.
+// It is a full fetch request if the session is not created yet, otherwise it
+// only holds the changes since the previous request of the session.
+func (s *fetchSession) request(req fetchRequestV5) fetchRequestV7 {
+	request := fetchRequestV7{
+		ReplicaID:      req.ReplicaID,
+		MaxWaitTime:    req.MaxWaitTime,
+		MinBytes:       req.MinBytes,
+		MaxBytes:       req.MaxBytes,
+		IsolationLevel: req.IsolationLevel,
+	}
+
+	if s.id == 0 {
+		request.Topics = req.Topics
+		return request
+	}
+
+	request.SessionID, request.SessionEpoch = s.id, s.epoch
+	fetched := make(map[topicPartition]struct{})
+
+	for _, t := range req.Topics {
+		var changed []fetchRequestPartitionV5
+
+		for _, p := range t.Partitions {
+			key := topicPartition{topic: t.TopicName, partition: int(p.Partition)}
+			fetched[key] = struct{}{}
+
+			if state, ok := s.partitions[key]; !ok || state.offset != p.FetchOffset || state.maxBytes != p.MaxBytes {
+				changed = append(changed, p)
+			}
+		}
+
+		if len(changed) != 0 {
+			request.Topics = append(request.Topics, fetchRequestTopicV5{TopicName: t.TopicName, Partitions: changed})
+		}
+	}
+
+	forgotten := make(map[string][]int32)
+	for key := range s.partitions {
+		if _, ok := fetched[key]; !ok {
+			forgotten[key.topic] = append(forgotten[key.topic], int32(key.partition))
+		}
+	}
+	for topic, partitions := range forgotten {
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+		request.ForgottenTopics = append(request.ForgottenTopics, fetchRequestForgottenTopicV7{
+			TopicName:  topic,
+			Partitions: partitions,
+		})
+	}
+	sort.Slice(request.ForgottenTopics, func(i, j int) bool {
+		return request.ForgottenTopics[i].TopicName < request.ForgottenTopics[j].TopicName
+	})
+
+	return request
+}
+
+// update applies the response to a request of the session and returns the
+// response to req, in which the partitions omitted by the broker are filled in
+// with their last known state and no records.
+func (s *fetchSession) update(req fetchRequestV5, request fetchRequestV7, res fetchResponseV7) (fetchResponseV5, error) {
+	if res.ErrorCode != 0 {
+		s.reset()
+		return fetchResponseV5{}, Error(res.ErrorCode)
+	}
+
+	if request.SessionID == 0 {
+		// The broker answers with a zero session ID when it could not create
+		// the session, for example when its fetch session cache is full. The
+		// next request tries to create one again.
+		s.id, s.epoch, s.partitions = res.SessionID, 1, nil
+	} else if s.epoch == math.MaxInt32 {
+		s.epoch = 1
+	} else {
+		s.epoch++
+	}
+
+	partitions := make(map[topicPartition]fetchSessionPartition)
+	for _, t := range req.Topics {
+		for _, p := range t.Partitions {
+			key := topicPartition{topic: t.TopicName, partition: int(p.Partition)}
+			state, ok := s.partitions[key]
+			if !ok {
+				state.highWatermark, state.lastStableOffset, state.logStartOffset = -1, -1, -1
+			}
+			state.offset, state.maxBytes = p.FetchOffset, p.MaxBytes
+			partitions[key] = state
+		}
+	}
+
+	received := make(map[topicPartition]fetchResponsePartitionV5)
+	for _, t := range res.Topics {
+		for _, p := range t.Partitions {
+			key := topicPartition{topic: t.TopicName, partition: int(p.Partition)}
+			received[key] = p
+
+			if state, ok := partitions[key]; ok && p.ErrorCode == 0 {
+				state.highWatermark = p.HighWatermark
+				state.lastStableOffset = p.LastStableOffset
+				state.logStartOffset = p.LogStartOffset
+				partitions[key] = state
+			}
+		}
+	}
+
+	if s.id != 0 {
+		s.partitions = partitions
+	}
+
+	response := fetchResponseV5{
+		ThrottleTimeMS: res.ThrottleTimeMS,
+		Topics:         make([]fetchResponseTopicV5, len(req.Topics)),
+	}
+
+	for i, t := range req.Topics {
+		topic := fetchResponseTopicV5{
+			TopicName:  t.TopicName,
+			Partitions: make([]fetchResponsePartitionV5, len(t.Partitions)),
+		}
+		for j, p := range t.Partitions {
+			key := topicPartition{topic: t.TopicName, partition: int(p.Partition)}
+			partition, ok := received[key]
+			if !ok {
+				state := partitions[key]
+				partition = fetchResponsePartitionV5{
+					Partition:        p.Partition,
+					HighWatermark:    state.highWatermark,
+					LastStableOffset: state.lastStableOffset,
+					LogStartOffset:   state.logStartOffset,
+				}
+			}
+			topic.Partitions[j] = partition
+		}
+		response.Topics[i] = topic
+	}
+
+	return response, nil
+}
+
+// fetch sends the fetch request req on conn within the session. A request
+// rejected because the broker evicted the session or lost track of its epoch
+// is retried as a full fetch request creating a new session.
+func (s *fetchSession) fetch(conn *Conn, req fetchRequestV5) (fetchResponseV5, error) {
+	for {
+		request := s.request(req)
+
+		res, err := conn.fetchV7(request)
+		if err != nil {
+			// The broker may have processed the request and moved to the next
+			// epoch, the session cannot be trusted anymore.
+			s.reset()
+			return fetchResponseV5{}, err
+		}
+
+		response, err := s.update(req, request, res)
+		switch err {
+		case FetchSessionIDNotFound, InvalidFetchSessionEpoch:
+			if request.SessionID != 0 {
+				continue
+			}
+		}
+		return response, err
+	}
+}
+
+// fetchV7 sends a fetch request of a fetch session, the broker must be the
+// leader of the partitions. Like fetchV5, the record sets are fully buffered in
+// the response.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_Fetch
+func (c *Conn) fetchV7(request fetchRequestV7) (fetchResponseV7, error) {
+	var response fetchResponseV7
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(fetch, v7, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return fetchResponseV7{}, err
+	}
+
+	return response, nil
+}
+
+// fetchRequestV7 has the same layout than fetchRequestV5 with the ID and epoch
+// of the fetch session, and the partitions removed from the session.
+type fetchRequestV7 struct {
+	ReplicaID       int32
+	MaxWaitTime     int32
+	MinBytes        int32
+	MaxBytes        int32
+	IsolationLevel  int8
+	SessionID       int32
+	SessionEpoch    int32
+	Topics          []fetchRequestTopicV5
+	ForgottenTopics []fetchRequestForgottenTopicV7
+}
+
+func (r fetchRequestV7) size() int32 {
+	return 4 + 4 + 4 + 4 + 1 + 4 + 4 +
+		sizeofArray(len(r.Topics), func(i int) int32 { return r.Topics[i].size() }) +
+		sizeofArray(len(r.ForgottenTopics), func(i int) int32 { return r.ForgottenTopics[i].size() })
+}
+
+func (r fetchRequestV7) writeTo(wb *writeBuffer) {
+	wb.writeInt32(r.ReplicaID)
+	wb.writeInt32(r.MaxWaitTime)
+	wb.writeInt32(r.MinBytes)
+	wb.writeInt32(r.MaxBytes)
+	wb.writeInt8(r.IsolationLevel)
+	wb.writeInt32(r.SessionID)
+	wb.writeInt32(r.SessionEpoch)
+	wb.writeArray(len(r.Topics), func(i int) { r.Topics[i].writeTo(wb) })
+	wb.writeArray(len(r.ForgottenTopics), func(i int) { r.ForgottenTopics[i].writeTo(wb) })
+}
+
+type fetchRequestForgottenTopicV7 struct {
+	TopicName  string
+	Partitions []int32
+}
+
+func (t fetchRequestForgottenTopicV7) size() int32 {
+	return sizeofString(t.TopicName) + sizeofInt32Array(t.Partitions)
+}
+
+func (t fetchRequestForgottenTopicV7) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TopicName)
+	wb.writeInt32Array(t.Partitions)
+}
+
+// fetchResponseV7 has the same layout than fetchResponseV5 with the error code
+// and ID of the fetch session.
+type fetchResponseV7 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	SessionID      int32
+	Topics         []fetchResponseTopicV5
+}
+
+func (r fetchResponseV7) size() int32 {
+	return 4 + 2 + 4 + sizeofArray(len(r.Topics), func(i int) int32 { return r.Topics[i].size() })
+}
+
+func (r fetchResponseV7) writeTo(wb *writeBuffer) {
+	wb.writeInt32(r.ThrottleTimeMS)
+	wb.writeInt16(r.ErrorCode)
+	wb.writeInt32(r.SessionID)
+	wb.writeArray(len(r.Topics), func(i int) { r.Topics[i].writeTo(wb) })
+}
+
+func (r *fetchResponseV7) readFrom(rd *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(rd, size, &r.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(rd, remain, &r.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readInt32(rd, remain, &r.SessionID); err != nil {
+		return
+	}
+
+	fn := func(rd *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic fetchResponseTopicV5
+		if fnRemain, fnErr = (&topic).readFrom(rd, size); fnErr != nil {
+			return
+		}
+		r.Topics = append(r.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(rd, remain, fn); err != nil {
+		return
+	}
+
+	return
+}
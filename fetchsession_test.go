@@ -0,0 +1,272 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestFetchRequestV7Size(t *testing.T) {
+	item := fetchRequestV7{
+		ReplicaID:    -1,
+		SessionID:    1,
+		SessionEpoch: 2,
+		Topics: []fetchRequestTopicV5{{
+			TopicName:  "a",
+			Partitions: []fetchRequestPartitionV5{{Partition: 0, FetchOffset: 1, MaxBytes: 10}},
+		}},
+		ForgottenTopics: []fetchRequestForgottenTopicV7{{TopicName: "b", Partitions: []int32{1, 2}}},
+	}
+
+	b := bytes.NewBuffer(nil)
+	item.writeTo(&writeBuffer{w: b})
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+}
+
+func TestFetchResponseV7(t *testing.T) {
+	item := fetchResponseV7{
+		ThrottleTimeMS: 1,
+		ErrorCode:      int16(InvalidFetchSessionEpoch),
+		SessionID:      2,
+		Topics: []fetchResponseTopicV5{{
+			TopicName: "a",
+			Partitions: []fetchResponsePartitionV5{{
+				Partition:        3,
+				HighWatermark:    4,
+				LastStableOffset: 5,
+				LogStartOffset:   6,
+				RecordSet:        []byte("b"),
+			}},
+		}},
+	}
+
+	b := bytes.NewBuffer(nil)
+	item.writeTo(&writeBuffer{w: b})
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found fetchResponseV7
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Errorf("expected %+v, got %+v", item, found)
+	}
+}
+
+func TestFetchSession(t *testing.T) {
+	makeRequest := func(offsets map[string][]int64) fetchRequestV5 {
+		req := fetchRequestV5{ReplicaID: -1, MaxBytes: 100}
+		for _, topic := range []string{"a", "b"} {
+			for partition, offset := range offsets[topic] {
+				req.add(topic, int32(partition), offset, 10)
+			}
+		}
+		return req
+	}
+
+	s := &fetchSession{}
+
+	req := makeRequest(map[string][]int64{"a": {1, 2}, "b": {3}})
+	request := s.request(req)
+	if request.SessionID != 0 || request.SessionEpoch != 0 || !reflect.DeepEqual(request.Topics, req.Topics) {
+		t.Fatalf("expected a full fetch request creating a session, got %+v", request)
+	}
+
+	res, err := s.update(req, request, fetchResponseV7{
+		SessionID: 42,
+		Topics: []fetchResponseTopicV5{
+			{TopicName: "a", Partitions: []fetchResponsePartitionV5{
+				{Partition: 0, HighWatermark: 5, LastStableOffset: 5, LogStartOffset: 0},
+				{Partition: 1, HighWatermark: 2, LastStableOffset: 2, LogStartOffset: 0},
+			}},
+			{TopicName: "b", Partitions: []fetchResponsePartitionV5{
+				{Partition: 0, HighWatermark: 3, LastStableOffset: 3, LogStartOffset: 1},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.id != 42 || s.epoch != 1 {
+		t.Fatalf("expected session 42 at epoch 1, got session %d at epoch %d", s.id, s.epoch)
+	}
+	if len(res.Topics) != 2 {
+		t.Fatalf("expected 2 topics in the response, got %d", len(res.Topics))
+	}
+
+	// Partition 0 of a moved forward, partition 0 of b was dropped, and the
+	// offset of partition 1 of a did not change.
+	req = makeRequest(map[string][]int64{"a": {5, 2}})
+	request = s.request(req)
+
+	if request.SessionID != 42 || request.SessionEpoch != 1 {
+		t.Errorf("expected an incremental request for session 42 at epoch 1, got session %d at epoch %d", request.SessionID, request.SessionEpoch)
+	}
+	expectedTopics := []fetchRequestTopicV5{{
+		TopicName:  "a",
+		Partitions: []fetchRequestPartitionV5{{Partition: 0, FetchOffset: 5, MaxBytes: 10}},
+	}}
+	if !reflect.DeepEqual(request.Topics, expectedTopics) {
+		t.Errorf("expected the changed partitions to be %+v, got %+v", expectedTopics, request.Topics)
+	}
+	expectedForgotten := []fetchRequestForgottenTopicV7{{TopicName: "b", Partitions: []int32{0}}}
+	if !reflect.DeepEqual(request.ForgottenTopics, expectedForgotten) {
+		t.Errorf("expected the forgotten partitions to be %+v, got %+v", expectedForgotten, request.ForgottenTopics)
+	}
+
+	res, err = s.update(req, request, fetchResponseV7{
+		SessionID: 42,
+		Topics: []fetchResponseTopicV5{{TopicName: "a", Partitions: []fetchResponsePartitionV5{
+			{Partition: 0, HighWatermark: 6, LastStableOffset: 6, LogStartOffset: 0, RecordSet: []byte("x")},
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedResponse := fetchResponseV5{Topics: []fetchResponseTopicV5{{
+		TopicName: "a",
+		Partitions: []fetchResponsePartitionV5{
+			{Partition: 0, HighWatermark: 6, LastStableOffset: 6, LogStartOffset: 0, RecordSet: []byte("x")},
+			{Partition: 1, HighWatermark: 2, LastStableOffset: 2, LogStartOffset: 0},
+		},
+	}}}
+	if !reflect.DeepEqual(res, expectedResponse) {
+		t.Errorf("expected the omitted partitions to be filled in:\nexpected: %+v\nfound:    %+v", expectedResponse, res)
+	}
+	if s.epoch != 2 || len(s.partitions) != 2 {
+		t.Errorf("expected 2 partitions at epoch 2, got %d partitions at epoch %d", len(s.partitions), s.epoch)
+	}
+
+	s.epoch = math.MaxInt32
+	request = s.request(req)
+	if len(request.Topics) != 0 || len(request.ForgottenTopics) != 0 {
+		t.Errorf("expected an empty incremental request, got %+v", request)
+	}
+	if _, err := s.update(req, request, fetchResponseV7{SessionID: 42}); err != nil {
+		t.Fatal(err)
+	}
+	if s.epoch != 1 {
+		t.Errorf("expected the epoch to wrap around to 1, got %d", s.epoch)
+	}
+
+	if _, err := s.update(req, s.request(req), fetchResponseV7{ErrorCode: int16(FetchSessionIDNotFound)}); err != FetchSessionIDNotFound {
+		t.Errorf("expected FetchSessionIDNotFound, got %v", err)
+	}
+	if s.id != 0 || s.partitions != nil {
+		t.Error("the session was not reset after the broker rejected it")
+	}
+	if request := s.request(req); request.SessionID != 0 || !reflect.DeepEqual(request.Topics, req.Topics) {
+		t.Errorf("expected a full fetch request after the session was reset, got %+v", request)
+	}
+}
+
+func TestFetchSessionsAcquire(t *testing.T) {
+	var sessions fetchSessions
+
+	s := sessions.acquire(1)
+	if s == nil {
+		t.Fatal("the session of broker 1 could not be acquired")
+	}
+	if sessions.acquire(1) != nil {
+		t.Error("the session of broker 1 was acquired twice")
+	}
+	if sessions.acquire(2) == nil {
+		t.Error("the session of broker 2 could not be acquired")
+	}
+
+	sessions.release(s)
+	if sessions.acquire(1) != s {
+		t.Error("the session of broker 1 was not reused after being released")
+	}
+}
+
+func testClientMultiFetchSession(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("1.1.0") {
+		t.Skip("fetch sessions require kafka 1.1.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 3)
+
+	res, err := c.Produce(ctx, ProduceRequest{
+		Topic:     topic,
+		Partition: 1,
+		Messages:  makeTestSequence(3),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	c = NewClient("localhost:9092")
+	req := MultiFetchRequest{
+		Topics: map[string][]MultiFetchRequestPartition{
+			topic: {{Partition: 0, Offset: 0}, {Partition: 1, Offset: 0}, {Partition: 2, Offset: 0}},
+		},
+		MaxWait: 100 * time.Millisecond,
+	}
+
+	// The second request is an incremental fetch of the session created by
+	// the first one, the broker omits the partitions which did not change.
+	for i := 0; i < 2; i++ {
+		res, err := c.MultiFetch(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		partitions := res.Topics[topic]
+		if len(partitions) != 3 {
+			t.Fatalf("expected 3 partitions, got %d", len(partitions))
+		}
+		for _, p := range partitions {
+			if p.Error != nil {
+				t.Errorf("fetching partition %d failed: %v", p.Partition, p.Error)
+			}
+			expected := int64(0)
+			if p.Partition == 1 {
+				expected = 3
+			}
+			if p.HighWatermark != expected {
+				t.Errorf("request %d: expected high watermark %d on partition %d, got %d", i, expected, p.Partition, p.HighWatermark)
+			}
+			if len(p.Messages) != int(expected) {
+				t.Errorf("request %d: expected %d messages on partition %d, got %d", i, expected, p.Partition, len(p.Messages))
+			}
+		}
+	}
+
+	c.fetchSessions.mutex.Lock()
+	defer c.fetchSessions.mutex.Unlock()
+
+	if len(c.fetchSessions.sessions) == 0 {
+		t.Fatal("no fetch session was created")
+	}
+	for id, s := range c.fetchSessions.sessions {
+		if s.id == 0 {
+			t.Errorf("the broker %d did not create a fetch session", id)
+		}
+		if s.epoch != 2 {
+			t.Errorf("expected the fetch session of broker %d to be at epoch 2, got %d", id, s.epoch)
+		}
+	}
+}
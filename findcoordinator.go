@@ -2,8 +2,203 @@ package kafka
 
 import (
 	"bufio"
+	"context"
+	"fmt"
+	"time"
 )
 
+// CoordinatorKeyType is the type of key used to look up a coordinator.
+type CoordinatorKeyType int8
+
+const (
+	// CoordinatorKeyTypeGroup looks up the coordinator of a consumer group,
+	// the key is the group ID.
+	CoordinatorKeyTypeGroup CoordinatorKeyType = 0
+
+	// CoordinatorKeyTypeTransaction looks up the coordinator of the
+	// transactions of a producer, the key is the transactional ID.
+	CoordinatorKeyTypeTransaction CoordinatorKeyType = 1
+)
+
+// FindCoordinatorsRequest represents a request sent to a kafka cluster to
+// look up the coordinators of groups or of transactional IDs.
+type FindCoordinatorsRequest struct {
+	// Keys holds the keys to look up the coordinators of.
+	Keys []string
+
+	// KeyType is the type of the keys.
+	KeyType CoordinatorKeyType
+}
+
+// FindCoordinatorsResponse represents the response to a
+// FindCoordinatorsRequest.
+type FindCoordinatorsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// Coordinators holds the coordinator of each key, in the order they
+	// appeared in the request.
+	Coordinators []FindCoordinatorsResponseCoordinator
+}
+
+// FindCoordinatorsResponseCoordinator carries the result of looking up the
+// coordinator of a key.
+type FindCoordinatorsResponseCoordinator struct {
+	Key string
+
+	// Coordinator is the broker coordinating the key, the Rack field is not
+	// set.
+	Coordinator Broker
+
+	// Error is set to a non-nil value if the coordinator could not be found,
+	// for example GroupCoordinatorNotAvailable while the internal topic holding
+	// the state of the coordinators is being created.
+	Error error
+
+	// ErrorMessage optionally describes the error, it is only set by kafka
+	// 3.0 and above.
+	ErrorMessage string
+}
+
+// FindCoordinator looks up the broker coordinating the group or transactional
+// ID key.
+func (c *Client) FindCoordinator(ctx context.Context, key string, keyType CoordinatorKeyType) (Broker, error) {
+	res, err := c.FindCoordinators(ctx, FindCoordinatorsRequest{
+		Keys:    []string{key},
+		KeyType: keyType,
+	})
+	if err != nil {
+		return Broker{}, err
+	}
+	coordinator := res.Coordinators[0]
+	return coordinator.Coordinator, coordinator.Error
+}
+
+// FindCoordinators looks up the brokers coordinating the keys of the request.
+// The keys are resolved in a single request on kafka 3.0 and above (KIP-699),
+// and with one request per key on older versions.
+//
+// Errors that apply to a single key are reported on the coordinator of the
+// key and do not cause the method to fail.
+func (c *Client) FindCoordinators(ctx context.Context, req FindCoordinatorsRequest) (*FindCoordinatorsResponse, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := lookupCoordinators(conn, req.KeyType, req.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	coordinators := make(map[string]FindCoordinatorsResponseCoordinator, len(response.Coordinators))
+	for _, coordinator := range response.Coordinators {
+		res := FindCoordinatorsResponseCoordinator{
+			Key: coordinator.Key,
+			Coordinator: Broker{
+				ID:   int(coordinator.NodeID),
+				Host: coordinator.Host,
+				Port: int(coordinator.Port),
+			},
+			ErrorMessage: coordinator.ErrorMessage,
+		}
+		if coordinator.ErrorCode != 0 {
+			res.Error = Error(coordinator.ErrorCode)
+		}
+		coordinators[coordinator.Key] = res
+	}
+
+	res := &FindCoordinatorsResponse{
+		Throttle:     duration(response.ThrottleTimeMS),
+		Coordinators: make([]FindCoordinatorsResponseCoordinator, len(req.Keys)),
+	}
+
+	for i, key := range req.Keys {
+		coordinator, ok := coordinators[key]
+		if !ok {
+			coordinator = FindCoordinatorsResponseCoordinator{
+				Key:   key,
+				Error: fmt.Errorf("key %s missing from the find coordinator response", key),
+			}
+		}
+		res.Coordinators[i] = coordinator
+	}
+
+	return res, nil
+}
+
+// lookupCoordinators looks up the coordinators of the keys on conn, in a
+// single request if the broker supports FindCoordinator v4, or with one
+// request per key otherwise. Kafka errors are reported on the coordinators of
+// the keys.
+func lookupCoordinators(conn *Conn, keyType CoordinatorKeyType, keys []string) (findCoordinatorResponseV4, error) {
+	version, err := conn.negotiateVersion(findCoordinator, v0, v1, v4)
+	if err != nil {
+		return findCoordinatorResponseV4{}, err
+	}
+
+	switch version {
+	case v4:
+		return conn.findCoordinatorV4(findCoordinatorRequestV4{
+			KeyType:         int8(keyType),
+			CoordinatorKeys: keys,
+		})
+	case v0:
+		if keyType != CoordinatorKeyTypeGroup {
+			return findCoordinatorResponseV4{}, UnsupportedVersion
+		}
+	}
+
+	response := findCoordinatorResponseV4{
+		Coordinators: make([]findCoordinatorResponseCoordinatorV4, len(keys)),
+	}
+
+	for i, key := range keys {
+		var coordinator findCoordinatorResponseCoordinatorV0
+		var throttle int32
+		var err error
+
+		if version == v1 {
+			var res findCoordinatorResponseV1
+			res, err = conn.findCoordinatorV1(findCoordinatorRequestV1{
+				CoordinatorKey:  key,
+				CoordinatorType: int8(keyType),
+			})
+			coordinator, throttle = res.Coordinator, res.ThrottleTimeMS
+		} else {
+			var res findCoordinatorResponseV0
+			res, err = conn.findCoordinator(findCoordinatorRequestV0{
+				CoordinatorKey: key,
+			})
+			coordinator = res.Coordinator
+		}
+
+		response.Coordinators[i] = findCoordinatorResponseCoordinatorV4{
+			Key:    key,
+			NodeID: coordinator.NodeID,
+			Host:   coordinator.Host,
+			Port:   coordinator.Port,
+		}
+
+		if err != nil {
+			e, ok := err.(Error)
+			if !ok {
+				return findCoordinatorResponseV4{}, err
+			}
+			response.Coordinators[i].NodeID = -1
+			response.Coordinators[i].ErrorCode = int16(e)
+		}
+
+		if throttle > response.ThrottleTimeMS {
+			response.ThrottleTimeMS = throttle
+		}
+	}
+
+	return response, nil
+}
+
 // FindCoordinatorRequestV0 requests the coordinator for the specified group or transaction
 //
 // See http://kafka.apache.org/protocol.html#The_Messages_FindCoordinator
@@ -84,3 +279,231 @@ func (t *findCoordinatorResponseV0) readFrom(r *bufio.Reader, size int) (remain
 	}
 	return
 }
+
+// See http://kafka.apache.org/protocol.html#The_Messages_FindCoordinator
+type findCoordinatorRequestV1 struct {
+	// CoordinatorKey holds id to use for finding the coordinator (for groups, this is
+	// the groupId, for transactional producers, this is the transactional id)
+	CoordinatorKey string
+
+	// CoordinatorType tells whether CoordinatorKey is a group or a
+	// transactional id.
+	CoordinatorType int8
+}
+
+func (t findCoordinatorRequestV1) size() int32 {
+	return sizeofString(t.CoordinatorKey) +
+		sizeofInt8(t.CoordinatorType)
+}
+
+func (t findCoordinatorRequestV1) writeTo(wb *writeBuffer) {
+	wb.writeString(t.CoordinatorKey)
+	wb.writeInt8(t.CoordinatorType)
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_FindCoordinator
+type findCoordinatorResponseV1 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	ErrorMessage   *string
+
+	// Coordinator has the same layout than in v0.
+	Coordinator findCoordinatorResponseCoordinatorV0
+}
+
+func (t findCoordinatorResponseV1) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofNullableString(t.ErrorMessage) +
+		t.Coordinator.size()
+}
+
+func (t findCoordinatorResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeNullableString(t.ErrorMessage)
+	t.Coordinator.writeTo(wb)
+}
+
+func (t *findCoordinatorResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readNullableString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = (&t.Coordinator).readFrom(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// findCoordinatorV1 looks up the coordinator of a group or of a transactional
+// id.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_FindCoordinator
+func (c *Conn) findCoordinatorV1(request findCoordinatorRequestV1) (findCoordinatorResponseV1, error) {
+	var response findCoordinatorResponseV1
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(findCoordinator, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return findCoordinatorResponseV1{}, err
+	}
+	if response.ErrorCode != 0 {
+		return findCoordinatorResponseV1{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// findCoordinatorV4 looks up the coordinators of multiple groups or
+// transactional ids. Unlike findCoordinatorV1, errors on keys do not cause the
+// method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_FindCoordinator
+func (c *Conn) findCoordinatorV4(request findCoordinatorRequestV4) (findCoordinatorResponseV4, error) {
+	var response findCoordinatorResponseV4
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(findCoordinator, v4, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return findCoordinatorResponseV4{}, err
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_FindCoordinator
+type findCoordinatorRequestV4 struct {
+	KeyType         int8
+	CoordinatorKeys []string
+}
+
+func (t findCoordinatorRequestV4) size() int32 {
+	return sizeofInt8(t.KeyType) +
+		sizeofCompactStringArray(t.CoordinatorKeys) +
+		sizeofTaggedFields()
+}
+
+func (t findCoordinatorRequestV4) writeTo(wb *writeBuffer) {
+	wb.writeInt8(t.KeyType)
+	wb.writeCompactStringArray(t.CoordinatorKeys)
+	wb.writeTaggedFields()
+}
+
+type findCoordinatorResponseCoordinatorV4 struct {
+	Key          string
+	NodeID       int32
+	Host         string
+	Port         int32
+	ErrorCode    int16
+	ErrorMessage string
+}
+
+func (t findCoordinatorResponseCoordinatorV4) size() int32 {
+	return sizeofCompactString(t.Key) +
+		sizeofInt32(t.NodeID) +
+		sizeofCompactString(t.Host) +
+		sizeofInt32(t.Port) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofTaggedFields()
+}
+
+func (t findCoordinatorResponseCoordinatorV4) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Key)
+	wb.writeInt32(t.NodeID)
+	wb.writeCompactString(t.Host)
+	wb.writeInt32(t.Port)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeTaggedFields()
+}
+
+func (t *findCoordinatorResponseCoordinatorV4) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.Key); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.NodeID); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.Host); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.Port); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_FindCoordinator
+type findCoordinatorResponseV4 struct {
+	ThrottleTimeMS int32
+	Coordinators   []findCoordinatorResponseCoordinatorV4
+}
+
+func (t findCoordinatorResponseV4) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofCompactArray(len(t.Coordinators), func(i int) int32 { return t.Coordinators[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t findCoordinatorResponseV4) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeCompactArray(len(t.Coordinators), func(i int) { t.Coordinators[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *findCoordinatorResponseV4) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var coordinator findCoordinatorResponseCoordinatorV4
+		if fnRemain, fnErr = (&coordinator).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Coordinators = append(t.Coordinators, coordinator)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
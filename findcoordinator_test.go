@@ -3,6 +3,7 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
 )
@@ -36,3 +37,135 @@ func TestFindCoordinatorResponseV0(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestFindCoordinatorResponseV1(t *testing.T) {
+	errorMessage := "a"
+
+	for _, item := range []findCoordinatorResponseV1{
+		{
+			ThrottleTimeMS: 1,
+			Coordinator: findCoordinatorResponseCoordinatorV0{
+				NodeID: 3,
+				Host:   "b",
+				Port:   4,
+			},
+		},
+		{
+			ThrottleTimeMS: 1,
+			ErrorCode:      int16(GroupCoordinatorNotAvailable),
+			ErrorMessage:   &errorMessage,
+			Coordinator: findCoordinatorResponseCoordinatorV0{
+				NodeID: -1,
+			},
+		},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		var found findCoordinatorResponseV1
+		remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if remain != 0 {
+			t.Errorf("expected 0 remain, got %v", remain)
+			t.FailNow()
+		}
+		if !reflect.DeepEqual(item, found) {
+			t.Error("expected item and found to be the same")
+			t.FailNow()
+		}
+	}
+}
+
+func TestFindCoordinatorResponseV4(t *testing.T) {
+	item := findCoordinatorResponseV4{
+		ThrottleTimeMS: 1,
+		Coordinators: []findCoordinatorResponseCoordinatorV4{
+			{Key: "a", NodeID: 2, Host: "b", Port: 3},
+			{Key: "c", NodeID: -1, ErrorCode: int16(GroupCoordinatorNotAvailable), ErrorMessage: "d"},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found findCoordinatorResponseV4
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestFindCoordinatorRequestV4Size(t *testing.T) {
+	for _, item := range []findCoordinatorRequestV4{
+		{KeyType: int8(CoordinatorKeyTypeGroup)},
+		{KeyType: int8(CoordinatorKeyTypeTransaction), CoordinatorKeys: []string{"a", "b"}},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func testClientFindCoordinator(t *testing.T, ctx context.Context, c *Client) {
+	groupIDs := []string{makeGroupID(), makeGroupID(), makeGroupID()}
+
+	res, err := c.FindCoordinators(ctx, FindCoordinatorsRequest{
+		Keys:    groupIDs,
+		KeyType: CoordinatorKeyTypeGroup,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Coordinators) != len(groupIDs) {
+		t.Fatalf("expected %d coordinators, got %d", len(groupIDs), len(res.Coordinators))
+	}
+
+	for i, coordinator := range res.Coordinators {
+		if coordinator.Key != groupIDs[i] {
+			t.Errorf("expected coordinator %d to be for %s, got %s", i, groupIDs[i], coordinator.Key)
+		}
+		if coordinator.Error != nil {
+			t.Errorf("finding the coordinator of %s failed: %v", coordinator.Key, coordinator.Error)
+			continue
+		}
+
+		b, err := c.FindCoordinator(ctx, coordinator.Key, CoordinatorKeyTypeGroup)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if b != coordinator.Coordinator {
+			t.Errorf("expected the coordinator of %s to be %+v, got %+v", coordinator.Key, coordinator.Coordinator, b)
+		}
+	}
+
+	b, err := c.FindCoordinator(ctx, makeTopic(), CoordinatorKeyTypeTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Host == "" || b.Port == 0 {
+		t.Errorf("expected the address of the transaction coordinator, got %+v", b)
+	}
+}
@@ -1,6 +1,8 @@
 package kafka
 
 import (
+	"bufio"
+	"bytes"
 	"sort"
 )
 
@@ -15,6 +17,11 @@ type GroupMember struct {
 	// UserData contains any information that the GroupBalancer sent to the
 	// consumer group coordinator.
 	UserData []byte
+
+	// OwnedPartitions holds topic => partitions that the member consumed in
+	// the previous generation.  It is only sent by the members using a
+	// GroupBalancer with the CooperativeRebalance protocol.
+	OwnedPartitions map[string][]int
 }
 
 // GroupMemberAssignments holds MemberID => topic => partitions
@@ -38,6 +45,46 @@ type GroupBalancer interface {
 	AssignGroups(members []GroupMember, partitions []Partition) GroupMemberAssignments
 }
 
+// RebalanceProtocol is the protocol followed by the members of a consumer group
+// to give up their partitions when the group is rebalanced.
+type RebalanceProtocol int8
+
+const (
+	// EagerRebalance is the protocol of the GroupBalancers which don't
+	// implement a RebalanceProtocol method: the members stop consuming all
+	// their partitions when the group is rebalanced, before they are
+	// assigned again.
+	EagerRebalance RebalanceProtocol = iota
+
+	// CooperativeRebalance is the incremental protocol of KIP-429: the
+	// members keep consuming their partitions while the group is rebalanced,
+	// and only give up the partitions that are assigned to other members.
+	// Those partitions are not assigned to anyone in the generation that
+	// takes them away from their owner, the owner revokes them and rejoins
+	// the group so they are assigned in a second rebalance.
+	CooperativeRebalance
+)
+
+func (p RebalanceProtocol) String() string {
+	switch p {
+	case EagerRebalance:
+		return "eager"
+	case CooperativeRebalance:
+		return "cooperative"
+	default:
+		return "unknown"
+	}
+}
+
+// rebalanceProtocolOf returns the protocol of the balancer, which is declared
+// by an optional RebalanceProtocol method.
+func rebalanceProtocolOf(balancer GroupBalancer) RebalanceProtocol {
+	if b, ok := balancer.(interface{ RebalanceProtocol() RebalanceProtocol }); ok {
+		return b.RebalanceProtocol()
+	}
+	return EagerRebalance
+}
+
 // RangeGroupBalancer groups consumers by partition
 //
 // Example: 5 partitions, 2 consumers
@@ -285,6 +332,298 @@ func (r *RackAffinityGroupBalancer) assignTopic(members []GroupMember, partition
 	return assignments
 }
 
+// StickyGroupBalancer divides the partitions evenly among consumers, moving as
+// few partitions as possible when the group is rebalanced.  The consumers send
+// the partitions that they were assigned in the previous generation in their
+// user data, so the leader can keep them where they are.
+//
+// Example: 6 partitions, C2 joins C0 and C1
+// 		C0: [0, 1, 2] => [0, 1]
+// 		C1: [3, 4, 5] => [3, 4]
+// 		C2: []        => [2, 5]
+//
+// The balancer follows the EagerRebalance protocol, see
+// CooperativeStickyGroupBalancer for a sticky balancer which keeps consuming
+// during rebalances.  It uses the same protocol name and user data format as
+// the sticky assignor of the Java client, so both kinds of consumers can be
+// part of the same group.
+type StickyGroupBalancer struct{}
+
+func (s StickyGroupBalancer) ProtocolName() string {
+	return "sticky"
+}
+
+// UserData returns no user data, consumer groups send the assignment of the
+// previous generation instead.
+func (s StickyGroupBalancer) UserData() ([]byte, error) {
+	return nil, nil
+}
+
+func (s StickyGroupBalancer) AssignGroups(members []GroupMember, topicPartitions []Partition) GroupMemberAssignments {
+	claims := make(map[string]stickyClaim, len(members))
+	for _, member := range members {
+		if len(member.UserData) == 0 {
+			continue
+		}
+		// members sending user data which can't be decoded still get their
+		// share of the partitions, they only lose their claims.
+		userData := stickyAssignorUserData{}
+		reader := bufio.NewReader(bytes.NewReader(member.UserData))
+		if _, err := (&userData).readFrom(reader, len(member.UserData)); err != nil {
+			continue
+		}
+		claim := stickyClaim{
+			partitions: make(map[string][]int, len(userData.Topics)),
+			generation: userData.Generation,
+		}
+		for topic, partitions := range userData.Topics {
+			for _, partition := range partitions {
+				claim.partitions[topic] = append(claim.partitions[topic], int(partition))
+			}
+		}
+		claims[member.ID] = claim
+	}
+	return assignSticky(members, topicPartitions, claims)
+}
+
+// userDataWithAssignments returns the user data of a member which was assigned
+// the partitions in the generation.
+func (s StickyGroupBalancer) userDataWithAssignments(assignments map[string][]int32, generationID int32) ([]byte, error) {
+	if assignments == nil {
+		return nil, nil
+	}
+	return stickyAssignorUserData{
+		Topics:     assignments,
+		Generation: generationID,
+	}.bytes(), nil
+}
+
+// CooperativeStickyGroupBalancer divides the partitions evenly among consumers,
+// keeping the partitions that the consumers already own when the group is
+// rebalanced, and follows the CooperativeRebalance protocol so the consumers
+// keep consuming those partitions during the rebalance.  The partitions are
+// assigned like StickyGroupBalancer does.
+//
+// The balancer uses the same protocol name as the cooperative-sticky assignor
+// of the Java client, so both kinds of consumers can be part of the same group.
+type CooperativeStickyGroupBalancer struct{}
+
+func (s CooperativeStickyGroupBalancer) ProtocolName() string {
+	return "cooperative-sticky"
+}
+
+func (s CooperativeStickyGroupBalancer) UserData() ([]byte, error) {
+	return nil, nil
+}
+
+func (s CooperativeStickyGroupBalancer) RebalanceProtocol() RebalanceProtocol {
+	return CooperativeRebalance
+}
+
+func (s CooperativeStickyGroupBalancer) AssignGroups(members []GroupMember, topicPartitions []Partition) GroupMemberAssignments {
+	claims := make(map[string]stickyClaim, len(members))
+	for _, member := range members {
+		claims[member.ID] = stickyClaim{partitions: member.OwnedPartitions}
+	}
+	return assignSticky(members, topicPartitions, claims)
+}
+
+// assignmentUserData is implemented by the balancers whose user data holds the
+// partitions assigned to the member in the previous generation, consumer
+// groups call it instead of UserData.
+type assignmentUserData interface {
+	userDataWithAssignments(assignments map[string][]int32, generationID int32) ([]byte, error)
+}
+
+// stickyAssignorUserData is the user data of the sticky assignor of the Java
+// client: topic => partitions assigned to the member, and the generation they
+// were assigned in.  Version 0 of the format, written by older clients, doesn't
+// have the generation.
+type stickyAssignorUserData struct {
+	Topics     map[string][]int32
+	Generation int32
+}
+
+func (t stickyAssignorUserData) size() int32 {
+	sz := sizeofInt32(int32(len(t.Topics))) + sizeofInt32(t.Generation)
+	for topic, partitions := range t.Topics {
+		sz += sizeofString(topic) + sizeofInt32Array(partitions)
+	}
+	return sz
+}
+
+func (t stickyAssignorUserData) writeTo(wb *writeBuffer) {
+	topics := make([]string, 0, len(t.Topics))
+	for topic := range t.Topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	wb.writeInt32(int32(len(topics)))
+	for _, topic := range topics {
+		wb.writeString(topic)
+		wb.writeInt32Array(t.Topics[topic])
+	}
+	wb.writeInt32(t.Generation)
+}
+
+func (t stickyAssignorUserData) bytes() []byte {
+	buf := bytes.NewBuffer(nil)
+	t.writeTo(&writeBuffer{w: buf})
+	return buf.Bytes()
+}
+
+func (t *stickyAssignorUserData) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readMapStringInt32(r, size, &t.Topics); err != nil {
+		return
+	}
+	// the Java client uses -1 for the generation of version 0.
+	t.Generation = -1
+	if remain != 0 {
+		if remain, err = readInt32(r, remain, &t.Generation); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// stickyClaim holds topic => partitions that a member was assigned in a
+// generation.
+type stickyClaim struct {
+	partitions map[string][]int
+	generation int32
+}
+
+// assignSticky divides the partitions among the members, moving as few of the
+// partitions that they claim as possible.  A partition claimed by several
+// members is kept by the member which claims it from the latest generation,
+// ties are broken by member ID.
+//
+// The partitions are balanced once no partition could move to a member which
+// is subscribed to its topic and has at least two partitions less than its
+// owner, members subscribed to the same topics have the same number of
+// partitions, give or take one.
+func assignSticky(members []GroupMember, topicPartitions []Partition, claims map[string]stickyClaim) GroupMemberAssignments {
+	members = append([]GroupMember(nil), members...)
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+
+	subscribed := make(map[string]map[string]bool, len(members))
+	topics := make(map[string]bool)
+	for _, member := range members {
+		subscribed[member.ID] = make(map[string]bool, len(member.Topics))
+		for _, topic := range member.Topics {
+			subscribed[member.ID][topic] = true
+			topics[topic] = true
+		}
+	}
+
+	var partitions []topicPartition
+	exists := make(map[topicPartition]bool, len(topicPartitions))
+	for _, p := range topicPartitions {
+		partition := topicPartition{topic: p.Topic, partition: p.ID}
+		if topics[p.Topic] && !exists[partition] {
+			partitions = append(partitions, partition)
+			exists[partition] = true
+		}
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].topic != partitions[j].topic {
+			return partitions[i].topic < partitions[j].topic
+		}
+		return partitions[i].partition < partitions[j].partition
+	})
+
+	// partitions which were deleted, or whose topic the member doesn't
+	// subscribe to anymore, can't be kept.
+	owners := make(map[topicPartition]string, len(partitions))
+	for _, member := range members {
+		claim := claims[member.ID]
+		for topic, ids := range claim.partitions {
+			if !subscribed[member.ID][topic] {
+				continue
+			}
+			for _, id := range ids {
+				partition := topicPartition{topic: topic, partition: id}
+				if !exists[partition] {
+					continue
+				}
+				if owner, ok := owners[partition]; !ok || claim.generation > claims[owner].generation {
+					owners[partition] = member.ID
+				}
+			}
+		}
+	}
+
+	// the partitions of each member are listed in the order they were given
+	// to it, so the ones it owned come first.
+	assigned := make(map[string][]topicPartition, len(members))
+	for _, partition := range partitions {
+		if owner, ok := owners[partition]; ok {
+			assigned[owner] = append(assigned[owner], partition)
+		}
+	}
+
+	// hand out the other partitions to the members with the fewest partitions.
+	for _, partition := range partitions {
+		if _, ok := owners[partition]; ok {
+			continue
+		}
+		least := -1
+		for i, member := range members {
+			if subscribed[member.ID][partition.topic] && (least < 0 || len(assigned[member.ID]) < len(assigned[members[least].ID])) {
+				least = i
+			}
+		}
+		assigned[members[least].ID] = append(assigned[members[least].ID], partition)
+	}
+
+	// move partitions from the members with the most partitions to the
+	// members with the fewest, the last partitions given to a member move
+	// first so it keeps the ones it owned.  every move narrows the gap between
+	// two members, so the loop ends.
+	byCount := append([]GroupMember(nil), members...)
+	for moved := true; moved; {
+		moved = false
+		sort.SliceStable(byCount, func(i, j int) bool {
+			return len(assigned[byCount[i].ID]) > len(assigned[byCount[j].ID])
+		})
+
+	search:
+		for _, from := range byCount {
+			for i := len(byCount) - 1; i >= 0; i-- {
+				to := byCount[i]
+				if len(assigned[from.ID])-len(assigned[to.ID]) <= 1 {
+					break
+				}
+				source := assigned[from.ID]
+				for j := len(source) - 1; j >= 0; j-- {
+					if partition := source[j]; subscribed[to.ID][partition.topic] {
+						assigned[from.ID] = append(source[:j:j], source[j+1:]...)
+						assigned[to.ID] = append(assigned[to.ID], partition)
+						moved = true
+						break search
+					}
+				}
+			}
+		}
+	}
+
+	groupAssignments := GroupMemberAssignments{}
+	for _, member := range members {
+		assignmentsByTopic := map[string][]int{}
+		for _, partition := range assigned[member.ID] {
+			assignmentsByTopic[partition.topic] = append(assignmentsByTopic[partition.topic], partition.partition)
+		}
+		for _, partitions := range assignmentsByTopic {
+			sort.Ints(partitions)
+		}
+		groupAssignments[member.ID] = assignmentsByTopic
+	}
+	return groupAssignments
+}
+
 // findPartitions extracts the partition ids associated with the topic from the
 // list of Partitions provided
 func findPartitions(topic string, partitions []Partition) []int {
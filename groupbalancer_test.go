@@ -1,8 +1,10 @@
 package kafka
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"math/rand"
 	"reflect"
 	"strconv"
 	"testing"
@@ -688,3 +690,406 @@ func TestRackAffinityGroupBalancer(t *testing.T) {
 		}
 	})
 }
+
+func TestCooperativeStickyAssignGroups(t *testing.T) {
+	newMeta := func(memberID string, owned map[string][]int, topics ...string) GroupMember {
+		return GroupMember{
+			ID:              memberID,
+			Topics:          topics,
+			OwnedPartitions: owned,
+		}
+	}
+
+	newPartitions := func(partitionCount int, topics ...string) []Partition {
+		partitions := make([]Partition, 0, len(topics)*partitionCount)
+		for _, topic := range topics {
+			for partition := 0; partition < partitionCount; partition++ {
+				partitions = append(partitions, Partition{
+					Topic: topic,
+					ID:    partition,
+				})
+			}
+		}
+		return partitions
+	}
+
+	tests := map[string]struct {
+		Members    []GroupMember
+		Partitions []Partition
+		Expected   GroupMemberAssignments
+	}{
+		"empty": {
+			Expected: GroupMemberAssignments{},
+		},
+		"new group": {
+			Members: []GroupMember{
+				newMeta("a", nil, "topic-1"),
+				newMeta("b", nil, "topic-1"),
+			},
+			Partitions: newPartitions(5, "topic-1"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 2, 4}},
+				"b": map[string][]int{"topic-1": {1, 3}},
+			},
+		},
+		"member joins": {
+			Members: []GroupMember{
+				newMeta("a", map[string][]int{"topic-1": {0, 1, 2}}, "topic-1"),
+				newMeta("b", map[string][]int{"topic-1": {3, 4, 5}}, "topic-1"),
+				newMeta("c", nil, "topic-1"),
+			},
+			Partitions: newPartitions(6, "topic-1"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 1}},
+				"b": map[string][]int{"topic-1": {3, 4}},
+				"c": map[string][]int{"topic-1": {2, 5}},
+			},
+		},
+		"member leaves": {
+			Members: []GroupMember{
+				newMeta("a", map[string][]int{"topic-1": {0, 1}}, "topic-1"),
+				newMeta("c", map[string][]int{"topic-1": {2, 5}}, "topic-1"),
+			},
+			Partitions: newPartitions(6, "topic-1"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 1, 3}},
+				"c": map[string][]int{"topic-1": {2, 4, 5}},
+			},
+		},
+		"remainder goes to the largest owner": {
+			Members: []GroupMember{
+				newMeta("a", map[string][]int{"topic-1": {0}}, "topic-1"),
+				newMeta("b", map[string][]int{"topic-1": {1, 2, 3, 4}}, "topic-1"),
+			},
+			Partitions: newPartitions(5, "topic-1"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 4}},
+				"b": map[string][]int{"topic-1": {1, 2, 3}},
+			},
+		},
+		"owned partitions are deleted or claimed twice": {
+			Members: []GroupMember{
+				newMeta("a", map[string][]int{"topic-1": {0, 7}}, "topic-1"),
+				newMeta("b", map[string][]int{"topic-1": {0, 1}}, "topic-1"),
+			},
+			Partitions: newPartitions(4, "topic-1"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 2}},
+				"b": map[string][]int{"topic-1": {1, 3}},
+			},
+		},
+		"multiple topics": {
+			Members: []GroupMember{
+				newMeta("a", map[string][]int{"topic-1": {1}, "topic-2": {0}}, "topic-1", "topic-2"),
+				newMeta("b", nil, "topic-2"),
+			},
+			Partitions: newPartitions(2, "topic-1", "topic-2"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 1}},
+				"b": map[string][]int{"topic-2": {0, 1}},
+			},
+		},
+	}
+
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			assignments := CooperativeStickyGroupBalancer{}.AssignGroups(test.Members, test.Partitions)
+			if !reflect.DeepEqual(test.Expected, assignments) {
+				buf := bytes.NewBuffer(nil)
+				encoder := json.NewEncoder(buf)
+				encoder.SetIndent("", "  ")
+
+				buf.WriteString("expected: ")
+				encoder.Encode(test.Expected)
+				buf.WriteString("got: ")
+				encoder.Encode(assignments)
+
+				t.Error(buf.String())
+			}
+		})
+	}
+}
+
+func TestStickyAssignGroups(t *testing.T) {
+	newMeta := func(memberID string, owned map[string][]int32, generation int32, topics ...string) GroupMember {
+		userData, _ := StickyGroupBalancer{}.userDataWithAssignments(owned, generation)
+		return GroupMember{
+			ID:       memberID,
+			Topics:   topics,
+			UserData: userData,
+		}
+	}
+
+	newPartitions := func(partitionCount int, topics ...string) []Partition {
+		partitions := make([]Partition, 0, len(topics)*partitionCount)
+		for _, topic := range topics {
+			for partition := 0; partition < partitionCount; partition++ {
+				partitions = append(partitions, Partition{
+					Topic: topic,
+					ID:    partition,
+				})
+			}
+		}
+		return partitions
+	}
+
+	tests := map[string]struct {
+		Members    []GroupMember
+		Partitions []Partition
+		Expected   GroupMemberAssignments
+	}{
+		"empty": {
+			Expected: GroupMemberAssignments{},
+		},
+		"new group": {
+			Members: []GroupMember{
+				newMeta("a", nil, 0, "topic-1", "topic-2"),
+				newMeta("b", nil, 0, "topic-1", "topic-2"),
+			},
+			Partitions: newPartitions(3, "topic-1", "topic-2"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 2}, "topic-2": {1}},
+				"b": map[string][]int{"topic-1": {1}, "topic-2": {0, 2}},
+			},
+		},
+		"member joins": {
+			Members: []GroupMember{
+				newMeta("a", map[string][]int32{"topic-1": {0, 1, 2}}, 1, "topic-1"),
+				newMeta("b", map[string][]int32{"topic-1": {3, 4, 5}}, 1, "topic-1"),
+				newMeta("c", nil, 0, "topic-1"),
+			},
+			Partitions: newPartitions(6, "topic-1"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 1}},
+				"b": map[string][]int{"topic-1": {3, 4}},
+				"c": map[string][]int{"topic-1": {2, 5}},
+			},
+		},
+		"member leaves": {
+			Members: []GroupMember{
+				newMeta("a", map[string][]int32{"topic-1": {0, 1}}, 2, "topic-1"),
+				newMeta("c", map[string][]int32{"topic-1": {2, 5}}, 2, "topic-1"),
+			},
+			Partitions: newPartitions(6, "topic-1"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 1, 3}},
+				"c": map[string][]int{"topic-1": {2, 4, 5}},
+			},
+		},
+		"the latest generation wins the partitions claimed twice": {
+			Members: []GroupMember{
+				newMeta("a", map[string][]int32{"topic-1": {0, 1}}, 1, "topic-1"),
+				newMeta("b", map[string][]int32{"topic-1": {1, 2}}, 2, "topic-1"),
+			},
+			Partitions: newPartitions(4, "topic-1"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 3}},
+				"b": map[string][]int{"topic-1": {1, 2}},
+			},
+		},
+		"members subscribed to different topics": {
+			Members: []GroupMember{
+				newMeta("a", map[string][]int32{"topic-1": {0, 1}, "topic-2": {0, 1}}, 1, "topic-1", "topic-2"),
+				newMeta("b", nil, 0, "topic-2"),
+			},
+			Partitions: newPartitions(2, "topic-1", "topic-2"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {0, 1}},
+				"b": map[string][]int{"topic-2": {0, 1}},
+			},
+		},
+		"invalid user data": {
+			Members: []GroupMember{
+				{ID: "a", Topics: []string{"topic-1"}, UserData: []byte{0, 0, 0, 1}},
+				newMeta("b", map[string][]int32{"topic-1": {0, 1}}, 1, "topic-1"),
+			},
+			Partitions: newPartitions(2, "topic-1"),
+			Expected: GroupMemberAssignments{
+				"a": map[string][]int{"topic-1": {1}},
+				"b": map[string][]int{"topic-1": {0}},
+			},
+		},
+	}
+
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			assignments := StickyGroupBalancer{}.AssignGroups(test.Members, test.Partitions)
+			if !reflect.DeepEqual(test.Expected, assignments) {
+				buf := bytes.NewBuffer(nil)
+				encoder := json.NewEncoder(buf)
+				encoder.SetIndent("", "  ")
+
+				buf.WriteString("expected: ")
+				encoder.Encode(test.Expected)
+				buf.WriteString("got: ")
+				encoder.Encode(assignments)
+
+				t.Error(buf.String())
+			}
+		})
+	}
+}
+
+func TestStickyAssignGroupsIsDeterministic(t *testing.T) {
+	prng := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 100; i++ {
+		var members []GroupMember
+		for m := 0; m < 1+prng.Intn(10); m++ {
+			owned := map[string][]int32{}
+			for p := 0; p < prng.Intn(8); p++ {
+				topic := "topic-" + strconv.Itoa(prng.Intn(3))
+				owned[topic] = append(owned[topic], int32(prng.Intn(10)))
+			}
+			userData, _ := StickyGroupBalancer{}.userDataWithAssignments(owned, int32(prng.Intn(3)))
+			members = append(members, GroupMember{
+				ID:       "member-" + strconv.Itoa(m),
+				Topics:   []string{"topic-" + strconv.Itoa(prng.Intn(3)), "topic-" + strconv.Itoa(prng.Intn(3))},
+				UserData: userData,
+			})
+		}
+
+		var partitions []Partition
+		for topic := 0; topic < 3; topic++ {
+			for p := 0; p < prng.Intn(10); p++ {
+				partitions = append(partitions, Partition{Topic: "topic-" + strconv.Itoa(topic), ID: p})
+			}
+		}
+
+		expected := StickyGroupBalancer{}.AssignGroups(members, partitions)
+		for j := 0; j < 10; j++ {
+			prng.Shuffle(len(members), func(a, b int) { members[a], members[b] = members[b], members[a] })
+			prng.Shuffle(len(partitions), func(a, b int) { partitions[a], partitions[b] = partitions[b], partitions[a] })
+
+			if assignments := (StickyGroupBalancer{}).AssignGroups(members, partitions); !reflect.DeepEqual(expected, assignments) {
+				t.Fatalf("the assignments changed with the order of the inputs\nexpected: %v\nfound:    %v", expected, assignments)
+			}
+		}
+	}
+}
+
+func TestStickyAssignGroupsMovesFewPartitions(t *testing.T) {
+	var members []GroupMember
+	for m := 0; m < 7; m++ {
+		members = append(members, GroupMember{ID: "member-" + strconv.Itoa(m), Topics: []string{"topic-1", "topic-2"}})
+	}
+
+	var partitions []Partition
+	for p := 0; p < 50; p++ {
+		partitions = append(partitions, Partition{Topic: "topic-1", ID: p}, Partition{Topic: "topic-2", ID: p})
+	}
+
+	contains := func(ids []int, id int) bool {
+		for _, i := range ids {
+			if i == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	assignments := StickyGroupBalancer{}.AssignGroups(members, partitions)
+
+	for generation := int32(1); len(members) > 1; generation++ {
+		// the first member leaves the group, the others keep all their
+		// partitions and share the ones it owned.
+		members = members[1:]
+		for i, member := range members {
+			owned := map[string][]int32{}
+			for topic, ids := range assignments[member.ID] {
+				for _, id := range ids {
+					owned[topic] = append(owned[topic], int32(id))
+				}
+			}
+			members[i].UserData, _ = StickyGroupBalancer{}.userDataWithAssignments(owned, generation)
+		}
+
+		previous := assignments
+		assignments = StickyGroupBalancer{}.AssignGroups(members, partitions)
+
+		min, max := len(partitions), 0
+		for _, member := range members {
+			count := 0
+			for topic, ids := range previous[member.ID] {
+				for _, id := range ids {
+					if !contains(assignments[member.ID][topic], id) {
+						t.Errorf("partition %s/%d moved away from %s", topic, id, member.ID)
+					}
+				}
+			}
+			for _, ids := range assignments[member.ID] {
+				count += len(ids)
+			}
+			if count < min {
+				min = count
+			}
+			if count > max {
+				max = count
+			}
+		}
+		if max-min > 1 {
+			t.Errorf("the assignments of %d members are not balanced: between %d and %d partitions", len(members), min, max)
+		}
+	}
+}
+
+func TestStickyAssignorUserData(t *testing.T) {
+	userData, err := StickyGroupBalancer{}.userDataWithAssignments(map[string][]int32{
+		"topic-2": {1},
+		"topic-1": {0, 2},
+	}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the user data of the Java client: [topic, [partitions]], generation.
+	expected := []byte{
+		0, 0, 0, 2,
+		0, 7, 't', 'o', 'p', 'i', 'c', '-', '1', 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 2,
+		0, 7, 't', 'o', 'p', 'i', 'c', '-', '2', 0, 0, 0, 1, 0, 0, 0, 1,
+		0, 0, 0, 3,
+	}
+	if !bytes.Equal(expected, userData) {
+		t.Fatalf("unexpected user data\nexpected: %v\nfound:    %v", expected, userData)
+	}
+
+	tests := map[string]struct {
+		UserData []byte
+		Expected stickyAssignorUserData
+	}{
+		"version 1": {
+			UserData: expected,
+			Expected: stickyAssignorUserData{
+				Topics:     map[string][]int32{"topic-1": {0, 2}, "topic-2": {1}},
+				Generation: 3,
+			},
+		},
+		"version 0": {
+			UserData: expected[:len(expected)-4],
+			Expected: stickyAssignorUserData{
+				Topics:     map[string][]int32{"topic-1": {0, 2}, "topic-2": {1}},
+				Generation: -1,
+			},
+		},
+	}
+
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			var found stickyAssignorUserData
+			remain, err := (&found).readFrom(bufio.NewReader(bytes.NewReader(test.UserData)), len(test.UserData))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if remain != 0 {
+				t.Fatalf("%d bytes left to read", remain)
+			}
+			if !reflect.DeepEqual(test.Expected, found) {
+				t.Errorf("unexpected user data\nexpected: %+v\nfound:    %+v", test.Expected, found)
+			}
+		})
+	}
+
+	if userData, _ := (StickyGroupBalancer{}).userDataWithAssignments(nil, 0); userData != nil {
+		t.Errorf("members without assignments should not send user data, found %v", userData)
+	}
+}
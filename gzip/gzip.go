@@ -51,6 +51,8 @@ const (
 	Code = 1
 
 	DefaultCompressionLevel = gzip.DefaultCompression
+	BestSpeed               = gzip.BestSpeed
+	BestCompression         = gzip.BestCompression
 )
 
 type CompressionCodec struct{ writerPool sync.Pool }
@@ -59,6 +61,8 @@ func NewCompressionCodec() *CompressionCodec {
 	return NewCompressionCodecLevel(DefaultCompressionLevel)
 }
 
+// NewCompressionCodecLevel returns a codec compressing at the given level of
+// the compress/gzip package, from BestSpeed to BestCompression.
 func NewCompressionCodecLevel(level int) *CompressionCodec {
 	return &CompressionCodec{
 		writerPool: sync.Pool{
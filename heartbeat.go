@@ -1,6 +1,89 @@
 package kafka
 
-import "bufio"
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// HeartbeatRequest represents a heartbeat sent by a member of a group to the
+// coordinator to keep its session alive.
+type HeartbeatRequest struct {
+	// GroupID is the ID of the group.
+	GroupID string
+
+	// GenerationID, MemberID, and GroupInstanceID identify the member, as
+	// returned by JoinGroup.
+	GenerationID    int
+	MemberID        string
+	GroupInstanceID string
+}
+
+// HeartbeatResponse represents the response to a HeartbeatRequest.
+type HeartbeatResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+
+	// Error is set to a non-nil value if the heartbeat was rejected, for
+	// example RebalanceInProgress if the member must rejoin the group.
+	Error error
+}
+
+// Heartbeat sends a heartbeat to the coordinator of the group. Members must
+// send heartbeats more often than the session timeout to remain in the group.
+// The method requires kafka 2.3 or above.
+func (c *Client) Heartbeat(ctx context.Context, req HeartbeatRequest) (*HeartbeatResponse, error) {
+	conn, err := c.groupCoordinator(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.heartbeatV3(heartbeatRequestV3{
+		GroupID:         req.GroupID,
+		GenerationID:    int32(req.GenerationID),
+		MemberID:        req.MemberID,
+		GroupInstanceID: emptyToNullable(req.GroupInstanceID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &HeartbeatResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+	}
+	if response.ErrorCode != 0 {
+		res.Error = Error(response.ErrorCode)
+	}
+
+	return res, nil
+}
+
+// heartbeatV3 sends a heartbeat, the broker must be the coordinator of the
+// group. Unlike heartbeat, the error code of the response does not cause the
+// method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_Heartbeat
+func (c *Conn) heartbeatV3(request heartbeatRequestV3) (heartbeatResponseV3, error) {
+	var response heartbeatResponseV3
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(heartbeat, v3, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return heartbeatResponseV3{}, err
+	}
+
+	return response, nil
+}
 
 type heartbeatRequestV0 struct {
 	// GroupID holds the unique group identifier
@@ -44,3 +127,51 @@ func (t *heartbeatResponseV0) readFrom(r *bufio.Reader, sz int) (remain int, err
 	}
 	return
 }
+
+// heartbeatRequestV3 has the same layout than heartbeatRequestV0 with the
+// group instance ID of static members.
+type heartbeatRequestV3 struct {
+	GroupID         string
+	GenerationID    int32
+	MemberID        string
+	GroupInstanceID *string
+}
+
+func (t heartbeatRequestV3) size() int32 {
+	return sizeofString(t.GroupID) +
+		sizeofInt32(t.GenerationID) +
+		sizeofString(t.MemberID) +
+		sizeofNullableString(t.GroupInstanceID)
+}
+
+func (t heartbeatRequestV3) writeTo(wb *writeBuffer) {
+	wb.writeString(t.GroupID)
+	wb.writeInt32(t.GenerationID)
+	wb.writeString(t.MemberID)
+	wb.writeNullableString(t.GroupInstanceID)
+}
+
+type heartbeatResponseV3 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+}
+
+func (t heartbeatResponseV3) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode)
+}
+
+func (t heartbeatResponseV3) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+}
+
+func (t *heartbeatResponseV3) readFrom(r *bufio.Reader, sz int) (remain int, err error) {
+	if remain, err = readInt32(r, sz, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	return
+}
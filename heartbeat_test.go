@@ -31,3 +31,33 @@ func TestHeartbeatRequestV0(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestHeartbeatResponseV3(t *testing.T) {
+	item := heartbeatResponseV3{
+		ThrottleTimeMS: 1,
+		ErrorCode:      2,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found heartbeatResponseV3
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,224 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ConfigOperation is the operation applied to a configuration entry by an
+// IncrementalAlterConfigs request.
+type ConfigOperation int8
+
+const (
+	// ConfigOperationSet sets the value of the configuration entry.
+	ConfigOperationSet ConfigOperation = 0
+
+	// ConfigOperationDelete reverts the configuration entry to its default.
+	ConfigOperationDelete ConfigOperation = 1
+
+	// ConfigOperationAppend adds the value to a list configuration entry.
+	ConfigOperationAppend ConfigOperation = 2
+
+	// ConfigOperationSubtract removes the value from a list configuration
+	// entry.
+	ConfigOperationSubtract ConfigOperation = 3
+)
+
+// IncrementalAlterConfigsRequest represents a request sent to a kafka cluster
+// to update individual configuration entries of topics or brokers, leaving
+// the other entries untouched.
+type IncrementalAlterConfigsRequest struct {
+	// Resources holds the list of resources to update.
+	Resources []IncrementalAlterConfigsRequestResource
+
+	// When ValidateOnly is true, the brokers validate the request without
+	// applying the changes.
+	ValidateOnly bool
+}
+
+// IncrementalAlterConfigsRequestResource designates a resource and the
+// operations to apply to its configuration.
+type IncrementalAlterConfigsRequestResource struct {
+	// ResourceType is the type of the resource, valid values are
+	// ResourceTypeTopic, ResourceTypeBroker, and ResourceTypeBrokerLogger.
+	ResourceType ResourceType
+
+	// ResourceName is the topic name, or the broker ID for broker resources.
+	ResourceName string
+
+	// Configs holds the operations to apply to the configuration entries.
+	Configs []IncrementalAlterConfigsRequestConfig
+}
+
+// IncrementalAlterConfigsRequestConfig is an operation on a configuration
+// entry.
+type IncrementalAlterConfigsRequestConfig struct {
+	Name  string
+	Value string
+
+	// ConfigOperation defaults to ConfigOperationSet. The value is ignored for
+	// ConfigOperationDelete.
+	ConfigOperation ConfigOperation
+}
+
+// IncrementalAlterConfigsResponse represents the response to an
+// IncrementalAlterConfigsRequest.
+type IncrementalAlterConfigsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// brokers.
+	Throttle time.Duration
+
+	// Resources holds the result of altering each resource, in the order they
+	// appeared in the request.
+	Resources []AlterConfigResponseResource
+}
+
+// IncrementalAlterConfigs sends an IncrementalAlterConfigs request to the kafka
+// cluster. The API was introduced in kafka 2.3 (KIP-339).
+//
+// Broker resources are sent to the brokers they name, other resources are
+// sent to any broker. Errors that apply to a single resource are reported on
+// the resource and do not cause the method to fail.
+func (c *Client) IncrementalAlterConfigs(ctx context.Context, req IncrementalAlterConfigsRequest) (*IncrementalAlterConfigsResponse, error) {
+	routes, err := configResourceRoutes(len(req.Resources), func(i int) (ResourceType, string) {
+		return req.Resources[i].ResourceType, req.Resources[i].ResourceName
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &IncrementalAlterConfigsResponse{
+		Resources: make([]AlterConfigResponseResource, len(req.Resources)),
+	}
+
+	for broker, indexes := range routes {
+		request := incrementalAlterConfigsRequestV0{
+			Resources:    make([]incrementalAlterConfigsRequestResourceV0, len(indexes)),
+			ValidateOnly: req.ValidateOnly,
+		}
+		for i, index := range indexes {
+			r := req.Resources[index]
+			request.Resources[i] = incrementalAlterConfigsRequestResourceV0{
+				ResourceType: int8(r.ResourceType),
+				ResourceName: r.ResourceName,
+				Configs:      make([]incrementalAlterConfigsRequestConfigV0, len(r.Configs)),
+			}
+			for j, e := range r.Configs {
+				config := incrementalAlterConfigsRequestConfigV0{
+					Name:            e.Name,
+					ConfigOperation: int8(e.ConfigOperation),
+				}
+				if e.ConfigOperation != ConfigOperationDelete {
+					value := e.Value
+					config.Value = &value
+				}
+				request.Resources[i].Configs[j] = config
+			}
+		}
+
+		conn, err := c.connectBrokerOrAny(ctx, broker)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := conn.incrementalAlterConfigs(request)
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if throttle := duration(response.ThrottleTimeMS); throttle > res.Throttle {
+			res.Throttle = throttle
+		}
+
+		if len(response.Resources) != len(indexes) {
+			return nil, fmt.Errorf("expected %d resources in the incremental alter configs response, got %d", len(indexes), len(response.Resources))
+		}
+
+		for i, r := range response.Resources {
+			res.Resources[indexes[i]] = r.toAlterConfigResponseResource()
+		}
+	}
+
+	return res, nil
+}
+
+// incrementalAlterConfigs applies operations to the configuration of the
+// requested resources. The response has the same layout than the response to
+// AlterConfigs v0.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_IncrementalAlterConfigs
+func (c *Conn) incrementalAlterConfigs(request incrementalAlterConfigsRequestV0) (alterConfigsResponseV0, error) {
+	var response alterConfigsResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(incrementalAlterConfigs, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return alterConfigsResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+type incrementalAlterConfigsRequestConfigV0 struct {
+	Name            string
+	ConfigOperation int8
+
+	// Value is null for delete operations.
+	Value *string
+}
+
+func (t incrementalAlterConfigsRequestConfigV0) size() int32 {
+	return sizeofString(t.Name) +
+		sizeofInt8(t.ConfigOperation) +
+		sizeofNullableString(t.Value)
+}
+
+func (t incrementalAlterConfigsRequestConfigV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Name)
+	wb.writeInt8(t.ConfigOperation)
+	wb.writeNullableString(t.Value)
+}
+
+type incrementalAlterConfigsRequestResourceV0 struct {
+	ResourceType int8
+	ResourceName string
+	Configs      []incrementalAlterConfigsRequestConfigV0
+}
+
+func (t incrementalAlterConfigsRequestResourceV0) size() int32 {
+	return sizeofInt8(t.ResourceType) +
+		sizeofString(t.ResourceName) +
+		sizeofArray(len(t.Configs), func(i int) int32 { return t.Configs[i].size() })
+}
+
+func (t incrementalAlterConfigsRequestResourceV0) writeTo(wb *writeBuffer) {
+	wb.writeInt8(t.ResourceType)
+	wb.writeString(t.ResourceName)
+	wb.writeArray(len(t.Configs), func(i int) { t.Configs[i].writeTo(wb) })
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_IncrementalAlterConfigs
+type incrementalAlterConfigsRequestV0 struct {
+	Resources    []incrementalAlterConfigsRequestResourceV0
+	ValidateOnly bool
+}
+
+func (t incrementalAlterConfigsRequestV0) size() int32 {
+	return sizeofArray(len(t.Resources), func(i int) int32 { return t.Resources[i].size() }) +
+		sizeofBool(t.ValidateOnly)
+}
+
+func (t incrementalAlterConfigsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Resources), func(i int) { t.Resources[i].writeTo(wb) })
+	wb.writeBool(t.ValidateOnly)
+}
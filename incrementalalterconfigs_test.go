@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestIncrementalAlterConfigsRequestV0(t *testing.T) {
+	value := "a"
+	item := incrementalAlterConfigsRequestV0{
+		Resources: []incrementalAlterConfigsRequestResourceV0{
+			{
+				ResourceType: int8(ResourceTypeTopic),
+				ResourceName: "b",
+				Configs: []incrementalAlterConfigsRequestConfigV0{
+					{Name: "c", ConfigOperation: int8(ConfigOperationAppend), Value: &value},
+					{Name: "d", ConfigOperation: int8(ConfigOperationDelete)},
+				},
+			},
+		},
+		ValidateOnly: true,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Fatalf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	// The delete operation is encoded last, followed by the validate only
+	// flag: its value must be null.
+	tail := b.Bytes()[b.Len()-3:]
+	if n := makeInt16(tail[:2]); n != -1 {
+		t.Errorf("expected a null value for the delete operation, got length %d", n)
+	}
+	if tail[2] != 1 {
+		t.Error("expected validate only to be set")
+	}
+}
+
+func testClientIncrementalAlterConfigs(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.3.0") {
+		t.Skip("incremental alter configs requires kafka 2.3.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	alter := func(op ConfigOperation, value string) {
+		res, err := c.IncrementalAlterConfigs(ctx, IncrementalAlterConfigsRequest{
+			Resources: []IncrementalAlterConfigsRequestResource{{
+				ResourceType: ResourceTypeTopic,
+				ResourceName: topic,
+				Configs: []IncrementalAlterConfigsRequestConfig{{
+					Name:            "follower.replication.throttled.replicas",
+					Value:           value,
+					ConfigOperation: op,
+				}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := res.Resources[0].Error; err != nil {
+			t.Fatal(err, res.Resources[0].ErrorMessage)
+		}
+	}
+
+	describe := func() string {
+		res, err := c.DescribeConfigs(ctx, DescribeConfigsRequest{
+			Resources: []DescribeConfigRequestResource{{
+				ResourceType: ResourceTypeTopic,
+				ResourceName: topic,
+				ConfigNames:  []string{"follower.replication.throttled.replicas"},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Resources[0].ConfigEntries[0].ConfigValue
+	}
+
+	alter(ConfigOperationSet, "0:1")
+	alter(ConfigOperationAppend, "0:2")
+	if v := describe(); v != "0:1,0:2" {
+		t.Errorf("expected appended value, got %q", v)
+	}
+
+	alter(ConfigOperationSubtract, "0:1")
+	if v := describe(); v != "0:2" {
+		t.Errorf("expected subtracted value, got %q", v)
+	}
+
+	alter(ConfigOperationDelete, "")
+	if v := describe(); v != "" {
+		t.Errorf("expected deleted value, got %q", v)
+	}
+}
@@ -0,0 +1,156 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// InitProducerIDRequest represents a request sent to a kafka cluster to obtain
+// a producer id.
+type InitProducerIDRequest struct {
+	// TransactionalID identifies the transactional producer, leave it empty to
+	// obtain the id of an idempotent producer.
+	TransactionalID string
+
+	// TransactionTimeout is the time after which the coordinator aborts the
+	// transactions of the producer if they are not completed. It defaults to
+	// one minute.
+	TransactionTimeout time.Duration
+}
+
+// InitProducerIDResponse represents the response to an InitProducerIDRequest.
+type InitProducerIDResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// ProducerID and ProducerEpoch identify the producer, the epoch is bumped
+	// each time the same transactional id is initialized, fencing off the
+	// producers using older epochs.
+	ProducerID    int64
+	ProducerEpoch int
+}
+
+// InitProducerID obtains a producer id. When the request has a transactional
+// id, it is sent to the coordinator of the transactional id, which also
+// aborts any transaction left pending by a previous producer of the same id.
+// The API was introduced in kafka 0.11.
+func (c *Client) InitProducerID(ctx context.Context, req InitProducerIDRequest) (*InitProducerIDResponse, error) {
+	var conn *Conn
+	var err error
+
+	if req.TransactionalID != "" {
+		conn, err = c.transactionCoordinator(ctx, req.TransactionalID)
+	} else {
+		conn, err = c.connect(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	timeout := req.TransactionTimeout
+	if timeout == 0 {
+		timeout = defaultTransactionTimeout
+	}
+
+	response, err := conn.initProducerID(initProducerIDRequestV0{
+		TransactionalID:      emptyToNullable(req.TransactionalID),
+		TransactionTimeoutMS: milliseconds(timeout),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &InitProducerIDResponse{
+		Throttle:      duration(response.ThrottleTimeMS),
+		ProducerID:    response.ProducerID,
+		ProducerEpoch: int(response.ProducerEpoch),
+	}, nil
+}
+
+// defaultTransactionTimeout is the transaction timeout used when none is
+// configured, it matches the default of the java client.
+const defaultTransactionTimeout = time.Minute
+
+// initProducerID obtains a producer id, the broker must be the coordinator of
+// the transactional id if the request has one.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_InitProducerId
+func (c *Conn) initProducerID(request initProducerIDRequestV0) (initProducerIDResponseV0, error) {
+	var response initProducerIDResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(initProducerId, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return initProducerIDResponseV0{}, err
+	}
+	if response.ErrorCode != 0 {
+		return initProducerIDResponseV0{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_InitProducerId
+type initProducerIDRequestV0 struct {
+	TransactionalID      *string
+	TransactionTimeoutMS int32
+}
+
+func (t initProducerIDRequestV0) size() int32 {
+	return sizeofNullableString(t.TransactionalID) +
+		sizeofInt32(t.TransactionTimeoutMS)
+}
+
+func (t initProducerIDRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeNullableString(t.TransactionalID)
+	wb.writeInt32(t.TransactionTimeoutMS)
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_InitProducerId
+type initProducerIDResponseV0 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	ProducerID     int64
+	ProducerEpoch  int16
+}
+
+func (t initProducerIDResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofInt64(t.ProducerID) +
+		sizeofInt16(t.ProducerEpoch)
+}
+
+func (t initProducerIDResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeInt64(t.ProducerID)
+	wb.writeInt16(t.ProducerEpoch)
+}
+
+func (t *initProducerIDResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.ProducerID); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ProducerEpoch); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestInitProducerIDResponseV0(t *testing.T) {
+	item := initProducerIDResponseV0{
+		ThrottleTimeMS: 1,
+		ProducerID:     2,
+		ProducerEpoch:  3,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found initProducerIDResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestInitProducerIDRequestV0Size(t *testing.T) {
+	transactionalID := "a"
+
+	for _, item := range []initProducerIDRequestV0{
+		{TransactionTimeoutMS: 1},
+		{TransactionalID: &transactionalID, TransactionTimeoutMS: 1},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
@@ -3,8 +3,228 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
 )
 
+// JoinGroupRequest represents a request sent to the coordinator of a group to
+// join the group, or to rejoin it when the group is rebalancing.
+type JoinGroupRequest struct {
+	// GroupID is the ID of the group to join.
+	GroupID string
+
+	// MemberID is the ID assigned to the member by the coordinator, leave it
+	// empty when joining the group for the first time.
+	MemberID string
+
+	// GroupInstanceID optionally identifies a static member of the group
+	// (KIP-345), which keeps its assignments across restarts as long as it
+	// rejoins within the session timeout.
+	GroupInstanceID string
+
+	// SessionTimeout is the time after which the coordinator removes the
+	// member from the group if it does not receive any heartbeat.
+	SessionTimeout time.Duration
+
+	// RebalanceTimeout is the maximum time that the coordinator waits for the
+	// members to rejoin when the group is rebalancing. It defaults to the
+	// session timeout.
+	RebalanceTimeout time.Duration
+
+	// ProtocolType is the type of protocol implemented by the group, it is
+	// "consumer" for consumer groups.
+	ProtocolType string
+
+	// Protocols holds the protocols supported by the member, in order of
+	// preference.
+	Protocols []GroupProtocol
+}
+
+// GroupProtocol is a protocol supported by a member of a group, with the
+// metadata that the member associates with it. For consumer groups, the name
+// is the name of a balancer and the metadata is an encoded
+// GroupProtocolSubscription.
+type GroupProtocol struct {
+	Name     string
+	Metadata []byte
+}
+
+// JoinGroupResponse represents the response to a JoinGroupRequest.
+type JoinGroupResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+
+	// Error is set to a non-nil value if the member could not join the group.
+	// When a member without an ID joins the group, the coordinator responds
+	// with MemberIDRequired and the ID that the member must rejoin with.
+	Error error
+
+	// GenerationID is the generation of the group that the member joined.
+	GenerationID int
+
+	// ProtocolName is the name of the protocol selected by the coordinator.
+	ProtocolName string
+
+	// LeaderID is the ID of the member elected leader of the group.
+	LeaderID string
+
+	// MemberID is the ID assigned to the member by the coordinator.
+	MemberID string
+
+	// Members holds the members of the group and their metadata for the
+	// selected protocol, it is only set on the response sent to the leader.
+	Members []JoinGroupResponseMember
+}
+
+// JoinGroupResponseMember is a member of a group, as reported to the leader of
+// the group.
+type JoinGroupResponseMember struct {
+	ID              string
+	GroupInstanceID string
+
+	// Metadata is the metadata of the member for the selected protocol.
+	Metadata []byte
+}
+
+// JoinGroup sends a join group request to the coordinator of the group. The
+// coordinator responds once all members have joined, or when the rebalance
+// timeout expires, the deadline of ctx must leave enough time for it. The
+// method requires kafka 2.3 or above.
+//
+// The leader of the group must then compute the assignments of the members
+// and send them with SyncGroup.
+func (c *Client) JoinGroup(ctx context.Context, req JoinGroupRequest) (*JoinGroupResponse, error) {
+	conn, err := c.groupCoordinator(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rebalanceTimeout := req.RebalanceTimeout
+	if rebalanceTimeout == 0 {
+		rebalanceTimeout = req.SessionTimeout
+	}
+
+	request := joinGroupRequestV5{
+		GroupID:          req.GroupID,
+		SessionTimeout:   milliseconds(req.SessionTimeout),
+		RebalanceTimeout: milliseconds(rebalanceTimeout),
+		MemberID:         req.MemberID,
+		GroupInstanceID:  emptyToNullable(req.GroupInstanceID),
+		ProtocolType:     req.ProtocolType,
+		GroupProtocols:   make([]joinGroupRequestGroupProtocolV1, len(req.Protocols)),
+	}
+
+	for i, p := range req.Protocols {
+		request.GroupProtocols[i] = joinGroupRequestGroupProtocolV1{
+			ProtocolName:     p.Name,
+			ProtocolMetadata: p.Metadata,
+		}
+	}
+
+	response, err := conn.joinGroupV5(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &JoinGroupResponse{
+		Throttle:     duration(response.ThrottleTimeMS),
+		GenerationID: int(response.GenerationID),
+		ProtocolName: response.GroupProtocol,
+		LeaderID:     response.LeaderID,
+		MemberID:     response.MemberID,
+		Members:      make([]JoinGroupResponseMember, len(response.Members)),
+	}
+
+	if response.ErrorCode != 0 {
+		res.Error = Error(response.ErrorCode)
+	}
+
+	for i, m := range response.Members {
+		res.Members[i] = JoinGroupResponseMember{
+			ID:       m.MemberID,
+			Metadata: m.MemberMetadata,
+		}
+		if m.GroupInstanceID != nil {
+			res.Members[i].GroupInstanceID = *m.GroupInstanceID
+		}
+	}
+
+	return res, nil
+}
+
+// GroupProtocolSubscription is the metadata that the members of a consumer
+// group associate with the protocols they support, as defined by the consumer
+// protocol.
+type GroupProtocolSubscription struct {
+	Version int
+
+	// Topics holds the topics that the member subscribes to.
+	Topics []string
+
+	// UserData is arbitrary data interpreted by the balancer.
+	UserData []byte
+}
+
+// Bytes returns the encoded form of the subscription.
+func (s GroupProtocolSubscription) Bytes() []byte {
+	return groupMetadata{
+		Version:  int16(s.Version),
+		Topics:   s.Topics,
+		UserData: s.UserData,
+	}.bytes()
+}
+
+// DecodeGroupProtocolSubscription decodes the subscription of a member of a
+// consumer group. An empty slice decodes to an empty subscription.
+func DecodeGroupProtocolSubscription(b []byte) (GroupProtocolSubscription, error) {
+	if len(b) == 0 {
+		return GroupProtocolSubscription{}, nil
+	}
+
+	metadata := groupMetadata{}
+	if remain, err := (&metadata).readFrom(bufio.NewReader(bytes.NewReader(b)), len(b)); err != nil {
+		return GroupProtocolSubscription{}, err
+	} else if remain != 0 {
+		return GroupProtocolSubscription{}, fmt.Errorf("%d unexpected bytes after the member metadata", remain)
+	}
+
+	return GroupProtocolSubscription{
+		Version:  int(metadata.Version),
+		Topics:   metadata.Topics,
+		UserData: metadata.UserData,
+	}, nil
+}
+
+// joinGroupV5 sends a join group request, the broker must be the coordinator
+// of the group. Unlike joinGroup, the error code of the response does not
+// cause the method to fail since the response carries the member ID to rejoin
+// with.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_JoinGroup
+func (c *Conn) joinGroupV5(request joinGroupRequestV5) (joinGroupResponseV5, error) {
+	var response joinGroupResponseV5
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(joinGroup, v5, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return joinGroupResponseV5{}, err
+	}
+
+	return response, nil
+}
+
 type memberGroupMetadata struct {
 	// MemberID assigned by the group coordinator or null if joining for the
 	// first time.
@@ -16,18 +236,47 @@ type groupMetadata struct {
 	Version  int16
 	Topics   []string
 	UserData []byte
+
+	// OwnedPartitions holds topic => partitions that the member consumed in
+	// the previous generation, it is only written by members following the
+	// cooperative rebalance protocol, and only when it is not nil.  Versions
+	// older than 1 don't have the field.
+	OwnedPartitions map[string][]int32
 }
 
 func (t groupMetadata) size() int32 {
-	return sizeofInt16(t.Version) +
+	sz := sizeofInt16(t.Version) +
 		sizeofStringArray(t.Topics) +
 		sizeofBytes(t.UserData)
+
+	if t.OwnedPartitions != nil {
+		sz += sizeofInt32(int32(len(t.OwnedPartitions)))
+		for topic, partitions := range t.OwnedPartitions {
+			sz += sizeofString(topic) + sizeofInt32Array(partitions)
+		}
+	}
+
+	return sz
 }
 
 func (t groupMetadata) writeTo(wb *writeBuffer) {
 	wb.writeInt16(t.Version)
 	wb.writeStringArray(t.Topics)
 	wb.writeBytes(t.UserData)
+
+	if t.OwnedPartitions != nil {
+		topics := make([]string, 0, len(t.OwnedPartitions))
+		for topic := range t.OwnedPartitions {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+
+		wb.writeInt32(int32(len(topics)))
+		for _, topic := range topics {
+			wb.writeString(topic)
+			wb.writeInt32Array(t.OwnedPartitions[topic])
+		}
+	}
 }
 
 func (t groupMetadata) bytes() []byte {
@@ -46,6 +295,20 @@ func (t *groupMetadata) readFrom(r *bufio.Reader, size int) (remain int, err err
 	if remain, err = readBytes(r, remain, &t.UserData); err != nil {
 		return
 	}
+	// members following the eager protocol may write version 1 without the
+	// owned partitions.
+	if t.Version >= 1 && remain != 0 {
+		if remain, err = readMapStringInt32(r, remain, &t.OwnedPartitions); err != nil {
+			return
+		}
+	}
+	// later versions append fields that the balancers don't use, like the
+	// generation and rack of the Java client.
+	if t.Version > 1 && remain != 0 {
+		if remain, err = discardN(r, remain, remain); err != nil {
+			return
+		}
+	}
 	return
 }
 
@@ -198,3 +461,133 @@ func (t *joinGroupResponseV1) readFrom(r *bufio.Reader, size int) (remain int, e
 
 	return
 }
+
+// joinGroupRequestV5 has the same layout than joinGroupRequestV1 with the
+// group instance ID of static members after the member ID.
+type joinGroupRequestV5 struct {
+	GroupID          string
+	SessionTimeout   int32
+	RebalanceTimeout int32
+	MemberID         string
+	GroupInstanceID  *string
+	ProtocolType     string
+	GroupProtocols   []joinGroupRequestGroupProtocolV1
+}
+
+func (t joinGroupRequestV5) size() int32 {
+	return sizeofString(t.GroupID) +
+		sizeofInt32(t.SessionTimeout) +
+		sizeofInt32(t.RebalanceTimeout) +
+		sizeofString(t.MemberID) +
+		sizeofNullableString(t.GroupInstanceID) +
+		sizeofString(t.ProtocolType) +
+		sizeofArray(len(t.GroupProtocols), func(i int) int32 { return t.GroupProtocols[i].size() })
+}
+
+func (t joinGroupRequestV5) writeTo(wb *writeBuffer) {
+	wb.writeString(t.GroupID)
+	wb.writeInt32(t.SessionTimeout)
+	wb.writeInt32(t.RebalanceTimeout)
+	wb.writeString(t.MemberID)
+	wb.writeNullableString(t.GroupInstanceID)
+	wb.writeString(t.ProtocolType)
+	wb.writeArray(len(t.GroupProtocols), func(i int) { t.GroupProtocols[i].writeTo(wb) })
+}
+
+type joinGroupResponseMemberV5 struct {
+	MemberID        string
+	GroupInstanceID *string
+	MemberMetadata  []byte
+}
+
+func (t joinGroupResponseMemberV5) size() int32 {
+	return sizeofString(t.MemberID) +
+		sizeofNullableString(t.GroupInstanceID) +
+		sizeofBytes(t.MemberMetadata)
+}
+
+func (t joinGroupResponseMemberV5) writeTo(wb *writeBuffer) {
+	wb.writeString(t.MemberID)
+	wb.writeNullableString(t.GroupInstanceID)
+	wb.writeBytes(t.MemberMetadata)
+}
+
+func (t *joinGroupResponseMemberV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.MemberID); err != nil {
+		return
+	}
+	if remain, err = readNullableString(r, remain, &t.GroupInstanceID); err != nil {
+		return
+	}
+	if remain, err = readBytes(r, remain, &t.MemberMetadata); err != nil {
+		return
+	}
+	return
+}
+
+// joinGroupResponseV5 has the same layout than joinGroupResponseV1, preceded
+// by the throttle time, and with the group instance IDs of the members.
+type joinGroupResponseV5 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	GenerationID   int32
+	GroupProtocol  string
+	LeaderID       string
+	MemberID       string
+	Members        []joinGroupResponseMemberV5
+}
+
+func (t joinGroupResponseV5) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofInt32(t.GenerationID) +
+		sizeofString(t.GroupProtocol) +
+		sizeofString(t.LeaderID) +
+		sizeofString(t.MemberID) +
+		sizeofArray(len(t.Members), func(i int) int32 { return t.Members[i].size() })
+}
+
+func (t joinGroupResponseV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeInt32(t.GenerationID)
+	wb.writeString(t.GroupProtocol)
+	wb.writeString(t.LeaderID)
+	wb.writeString(t.MemberID)
+	wb.writeArray(len(t.Members), func(i int) { t.Members[i].writeTo(wb) })
+}
+
+func (t *joinGroupResponseV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.GenerationID); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.GroupProtocol); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.LeaderID); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.MemberID); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var item joinGroupResponseMemberV5
+		if fnRemain, fnErr = (&item).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Members = append(t.Members, item)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+
+	return
+}
@@ -3,8 +3,12 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
 )
 
 func TestSaramaCompatibility(t *testing.T) {
@@ -100,6 +104,39 @@ func TestMemberMetadata(t *testing.T) {
 	}
 }
 
+func TestMemberMetadataOwnedPartitions(t *testing.T) {
+	item := groupMetadata{
+		Version:         1,
+		Topics:          []string{"a", "b"},
+		OwnedPartitions: map[string][]int32{"a": {0, 2}, "b": {1}},
+	}
+
+	b := bytes.NewBuffer(nil)
+	item.writeTo(&writeBuffer{w: b})
+	// the fields of later versions are skipped.
+	b.Write([]byte{0, 0, 0, 7})
+
+	for _, version := range []int16{1, 2} {
+		data := append([]byte(nil), b.Bytes()...)
+		data[1] = byte(version)
+		if version == 1 {
+			data = data[:len(data)-4]
+		}
+
+		var found groupMetadata
+		remain, err := (&found).readFrom(bufio.NewReader(bytes.NewReader(data)), len(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if remain != 0 {
+			t.Errorf("version %d: expected 0 remain, got %v", version, remain)
+		}
+		if !reflect.DeepEqual(item.OwnedPartitions, found.OwnedPartitions) {
+			t.Errorf("version %d: expected owned partitions %v, got %v", version, item.OwnedPartitions, found.OwnedPartitions)
+		}
+	}
+}
+
 func TestJoinGroupResponseV1(t *testing.T) {
 	item := joinGroupResponseV1{
 		ErrorCode:     2,
@@ -134,3 +171,190 @@ func TestJoinGroupResponseV1(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestJoinGroupResponseV5(t *testing.T) {
+	instanceID := "e"
+
+	item := joinGroupResponseV5{
+		ThrottleTimeMS: 1,
+		GenerationID:   3,
+		GroupProtocol:  "a",
+		LeaderID:       "b",
+		MemberID:       "c",
+		Members: []joinGroupResponseMemberV5{
+			{MemberID: "c", MemberMetadata: []byte("blah")},
+			{MemberID: "d", GroupInstanceID: &instanceID, MemberMetadata: []byte("blah")},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found joinGroupResponseV5
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestJoinGroupRequestV5Size(t *testing.T) {
+	instanceID := "c"
+
+	for _, item := range []joinGroupRequestV5{
+		{GroupID: "a", ProtocolType: "consumer"},
+		{
+			GroupID:         "a",
+			MemberID:        "b",
+			GroupInstanceID: &instanceID,
+			ProtocolType:    "consumer",
+			GroupProtocols:  []joinGroupRequestGroupProtocolV1{{ProtocolName: "range", ProtocolMetadata: []byte("d")}},
+		},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func TestGroupProtocolSubscription(t *testing.T) {
+	item := GroupProtocolSubscription{
+		Version:  1,
+		Topics:   []string{"a", "b"},
+		UserData: []byte("c"),
+	}
+
+	found, err := DecodeGroupProtocolSubscription(item.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Errorf("expected %+v, got %+v", item, found)
+	}
+
+	if found, err := DecodeGroupProtocolSubscription(nil); err != nil || !reflect.DeepEqual(found, GroupProtocolSubscription{}) {
+		t.Errorf("expected an empty subscription, got %+v (%v)", found, err)
+	}
+}
+
+func testClientGroupMembership(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.4.0") {
+		t.Skip("removing members from a group in batches requires kafka 2.4.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	groupID := makeGroupID()
+	createTopic(t, topic, 2)
+
+	join := JoinGroupRequest{
+		GroupID:        groupID,
+		SessionTimeout: 10 * time.Second,
+		ProtocolType:   "consumer",
+		Protocols: []GroupProtocol{{
+			Name:     "range",
+			Metadata: GroupProtocolSubscription{Topics: []string{topic}}.Bytes(),
+		}},
+	}
+
+	joined, err := c.JoinGroup(ctx, join)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined.Error == MemberIDRequired {
+		join.MemberID = joined.MemberID
+		if joined, err = c.JoinGroup(ctx, join); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if joined.Error != nil {
+		t.Fatalf("joining the group failed: %v", joined.Error)
+	}
+	if joined.LeaderID != joined.MemberID {
+		t.Fatalf("expected the only member of the group to be the leader, got %s instead of %s", joined.LeaderID, joined.MemberID)
+	}
+	if joined.ProtocolName != "range" {
+		t.Errorf("expected the range protocol to be selected, got %q", joined.ProtocolName)
+	}
+	if len(joined.Members) != 1 {
+		t.Fatalf("expected 1 member, got %d", len(joined.Members))
+	}
+
+	subscription, err := DecodeGroupProtocolSubscription(joined.Members[0].Metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(subscription.Topics, []string{topic}) {
+		t.Errorf("expected the member to subscribe to %s, got %v", topic, subscription.Topics)
+	}
+
+	synced, err := c.SyncGroup(ctx, SyncGroupRequest{
+		GroupID:      groupID,
+		GenerationID: joined.GenerationID,
+		MemberID:     joined.MemberID,
+		Assignments: []SyncGroupRequestAssignment{{
+			MemberID:   joined.MemberID,
+			Assignment: GroupProtocolAssignment{Topics: map[string][]int{topic: {0, 1}}}.Bytes(),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synced.Error != nil {
+		t.Fatalf("syncing the group failed: %v", synced.Error)
+	}
+
+	assignment, err := DecodeGroupProtocolAssignment(synced.Assignment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(assignment.Topics, map[string][]int{topic: {0, 1}}) {
+		t.Errorf("expected partitions 0 and 1 of %s to be assigned, got %v", topic, assignment.Topics)
+	}
+
+	beat, err := c.Heartbeat(ctx, HeartbeatRequest{
+		GroupID:      groupID,
+		GenerationID: joined.GenerationID,
+		MemberID:     joined.MemberID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beat.Error != nil {
+		t.Errorf("sending a heartbeat failed: %v", beat.Error)
+	}
+
+	left, err := c.LeaveGroup(ctx, LeaveGroupRequest{
+		GroupID: groupID,
+		Members: []LeaveGroupMember{{MemberID: joined.MemberID}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left.Error != nil {
+		t.Fatalf("leaving the group failed: %v", left.Error)
+	}
+	if len(left.Members) != 1 {
+		t.Fatalf("expected the result of 1 member, got %d", len(left.Members))
+	}
+	if m := left.Members[0]; m.MemberID != joined.MemberID || m.Error != nil {
+		t.Errorf("expected %s to leave the group, got %+v", joined.MemberID, m)
+	}
+}
@@ -1,6 +1,124 @@
 package kafka
 
-import "bufio"
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// LeaveGroupRequest represents a request sent to the coordinator of a group to
+// remove members from the group.
+type LeaveGroupRequest struct {
+	// GroupID is the ID of the group.
+	GroupID string
+
+	// Members holds the members leaving the group. Static members may be
+	// removed by their group instance ID alone.
+	Members []LeaveGroupMember
+}
+
+// LeaveGroupMember identifies a member leaving a group.
+type LeaveGroupMember struct {
+	MemberID        string
+	GroupInstanceID string
+}
+
+// LeaveGroupResponse represents the response to a LeaveGroupRequest.
+type LeaveGroupResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+
+	// Error is set to a non-nil value if the request failed as a whole.
+	Error error
+
+	// Members holds the result of removing each member from the group.
+	Members []LeaveGroupResponseMember
+}
+
+// LeaveGroupResponseMember carries the result of removing a member from a
+// group.
+type LeaveGroupResponseMember struct {
+	MemberID        string
+	GroupInstanceID string
+
+	// Error is set to a non-nil value if the member could not be removed, for
+	// example UnknownMemberId if it was not a member of the group.
+	Error error
+}
+
+// LeaveGroup removes members from a group, which triggers a rebalance of the
+// group without waiting for the sessions of the members to expire. The method
+// requires kafka 2.4 or above.
+func (c *Client) LeaveGroup(ctx context.Context, req LeaveGroupRequest) (*LeaveGroupResponse, error) {
+	conn, err := c.groupCoordinator(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	request := leaveGroupRequestV3{
+		GroupID: req.GroupID,
+		Members: make([]leaveGroupRequestMemberV3, len(req.Members)),
+	}
+
+	for i, m := range req.Members {
+		request.Members[i] = leaveGroupRequestMemberV3{
+			MemberID:        m.MemberID,
+			GroupInstanceID: emptyToNullable(m.GroupInstanceID),
+		}
+	}
+
+	response, err := conn.leaveGroupV3(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &LeaveGroupResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Members:  make([]LeaveGroupResponseMember, len(response.Members)),
+	}
+	if response.ErrorCode != 0 {
+		res.Error = Error(response.ErrorCode)
+	}
+
+	for i, m := range response.Members {
+		res.Members[i] = LeaveGroupResponseMember{MemberID: m.MemberID}
+		if m.GroupInstanceID != nil {
+			res.Members[i].GroupInstanceID = *m.GroupInstanceID
+		}
+		if m.ErrorCode != 0 {
+			res.Members[i].Error = Error(m.ErrorCode)
+		}
+	}
+
+	return res, nil
+}
+
+// leaveGroupV3 removes members from a group, the broker must be the
+// coordinator of the group. Unlike leaveGroup, errors on members do not cause
+// the method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_LeaveGroup
+func (c *Conn) leaveGroupV3(request leaveGroupRequestV3) (leaveGroupResponseV3, error) {
+	var response leaveGroupResponseV3
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(leaveGroup, v3, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return leaveGroupResponseV3{}, err
+	}
+
+	return response, nil
+}
 
 type leaveGroupRequestV0 struct {
 	// GroupID holds the unique group identifier
@@ -37,3 +155,106 @@ func (t *leaveGroupResponseV0) readFrom(r *bufio.Reader, size int) (remain int,
 	remain, err = readInt16(r, size, &t.ErrorCode)
 	return
 }
+
+type leaveGroupRequestMemberV3 struct {
+	MemberID        string
+	GroupInstanceID *string
+}
+
+func (t leaveGroupRequestMemberV3) size() int32 {
+	return sizeofString(t.MemberID) +
+		sizeofNullableString(t.GroupInstanceID)
+}
+
+func (t leaveGroupRequestMemberV3) writeTo(wb *writeBuffer) {
+	wb.writeString(t.MemberID)
+	wb.writeNullableString(t.GroupInstanceID)
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_LeaveGroup
+type leaveGroupRequestV3 struct {
+	GroupID string
+	Members []leaveGroupRequestMemberV3
+}
+
+func (t leaveGroupRequestV3) size() int32 {
+	return sizeofString(t.GroupID) +
+		sizeofArray(len(t.Members), func(i int) int32 { return t.Members[i].size() })
+}
+
+func (t leaveGroupRequestV3) writeTo(wb *writeBuffer) {
+	wb.writeString(t.GroupID)
+	wb.writeArray(len(t.Members), func(i int) { t.Members[i].writeTo(wb) })
+}
+
+type leaveGroupResponseMemberV3 struct {
+	MemberID        string
+	GroupInstanceID *string
+	ErrorCode       int16
+}
+
+func (t leaveGroupResponseMemberV3) size() int32 {
+	return sizeofString(t.MemberID) +
+		sizeofNullableString(t.GroupInstanceID) +
+		sizeofInt16(t.ErrorCode)
+}
+
+func (t leaveGroupResponseMemberV3) writeTo(wb *writeBuffer) {
+	wb.writeString(t.MemberID)
+	wb.writeNullableString(t.GroupInstanceID)
+	wb.writeInt16(t.ErrorCode)
+}
+
+func (t *leaveGroupResponseMemberV3) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.MemberID); err != nil {
+		return
+	}
+	if remain, err = readNullableString(r, remain, &t.GroupInstanceID); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_LeaveGroup
+type leaveGroupResponseV3 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	Members        []leaveGroupResponseMemberV3
+}
+
+func (t leaveGroupResponseV3) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofArray(len(t.Members), func(i int) int32 { return t.Members[i].size() })
+}
+
+func (t leaveGroupResponseV3) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeArray(len(t.Members), func(i int) { t.Members[i].writeTo(wb) })
+}
+
+func (t *leaveGroupResponseV3) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var member leaveGroupResponseMemberV3
+		if fnRemain, fnErr = (&member).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Members = append(t.Members, member)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
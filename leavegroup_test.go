@@ -31,3 +31,38 @@ func TestLeaveGroupResponseV0(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestLeaveGroupResponseV3(t *testing.T) {
+	instanceID := "b"
+
+	item := leaveGroupResponseV3{
+		ThrottleTimeMS: 1,
+		Members: []leaveGroupResponseMemberV3{
+			{MemberID: "a"},
+			{GroupInstanceID: &instanceID, ErrorCode: int16(UnknownMemberId)},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found leaveGroupResponseV3
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
@@ -2,8 +2,187 @@ package kafka
 
 import (
 	"bufio"
+	"context"
+	"sort"
+	"sync"
+	"time"
 )
 
+// ListGroupsRequest represents a request sent to a kafka cluster to list the
+// consumer groups that exist on the cluster.
+type ListGroupsRequest struct {
+	// States optionally restricts the response to groups in one of the listed
+	// states (e.g. "Stable", "Empty"). Filtering by state requires kafka 2.6
+	// (KIP-518), brokers of older versions report UnsupportedVersion.
+	States []string
+}
+
+// ListGroupsResponse represents the response to a ListGroupsRequest.
+type ListGroupsResponse struct {
+	// Groups holds the groups found on the cluster, sorted by group ID.
+	Groups []ListGroupsResponseGroup
+
+	// Errors holds the errors that occurred while listing the groups of
+	// individual brokers, indexed by broker ID. The groups of those brokers
+	// are missing from the response.
+	Errors map[int]error
+}
+
+// ListGroupsResponseGroup describes a group listed by a ListGroupsRequest.
+type ListGroupsResponseGroup struct {
+	GroupID      string
+	ProtocolType string
+
+	// GroupState is the state of the group, it is only known when the broker
+	// runs kafka 2.6 or above and is empty otherwise.
+	GroupState string
+
+	// Coordinator is the ID of the broker coordinating the group.
+	Coordinator int
+}
+
+// ListGroups lists the groups of the kafka cluster. Groups are spread across
+// the brokers that coordinate them, the request is sent to every broker and
+// the results are merged.
+//
+// Errors that occur on a single broker are reported in the Errors field of the
+// response and do not cause the method to fail.
+func (c *Client) ListGroups(ctx context.Context, req ListGroupsRequest) (*ListGroupsResponse, error) {
+	brokers, err := c.brokerList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		broker Broker
+		groups []ListGroupsResponseGroup
+		err    error
+	}
+
+	results := make([]result, len(brokers))
+	wg := sync.WaitGroup{}
+
+	for i, b := range brokers {
+		results[i].broker = b
+		wg.Add(1)
+		go func(r *result) {
+			defer wg.Done()
+			r.groups, r.err = c.listBrokerGroups(ctx, r.broker, req.States)
+		}(&results[i])
+	}
+
+	wg.Wait()
+
+	res := &ListGroupsResponse{}
+	seen := make(map[string]struct{})
+
+	for _, r := range results {
+		if r.err != nil {
+			if res.Errors == nil {
+				res.Errors = make(map[int]error)
+			}
+			res.Errors[r.broker.ID] = r.err
+			continue
+		}
+		for _, g := range r.groups {
+			if _, ok := seen[g.GroupID]; ok {
+				continue
+			}
+			seen[g.GroupID] = struct{}{}
+			res.Groups = append(res.Groups, g)
+		}
+	}
+
+	sort.Slice(res.Groups, func(i, j int) bool {
+		return res.Groups[i].GroupID < res.Groups[j].GroupID
+	})
+
+	return res, nil
+}
+
+// listBrokerGroups lists the groups coordinated by the broker b, using the
+// most recent version of the API that the broker supports.
+func (c *Client) listBrokerGroups(ctx context.Context, b Broker, states []string) ([]ListGroupsResponseGroup, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	version, err := conn.negotiateVersion(listGroups, v1, v4)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []ListGroupsResponseGroup
+
+	switch version {
+	case v4:
+		response, err := conn.listGroupsV4(listGroupsRequestV4{StatesFilter: states})
+		if err != nil {
+			return nil, err
+		}
+		groups = make([]ListGroupsResponseGroup, len(response.Groups))
+		for i, g := range response.Groups {
+			groups[i] = ListGroupsResponseGroup{
+				GroupID:      g.GroupID,
+				ProtocolType: g.ProtocolType,
+				GroupState:   g.GroupState,
+				Coordinator:  b.ID,
+			}
+		}
+
+	default:
+		if len(states) != 0 {
+			return nil, UnsupportedVersion
+		}
+		response, err := conn.listGroups(listGroupsRequestV1{})
+		if err != nil {
+			return nil, err
+		}
+		groups = make([]ListGroupsResponseGroup, len(response.Groups))
+		for i, g := range response.Groups {
+			groups[i] = ListGroupsResponseGroup{
+				GroupID:      g.GroupID,
+				ProtocolType: g.ProtocolType,
+				Coordinator:  b.ID,
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// listGroupsV4 lists the consumer groups coordinated by the broker, optionally
+// filtered by state.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_ListGroups
+func (c *Conn) listGroupsV4(request listGroupsRequestV4) (listGroupsResponseV4, error) {
+	var response listGroupsResponseV4
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(listGroups, v4, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return listGroupsResponseV4{}, err
+	}
+	if response.ErrorCode != 0 {
+		return listGroupsResponseV4{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
 type listGroupsRequestV1 struct {
 }
 
@@ -84,3 +263,102 @@ func (t *listGroupsResponseV1) readFrom(r *bufio.Reader, size int) (remain int,
 
 	return
 }
+
+// See http://kafka.apache.org/protocol.html#The_Messages_ListGroups
+type listGroupsRequestV4 struct {
+	// StatesFilter holds the states of the groups to list, all groups are
+	// listed when it is empty.
+	StatesFilter []string
+}
+
+func (t listGroupsRequestV4) size() int32 {
+	return sizeofCompactStringArray(t.StatesFilter) +
+		sizeofTaggedFields()
+}
+
+func (t listGroupsRequestV4) writeTo(wb *writeBuffer) {
+	wb.writeCompactStringArray(t.StatesFilter)
+	wb.writeTaggedFields()
+}
+
+type listGroupsResponseGroupV4 struct {
+	GroupID      string
+	ProtocolType string
+	GroupState   string
+}
+
+func (t listGroupsResponseGroupV4) size() int32 {
+	return sizeofCompactString(t.GroupID) +
+		sizeofCompactString(t.ProtocolType) +
+		sizeofCompactString(t.GroupState) +
+		sizeofTaggedFields()
+}
+
+func (t listGroupsResponseGroupV4) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.GroupID)
+	wb.writeCompactString(t.ProtocolType)
+	wb.writeCompactString(t.GroupState)
+	wb.writeTaggedFields()
+}
+
+func (t *listGroupsResponseGroupV4) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.GroupID); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ProtocolType); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.GroupState); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_ListGroups
+type listGroupsResponseV4 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	Groups         []listGroupsResponseGroupV4
+}
+
+func (t listGroupsResponseV4) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactArray(len(t.Groups), func(i int) int32 { return t.Groups[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t listGroupsResponseV4) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactArray(len(t.Groups), func(i int) { t.Groups[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *listGroupsResponseV4) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var item listGroupsResponseGroupV4
+		if fnRemain, fnErr = (&item).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Groups = append(t.Groups, item)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
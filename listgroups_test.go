@@ -3,8 +3,10 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestListGroupsResponseV1(t *testing.T) {
@@ -37,3 +39,102 @@ func TestListGroupsResponseV1(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestListGroupsResponseV4(t *testing.T) {
+	item := listGroupsResponseV4{
+		ThrottleTimeMS: 1,
+		Groups: []listGroupsResponseGroupV4{
+			{
+				GroupID:      "a",
+				ProtocolType: "consumer",
+				GroupState:   "Stable",
+			},
+			{
+				GroupID:      "b",
+				ProtocolType: "consumer",
+				GroupState:   "Empty",
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		t.FailNow()
+	}
+
+	var found listGroupsResponseV4
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestListGroupsRequestV4(t *testing.T) {
+	item := listGroupsRequestV4{StatesFilter: []string{"Stable"}}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	// compact array of 1 element, compact string of 6 bytes, no tagged fields
+	expected := []byte{2, 7, 'S', 't', 'a', 'b', 'l', 'e', 0}
+	if !bytes.Equal(expected, b.Bytes()) {
+		t.Errorf("expected %v, got %v", expected, b.Bytes())
+	}
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+}
+
+func testClientListGroups(t *testing.T, ctx context.Context, c *Client) {
+	topic := makeTopic()
+	groupID := makeGroupID()
+	createTopic(t, topic, 1)
+
+	r := NewReader(ReaderConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   topic,
+		GroupID: groupID,
+		MaxWait: 100 * time.Millisecond,
+	})
+	defer r.Close()
+
+	// Joining the group happens in the background, wait for the coordinator
+	// to know about it.
+	for {
+		res, err := c.ListGroups(ctx, ListGroupsRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for broker, err := range res.Errors {
+			t.Fatalf("listing the groups of broker %d failed: %v", broker, err)
+		}
+		for _, g := range res.Groups {
+			if g.GroupID == groupID {
+				if g.ProtocolType != "consumer" {
+					t.Errorf("expected protocol type consumer, got %q", g.ProtocolType)
+				}
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("group %s not found: %v", groupID, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -16,6 +16,24 @@ func (r listOffsetRequestV1) writeTo(wb *writeBuffer) {
 	wb.writeArray(len(r.Topics), func(i int) { r.Topics[i].writeTo(wb) })
 }
 
+// listOffsetRequestV2 adds the isolation level to listOffsetRequestV1. With
+// ReadCommitted, the last offset of a partition is its last stable offset.
+type listOffsetRequestV2 struct {
+	ReplicaID      int32
+	IsolationLevel int8
+	Topics         []listOffsetRequestTopicV1
+}
+
+func (r listOffsetRequestV2) size() int32 {
+	return 4 + 1 + sizeofArray(len(r.Topics), func(i int) int32 { return r.Topics[i].size() })
+}
+
+func (r listOffsetRequestV2) writeTo(wb *writeBuffer) {
+	wb.writeInt32(r.ReplicaID)
+	wb.writeInt8(r.IsolationLevel)
+	wb.writeArray(len(r.Topics), func(i int) { r.Topics[i].writeTo(wb) })
+}
+
 type listOffsetRequestTopicV1 struct {
 	TopicName  string
 	Partitions []listOffsetRequestPartitionV1
@@ -55,6 +73,28 @@ func (r listOffsetResponseV1) writeTo(wb *writeBuffer) {
 	wb.writeArray(len(r), func(i int) { r[i].writeTo(wb) })
 }
 
+// listOffsetResponseV2 is listOffsetResponseV1 preceded by the throttle time.
+type listOffsetResponseV2 struct {
+	ThrottleTimeMS int32
+	Topics         listOffsetResponseV1
+}
+
+func (r listOffsetResponseV2) size() int32 {
+	return 4 + r.Topics.size()
+}
+
+func (r listOffsetResponseV2) writeTo(wb *writeBuffer) {
+	wb.writeInt32(r.ThrottleTimeMS)
+	r.Topics.writeTo(wb)
+}
+
+func (r *listOffsetResponseV2) readFrom(rd *bufio.Reader, sz int) (remain int, err error) {
+	if remain, err = readInt32(rd, sz, &r.ThrottleTimeMS); err != nil {
+		return
+	}
+	return (&r.Topics).readFrom(rd, remain)
+}
+
 type listOffsetResponseTopicV1 struct {
 	TopicName        string
 	PartitionOffsets []partitionOffsetV1
@@ -103,3 +143,50 @@ func (p *partitionOffsetV1) readFrom(r *bufio.Reader, sz int) (remain int, err e
 	}
 	return
 }
+
+// add appends a partition to the request, topics are expected to be added in
+// order.
+func (r *listOffsetRequestV1) add(topic string, partition int32, time int64) {
+	if n := len(r.Topics); n == 0 || r.Topics[n-1].TopicName != topic {
+		r.Topics = append(r.Topics, listOffsetRequestTopicV1{TopicName: topic})
+	}
+	last := &r.Topics[len(r.Topics)-1]
+	last.Partitions = append(last.Partitions, listOffsetRequestPartitionV1{
+		Partition: partition,
+		Time:      time,
+	})
+}
+
+func (r *listOffsetResponseV1) readFrom(rd *bufio.Reader, sz int) (remain int, err error) {
+	fn := func(rd *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic listOffsetResponseTopicV1
+		if fnRemain, fnErr = (&topic).readFrom(rd, size); fnErr != nil {
+			return
+		}
+		*r = append(*r, topic)
+		return
+	}
+	if remain, err = readArrayWith(rd, sz, fn); err != nil {
+		return
+	}
+	return
+}
+
+func (t *listOffsetResponseTopicV1) readFrom(r *bufio.Reader, sz int) (remain int, err error) {
+	if remain, err = readString(r, sz, &t.TopicName); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var p partitionOffsetV1
+		if fnRemain, fnErr = (&p).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.PartitionOffsets = append(t.PartitionOffsets, p)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
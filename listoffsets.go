@@ -0,0 +1,203 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OffsetRequest designates a partition and the offset to look up in it.
+type OffsetRequest struct {
+	Partition int
+
+	// Timestamp is FirstOffset, LastOffset, or a timestamp in milliseconds,
+	// in which case the earliest offset whose timestamp is greater than or
+	// equal to it is returned.
+	Timestamp int64
+}
+
+// FirstOffsetOf constructs an OffsetRequest which asks for the first offset of
+// the partition given as argument.
+func FirstOffsetOf(partition int) OffsetRequest {
+	return OffsetRequest{Partition: partition, Timestamp: FirstOffset}
+}
+
+// LastOffsetOf constructs an OffsetRequest which asks for the last offset of
+// the partition given as argument.
+func LastOffsetOf(partition int) OffsetRequest {
+	return OffsetRequest{Partition: partition, Timestamp: LastOffset}
+}
+
+// TimeOffsetOf constructs an OffsetRequest which asks for the offset of the
+// first message written to the partition at or after the time given as
+// argument.
+func TimeOffsetOf(partition int, at time.Time) OffsetRequest {
+	return OffsetRequest{Partition: partition, Timestamp: timestamp(at)}
+}
+
+// ListOffsetsRequest represents a request sent to a kafka cluster to look up
+// offsets of partitions.
+type ListOffsetsRequest struct {
+	// Topics holds the offsets to look up, indexed by topic name.
+	Topics map[string][]OffsetRequest
+}
+
+// ListOffsetsResponse represents the response to a ListOffsetsRequest.
+type ListOffsetsResponse struct {
+	// Topics holds the offsets found in each partition, indexed by topic
+	// name and sorted by partition.
+	Topics map[string][]ListOffsetsResponsePartition
+}
+
+// ListOffsetsResponsePartition carries the offset found in a partition.
+type ListOffsetsResponsePartition struct {
+	Partition int
+
+	// Offset is the offset found in the partition, it is -1 when looking up a
+	// timestamp later than the one of the last message of the partition.
+	Offset int64
+
+	// Timestamp is the time of the message at Offset when looking up a
+	// timestamp, it is the zero time when looking up the first or last
+	// offset.
+	Timestamp time.Time
+
+	// Error is set to a non-nil value if the offset could not be looked up.
+	Error error
+}
+
+// ListOffsets looks up offsets of partitions. Partitions are grouped by
+// leader, the requests to different leaders are sent concurrently. Looking up
+// offsets by timestamp requires kafka 0.10.1 or above.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) ListOffsets(ctx context.Context, req ListOffsetsRequest) (*ListOffsetsResponse, error) {
+	topics := make([]string, 0, len(req.Topics))
+	for topic := range req.Topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	leaders, err := c.partitionLeaders(ctx, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListOffsetsResponse{
+		Topics: make(map[string][]ListOffsetsResponsePartition, len(req.Topics)),
+	}
+
+	requests := make(map[Broker]*listOffsetRequestV1)
+
+	for _, topic := range topics {
+		for _, p := range req.Topics[topic] {
+			leader, ok := leaders[topicPartition{topic: topic, partition: p.Partition}]
+			if !ok {
+				res.Topics[topic] = append(res.Topics[topic], ListOffsetsResponsePartition{
+					Partition: p.Partition,
+					Offset:    -1,
+					Error:     LeaderNotAvailable,
+				})
+				continue
+			}
+
+			request := requests[leader]
+			if request == nil {
+				request = &listOffsetRequestV1{ReplicaID: -1}
+				requests[leader] = request
+			}
+			request.add(topic, int32(p.Partition), p.Timestamp)
+		}
+	}
+
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for b, request := range requests {
+		wg.Add(1)
+		go func(b Broker, request listOffsetRequestV1) {
+			defer wg.Done()
+
+			response, err := c.listLeaderOffsets(ctx, b, request)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				for _, t := range request.Topics {
+					for _, p := range t.Partitions {
+						res.Topics[t.TopicName] = append(res.Topics[t.TopicName], ListOffsetsResponsePartition{
+							Partition: int(p.Partition),
+							Offset:    -1,
+							Error:     err,
+						})
+					}
+				}
+				return
+			}
+
+			for _, t := range response {
+				for _, p := range t.PartitionOffsets {
+					partition := ListOffsetsResponsePartition{
+						Partition: int(p.Partition),
+						Offset:    p.Offset,
+					}
+					if p.Timestamp >= 0 {
+						partition.Timestamp = timestampToTime(p.Timestamp)
+					}
+					if p.ErrorCode != 0 {
+						partition.Error = Error(p.ErrorCode)
+					}
+					res.Topics[t.TopicName] = append(res.Topics[t.TopicName], partition)
+				}
+			}
+		}(b, *request)
+	}
+
+	wg.Wait()
+
+	for _, partitions := range res.Topics {
+		sort.Slice(partitions, func(i, j int) bool {
+			return partitions[i].Partition < partitions[j].Partition
+		})
+	}
+
+	return res, nil
+}
+
+// listLeaderOffsets looks up the offsets of partitions led by the broker b.
+func (c *Client) listLeaderOffsets(ctx context.Context, b Broker, request listOffsetRequestV1) (listOffsetResponseV1, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.listOffsets(request)
+}
+
+// listOffsets looks up the offsets of the requested partitions, the broker
+// must be the leader of the partitions. Unlike readOffset, errors on
+// partitions do not cause the method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_ListOffsets
+func (c *Conn) listOffsets(request listOffsetRequestV1) (listOffsetResponseV1, error) {
+	var response listOffsetResponseV1
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(listOffsets, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,147 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListOffsetResponseV1(t *testing.T) {
+	item := listOffsetResponseV1{
+		{
+			TopicName: "a",
+			PartitionOffsets: []partitionOffsetV1{
+				{
+					Partition: 1,
+					Timestamp: -1,
+					Offset:    2,
+				},
+				{
+					Partition: 3,
+					ErrorCode: int16(NotLeaderForPartition),
+					Timestamp: -1,
+					Offset:    -1,
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found listOffsetResponseV1
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestListOffsetRequestV1Add(t *testing.T) {
+	request := listOffsetRequestV1{ReplicaID: -1}
+	request.add("a", 0, FirstOffset)
+	request.add("a", 1, LastOffset)
+	request.add("b", 0, 1234)
+
+	expected := listOffsetRequestV1{
+		ReplicaID: -1,
+		Topics: []listOffsetRequestTopicV1{
+			{
+				TopicName: "a",
+				Partitions: []listOffsetRequestPartitionV1{
+					{Partition: 0, Time: FirstOffset},
+					{Partition: 1, Time: LastOffset},
+				},
+			},
+			{
+				TopicName: "b",
+				Partitions: []listOffsetRequestPartitionV1{
+					{Partition: 0, Time: 1234},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(request, expected) {
+		t.Errorf("unexpected request: %+v", request)
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	request.writeTo(w)
+
+	if int32(b.Len()) != request.size() {
+		t.Errorf("expected %d bytes, got %d", request.size(), b.Len())
+	}
+}
+
+func testClientListOffsets(t *testing.T, ctx context.Context, c *Client) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	now := time.Now()
+
+	w := NewWriter(WriterConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     topic,
+		BatchSize: 1,
+	})
+	if err := w.WriteMessages(ctx, makeTestSequence(3)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	res, err := c.ListOffsets(ctx, ListOffsetsRequest{
+		Topics: map[string][]OffsetRequest{
+			topic: {FirstOffsetOf(0)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := res.Topics[topic]
+
+	res, err = c.ListOffsets(ctx, ListOffsetsRequest{
+		Topics: map[string][]OffsetRequest{
+			topic: {LastOffsetOf(0), {Partition: 1, Timestamp: LastOffset}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := res.Topics[topic]
+
+	res, err = c.ListOffsets(ctx, ListOffsetsRequest{
+		Topics: map[string][]OffsetRequest{
+			topic: {TimeOffsetOf(0, now.Add(-time.Minute))},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byTime := res.Topics[topic]
+
+	if len(first) != 1 || first[0].Error != nil || first[0].Offset != 0 {
+		t.Errorf("unexpected first offset: %+v", first)
+	}
+	if len(last) != 2 || last[0].Error != nil || last[0].Offset != 3 {
+		t.Errorf("unexpected last offset: %+v", last)
+	}
+	if len(last) == 2 && last[1].Error != LeaderNotAvailable {
+		t.Errorf("expected %v on a partition that does not exist, got %v", LeaderNotAvailable, last[1].Error)
+	}
+	if len(byTime) != 1 || byTime[0].Error != nil || byTime[0].Offset != 0 || byTime[0].Timestamp.IsZero() {
+		t.Errorf("unexpected offset by time: %+v", byTime)
+	}
+}
@@ -0,0 +1,321 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"time"
+)
+
+// ListPartitionReassignmentsRequest represents a request sent to a kafka
+// cluster to list the reassignments of partitions that are in progress.
+type ListPartitionReassignmentsRequest struct {
+	// Topics optionally restricts the response to the listed partitions,
+	// indexed by topic name. When nil, all the reassignments in progress are
+	// listed.
+	Topics map[string][]int
+}
+
+// ListPartitionReassignmentsResponse represents the response to a
+// ListPartitionReassignmentsRequest.
+type ListPartitionReassignmentsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// controller.
+	Throttle time.Duration
+
+	// Topics holds the partitions being reassigned, indexed by topic name.
+	// Partitions that are not being reassigned are omitted.
+	Topics map[string][]ListPartitionReassignmentsResponsePartition
+}
+
+// ListPartitionReassignmentsResponsePartition describes the reassignment of a
+// partition.
+type ListPartitionReassignmentsResponsePartition struct {
+	Partition int
+
+	// Replicas holds the IDs of the brokers currently hosting the replicas of
+	// the partition.
+	Replicas []int
+
+	// AddingReplicas holds the IDs of the brokers that replicas are being
+	// moved to.
+	AddingReplicas []int
+
+	// RemovingReplicas holds the IDs of the brokers that replicas are being
+	// moved away from.
+	RemovingReplicas []int
+}
+
+// ListPartitionReassignments sends a ListPartitionReassignments request to the
+// controller of the kafka cluster. The API was introduced in kafka 2.4
+// (KIP-455).
+func (c *Client) ListPartitionReassignments(ctx context.Context, req ListPartitionReassignmentsRequest) (*ListPartitionReassignmentsResponse, error) {
+	request := listPartitionReassignmentsRequestV0{}
+
+	if req.Topics != nil {
+		request.Topics = make([]listPartitionReassignmentsRequestTopicV0, 0, len(req.Topics))
+		for topic, partitions := range req.Topics {
+			t := listPartitionReassignmentsRequestTopicV0{
+				Name:             topic,
+				PartitionIndexes: make([]int32, len(partitions)),
+			}
+			for i, p := range partitions {
+				t.PartitionIndexes[i] = int32(p)
+			}
+			request.Topics = append(request.Topics, t)
+		}
+		sort.Slice(request.Topics, func(i, j int) bool {
+			return request.Topics[i].Name < request.Topics[j].Name
+		})
+	}
+
+	conn, err := c.connectController(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.listPartitionReassignments(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListPartitionReassignmentsResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make(map[string][]ListPartitionReassignmentsResponsePartition, len(response.Topics)),
+	}
+
+	for _, t := range response.Topics {
+		partitions := make([]ListPartitionReassignmentsResponsePartition, len(t.Partitions))
+		for i, p := range t.Partitions {
+			partitions[i] = ListPartitionReassignmentsResponsePartition{
+				Partition:        int(p.PartitionIndex),
+				Replicas:         makeBrokerIDs(p.Replicas),
+				AddingReplicas:   makeBrokerIDs(p.AddingReplicas),
+				RemovingReplicas: makeBrokerIDs(p.RemovingReplicas),
+			}
+		}
+		res.Topics[t.Name] = partitions
+	}
+
+	return res, nil
+}
+
+func makeBrokerIDs(ids []int32) []int {
+	brokers := make([]int, len(ids))
+	for i, id := range ids {
+		brokers[i] = int(id)
+	}
+	return brokers
+}
+
+// listPartitionReassignments lists the reassignments in progress, the broker
+// must be the controller of the cluster.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_ListPartitionReassignments
+func (c *Conn) listPartitionReassignments(request listPartitionReassignmentsRequestV0) (listPartitionReassignmentsResponseV0, error) {
+	var response listPartitionReassignmentsResponseV0
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			if request.Timeout == 0 {
+				now := time.Now()
+				deadline = adjustDeadlineForRTT(deadline, now, defaultRTT)
+				request.Timeout = milliseconds(deadlineToTimeout(deadline, now))
+			}
+			return c.writeFlexibleRequest(listPartitionReassignments, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return listPartitionReassignmentsResponseV0{}, err
+	}
+	if response.ErrorCode != 0 {
+		return listPartitionReassignmentsResponseV0{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+type listPartitionReassignmentsRequestTopicV0 struct {
+	Name             string
+	PartitionIndexes []int32
+}
+
+func (t listPartitionReassignmentsRequestTopicV0) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofCompactInt32Array(t.PartitionIndexes) +
+		sizeofTaggedFields()
+}
+
+func (t listPartitionReassignmentsRequestTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeCompactInt32Array(t.PartitionIndexes)
+	wb.writeTaggedFields()
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_ListPartitionReassignments
+type listPartitionReassignmentsRequestV0 struct {
+	// Timeout ms to wait for the response.
+	Timeout int32
+
+	// Topics is nullable, all the reassignments in progress are listed when
+	// it is nil.
+	Topics []listPartitionReassignmentsRequestTopicV0
+}
+
+func (t listPartitionReassignmentsRequestV0) size() int32 {
+	return sizeofInt32(t.Timeout) +
+		sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t listPartitionReassignmentsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.Timeout)
+	if t.Topics == nil {
+		wb.writeCompactArrayLen(-1)
+	} else {
+		wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	}
+	wb.writeTaggedFields()
+}
+
+type listPartitionReassignmentsResponsePartitionV0 struct {
+	PartitionIndex   int32
+	Replicas         []int32
+	AddingReplicas   []int32
+	RemovingReplicas []int32
+}
+
+func (t listPartitionReassignmentsResponsePartitionV0) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofCompactInt32Array(t.Replicas) +
+		sizeofCompactInt32Array(t.AddingReplicas) +
+		sizeofCompactInt32Array(t.RemovingReplicas) +
+		sizeofTaggedFields()
+}
+
+func (t listPartitionReassignmentsResponsePartitionV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeCompactInt32Array(t.Replicas)
+	wb.writeCompactInt32Array(t.AddingReplicas)
+	wb.writeCompactInt32Array(t.RemovingReplicas)
+	wb.writeTaggedFields()
+}
+
+func (t *listPartitionReassignmentsResponsePartitionV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.PartitionIndex); err != nil {
+		return
+	}
+	if remain, err = readCompactInt32Array(r, remain, &t.Replicas); err != nil {
+		return
+	}
+	if remain, err = readCompactInt32Array(r, remain, &t.AddingReplicas); err != nil {
+		return
+	}
+	if remain, err = readCompactInt32Array(r, remain, &t.RemovingReplicas); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+type listPartitionReassignmentsResponseTopicV0 struct {
+	Name       string
+	Partitions []listPartitionReassignmentsResponsePartitionV0
+}
+
+func (t listPartitionReassignmentsResponseTopicV0) size() int32 {
+	return sizeofCompactString(t.Name) +
+		sizeofCompactArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t listPartitionReassignmentsResponseTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.Name)
+	wb.writeCompactArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *listPartitionReassignmentsResponseTopicV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.Name); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition listPartitionReassignmentsResponsePartitionV0
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_ListPartitionReassignments
+type listPartitionReassignmentsResponseV0 struct {
+	ThrottleTimeMS int32
+	ErrorCode      int16
+	ErrorMessage   string
+	Topics         []listPartitionReassignmentsResponseTopicV0
+}
+
+func (t listPartitionReassignmentsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.ErrorMessage) +
+		sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t listPartitionReassignmentsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.ErrorMessage)
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *listPartitionReassignmentsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ErrorMessage); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic listPartitionReassignmentsResponseTopicV0
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,68 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestListPartitionReassignmentsResponseV0(t *testing.T) {
+	item := listPartitionReassignmentsResponseV0{
+		ThrottleTimeMS: 1,
+		Topics: []listPartitionReassignmentsResponseTopicV0{
+			{
+				Name: "a",
+				Partitions: []listPartitionReassignmentsResponsePartitionV0{
+					{
+						PartitionIndex:   0,
+						Replicas:         []int32{1, 2, 3},
+						AddingReplicas:   []int32{3},
+						RemovingReplicas: []int32{1},
+					},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		t.FailNow()
+	}
+
+	var found listPartitionReassignmentsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestListPartitionReassignmentsRequestV0All(t *testing.T) {
+	item := listPartitionReassignmentsRequestV0{Timeout: 1}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	// timeout, null compact array, no tagged fields
+	expected := []byte{0, 0, 0, 1, 0, 0}
+	if !bytes.Equal(expected, b.Bytes()) {
+		t.Errorf("expected %v, got %v", expected, b.Bytes())
+	}
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+}
@@ -0,0 +1,279 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ListTransactionsRequest represents a request sent to a kafka cluster to list
+// the transactions that the coordinators of the cluster keep track of.
+type ListTransactionsRequest struct {
+	// States optionally restricts the response to transactions in one of the
+	// listed states (e.g. "Ongoing", "PrepareCommit").
+	States []string
+
+	// ProducerIDs optionally restricts the response to transactions of the
+	// listed producers.
+	ProducerIDs []int64
+}
+
+// ListTransactionsResponse represents the response to a
+// ListTransactionsRequest.
+type ListTransactionsResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// brokers.
+	Throttle time.Duration
+
+	// Transactions holds the transactions found on the cluster, sorted by
+	// transactional ID.
+	Transactions []ListTransactionsResponseTransaction
+
+	// UnknownStates holds the states of the request that the brokers do not
+	// know about.
+	UnknownStates []string
+
+	// Errors holds the errors that occurred while listing the transactions of
+	// individual brokers, indexed by broker ID. The transactions of those
+	// brokers are missing from the response.
+	Errors map[int]error
+}
+
+// ListTransactionsResponseTransaction describes a transaction listed by a
+// ListTransactionsRequest.
+type ListTransactionsResponseTransaction struct {
+	TransactionalID string
+	ProducerID      int64
+
+	// TransactionState is one of Empty, Ongoing, PrepareCommit, PrepareAbort,
+	// CompleteCommit, CompleteAbort, Dead, or PrepareEpochFence.
+	TransactionState string
+
+	// Coordinator is the ID of the broker coordinating the transaction.
+	Coordinator int
+}
+
+// ListTransactions lists the transactions of the kafka cluster. Transactions
+// are spread across the brokers that coordinate them, the request is sent to
+// every broker and the results are merged. The API was introduced in kafka 3.0
+// (KIP-664).
+//
+// Errors that occur on a single broker are reported in the Errors field of the
+// response and do not cause the method to fail.
+func (c *Client) ListTransactions(ctx context.Context, req ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	brokers, err := c.brokerList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request := listTransactionsRequestV0{
+		StateFilters:      req.States,
+		ProducerIDFilters: req.ProducerIDs,
+	}
+
+	type result struct {
+		broker   Broker
+		response listTransactionsResponseV0
+		err      error
+	}
+
+	results := make([]result, len(brokers))
+	wg := sync.WaitGroup{}
+
+	for i, b := range brokers {
+		results[i].broker = b
+		wg.Add(1)
+		go func(r *result) {
+			defer wg.Done()
+			r.response, r.err = c.listBrokerTransactions(ctx, r.broker, request)
+		}(&results[i])
+	}
+
+	wg.Wait()
+
+	res := &ListTransactionsResponse{}
+	unknownStates := make(map[string]struct{})
+
+	for _, r := range results {
+		if r.err == nil && r.response.ErrorCode != 0 {
+			r.err = Error(r.response.ErrorCode)
+		}
+		if r.err != nil {
+			if res.Errors == nil {
+				res.Errors = make(map[int]error)
+			}
+			res.Errors[r.broker.ID] = r.err
+			continue
+		}
+
+		if throttle := duration(r.response.ThrottleTimeMS); throttle > res.Throttle {
+			res.Throttle = throttle
+		}
+
+		for _, state := range r.response.UnknownStateFilters {
+			if _, ok := unknownStates[state]; !ok {
+				unknownStates[state] = struct{}{}
+				res.UnknownStates = append(res.UnknownStates, state)
+			}
+		}
+
+		for _, t := range r.response.TransactionStates {
+			res.Transactions = append(res.Transactions, ListTransactionsResponseTransaction{
+				TransactionalID:  t.TransactionalID,
+				ProducerID:       t.ProducerID,
+				TransactionState: t.TransactionState,
+				Coordinator:      r.broker.ID,
+			})
+		}
+	}
+
+	sort.Slice(res.Transactions, func(i, j int) bool {
+		return res.Transactions[i].TransactionalID < res.Transactions[j].TransactionalID
+	})
+
+	return res, nil
+}
+
+// listBrokerTransactions lists the transactions coordinated by the broker b.
+func (c *Client) listBrokerTransactions(ctx context.Context, b Broker, request listTransactionsRequestV0) (listTransactionsResponseV0, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return listTransactionsResponseV0{}, err
+	}
+	defer conn.Close()
+	return conn.listTransactions(request)
+}
+
+// listTransactions lists the transactions coordinated by the broker.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_ListTransactions
+func (c *Conn) listTransactions(request listTransactionsRequestV0) (listTransactionsResponseV0, error) {
+	var response listTransactionsResponseV0
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(listTransactions, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return listTransactionsResponseV0{}, err
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_ListTransactions
+type listTransactionsRequestV0 struct {
+	StateFilters      []string
+	ProducerIDFilters []int64
+}
+
+func (t listTransactionsRequestV0) size() int32 {
+	return sizeofCompactStringArray(t.StateFilters) +
+		sizeofCompactArray(len(t.ProducerIDFilters), func(i int) int32 { return sizeofInt64(t.ProducerIDFilters[i]) }) +
+		sizeofTaggedFields()
+}
+
+func (t listTransactionsRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactStringArray(t.StateFilters)
+	wb.writeCompactArray(len(t.ProducerIDFilters), func(i int) { wb.writeInt64(t.ProducerIDFilters[i]) })
+	wb.writeTaggedFields()
+}
+
+type listTransactionsResponseStateV0 struct {
+	TransactionalID  string
+	ProducerID       int64
+	TransactionState string
+}
+
+func (t listTransactionsResponseStateV0) size() int32 {
+	return sizeofCompactString(t.TransactionalID) +
+		sizeofInt64(t.ProducerID) +
+		sizeofCompactString(t.TransactionState) +
+		sizeofTaggedFields()
+}
+
+func (t listTransactionsResponseStateV0) writeTo(wb *writeBuffer) {
+	wb.writeCompactString(t.TransactionalID)
+	wb.writeInt64(t.ProducerID)
+	wb.writeCompactString(t.TransactionState)
+	wb.writeTaggedFields()
+}
+
+func (t *listTransactionsResponseStateV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readCompactString(r, size, &t.TransactionalID); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.ProducerID); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.TransactionState); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_ListTransactions
+type listTransactionsResponseV0 struct {
+	ThrottleTimeMS      int32
+	ErrorCode           int16
+	UnknownStateFilters []string
+	TransactionStates   []listTransactionsResponseStateV0
+}
+
+func (t listTransactionsResponseV0) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofCompactStringArray(t.UnknownStateFilters) +
+		sizeofCompactArray(len(t.TransactionStates), func(i int) int32 { return t.TransactionStates[i].size() }) +
+		sizeofTaggedFields()
+}
+
+func (t listTransactionsResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactStringArray(t.UnknownStateFilters)
+	wb.writeCompactArray(len(t.TransactionStates), func(i int) { t.TransactionStates[i].writeTo(wb) })
+	wb.writeTaggedFields()
+}
+
+func (t *listTransactionsResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactStringArray(r, remain, &t.UnknownStateFilters); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var state listTransactionsResponseStateV0
+		if fnRemain, fnErr = (&state).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.TransactionStates = append(t.TransactionStates, state)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,141 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestListTransactionsResponseV0(t *testing.T) {
+	item := listTransactionsResponseV0{
+		ThrottleTimeMS:      1,
+		UnknownStateFilters: []string{"a"},
+		TransactionStates: []listTransactionsResponseStateV0{
+			{TransactionalID: "b", ProducerID: 2, TransactionState: "Ongoing"},
+			{TransactionalID: "c", ProducerID: 3, TransactionState: "CompleteAbort"},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found listTransactionsResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestListTransactionsRequestV0Size(t *testing.T) {
+	for _, item := range []listTransactionsRequestV0{
+		{},
+		{StateFilters: []string{"Ongoing", "PrepareCommit"}, ProducerIDFilters: []int64{1, 2}},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func testClientListTransactions(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("3.0.0") {
+		t.Skip("listing transactions requires kafka 3.0.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	transactionalID := makeTopic()
+	createTopic(t, topic, 1)
+
+	producer := openTestTransaction(t, ctx, c, topic, transactionalID)
+
+	res, err := c.ListTransactions(ctx, ListTransactionsRequest{
+		States:      []string{"Ongoing", "NotAState"},
+		ProducerIDs: []int64{producer.ProducerID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, err := range res.Errors {
+		t.Errorf("listing the transactions of broker %d failed: %v", id, err)
+	}
+
+	if !reflect.DeepEqual(res.UnknownStates, []string{"NotAState"}) {
+		t.Errorf("expected the unknown states to be [NotAState], got %v", res.UnknownStates)
+	}
+	if len(res.Transactions) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(res.Transactions))
+	}
+
+	txn := res.Transactions[0]
+	if txn.TransactionalID != transactionalID {
+		t.Errorf("expected transactional id %q, got %q", transactionalID, txn.TransactionalID)
+	}
+	if txn.ProducerID != producer.ProducerID {
+		t.Errorf("expected producer id %d, got %d", producer.ProducerID, txn.ProducerID)
+	}
+	if txn.TransactionState != "Ongoing" {
+		t.Errorf("expected the transaction to be ongoing, got %q", txn.TransactionState)
+	}
+}
+
+// openTestTransaction starts a transaction on the partition 0 of topic and
+// produces a message in it, the transaction is left open.
+func openTestTransaction(t *testing.T, ctx context.Context, c *Client, topic, transactionalID string) *InitProducerIDResponse {
+	producer, err := c.InitProducerID(ctx, InitProducerIDRequest{
+		TransactionalID:    transactionalID,
+		TransactionTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.AddPartitionsToTxn(ctx, AddPartitionsToTxnRequest{
+		TransactionalID: transactionalID,
+		ProducerID:      producer.ProducerID,
+		ProducerEpoch:   producer.ProducerEpoch,
+		Topics:          map[string][]int{topic: {0}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	produced, err := c.Produce(ctx, ProduceRequest{
+		Topic:           topic,
+		Partition:       0,
+		Messages:        makeTestSequence(1),
+		TransactionalID: transactionalID,
+		ProducerID:      producer.ProducerID,
+		ProducerEpoch:   producer.ProducerEpoch,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if produced.Error != nil {
+		t.Fatalf("producing in the transaction failed: %v", produced.Error)
+	}
+
+	return producer
+}
@@ -14,12 +14,44 @@ func init() {
 
 const (
 	Code = 3
+
+	DefaultCompressionLevel = 0
 )
 
-type CompressionCodec struct{}
+// The block sizes supported by the lz4 frame format.
+const (
+	BlockSize64KB  = 64 << 10
+	BlockSize256KB = 256 << 10
+	BlockSize1MB   = 1 << 20
+	BlockSize4MB   = 4 << 20
+)
+
+// CompressionCodec is the lz4 codec, the zero value compresses at the default
+// level with blocks of 4MB.
+//
+// The frames written by the codec carry their block size, so they are read by
+// any client regardless of the configuration of the codec, and the codec reads
+// frames of any block size.
+type CompressionCodec struct {
+	// Level is the compression level, 0 is the fastest and higher levels
+	// compress better.
+	Level int
+
+	// BlockSize is the size of the uncompressed blocks of the frames, one of
+	// BlockSize64KB, BlockSize256KB, BlockSize1MB, or BlockSize4MB. Larger
+	// blocks compress large batches better but take more memory. Writing
+	// with another block size fails.
+	BlockSize int
+}
 
 func NewCompressionCodec() *CompressionCodec {
-	return &CompressionCodec{}
+	return NewCompressionCodecLevel(DefaultCompressionLevel)
+}
+
+// NewCompressionCodecLevel returns a codec compressing at the given level, the
+// default level of 0 is the fastest, higher levels compress better.
+func NewCompressionCodecLevel(level int) *CompressionCodec {
+	return &CompressionCodec{Level: level}
 }
 
 // Code implements the kafka.CompressionCodec interface.
@@ -36,9 +68,11 @@ func (CompressionCodec) NewReader(r io.Reader) io.ReadCloser {
 }
 
 // NewWriter implements the kafka.CompressionCodec interface.
-func (CompressionCodec) NewWriter(w io.Writer) io.WriteCloser {
+func (c CompressionCodec) NewWriter(w io.Writer) io.WriteCloser {
 	z := writerPool.Get().(*lz4.Writer)
 	z.Reset(w)
+	z.Header.CompressionLevel = c.Level
+	z.Header.BlockMaxSize = c.BlockSize
 	return &writer{z}
 }
 
@@ -3,8 +3,10 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"sort"
 	"time"
 )
 
@@ -13,12 +15,17 @@ type Message struct {
 	// Topic is reads only and MUST NOT be set when writing messages
 	Topic string
 
-	// Partition is reads only and MUST NOT be set when writing messages
+	// Partition is reads only and MUST NOT be set when writing messages,
+	// unless the Writer is configured with ExplicitPartitions.
 	Partition int
 	Offset    int64
-	Key       []byte
-	Value     []byte
-	Headers   []Header
+
+	// Key and Value are nil for null keys and values, like the values of the
+	// tombstones of compacted topics, and empty but not nil for empty ones.
+	// Writing a message with a nil Key or Value writes a null key or value.
+	Key     []byte
+	Value   []byte
+	Headers []Header
 
 	// If not set at the creation, Time will be automatically set when
 	// writing the message.
@@ -44,6 +51,24 @@ func (msg Message) size() int32 {
 	return 4 + 1 + 1 + sizeofBytes(msg.Key) + sizeofBytes(msg.Value) + timestampSize
 }
 
+// totalSize returns the size of msg written in a record batch of its own, which
+// is what the brokers compare to their message.max.bytes setting.
+func (msg Message) totalSize() int32 {
+	return recordBatchSize(msg)
+}
+
+// recordBytes returns the size of a record batch of batchBytes bytes after msg
+// was added to it, with zero standing for an empty batch. The deltas of the
+// timestamp and offset of the record are not known until the batch is written,
+// so they are counted at their largest size.
+func (msg Message) recordBytes(batchBytes int) int {
+	if batchBytes == 0 {
+		batchBytes = int(recordBatchHeaderSize)
+	}
+	size := recordSize(&msg, 0, 0) + 2*(binary.MaxVarintLen64-1)
+	return batchBytes + size + varIntLen(int64(size))
+}
+
 type message struct {
 	CRC        int32
 	MagicByte  int8
@@ -153,6 +178,15 @@ func (r *messageSetReader) remaining() (remain int) {
 	}
 }
 
+// lastSkippedOffset returns the last offset of the record batches that were
+// skipped by the reader, or -1 if none were.
+func (r *messageSetReader) lastSkippedOffset() int64 {
+	if r.empty || r.version != 2 {
+		return -1
+	}
+	return r.v2.lastSkippedOffset
+}
+
 func (r *messageSetReader) discard() (err error) {
 	if r.empty {
 		return nil
@@ -167,6 +201,35 @@ func (r *messageSetReader) discard() (err error) {
 	}
 }
 
+// brokenBatchError is returned by the message set readers when a record batch
+// which was fully received fails to be decompressed or decoded. Unlike the
+// errors of the connection, fetching the batch again fails the same way, the
+// offsets of the batch let the program skip it.
+type brokenBatchError struct {
+	offset     int64
+	lastOffset int64
+	err        error
+}
+
+func (e *brokenBatchError) Error() string {
+	return fmt.Sprintf("broken record batch at offsets %d to %d: %s", e.offset, e.lastOffset, e.err)
+}
+
+// sourceReader records the error of the reader it wraps, so the errors of a
+// codec decompressing a batch can be told apart from those of the connection.
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (s *sourceReader) Read(b []byte) (int, error) {
+	n, err := s.r.Read(b)
+	if err != nil {
+		s.err = err
+	}
+	return n, err
+}
+
 type messageSetReaderV1 struct {
 	*readerStack
 }
@@ -176,6 +239,19 @@ type readerStack struct {
 	remain int
 	base   int64
 	parent *readerStack
+
+	// buffer holds the decompressed message set that reader reads from, it is
+	// returned to the pool when the reader is popped off the stack.
+	buffer *bytes.Buffer
+}
+
+// pop releases the buffer of the reader and returns its parent. The messages
+// read from the stack copy their keys and values, so they never retain the
+// pooled memory.
+func (s *readerStack) pop() *readerStack {
+	releaseBuffer(s.buffer)
+	s.buffer = nil
+	return s.parent
 }
 
 func newMessageSetReader(reader *bufio.Reader, remain int) (*messageSetReader, error) {
@@ -207,7 +283,8 @@ func newMessageSetReader(reader *bufio.Reader, remain int) (*messageSetReader, e
 					reader: reader,
 					remain: remain,
 				},
-				messageCount: 0,
+				messageCount:      0,
+				lastSkippedOffset: -1,
 			}}
 		return mr, nil
 	default:
@@ -221,7 +298,7 @@ func (r *messageSetReaderV1) readMessage(min int64,
 ) (offset int64, timestamp int64, headers []Header, err error) {
 	for r.readerStack != nil {
 		if r.remain == 0 {
-			r.readerStack = r.parent
+			r.readerStack = r.readerStack.pop()
 			continue
 		}
 
@@ -245,7 +322,7 @@ func (r *messageSetReaderV1) readMessage(min int64,
 			}
 
 			// read and decompress the contained message set.
-			var decompressed bytes.Buffer
+			decompressed := acquireBuffer()
 
 			if r.remain, err = readBytesWith(r.reader, r.remain, func(r *bufio.Reader, sz, n int) (remain int, err error) {
 				// x4 as a guess that the average compression ratio is near 75%
@@ -260,6 +337,7 @@ func (r *messageSetReaderV1) readMessage(min int64,
 				d.Close()
 				return
 			}); err != nil {
+				releaseBuffer(decompressed)
 				return
 			}
 
@@ -271,6 +349,7 @@ func (r *messageSetReaderV1) readMessage(min int64,
 			// offset 13 and the contained messages will be 0,1,2,3.  the base
 			// offset for the container, then is 13-3=10.
 			if offset, err = extractOffset(offset, decompressed.Bytes()); err != nil {
+				releaseBuffer(decompressed)
 				return
 			}
 
@@ -278,10 +357,11 @@ func (r *messageSetReaderV1) readMessage(min int64,
 				// Allocate a buffer of size 0, which gets capped at 16 bytes
 				// by the bufio package. We are already reading buffered data
 				// here, no need to reserve another 4KB buffer.
-				reader: bufio.NewReaderSize(&decompressed, 0),
+				reader: bufio.NewReaderSize(decompressed, 0),
 				remain: decompressed.Len(),
 				base:   offset,
 				parent: r.readerStack,
+				buffer: decompressed,
 			}
 			continue
 		}
@@ -329,7 +409,7 @@ func (r *messageSetReaderV1) discard() (err error) {
 	// actual i/o.  the rest are byte buffers that have been pushed on the stack
 	// while reading compressed message sets.
 	for r.parent != nil {
-		r.readerStack = r.parent
+		r.readerStack = r.readerStack.pop()
 	}
 	r.remain, err = discardN(r.reader, r.remain, r.remain)
 	return
@@ -353,11 +433,63 @@ func extractOffset(base int64, msgSet []byte) (offset int64, err error) {
 	return
 }
 
+// Header is a key and value attached to a message. A message may have several
+// headers with the same key, they are written and read in their order.
 type Header struct {
 	Key   string
 	Value []byte
 }
 
+// Header returns the value of the last header of the message with the given
+// key, and whether the message has one. The last one takes precedence over the
+// previous headers with the same key, like it does with the Java client, the
+// other values can be read from Headers.
+func (msg Message) Header(key string) ([]byte, bool) {
+	for i := len(msg.Headers) - 1; i >= 0; i-- {
+		if msg.Headers[i].Key == key {
+			return msg.Headers[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// SetHeader sets the header of the message with the given key to value. The
+// header replaces all the headers with that key, in the place of the first one,
+// or is added after the other headers if the message has none.
+//
+// SetHeader and DeleteHeader do not modify the headers in place, so the copies
+// of the message which share them are left unchanged.
+func (msg *Message) SetHeader(key string, value []byte) {
+	headers := make([]Header, 0, len(msg.Headers)+1)
+	set := false
+	for _, h := range msg.Headers {
+		if h.Key != key {
+			headers = append(headers, h)
+		} else if !set {
+			headers = append(headers, Header{Key: key, Value: value})
+			set = true
+		}
+	}
+	if !set {
+		headers = append(headers, Header{Key: key, Value: value})
+	}
+	msg.Headers = headers
+}
+
+// DeleteHeader removes all the headers of the message with the given key.
+func (msg *Message) DeleteHeader(key string) {
+	if _, ok := msg.Header(key); !ok {
+		return
+	}
+	headers := make([]Header, 0, len(msg.Headers)-1)
+	for _, h := range msg.Headers {
+		if h.Key != key {
+			headers = append(headers, h)
+		}
+	}
+	msg.Headers = headers
+}
+
 type messageSetHeaderV2 struct {
 	firstOffset          int64
 	length               int32
@@ -394,6 +526,14 @@ const (
 	controlMessage    controlType = 1
 )
 
+func (h *messageSetHeaderV2) brokenBatch(err error) *brokenBatchError {
+	return &brokenBatchError{
+		offset:     h.firstOffset,
+		lastOffset: h.firstOffset + int64(h.lastOffsetDelta),
+		err:        err,
+	}
+}
+
 func (h *messageSetHeaderV2) compression() int8 {
 	return int8(h.batchAttributes & 7)
 }
@@ -415,6 +555,101 @@ type messageSetReaderV2 struct {
 	messageCount int
 
 	header messageSetHeaderV2
+
+	// abortedTransactions holds the transactions aborted in the range of
+	// offsets being read, sorted by first offset. An aborted transaction
+	// is moved to abortedProducers when its first batch is reached, the
+	// batches of the producer are then skipped until its abort marker.
+	abortedTransactions []abortedTransaction
+	abortedProducers    map[int64]struct{}
+
+	// lastSkippedOffset is the last offset of the batches that were skipped
+	// because they were control batches or part of aborted transactions.
+	lastSkippedOffset int64
+
+	// concurrency is the maximum number of batches read ahead of the one
+	// being returned, and decompressed concurrently when they are compressed.
+	// The batches are read one at a time when it is lower than 2.
+	concurrency     int
+	pending         []*pendingBatch
+	readAheadFailed bool
+}
+
+// pendingBatch is a record batch which was read ahead of the records returned
+// by a messageSetReaderV2. done is closed once the records of a compressed
+// batch are decompressed into buffer, it is nil for uncompressed batches whose
+// records were copied to buffer as they were read.
+type pendingBatch struct {
+	header messageSetHeaderV2
+	count  int
+	skip   bool
+	buffer *bytes.Buffer
+	done   chan struct{}
+	err    error
+}
+
+func (p *pendingBatch) decompress(codec CompressionCodec, compressed *bytes.Buffer) {
+	defer close(p.done)
+	defer releaseBuffer(compressed)
+
+	// x4 as a guess that the average compression ratio is near 75%
+	p.buffer = acquireBuffer()
+	p.buffer.Grow(4 * compressed.Len())
+
+	d := codec.NewReader(compressed)
+	_, err := p.buffer.ReadFrom(d)
+	d.Close()
+
+	if err != nil {
+		p.err = p.header.brokenBatch(err)
+	}
+}
+
+// wait blocks until the batch is ready to be read.
+func (p *pendingBatch) wait() {
+	if p.done != nil {
+		<-p.done
+	}
+}
+
+func (r *messageSetReaderV2) setAbortedTransactions(txns []abortedTransaction) {
+	r.abortedTransactions = append([]abortedTransaction(nil), txns...)
+	sort.Slice(r.abortedTransactions, func(i, j int) bool {
+		return r.abortedTransactions[i].FirstOffset < r.abortedTransactions[j].FirstOffset
+	})
+}
+
+// skipBatch returns true if the batch described by the current header must not
+// be returned to the program. The transaction markers of control batches are
+// never exposed, and the records of aborted transactions are only dropped when
+// the fetch response listed the transaction as aborted, which the brokers do
+// for read_committed fetches.
+func (r *messageSetReaderV2) skipBatch() bool {
+	h := &r.header
+	lastOffset := h.firstOffset + int64(h.lastOffsetDelta)
+
+	for len(r.abortedTransactions) != 0 && r.abortedTransactions[0].FirstOffset <= lastOffset {
+		if r.abortedProducers == nil {
+			r.abortedProducers = make(map[int64]struct{})
+		}
+		r.abortedProducers[r.abortedTransactions[0].ProducerID] = struct{}{}
+		r.abortedTransactions = r.abortedTransactions[1:]
+	}
+
+	if h.controlType() == controlMessage {
+		// The control batch holds the marker that ended the transaction of
+		// the producer, its next transactional batches are part of a new
+		// transaction.
+		delete(r.abortedProducers, h.producerId)
+		return true
+	}
+
+	if h.transactionType() == transactional {
+		_, aborted := r.abortedProducers[h.producerId]
+		return aborted
+	}
+
+	return false
 }
 
 func (r *messageSetReaderV2) readHeader() (err error) {
@@ -469,10 +704,17 @@ func (r *messageSetReaderV2) readMessage(min int64,
 	val func(*bufio.Reader, int, int) (int, error),
 ) (offset int64, timestamp int64, headers []Header, err error) {
 
-	if r.messageCount == 0 {
+	for r.messageCount == 0 {
+		if r.concurrency > 1 {
+			if err = r.nextPendingBatch(); err != nil {
+				return
+			}
+			continue
+		}
+
 		if r.remain == 0 {
 			if r.parent != nil {
-				r.readerStack = r.parent
+				r.readerStack = r.readerStack.pop()
 			}
 		}
 
@@ -480,6 +722,15 @@ func (r *messageSetReaderV2) readMessage(min int64,
 			return
 		}
 
+		if r.skipBatch() {
+			if r.remain, err = discardN(r.reader, r.remain, int(r.header.length-49)); err != nil {
+				return
+			}
+			r.messageCount = 0
+			r.lastSkippedOffset = r.header.firstOffset + int64(r.header.lastOffsetDelta)
+			continue
+		}
+
 		if code := r.header.compression(); code != 0 {
 			var codec CompressionCodec
 			if codec, err = resolveCodec(code); err != nil {
@@ -492,10 +743,11 @@ func (r *messageSetReaderV2) readMessage(min int64,
 				return
 			}
 
-			var decompressed bytes.Buffer
+			decompressed := acquireBuffer()
 			decompressed.Grow(4 * batchRemain)
 
-			l := io.LimitedReader{R: r.reader, N: int64(batchRemain)}
+			src := sourceReader{r: r.reader}
+			l := io.LimitedReader{R: &src, N: int64(batchRemain)}
 			d := codec.NewReader(&l)
 
 			_, err = decompressed.ReadFrom(d)
@@ -503,18 +755,34 @@ func (r *messageSetReaderV2) readMessage(min int64,
 			d.Close()
 
 			if err != nil {
+				releaseBuffer(decompressed)
+				if src.err == nil {
+					err = r.header.brokenBatch(err)
+				}
 				return
 			}
 
 			r.readerStack = &readerStack{
-				reader: bufio.NewReaderSize(&decompressed, 0),
+				reader: bufio.NewReaderSize(decompressed, 0),
 				remain: decompressed.Len(),
 				base:   -1, // base is unused here
 				parent: r.readerStack,
+				buffer: decompressed,
 			}
 		}
 	}
 
+	// The records of the batches which were decompressed or read ahead are
+	// decoded from memory, failing to decode them means that the batch is
+	// broken rather than truncated by the broker.
+	if r.parent != nil {
+		defer func() {
+			if err != nil {
+				err = r.header.brokenBatch(err)
+			}
+		}()
+	}
+
 	var length int64
 	if r.remain, err = readVarInt(r.reader, r.remain, &length); err != nil {
 		return
@@ -577,7 +845,7 @@ func (r *messageSetReaderV2) readMessageHeader(header *Header) (err error) {
 	if r.remain, err = readVarInt(r.reader, r.remain, &valLen); err != nil {
 		return
 	}
-	if header.Value, r.remain, err = readNewBytes(r.reader, r.remain, int(valLen)); err != nil {
+	if header.Value, r.remain, err = readNewNullableBytes(r.reader, r.remain, int(valLen)); err != nil {
 		return
 	}
 	return nil
@@ -587,7 +855,122 @@ func (r *messageSetReaderV2) remaining() (remain int) {
 	return r.remain
 }
 
+// nextPendingBatch moves the reader to the next batch read ahead of the records
+// returned so far, the records are returned in offset order regardless of the
+// order in which the batches finish decompressing.
+func (r *messageSetReaderV2) nextPendingBatch() error {
+	if r.parent != nil {
+		r.readerStack = r.readerStack.pop()
+	}
+	r.readAhead()
+
+	if len(r.pending) == 0 {
+		return errShortRead
+	}
+	p := r.pending[0]
+	r.pending[0] = nil
+	r.pending = r.pending[1:]
+
+	p.wait()
+	if p.err != nil {
+		releaseBuffer(p.buffer)
+		return p.err
+	}
+
+	r.header = p.header
+	if p.skip {
+		r.lastSkippedOffset = p.header.firstOffset + int64(p.header.lastOffsetDelta)
+		return nil
+	}
+
+	r.messageCount = p.count
+	r.readerStack = &readerStack{
+		reader: bufio.NewReaderSize(p.buffer, 0),
+		remain: p.buffer.Len(),
+		base:   -1, // base is unused here
+		parent: r.readerStack,
+		buffer: p.buffer,
+	}
+	return nil
+}
+
+// readAhead reads batches from the top-most reader until concurrency batches
+// are pending, and starts decompressing the compressed ones. The reader stops
+// at the first batch which fails to be read, usually the one that the broker
+// truncated at the end of the response, the error is returned when the batch
+// is reached by nextPendingBatch.
+func (r *messageSetReaderV2) readAhead() {
+	for !r.readAheadFailed && len(r.pending) < r.concurrency && r.remain > 0 {
+		p := &pendingBatch{}
+		r.pending = append(r.pending, p)
+
+		if err := r.readPendingBatch(p); err != nil {
+			p.err, r.readAheadFailed = err, true
+		}
+	}
+}
+
+func (r *messageSetReaderV2) readPendingBatch(p *pendingBatch) (err error) {
+	if err = r.readHeader(); err != nil {
+		return
+	}
+	p.header, p.count = r.header, r.messageCount
+	r.messageCount = 0
+
+	batchRemain := int(p.header.length - 49)
+
+	if r.skipBatch() {
+		p.skip = true
+		r.remain, err = discardN(r.reader, r.remain, batchRemain)
+		return
+	}
+
+	if batchRemain > r.remain {
+		return errShortRead
+	}
+
+	var codec CompressionCodec
+	if code := p.header.compression(); code != 0 {
+		if codec, err = resolveCodec(code); err != nil {
+			return
+		}
+	}
+
+	records := acquireBuffer()
+	n, err := io.CopyN(records, r.reader, int64(batchRemain))
+	r.remain -= int(n)
+	if err != nil {
+		releaseBuffer(records)
+		return
+	}
+
+	if codec == nil {
+		p.buffer = records
+		return
+	}
+	p.done = make(chan struct{})
+	go p.decompress(codec, records)
+	return
+}
+
+// releasePending waits for the batches read ahead to be decompressed and
+// releases their buffers.
+func (r *messageSetReaderV2) releasePending() {
+	for _, p := range r.pending {
+		p.wait()
+		releaseBuffer(p.buffer)
+	}
+	r.pending = nil
+}
+
 func (r *messageSetReaderV2) discard() (err error) {
+	r.releasePending()
+
+	// like in v1, only the top-most reader does i/o, the decompressed batches
+	// pushed on the stack are released to the pool.
+	for r.parent != nil {
+		r.readerStack = r.readerStack.pop()
+	}
 	r.remain, err = discardN(r.reader, r.remain, r.remain)
 	return
 }
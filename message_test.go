@@ -2,7 +2,14 @@ package kafka
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -37,6 +44,141 @@ func TestMessageSetReaderEmpty(t *testing.T) {
 	}
 }
 
+// makeRecordBatchAt returns the encoding of a record batch holding msgs at the
+// given offset, a non-negative producerID stamps the batch with the producer
+// and the batch attributes are combined with attributes.
+func makeRecordBatchAt(t *testing.T, offset int64, producerID int64, attributes int16, msgs ...Message) []byte {
+	return makeCompressedRecordBatchAt(t, nil, offset, producerID, attributes, msgs...)
+}
+
+// makeCompressedRecordBatchAt is like makeRecordBatchAt but compresses the
+// records of the batch with codec.
+func makeCompressedRecordBatchAt(t testing.TB, codec CompressionCodec, offset int64, producerID int64, attributes int16, msgs ...Message) []byte {
+	batch, err := newRecordBatch(codec, msgs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if producerID >= 0 {
+		batch.setProducer(producerID, 0, 0)
+	}
+
+	b := &bytes.Buffer{}
+	batch.writeTo(&writeBuffer{w: b})
+
+	// skip the size of the message set written before the batch
+	data := b.Bytes()[4:]
+	binary.BigEndian.PutUint64(data[0:], uint64(offset))
+	binary.BigEndian.PutUint16(data[21:], binary.BigEndian.Uint16(data[21:])|uint16(attributes))
+	return data
+}
+
+func readMessageSetValues(t *testing.T, data []byte, aborted []abortedTransaction) ([]string, *messageSetReader) {
+	r, err := newMessageSetReader(bufio.NewReader(bytes.NewReader(data)), len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.v2.setAbortedTransactions(aborted)
+
+	var values []string
+	for {
+		var value []byte
+		_, _, _, err := r.readMessage(0,
+			func(r *bufio.Reader, size int, nbytes int) (remain int, err error) {
+				_, remain, err = readNewBytes(r, size, nbytes)
+				return
+			},
+			func(r *bufio.Reader, size int, nbytes int) (remain int, err error) {
+				value, remain, err = readNewBytes(r, size, nbytes)
+				return
+			},
+		)
+		if err == errShortRead {
+			return values, r
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, string(value))
+	}
+}
+
+// controlFlag is the bit set in the attributes of control batches.
+const controlFlag int16 = 1 << 5
+
+func TestMessageSetReaderTransactions(t *testing.T) {
+	now := time.Now()
+	msg := func(value string) Message { return Message{Value: []byte(value), Time: now} }
+
+	var data []byte
+	for _, b := range [][]byte{
+		makeRecordBatchAt(t, 0, -1, 0, msg("a")),
+		makeRecordBatchAt(t, 1, 1, transactionalFlag, msg("b1"), msg("b2")),
+		makeRecordBatchAt(t, 3, 2, transactionalFlag, msg("c")),
+		makeRecordBatchAt(t, 4, 1, transactionalFlag|controlFlag, msg("abort")),
+		makeRecordBatchAt(t, 5, 2, transactionalFlag|controlFlag, msg("commit")),
+		makeRecordBatchAt(t, 6, 1, transactionalFlag, msg("f")),
+		makeRecordBatchAt(t, 7, 1, transactionalFlag|controlFlag, msg("commit")),
+	} {
+		data = append(data, b...)
+	}
+
+	tests := []struct {
+		scenario string
+		aborted  []abortedTransaction
+		values   []string
+	}{
+		{
+			scenario: "read uncommitted skips control records",
+			values:   []string{"a", "b1", "b2", "c", "f"},
+		},
+		{
+			scenario: "read committed skips aborted transactions",
+			aborted:  []abortedTransaction{{ProducerID: 1, FirstOffset: 1}},
+			values:   []string{"a", "c", "f"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			values, r := readMessageSetValues(t, data, test.aborted)
+			if !reflect.DeepEqual(values, test.values) {
+				t.Errorf("expected %q, got %q", test.values, values)
+			}
+			if skipped := r.lastSkippedOffset(); skipped != 7 {
+				t.Errorf("expected last skipped offset 7, got %d", skipped)
+			}
+		})
+	}
+}
+
+func TestBatchSkipsTrailingControlRecords(t *testing.T) {
+	now := time.Now()
+	data := append(
+		makeRecordBatchAt(t, 0, 1, transactionalFlag, Message{Value: []byte("a"), Time: now}),
+		makeRecordBatchAt(t, 1, 1, transactionalFlag|controlFlag, Message{Time: now})...,
+	)
+
+	msgs, err := newMessageSetReader(bufio.NewReader(bytes.NewReader(data)), len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := &Batch{msgs: msgs}
+
+	m, err := batch.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(m.Value) != "a" {
+		t.Errorf("expected message a, got %q", m.Value)
+	}
+	if _, err := batch.ReadMessage(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if offset := batch.Offset(); offset != 2 {
+		t.Errorf("expected the batch to move past the control record to offset 2, got %d", offset)
+	}
+}
+
 func TestMessageSize(t *testing.T) {
 	rand.Seed(time.Now().UnixNano())
 	for i := 0; i < 20; i++ {
@@ -56,6 +198,451 @@ func TestMessageSize(t *testing.T) {
 
 }
 
+func TestMessageRecordBytes(t *testing.T) {
+	msg := Message{Value: []byte("Hello World!")}
+	withHeaders := msg
+	withHeaders.Headers = []Header{{Key: "a", Value: []byte("b")}}
+	if withHeaders.totalSize() <= msg.totalSize() {
+		t.Errorf("expected the headers to be counted in the size of the message, got %d and %d bytes", withHeaders.totalSize(), msg.totalSize())
+	}
+
+	// The size of a batch is estimated before the deltas of its records are
+	// known, the estimate must never be lower than the actual size.
+	t0 := time.Now()
+	msgs := make([]Message, 0, 20)
+	size := 0
+	for i := 0; i < 20; i++ {
+		msg := Message{
+			Key:   make([]byte, rand.Intn(200)),
+			Value: make([]byte, rand.Intn(200)),
+			Time:  t0.Add(time.Duration(rand.Intn(1e6)) * time.Hour),
+		}
+		msgs, size = append(msgs, msg), msg.recordBytes(size)
+
+		if actual := int(recordBatchSize(msgs...)); size < actual {
+			t.Fatalf("the size of a batch of %d messages was estimated to %d bytes, but it has %d", len(msgs), size, actual)
+		}
+	}
+}
+
+// gzipTestCodec is a gzip codec using a code which is not taken by the codecs of
+// the sub-packages, which this package's tests cannot import.
+type gzipTestCodec struct{}
+
+func (gzipTestCodec) Code() int8   { return 6 }
+func (gzipTestCodec) Name() string { return "gzip-test" }
+
+func (gzipTestCodec) NewReader(r io.Reader) io.ReadCloser {
+	z, err := gzip.NewReader(r)
+	if err != nil {
+		return ioutil.NopCloser(&failingReader{err: err})
+	}
+	return z
+}
+
+func (gzipTestCodec) NewWriter(w io.Writer) io.WriteCloser {
+	return gzip.NewWriter(w)
+}
+
+// makeCompressedRecordBatches returns the encoding of n record batches of count
+// messages each, compressed with gzipTestCodec.
+func makeCompressedRecordBatches(tb testing.TB, n, count int) []byte {
+	RegisterCompressionCodec(gzipTestCodec{})
+
+	var data []byte
+	for i := 0; i < n; i++ {
+		msgs := make([]Message, count)
+		for j := range msgs {
+			msgs[j] = Message{Value: []byte(compressedTestValue(i, j)), Time: time.Now()}
+		}
+
+		data = append(data, makeCompressedRecordBatchAt(tb, gzipTestCodec{}, int64(i*count), -1, 0, msgs...)...)
+	}
+	return data
+}
+
+// compressedTestValue returns the value of message j in batch i, padded to about
+// 1KB so the decompressed batches outgrow their initial buffer.
+func compressedTestValue(i, j int) string {
+	return fmt.Sprintf("value-%d-%d-%01000d", i, j, 0)
+}
+
+func TestMessageSetReaderPooledBuffers(t *testing.T) {
+	data := makeCompressedRecordBatches(t, 3, 10)
+
+	// The decompressed batches are released to the pool when the reader moves
+	// to the next one, the values read before must not be overwritten.
+	values, _ := readMessageSetValues(t, data, nil)
+	if len(values) != 30 {
+		t.Fatalf("expected 30 values, got %d", len(values))
+	}
+	makeCompressedRecordBatches(t, 3, 10)
+
+	for i, value := range values {
+		if expected := compressedTestValue(i/10, i%10); value != expected {
+			t.Errorf("value %d: expected %q, got %q", i, expected, value)
+		}
+	}
+
+	// Discarding a reader in the middle of a compressed batch releases the
+	// batch and discards the rest of the message set.
+	r, err := newMessageSetReader(bufio.NewReader(bytes.NewReader(data)), len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := r.readMessage(0, discardTestBytes, discardTestBytes); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.discard(); err != nil {
+		t.Fatal(err)
+	}
+	if r.v2.parent != nil || r.v2.buffer != nil {
+		t.Error("expected the decompressed batch to be popped off the stack")
+	}
+	if remain := r.remaining(); remain != 0 {
+		t.Errorf("expected the message set to be discarded, %d bytes remain", remain)
+	}
+}
+
+func discardTestBytes(r *bufio.Reader, size int, nbytes int) (int, error) {
+	if nbytes < 0 {
+		return size, nil
+	}
+	return discardN(r, size, nbytes)
+}
+
+func TestMessageSetReaderParallelDecompression(t *testing.T) {
+	RegisterCompressionCodec(gzipTestCodec{})
+
+	now := time.Now()
+	msgs := func(prefix string, n int) []Message {
+		msgs := make([]Message, n)
+		for i := range msgs {
+			msgs[i] = Message{Value: []byte(fmt.Sprintf("%s%d", prefix, i)), Time: now}
+		}
+		return msgs
+	}
+	codec := gzipTestCodec{}
+
+	var data []byte
+	for _, b := range [][]byte{
+		makeCompressedRecordBatchAt(t, codec, 0, -1, 0, msgs("a", 10)...),
+		makeCompressedRecordBatchAt(t, nil, 10, -1, 0, msgs("b", 2)...),
+		makeCompressedRecordBatchAt(t, codec, 12, 1, transactionalFlag, msgs("c", 3)...),
+		makeCompressedRecordBatchAt(t, codec, 15, 1, transactionalFlag|controlFlag, msgs("abort", 1)...),
+		makeCompressedRecordBatchAt(t, codec, 16, -1, 0, msgs("d", 5)...),
+		makeCompressedRecordBatchAt(t, codec, 21, -1, 0, msgs("e", 1)...),
+	} {
+		data = append(data, b...)
+	}
+	// The broker truncates the last batch of the response at MaxBytes.
+	truncated := makeCompressedRecordBatchAt(t, codec, 22, -1, 0, msgs("f", 10)...)
+	data = append(data, truncated[:len(truncated)-10]...)
+
+	expected := []string{}
+	for _, m := range append(append(append(msgs("a", 10), msgs("b", 2)...), msgs("d", 5)...), msgs("e", 1)...) {
+		expected = append(expected, string(m.Value))
+	}
+	aborted := []abortedTransaction{{ProducerID: 1, FirstOffset: 12}}
+
+	newBatch := func(concurrency int) *Batch {
+		r, err := newMessageSetReader(bufio.NewReader(bytes.NewReader(data)), len(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.v2.setAbortedTransactions(aborted)
+		r.v2.concurrency = concurrency
+		return &Batch{msgs: r}
+	}
+
+	for _, concurrency := range []int{0, 2, 3, 16} {
+		t.Run(fmt.Sprintf("concurrency=%d", concurrency), func(t *testing.T) {
+			batch := newBatch(concurrency)
+
+			var values []string
+			for {
+				m, err := batch.ReadMessage()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				values = append(values, string(m.Value))
+			}
+			if !reflect.DeepEqual(values, expected) {
+				t.Errorf("expected %q, got %q", expected, values)
+			}
+			if offset := batch.Offset(); offset != 22 {
+				t.Errorf("expected the batch to stop at the truncated batch at offset 22, got %d", offset)
+			}
+		})
+	}
+
+	t.Run("close", func(t *testing.T) {
+		// The batches after the first one are read ahead, closing the batch
+		// must release them without moving the offset past the batches which
+		// were skipped ahead of the messages returned.
+		batch := newBatch(4)
+		for i := 0; i < 3; i++ {
+			if _, err := batch.ReadMessage(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := batch.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if offset := batch.Offset(); offset != 3 {
+			t.Errorf("expected the batch to be at offset 3, got %d", offset)
+		}
+		if len(batch.msgs.v2.pending) != 0 {
+			t.Errorf("expected the pending batches to be released, %d remain", len(batch.msgs.v2.pending))
+		}
+		if remain := batch.msgs.remaining(); remain != 0 {
+			t.Errorf("expected the message set to be discarded, %d bytes remain", remain)
+		}
+	})
+}
+
+func TestMessageSetReaderNullValues(t *testing.T) {
+	now := time.Now()
+	msgs := []Message{
+		{Key: []byte("tombstone"), Value: nil, Time: now},
+		{Key: []byte("empty"), Value: []byte{}, Time: now},
+		{Key: nil, Value: []byte("a"), Time: now, Headers: []Header{{Key: "null"}, {Key: "empty", Value: []byte{}}}},
+	}
+
+	v1 := &bytes.Buffer{}
+	for i, msg := range msgs {
+		item := messageSetItem{Offset: int64(i), Message: msg.message(nil)}
+		item.MessageSize = item.Message.size()
+		item.writeTo(&writeBuffer{w: v1})
+	}
+
+	for _, test := range []struct {
+		version string
+		data    []byte
+	}{
+		{version: "v1", data: v1.Bytes()},
+		{version: "v2", data: makeRecordBatchAt(t, 0, -1, 0, msgs...)},
+	} {
+		t.Run(test.version, func(t *testing.T) {
+			r, err := newMessageSetReader(bufio.NewReader(bytes.NewReader(test.data)), len(test.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			batch := &Batch{msgs: r}
+
+			for _, expected := range msgs {
+				m, err := batch.ReadMessage()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if (m.Key == nil) != (expected.Key == nil) || (m.Value == nil) != (expected.Value == nil) {
+					t.Errorf("expected key %#v and value %#v, got %#v and %#v", expected.Key, expected.Value, m.Key, m.Value)
+				}
+				if test.version == "v1" {
+					continue
+				}
+				for i, h := range expected.Headers {
+					if (m.Headers[i].Value == nil) != (h.Value == nil) {
+						t.Errorf("header %q: expected value %#v, got %#v", h.Key, h.Value, m.Headers[i].Value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMessageHeaders(t *testing.T) {
+	msg := Message{Headers: []Header{
+		{Key: "a", Value: []byte("1")},
+		{Key: "b", Value: []byte("2")},
+		{Key: "a", Value: []byte("3")},
+	}}
+	original := msg
+
+	if v, ok := msg.Header("a"); !ok || string(v) != "3" {
+		t.Errorf("expected the last value of the header to be returned, got %q (%t)", v, ok)
+	}
+	if v, ok := msg.Header("c"); ok || v != nil {
+		t.Errorf("expected no value for a missing header, got %q (%t)", v, ok)
+	}
+
+	msg.SetHeader("c", []byte("4"))
+	msg.SetHeader("a", []byte("5"))
+	expected := []Header{
+		{Key: "a", Value: []byte("5")},
+		{Key: "b", Value: []byte("2")},
+		{Key: "c", Value: []byte("4")},
+	}
+	if !reflect.DeepEqual(expected, msg.Headers) {
+		t.Errorf("expected headers %+v after setting them, got %+v", expected, msg.Headers)
+	}
+
+	msg.DeleteHeader("a")
+	msg.DeleteHeader("d")
+	expected = expected[1:]
+	if !reflect.DeepEqual(expected, msg.Headers) {
+		t.Errorf("expected headers %+v after deleting one, got %+v", expected, msg.Headers)
+	}
+
+	if v, _ := original.Header("a"); len(original.Headers) != 3 || string(v) != "3" {
+		t.Errorf("the headers of the copy of the message were modified: %+v", original.Headers)
+	}
+}
+
+func TestMessageSetReaderHeaders(t *testing.T) {
+	RegisterCompressionCodec(gzipTestCodec{})
+
+	now := time.Now()
+	headers := []Header{
+		{Key: "trace", Value: []byte("1")},
+		{Key: "hop", Value: []byte("a")},
+		{Key: "hop", Value: []byte("b")},
+		{Key: "hop", Value: []byte("c")},
+	}
+	msgs := []Message{
+		{Value: []byte("0"), Time: now, Headers: headers},
+		{Value: []byte("1"), Time: now},
+		{Value: []byte("2"), Time: now, Headers: headers[1:]},
+	}
+
+	for _, codec := range []CompressionCodec{nil, gzipTestCodec{}} {
+		name := "uncompressed"
+		if codec != nil {
+			name = codec.Name()
+		}
+		t.Run(name, func(t *testing.T) {
+			data := makeCompressedRecordBatchAt(t, codec, 0, -1, 0, msgs...)
+
+			r, err := newMessageSetReader(bufio.NewReader(bytes.NewReader(data)), len(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			batch := &Batch{msgs: r}
+			fetched, err := readRecordSet("topic", 0, 0, data, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, expected := range msgs {
+				m, err := batch.ReadMessage()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(headerPairs(expected.Headers), headerPairs(m.Headers)) {
+					t.Errorf("message %d: expected headers %+v, got %+v", i, expected.Headers, m.Headers)
+				}
+				if !reflect.DeepEqual(headerPairs(expected.Headers), headerPairs(fetched[i].Headers)) {
+					t.Errorf("message %d: expected fetched headers %+v, got %+v", i, expected.Headers, fetched[i].Headers)
+				}
+			}
+		})
+	}
+}
+
+// headerPairs returns the keys and values of headers as strings, so headers
+// read as empty slices compare equal to those written as nil ones.
+func headerPairs(headers []Header) []string {
+	var pairs []string
+	for _, h := range headers {
+		pairs = append(pairs, h.Key+"="+string(h.Value))
+	}
+	return pairs
+}
+
+func TestMessageSetReaderBrokenBatch(t *testing.T) {
+	RegisterCompressionCodec(gzipTestCodec{})
+
+	now := time.Now()
+	msgs := func(n int) []Message {
+		msgs := make([]Message, n)
+		for i := range msgs {
+			msgs[i] = Message{Value: []byte(fmt.Sprintf("%d", i)), Time: now}
+		}
+		return msgs
+	}
+	codec := gzipTestCodec{}
+
+	// The checksum of the second batch is corrupted, it fails to be
+	// decompressed after being received in full.
+	broken := makeCompressedRecordBatchAt(t, codec, 2, -1, 0, msgs(3)...)
+	broken[len(broken)-8] ^= 0xff
+
+	var data []byte
+	data = append(data, makeCompressedRecordBatchAt(t, codec, 0, -1, 0, msgs(2)...)...)
+	data = append(data, broken...)
+	data = append(data, makeCompressedRecordBatchAt(t, codec, 5, -1, 0, msgs(1)...)...)
+
+	for _, concurrency := range []int{0, 2} {
+		t.Run(fmt.Sprintf("concurrency=%d", concurrency), func(t *testing.T) {
+			r, err := newMessageSetReader(bufio.NewReader(bytes.NewReader(data)), len(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.v2.concurrency = concurrency
+			batch := &Batch{msgs: r}
+
+			for i := 0; i < 2; i++ {
+				if _, err := batch.ReadMessage(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err = batch.ReadMessage()
+			e, ok := err.(*brokenBatchError)
+			if !ok {
+				t.Fatalf("expected a broken batch error, got %v", err)
+			}
+			if e.offset != 2 || e.lastOffset != 4 {
+				t.Errorf("expected the broken batch to span offsets 2 to 4, got %d to %d", e.offset, e.lastOffset)
+			}
+			if offset := batch.Offset(); offset != 2 {
+				t.Errorf("expected the batch to stop at the broken batch at offset 2, got %d", offset)
+			}
+		})
+	}
+}
+
+// BenchmarkMessageSetReaderCompressed reads one record of compressed batches
+// per iteration, the allocations per op are the allocations per fetched record.
+func BenchmarkMessageSetReaderCompressed(b *testing.B) {
+	data := makeCompressedRecordBatches(b, 10, 100)
+	input := bytes.NewReader(data)
+	br := bufio.NewReader(input)
+
+	var r *messageSetReader
+	var value []byte
+	val := func(r *bufio.Reader, size int, nbytes int) (remain int, err error) {
+		value, remain, err = readNewBytes(r, size, nbytes)
+		return
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if r == nil {
+			input.Reset(data)
+			br.Reset(input)
+			var err error
+			if r, err = newMessageSetReader(br, len(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+		switch _, _, _, err := r.readMessage(0, discardTestBytes, val); err {
+		case nil:
+		case errShortRead:
+			r = nil
+		default:
+			b.Fatal(err)
+		}
+	}
+
+	_ = value
+}
+
 // https://stackoverflow.com/questions/43495745/how-to-generate-random-date-in-go-lang/43497333#43497333
 func randate() time.Time {
 	min := time.Date(1970, 1, 0, 0, 0, 0, 0, time.UTC).Unix()
@@ -1,5 +1,12 @@
 package kafka
 
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"time"
+)
+
 type topicMetadataRequestV1 []string
 
 func (r topicMetadataRequestV1) size() int32 {
@@ -92,3 +99,449 @@ func (p partitionMetadataV1) writeTo(wb *writeBuffer) {
 	wb.writeInt32Array(p.Replicas)
 	wb.writeInt32Array(p.Isr)
 }
+
+// TopicID is the unique identifier that kafka 2.8 and above assign to topics
+// (KIP-516). Unlike its name, the ID of a topic changes when the topic is
+// deleted and created again.
+type TopicID [16]byte
+
+// String returns the representation of the ID used by the kafka tools, which
+// is the URL safe base64 encoding of its bytes.
+func (id TopicID) String() string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+// MetadataRequest represents a request sent to a kafka cluster to retrieve
+// the metadata of its brokers and topics.
+type MetadataRequest struct {
+	// Topics holds the names of the topics to retrieve the metadata of. When
+	// nil, the metadata of all the topics of the cluster is returned.
+	Topics []string
+
+	// When IncludeClusterAuthorizedOperations is true, the response carries
+	// the operations that the client is authorized to perform on the cluster.
+	IncludeClusterAuthorizedOperations bool
+
+	// When IncludeTopicAuthorizedOperations is true, the response carries the
+	// operations that the client is authorized to perform on each topic.
+	IncludeTopicAuthorizedOperations bool
+}
+
+// MetadataResponse represents the response to a MetadataRequest.
+type MetadataResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// ClusterID is the unique identifier of the cluster.
+	ClusterID string
+
+	// Controller is the broker acting as controller of the cluster.
+	Controller Broker
+
+	// Brokers holds the brokers of the cluster.
+	Brokers []Broker
+
+	// Topics holds the metadata of the topics, in the order returned by the
+	// broker.
+	Topics []MetadataTopic
+
+	// ClusterAuthorizedOperations is a bit field of the ACL operations that
+	// the client is authorized to perform on the cluster, where bit N is set
+	// if the operation of code N is allowed. It is only set when the request
+	// had IncludeClusterAuthorizedOperations set to true.
+	ClusterAuthorizedOperations int32
+}
+
+// MetadataTopic carries the metadata of a topic.
+type MetadataTopic struct {
+	Name string
+
+	// ID is the unique identifier of the topic.
+	ID TopicID
+
+	// Internal is true for the topics that kafka uses internally, like the
+	// topic holding the offsets committed by consumer groups.
+	Internal bool
+
+	// Partitions holds the partitions of the topic, the leader of partitions
+	// that currently have no leader is the zero value of Broker. Partitions
+	// that have offline replicas are under-replicated.
+	Partitions []Partition
+
+	// TopicAuthorizedOperations is a bit field of the ACL operations that the
+	// client is authorized to perform on the topic, with the same layout as
+	// MetadataResponse.ClusterAuthorizedOperations. It is only set when the
+	// request had IncludeTopicAuthorizedOperations set to true.
+	TopicAuthorizedOperations int32
+
+	// Error is set to a non-nil value if the metadata of the topic could not
+	// be retrieved, for example UnknownTopicOrPartition.
+	Error error
+}
+
+// Metadata retrieves the metadata of the brokers and topics of the cluster,
+// including the IDs of the topics. Unlike Conn.ReadPartitions, the request
+// does not create the topics that do not exist. The method requires kafka 2.8
+// or above.
+//
+// Errors that apply to a single topic are reported on the topic and do not
+// cause the method to fail.
+func (c *Client) Metadata(ctx context.Context, req MetadataRequest) (*MetadataResponse, error) {
+	request := metadataRequestV10{
+		IncludeClusterAuthorizedOperations: req.IncludeClusterAuthorizedOperations,
+		IncludeTopicAuthorizedOperations:   req.IncludeTopicAuthorizedOperations,
+	}
+	if req.Topics != nil {
+		request.Topics = make([]metadataRequestTopicV10, len(req.Topics))
+		for i, topic := range req.Topics {
+			request.Topics[i] = metadataRequestTopicV10{Name: topic}
+		}
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.metadataV10(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MetadataResponse{
+		Throttle:                    duration(response.ThrottleTimeMS),
+		ClusterID:                   response.ClusterID,
+		Controller:                  Broker{ID: int(response.ControllerID)},
+		Brokers:                     make([]Broker, len(response.Brokers)),
+		Topics:                      make([]MetadataTopic, len(response.Topics)),
+		ClusterAuthorizedOperations: response.ClusterAuthorizedOperations,
+	}
+
+	brokers := make(map[int32]Broker, len(response.Brokers))
+	for i, b := range response.Brokers {
+		res.Brokers[i] = Broker{
+			Host: b.Host,
+			Port: int(b.Port),
+			ID:   int(b.BrokerID),
+			Rack: b.Rack,
+		}
+		brokers[b.BrokerID] = res.Brokers[i]
+		if b.BrokerID == response.ControllerID {
+			res.Controller = res.Brokers[i]
+		}
+	}
+
+	makeBrokers := func(ids []int32) []Broker {
+		b := make([]Broker, len(ids))
+		for i, id := range ids {
+			b[i] = brokers[id]
+		}
+		return b
+	}
+
+	for i, t := range response.Topics {
+		topic := MetadataTopic{
+			Name:                      t.Name,
+			ID:                        t.TopicID,
+			Internal:                  t.IsInternal,
+			Partitions:                make([]Partition, len(t.Partitions)),
+			TopicAuthorizedOperations: t.TopicAuthorizedOperations,
+		}
+		if t.ErrorCode != 0 {
+			topic.Error = Error(t.ErrorCode)
+		}
+		for j, p := range t.Partitions {
+			topic.Partitions[j] = Partition{
+				Topic:           t.Name,
+				Leader:          brokers[p.LeaderID],
+				Replicas:        makeBrokers(p.ReplicaNodes),
+				Isr:             makeBrokers(p.IsrNodes),
+				ID:              int(p.PartitionIndex),
+				LeaderEpoch:     int(p.LeaderEpoch),
+				OfflineReplicas: makeBrokers(p.OfflineReplicas),
+			}
+		}
+		res.Topics[i] = topic
+	}
+
+	return res, nil
+}
+
+// metadataV10 retrieves the metadata of the cluster, including the IDs of the
+// topics. Errors on topics do not cause the method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_Metadata
+func (c *Conn) metadataV10(request metadataRequestV10) (metadataResponseV10, error) {
+	var response metadataResponseV10
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeFlexibleRequest(metadata, v10, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if remain, err = c.readFlexibleResponseHeader(size); err != nil {
+					return
+				}
+				return (&response).readFrom(&c.rbuf, remain)
+			}())
+		},
+	)
+	if err != nil {
+		return metadataResponseV10{}, err
+	}
+
+	return response, nil
+}
+
+type metadataRequestTopicV10 struct {
+	TopicID [16]byte
+	Name    string
+}
+
+func (t metadataRequestTopicV10) size() int32 {
+	return sizeofUUID(t.TopicID) +
+		sizeofCompactString(t.Name) +
+		sizeofTaggedFields()
+}
+
+func (t metadataRequestTopicV10) writeTo(wb *writeBuffer) {
+	wb.writeUUID(t.TopicID)
+	wb.writeCompactString(t.Name)
+	wb.writeTaggedFields()
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_Metadata
+type metadataRequestV10 struct {
+	// Topics is encoded as a null array when nil, which the broker interprets
+	// as a request for all topics.
+	Topics                             []metadataRequestTopicV10
+	AllowAutoTopicCreation             bool
+	IncludeClusterAuthorizedOperations bool
+	IncludeTopicAuthorizedOperations   bool
+}
+
+func (t metadataRequestV10) size() int32 {
+	n := int32(1)
+	if t.Topics != nil {
+		n = sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+	}
+	return n +
+		sizeofBool(t.AllowAutoTopicCreation) +
+		sizeofBool(t.IncludeClusterAuthorizedOperations) +
+		sizeofBool(t.IncludeTopicAuthorizedOperations) +
+		sizeofTaggedFields()
+}
+
+func (t metadataRequestV10) writeTo(wb *writeBuffer) {
+	if t.Topics == nil {
+		wb.writeUnsignedVarInt(0)
+	} else {
+		wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	}
+	wb.writeBool(t.AllowAutoTopicCreation)
+	wb.writeBool(t.IncludeClusterAuthorizedOperations)
+	wb.writeBool(t.IncludeTopicAuthorizedOperations)
+	wb.writeTaggedFields()
+}
+
+type metadataResponsePartitionV10 struct {
+	ErrorCode       int16
+	PartitionIndex  int32
+	LeaderID        int32
+	LeaderEpoch     int32
+	ReplicaNodes    []int32
+	IsrNodes        []int32
+	OfflineReplicas []int32
+}
+
+func (t metadataResponsePartitionV10) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofInt32(t.PartitionIndex) +
+		sizeofInt32(t.LeaderID) +
+		sizeofInt32(t.LeaderEpoch) +
+		sizeofCompactInt32Array(t.ReplicaNodes) +
+		sizeofCompactInt32Array(t.IsrNodes) +
+		sizeofCompactInt32Array(t.OfflineReplicas) +
+		sizeofTaggedFields()
+}
+
+func (t metadataResponsePartitionV10) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeInt32(t.LeaderID)
+	wb.writeInt32(t.LeaderEpoch)
+	wb.writeCompactInt32Array(t.ReplicaNodes)
+	wb.writeCompactInt32Array(t.IsrNodes)
+	wb.writeCompactInt32Array(t.OfflineReplicas)
+	wb.writeTaggedFields()
+}
+
+func (t *metadataResponsePartitionV10) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.PartitionIndex); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.LeaderID); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.LeaderEpoch); err != nil {
+		return
+	}
+	if remain, err = readCompactInt32Array(r, remain, &t.ReplicaNodes); err != nil {
+		return
+	}
+	if remain, err = readCompactInt32Array(r, remain, &t.IsrNodes); err != nil {
+		return
+	}
+	if remain, err = readCompactInt32Array(r, remain, &t.OfflineReplicas); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+type metadataResponseTopicV10 struct {
+	ErrorCode                 int16
+	Name                      string
+	TopicID                   [16]byte
+	IsInternal                bool
+	Partitions                []metadataResponsePartitionV10
+	TopicAuthorizedOperations int32
+}
+
+func (t metadataResponseTopicV10) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofCompactString(t.Name) +
+		sizeofUUID(t.TopicID) +
+		sizeofBool(t.IsInternal) +
+		sizeofCompactArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() }) +
+		sizeofInt32(t.TopicAuthorizedOperations) +
+		sizeofTaggedFields()
+}
+
+func (t metadataResponseTopicV10) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeCompactString(t.Name)
+	wb.writeUUID(t.TopicID)
+	wb.writeBool(t.IsInternal)
+	wb.writeCompactArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+	wb.writeInt32(t.TopicAuthorizedOperations)
+	wb.writeTaggedFields()
+}
+
+func (t *metadataResponseTopicV10) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.Name); err != nil {
+		return
+	}
+	if remain, err = readUUID(r, remain, &t.TopicID); err != nil {
+		return
+	}
+	if remain, err = readBool(r, remain, &t.IsInternal); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition metadataResponsePartitionV10
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.TopicAuthorizedOperations); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
+
+// The brokers of metadataResponseV10 have the same layout than the brokers of
+// describeClusterResponseV0.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_Metadata
+type metadataResponseV10 struct {
+	ThrottleTimeMS              int32
+	Brokers                     []describeClusterResponseBrokerV0
+	ClusterID                   string
+	ControllerID                int32
+	Topics                      []metadataResponseTopicV10
+	ClusterAuthorizedOperations int32
+}
+
+func (t metadataResponseV10) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofCompactArray(len(t.Brokers), func(i int) int32 { return t.Brokers[i].size() }) +
+		sizeofCompactString(t.ClusterID) +
+		sizeofInt32(t.ControllerID) +
+		sizeofCompactArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofInt32(t.ClusterAuthorizedOperations) +
+		sizeofTaggedFields()
+}
+
+func (t metadataResponseV10) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeCompactArray(len(t.Brokers), func(i int) { t.Brokers[i].writeTo(wb) })
+	wb.writeCompactString(t.ClusterID)
+	wb.writeInt32(t.ControllerID)
+	wb.writeCompactArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeInt32(t.ClusterAuthorizedOperations)
+	wb.writeTaggedFields()
+}
+
+func (t *metadataResponseV10) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	brokerFn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var broker describeClusterResponseBrokerV0
+		if fnRemain, fnErr = (&broker).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Brokers = append(t.Brokers, broker)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, brokerFn); err != nil {
+		return
+	}
+	if remain, err = readCompactString(r, remain, &t.ClusterID); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.ControllerID); err != nil {
+		return
+	}
+
+	topicFn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic metadataResponseTopicV10
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, remain, topicFn); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.ClusterAuthorizedOperations); err != nil {
+		return
+	}
+	if remain, err = readTaggedFields(r, remain); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,155 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestTopicIDString(t *testing.T) {
+	id := TopicID{0xf8, 0xa4, 0x2d, 0x8b, 0x9c, 0x7e, 0x4e, 0x51, 0xb5, 0x6f, 0x11, 0xdc, 0x93, 0x5e, 0x0b, 0xff}
+	if s := id.String(); s != "-KQti5x-TlG1bxHck14L_w" {
+		t.Errorf("unexpected topic id string: %s", s)
+	}
+	if s := (TopicID{}).String(); s != "AAAAAAAAAAAAAAAAAAAAAA" {
+		t.Errorf("unexpected zero topic id string: %s", s)
+	}
+}
+
+func TestMetadataResponseV10(t *testing.T) {
+	item := metadataResponseV10{
+		ThrottleTimeMS: 1,
+		Brokers: []describeClusterResponseBrokerV0{
+			{BrokerID: 1, Host: "a", Port: 9092, Rack: "b"},
+			{BrokerID: 2, Host: "c", Port: 9092},
+		},
+		ClusterID:    "d",
+		ControllerID: 1,
+		Topics: []metadataResponseTopicV10{
+			{
+				Name:    "e",
+				TopicID: [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+				Partitions: []metadataResponsePartitionV10{
+					{PartitionIndex: 0, LeaderID: 1, LeaderEpoch: 2, ReplicaNodes: []int32{1, 2}, IsrNodes: []int32{1}, OfflineReplicas: []int32{2}},
+					{PartitionIndex: 1, LeaderID: -1, LeaderEpoch: -1, ErrorCode: int16(LeaderNotAvailable)},
+				},
+				TopicAuthorizedOperations: -2147483648,
+			},
+			{
+				ErrorCode: int16(UnknownTopicOrPartition),
+				Name:      "f",
+			},
+		},
+		ClusterAuthorizedOperations: -2147483648,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		t.FailNow()
+	}
+
+	var found metadataResponseV10
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestMetadataRequestV10Size(t *testing.T) {
+	for _, item := range []metadataRequestV10{
+		{},
+		{Topics: []metadataRequestTopicV10{}},
+		{Topics: []metadataRequestTopicV10{{Name: "a"}, {Name: "b"}}, IncludeTopicAuthorizedOperations: true},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func testClientMetadata(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.8.0") {
+		t.Skip("topic ids require kafka 2.8.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 2)
+
+	res, err := c.Metadata(ctx, MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.ClusterID == "" {
+		t.Error("empty cluster id")
+	}
+	if res.Controller.Host == "" {
+		t.Errorf("controller %d not found in the list of brokers", res.Controller.ID)
+	}
+	if len(res.Topics) != 1 {
+		t.Fatalf("expected the metadata of 1 topic, got %d", len(res.Topics))
+	}
+
+	m := res.Topics[0]
+	if m.Error != nil {
+		t.Fatalf("retrieving the metadata of topic %s failed: %v", m.Name, m.Error)
+	}
+	if m.Name != topic {
+		t.Errorf("expected the metadata of topic %s, got %s", topic, m.Name)
+	}
+	if m.ID == (TopicID{}) {
+		t.Error("the topic id is not set")
+	}
+	if len(m.Partitions) != 2 {
+		t.Errorf("expected 2 partitions, got %d", len(m.Partitions))
+	}
+	for _, p := range m.Partitions {
+		if p.LeaderEpoch < 0 {
+			t.Errorf("expected the leader epoch of partition %d to be set, got %d", p.ID, p.LeaderEpoch)
+		}
+		if len(p.OfflineReplicas) != 0 {
+			t.Errorf("expected partition %d to have no offline replicas, got %v", p.ID, p.OfflineReplicas)
+		}
+	}
+	if res.ClusterAuthorizedOperations != math.MinInt32 || m.TopicAuthorizedOperations != math.MinInt32 {
+		t.Errorf("expected the authorized operations to be omitted, got %d and %d", res.ClusterAuthorizedOperations, m.TopicAuthorizedOperations)
+	}
+
+	res, err = c.Metadata(ctx, MetadataRequest{
+		Topics:                             []string{topic},
+		IncludeClusterAuthorizedOperations: true,
+		IncludeTopicAuthorizedOperations:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ClusterAuthorizedOperations == math.MinInt32 {
+		t.Error("the cluster authorized operations are not set")
+	}
+	if len(res.Topics) != 1 || res.Topics[0].TopicAuthorizedOperations == math.MinInt32 {
+		t.Error("the topic authorized operations are not set")
+	}
+}
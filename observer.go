@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// Observer is the interface implemented by types that receive measurements of
+// the requests sent by a Client, for example to export them as metrics.
+//
+// ObserveRequest is called once the response to a request was read, or the
+// request failed, from the goroutine that sent the request. It may be called
+// concurrently by different goroutines and should return quickly, any slow
+// processing delays the method of the Client which sent the request.
+type Observer interface {
+	ObserveRequest(RequestObservation)
+}
+
+// ObserverFunc is a bridge between Observer and plain functions, which allows
+// setting ClientConfig.Observer to a function.
+type ObserverFunc func(RequestObservation)
+
+func (f ObserverFunc) ObserveRequest(o RequestObservation) { f(o) }
+
+// RequestObservation carries the measurements of a request sent to a broker.
+type RequestObservation struct {
+	// ApiKey and ApiVersion identify the API and version of the request, and
+	// ApiName is the name of the API, for example "Metadata".
+	ApiKey     int16
+	ApiVersion int16
+	ApiName    string
+
+	// Broker is the address of the broker that the request was sent to.
+	Broker string
+
+	// RequestSize and ResponseSize are the sizes of the request and response
+	// in bytes, including their size prefix. ResponseSize is zero if no
+	// response was received.
+	RequestSize  int
+	ResponseSize int
+
+	// Duration is the time from sending the request to reading its response,
+	// or to the failure of the request. The response to a fetch request is
+	// considered read once its header was, since the records are read
+	// later on.
+	Duration time.Duration
+
+	// Error is set to a non-nil value if the request failed, it includes the
+	// errors of the kafka protocol returned as the error of the request.
+	Error error
+}
+
+// requestObserver measures a request sent on a connection with an observer,
+// its methods are no-ops on a nil receiver. The API key, API version, and size
+// of requests are captured from the first bytes written, which every request
+// starts with.
+type requestObserver struct {
+	conn         *Conn
+	start        time.Time
+	w            io.Writer
+	header       [8]byte
+	n            int
+	responseSize int
+}
+
+// observeRequest returns a requestObserver measuring the next request sent on
+// the connection, or nil if the connection has no observer.
+func (c *Conn) observeRequest() *requestObserver {
+	if c.observer == nil {
+		return nil
+	}
+	return &requestObserver{conn: c, start: time.Now()}
+}
+
+// write wraps a function writing a request to capture its header.
+func (o *requestObserver) write(write func(time.Time, int32) error) func(time.Time, int32) error {
+	if o == nil {
+		return write
+	}
+	return func(deadline time.Time, id int32) error {
+		// The write lock of the connection is held while writing requests.
+		o.w, o.conn.wb.w = o.conn.wb.w, o
+		defer func() { o.conn.wb.w = o.w }()
+		return write(deadline, id)
+	}
+}
+
+// read wraps a function reading a response to capture its size.
+func (o *requestObserver) read(read func(time.Time, int) error) func(time.Time, int) error {
+	if o == nil {
+		return read
+	}
+	return func(deadline time.Time, size int) error {
+		o.received(size)
+		return read(deadline, size)
+	}
+}
+
+// received records the size of a response, given as the number of bytes left
+// after its size and correlation ID.
+func (o *requestObserver) received(size int) {
+	if o != nil {
+		o.responseSize = size + 8
+	}
+}
+
+func (o *requestObserver) Write(b []byte) (int, error) {
+	o.n += copy(o.header[o.n:], b)
+	return o.w.Write(b)
+}
+
+func (o *requestObserver) Flush() error {
+	if x, ok := o.w.(interface{ Flush() error }); ok {
+		return x.Flush()
+	}
+	return nil
+}
+
+// observe reports the request to the observer of the connection.
+func (o *requestObserver) observe(err error) {
+	if o == nil {
+		return
+	}
+
+	observation := RequestObservation{
+		ApiKey:       -1,
+		ApiVersion:   -1,
+		Broker:       o.conn.observerAddr,
+		ResponseSize: o.responseSize,
+		Duration:     time.Since(o.start),
+		Error:        err,
+	}
+
+	if o.n == len(o.header) {
+		observation.RequestSize = int(binary.BigEndian.Uint32(o.header[:4])) + 4
+		observation.ApiKey = int16(binary.BigEndian.Uint16(o.header[4:6]))
+		observation.ApiVersion = int16(binary.BigEndian.Uint16(o.header[6:8]))
+		observation.ApiName = apiKey(observation.ApiKey).String()
+	}
+
+	o.conn.observer.ObserveRequest(observation)
+}
@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConnObserver(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	requestSize := make(chan int, 1)
+
+	// The server answers a single ApiVersions request with an empty list of
+	// versions.
+	go func() {
+		var size [4]byte
+		if _, err := io.ReadFull(server, size[:]); err != nil {
+			return
+		}
+		b := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(server, b); err != nil {
+			return
+		}
+		requestSize <- len(b) + 4
+
+		response := make([]byte, 14)
+		binary.BigEndian.PutUint32(response[0:4], 10)
+		copy(response[4:8], b[4:8]) // correlation ID
+		server.Write(response)
+	}()
+
+	var observations []RequestObservation
+
+	conn := NewConn(client, "", 0)
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	conn.observer = ObserverFunc(func(o RequestObservation) { observations = append(observations, o) })
+	conn.observerAddr = "localhost:9092"
+
+	if _, err := conn.ApiVersions(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(observations) != 1 {
+		t.Fatalf("expected 1 observation, got %d", len(observations))
+	}
+
+	o := observations[0]
+	if o.ApiKey != int16(apiVersions) || o.ApiVersion != 0 || o.ApiName != "ApiVersions" {
+		t.Errorf("expected an observation of ApiVersions v0, got %s v%d (key %d)", o.ApiName, o.ApiVersion, o.ApiKey)
+	}
+	if o.Broker != "localhost:9092" {
+		t.Errorf("expected the request to be sent to localhost:9092, got %s", o.Broker)
+	}
+	if size := <-requestSize; o.RequestSize != size {
+		t.Errorf("expected a request of %d bytes, got %d", size, o.RequestSize)
+	}
+	if o.ResponseSize != 14 {
+		t.Errorf("expected a response of 14 bytes, got %d", o.ResponseSize)
+	}
+	if o.Duration <= 0 || o.Error != nil {
+		t.Errorf("expected a successful request with a positive duration, got %v and %v", o.Duration, o.Error)
+	}
+}
+
+func testClientObserver(t *testing.T, ctx context.Context, c *Client) {
+	mutex := sync.Mutex{}
+	observations := []RequestObservation{}
+
+	c = NewClientWith(ClientConfig{
+		Brokers: []string{"localhost:9092"},
+		Observer: ObserverFunc(func(o RequestObservation) {
+			mutex.Lock()
+			observations = append(observations, o)
+			mutex.Unlock()
+		}),
+	})
+
+	if _, err := c.ListGroups(ctx, ListGroupsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	found := false
+	for _, o := range observations {
+		if o.ApiName != "ListGroups" {
+			continue
+		}
+		found = true
+		if o.Error != nil || o.RequestSize <= 0 || o.ResponseSize <= 0 || o.Broker == "" {
+			t.Errorf("unexpected observation of a ListGroups request: %+v", o)
+		}
+	}
+	if !found {
+		t.Errorf("no ListGroups request was observed in %+v", observations)
+	}
+}
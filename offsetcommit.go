@@ -1,6 +1,148 @@
 package kafka
 
-import "bufio"
+import (
+	"bufio"
+	"context"
+	"sort"
+	"time"
+)
+
+// OffsetCommitRequest represents a request sent to a kafka cluster to commit
+// offsets on behalf of a consumer group.
+type OffsetCommitRequest struct {
+	// GroupID is the ID of the group to commit the offsets of.
+	GroupID string
+
+	// GenerationID and MemberID identify the member of the group committing
+	// the offsets. Set GenerationID to -1 and leave MemberID empty to commit
+	// offsets for a group that has no active members, like a simple consumer
+	// would.
+	GenerationID int
+	MemberID     string
+
+	// Topics holds the offsets to commit, indexed by topic name.
+	Topics map[string][]OffsetCommit
+}
+
+// OffsetCommit is an offset committed on a partition.
+type OffsetCommit struct {
+	Partition int
+
+	// Offset is the offset of the next message to consume.
+	Offset int64
+
+	// Metadata is an arbitrary string stored alongside the offset.
+	Metadata string
+}
+
+// OffsetCommitResponse represents the response to an OffsetCommitRequest.
+type OffsetCommitResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+
+	// Topics holds the result of committing the offset of each partition,
+	// indexed by topic name.
+	Topics map[string][]OffsetCommitPartition
+}
+
+// OffsetCommitPartition carries the result of committing the offset of a
+// partition.
+type OffsetCommitPartition struct {
+	Partition int
+
+	// Error is set to a non-nil value if the offset could not be committed,
+	// for example IllegalGeneration if the generation of the group has
+	// changed, or RebalanceInProgress if the group is rebalancing.
+	Error error
+}
+
+// OffsetCommit commits offsets on behalf of a consumer group. The request is
+// sent to the coordinator of the group. The method requires kafka 2.1 or above.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) OffsetCommit(ctx context.Context, req OffsetCommitRequest) (*OffsetCommitResponse, error) {
+	request := offsetCommitRequestV5{
+		GroupID:      req.GroupID,
+		GenerationID: int32(req.GenerationID),
+		MemberID:     req.MemberID,
+		Topics:       make([]offsetCommitRequestV2Topic, 0, len(req.Topics)),
+	}
+
+	for topic, commits := range req.Topics {
+		t := offsetCommitRequestV2Topic{
+			Topic:      topic,
+			Partitions: make([]offsetCommitRequestV2Partition, len(commits)),
+		}
+		for i, commit := range commits {
+			t.Partitions[i] = offsetCommitRequestV2Partition{
+				Partition: int32(commit.Partition),
+				Offset:    commit.Offset,
+				Metadata:  commit.Metadata,
+			}
+		}
+		request.Topics = append(request.Topics, t)
+	}
+
+	sort.Slice(request.Topics, func(i, j int) bool {
+		return request.Topics[i].Topic < request.Topics[j].Topic
+	})
+
+	conn, err := c.groupCoordinator(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.offsetCommitV5(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &OffsetCommitResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make(map[string][]OffsetCommitPartition, len(response.Topics)),
+	}
+
+	for _, t := range response.Topics {
+		partitions := make([]OffsetCommitPartition, len(t.PartitionResponses))
+		for i, p := range t.PartitionResponses {
+			partitions[i] = OffsetCommitPartition{Partition: int(p.Partition)}
+			if p.ErrorCode != 0 {
+				partitions[i].Error = Error(p.ErrorCode)
+			}
+		}
+		res.Topics[t.Topic] = partitions
+	}
+
+	return res, nil
+}
+
+// offsetCommitV5 commits offsets on behalf of a group, the broker must be the
+// coordinator of the group. Unlike offsetCommit, errors on partitions do not
+// cause the method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_OffsetCommit
+func (c *Conn) offsetCommitV5(request offsetCommitRequestV5) (offsetCommitResponseV5, error) {
+	var response offsetCommitResponseV5
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(offsetCommit, v5, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return offsetCommitResponseV5{}, err
+	}
+
+	return response, nil
+}
 
 type offsetCommitRequestV2Partition struct {
 	// Partition ID
@@ -165,3 +307,62 @@ func (t *offsetCommitResponseV2) readFrom(r *bufio.Reader, size int) (remain int
 
 	return
 }
+
+// offsetCommitRequestV5 has the same layout than offsetCommitRequestV2 without
+// the retention time, which is configured by the brokers since kafka 2.1.
+type offsetCommitRequestV5 struct {
+	GroupID      string
+	GenerationID int32
+	MemberID     string
+	Topics       []offsetCommitRequestV2Topic
+}
+
+func (t offsetCommitRequestV5) size() int32 {
+	return sizeofString(t.GroupID) +
+		sizeofInt32(t.GenerationID) +
+		sizeofString(t.MemberID) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t offsetCommitRequestV5) writeTo(wb *writeBuffer) {
+	wb.writeString(t.GroupID)
+	wb.writeInt32(t.GenerationID)
+	wb.writeString(t.MemberID)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
+
+// offsetCommitResponseV5 has the same layout than offsetCommitResponseV2,
+// preceded by the throttle time.
+type offsetCommitResponseV5 struct {
+	ThrottleTimeMS int32
+	Topics         []offsetCommitResponseV2Response
+}
+
+func (t offsetCommitResponseV5) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t offsetCommitResponseV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
+
+func (t *offsetCommitResponseV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic offsetCommitResponseV2Response
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
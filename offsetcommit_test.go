@@ -3,8 +3,11 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
 )
 
 func TestOffsetCommitResponseV2(t *testing.T) {
@@ -41,3 +44,94 @@ func TestOffsetCommitResponseV2(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestOffsetCommitResponseV5(t *testing.T) {
+	item := offsetCommitResponseV5{
+		ThrottleTimeMS: 1,
+		Topics: []offsetCommitResponseV2Response{
+			{
+				Topic: "a",
+				PartitionResponses: []offsetCommitResponseV2PartitionResponse{
+					{Partition: 1},
+					{Partition: 2, ErrorCode: int16(IllegalGeneration)},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found offsetCommitResponseV5
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func testClientOffsetCommit(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.1.0") {
+		t.Skip("offset commit v5 requires kafka 2.1.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	groupID := makeGroupID()
+	createTopic(t, topic, 2)
+
+	commits := []OffsetCommit{
+		{Partition: 0, Offset: 12, Metadata: "checkpoint-a"},
+		{Partition: 1, Offset: 34, Metadata: "checkpoint-b"},
+	}
+
+	res, err := c.OffsetCommit(ctx, OffsetCommitRequest{
+		GroupID:      groupID,
+		GenerationID: -1,
+		Topics:       map[string][]OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range res.Topics[topic] {
+		if p.Error != nil {
+			t.Errorf("committing the offset of partition %d failed: %v", p.Partition, p.Error)
+		}
+	}
+
+	fetched, err := c.OffsetFetch(ctx, OffsetFetchRequest{
+		GroupID: groupID,
+		Topics:  map[string][]int{topic: {0, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range fetched.Topics[topic] {
+		if p.CommittedOffset != commits[i].Offset || p.Metadata != commits[i].Metadata {
+			t.Errorf("expected %+v, got %+v", commits[i], p)
+		}
+	}
+
+	// Commits from members of an unknown generation are rejected.
+	res, err = c.OffsetCommit(ctx, OffsetCommitRequest{
+		GroupID:      groupID,
+		GenerationID: 42,
+		MemberID:     "unknown",
+		Topics:       map[string][]OffsetCommit{topic: commits[:1]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := res.Topics[topic][0]; p.Error == nil {
+		t.Error("expected the commit of an unknown member to fail")
+	}
+}
@@ -0,0 +1,257 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"time"
+)
+
+// OffsetDeleteRequest represents a request sent to a kafka cluster to delete
+// the offsets committed by a consumer group on topic partitions.
+type OffsetDeleteRequest struct {
+	// GroupID is the ID of the group to delete the offsets of.
+	GroupID string
+
+	// Topics holds the partitions to delete the offsets of, indexed by topic
+	// name.
+	Topics map[string][]int
+}
+
+// OffsetDeleteResponse represents the response to an OffsetDeleteRequest.
+type OffsetDeleteResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+
+	// Topics holds the result of deleting the offsets of each partition,
+	// indexed by topic name.
+	Topics map[string][]OffsetDeletePartition
+}
+
+// OffsetDeletePartition carries the result of deleting the offset committed on
+// a partition.
+type OffsetDeletePartition struct {
+	Partition int
+
+	// Error is set to a non-nil value if the offset could not be deleted, for
+	// example GroupSubscribedToTopic if members of the group still consume the
+	// topic.
+	Error error
+}
+
+// OffsetDelete deletes the offsets committed by a consumer group on topic
+// partitions. The request is sent to the coordinator of the group. The API
+// was introduced in kafka 2.4 (KIP-496).
+//
+// Errors that apply to the whole group, such as GroupIdNotFound, cause the
+// method to fail, while errors that apply to a single partition are reported
+// on the partition.
+func (c *Client) OffsetDelete(ctx context.Context, req OffsetDeleteRequest) (*OffsetDeleteResponse, error) {
+	request := offsetDeleteRequestV0{
+		GroupID: req.GroupID,
+		Topics:  make([]offsetDeleteRequestTopicV0, 0, len(req.Topics)),
+	}
+
+	for topic, partitions := range req.Topics {
+		t := offsetDeleteRequestTopicV0{
+			Name:       topic,
+			Partitions: make([]int32, len(partitions)),
+		}
+		for i, p := range partitions {
+			t.Partitions[i] = int32(p)
+		}
+		request.Topics = append(request.Topics, t)
+	}
+
+	sort.Slice(request.Topics, func(i, j int) bool {
+		return request.Topics[i].Name < request.Topics[j].Name
+	})
+
+	conn, err := c.groupCoordinator(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.offsetDelete(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &OffsetDeleteResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make(map[string][]OffsetDeletePartition, len(response.Topics)),
+	}
+
+	for _, t := range response.Topics {
+		partitions := make([]OffsetDeletePartition, len(t.Partitions))
+		for i, p := range t.Partitions {
+			partitions[i] = OffsetDeletePartition{Partition: int(p.PartitionIndex)}
+			if p.ErrorCode != 0 {
+				partitions[i].Error = Error(p.ErrorCode)
+			}
+		}
+		res.Topics[t.Name] = partitions
+	}
+
+	return res, nil
+}
+
+// offsetDelete deletes the offsets committed by a group, the broker must be
+// the coordinator of the group.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_OffsetDelete
+func (c *Conn) offsetDelete(request offsetDeleteRequestV0) (offsetDeleteResponseV0, error) {
+	var response offsetDeleteResponseV0
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(offsetDelete, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return offsetDeleteResponseV0{}, err
+	}
+	if response.ErrorCode != 0 {
+		return offsetDeleteResponseV0{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+type offsetDeleteRequestTopicV0 struct {
+	Name       string
+	Partitions []int32
+}
+
+func (t offsetDeleteRequestTopicV0) size() int32 {
+	return sizeofString(t.Name) +
+		sizeofInt32Array(t.Partitions)
+}
+
+func (t offsetDeleteRequestTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Name)
+	wb.writeInt32Array(t.Partitions)
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_OffsetDelete
+type offsetDeleteRequestV0 struct {
+	GroupID string
+	Topics  []offsetDeleteRequestTopicV0
+}
+
+func (t offsetDeleteRequestV0) size() int32 {
+	return sizeofString(t.GroupID) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t offsetDeleteRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.GroupID)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
+
+type offsetDeleteResponsePartitionV0 struct {
+	PartitionIndex int32
+	ErrorCode      int16
+}
+
+func (t offsetDeleteResponsePartitionV0) size() int32 {
+	return sizeofInt32(t.PartitionIndex) +
+		sizeofInt16(t.ErrorCode)
+}
+
+func (t offsetDeleteResponsePartitionV0) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.PartitionIndex)
+	wb.writeInt16(t.ErrorCode)
+}
+
+func (t *offsetDeleteResponsePartitionV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.PartitionIndex); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	return
+}
+
+type offsetDeleteResponseTopicV0 struct {
+	Name       string
+	Partitions []offsetDeleteResponsePartitionV0
+}
+
+func (t offsetDeleteResponseTopicV0) size() int32 {
+	return sizeofString(t.Name) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t offsetDeleteResponseTopicV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Name)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+func (t *offsetDeleteResponseTopicV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.Name); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition offsetDeleteResponsePartitionV0
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_OffsetDelete
+type offsetDeleteResponseV0 struct {
+	ErrorCode      int16
+	ThrottleTimeMS int32
+	Topics         []offsetDeleteResponseTopicV0
+}
+
+func (t offsetDeleteResponseV0) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t offsetDeleteResponseV0) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
+
+func (t *offsetDeleteResponseV0) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic offsetDeleteResponseTopicV0
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
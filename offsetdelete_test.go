@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestOffsetDeleteResponseV0(t *testing.T) {
+	item := offsetDeleteResponseV0{
+		ThrottleTimeMS: 1,
+		Topics: []offsetDeleteResponseTopicV0{
+			{
+				Name: "a",
+				Partitions: []offsetDeleteResponsePartitionV0{
+					{PartitionIndex: 0},
+					{PartitionIndex: 1, ErrorCode: int16(GroupSubscribedToTopic)},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found offsetDeleteResponseV0
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func testClientOffsetDelete(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.4.0") {
+		t.Skip("offset delete requires kafka 2.4.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	groupID := makeGroupID()
+	createTopic(t, topic, 2)
+
+	w := NewWriter(WriterConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     topic,
+		Balancer:  &RoundRobin{},
+		BatchSize: 1,
+	})
+	if err := w.WriteMessages(ctx, makeTestSequence(2)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	r := NewReader(ReaderConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   topic,
+		GroupID: groupID,
+		MaxWait: 100 * time.Millisecond,
+	})
+	for i := 0; i < 2; i++ {
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			r.Close()
+			t.Fatal(err)
+		}
+		if err := r.CommitMessages(ctx, m); err != nil {
+			r.Close()
+			t.Fatal(err)
+		}
+	}
+	r.Close()
+
+	res, err := c.OffsetDelete(ctx, OffsetDeleteRequest{
+		GroupID: groupID,
+		Topics:  map[string][]int{topic: {0, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range res.Topics[topic] {
+		if p.Error != nil {
+			t.Errorf("deleting the offset of partition %d failed: %v", p.Partition, p.Error)
+		}
+	}
+	if n := len(res.Topics[topic]); n != 2 {
+		t.Errorf("expected results for 2 partitions, got %d", n)
+	}
+}
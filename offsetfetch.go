@@ -2,8 +2,145 @@ package kafka
 
 import (
 	"bufio"
+	"context"
+	"sort"
+	"time"
 )
 
+// OffsetFetchRequest represents a request sent to a kafka cluster to retrieve
+// the offsets committed by a consumer group.
+type OffsetFetchRequest struct {
+	// GroupID is the ID of the group to fetch the offsets of.
+	GroupID string
+
+	// Topics holds the partitions to fetch the offsets of, indexed by topic
+	// name. The offsets of all the partitions that the group has committed
+	// offsets for are returned when it is nil.
+	Topics map[string][]int
+}
+
+// OffsetFetchResponse represents the response to an OffsetFetchRequest.
+type OffsetFetchResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+
+	// Topics holds the offsets committed on each partition, indexed by topic
+	// name.
+	Topics map[string][]OffsetFetchPartition
+}
+
+// OffsetFetchPartition carries the offset committed by a group on a
+// partition.
+type OffsetFetchPartition struct {
+	Partition int
+
+	// CommittedOffset is the offset committed by the group, it is -1 when
+	// the group has no committed offset on the partition.
+	CommittedOffset int64
+
+	// LeaderEpoch is the leader epoch of the last consumed record, it is -1
+	// when it is unknown.
+	LeaderEpoch int
+
+	// Metadata is the metadata string committed alongside the offset.
+	Metadata string
+
+	// Error is set to a non-nil value if the offset could not be fetched.
+	Error error
+}
+
+// OffsetFetch retrieves the offsets committed by a consumer group. The request
+// is sent to the coordinator of the group. The method requires kafka 2.1 or
+// above, which exposes the leader epoch of committed offsets (KIP-320).
+//
+// Errors that apply to the whole group cause the method to fail, while errors
+// that apply to a single partition are reported on the partition.
+func (c *Client) OffsetFetch(ctx context.Context, req OffsetFetchRequest) (*OffsetFetchResponse, error) {
+	request := offsetFetchRequestV5{
+		GroupID: req.GroupID,
+	}
+
+	if req.Topics != nil {
+		request.Topics = make([]offsetFetchRequestV1Topic, 0, len(req.Topics))
+		for topic, partitions := range req.Topics {
+			t := offsetFetchRequestV1Topic{
+				Topic:      topic,
+				Partitions: make([]int32, len(partitions)),
+			}
+			for i, p := range partitions {
+				t.Partitions[i] = int32(p)
+			}
+			request.Topics = append(request.Topics, t)
+		}
+		sort.Slice(request.Topics, func(i, j int) bool {
+			return request.Topics[i].Topic < request.Topics[j].Topic
+		})
+	}
+
+	conn, err := c.groupCoordinator(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.offsetFetchV5(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &OffsetFetchResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make(map[string][]OffsetFetchPartition, len(response.Topics)),
+	}
+
+	for _, t := range response.Topics {
+		partitions := make([]OffsetFetchPartition, len(t.Partitions))
+		for i, p := range t.Partitions {
+			partitions[i] = OffsetFetchPartition{
+				Partition:       int(p.Partition),
+				CommittedOffset: p.CommittedOffset,
+				LeaderEpoch:     int(p.CommittedLeaderEpoch),
+				Metadata:        p.Metadata,
+			}
+			if p.ErrorCode != 0 {
+				partitions[i].Error = Error(p.ErrorCode)
+			}
+		}
+		res.Topics[t.Topic] = partitions
+	}
+
+	return res, nil
+}
+
+// offsetFetchV5 fetches the offsets committed by a group, the broker must be
+// the coordinator of the group. Unlike offsetFetch, errors on partitions do
+// not cause the method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_OffsetFetch
+func (c *Conn) offsetFetchV5(request offsetFetchRequestV5) (offsetFetchResponseV5, error) {
+	var response offsetFetchResponseV5
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(offsetFetch, v5, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return offsetFetchResponseV5{}, err
+	}
+	if response.ErrorCode != 0 {
+		return offsetFetchResponseV5{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
 type offsetFetchRequestV1Topic struct {
 	// Topic name
 	Topic string
@@ -40,6 +177,27 @@ func (t offsetFetchRequestV1) writeTo(wb *writeBuffer) {
 	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
 }
 
+// offsetFetchRequestV5 has the same layout than offsetFetchRequestV1, but the
+// topics may be null to fetch the offsets of all the partitions of the group.
+type offsetFetchRequestV5 struct {
+	GroupID string
+	Topics  []offsetFetchRequestV1Topic
+}
+
+func (t offsetFetchRequestV5) size() int32 {
+	return sizeofString(t.GroupID) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t offsetFetchRequestV5) writeTo(wb *writeBuffer) {
+	wb.writeString(t.GroupID)
+	if t.Topics == nil {
+		wb.writeArrayLen(-1)
+	} else {
+		wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	}
+}
+
 type offsetFetchResponseV1PartitionResponse struct {
 	// Partition ID
 	Partition int32
@@ -166,3 +324,120 @@ func findOffset(topic string, partition int32, response offsetFetchResponseV1) (
 
 	return 0, false
 }
+
+type offsetFetchResponsePartitionV5 struct {
+	Partition            int32
+	CommittedOffset      int64
+	CommittedLeaderEpoch int32
+	Metadata             string
+	ErrorCode            int16
+}
+
+func (t offsetFetchResponsePartitionV5) size() int32 {
+	return sizeofInt32(t.Partition) +
+		sizeofInt64(t.CommittedOffset) +
+		sizeofInt32(t.CommittedLeaderEpoch) +
+		sizeofString(t.Metadata) +
+		sizeofInt16(t.ErrorCode)
+}
+
+func (t offsetFetchResponsePartitionV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.Partition)
+	wb.writeInt64(t.CommittedOffset)
+	wb.writeInt32(t.CommittedLeaderEpoch)
+	wb.writeString(t.Metadata)
+	wb.writeInt16(t.ErrorCode)
+}
+
+func (t *offsetFetchResponsePartitionV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.Partition); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.CommittedOffset); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.CommittedLeaderEpoch); err != nil {
+		return
+	}
+	if remain, err = readString(r, remain, &t.Metadata); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	return
+}
+
+type offsetFetchResponseTopicV5 struct {
+	Topic      string
+	Partitions []offsetFetchResponsePartitionV5
+}
+
+func (t offsetFetchResponseTopicV5) size() int32 {
+	return sizeofString(t.Topic) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t offsetFetchResponseTopicV5) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Topic)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+func (t *offsetFetchResponseTopicV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.Topic); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition offsetFetchResponsePartitionV5
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+type offsetFetchResponseV5 struct {
+	ThrottleTimeMS int32
+	Topics         []offsetFetchResponseTopicV5
+	ErrorCode      int16
+}
+
+func (t offsetFetchResponseV5) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() }) +
+		sizeofInt16(t.ErrorCode)
+}
+
+func (t offsetFetchResponseV5) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+	wb.writeInt16(t.ErrorCode)
+}
+
+func (t *offsetFetchResponseV5) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic offsetFetchResponseTopicV5
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	return
+}
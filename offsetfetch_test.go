@@ -3,8 +3,12 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
 )
 
 func TestOffsetFetchResponseV1(t *testing.T) {
@@ -43,3 +47,123 @@ func TestOffsetFetchResponseV1(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestOffsetFetchResponseV5(t *testing.T) {
+	item := offsetFetchResponseV5{
+		ThrottleTimeMS: 1,
+		Topics: []offsetFetchResponseTopicV5{
+			{
+				Topic: "a",
+				Partitions: []offsetFetchResponsePartitionV5{
+					{
+						Partition:            2,
+						CommittedOffset:      3,
+						CommittedLeaderEpoch: 4,
+						Metadata:             "b",
+					},
+					{
+						Partition:            5,
+						CommittedOffset:      -1,
+						CommittedLeaderEpoch: -1,
+						ErrorCode:            int16(UnknownTopicOrPartition),
+					},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found offsetFetchResponseV5
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestOffsetFetchRequestV5Size(t *testing.T) {
+	for _, item := range []offsetFetchRequestV5{
+		{GroupID: "a"},
+		{GroupID: "a", Topics: []offsetFetchRequestV1Topic{{Topic: "b", Partitions: []int32{0, 1}}}},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func testClientOffsetFetch(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.1.0") {
+		t.Skip("fetching the leader epoch of offsets requires kafka 2.1.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	groupID := makeGroupID()
+	createTopic(t, topic, 2)
+
+	w := NewWriter(WriterConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     topic,
+		Balancer:  &RoundRobin{},
+		BatchSize: 1,
+	})
+	if err := w.WriteMessages(ctx, makeTestSequence(2)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	r := NewReader(ReaderConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   topic,
+		GroupID: groupID,
+		MaxWait: 100 * time.Millisecond,
+	})
+	for i := 0; i < 2; i++ {
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			r.Close()
+			t.Fatal(err)
+		}
+		if err := r.CommitMessages(ctx, m); err != nil {
+			r.Close()
+			t.Fatal(err)
+		}
+	}
+	r.Close()
+
+	for _, topics := range []map[string][]int{{topic: {0, 1}}, nil} {
+		res, err := c.OffsetFetch(ctx, OffsetFetchRequest{GroupID: groupID, Topics: topics})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		partitions := res.Topics[topic]
+		if len(partitions) != 2 {
+			t.Fatalf("expected offsets for 2 partitions, got %d", len(partitions))
+		}
+		for _, p := range partitions {
+			if p.Error != nil {
+				t.Errorf("fetching the offset of partition %d failed: %v", p.Partition, p.Error)
+			}
+			if p.CommittedOffset != 1 {
+				t.Errorf("expected committed offset 1 on partition %d, got %d", p.Partition, p.CommittedOffset)
+			}
+		}
+	}
+}
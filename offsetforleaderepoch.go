@@ -0,0 +1,369 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OffsetForLeaderEpochRequest represents a request sent to a kafka cluster to
+// look up the end offsets of leader epochs of partitions.
+type OffsetForLeaderEpochRequest struct {
+	// Topics holds the partitions to look up the epochs of, indexed by topic
+	// name.
+	Topics map[string][]OffsetForLeaderEpochRequestPartition
+}
+
+// OffsetForLeaderEpochRequestPartition designates a partition and the leader
+// epoch to look up the end offset of.
+type OffsetForLeaderEpochRequestPartition struct {
+	Partition int
+
+	// CurrentLeaderEpoch is the leader epoch known by the client, the broker
+	// answers with FencedLeaderEpoch or UnknownLeaderEpoch if it does not
+	// match its own. Use -1 to skip the check.
+	CurrentLeaderEpoch int
+
+	// LeaderEpoch is the epoch to look up the end offset of.
+	LeaderEpoch int
+}
+
+// OffsetForLeaderEpochResponse represents the response to an
+// OffsetForLeaderEpochRequest.
+type OffsetForLeaderEpochResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// brokers.
+	Throttle time.Duration
+
+	// Topics holds the end offsets found in each partition, indexed by topic
+	// name and sorted by partition.
+	Topics map[string][]OffsetForLeaderEpochResponsePartition
+}
+
+// OffsetForLeaderEpochResponsePartition carries the end offset of a leader
+// epoch of a partition.
+type OffsetForLeaderEpochResponsePartition struct {
+	Partition int
+
+	// LeaderEpoch is the largest epoch of the partition less than or equal to
+	// the requested one.
+	LeaderEpoch int
+
+	// EndOffset is the end offset of LeaderEpoch, which is the start offset of
+	// the next epoch, or the log end offset if LeaderEpoch is the latest. A
+	// consumer whose position is beyond EndOffset has observed a truncated
+	// log.
+	EndOffset int64
+
+	// Error is set to a non-nil value if the end offset could not be looked
+	// up.
+	Error error
+}
+
+// OffsetForLeaderEpoch looks up the end offsets of leader epochs of
+// partitions, which is how clients detect log truncation (KIP-320). Partitions
+// are grouped by leader, the requests to different leaders are sent
+// concurrently. The API version used requires kafka 2.1 or above.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) OffsetForLeaderEpoch(ctx context.Context, req OffsetForLeaderEpochRequest) (*OffsetForLeaderEpochResponse, error) {
+	topics := make([]string, 0, len(req.Topics))
+	for topic := range req.Topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	leaders, err := c.partitionLeaders(ctx, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &OffsetForLeaderEpochResponse{
+		Topics: make(map[string][]OffsetForLeaderEpochResponsePartition, len(req.Topics)),
+	}
+
+	requests := make(map[Broker]*offsetForLeaderEpochRequestV2)
+
+	for _, topic := range topics {
+		for _, p := range req.Topics[topic] {
+			leader, ok := leaders[topicPartition{topic: topic, partition: p.Partition}]
+			if !ok {
+				res.Topics[topic] = append(res.Topics[topic], OffsetForLeaderEpochResponsePartition{
+					Partition:   p.Partition,
+					LeaderEpoch: -1,
+					EndOffset:   -1,
+					Error:       LeaderNotAvailable,
+				})
+				continue
+			}
+
+			request := requests[leader]
+			if request == nil {
+				request = &offsetForLeaderEpochRequestV2{}
+				requests[leader] = request
+			}
+			request.add(topic, int32(p.Partition), int32(p.CurrentLeaderEpoch), int32(p.LeaderEpoch))
+		}
+	}
+
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for b, request := range requests {
+		wg.Add(1)
+		go func(b Broker, request offsetForLeaderEpochRequestV2) {
+			defer wg.Done()
+
+			response, err := c.leaderOffsetForLeaderEpoch(ctx, b, request)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				for _, t := range request.Topics {
+					for _, p := range t.Partitions {
+						res.Topics[t.Topic] = append(res.Topics[t.Topic], OffsetForLeaderEpochResponsePartition{
+							Partition:   int(p.Partition),
+							LeaderEpoch: -1,
+							EndOffset:   -1,
+							Error:       err,
+						})
+					}
+				}
+				return
+			}
+
+			if throttle := duration(response.ThrottleTimeMS); throttle > res.Throttle {
+				res.Throttle = throttle
+			}
+
+			for _, t := range response.Topics {
+				for _, p := range t.Partitions {
+					partition := OffsetForLeaderEpochResponsePartition{
+						Partition:   int(p.Partition),
+						LeaderEpoch: int(p.LeaderEpoch),
+						EndOffset:   p.EndOffset,
+					}
+					if p.ErrorCode != 0 {
+						partition.Error = Error(p.ErrorCode)
+					}
+					res.Topics[t.Topic] = append(res.Topics[t.Topic], partition)
+				}
+			}
+		}(b, *request)
+	}
+
+	wg.Wait()
+
+	for _, partitions := range res.Topics {
+		sort.Slice(partitions, func(i, j int) bool {
+			return partitions[i].Partition < partitions[j].Partition
+		})
+	}
+
+	return res, nil
+}
+
+// leaderOffsetForLeaderEpoch looks up the end offsets of epochs of partitions
+// led by the broker b.
+func (c *Client) leaderOffsetForLeaderEpoch(ctx context.Context, b Broker, request offsetForLeaderEpochRequestV2) (offsetForLeaderEpochResponseV2, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return offsetForLeaderEpochResponseV2{}, err
+	}
+	defer conn.Close()
+	return conn.offsetForLeaderEpoch(request)
+}
+
+// offsetForLeaderEpoch looks up the end offsets of epochs of the requested
+// partitions, the broker must be the leader of the partitions.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_OffsetForLeaderEpoch
+func (c *Conn) offsetForLeaderEpoch(request offsetForLeaderEpochRequestV2) (offsetForLeaderEpochResponseV2, error) {
+	var response offsetForLeaderEpochResponseV2
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(offsetForLeaderEpoch, v2, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return offsetForLeaderEpochResponseV2{}, err
+	}
+
+	return response, nil
+}
+
+type offsetForLeaderEpochRequestPartitionV2 struct {
+	Partition          int32
+	CurrentLeaderEpoch int32
+	LeaderEpoch        int32
+}
+
+func (t offsetForLeaderEpochRequestPartitionV2) size() int32 {
+	return sizeofInt32(t.Partition) +
+		sizeofInt32(t.CurrentLeaderEpoch) +
+		sizeofInt32(t.LeaderEpoch)
+}
+
+func (t offsetForLeaderEpochRequestPartitionV2) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.Partition)
+	wb.writeInt32(t.CurrentLeaderEpoch)
+	wb.writeInt32(t.LeaderEpoch)
+}
+
+type offsetForLeaderEpochRequestTopicV2 struct {
+	Topic      string
+	Partitions []offsetForLeaderEpochRequestPartitionV2
+}
+
+func (t offsetForLeaderEpochRequestTopicV2) size() int32 {
+	return sizeofString(t.Topic) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t offsetForLeaderEpochRequestTopicV2) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Topic)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_OffsetForLeaderEpoch
+type offsetForLeaderEpochRequestV2 struct {
+	Topics []offsetForLeaderEpochRequestTopicV2
+}
+
+// add appends a partition to the request, topics are expected to be added in
+// order.
+func (t *offsetForLeaderEpochRequestV2) add(topic string, partition, currentLeaderEpoch, leaderEpoch int32) {
+	if n := len(t.Topics); n == 0 || t.Topics[n-1].Topic != topic {
+		t.Topics = append(t.Topics, offsetForLeaderEpochRequestTopicV2{Topic: topic})
+	}
+	last := &t.Topics[len(t.Topics)-1]
+	last.Partitions = append(last.Partitions, offsetForLeaderEpochRequestPartitionV2{
+		Partition:          partition,
+		CurrentLeaderEpoch: currentLeaderEpoch,
+		LeaderEpoch:        leaderEpoch,
+	})
+}
+
+func (t offsetForLeaderEpochRequestV2) size() int32 {
+	return sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t offsetForLeaderEpochRequestV2) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
+
+type offsetForLeaderEpochResponsePartitionV2 struct {
+	ErrorCode   int16
+	Partition   int32
+	LeaderEpoch int32
+	EndOffset   int64
+}
+
+func (t offsetForLeaderEpochResponsePartitionV2) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofInt32(t.Partition) +
+		sizeofInt32(t.LeaderEpoch) +
+		sizeofInt64(t.EndOffset)
+}
+
+func (t offsetForLeaderEpochResponsePartitionV2) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeInt32(t.Partition)
+	wb.writeInt32(t.LeaderEpoch)
+	wb.writeInt64(t.EndOffset)
+}
+
+func (t *offsetForLeaderEpochResponsePartitionV2) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.Partition); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.LeaderEpoch); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.EndOffset); err != nil {
+		return
+	}
+	return
+}
+
+type offsetForLeaderEpochResponseTopicV2 struct {
+	Topic      string
+	Partitions []offsetForLeaderEpochResponsePartitionV2
+}
+
+func (t offsetForLeaderEpochResponseTopicV2) size() int32 {
+	return sizeofString(t.Topic) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t offsetForLeaderEpochResponseTopicV2) writeTo(wb *writeBuffer) {
+	wb.writeString(t.Topic)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+func (t *offsetForLeaderEpochResponseTopicV2) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readString(r, size, &t.Topic); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var partition offsetForLeaderEpochResponsePartitionV2
+		if fnRemain, fnErr = (&partition).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, partition)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_OffsetForLeaderEpoch
+type offsetForLeaderEpochResponseV2 struct {
+	ThrottleTimeMS int32
+	Topics         []offsetForLeaderEpochResponseTopicV2
+}
+
+func (t offsetForLeaderEpochResponseV2) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t offsetForLeaderEpochResponseV2) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
+
+func (t *offsetForLeaderEpochResponseV2) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt32(r, size, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic offsetForLeaderEpochResponseTopicV2
+		if fnRemain, fnErr = (&topic).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Topics = append(t.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestOffsetForLeaderEpochResponseV2(t *testing.T) {
+	item := offsetForLeaderEpochResponseV2{
+		ThrottleTimeMS: 1,
+		Topics: []offsetForLeaderEpochResponseTopicV2{
+			{
+				Topic: "a",
+				Partitions: []offsetForLeaderEpochResponsePartitionV2{
+					{
+						Partition:   2,
+						LeaderEpoch: 3,
+						EndOffset:   4,
+					},
+					{
+						ErrorCode:   int16(FencedLeaderEpoch),
+						Partition:   5,
+						LeaderEpoch: -1,
+						EndOffset:   -1,
+					},
+				},
+			},
+		},
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found offsetForLeaderEpochResponseV2
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestOffsetForLeaderEpochRequestV2Size(t *testing.T) {
+	item := offsetForLeaderEpochRequestV2{}
+	item.add("a", 0, -1, 1)
+	item.add("a", 1, 2, 2)
+	item.add("b", 0, -1, 0)
+
+	if len(item.Topics) != 2 || len(item.Topics[0].Partitions) != 2 || len(item.Topics[1].Partitions) != 1 {
+		t.Fatalf("unexpected request: %+v", item)
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+}
+
+func testClientOffsetForLeaderEpoch(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.1.0") {
+		t.Skip("checking the current leader epoch requires kafka 2.1.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	w := NewWriter(WriterConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     topic,
+		BatchSize: 1,
+	})
+	if err := w.WriteMessages(ctx, makeTestSequence(3)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	res, err := c.OffsetForLeaderEpoch(ctx, OffsetForLeaderEpochRequest{
+		Topics: map[string][]OffsetForLeaderEpochRequestPartition{
+			topic: {
+				{Partition: 0, CurrentLeaderEpoch: -1, LeaderEpoch: 0},
+				{Partition: 1, CurrentLeaderEpoch: -1, LeaderEpoch: 0},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partitions := res.Topics[topic]
+	if len(partitions) != 2 {
+		t.Fatalf("expected 2 partitions, got %d", len(partitions))
+	}
+	if p := partitions[0]; p.Error != nil || p.LeaderEpoch != 0 || p.EndOffset != 3 {
+		t.Errorf("unexpected end offset of the leader epoch: %+v", p)
+	}
+	if p := partitions[1]; p.Error != LeaderNotAvailable {
+		t.Errorf("expected %v on a partition that does not exist, got %v", LeaderNotAvailable, p.Error)
+	}
+}
@@ -1,6 +1,460 @@
 package kafka
 
-import "bufio"
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProduceRequest represents a request sent to a kafka cluster to produce
+// records to a partition.
+type ProduceRequest struct {
+	// Topic and Partition designate the partition to produce the records to.
+	Topic     string
+	Partition int
+
+	// Number of acknowledges from partition replicas required before
+	// receiving the response, either 1 or -1 (the default), which means to
+	// wait for all replicas.
+	RequiredAcks int
+
+	// Compression is the codec used to compress the records, they are not
+	// compressed when nil. The records are compressed at the level that the
+	// codec was created with.
+	Compression CompressionCodec
+
+	// Messages holds the records to produce, the Topic and Partition fields
+	// of the messages are ignored.
+	Messages []Message
+
+	// TransactionalID designates the transactional producer the records are
+	// produced by, the partition must have been added to the ongoing
+	// transaction with AddPartitionsToTxn.
+	TransactionalID string
+
+	// Idempotent is true when the records are produced by an idempotent
+	// producer, the broker then discards batches that it has already written
+	// based on their sequence numbers.
+	Idempotent bool
+
+	// Timeout is how long the leaders wait for the replicas to acknowledge the
+	// records when RequiredAcks is -1. When zero, the timeout is the time
+	// remaining until the deadline of the context.
+	Timeout time.Duration
+
+	// ProducerID, ProducerEpoch, and BaseSequence are only used when
+	// TransactionalID is set or Idempotent is true. The producer id and epoch
+	// are the ones returned by InitProducerID, BaseSequence is the sequence
+	// number of the first record, which the producer increments by the number
+	// of records written to the partition. A retried batch must keep its
+	// sequence number for the broker to detect the duplicate.
+	ProducerID    int64
+	ProducerEpoch int
+	BaseSequence  int
+
+	// Batches holds records to produce to multiple partitions, it is used
+	// instead of Topic, Partition, Messages, and BaseSequence, which must then
+	// be left empty. A request carries at most one batch per partition.
+	Batches []ProduceBatch
+}
+
+// ProduceBatch holds records produced to a partition by a ProduceRequest with
+// multiple batches.
+type ProduceBatch struct {
+	Topic     string
+	Partition int
+
+	// Messages holds the records to produce, the Topic and Partition fields
+	// of the messages are ignored.
+	Messages []Message
+
+	// BaseSequence is the sequence number of the first record, see
+	// ProduceRequest.
+	BaseSequence int
+}
+
+// ProduceResponse represents the response to a ProduceRequest.
+type ProduceResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// BaseOffset is the offset assigned to the first record.
+	BaseOffset int64
+
+	// LogAppendTime is the time at which the broker appended the records, it
+	// is the zero time unless the topic uses log append time for timestamps.
+	LogAppendTime time.Time
+
+	// Error is set to a non-nil value if the records could not be produced.
+	// An idempotent producer gets DuplicateSequenceNumber if the batch was
+	// already written, and OutOfOrderSequenceNumber if the broker is missing
+	// earlier batches of the producer.
+	Error error
+
+	// ErrorMessage and RecordErrors give details about Error, they require
+	// kafka 2.4 or above. RecordErrors designates the records that caused the
+	// batch to be rejected, for example because they were too large.
+	ErrorMessage string
+	RecordErrors []ProduceRecordError
+
+	// Batches holds the results of producing each batch when the request had
+	// multiple batches, in the order of the request. BaseOffset and Error are
+	// then unused.
+	Batches []ProduceBatchResponse
+}
+
+// ProduceBatchResponse carries the result of producing a batch of records to a
+// partition.
+type ProduceBatchResponse struct {
+	Topic     string
+	Partition int
+
+	// BaseOffset is the offset assigned to the first record of the batch.
+	BaseOffset int64
+
+	// LogAppendTime is the time at which the broker appended the records, see
+	// ProduceResponse.
+	LogAppendTime time.Time
+
+	// Error is set to a non-nil value if the records could not be produced,
+	// ErrorMessage and RecordErrors give details about it, see
+	// ProduceResponse.
+	Error        error
+	ErrorMessage string
+	RecordErrors []ProduceRecordError
+}
+
+// ProduceRecordError designates a record that caused a batch to be rejected.
+type ProduceRecordError struct {
+	// Index is the index of the record in the batch.
+	Index int
+
+	// Message describes why the record was rejected, it may be empty.
+	Message string
+}
+
+var (
+	errNoMessagesToProduce       = errors.New("cannot produce an empty list of messages")
+	errProduceMessagesAndBatches = errors.New("cannot produce both the messages of the request and batches")
+)
+
+// Produce produces records to a partition, or to multiple partitions when
+// Batches is set. The batches are grouped by the leader of their partition, a
+// single request is sent to each leader and the requests to different leaders
+// are sent concurrently. The method requires kafka 0.11 or above.
+//
+// Errors that apply to a single partition are reported on the response and do
+// not cause the method to fail. The batches that fail with a retriable error
+// are produced again as configured by ClientConfig.Retries.
+func (c *Client) Produce(ctx context.Context, req ProduceRequest) (*ProduceResponse, error) {
+	batches := append([]ProduceBatch(nil), req.Batches...)
+	if len(batches) == 0 {
+		batches = []ProduceBatch{{
+			Topic:        req.Topic,
+			Partition:    req.Partition,
+			Messages:     req.Messages,
+			BaseSequence: req.BaseSequence,
+		}}
+	} else if len(req.Messages) != 0 {
+		return nil, errProduceMessagesAndBatches
+	}
+
+	requiredAcks := req.RequiredAcks
+	switch requiredAcks {
+	case 0:
+		requiredAcks = -1
+	case -1, 1:
+	default:
+		return nil, InvalidRequiredAcks
+	}
+
+	writeTime := time.Now()
+	partitions := make(map[topicPartition]struct{}, len(batches))
+	pending := make([]int, len(batches))
+
+	for i, b := range batches {
+		if len(b.Messages) == 0 {
+			return nil, errNoMessagesToProduce
+		}
+
+		tp := topicPartition{topic: b.Topic, partition: b.Partition}
+		if _, exists := partitions[tp]; exists {
+			return nil, fmt.Errorf("multiple batches produced to partition %d of topic %s", b.Partition, b.Topic)
+		}
+		partitions[tp] = struct{}{}
+		pending[i] = i
+
+		msgs := make([]Message, len(b.Messages))
+		for j, msg := range b.Messages {
+			if msg.Time.IsZero() {
+				msg.Time = writeTime
+			}
+			msgs[j] = msg
+		}
+		batches[i].Messages = msgs
+	}
+
+	results := make([]ProduceBatchResponse, len(batches))
+	res := &ProduceResponse{BaseOffset: -1}
+
+	// Batches that failed with a retriable error are produced again, the
+	// batches of idempotent producers keep their sequence numbers so the
+	// brokers discard the records that were already written.
+	attempt := 0
+	for ; ; attempt++ {
+		throttle, err := c.produceBatches(ctx, req, int16(requiredAcks), batches, pending, results)
+		if throttle > res.Throttle {
+			res.Throttle = throttle
+		}
+		if err != nil {
+			if isRetriable(err) && c.retry(ctx, attempt) {
+				continue
+			}
+			return nil, retryError(attempt, err)
+		}
+
+		retriable := pending[:0]
+		for _, i := range pending {
+			if isRetriable(results[i].Error) {
+				retriable = append(retriable, i)
+			}
+		}
+		pending = retriable
+
+		if len(pending) == 0 || !c.retry(ctx, attempt) {
+			break
+		}
+	}
+
+	if len(req.Batches) != 0 {
+		res.Batches = results
+		return res, nil
+	}
+
+	// Errors that are not kafka errors, like failing to connect to the leader,
+	// fail the method when producing to a single partition.
+	result := results[0]
+	if _, ok := result.Error.(Error); result.Error != nil && !ok {
+		return nil, retryError(attempt, result.Error)
+	}
+	res.BaseOffset = result.BaseOffset
+	res.LogAppendTime = result.LogAppendTime
+	res.Error = result.Error
+	res.ErrorMessage = result.ErrorMessage
+	res.RecordErrors = result.RecordErrors
+	return res, nil
+}
+
+// Offset returns the offset assigned to the record at index in the messages of
+// the request, or -1 if the records were not produced.
+func (res *ProduceResponse) Offset(index int) int64 {
+	return recordOffset(res.BaseOffset, index)
+}
+
+// Offset returns the offset assigned to the record at index in the messages of
+// the batch, or -1 if the records were not produced.
+func (res *ProduceBatchResponse) Offset(index int) int64 {
+	return recordOffset(res.BaseOffset, index)
+}
+
+func recordOffset(baseOffset int64, index int) int64 {
+	if baseOffset < 0 {
+		return -1
+	}
+	return baseOffset + int64(index)
+}
+
+// produceBatches produces the batches at the pending indexes to the leaders of
+// their partitions and sets their results, it returns the maximum throttle of
+// the leaders. The requests to different leaders are sent concurrently.
+func (c *Client) produceBatches(ctx context.Context, req ProduceRequest, requiredAcks int16, batches []ProduceBatch, pending []int, results []ProduceBatchResponse) (time.Duration, error) {
+	indexes := make(map[topicPartition]int, len(pending))
+	topics := make([]string, 0, len(pending))
+
+	for _, i := range pending {
+		b := batches[i]
+		indexes[topicPartition{topic: b.Topic, partition: b.Partition}] = i
+		if len(topics) == 0 || topics[len(topics)-1] != b.Topic {
+			topics = append(topics, b.Topic)
+		}
+	}
+
+	leaders, err := c.partitionLeaders(ctx, topics)
+	if err != nil {
+		return 0, err
+	}
+
+	requests := make(map[Broker]*produceRequestV3)
+
+	for _, i := range pending {
+		b := batches[i]
+		results[i] = ProduceBatchResponse{
+			Topic:      b.Topic,
+			Partition:  b.Partition,
+			BaseOffset: -1,
+		}
+
+		leader, ok := leaders[topicPartition{topic: b.Topic, partition: b.Partition}]
+		if !ok {
+			results[i].Error = LeaderNotAvailable
+			continue
+		}
+
+		// The record batches are built on each attempt because writing a
+		// compressed batch releases its buffer.
+		batch, err := newRecordBatch(req.Compression, b.Messages...)
+		if err != nil {
+			return 0, err
+		}
+		if req.TransactionalID != "" || req.Idempotent {
+			batch.setProducer(req.ProducerID, int16(req.ProducerEpoch), int32(b.BaseSequence))
+		}
+		if req.TransactionalID != "" {
+			batch.setTransactional()
+		}
+
+		request := requests[leader]
+		if request == nil {
+			request = &produceRequestV3{
+				TransactionalID: emptyToNullable(req.TransactionalID),
+				RequiredAcks:    requiredAcks,
+				Timeout:         protocolTimeout(ctx, req.Timeout),
+			}
+			requests[leader] = request
+		}
+		request.add(b.Topic, int32(b.Partition), batch)
+	}
+
+	var throttle time.Duration
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for b, request := range requests {
+		wg.Add(1)
+		go func(b Broker, request produceRequestV3) {
+			defer wg.Done()
+
+			response, err := c.leaderProduce(ctx, b, request)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				for _, t := range request.Topics {
+					for _, p := range t.Partitions {
+						results[indexes[topicPartition{topic: t.TopicName, partition: int(p.Partition)}]].Error = err
+					}
+				}
+				return
+			}
+
+			if t := duration(response.ThrottleTimeMS); t > throttle {
+				throttle = t
+			}
+
+			for _, t := range response.Topics {
+				for _, p := range t.Partitions {
+					i, ok := indexes[topicPartition{topic: t.TopicName, partition: int(p.Partition)}]
+					if !ok {
+						continue
+					}
+					results[i].BaseOffset = p.Offset
+					if p.Timestamp >= 0 {
+						results[i].LogAppendTime = timestampToTime(p.Timestamp)
+					}
+					if p.ErrorCode != 0 {
+						results[i].Error = Error(p.ErrorCode)
+					}
+					if p.ErrorMessage != nil {
+						results[i].ErrorMessage = *p.ErrorMessage
+					}
+					for _, e := range p.RecordErrors {
+						recordError := ProduceRecordError{Index: int(e.BatchIndex)}
+						if e.BatchIndexErrorMessage != nil {
+							recordError.Message = *e.BatchIndexErrorMessage
+						}
+						results[i].RecordErrors = append(results[i].RecordErrors, recordError)
+					}
+				}
+			}
+		}(b, *request)
+	}
+
+	wg.Wait()
+	return throttle, nil
+}
+
+// leaderProduce writes record batches to partitions led by the broker b.
+func (c *Client) leaderProduce(ctx context.Context, b Broker, request produceRequestV3) (produceResponseV8, error) {
+	conn, err := c.dialBroker(ctx, b)
+	if err != nil {
+		return produceResponseV8{}, err
+	}
+	defer conn.Close()
+	return conn.produce(request)
+}
+
+// produce writes record batches to the requested partitions, the broker must
+// be the leader of the partitions. Brokers that support v8 of the API report
+// the records that caused a batch to be rejected, v3 responses are converted
+// to the v8 layout.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_Produce
+func (c *Conn) produce(request produceRequestV3) (produceResponseV8, error) {
+	version, err := c.negotiateVersion(produce, v3, v8)
+	if err != nil {
+		return produceResponseV8{}, err
+	}
+
+	var response produceResponseV8
+	var responseV3 produceResponseV3
+
+	err = c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			if request.Timeout == 0 {
+				now := time.Now()
+				deadline = adjustDeadlineForRTT(deadline, now, defaultRTT)
+				request.Timeout = milliseconds(deadlineToTimeout(deadline, now))
+			}
+			// v8 requests have the same layout than v3
+			return c.writeRequest(produce, version, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				if version == v8 {
+					return (&response).readFrom(&c.rbuf, size)
+				}
+				return (&responseV3).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return produceResponseV8{}, err
+	}
+
+	if version != v8 {
+		response.ThrottleTimeMS = responseV3.ThrottleTimeMS
+		for _, t := range responseV3.Topics {
+			topic := produceResponseTopicV8{TopicName: t.TopicName}
+			for _, p := range t.Partitions {
+				topic.Partitions = append(topic.Partitions, produceResponsePartitionV8{
+					Partition:   p.Partition,
+					ErrorCode:   p.ErrorCode,
+					Offset:      p.Offset,
+					Timestamp:   p.Timestamp,
+					StartOffset: -1,
+				})
+			}
+			response.Topics = append(response.Topics, topic)
+		}
+	}
+
+	return response, nil
+}
 
 type produceRequestV2 struct {
 	RequiredAcks int16
@@ -150,3 +604,297 @@ func (p *produceResponsePartitionV7) readFrom(r *bufio.Reader, sz int) (remain i
 	}
 	return
 }
+
+// See http://kafka.apache.org/protocol.html#The_Messages_Produce
+type produceRequestV3 struct {
+	TransactionalID *string
+	RequiredAcks    int16
+	Timeout         int32
+	Topics          []produceRequestTopicV3
+}
+
+func (r *produceRequestV3) add(topic string, partition int32, batch *recordBatch) {
+	for i := range r.Topics {
+		if t := &r.Topics[i]; t.TopicName == topic {
+			t.Partitions = append(t.Partitions, produceRequestPartitionV3{
+				Partition:   partition,
+				RecordBatch: batch,
+			})
+			return
+		}
+	}
+	r.Topics = append(r.Topics, produceRequestTopicV3{
+		TopicName: topic,
+		Partitions: []produceRequestPartitionV3{{
+			Partition:   partition,
+			RecordBatch: batch,
+		}},
+	})
+}
+
+func (r produceRequestV3) size() int32 {
+	return sizeofNullableString(r.TransactionalID) +
+		sizeofInt16(r.RequiredAcks) +
+		sizeofInt32(r.Timeout) +
+		sizeofArray(len(r.Topics), func(i int) int32 { return r.Topics[i].size() })
+}
+
+func (r produceRequestV3) writeTo(wb *writeBuffer) {
+	wb.writeNullableString(r.TransactionalID)
+	wb.writeInt16(r.RequiredAcks)
+	wb.writeInt32(r.Timeout)
+	wb.writeArray(len(r.Topics), func(i int) { r.Topics[i].writeTo(wb) })
+}
+
+type produceRequestTopicV3 struct {
+	TopicName  string
+	Partitions []produceRequestPartitionV3
+}
+
+func (t produceRequestTopicV3) size() int32 {
+	return sizeofString(t.TopicName) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t produceRequestTopicV3) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TopicName)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+type produceRequestPartitionV3 struct {
+	Partition   int32
+	RecordBatch *recordBatch
+}
+
+func (p produceRequestPartitionV3) size() int32 {
+	return 4 + 4 + p.RecordBatch.size
+}
+
+func (p produceRequestPartitionV3) writeTo(wb *writeBuffer) {
+	wb.writeInt32(p.Partition)
+	p.RecordBatch.writeTo(wb)
+}
+
+// produceResponseV3 differs from produceResponseV2 in that the throttle time
+// trails the topics, the partitions have the same layout than in v2.
+type produceResponseV3 struct {
+	Topics         []produceResponseTopicV3
+	ThrottleTimeMS int32
+}
+
+func (r produceResponseV3) size() int32 {
+	return sizeofArray(len(r.Topics), func(i int) int32 { return r.Topics[i].size() }) +
+		sizeofInt32(r.ThrottleTimeMS)
+}
+
+func (r produceResponseV3) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(r.Topics), func(i int) { r.Topics[i].writeTo(wb) })
+	wb.writeInt32(r.ThrottleTimeMS)
+}
+
+func (r *produceResponseV3) readFrom(rd *bufio.Reader, sz int) (remain int, err error) {
+	fn := func(rd *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic produceResponseTopicV3
+		if fnRemain, fnErr = (&topic).readFrom(rd, size); fnErr != nil {
+			return
+		}
+		r.Topics = append(r.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(rd, sz, fn); err != nil {
+		return
+	}
+	if remain, err = readInt32(rd, remain, &r.ThrottleTimeMS); err != nil {
+		return
+	}
+	return
+}
+
+type produceResponseTopicV3 struct {
+	TopicName  string
+	Partitions []produceResponsePartitionV2
+}
+
+func (t produceResponseTopicV3) size() int32 {
+	return sizeofString(t.TopicName) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t produceResponseTopicV3) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TopicName)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+func (t *produceResponseTopicV3) readFrom(r *bufio.Reader, sz int) (remain int, err error) {
+	if remain, err = readString(r, sz, &t.TopicName); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var p produceResponsePartitionV2
+		if fnRemain, fnErr = (&p).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, p)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// produceResponseV8 has the same layout than produceResponseV3, the partitions
+// carry the log start offset since v5, and the errors of records since v8.
+type produceResponseV8 struct {
+	Topics         []produceResponseTopicV8
+	ThrottleTimeMS int32
+}
+
+func (r produceResponseV8) size() int32 {
+	return sizeofArray(len(r.Topics), func(i int) int32 { return r.Topics[i].size() }) +
+		sizeofInt32(r.ThrottleTimeMS)
+}
+
+func (r produceResponseV8) writeTo(wb *writeBuffer) {
+	wb.writeArray(len(r.Topics), func(i int) { r.Topics[i].writeTo(wb) })
+	wb.writeInt32(r.ThrottleTimeMS)
+}
+
+func (r *produceResponseV8) readFrom(rd *bufio.Reader, sz int) (remain int, err error) {
+	fn := func(rd *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var topic produceResponseTopicV8
+		if fnRemain, fnErr = (&topic).readFrom(rd, size); fnErr != nil {
+			return
+		}
+		r.Topics = append(r.Topics, topic)
+		return
+	}
+	if remain, err = readArrayWith(rd, sz, fn); err != nil {
+		return
+	}
+	if remain, err = readInt32(rd, remain, &r.ThrottleTimeMS); err != nil {
+		return
+	}
+	return
+}
+
+type produceResponseTopicV8 struct {
+	TopicName  string
+	Partitions []produceResponsePartitionV8
+}
+
+func (t produceResponseTopicV8) size() int32 {
+	return sizeofString(t.TopicName) +
+		sizeofArray(len(t.Partitions), func(i int) int32 { return t.Partitions[i].size() })
+}
+
+func (t produceResponseTopicV8) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TopicName)
+	wb.writeArray(len(t.Partitions), func(i int) { t.Partitions[i].writeTo(wb) })
+}
+
+func (t *produceResponseTopicV8) readFrom(r *bufio.Reader, sz int) (remain int, err error) {
+	if remain, err = readString(r, sz, &t.TopicName); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var p produceResponsePartitionV8
+		if fnRemain, fnErr = (&p).readFrom(r, size); fnErr != nil {
+			return
+		}
+		t.Partitions = append(t.Partitions, p)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	return
+}
+
+// produceResponsePartitionV8 has the same layout than
+// produceResponsePartitionV7, followed by the errors of records.
+type produceResponsePartitionV8 struct {
+	Partition    int32
+	ErrorCode    int16
+	Offset       int64
+	Timestamp    int64
+	StartOffset  int64
+	RecordErrors []produceResponseRecordErrorV8
+	ErrorMessage *string
+}
+
+func (p produceResponsePartitionV8) size() int32 {
+	return 4 + 2 + 8 + 8 + 8 +
+		sizeofArray(len(p.RecordErrors), func(i int) int32 { return p.RecordErrors[i].size() }) +
+		sizeofNullableString(p.ErrorMessage)
+}
+
+func (p produceResponsePartitionV8) writeTo(wb *writeBuffer) {
+	wb.writeInt32(p.Partition)
+	wb.writeInt16(p.ErrorCode)
+	wb.writeInt64(p.Offset)
+	wb.writeInt64(p.Timestamp)
+	wb.writeInt64(p.StartOffset)
+	wb.writeArray(len(p.RecordErrors), func(i int) { p.RecordErrors[i].writeTo(wb) })
+	wb.writeNullableString(p.ErrorMessage)
+}
+
+func (p *produceResponsePartitionV8) readFrom(r *bufio.Reader, sz int) (remain int, err error) {
+	if remain, err = readInt32(r, sz, &p.Partition); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &p.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &p.Offset); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &p.Timestamp); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &p.StartOffset); err != nil {
+		return
+	}
+
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var e produceResponseRecordErrorV8
+		if fnRemain, fnErr = (&e).readFrom(r, size); fnErr != nil {
+			return
+		}
+		p.RecordErrors = append(p.RecordErrors, e)
+		return
+	}
+	if remain, err = readArrayWith(r, remain, fn); err != nil {
+		return
+	}
+	if remain, err = readNullableString(r, remain, &p.ErrorMessage); err != nil {
+		return
+	}
+	return
+}
+
+type produceResponseRecordErrorV8 struct {
+	BatchIndex             int32
+	BatchIndexErrorMessage *string
+}
+
+func (e produceResponseRecordErrorV8) size() int32 {
+	return 4 + sizeofNullableString(e.BatchIndexErrorMessage)
+}
+
+func (e produceResponseRecordErrorV8) writeTo(wb *writeBuffer) {
+	wb.writeInt32(e.BatchIndex)
+	wb.writeNullableString(e.BatchIndexErrorMessage)
+}
+
+func (e *produceResponseRecordErrorV8) readFrom(r *bufio.Reader, sz int) (remain int, err error) {
+	if remain, err = readInt32(r, sz, &e.BatchIndex); err != nil {
+		return
+	}
+	if remain, err = readNullableString(r, remain, &e.BatchIndexErrorMessage); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,341 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func TestProduceResponseV3(t *testing.T) {
+	item := produceResponseV3{
+		Topics: []produceResponseTopicV3{
+			{
+				TopicName: "a",
+				Partitions: []produceResponsePartitionV2{
+					{
+						Partition: 1,
+						Offset:    2,
+						Timestamp: -1,
+					},
+					{
+						Partition: 3,
+						ErrorCode: int16(NotLeaderForPartition),
+						Offset:    -1,
+						Timestamp: -1,
+					},
+				},
+			},
+		},
+		ThrottleTimeMS: 4,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	var found produceResponseV3
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestProduceResponseV8(t *testing.T) {
+	message := "record is too large"
+	item := produceResponseV8{
+		Topics: []produceResponseTopicV8{
+			{
+				TopicName: "a",
+				Partitions: []produceResponsePartitionV8{
+					{
+						Partition:   1,
+						Offset:      2,
+						Timestamp:   -1,
+						StartOffset: 0,
+					},
+					{
+						Partition:   3,
+						ErrorCode:   int16(InvalidRecord),
+						Offset:      -1,
+						Timestamp:   -1,
+						StartOffset: -1,
+						RecordErrors: []produceResponseRecordErrorV8{
+							{BatchIndex: 4, BatchIndexErrorMessage: &message},
+							{BatchIndex: 5},
+						},
+						ErrorMessage: &message,
+					},
+				},
+			},
+		},
+		ThrottleTimeMS: 6,
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found produceResponseV8
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestProduceResponseOffset(t *testing.T) {
+	res := ProduceResponse{BaseOffset: 10}
+	if offset := res.Offset(3); offset != 13 {
+		t.Errorf("expected offset 13, got %d", offset)
+	}
+
+	res = ProduceResponse{BaseOffset: -1, Error: LeaderNotAvailable}
+	if offset := res.Offset(3); offset != -1 {
+		t.Errorf("expected offset -1 when the records were not produced, got %d", offset)
+	}
+}
+
+func TestProduceRequestV3Transactional(t *testing.T) {
+	batch, err := newRecordBatch(nil, makeTestSequence(2)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.setProducer(42, 3, 7)
+	batch.setTransactional()
+
+	transactionalID := "txn"
+	item := produceRequestV3{
+		TransactionalID: &transactionalID,
+		RequiredAcks:    -1,
+		Timeout:         100,
+		Topics: []produceRequestTopicV3{{
+			TopicName: "a",
+			Partitions: []produceRequestPartitionV3{{
+				Partition:   1,
+				RecordBatch: batch,
+			}},
+		}},
+	}
+
+	size := item.size()
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != size {
+		t.Fatalf("expected %d bytes, got %d", size, b.Len())
+	}
+
+	// The record batch starts after the request fields, the topic, the
+	// partition, and the size of the batch.
+	header := b.Bytes()[size-batch.size:]
+	if attributes := int16(binary.BigEndian.Uint16(header[21:])); attributes&transactionalFlag == 0 {
+		t.Errorf("expected the transactional flag to be set on the batch attributes, got %#x", attributes)
+	}
+	if producerID := int64(binary.BigEndian.Uint64(header[43:])); producerID != 42 {
+		t.Errorf("expected producer id 42, got %d", producerID)
+	}
+	if producerEpoch := int16(binary.BigEndian.Uint16(header[51:])); producerEpoch != 3 {
+		t.Errorf("expected producer epoch 3, got %d", producerEpoch)
+	}
+	if baseSequence := int32(binary.BigEndian.Uint32(header[53:])); baseSequence != 7 {
+		t.Errorf("expected base sequence 7, got %d", baseSequence)
+	}
+}
+
+func TestProduceRequestV3Add(t *testing.T) {
+	batches := make([]*recordBatch, 3)
+	for i := range batches {
+		batch, err := newRecordBatch(nil, Message{Value: []byte("a"), Time: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		batches[i] = batch
+	}
+
+	var request produceRequestV3
+	request.add("a", 0, batches[0])
+	request.add("b", 1, batches[1])
+	request.add("a", 2, batches[2])
+
+	expected := []produceRequestTopicV3{
+		{TopicName: "a", Partitions: []produceRequestPartitionV3{{Partition: 0, RecordBatch: batches[0]}, {Partition: 2, RecordBatch: batches[2]}}},
+		{TopicName: "b", Partitions: []produceRequestPartitionV3{{Partition: 1, RecordBatch: batches[1]}}},
+	}
+	if !reflect.DeepEqual(request.Topics, expected) {
+		t.Errorf("expected %+v, got %+v", expected, request.Topics)
+	}
+}
+
+func testClientProduce(t *testing.T, ctx context.Context, c *Client) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	for i := int64(0); i < 2; i++ {
+		res, err := c.Produce(ctx, ProduceRequest{
+			Topic:     topic,
+			Partition: 0,
+			Messages:  makeTestSequence(3),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		if res.BaseOffset != 3*i {
+			t.Errorf("expected base offset %d, got %d", 3*i, res.BaseOffset)
+		}
+	}
+
+	res, err := c.Produce(ctx, ProduceRequest{
+		Topic:     topic,
+		Partition: 1,
+		Messages:  makeTestSequence(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != LeaderNotAvailable {
+		t.Errorf("expected %v on a partition that does not exist, got %v", LeaderNotAvailable, res.Error)
+	}
+
+	if _, err := c.Produce(ctx, ProduceRequest{Topic: topic}); err != errNoMessagesToProduce {
+		t.Errorf("expected %v when producing no messages, got %v", errNoMessagesToProduce, err)
+	}
+}
+
+func testClientProduceIdempotent(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("0.11.0") {
+		t.Skip("idempotent producers require kafka 0.11.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	producer, err := c.InitProducerID(ctx, InitProducerIDRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Producing the same batch twice simulates a retry after the response to
+	// the first attempt was lost, the broker must not write it again.
+	msgs := makeTestSequence(3)
+	for i := 0; i < 2; i++ {
+		res, err := c.Produce(ctx, ProduceRequest{
+			Topic:         topic,
+			Partition:     0,
+			Messages:      msgs,
+			Idempotent:    true,
+			ProducerID:    producer.ProducerID,
+			ProducerEpoch: producer.ProducerEpoch,
+			BaseSequence:  0,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Error != nil && res.Error != DuplicateSequenceNumber {
+			t.Fatalf("producing attempt %d failed: %v", i, res.Error)
+		}
+	}
+
+	res, err := c.Produce(ctx, ProduceRequest{
+		Topic:         topic,
+		Partition:     0,
+		Messages:      msgs,
+		Idempotent:    true,
+		ProducerID:    producer.ProducerID,
+		ProducerEpoch: producer.ProducerEpoch,
+		BaseSequence:  5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != OutOfOrderSequenceNumber {
+		t.Errorf("expected %v when skipping sequence numbers, got %v", OutOfOrderSequenceNumber, res.Error)
+	}
+
+	offsets, err := c.ListOffsets(ctx, ListOffsetsRequest{
+		Topics: map[string][]OffsetRequest{topic: {LastOffsetOf(0)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := offsets.Topics[topic]; len(p) != 1 || p[0].Offset != 3 {
+		t.Errorf("expected the batch to be written once, got %+v", p)
+	}
+}
+
+func testClientProduceBatches(t *testing.T, ctx context.Context, c *Client) {
+	topics := []string{makeTopic(), makeTopic()}
+	for _, topic := range topics {
+		createTopic(t, topic, 2)
+	}
+
+	batches := []ProduceBatch{
+		{Topic: topics[0], Partition: 0, Messages: makeTestSequence(2)},
+		{Topic: topics[1], Partition: 1, Messages: makeTestSequence(3)},
+		{Topic: topics[0], Partition: 1, Messages: makeTestSequence(1)},
+		{Topic: topics[1], Partition: 2, Messages: makeTestSequence(1)},
+	}
+
+	for round := int64(0); round < 2; round++ {
+		res, err := c.Produce(ctx, ProduceRequest{Batches: batches})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Batches) != len(batches) {
+			t.Fatalf("expected %d batch results, got %d", len(batches), len(res.Batches))
+		}
+
+		for i, b := range res.Batches {
+			if b.Topic != batches[i].Topic || b.Partition != batches[i].Partition {
+				t.Errorf("expected the result of batch %d to be for partition %d of %s, got partition %d of %s", i, batches[i].Partition, batches[i].Topic, b.Partition, b.Topic)
+			}
+			if i == 3 {
+				if b.Error != LeaderNotAvailable {
+					t.Errorf("expected %v on a partition that does not exist, got %v", LeaderNotAvailable, b.Error)
+				}
+				continue
+			}
+			if b.Error != nil {
+				t.Errorf("producing batch %d failed: %v", i, b.Error)
+			}
+			if expected := round * int64(len(batches[i].Messages)); b.BaseOffset != expected {
+				t.Errorf("expected base offset %d on batch %d, got %d", expected, i, b.BaseOffset)
+			}
+		}
+	}
+
+	if _, err := c.Produce(ctx, ProduceRequest{Batches: batches, Messages: makeTestSequence(1)}); err != errProduceMessagesAndBatches {
+		t.Errorf("expected %v when producing both messages and batches, got %v", errProduceMessagesAndBatches, err)
+	}
+}
@@ -42,54 +42,73 @@ func (v ApiVersion) Format(w fmt.State, r rune) {
 type apiKey int16
 
 const (
-	produce                     apiKey = 0
-	fetch                       apiKey = 1
-	listOffsets                 apiKey = 2
-	metadata                    apiKey = 3
-	leaderAndIsr                apiKey = 4
-	stopReplica                 apiKey = 5
-	updateMetadata              apiKey = 6
-	controlledShutdown          apiKey = 7
-	offsetCommit                apiKey = 8
-	offsetFetch                 apiKey = 9
-	findCoordinator             apiKey = 10
-	joinGroup                   apiKey = 11
-	heartbeat                   apiKey = 12
-	leaveGroup                  apiKey = 13
-	syncGroup                   apiKey = 14
-	describeGroups              apiKey = 15
-	listGroups                  apiKey = 16
-	saslHandshake               apiKey = 17
-	apiVersions                 apiKey = 18
-	createTopics                apiKey = 19
-	deleteTopics                apiKey = 20
-	deleteRecords               apiKey = 21
-	initProducerId              apiKey = 22
-	offsetForLeaderEpoch        apiKey = 23
-	addPartitionsToTxn          apiKey = 24
-	addOffsetsToTxn             apiKey = 25
-	endTxn                      apiKey = 26
-	writeTxnMarkers             apiKey = 27
-	txnOffsetCommit             apiKey = 28
-	describeAcls                apiKey = 29
-	createAcls                  apiKey = 30
-	deleteAcls                  apiKey = 31
-	describeConfigs             apiKey = 32
-	alterConfigs                apiKey = 33
-	alterReplicaLogDirs         apiKey = 34
-	describeLogDirs             apiKey = 35
-	saslAuthenticate            apiKey = 36
-	createPartitions            apiKey = 37
-	createDelegationToken       apiKey = 38
-	renewDelegationToken        apiKey = 39
-	expireDelegationToken       apiKey = 40
-	describeDelegationToken     apiKey = 41
-	deleteGroups                apiKey = 42
-	electLeaders                apiKey = 43
-	incrementalAlterConfigs     apiKey = 44
-	alterPartitionReassignments apiKey = 45
-	listPartitionReassignments  apiKey = 46
-	offsetDelete                apiKey = 47
+	produce                      apiKey = 0
+	fetch                        apiKey = 1
+	listOffsets                  apiKey = 2
+	metadata                     apiKey = 3
+	leaderAndIsr                 apiKey = 4
+	stopReplica                  apiKey = 5
+	updateMetadata               apiKey = 6
+	controlledShutdown           apiKey = 7
+	offsetCommit                 apiKey = 8
+	offsetFetch                  apiKey = 9
+	findCoordinator              apiKey = 10
+	joinGroup                    apiKey = 11
+	heartbeat                    apiKey = 12
+	leaveGroup                   apiKey = 13
+	syncGroup                    apiKey = 14
+	describeGroups               apiKey = 15
+	listGroups                   apiKey = 16
+	saslHandshake                apiKey = 17
+	apiVersions                  apiKey = 18
+	createTopics                 apiKey = 19
+	deleteTopics                 apiKey = 20
+	deleteRecords                apiKey = 21
+	initProducerId               apiKey = 22
+	offsetForLeaderEpoch         apiKey = 23
+	addPartitionsToTxn           apiKey = 24
+	addOffsetsToTxn              apiKey = 25
+	endTxn                       apiKey = 26
+	writeTxnMarkers              apiKey = 27
+	txnOffsetCommit              apiKey = 28
+	describeAcls                 apiKey = 29
+	createAcls                   apiKey = 30
+	deleteAcls                   apiKey = 31
+	describeConfigs              apiKey = 32
+	alterConfigs                 apiKey = 33
+	alterReplicaLogDirs          apiKey = 34
+	describeLogDirs              apiKey = 35
+	saslAuthenticate             apiKey = 36
+	createPartitions             apiKey = 37
+	createDelegationToken        apiKey = 38
+	renewDelegationToken         apiKey = 39
+	expireDelegationToken        apiKey = 40
+	describeDelegationToken      apiKey = 41
+	deleteGroups                 apiKey = 42
+	electLeaders                 apiKey = 43
+	incrementalAlterConfigs      apiKey = 44
+	alterPartitionReassignments  apiKey = 45
+	listPartitionReassignments   apiKey = 46
+	offsetDelete                 apiKey = 47
+	describeClientQuotas         apiKey = 48
+	alterClientQuotas            apiKey = 49
+	describeUserScramCredentials apiKey = 50
+	alterUserScramCredentials    apiKey = 51
+	vote                         apiKey = 52
+	beginQuorumEpoch             apiKey = 53
+	endQuorumEpoch               apiKey = 54
+	describeQuorum               apiKey = 55
+	alterIsr                     apiKey = 56
+	updateFeatures               apiKey = 57
+	envelope                     apiKey = 58
+	fetchSnapshot                apiKey = 59
+	describeCluster              apiKey = 60
+	describeProducers            apiKey = 61
+	brokerRegistration           apiKey = 62
+	brokerHeartbeat              apiKey = 63
+	unregisterBroker             apiKey = 64
+	describeTransactions         apiKey = 65
+	listTransactions             apiKey = 66
 )
 
 func (k apiKey) String() string {
@@ -113,57 +132,77 @@ const (
 	v8  apiVersion = 8
 	v9  apiVersion = 9
 	v10 apiVersion = 10
+	v11 apiVersion = 11
 )
 
 var apiKeyStrings = [...]string{
-	produce:                     "Produce",
-	fetch:                       "Fetch",
-	listOffsets:                 "ListOffsets",
-	metadata:                    "Metadata",
-	leaderAndIsr:                "LeaderAndIsr",
-	stopReplica:                 "StopReplica",
-	updateMetadata:              "UpdateMetadata",
-	controlledShutdown:          "ControlledShutdown",
-	offsetCommit:                "OffsetCommit",
-	offsetFetch:                 "OffsetFetch",
-	findCoordinator:             "FindCoordinator",
-	joinGroup:                   "JoinGroup",
-	heartbeat:                   "Heartbeat",
-	leaveGroup:                  "LeaveGroup",
-	syncGroup:                   "SyncGroup",
-	describeGroups:              "DescribeGroups",
-	listGroups:                  "ListGroups",
-	saslHandshake:               "SaslHandshake",
-	apiVersions:                 "ApiVersions",
-	createTopics:                "CreateTopics",
-	deleteTopics:                "DeleteTopics",
-	deleteRecords:               "DeleteRecords",
-	initProducerId:              "InitProducerId",
-	offsetForLeaderEpoch:        "OffsetForLeaderEpoch",
-	addPartitionsToTxn:          "AddPartitionsToTxn",
-	addOffsetsToTxn:             "AddOffsetsToTxn",
-	endTxn:                      "EndTxn",
-	writeTxnMarkers:             "WriteTxnMarkers",
-	txnOffsetCommit:             "TxnOffsetCommit",
-	describeAcls:                "DescribeAcls",
-	createAcls:                  "CreateAcls",
-	deleteAcls:                  "DeleteAcls",
-	describeConfigs:             "DescribeConfigs",
-	alterConfigs:                "AlterConfigs",
-	alterReplicaLogDirs:         "AlterReplicaLogDirs",
-	describeLogDirs:             "DescribeLogDirs",
-	saslAuthenticate:            "SaslAuthenticate",
-	createPartitions:            "CreatePartitions",
-	createDelegationToken:       "CreateDelegationToken",
-	renewDelegationToken:        "RenewDelegationToken",
-	expireDelegationToken:       "ExpireDelegationToken",
-	describeDelegationToken:     "DescribeDelegationToken",
-	deleteGroups:                "DeleteGroups",
-	electLeaders:                "ElectLeaders",
-	incrementalAlterConfigs:     "IncrementalAlfterConfigs",
-	alterPartitionReassignments: "AlterPartitionReassignments",
-	listPartitionReassignments:  "ListPartitionReassignments",
-	offsetDelete:                "OffsetDelete",
+	produce:                      "Produce",
+	fetch:                        "Fetch",
+	listOffsets:                  "ListOffsets",
+	metadata:                     "Metadata",
+	leaderAndIsr:                 "LeaderAndIsr",
+	stopReplica:                  "StopReplica",
+	updateMetadata:               "UpdateMetadata",
+	controlledShutdown:           "ControlledShutdown",
+	offsetCommit:                 "OffsetCommit",
+	offsetFetch:                  "OffsetFetch",
+	findCoordinator:              "FindCoordinator",
+	joinGroup:                    "JoinGroup",
+	heartbeat:                    "Heartbeat",
+	leaveGroup:                   "LeaveGroup",
+	syncGroup:                    "SyncGroup",
+	describeGroups:               "DescribeGroups",
+	listGroups:                   "ListGroups",
+	saslHandshake:                "SaslHandshake",
+	apiVersions:                  "ApiVersions",
+	createTopics:                 "CreateTopics",
+	deleteTopics:                 "DeleteTopics",
+	deleteRecords:                "DeleteRecords",
+	initProducerId:               "InitProducerId",
+	offsetForLeaderEpoch:         "OffsetForLeaderEpoch",
+	addPartitionsToTxn:           "AddPartitionsToTxn",
+	addOffsetsToTxn:              "AddOffsetsToTxn",
+	endTxn:                       "EndTxn",
+	writeTxnMarkers:              "WriteTxnMarkers",
+	txnOffsetCommit:              "TxnOffsetCommit",
+	describeAcls:                 "DescribeAcls",
+	createAcls:                   "CreateAcls",
+	deleteAcls:                   "DeleteAcls",
+	describeConfigs:              "DescribeConfigs",
+	alterConfigs:                 "AlterConfigs",
+	alterReplicaLogDirs:          "AlterReplicaLogDirs",
+	describeLogDirs:              "DescribeLogDirs",
+	saslAuthenticate:             "SaslAuthenticate",
+	createPartitions:             "CreatePartitions",
+	createDelegationToken:        "CreateDelegationToken",
+	renewDelegationToken:         "RenewDelegationToken",
+	expireDelegationToken:        "ExpireDelegationToken",
+	describeDelegationToken:      "DescribeDelegationToken",
+	deleteGroups:                 "DeleteGroups",
+	electLeaders:                 "ElectLeaders",
+	incrementalAlterConfigs:      "IncrementalAlterConfigs",
+	alterPartitionReassignments:  "AlterPartitionReassignments",
+	listPartitionReassignments:   "ListPartitionReassignments",
+	offsetDelete:                 "OffsetDelete",
+	describeClientQuotas:         "DescribeClientQuotas",
+	alterClientQuotas:            "AlterClientQuotas",
+	describeUserScramCredentials: "DescribeUserScramCredentials",
+	alterUserScramCredentials:    "AlterUserScramCredentials",
+	vote:                         "Vote",
+	beginQuorumEpoch:             "BeginQuorumEpoch",
+	endQuorumEpoch:               "EndQuorumEpoch",
+	describeQuorum:               "DescribeQuorum",
+	alterIsr:                     "AlterIsr",
+	updateFeatures:               "UpdateFeatures",
+	envelope:                     "Envelope",
+	fetchSnapshot:                "FetchSnapshot",
+	describeCluster:              "DescribeCluster",
+	describeProducers:            "DescribeProducers",
+	brokerRegistration:           "BrokerRegistration",
+	brokerHeartbeat:              "BrokerHeartbeat",
+	unregisterBroker:             "UnregisterBroker",
+	describeTransactions:         "DescribeTransactions",
+	listTransactions:             "ListTransactions",
 }
 
 type requestHeader struct {
@@ -95,6 +95,25 @@ func TestProtocol(t *testing.T) {
 			},
 		},
 
+		listOffsetRequestV2{
+			ReplicaID:      -1,
+			IsolationLevel: int8(ReadCommitted),
+			Topics: []listOffsetRequestTopicV1{
+				{TopicName: "A", Partitions: []listOffsetRequestPartitionV1{
+					{Partition: 0, Time: -1},
+				}},
+			},
+		},
+
+		listOffsetResponseV2{
+			ThrottleTimeMS: 10,
+			Topics: listOffsetResponseV1{
+				{TopicName: "A", PartitionOffsets: []partitionOffsetV1{
+					{Partition: 0, Timestamp: -1, Offset: 12},
+				}},
+			},
+		},
+
 		listOffsetResponseV1{
 			{TopicName: "A", PartitionOffsets: []partitionOffsetV1{
 				{Partition: 0, Timestamp: 42, Offset: 1},
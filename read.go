@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
 )
 
@@ -42,6 +43,14 @@ func readInt64(r *bufio.Reader, sz int, v *int64) (int, error) {
 	return peekRead(r, sz, 8, func(b []byte) { *v = makeInt64(b) })
 }
 
+func readUUID(r *bufio.Reader, sz int, v *[16]byte) (int, error) {
+	return peekRead(r, sz, 16, func(b []byte) { copy(v[:], b) })
+}
+
+func readFloat64(r *bufio.Reader, sz int, v *float64) (int, error) {
+	return peekRead(r, sz, 8, func(b []byte) { *v = math.Float64frombits(uint64(makeInt64(b))) })
+}
+
 func readVarInt(r *bufio.Reader, sz int, v *int64) (remain int, err error) {
 	// Optimistically assume that most of the time, there will be data buffered
 	// in the reader. If this is not the case, the buffer will be refilled after
@@ -102,6 +111,22 @@ func readString(r *bufio.Reader, sz int, v *string) (int, error) {
 	})
 }
 
+// readNullableString reads a string which may be null, v is set to nil when
+// the string is null.
+func readNullableString(r *bufio.Reader, sz int, v **string) (int, error) {
+	return readStringWith(r, sz, func(r *bufio.Reader, sz int, n int) (remain int, err error) {
+		if n < 0 {
+			*v = nil
+			return sz, nil
+		}
+		var s string
+		if s, remain, err = readNewString(r, sz, n); err == nil {
+			*v = &s
+		}
+		return
+	})
+}
+
 func readStringWith(r *bufio.Reader, sz int, cb func(*bufio.Reader, int, int) (int, error)) (int, error) {
 	var err error
 	var len int16
@@ -169,6 +194,16 @@ func readNewBytes(r *bufio.Reader, sz int, n int) ([]byte, int, error) {
 	return b, sz, err
 }
 
+// readNewNullableBytes is like readNewBytes but returns an empty slice instead
+// of nil when n is zero, so the null keys and values of records (n < 0) can be
+// told apart from the empty ones.
+func readNewNullableBytes(r *bufio.Reader, sz int, n int) ([]byte, int, error) {
+	if n == 0 {
+		return []byte{}, sz, nil
+	}
+	return readNewBytes(r, sz, n)
+}
+
 func readArrayLen(r *bufio.Reader, sz int, n *int) (int, error) {
 	var err error
 	var len int32
@@ -248,6 +283,147 @@ func readMapStringInt32(r *bufio.Reader, sz int, v *map[string][]int32) (remain
 	return
 }
 
+func readUnsignedVarInt(r *bufio.Reader, sz int, v *uint64) (remain int, err error) {
+	x := uint64(0)
+	remain = sz
+
+	for s := uint(0); s < 64; s += 7 {
+		var b int8
+		if remain, err = readInt8(r, remain, &b); err != nil {
+			return
+		}
+		if uint8(b) < 0x80 {
+			*v = x | uint64(b)<<s
+			return
+		}
+		x |= uint64(uint8(b)&0x7f) << s
+	}
+
+	err = errors.New("unsigned varint overflows a 64-bit integer")
+	return
+}
+
+// readCompactLen reads the length prefix of a compact string, bytes, or array
+// value. A length of -1 indicates a null value.
+func readCompactLen(r *bufio.Reader, sz int, n *int) (int, error) {
+	var u uint64
+	sz, err := readUnsignedVarInt(r, sz, &u)
+	if err != nil {
+		return sz, err
+	}
+	*n = int(u) - 1
+	return sz, nil
+}
+
+func readCompactString(r *bufio.Reader, sz int, v *string) (int, error) {
+	var n int
+	sz, err := readCompactLen(r, sz, &n)
+	if err != nil {
+		return sz, err
+	}
+	if n > sz {
+		return sz, errShortRead
+	}
+	*v, sz, err = readNewString(r, sz, n)
+	return sz, err
+}
+
+func readCompactBytes(r *bufio.Reader, sz int, v *[]byte) (int, error) {
+	var n int
+	sz, err := readCompactLen(r, sz, &n)
+	if err != nil {
+		return sz, err
+	}
+	if n > sz {
+		return sz, errShortRead
+	}
+	if n < 0 {
+		*v = nil
+		return sz, nil
+	}
+	if *v, sz, err = readNewBytes(r, sz, n); err == nil && *v == nil {
+		*v = []byte{}
+	}
+	return sz, err
+}
+
+func readCompactArrayWith(r *bufio.Reader, sz int, cb func(*bufio.Reader, int) (int, error)) (int, error) {
+	var n int
+	sz, err := readCompactLen(r, sz, &n)
+	if err != nil {
+		return sz, err
+	}
+
+	for ; n > 0; n-- {
+		if sz, err = cb(r, sz); err != nil {
+			break
+		}
+	}
+
+	return sz, err
+}
+
+func readCompactStringArray(r *bufio.Reader, sz int, v *[]string) (remain int, err error) {
+	var content []string
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var value string
+		if fnRemain, fnErr = readCompactString(r, size, &value); fnErr != nil {
+			return
+		}
+		content = append(content, value)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, sz, fn); err != nil {
+		return
+	}
+
+	*v = content
+	return
+}
+
+func readCompactInt32Array(r *bufio.Reader, sz int, v *[]int32) (remain int, err error) {
+	var content []int32
+	fn := func(r *bufio.Reader, size int) (fnRemain int, fnErr error) {
+		var value int32
+		if fnRemain, fnErr = readInt32(r, size, &value); fnErr != nil {
+			return
+		}
+		content = append(content, value)
+		return
+	}
+	if remain, err = readCompactArrayWith(r, sz, fn); err != nil {
+		return
+	}
+
+	*v = content
+	return
+}
+
+// readTaggedFields skips over the tagged fields that terminate structures of
+// the flexible versions of the kafka protocol, none of them are used by this
+// package.
+func readTaggedFields(r *bufio.Reader, sz int) (remain int, err error) {
+	var n uint64
+	if remain, err = readUnsignedVarInt(r, sz, &n); err != nil {
+		return
+	}
+
+	for i := uint64(0); i < n; i++ {
+		var tag, size uint64
+		if remain, err = readUnsignedVarInt(r, remain, &tag); err != nil {
+			return
+		}
+		if remain, err = readUnsignedVarInt(r, remain, &size); err != nil {
+			return
+		}
+		if remain, err = discardN(r, remain, int(size)); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 func read(r *bufio.Reader, sz int, a interface{}) (int, error) {
 	switch v := a.(type) {
 	case *int8:
@@ -403,12 +579,15 @@ func readFetchResponseHeaderV2(r *bufio.Reader, size int) (throttle int32, water
 	return
 }
 
-func readFetchResponseHeaderV5(r *bufio.Reader, size int) (throttle int32, watermark int64, remain int, err error) {
+// abortedTransaction is an entry of the list of transactions aborted in the
+// range of offsets returned by a fetch response.
+type abortedTransaction struct {
+	ProducerID  int64
+	FirstOffset int64
+}
+
+func readFetchResponseHeaderV5(r *bufio.Reader, size int) (throttle int32, watermark int64, lastStableOffset int64, logStartOffset int64, abortedTransactions []abortedTransaction, remain int, err error) {
 	var n int32
-	type AbortedTransaction struct {
-		ProducerId  int64
-		FirstOffset int64
-	}
 	var p struct {
 		Partition           int32
 		ErrorCode           int16
@@ -417,7 +596,8 @@ func readFetchResponseHeaderV5(r *bufio.Reader, size int) (throttle int32, water
 		LogStartOffset      int64
 	}
 	var messageSetSize int32
-	var abortedTransactions []AbortedTransaction
+
+	logStartOffset = -1
 
 	if remain, err = readInt32(r, size, &throttle); err != nil {
 		return
@@ -455,6 +635,7 @@ func readFetchResponseHeaderV5(r *bufio.Reader, size int) (throttle int32, water
 	if remain, err = read(r, remain, &p); err != nil {
 		return
 	}
+	logStartOffset = p.LogStartOffset
 
 	var abortedTransactionLen int
 	if remain, err = readArrayLen(r, remain, &abortedTransactionLen); err != nil {
@@ -464,7 +645,7 @@ func readFetchResponseHeaderV5(r *bufio.Reader, size int) (throttle int32, water
 	if abortedTransactionLen == -1 {
 		abortedTransactions = nil
 	} else {
-		abortedTransactions = make([]AbortedTransaction, abortedTransactionLen)
+		abortedTransactions = make([]abortedTransaction, abortedTransactionLen)
 		for i := 0; i < abortedTransactionLen; i++ {
 			if remain, err = read(r, remain, &abortedTransactions[i]); err != nil {
 				return
@@ -490,17 +671,13 @@ func readFetchResponseHeaderV5(r *bufio.Reader, size int) (throttle int32, water
 	}
 
 	watermark = p.HighwaterMarkOffset
+	lastStableOffset = p.LastStableOffset
 	return
-
 }
 
-func readFetchResponseHeaderV10(r *bufio.Reader, size int) (throttle int32, watermark int64, remain int, err error) {
+func readFetchResponseHeaderV10(r *bufio.Reader, size int) (throttle int32, watermark int64, lastStableOffset int64, logStartOffset int64, abortedTransactions []abortedTransaction, remain int, err error) {
 	var n int32
 	var errorCode int16
-	type AbortedTransaction struct {
-		ProducerId  int64
-		FirstOffset int64
-	}
 	var p struct {
 		Partition           int32
 		ErrorCode           int16
@@ -509,7 +686,8 @@ func readFetchResponseHeaderV10(r *bufio.Reader, size int) (throttle int32, wate
 		LogStartOffset      int64
 	}
 	var messageSetSize int32
-	var abortedTransactions []AbortedTransaction
+
+	logStartOffset = -1
 
 	if remain, err = readInt32(r, size, &throttle); err != nil {
 		return
@@ -559,6 +737,7 @@ func readFetchResponseHeaderV10(r *bufio.Reader, size int) (throttle int32, wate
 	if remain, err = read(r, remain, &p); err != nil {
 		return
 	}
+	logStartOffset = p.LogStartOffset
 
 	var abortedTransactionLen int
 	if remain, err = readArrayLen(r, remain, &abortedTransactionLen); err != nil {
@@ -568,7 +747,7 @@ func readFetchResponseHeaderV10(r *bufio.Reader, size int) (throttle int32, wate
 	if abortedTransactionLen == -1 {
 		abortedTransactions = nil
 	} else {
-		abortedTransactions = make([]AbortedTransaction, abortedTransactionLen)
+		abortedTransactions = make([]abortedTransaction, abortedTransactionLen)
 		for i := 0; i < abortedTransactionLen; i++ {
 			if remain, err = read(r, remain, &abortedTransactions[i]); err != nil {
 				return
@@ -594,8 +773,114 @@ func readFetchResponseHeaderV10(r *bufio.Reader, size int) (throttle int32, wate
 	}
 
 	watermark = p.HighwaterMarkOffset
+	lastStableOffset = p.LastStableOffset
 	return
+}
+
+func readFetchResponseHeaderV11(r *bufio.Reader, size int) (throttle int32, watermark int64, lastStableOffset int64, logStartOffset int64, abortedTransactions []abortedTransaction, preferredReadReplica int32, remain int, err error) {
+	var n int32
+	var errorCode int16
+	var p struct {
+		Partition           int32
+		ErrorCode           int16
+		HighwaterMarkOffset int64
+		LastStableOffset    int64
+		LogStartOffset      int64
+	}
+	var messageSetSize int32
+
+	logStartOffset, preferredReadReplica = -1, -1
+
+	if remain, err = readInt32(r, size, &throttle); err != nil {
+		return
+	}
+
+	if remain, err = readInt16(r, remain, &errorCode); err != nil {
+		return
+	}
+	if errorCode != 0 {
+		err = Error(errorCode)
+		return
+	}
+
+	if remain, err = discardInt32(r, remain); err != nil {
+		return
+	}
+
+	if remain, err = readInt32(r, remain, &n); err != nil {
+		return
+	}
 
+	// This error should never trigger, unless there's a bug in the kafka client
+	// or server.
+	if n != 1 {
+		err = fmt.Errorf("1 kafka topic was expected in the fetch response but the client received %d", n)
+		return
+	}
+
+	// We ignore the topic name because we've requests messages for a single
+	// topic, unless there's a bug in the kafka server we will have received
+	// the name of the topic that we requested.
+	if remain, err = discardString(r, remain); err != nil {
+		return
+	}
+
+	if remain, err = readInt32(r, remain, &n); err != nil {
+		return
+	}
+
+	// This error should never trigger, unless there's a bug in the kafka client
+	// or server.
+	if n != 1 {
+		err = fmt.Errorf("1 kafka partition was expected in the fetch response but the client received %d", n)
+		return
+	}
+
+	if remain, err = read(r, remain, &p); err != nil {
+		return
+	}
+	logStartOffset = p.LogStartOffset
+
+	var abortedTransactionLen int
+	if remain, err = readArrayLen(r, remain, &abortedTransactionLen); err != nil {
+		return
+	}
+
+	if abortedTransactionLen == -1 {
+		abortedTransactions = nil
+	} else {
+		abortedTransactions = make([]abortedTransaction, abortedTransactionLen)
+		for i := 0; i < abortedTransactionLen; i++ {
+			if remain, err = read(r, remain, &abortedTransactions[i]); err != nil {
+				return
+			}
+		}
+	}
+
+	if remain, err = readInt32(r, remain, &preferredReadReplica); err != nil {
+		return
+	}
+
+	if p.ErrorCode != 0 {
+		err = Error(p.ErrorCode)
+		return
+	}
+
+	remain, err = readInt32(r, remain, &messageSetSize)
+	if err != nil {
+		return
+	}
+
+	// This error should never trigger, unless there's a bug in the kafka client
+	// or server.
+	if remain != int(messageSetSize) {
+		err = fmt.Errorf("the size of the message set in a fetch response doesn't match the number of remaining bytes (message set size = %d, remaining bytes = %d)", messageSetSize, remain)
+		return
+	}
+
+	watermark = p.HighwaterMarkOffset
+	lastStableOffset = p.LastStableOffset
+	return
 }
 
 func readMessageHeader(r *bufio.Reader, sz int) (offset int64, attributes int8, timestamp int64, remain int, err error) {
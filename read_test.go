@@ -206,3 +206,52 @@ func BenchmarkReadVarInt(b *testing.B) {
 		rb.Reset(b2)
 	}
 }
+
+func TestReadFetchResponseHeaderV11(t *testing.T) {
+	for _, errorCode := range []Error{0, OffsetOutOfRange} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		w.writeInt32(1) // throttle time
+		w.writeInt16(0) // error code
+		w.writeInt32(0) // session ID
+		w.writeArrayLen(1)
+		w.writeString("topic")
+		w.writeArrayLen(1)
+		w.writeInt32(0) // partition
+		w.writeInt16(int16(errorCode))
+		w.writeInt64(10) // high watermark
+		w.writeInt64(8)  // last stable offset
+		w.writeInt64(2)  // log start offset
+		w.writeArrayLen(1)
+		w.writeInt64(3) // aborted producer ID
+		w.writeInt64(4) // aborted first offset
+		w.writeInt32(5) // preferred read replica
+		w.writeInt32(0) // record set size
+
+		throttle, watermark, lastStableOffset, logStartOffset, aborted, preferredReadReplica, remain, err := readFetchResponseHeaderV11(bufio.NewReader(b), b.Len())
+
+		if errorCode != 0 {
+			if err != errorCode {
+				t.Errorf("expected %v, got %v", errorCode, err)
+			}
+			if logStartOffset != 2 {
+				t.Errorf("expected the log start offset to be reported with %v, got %d", errorCode, logStartOffset)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		if remain != 0 {
+			t.Errorf("expected 0 remain, got %v", remain)
+		}
+		if throttle != 1 || watermark != 10 || lastStableOffset != 8 || logStartOffset != 2 || preferredReadReplica != 5 {
+			t.Errorf("unexpected header: throttle=%d watermark=%d lastStableOffset=%d logStartOffset=%d preferredReadReplica=%d",
+				throttle, watermark, lastStableOffset, logStartOffset, preferredReadReplica)
+		}
+		if !reflect.DeepEqual(aborted, []abortedTransaction{{ProducerID: 3, FirstOffset: 4}}) {
+			t.Errorf("unexpected aborted transactions: %+v", aborted)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"sync"
@@ -29,6 +31,11 @@ var (
 	errNotAvailableWithGroup  = errors.New("unavailable when GroupID is set")
 )
 
+// ErrEndOfRange is returned by the methods of a Reader configured with an
+// EndOffset or an EndTime which read messages, once the reader reached the end
+// of all its partitions.
+var ErrEndOfRange = errors.New("kafka reader reached the end of the range of messages it reads")
+
 const (
 	// defaultReadBackoffMax/Min sets the boundaries for how long the reader wait before
 	// polling for new messages
@@ -55,10 +62,53 @@ type Reader struct {
 	done    chan struct{}
 	commits chan commitRequest
 	version int64 // version holds the generation of the spawned readers
-	offset  int64
+	offsets map[int]int64
 	lag     int64
 	closed  bool
 
+	// paused holds the partitions that the program paused, the messages of
+	// paused partitions received by FetchMessage are held until they are
+	// resumed. resumed wakes up FetchMessage when partitions are resumed.
+	paused  pausedPartitions
+	held    []readerMessage
+	resumed chan struct{}
+
+	// assigned holds the readers of the partitions assigned by a consumer
+	// group following the cooperative rebalance protocol, they keep running
+	// across generations while the partitions stay assigned to the reader.
+	// Their contexts are children of assignedCtx, which is cancelled by
+	// cancel.
+	assigned    map[topicPartition]*assignedPartition
+	assignedCtx context.Context
+
+	// unacked is the message returned by the last call to ReadMessage, which
+	// the next call commits when the reader uses CommitOnFetchAck.
+	unacked *readerMessage
+
+	// consumer dispatches the messages to the handlers of the partitions
+	// while Consume runs, it is nil otherwise.
+	consumer *consumer
+
+	// ended holds the partitions read by the reader and whether they reached
+	// the end configured by EndOffset or EndTime.
+	ended map[topicPartition]bool
+
+	// fatal is the error that reading a partition failed with and that the
+	// methods reading messages return, until the partitions are read again.
+	fatal error
+
+	// caughtUp holds the partitions read by the reader and whether the
+	// messages up to their high watermark when the reader started reading
+	// them were returned. caughtUpCh is closed, and caughtUpDone set, once all
+	// the partitions caught up, it is replaced when partitions are added.
+	caughtUp     map[topicPartition]bool
+	caughtUpCh   chan struct{}
+	caughtUpDone bool
+
+	// positions holds the position of the reader in each of its partitions,
+	// reported by PartitionStats.
+	positions partitionPositions
+
 	// reader stats are all made of atomic values, no need for synchronization.
 	once  uint32
 	stctx context.Context
@@ -74,7 +124,113 @@ func (r *Reader) useConsumerGroup() bool { return r.config.GroupID != "" }
 // async commits.
 func (r *Reader) useSyncCommits() bool { return r.config.CommitInterval == 0 }
 
+// decompressionConcurrency returns the number of batches of a fetch response
+// decompressed concurrently, or zero when they are decompressed one at a time.
+func (r *Reader) decompressionConcurrency() int {
+	if !r.config.ParallelDecompression {
+		return 0
+	}
+	return r.config.DecompressionConcurrency
+}
+
+// CaughtUp returns a channel which is closed once the reader returned the
+// messages of all its partitions up to their high watermark at the time it
+// started reading them, for example to know when a cache was loaded from a
+// compacted topic. When the consumer group assigns new partitions to the
+// reader or its offset is changed, the method returns a new channel which is
+// closed once the reader caught up with the partitions again. The partitions
+// which caught up are reported by PartitionStats.
+func (r *Reader) CaughtUp() <-chan struct{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.caughtUpCh == nil {
+		r.caughtUpCh = make(chan struct{})
+	}
+	return r.caughtUpCh
+}
+
+// checkCaughtUp closes the channel returned by CaughtUp if all the partitions
+// of the reader caught up, it must be called with the mutex held.
+func (r *Reader) checkCaughtUp() {
+	if r.caughtUpDone || len(r.caughtUp) == 0 {
+		return
+	}
+	for _, caughtUp := range r.caughtUp {
+		if !caughtUp {
+			return
+		}
+	}
+	if r.caughtUpCh == nil {
+		r.caughtUpCh = make(chan struct{})
+	}
+	close(r.caughtUpCh)
+	r.caughtUpDone = true
+}
+
+// rearmCaughtUp replaces the channel returned by CaughtUp when partitions which
+// did not catch up yet are added, it must be called with the mutex held.
+func (r *Reader) rearmCaughtUp() {
+	if r.caughtUpDone {
+		r.caughtUpCh, r.caughtUpDone = nil, false
+	}
+}
+
+// partitions returns the partitions read by a reader which is not part of a
+// consumer group.
+func (r *Reader) partitions() []int {
+	if len(r.config.Partitions) != 0 {
+		return r.config.Partitions
+	}
+	return []int{r.config.Partition}
+}
+
+// readsPartition reports whether the partition is read by a reader which is not
+// part of a consumer group.
+func (r *Reader) readsPartition(partition int) bool {
+	for _, p := range r.partitions() {
+		if p == partition {
+			return true
+		}
+	}
+	return false
+}
+
+// bounded indicates whether the reader stops at the end of its partitions.
+func (r *Reader) bounded() bool {
+	return r.config.EndOffset != 0 || !r.config.EndTime.IsZero()
+}
+
+// rangeEnded reports whether all the partitions of the reader reached their
+// end, it must be called with the mutex held.
+func (r *Reader) rangeEnded() bool {
+	if !r.bounded() || len(r.ended) == 0 {
+		return false
+	}
+	for _, ended := range r.ended {
+		if !ended {
+			return false
+		}
+	}
+	return true
+}
+
+// startOffsetAt returns the time from which the partitions without committed
+// offsets are read, which is zero for readers which are not part of a group.
+func (r *Reader) startOffsetAt() time.Time {
+	if !r.useConsumerGroup() {
+		return time.Time{}
+	}
+	return r.config.StartOffsetAt
+}
+
 func (r *Reader) unsubscribe() {
+	r.mutex.Lock()
+	r.assigned = nil
+	r.ended = nil
+	r.caughtUp = nil
+	r.positions.reset()
+	r.mutex.Unlock()
+
 	r.cancel()
 	r.join.Wait()
 	// it would be interesting to drain the r.msgs channel at this point since
@@ -88,9 +244,9 @@ func (r *Reader) unsubscribe() {
 }
 
 func (r *Reader) subscribe(assignments []PartitionAssignment) {
-	offsetsByPartition := make(map[int]int64)
+	offsetsByPartition := make(map[topicPartition]int64)
 	for _, assignment := range assignments {
-		offsetsByPartition[assignment.ID] = assignment.Offset
+		offsetsByPartition[assignment.topicPartition()] = assignment.Offset
 	}
 
 	r.mutex.Lock()
@@ -102,6 +258,164 @@ func (r *Reader) subscribe(assignments []PartitionAssignment) {
 	})
 }
 
+// revoke stops the readers of the partitions which were cooperatively assigned
+// to the reader and are not part of the assignments anymore, and returns them.
+func (r *Reader) revoke(assignments []PartitionAssignment) []topicPartition {
+	keep := make(map[topicPartition]bool, len(assignments))
+	for _, assignment := range assignments {
+		keep[assignment.topicPartition()] = true
+	}
+
+	var revoked []topicPartition
+	var done []chan struct{}
+
+	r.mutex.Lock()
+	for tp, a := range r.assigned {
+		if !keep[tp] {
+			a.cancel()
+			delete(r.assigned, tp)
+			delete(r.ended, tp)
+			delete(r.caughtUp, tp)
+			r.positions.remove(tp)
+			revoked = append(revoked, tp)
+			done = append(done, a.done)
+		}
+	}
+	if len(revoked) != 0 {
+		// the messages of the revoked partitions which are still buffered
+		// are dropped.
+		r.version++
+		r.checkCaughtUp()
+	}
+	r.mutex.Unlock()
+
+	for _, ch := range done {
+		<-ch
+	}
+
+	sortTopicPartitions(revoked)
+	if len(revoked) != 0 {
+		r.withLogger(func(l Logger) {
+			l.Printf("revoked partitions: %+v", revoked)
+		})
+	}
+	return revoked
+}
+
+// sortTopicPartitions sorts the partitions by topic and partition.
+func sortTopicPartitions(partitions []topicPartition) {
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].topic != partitions[j].topic {
+			return partitions[i].topic < partitions[j].topic
+		}
+		return partitions[i].partition < partitions[j].partition
+	})
+}
+
+// exportTopicPartitions returns the partitions as passed to
+// OnPartitionsRevoked.
+func exportTopicPartitions(partitions []topicPartition) []TopicPartition {
+	tps := make([]TopicPartition, len(partitions))
+	for i, tp := range partitions {
+		tps[i] = TopicPartition{Topic: tp.topic, Partition: tp.partition}
+	}
+	return tps
+}
+
+// unassigned returns the assignments of the partitions which have no running
+// reader yet.
+func (r *Reader) unassigned(assignments []PartitionAssignment) []PartitionAssignment {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var added []PartitionAssignment
+	for _, assignment := range assignments {
+		if _, ok := r.assigned[assignment.topicPartition()]; !ok {
+			added = append(added, assignment)
+		}
+	}
+	return added
+}
+
+// assign starts the readers of partitions cooperatively assigned to the reader,
+// the readers of the partitions already assigned keep running.
+func (r *Reader) assign(assignments []PartitionAssignment) {
+	if len(assignments) == 0 {
+		return
+	}
+
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		return
+	}
+
+	if r.assigned == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		r.cancel() // stop the readers of previous eager generations
+		r.cancel = cancel
+		r.assigned = make(map[topicPartition]*assignedPartition)
+		r.assignedCtx = ctx
+		r.ended = make(map[topicPartition]bool)
+		r.caughtUp = make(map[topicPartition]bool)
+		r.positions.reset()
+	}
+	r.fatal = nil
+	r.version++
+	r.rearmCaughtUp()
+
+	offsetsByPartition := make(map[topicPartition]int64, len(assignments))
+	for _, assignment := range assignments {
+		ctx, cancel := context.WithCancel(r.assignedCtx)
+		a := &assignedPartition{
+			version: r.version,
+			cancel:  cancel,
+			done:    make(chan struct{}),
+		}
+		tp := assignment.topicPartition()
+		r.assigned[tp] = a
+		r.ended[tp] = false
+		r.caughtUp[tp] = false
+		offsetsByPartition[tp] = assignment.Offset
+
+		r.join.Add(1)
+		go func(tp topicPartition, offset int64) {
+			defer close(a.done)
+			r.runReader(ctx, a.version, tp, offset)
+		}(tp, assignment.Offset)
+	}
+	r.positions.add(offsetsByPartition, true)
+	r.mutex.Unlock()
+
+	r.withLogger(func(l Logger) {
+		l.Printf("assigned partitions: %+v", offsetsByPartition)
+	})
+}
+
+// isAssigned reports whether m was sent by the running reader of a partition
+// cooperatively assigned to the reader.
+func (r *Reader) isAssigned(m readerMessage) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	a, ok := r.assigned[m.topicPartition()]
+	return ok && a.version == m.version
+}
+
+// revokeAll stops the readers of all the partitions cooperatively assigned to
+// the reader, when it leaves the group or loses its partitions.
+func (r *Reader) revokeAll(cg *ConsumerGroup) {
+	revoked := r.revoke(nil)
+	if len(revoked) == 0 {
+		return
+	}
+	r.drainHandlers(revoked, cg.config.RebalanceTimeout)
+	if fn := r.config.OnPartitionsRevoked; fn != nil {
+		r.callRebalanceHook("OnPartitionsRevoked", cg.config.RebalanceTimeout, func(ctx context.Context) {
+			fn(ctx, exportTopicPartitions(revoked))
+		})
+	}
+}
+
 func (r *Reader) waitThrottleTime(throttleTimeMS int32) {
 	if throttleTimeMS == 0 {
 		return
@@ -120,19 +434,29 @@ func (r *Reader) waitThrottleTime(throttleTimeMS int32) {
 // commitOffsetsWithRetry attempts to commit the specified offsets and retries
 // up to the specified number of times
 func (r *Reader) commitOffsetsWithRetry(gen *Generation, offsetStash offsetStash, retries int) (err error) {
-	const (
-		backoffDelayMin = 100 * time.Millisecond
-		backoffDelayMax = 5 * time.Second
-	)
+	defer func() {
+		if err != nil {
+			r.commitFailed(offsetStash, err)
+		}
+	}()
 
 	for attempt := 0; attempt < retries; attempt++ {
 		if attempt != 0 {
-			if !sleep(r.stctx, backoff(attempt, backoffDelayMin, backoffDelayMax)) {
+			if !sleep(r.stctx, r.config.commitBackoff(attempt)) {
 				return
 			}
 		}
 
-		if err = gen.CommitOffsets(offsetStash); err == nil {
+		if err = gen.CommitOffsetsWithMetadata(offsetStash.commits()); err == nil {
+			for topic, offsets := range offsetStash {
+				for _, offset := range offsets {
+					r.positions.committed(topicPartition{topic: topic, partition: offset.Partition}, offset.Offset)
+				}
+			}
+			return
+		}
+		// the commit is rejected again if the metadata is too large.
+		if _, ok := err.(*OffsetMetadataTooLargeError); ok {
 			return
 		}
 	}
@@ -140,22 +464,71 @@ func (r *Reader) commitOffsetsWithRetry(gen *Generation, offsetStash offsetStash
 	return // err will not be nil
 }
 
-// offsetStash holds offsets by topic => partition => offset
-type offsetStash map[string]map[int]int64
+// commitFailed reports the offsets that the reader failed to commit.
+func (r *Reader) commitFailed(offsetStash offsetStash, err error) {
+	r.stats.commitErrs.observe(1)
+	if r.config.OnCommitError != nil {
+		commits := offsetStash.commits()
+		for _, offsets := range commits {
+			sort.Slice(offsets, func(i, j int) bool { return offsets[i].Partition < offsets[j].Partition })
+		}
+		r.config.OnCommitError(commits, err)
+	}
+}
+
+// commitAttempts returns the number of attempts made to commit offsets.
+func (config *ReaderConfig) commitAttempts() int {
+	if config.CommitMaxAttempts > 0 {
+		return config.CommitMaxAttempts
+	}
+	return defaultCommitRetries
+}
+
+// commitBackoff returns the time to wait for before the retry attempt of a
+// commit.
+func (config *ReaderConfig) commitBackoff(attempt int) time.Duration {
+	min, max := config.CommitBackoffMin, config.CommitBackoffMax
+	if min == 0 {
+		min = 100 * time.Millisecond
+	}
+	if max == 0 {
+		max = 5 * time.Second
+	}
+	return backoff(attempt, min, max)
+}
+
+// offsetStash holds offsets by topic => partition => offset and metadata
+type offsetStash map[string]map[int]OffsetCommit
 
-// merge updates the offsetStash with the offsets from the provided messages
+// merge updates the offsetStash with the offsets from the provided messages,
+// a commit of the same offset replaces its metadata.
 func (o offsetStash) merge(commits []commit) {
 	for _, c := range commits {
 		offsetsByPartition, ok := o[c.topic]
 		if !ok {
-			offsetsByPartition = map[int]int64{}
+			offsetsByPartition = map[int]OffsetCommit{}
 			o[c.topic] = offsetsByPartition
 		}
 
-		if offset, ok := offsetsByPartition[c.partition]; !ok || c.offset > offset {
-			offsetsByPartition[c.partition] = c.offset
+		if offset, ok := offsetsByPartition[c.partition]; !ok || c.offset >= offset.Offset {
+			offsetsByPartition[c.partition] = OffsetCommit{
+				Partition: c.partition,
+				Offset:    c.offset,
+				Metadata:  c.metadata,
+			}
+		}
+	}
+}
+
+// commits returns the contents of the offsetStash by topic
+func (o offsetStash) commits() map[string][]OffsetCommit {
+	commits := make(map[string][]OffsetCommit, len(o))
+	for topic, offsetsByPartition := range o {
+		for _, offset := range offsetsByPartition {
+			commits[topic] = append(commits[topic], offset)
 		}
 	}
+	return commits
 }
 
 // reset clears the contents of the offsetStash
@@ -176,7 +549,7 @@ func (r *Reader) commitLoopImmediate(ctx context.Context, gen *Generation) {
 
 		case req := <-r.commits:
 			offsets.merge(req.commits)
-			req.errch <- r.commitOffsetsWithRetry(gen, offsets, defaultCommitRetries)
+			req.errch <- r.commitOffsetsWithRetry(gen, offsets, r.config.commitAttempts())
 			offsets.reset()
 		}
 	}
@@ -193,9 +566,12 @@ func (r *Reader) commitLoopInterval(ctx context.Context, gen *Generation) {
 	offsets := offsetStash{}
 
 	commit := func() {
-		if err := r.commitOffsetsWithRetry(gen, offsets, defaultCommitRetries); err != nil {
+		err := r.commitOffsetsWithRetry(gen, offsets, r.config.commitAttempts())
+		if err != nil {
 			r.withErrorLogger(func(l Logger) { l.Printf(err.Error()) })
-		} else {
+		}
+		// offsets with too much metadata would fail on every tick.
+		if _, ok := err.(*OffsetMetadataTooLargeError); ok || err == nil {
 			offsets.reset()
 		}
 	}
@@ -256,23 +632,53 @@ func (r *Reader) run(cg *ConsumerGroup) {
 		gen, err := cg.Next(r.stctx)
 		if err != nil {
 			if err == r.stctx.Err() {
+				// the reader was closed during a cooperative rebalance.
+				r.revokeAll(cg)
 				return
 			}
 			r.stats.errors.observe(1)
 			r.withErrorLogger(func(l Logger) {
 				l.Printf(err.Error())
 			})
+			if _, fenced := err.(*GroupInstanceFencedError); fenced {
+				r.revokeAll(cg)
+				r.fail(err)
+				return
+			}
+			if err != RebalanceInProgress {
+				// the partitions are lost with the member id.
+				r.revokeAll(cg)
+			}
 			continue
 		}
 
 		r.stats.rebalances.observe(1)
 
-		r.subscribe(gen.Assignments[r.config.Topic])
+		if gen.Protocol == CooperativeRebalance {
+			r.runCooperative(cg, gen)
+			continue
+		}
+		r.revokeAll(cg)
+
+		assignments := gen.assignments()
+		if fn := r.config.OnPartitionsAssigned; fn != nil {
+			r.callRebalanceHook("OnPartitionsAssigned", cg.config.RebalanceTimeout, func(ctx context.Context) {
+				fn(ctx, assignments)
+			})
+		}
+
+		r.subscribe(assignments)
+
+		// the commit loop outlives the generation until the partitions were
+		// revoked, so the commits made when they are revoked are part of the
+		// generation.
+		commitCtx, stopCommits := context.WithCancel(context.Background())
 
 		gen.Start(func(ctx context.Context) {
-			r.commitLoop(ctx, gen)
+			r.commitLoop(commitCtx, gen)
 		})
 		gen.Start(func(ctx context.Context) {
+			defer stopCommits()
 			// wait for the generation to end and then unsubscribe.
 			select {
 			case <-ctx.Done():
@@ -281,10 +687,169 @@ func (r *Reader) run(cg *ConsumerGroup) {
 				// this will be the last loop because the reader is closed.
 			}
 			r.unsubscribe()
+
+			partitions := make([]topicPartition, len(assignments))
+			for i, assignment := range assignments {
+				partitions[i] = assignment.topicPartition()
+			}
+			r.drainHandlers(partitions, cg.config.RebalanceTimeout)
+
+			if fn := r.config.OnPartitionsRevoked; fn != nil {
+				r.callRebalanceHook("OnPartitionsRevoked", cg.config.RebalanceTimeout, func(ctx context.Context) {
+					fn(ctx, exportTopicPartitions(partitions))
+				})
+			}
+		})
+	}
+}
+
+// runCooperative handles a generation of a group following the cooperative
+// rebalance protocol: the readers of the partitions which stay assigned keep
+// running, only the revoked partitions are stopped.  When partitions are
+// revoked, the generation is ended right away so the reader rejoins the group
+// and the partitions are assigned to other members.
+func (r *Reader) runCooperative(cg *ConsumerGroup, gen *Generation) {
+	assignments := gen.assignments()
+
+	// the commit loop runs first so the revoke hook can commit the offsets of
+	// the revoked partitions.
+	commitCtx, stopCommits := context.WithCancel(context.Background())
+	gen.Start(func(ctx context.Context) {
+		r.commitLoop(commitCtx, gen)
+	})
+
+	revoked := r.revoke(assignments)
+	r.drainHandlers(revoked, cg.config.RebalanceTimeout)
+	if fn := r.config.OnPartitionsRevoked; fn != nil && len(revoked) != 0 {
+		r.callRebalanceHook("OnPartitionsRevoked", cg.config.RebalanceTimeout, func(ctx context.Context) {
+			fn(ctx, exportTopicPartitions(revoked))
+		})
+	}
+
+	added := r.unassigned(assignments)
+	if fn := r.config.OnPartitionsAssigned; fn != nil && len(added) != 0 {
+		r.callRebalanceHook("OnPartitionsAssigned", cg.config.RebalanceTimeout, func(ctx context.Context) {
+			fn(ctx, added)
+		})
+	}
+	r.assign(added)
+
+	gen.Start(func(ctx context.Context) {
+		defer stopCommits()
+		if len(revoked) != 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			// the partitions stay assigned during the rebalance.
+		case <-r.stctx.Done():
+			// this will be the last loop because the reader is closed.
+			r.revokeAll(cg)
+		}
+	})
+}
+
+// drainHandlers waits for the handlers running on the revoked partitions when
+// the reader is used with Consume, so the offsets of the messages they handle
+// are committed before the partitions are assigned to other members.
+func (r *Reader) drainHandlers(partitions []topicPartition, timeout time.Duration) {
+	r.mutex.Lock()
+	c, version := r.consumer, r.version
+	r.mutex.Unlock()
+
+	if c == nil || len(partitions) == 0 {
+		return
+	}
+	r.callRebalanceHook("the Consume handlers", timeout, func(ctx context.Context) {
+		c.drain(partitions, version)
+	})
+}
+
+// fail returns err from the calls to FetchMessage until the reader is closed,
+// it is used when the consumer group stopped on an error that retrying would
+// not resolve.
+func (r *Reader) fail(err error) {
+	r.mutex.Lock()
+	version := r.version
+	r.mutex.Unlock()
+
+	for {
+		select {
+		case r.msgs <- readerMessage{version: version, error: err}:
+		case <-r.stctx.Done():
+			return
+		}
+	}
+}
+
+// callRebalanceHook calls fn with a context which expires after timeout, and
+// returns when fn returns or when the context expired, so a function blocking
+// for too long doesn't prevent the reader from moving to the next generation.
+func (r *Reader) callRebalanceHook(name string, timeout time.Duration, fn func(context.Context)) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		r.withErrorLogger(func(l Logger) {
+			l.Printf("%s did not return within the rebalance timeout of %s", name, timeout)
 		})
 	}
 }
 
+// CommitMode is the policy of a Reader in a consumer group for committing the
+// offsets of the messages returned by ReadMessage.
+type CommitMode int
+
+const (
+	// CommitOnRead is the default mode: ReadMessage commits the offset of
+	// each message before returning it, which may commit messages that the
+	// program did not finish processing.
+	CommitOnRead CommitMode = iota
+
+	// CommitOnFetchAck makes ReadMessage acknowledge the message it returned
+	// on the previous call, and commit its offset before fetching the next
+	// one: a message is only committed once the program asked for the next,
+	// which gives at-least-once delivery.  The message returned by the last
+	// call is not committed when the reader is closed or loses its partition,
+	// it is delivered again.
+	//
+	// The acknowledged offsets are committed every CommitInterval, and when
+	// the generation of the group ends, after OnPartitionsRevoked returned.
+	// ReadMessage commits them synchronously when CommitInterval is zero.
+	CommitOnFetchAck
+)
+
+// OffsetOutOfRangePolicy is the policy of a Reader for the offsets that it
+// reads from which are before the first offset of a partition, for example
+// because the messages were deleted by the retention of the topic. Offsets
+// past the last offset of a partition are retried until messages are
+// produced, regardless of the policy.
+type OffsetOutOfRangePolicy int
+
+const (
+	// OffsetResetEarliest is the default policy: the reader skips to the
+	// first offset of the partition.
+	OffsetResetEarliest OffsetOutOfRangePolicy = iota
+
+	// OffsetResetLatest makes the reader skip to the last offset of the
+	// partition, it only reads the messages produced after the reset.
+	OffsetResetLatest
+
+	// OffsetResetError makes the reader return an *OffsetOutOfRangeError
+	// instead of skipping messages. The error is returned again, with a
+	// backoff, until the offset of the reader is changed or the reader is
+	// closed.
+	OffsetResetError
+)
+
 // ReaderConfig is a configuration object used to create new instances of
 // Reader.
 type ReaderConfig struct {
@@ -298,10 +863,39 @@ type ReaderConfig struct {
 	// The topic to read messages from.
 	Topic string
 
+	// GroupTopicPattern optionally makes the consumer group read the topics
+	// whose names match the pattern, instead of Topic. The topics of the
+	// cluster are listed every GroupTopicRefreshInterval, and the group is
+	// rebalanced when topics matching the pattern are created or deleted, so
+	// the reader starts reading new topics without being restarted. Either
+	// Topic or GroupTopicPattern may be assigned, but not both.
+	//
+	// The Topic of the messages, PartitionAssignment, TopicPartition and
+	// PartitionStats tells which topic they belong to, while the Topic of
+	// ReaderStats is empty.
+	//
+	// Only used when GroupID is set
+	GroupTopicPattern *regexp.Regexp
+
+	// GroupTopicRefreshInterval indicates how often the topics matching the
+	// GroupTopicPattern are listed.
+	//
+	// Default: 1m
+	//
+	// Only used when GroupTopicPattern is set
+	GroupTopicRefreshInterval time.Duration
+
 	// Partition to read messages from.  Either Partition or GroupID may
 	// be assigned, but not both
 	Partition int
 
+	// Partitions optionally makes the reader read multiple partitions of the
+	// topic instead of Partition, without a consumer group. The messages of
+	// the partitions are merged, and the reader tracks the offset of each
+	// partition, which can be changed with SetPartitionOffset. Either
+	// Partitions or GroupID may be assigned, but not both.
+	Partitions []int
+
 	// An dialer used to open connections to the kafka server. This field is
 	// optional, if nil, the default dialer is used instead.
 	Dialer *Dialer
@@ -325,6 +919,9 @@ type ReaderConfig struct {
 	// GroupBalancers is the priority-ordered list of client-side consumer group
 	// balancing strategies that will be offered to the coordinator.  The first
 	// strategy that all group members support will be chosen by the leader.
+	// The readers keep reading the partitions which stay assigned to them
+	// while the group is rebalanced when the chosen strategy follows the
+	// CooperativeRebalance protocol, see CooperativeStickyGroupBalancer.
 	//
 	// Default: [Range, RoundRobin]
 	//
@@ -347,6 +944,39 @@ type ReaderConfig struct {
 	// Only used when GroupID is set
 	CommitInterval time.Duration
 
+	// CommitMode sets how ReadMessage commits the offsets of the messages it
+	// returns, see CommitOnFetchAck for at-least-once delivery.  The mode
+	// doesn't apply to FetchMessage and FetchMessages.
+	//
+	// Default: CommitOnRead
+	//
+	// Only used when GroupID is set
+	CommitMode CommitMode
+
+	// CommitMaxAttempts is the number of times the reader tries to commit
+	// offsets before giving up, waiting between CommitBackoffMin and
+	// CommitBackoffMax between the attempts. It applies to the commits of
+	// CommitMessages as well as those made every CommitInterval. The commits
+	// which fail are counted by the CommitErrors stat.
+	//
+	// Default: 3, 100ms and 5s
+	//
+	// Only used when GroupID is set
+	CommitMaxAttempts int
+	CommitBackoffMin  time.Duration
+	CommitBackoffMax  time.Duration
+
+	// OnCommitError is called with the offsets that the reader failed to
+	// commit after CommitMaxAttempts attempts, indexed by topic and sorted by
+	// partition, and the error of the last attempt. Unless a later commit
+	// succeeds, the messages before these offsets are read again by the member
+	// of the group which reads their partitions next, including when the
+	// commit was made as the generation ended. It is called by the goroutine
+	// committing the offsets and must not block.
+	//
+	// Only used when GroupID is set
+	OnCommitError func(offsets map[string][]OffsetCommit, err error)
+
 	// PartitionWatchInterval indicates how often a reader checks for partition changes.
 	// If a reader sees a partition change (such as a partition add) it will rebalance the group
 	// picking up new partitions.
@@ -368,6 +998,15 @@ type ReaderConfig struct {
 	// Only used when GroupID is set
 	SessionTimeout time.Duration
 
+	// GroupInstanceID optionally makes the reader a static member of the
+	// consumer group, which keeps its partitions when it restarts within the
+	// SessionTimeout, see ConsumerGroupConfig.GroupInstanceID. If another
+	// member joins the group with the same ID, the reader stops consuming and
+	// its methods return a *GroupInstanceFencedError.
+	//
+	// Only used when GroupID is set
+	GroupInstanceID string
+
 	// RebalanceTimeout optionally sets the length of time the coordinator will wait
 	// for members to join as part of a rebalance.  For kafka servers under higher
 	// load, it may be useful to set this value higher.
@@ -383,6 +1022,40 @@ type ReaderConfig struct {
 	// Default: 5s
 	JoinGroupBackoff time.Duration
 
+	// OnPartitionsAssigned is an optional function called with the partitions
+	// assigned to the reader when it joins a new generation of the group, and
+	// the offsets that they are read from, before the reader starts reading
+	// them. The list of assignments may be empty.
+	//
+	// With a GroupBalancer following the CooperativeRebalance protocol, like
+	// CooperativeStickyGroupBalancer, the function is only called with the
+	// partitions newly assigned to the reader, the partitions which stay
+	// assigned are consumed during the rebalance.
+	//
+	// The context passed to the function expires after RebalanceTimeout, the
+	// reader stops waiting for the function to return at that point.
+	//
+	// Only used when GroupID is set
+	OnPartitionsAssigned func(ctx context.Context, assignments []PartitionAssignment)
+
+	// OnPartitionsRevoked is an optional function called with the partitions
+	// which were assigned to the reader when its generation of the group ends,
+	// after the reader stopped reading them and before the generation's final
+	// commit. Messages committed by the function with CommitMessages are part
+	// of the generation, which allows a clean hand off of the partitions. The
+	// function is called when the reader is closed as well, but the reader no
+	// longer accepts commits then.
+	//
+	// With a GroupBalancer following the CooperativeRebalance protocol, the
+	// function is only called with the partitions taken away from the reader,
+	// which rejoins the group after the function returned.
+	//
+	// The context passed to the function expires after RebalanceTimeout, the
+	// reader stops waiting for the function to return at that point.
+	//
+	// Only used when GroupID is set
+	OnPartitionsRevoked func(ctx context.Context, partitions []TopicPartition)
+
 	// RetentionTime optionally sets the length of time the consumer group will be saved
 	// by the broker
 	//
@@ -400,6 +1073,46 @@ type ReaderConfig struct {
 	// Only used when GroupID is set
 	StartOffset int64
 
+	// StartOffsetAt optionally makes the consumer group begin consuming the
+	// partitions without a committed offset at the first message with a time
+	// equal or greater to it, instead of StartOffset. The offset is looked up
+	// by the reader of each partition when it starts reading it, partitions
+	// without messages after that time are read from their last offset.
+	//
+	// Only used when GroupID is set
+	StartOffsetAt time.Time
+
+	// OffsetOutOfRangePolicy sets what the reader does when it reads from an
+	// offset before the first offset of a partition, either the committed
+	// offset of the consumer group or the one set by SetOffset. The resets
+	// are counted by the OffsetResets stat.
+	//
+	// Default: OffsetResetEarliest
+	OffsetOutOfRangePolicy OffsetOutOfRangePolicy
+
+	// EndOffset and EndTime bound the messages read from each partition: the
+	// reader stops reading a partition at EndOffset (exclusive), or at the
+	// first message with a time equal or greater to EndTime. Setting
+	// EndOffset to LastOffset stops at the last offset of the partition when
+	// the reader starts reading it, so only the messages which exist at that
+	// time are read. Once all the partitions of the reader, or all those
+	// assigned to it by the consumer group, reached their end, the methods
+	// reading messages return ErrEndOfRange.
+	//
+	// Default: 0 and the zero time, the partitions are read without end
+	EndOffset int64
+	EndTime   time.Time
+
+	// SkipBrokenMessages makes the reader skip the messages which cannot be
+	// read instead of retrying them, which blocks their partition forever:
+	// the records that the broker reports as corrupt, and the record batches
+	// which fail to be decompressed or decoded. OnBrokenMessage is called
+	// with the topic, the partition, the offset and the error of each record
+	// or batch before it is skipped, from the goroutine reading the partition.
+	// The skipped records and batches are counted by the BrokenMessages stat.
+	SkipBrokenMessages bool
+	OnBrokenMessage    func(topic string, partition int, offset int64, err error)
+
 	// BackoffDelayMin optionally sets the smallest amount of time the reader will wait before
 	// polling for new messages
 	//
@@ -412,6 +1125,25 @@ type ReaderConfig struct {
 	// Default: 1s
 	ReadBackoffMax time.Duration
 
+	// If not nil, Backoff returns the time to wait for before the given retry
+	// attempt to read a partition, starting from 1 for the first retry, after
+	// connecting to the brokers or fetching messages failed with err, instead
+	// of computing it from ReadBackoffMin and ReadBackoffMax.
+	Backoff func(attempt int, err error) time.Duration
+
+	// If not nil, OnError is called with the errors that reading the
+	// partitions fails with, including those which are retried and not
+	// returned to the program. It is called concurrently for different
+	// partitions and must not block.
+	//
+	// The errors that retrying does not fix, like the credentials of the
+	// dialer or the permissions on the topic being rejected, or the topic not
+	// existing after MaxAttempts attempts, are fatal: the partition is no
+	// longer read and the methods reading messages keep returning the error,
+	// until the offset of the reader is changed or the consumer group
+	// rebalances.
+	OnError func(err error)
+
 	// If not nil, specifies a logger used to report internal changes within the
 	// reader.
 	Logger Logger
@@ -422,15 +1154,70 @@ type ReaderConfig struct {
 
 	// IsolationLevel controls the visibility of transactional records.
 	// ReadUncommitted makes all records visible. With ReadCommitted only
-	// non-transactional and committed records are visible.
+	// non-transactional and committed records are visible, and the lag of the
+	// reader is measured from the last stable offset of the partition.
 	IsolationLevel IsolationLevel
 
+	// RackID is the rack of the reader (client.rack in the java client). When
+	// set, the partitions are read from the replica that their leader suggests
+	// for the rack, if the brokers have a replica.selector.class configured
+	// (KIP-392). The reader goes back to the leader if reading from the
+	// replica fails, and asks the leader again for the replica to read from
+	// every 5 minutes. It requires kafka 2.4 or above.
+	RackID string
+
+	// ParallelDecompression enables decompressing the record batches of a
+	// fetch response concurrently, which helps when reading large compressed
+	// batches from a partition is bound by the CPU time spent decompressing
+	// them. The messages are still returned in offset order.
+	ParallelDecompression bool
+
+	// DecompressionConcurrency is the maximum number of batches of a fetch
+	// response which are read ahead and decompressed concurrently when
+	// ParallelDecompression is set.
+	//
+	// Default: runtime.GOMAXPROCS(0)
+	DecompressionConcurrency int
+
 	// Limit of how many attempts will be made before delivering the error.
 	//
 	// The default is to try 3 times.
 	MaxAttempts int
+
+	// ConsumeMaxAttempts is the number of times Consume calls the handler
+	// with the same messages before stopping their partition, and
+	// ConsumeBackoffMin and ConsumeBackoffMax bound the time it waits between
+	// the attempts.
+	//
+	// Default: 3 attempts, waiting between 100ms and 1s
+	ConsumeMaxAttempts int
+	ConsumeBackoffMin  time.Duration
+	ConsumeBackoffMax  time.Duration
+
+	// Interceptors are called in order on each message before it is returned
+	// by the methods reading messages, or passed to the handlers of Consume.
+	// They may read the headers of the message and replace its Key, Value,
+	// or Headers, for example with the value decoded from the schema that a
+	// header refers to.
+	//
+	// When an interceptor returns an error, the message is passed to
+	// BadMessageHandler, or the method reading messages returns a
+	// *ReaderInterceptorError if BadMessageHandler is nil. The message is not
+	// returned again by the next calls either way.
+	Interceptors []ReaderInterceptor
+
+	// BadMessageHandler is called with the messages that an interceptor
+	// failed on and the error of the interceptor, before the reader moves to
+	// the next message. When it returns nil the message is skipped, a
+	// handler returning nil without doing anything skips all such messages.
+	// The errors it returns are returned by the method reading messages.
+	BadMessageHandler func(ctx context.Context, msg Message, err error) error
 }
 
+// ReaderInterceptor is the signature of the functions called by a Reader on the
+// messages it reads, see ReaderConfig.Interceptors.
+type ReaderInterceptor func(ctx context.Context, msg *Message) error
+
 // Validate method validates ReaderConfig properties.
 func (config *ReaderConfig) Validate() error {
 
@@ -438,10 +1225,23 @@ func (config *ReaderConfig) Validate() error {
 		return errors.New("cannot create a new kafka reader with an empty list of broker addresses")
 	}
 
-	if len(config.Topic) == 0 {
+	if len(config.Topic) == 0 && config.GroupTopicPattern == nil {
 		return errors.New("cannot create a new kafka reader with an empty topic")
 	}
 
+	if config.GroupTopicPattern != nil {
+		if config.GroupID == "" {
+			return errors.New("GroupTopicPattern may only be specified with GroupID")
+		}
+		if len(config.Topic) != 0 {
+			return errors.New("either Topic or GroupTopicPattern may be specified, but not both")
+		}
+	}
+
+	if config.GroupTopicRefreshInterval < 0 {
+		return errors.New(fmt.Sprintf("GroupTopicRefreshInterval out of bounds: %d", config.GroupTopicRefreshInterval))
+	}
+
 	if config.Partition < 0 || config.Partition >= math.MaxInt32 {
 		return errors.New(fmt.Sprintf("partition number out of bounds: %d", config.Partition))
 	}
@@ -458,6 +1258,25 @@ func (config *ReaderConfig) Validate() error {
 		return errors.New("either Partition or GroupID may be specified, but not both")
 	}
 
+	if len(config.Partitions) != 0 {
+		if config.GroupID != "" {
+			return errors.New("either Partitions or GroupID may be specified, but not both")
+		}
+		if config.Partition != 0 {
+			return errors.New("either Partition or Partitions may be specified, but not both")
+		}
+		seen := make(map[int]bool, len(config.Partitions))
+		for _, partition := range config.Partitions {
+			if partition < 0 || partition >= math.MaxInt32 {
+				return errors.New(fmt.Sprintf("partition number out of bounds: %d", partition))
+			}
+			if seen[partition] {
+				return errors.New(fmt.Sprintf("partition %d is listed more than once in Partitions", partition))
+			}
+			seen[partition] = true
+		}
+	}
+
 	if config.MinBytes > config.MaxBytes {
 		return errors.New(fmt.Sprintf("minimum batch size greater than the maximum (min = %d, max = %d)", config.MinBytes, config.MaxBytes))
 	}
@@ -470,10 +1289,48 @@ func (config *ReaderConfig) Validate() error {
 		return errors.New(fmt.Sprintf("ReadBackoffMin out of bounds: %d", config.ReadBackoffMin))
 	}
 
-	return nil
-}
+	if config.DecompressionConcurrency < 0 {
+		return errors.New(fmt.Sprintf("DecompressionConcurrency out of bounds: %d", config.DecompressionConcurrency))
+	}
 
-// ReaderStats is a data structure returned by a call to Reader.Stats that exposes
+	if config.CommitMode != CommitOnRead && config.CommitMode != CommitOnFetchAck {
+		return errors.New(fmt.Sprintf("invalid commit mode: %d", config.CommitMode))
+	}
+
+	if config.CommitMaxAttempts < 0 {
+		return errors.New(fmt.Sprintf("CommitMaxAttempts out of bounds: %d", config.CommitMaxAttempts))
+	}
+
+	if config.CommitBackoffMin < 0 {
+		return errors.New(fmt.Sprintf("CommitBackoffMin out of bounds: %d", config.CommitBackoffMin))
+	}
+
+	if config.CommitBackoffMax < 0 {
+		return errors.New(fmt.Sprintf("CommitBackoffMax out of bounds: %d", config.CommitBackoffMax))
+	}
+
+	if config.ConsumeMaxAttempts < 0 {
+		return errors.New(fmt.Sprintf("ConsumeMaxAttempts out of bounds: %d", config.ConsumeMaxAttempts))
+	}
+
+	if config.ConsumeBackoffMin < 0 || config.ConsumeBackoffMax < 0 {
+		return errors.New(fmt.Sprintf("Consume backoff out of bounds: %d to %d", config.ConsumeBackoffMin, config.ConsumeBackoffMax))
+	}
+
+	if config.EndOffset < 0 && config.EndOffset != LastOffset {
+		return errors.New(fmt.Sprintf("invalid end offset: %d", config.EndOffset))
+	}
+
+	switch config.OffsetOutOfRangePolicy {
+	case OffsetResetEarliest, OffsetResetLatest, OffsetResetError:
+	default:
+		return errors.New(fmt.Sprintf("invalid offset out of range policy: %d", config.OffsetOutOfRangePolicy))
+	}
+
+	return nil
+}
+
+// ReaderStats is a data structure returned by a call to Reader.Stats that exposes
 // details about the behavior of the reader.
 type ReaderStats struct {
 	Dials      int64 `metric:"kafka.reader.dial.count"      type:"counter"`
@@ -484,6 +1341,18 @@ type ReaderStats struct {
 	Timeouts   int64 `metric:"kafka.reader.timeout.count"   type:"counter"`
 	Errors     int64 `metric:"kafka.reader.error.count"     type:"counter"`
 
+	// OffsetResets counts the times the reader skipped to the first or last
+	// offset of a partition because it was reading before the first offset.
+	OffsetResets int64 `metric:"kafka.reader.offset_reset.count" type:"counter"`
+
+	// BrokenMessages counts the records and record batches that the reader
+	// skipped because they could not be read, see SkipBrokenMessages.
+	BrokenMessages int64 `metric:"kafka.reader.broken_message.count" type:"counter"`
+
+	// CommitErrors counts the commits of offsets which failed after all their
+	// attempts, see CommitMaxAttempts.
+	CommitErrors int64 `metric:"kafka.reader.commit_error.count" type:"counter"`
+
 	DialTime   DurationStats `metric:"kafka.reader.dial.seconds"`
 	ReadTime   DurationStats `metric:"kafka.reader.read.seconds"`
 	WaitTime   DurationStats `metric:"kafka.reader.wait.seconds"`
@@ -498,7 +1367,10 @@ type ReaderStats struct {
 	QueueLength   int64         `metric:"kafka.reader.queue.length"    type:"gauge"`
 	QueueCapacity int64         `metric:"kafka.reader.queue.capacity"  type:"gauge"`
 
-	ClientID  string `tag:"client_id"`
+	ClientID string `tag:"client_id"`
+
+	// Topic is empty when the reader reads the topics matching a
+	// GroupTopicPattern.
 	Topic     string `tag:"topic"`
 	Partition string `tag:"partition"`
 
@@ -509,6 +1381,35 @@ type ReaderStats struct {
 	DeprecatedFetchesWithTypo int64 `metric:"kafak.reader.fetch.count" type:"counter"`
 }
 
+// PartitionStats is the position of a Reader in one of its partitions,
+// returned by a call to Reader.PartitionStats.
+type PartitionStats struct {
+	Topic     string
+	Partition int
+
+	// Offset is the offset of the next message of the partition returned by
+	// the reader, and CommittedOffset the last offset committed for the
+	// partition by the consumer group. They are -1 when unknown.
+	Offset          int64
+	CommittedOffset int64
+
+	// HighWaterMark is the high watermark of the partition in the last fetch
+	// response, and Lag the number of messages between Offset and it, or the
+	// last stable offset of the partition with the ReadCommitted isolation
+	// level. Lag is zero until both are known.
+	HighWaterMark int64
+	Lag           int64
+
+	// LastFetch is the time of the last successful fetch from the partition,
+	// and LastError the last error that reading the partition failed with.
+	LastFetch time.Time
+	LastError error
+
+	// CaughtUp is true once the reader returned the messages of the partition
+	// up to its high watermark when the reader started reading it.
+	CaughtUp bool
+}
+
 // readerStats is a struct that contains statistics on a reader.
 type readerStats struct {
 	dials      counter
@@ -518,6 +1419,9 @@ type readerStats struct {
 	rebalances counter
 	timeouts   counter
 	errors     counter
+	resets     counter
+	broken     counter
+	commitErrs counter
 	dialTime   summary
 	readTime   summary
 	waitTime   summary
@@ -581,13 +1485,30 @@ func NewReader(config ReaderConfig) *Reader {
 		config.QueueCapacity = 100
 	}
 
+	if config.DecompressionConcurrency == 0 {
+		config.DecompressionConcurrency = runtime.GOMAXPROCS(0)
+	}
+
 	if config.MaxAttempts == 0 {
 		config.MaxAttempts = 3
 	}
 
-	// when configured as a consumer group; stats should report a partition of -1
+	if config.ConsumeMaxAttempts == 0 {
+		config.ConsumeMaxAttempts = 3
+	}
+
+	if config.ConsumeBackoffMin == 0 {
+		config.ConsumeBackoffMin = defaultReadBackoffMin
+	}
+
+	if config.ConsumeBackoffMax == 0 {
+		config.ConsumeBackoffMax = defaultReadBackoffMax
+	}
+
+	// when configured as a consumer group or with multiple partitions; stats
+	// should report a partition of -1
 	readerStatsPartition := config.Partition
-	if config.GroupID != "" {
+	if config.GroupID != "" || len(config.Partitions) != 0 {
 		readerStatsPartition = -1
 	}
 
@@ -605,7 +1526,7 @@ func NewReader(config ReaderConfig) *Reader {
 		cancel:  func() {},
 		commits: make(chan commitRequest, config.QueueCapacity),
 		stop:    stop,
-		offset:  FirstOffset,
+		resumed: make(chan struct{}, 1),
 		stctx:   stctx,
 		stats: &readerStats{
 			dialTime:   makeSummary(),
@@ -620,18 +1541,32 @@ func NewReader(config ReaderConfig) *Reader {
 		version: version,
 	}
 
+	if !r.useConsumerGroup() {
+		r.offsets = make(map[int]int64)
+		for _, partition := range r.partitions() {
+			r.offsets[partition] = FirstOffset
+		}
+	}
+
 	if r.useConsumerGroup() {
 		r.done = make(chan struct{})
+		var topics []string
+		if r.config.GroupTopicPattern == nil {
+			topics = []string{r.config.Topic}
+		}
 		cg, err := NewConsumerGroup(ConsumerGroupConfig{
 			ID:                     r.config.GroupID,
 			Brokers:                r.config.Brokers,
 			Dialer:                 r.config.Dialer,
-			Topics:                 []string{r.config.Topic},
+			Topics:                 topics,
+			TopicPattern:           r.config.GroupTopicPattern,
+			TopicRefreshInterval:   r.config.GroupTopicRefreshInterval,
 			GroupBalancers:         r.config.GroupBalancers,
 			HeartbeatInterval:      r.config.HeartbeatInterval,
 			PartitionWatchInterval: r.config.PartitionWatchInterval,
 			WatchPartitionChanges:  r.config.WatchPartitionChanges,
 			SessionTimeout:         r.config.SessionTimeout,
+			GroupInstanceID:        r.config.GroupInstanceID,
 			RebalanceTimeout:       r.config.RebalanceTimeout,
 			JoinGroupBackoff:       r.config.JoinGroupBackoff,
 			RetentionTime:          r.config.RetentionTime,
@@ -686,11 +1621,16 @@ func (r *Reader) Close() error {
 //
 // If consumer groups are used, ReadMessage will automatically commit the
 // offset when called. Note that this could result in an offset being committed
-// before the message is fully processed.
+// before the message is fully processed, unless the reader is configured with
+// CommitOnFetchAck.
 //
 // If more fine grained control of when offsets are  committed is required, it
 // is recommended to use FetchMessage with CommitMessages instead.
 func (r *Reader) ReadMessage(ctx context.Context) (Message, error) {
+	if r.useConsumerGroup() && r.config.CommitMode == CommitOnFetchAck {
+		return r.readMessageAck(ctx)
+	}
+
 	m, err := r.FetchMessage(ctx)
 	if err != nil {
 		return Message{}, err
@@ -705,6 +1645,55 @@ func (r *Reader) ReadMessage(ctx context.Context) (Message, error) {
 	return m, nil
 }
 
+// readMessageAck is ReadMessage with CommitOnFetchAck: the message returned by
+// the previous call is committed before the next message is fetched.
+func (r *Reader) readMessageAck(ctx context.Context) (Message, error) {
+	if err := r.ack(ctx); err != nil {
+		return Message{}, err
+	}
+
+	r.activateReadLag()
+
+	m, _, _ := r.nextMessage(ctx, true)
+	if m.error != nil {
+		return Message{}, m.error
+	}
+
+	r.mutex.Lock()
+	r.unacked = &m
+	r.mutex.Unlock()
+
+	return m.message, nil
+}
+
+// ack commits the message returned by the last call to ReadMessage.  The
+// message is not committed if its partition was revoked since, it was
+// delivered again to the member that the partition was assigned to.  If the
+// commit fails, the next call to ack retries it.
+func (r *Reader) ack(ctx context.Context) error {
+	r.mutex.Lock()
+	m := r.unacked
+	current := m != nil && m.version == r.version
+	r.mutex.Unlock()
+
+	if m == nil {
+		return nil
+	}
+
+	if current || r.isAssigned(*m) {
+		if err := r.CommitMessages(ctx, m.message); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	if r.unacked == m {
+		r.unacked = nil
+	}
+	r.mutex.Unlock()
+	return nil
+}
+
 // FetchMessage reads and return the next message from the r. The method call
 // blocks until a message becomes available, or an error occurs. The program
 // may also specify a context to asynchronously cancel the blocking operation.
@@ -716,64 +1705,326 @@ func (r *Reader) ReadMessage(ctx context.Context) (Message, error) {
 func (r *Reader) FetchMessage(ctx context.Context) (Message, error) {
 	r.activateReadLag()
 
+	m, _, _ := r.nextMessage(ctx, true)
+	return m.message, m.error
+}
+
+// FetchMessages reads and returns up to max messages from the r. The method
+// call blocks until a message becomes available, or an error occurs, then
+// returns the messages that the reader already fetched without waiting for
+// more.
+//
+// The messages of a call all belong to the same generation of the consumer
+// group: when the group is rebalanced while the messages are read, the call
+// returns the messages of the previous generation and the next call returns
+// the messages of the new one. Errors are returned once the messages read
+// before them were returned, by the next call.
+//
+// Like FetchMessage, FetchMessages does not commit offsets automatically when
+// using consumer groups. The messages can be passed to CommitMessages.
+func (r *Reader) FetchMessages(ctx context.Context, max int) ([]Message, error) {
+	if max < 1 {
+		return nil, fmt.Errorf("kafka.(*Reader).FetchMessages: max must be at least 1, got %d", max)
+	}
+	msgs, _, err := r.fetchMessages(ctx, max)
+	return msgs, err
+}
+
+// fetchMessages is FetchMessages, it also returns the version of the reader
+// that accepted the messages.
+func (r *Reader) fetchMessages(ctx context.Context, max int) ([]Message, int64, error) {
+	r.activateReadLag()
+
+	m, version, _ := r.nextMessage(ctx, true)
+	if m.error != nil {
+		return nil, version, m.error
+	}
+
+	n := max
+	if queued := len(r.msgs) + 1; queued < n {
+		n = queued
+	}
+	msgs := append(make([]Message, 0, n), m.message)
+
+	for len(msgs) < max {
+		m, v, ok := r.nextMessage(ctx, false)
+		if !ok {
+			break
+		}
+		if m.error != nil || v != version {
+			// hand the message to the next call.
+			r.mutex.Lock()
+			r.held = append([]readerMessage{m}, r.held...)
+			r.mutex.Unlock()
+			break
+		}
+		msgs = append(msgs, m.message)
+	}
+
+	return msgs, version, nil
+}
+
+// nextMessage returns the next message of the reader and the version of the
+// reader that accepted it. When block is false, it returns false instead of
+// waiting if no message is available.
+func (r *Reader) nextMessage(ctx context.Context, block bool) (readerMessage, int64, bool) {
 	for {
 		r.mutex.Lock()
 
 		if !r.closed && r.version == 0 {
-			r.start(map[int]int64{r.config.Partition: r.offset})
+			r.start(r.copyOffsets())
 		}
 
 		version := r.version
+		fatal := r.fatal
+		m, held := r.unhold()
+		ended := !held && r.rangeEnded()
 		r.mutex.Unlock()
 
-		select {
-		case <-ctx.Done():
-			return Message{}, ctx.Err()
+		if fatal != nil {
+			return readerMessage{error: fatal}, version, true
+		}
+
+		if ended {
+			return readerMessage{error: ErrEndOfRange}, version, true
+		}
 
-		case m, ok := <-r.msgs:
-			if !ok {
-				return Message{}, io.EOF
+		if !held {
+			if block {
+				select {
+				case <-ctx.Done():
+					return readerMessage{error: ctx.Err()}, version, true
+
+				case <-r.resumed:
+					continue
+
+				case msg, ok := <-r.msgs:
+					if !ok {
+						return readerMessage{error: io.EOF}, version, true
+					}
+					m = msg
+				}
+			} else {
+				select {
+				case msg, ok := <-r.msgs:
+					if !ok {
+						return readerMessage{error: io.EOF}, version, true
+					}
+					m = msg
+				default:
+					return readerMessage{}, version, false
+				}
 			}
 
-			if m.version >= version {
+			// the messages of paused partitions which were already fetched
+			// are held until the partitions are resumed.
+			if m.error == nil && r.paused.isPaused(m.topicPartition()) {
 				r.mutex.Lock()
+				r.held = append(r.held, m)
+				r.mutex.Unlock()
+				continue
+			}
+		}
 
-				switch {
-				case m.error != nil:
-				case version == r.version:
-					r.offset = m.message.Offset + 1
-					r.lag = m.watermark - r.offset
+		if m.version >= version || r.isAssigned(m) {
+			if m.end {
+				r.mutex.Lock()
+				if _, ok := r.ended[m.topicPartition()]; ok {
+					r.ended[m.topicPartition()] = true
 				}
+				r.mutex.Unlock()
+				continue
+			}
 
+			if m.caughtUp {
+				r.mutex.Lock()
+				if _, ok := r.caughtUp[m.topicPartition()]; ok {
+					r.caughtUp[m.topicPartition()] = true
+					r.positions.caughtUp(m.topicPartition())
+					r.checkCaughtUp()
+				}
 				r.mutex.Unlock()
+				continue
+			}
+
+			r.mutex.Lock()
 
-				switch m.error {
-				case nil:
-				case io.EOF:
-					// io.EOF is used as a marker to indicate that the stream
-					// has been closed, in case it was received from the inner
-					// reader we don't want to confuse the program and replace
-					// the error with io.ErrUnexpectedEOF.
-					m.error = io.ErrUnexpectedEOF
+			switch {
+			case m.fatal:
+				r.fatal = m.error
+			case m.error != nil:
+			case version == r.version:
+				if r.offsets == nil {
+					r.offsets = make(map[int]int64)
 				}
+				r.offsets[m.message.Partition] = m.message.Offset + 1
+				r.lag = m.watermark - (m.message.Offset + 1)
+				fallthrough
+			default:
+				r.positions.consumed(m.topicPartition(), m.message.Offset+1)
+			}
+
+			r.mutex.Unlock()
+
+			switch m.error {
+			case nil:
+			case io.EOF:
+				// io.EOF is used as a marker to indicate that the stream
+				// has been closed, in case it was received from the inner
+				// reader we don't want to confuse the program and replace
+				// the error with io.ErrUnexpectedEOF.
+				m.error = io.ErrUnexpectedEOF
+			}
 
-				return m.message, m.error
+			if m.error == nil && len(r.config.Interceptors) != 0 {
+				skip, err := r.intercept(ctx, &m.message)
+				if skip {
+					continue
+				}
+				m.error = err
 			}
+
+			return m, version, true
+		}
+	}
+}
+
+// intercept passes the message through the interceptors of the reader, it
+// returns true if the message must be skipped. The errors of interceptors go to
+// the BadMessageHandler if there is one.
+func (r *Reader) intercept(ctx context.Context, msg *Message) (bool, error) {
+	for i, interceptor := range r.config.Interceptors {
+		err := interceptor(ctx, msg)
+		if err == nil {
+			continue
+		}
+		if r.config.BadMessageHandler == nil {
+			return false, &ReaderInterceptorError{Interceptor: i, Message: *msg, Err: err}
 		}
+		if err := r.config.BadMessageHandler(ctx, *msg, err); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// unhold removes and returns the first message held for a partition which is
+// not paused anymore, or the first error left by FetchMessages. It must be
+// called with the mutex held.
+func (r *Reader) unhold() (readerMessage, bool) {
+	for i, m := range r.held {
+		if m.error != nil || !r.paused.isPaused(m.topicPartition()) {
+			r.held = append(r.held[:i], r.held[i+1:]...)
+			return m, true
+		}
+	}
+	return readerMessage{}, false
+}
+
+// Pause stops fetching messages from the partitions, without leaving the
+// consumer group when the reader is part of one. FetchMessage and ReadMessage
+// don't return the messages of paused partitions, and block when all the
+// partitions of the reader are paused, until they are resumed.
+//
+// The partitions stay paused across rebalances of the consumer group, until
+// they are resumed. With a GroupTopicPattern, the partitions are paused in all
+// the topics read by the reader, see PauseTopicPartitions.
+func (r *Reader) Pause(partitions ...int) {
+	r.paused.pause(anyTopic(partitions)...)
+	r.withLogger(func(log Logger) {
+		log.Printf("paused partitions %v of %s", partitions, r.topicName())
+	})
+}
+
+// Resume resumes fetching messages from partitions paused by Pause.
+func (r *Reader) Resume(partitions ...int) {
+	r.resume(anyTopic(partitions)...)
+	r.withLogger(func(log Logger) {
+		log.Printf("resumed partitions %v of %s", partitions, r.topicName())
+	})
+}
+
+// PauseTopicPartitions is like Pause, but pauses the partitions of a single
+// topic, for readers of the topics matching a GroupTopicPattern. A partition
+// with an empty Topic is paused in all the topics.
+func (r *Reader) PauseTopicPartitions(partitions ...TopicPartition) {
+	r.paused.pause(importTopicPartitions(partitions)...)
+	r.withLogger(func(log Logger) {
+		log.Printf("paused partitions %+v of %s", partitions, r.topicName())
+	})
+}
+
+// ResumeTopicPartitions resumes fetching messages from partitions paused by
+// Pause or PauseTopicPartitions. Resuming a partition with an empty Topic
+// resumes it in all the topics.
+func (r *Reader) ResumeTopicPartitions(partitions ...TopicPartition) {
+	r.resume(importTopicPartitions(partitions)...)
+	r.withLogger(func(log Logger) {
+		log.Printf("resumed partitions %+v of %s", partitions, r.topicName())
+	})
+}
+
+// topicName returns the topic of the reader as it appears in its logs, the
+// topics matching GroupTopicPattern are named after the pattern.
+func (r *Reader) topicName() string {
+	if r.config.GroupTopicPattern != nil {
+		return fmt.Sprintf("the topics matching %s", r.config.GroupTopicPattern)
+	}
+	return r.config.Topic
+}
+
+// resume resumes the partitions and wakes up FetchMessage.
+func (r *Reader) resume(partitions ...topicPartition) {
+	r.paused.resume(partitions...)
+
+	select {
+	case r.resumed <- struct{}{}:
+	default:
 	}
 }
 
+// Paused returns the sorted list of the partitions paused on the reader, in any
+// of its topics, see PausedTopicPartitions.
+func (r *Reader) Paused() []int {
+	return r.paused.list()
+}
+
+// PausedTopicPartitions returns the partitions paused on the reader, sorted by
+// topic and partition. The partitions paused by Pause, which are paused in all
+// the topics, have an empty Topic.
+func (r *Reader) PausedTopicPartitions() []TopicPartition {
+	return exportTopicPartitions(r.paused.topicPartitions())
+}
+
 // CommitMessages commits the list of messages passed as argument. The program
 // may pass a context to asynchronously cancel the commit operation when it was
 // configured to be blocking.
 func (r *Reader) CommitMessages(ctx context.Context, msgs ...Message) error {
+	return r.commitMessages(ctx, makeCommits(msgs...))
+}
+
+// CommitMessagesWithMetadata commits the list of messages like CommitMessages,
+// and stores the metadata string alongside the committed offsets. The metadata
+// is passed to OnPartitionsAssigned in PartitionAssignment.Metadata when the
+// partitions are assigned again, and returned by Client.OffsetFetch.
+//
+// Brokers reject metadata larger than their offset.metadata.max.bytes (4096
+// bytes by default), the commit then fails with an
+// *OffsetMetadataTooLargeError, which is logged when the reader is configured
+// with a CommitInterval.
+func (r *Reader) CommitMessagesWithMetadata(ctx context.Context, metadata string, msgs ...Message) error {
+	return r.commitMessages(ctx, makeCommitsWithMetadata(metadata, msgs...))
+}
+
+func (r *Reader) commitMessages(ctx context.Context, commits []commit) error {
 	if !r.useConsumerGroup() {
 		return errOnlyAvailableWithGroup
 	}
 
 	var errch <-chan error
 	var creq = commitRequest{
-		commits: makeCommits(msgs...),
+		commits: commits,
 	}
 
 	if r.useSyncCommits() {
@@ -805,7 +2056,10 @@ func (r *Reader) CommitMessages(ctx context.Context, msgs ...Message) error {
 
 // ReadLag returns the current lag of the reader by fetching the last offset of
 // the topic and partition and computing the difference between that value and
-// the offset of the last message returned by ReadMessage.
+// the offset of the last message returned by ReadMessage. The last stable
+// offset is used instead of the last offset when the reader is configured with
+// the ReadCommitted isolation level. When the reader reads multiple
+// partitions, the lag is the sum of the lags of the partitions.
 //
 // This method is intended to be used in cases where a program may be unable to
 // call ReadMessage to update the value returned by Lag, but still needs to get
@@ -819,6 +2073,22 @@ func (r *Reader) ReadLag(ctx context.Context) (lag int64, err error) {
 		return 0, errNotAvailableWithGroup
 	}
 
+	for _, partition := range r.partitions() {
+		r.mutex.Lock()
+		offset := r.offsets[partition]
+		r.mutex.Unlock()
+
+		n, err := r.readPartitionLag(ctx, partition, offset)
+		if err != nil {
+			return 0, err
+		}
+		lag += n
+	}
+
+	return lag, nil
+}
+
+func (r *Reader) readPartitionLag(ctx context.Context, partition int, cur int64) (lag int64, err error) {
 	type offsets struct {
 		first int64
 		last  int64
@@ -834,14 +2104,22 @@ func (r *Reader) ReadLag(ctx context.Context) (lag int64, err error) {
 		for _, broker := range r.config.Brokers {
 			var conn *Conn
 
-			if conn, err = r.config.Dialer.DialLeader(ctx, "tcp", broker, r.config.Topic, r.config.Partition); err != nil {
+			if conn, err = r.config.Dialer.DialLeader(ctx, "tcp", broker, r.config.Topic, partition); err != nil {
 				continue
 			}
 
 			deadline, _ := ctx.Deadline()
 			conn.SetDeadline(deadline)
 
-			off.first, off.last, err = conn.ReadOffsets()
+			if r.config.IsolationLevel == ReadCommitted {
+				// Records past the last stable offset can't be read until
+				// their transactions complete, they don't count as lag.
+				if off.first, err = conn.ReadFirstOffset(); err == nil {
+					off.last, err = conn.readLastStableOffset()
+				}
+			} else {
+				off.first, off.last, err = conn.ReadOffsets()
+			}
 			conn.Close()
 
 			if err == nil {
@@ -858,7 +2136,7 @@ func (r *Reader) ReadLag(ctx context.Context) (lag int64, err error) {
 
 	select {
 	case off := <-offch:
-		switch cur := r.Offset(); {
+		switch {
 		case cur == FirstOffset:
 			lag = off.last - off.first
 
@@ -877,14 +2155,14 @@ func (r *Reader) ReadLag(ctx context.Context) (lag int64, err error) {
 }
 
 // Offset returns the current absolute offset of the reader, or -1
-// if r is backed by a consumer group.
+// if r is backed by a consumer group or reads multiple partitions.
 func (r *Reader) Offset() int64 {
-	if r.useConsumerGroup() {
+	if r.useConsumerGroup() || len(r.config.Partitions) != 0 {
 		return -1
 	}
 
 	r.mutex.Lock()
-	offset := r.offset
+	offset := r.offsets[r.config.Partition]
 	r.mutex.Unlock()
 	r.withLogger(func(log Logger) {
 		log.Printf("looking up offset of kafka reader for partition %d of %s: %d", r.config.Partition, r.config.Topic, offset)
@@ -893,9 +2171,9 @@ func (r *Reader) Offset() int64 {
 }
 
 // Lag returns the lag of the last message returned by ReadMessage, or -1
-// if r is backed by a consumer group.
+// if r is backed by a consumer group or reads multiple partitions.
 func (r *Reader) Lag() int64 {
-	if r.useConsumerGroup() {
+	if r.useConsumerGroup() || len(r.config.Partitions) != 0 {
 		return -1
 	}
 
@@ -907,6 +2185,8 @@ func (r *Reader) Lag() int64 {
 
 // SetOffset changes the offset from which the next batch of messages will be
 // read. The method fails with io.ErrClosedPipe if the reader has already been closed.
+// When the reader reads multiple partitions, the offset of all the partitions
+// is changed, SetPartitionOffset changes the offset of one partition.
 //
 // From version 0.2.0, FirstOffset and LastOffset can be used to indicate the first
 // or last available offset in the partition. Please note while -1 and -2 were accepted
@@ -918,20 +2198,50 @@ func (r *Reader) SetOffset(offset int64) error {
 		return errNotAvailableWithGroup
 	}
 
+	offsets := make(map[int]int64)
+	for _, partition := range r.partitions() {
+		offsets[partition] = offset
+	}
+	return r.setOffsets(offsets)
+}
+
+// SetPartitionOffset is like SetOffset but changes the offset of one of the
+// partitions read by the reader, the other partitions keep being read from
+// their current offset.
+func (r *Reader) SetPartitionOffset(partition int, offset int64) error {
+	if r.useConsumerGroup() {
+		return errNotAvailableWithGroup
+	}
+	if !r.readsPartition(partition) {
+		return fmt.Errorf("kafka.(*Reader).SetPartitionOffset: partition %d of %s is not read by the reader", partition, r.config.Topic)
+	}
+	return r.setOffsets(map[int]int64{partition: offset})
+}
+
+func (r *Reader) setOffsets(offsets map[int]int64) error {
 	var err error
+	var changed bool
 	r.mutex.Lock()
 
 	if r.closed {
 		err = io.ErrClosedPipe
-	} else if offset != r.offset {
-		r.withLogger(func(log Logger) {
-			log.Printf("setting the offset of the kafka reader for partition %d of %s from %d to %d",
-				r.config.Partition, r.config.Topic, r.offset, offset)
-		})
-		r.offset = offset
+	} else {
+		for partition, offset := range offsets {
+			if offset == r.offsets[partition] {
+				continue
+			}
+			r.withLogger(func(log Logger) {
+				log.Printf("setting the offset of the kafka reader for partition %d of %s from %d to %d",
+					partition, r.config.Topic, r.offsets[partition], offset)
+			})
+			r.offsets[partition] = offset
+			changed = true
+		}
+	}
 
+	if changed {
 		if r.version != 0 {
-			r.start(map[int]int64{r.config.Partition: r.offset})
+			r.start(r.copyOffsets())
 		}
 
 		r.activateReadLag()
@@ -941,12 +2251,42 @@ func (r *Reader) SetOffset(offset int64) error {
 	return err
 }
 
+// copyOffsets returns a copy of the offsets of the partitions read by a reader
+// which is not part of a consumer group, it must be called with the mutex held.
+func (r *Reader) copyOffsets() map[topicPartition]int64 {
+	offsets := make(map[topicPartition]int64, len(r.offsets))
+	for partition, offset := range r.offsets {
+		offsets[topicPartition{topic: r.config.Topic, partition: partition}] = offset
+	}
+	return offsets
+}
+
 // SetOffsetAt changes the offset from which the next batch of messages will be
-// read given the timestamp t.
+// read given the timestamp t. When the reader reads multiple partitions, the
+// offset of each partition is set to its offset at t.
 //
 // The method fails if the unable to connect partition leader, or unable to read the offset
 // given the ts, or if the reader has been closed.
 func (r *Reader) SetOffsetAt(ctx context.Context, t time.Time) error {
+	if r.useConsumerGroup() {
+		return errNotAvailableWithGroup
+	}
+	return r.setOffsetsAt(ctx, r.partitions(), t)
+}
+
+// SetPartitionOffsetAt is like SetOffsetAt but changes the offset of one of the
+// partitions read by the reader.
+func (r *Reader) SetPartitionOffsetAt(ctx context.Context, partition int, t time.Time) error {
+	if r.useConsumerGroup() {
+		return errNotAvailableWithGroup
+	}
+	if !r.readsPartition(partition) {
+		return fmt.Errorf("kafka.(*Reader).SetPartitionOffsetAt: partition %d of %s is not read by the reader", partition, r.config.Topic)
+	}
+	return r.setOffsetsAt(ctx, []int{partition}, t)
+}
+
+func (r *Reader) setOffsetsAt(ctx context.Context, partitions []int, t time.Time) error {
 	r.mutex.Lock()
 	if r.closed {
 		r.mutex.Unlock()
@@ -954,8 +2294,20 @@ func (r *Reader) SetOffsetAt(ctx context.Context, t time.Time) error {
 	}
 	r.mutex.Unlock()
 
+	offsets := make(map[int]int64, len(partitions))
+	for _, partition := range partitions {
+		offset, err := r.lookupOffsetAt(ctx, partition, t)
+		if err != nil {
+			return err
+		}
+		offsets[partition] = offset
+	}
+	return r.setOffsets(offsets)
+}
+
+func (r *Reader) lookupOffsetAt(ctx context.Context, partition int, t time.Time) (int64, error) {
 	for _, broker := range r.config.Brokers {
-		conn, err := r.config.Dialer.DialLeader(ctx, "tcp", broker, r.config.Topic, r.config.Partition)
+		conn, err := r.config.Dialer.DialLeader(ctx, "tcp", broker, r.config.Topic, partition)
 		if err != nil {
 			continue
 		}
@@ -964,13 +2316,9 @@ func (r *Reader) SetOffsetAt(ctx context.Context, t time.Time) error {
 		conn.SetDeadline(deadline)
 		offset, err := conn.ReadOffset(t)
 		conn.Close()
-		if err != nil {
-			return err
-		}
-
-		return r.SetOffset(offset)
+		return offset, err
 	}
-	return fmt.Errorf("error setting offset for timestamp %+v", t)
+	return 0, fmt.Errorf("error setting offset for timestamp %+v", t)
 }
 
 // Stats returns a snapshot of the reader stats since the last time the method
@@ -982,34 +2330,45 @@ func (r *Reader) SetOffsetAt(ctx context.Context, t time.Time) error {
 // system.
 func (r *Reader) Stats() ReaderStats {
 	stats := ReaderStats{
-		Dials:         r.stats.dials.snapshot(),
-		Fetches:       r.stats.fetches.snapshot(),
-		Messages:      r.stats.messages.snapshot(),
-		Bytes:         r.stats.bytes.snapshot(),
-		Rebalances:    r.stats.rebalances.snapshot(),
-		Timeouts:      r.stats.timeouts.snapshot(),
-		Errors:        r.stats.errors.snapshot(),
-		DialTime:      r.stats.dialTime.snapshotDuration(),
-		ReadTime:      r.stats.readTime.snapshotDuration(),
-		WaitTime:      r.stats.waitTime.snapshotDuration(),
-		FetchSize:     r.stats.fetchSize.snapshot(),
-		FetchBytes:    r.stats.fetchBytes.snapshot(),
-		Offset:        r.stats.offset.snapshot(),
-		Lag:           r.stats.lag.snapshot(),
-		MinBytes:      int64(r.config.MinBytes),
-		MaxBytes:      int64(r.config.MaxBytes),
-		MaxWait:       r.config.MaxWait,
-		QueueLength:   int64(len(r.msgs)),
-		QueueCapacity: int64(cap(r.msgs)),
-		ClientID:      r.config.Dialer.ClientID,
-		Topic:         r.config.Topic,
-		Partition:     r.stats.partition,
+		Dials:          r.stats.dials.snapshot(),
+		Fetches:        r.stats.fetches.snapshot(),
+		Messages:       r.stats.messages.snapshot(),
+		Bytes:          r.stats.bytes.snapshot(),
+		Rebalances:     r.stats.rebalances.snapshot(),
+		Timeouts:       r.stats.timeouts.snapshot(),
+		Errors:         r.stats.errors.snapshot(),
+		OffsetResets:   r.stats.resets.snapshot(),
+		BrokenMessages: r.stats.broken.snapshot(),
+		CommitErrors:   r.stats.commitErrs.snapshot(),
+		DialTime:       r.stats.dialTime.snapshotDuration(),
+		ReadTime:       r.stats.readTime.snapshotDuration(),
+		WaitTime:       r.stats.waitTime.snapshotDuration(),
+		FetchSize:      r.stats.fetchSize.snapshot(),
+		FetchBytes:     r.stats.fetchBytes.snapshot(),
+		Offset:         r.stats.offset.snapshot(),
+		Lag:            r.stats.lag.snapshot(),
+		MinBytes:       int64(r.config.MinBytes),
+		MaxBytes:       int64(r.config.MaxBytes),
+		MaxWait:        r.config.MaxWait,
+		QueueLength:    int64(len(r.msgs)),
+		QueueCapacity:  int64(cap(r.msgs)),
+		ClientID:       r.config.Dialer.ClientID,
+		Topic:          r.config.Topic,
+		Partition:      r.stats.partition,
 	}
 	// TODO: remove when we get rid of the deprecated field.
 	stats.DeprecatedFetchesWithTypo = stats.Fetches
 	return stats
 }
 
+// PartitionStats returns the position of the reader in each of its partitions,
+// or in each partition assigned to it when it is part of a consumer group,
+// sorted by topic and partition. The positions are tracked from the fetch responses and
+// commits of the reader, the method doesn't make any requests to the brokers.
+func (r *Reader) PartitionStats() []PartitionStats {
+	return r.positions.snapshot()
+}
+
 func (r *Reader) withLogger(do func(Logger)) {
 	if r.config.Logger != nil {
 		do(r.config.Logger)
@@ -1046,7 +2405,7 @@ func (r *Reader) readLag(ctx context.Context) {
 		if err != nil {
 			r.stats.errors.observe(1)
 			r.withErrorLogger(func(log Logger) {
-				log.Printf("kafka reader failed to read lag of partition %d of %s", r.config.Partition, r.config.Topic)
+				log.Printf("kafka reader failed to read lag of partitions %v of %s", r.partitions(), r.topicName())
 			})
 		} else {
 			r.stats.lag.observe(lag)
@@ -1060,7 +2419,7 @@ func (r *Reader) readLag(ctx context.Context) {
 	}
 }
 
-func (r *Reader) start(offsetsByPartition map[int]int64) {
+func (r *Reader) start(offsetsByPartition map[topicPartition]int64) {
 	if r.closed {
 		// don't start child reader if parent Reader is closed
 		return
@@ -1070,35 +2429,64 @@ func (r *Reader) start(offsetsByPartition map[int]int64) {
 
 	r.cancel() // always cancel the previous reader
 	r.cancel = cancel
+	r.assigned = nil
+	r.fatal = nil
 	r.version++
 
+	r.ended = make(map[topicPartition]bool, len(offsetsByPartition))
+	r.caughtUp = make(map[topicPartition]bool, len(offsetsByPartition))
+	for tp := range offsetsByPartition {
+		r.ended[tp] = false
+		r.caughtUp[tp] = false
+	}
+	r.rearmCaughtUp()
+	r.positions.reset()
+	r.positions.add(offsetsByPartition, r.useConsumerGroup())
+
 	r.join.Add(len(offsetsByPartition))
-	for partition, offset := range offsetsByPartition {
-		go func(ctx context.Context, partition int, offset int64, join *sync.WaitGroup) {
-			defer join.Done()
-
-			(&reader{
-				dialer:          r.config.Dialer,
-				logger:          r.config.Logger,
-				errorLogger:     r.config.ErrorLogger,
-				brokers:         r.config.Brokers,
-				topic:           r.config.Topic,
-				partition:       partition,
-				minBytes:        r.config.MinBytes,
-				maxBytes:        r.config.MaxBytes,
-				maxWait:         r.config.MaxWait,
-				backoffDelayMin: r.config.ReadBackoffMin,
-				backoffDelayMax: r.config.ReadBackoffMax,
-				version:         r.version,
-				msgs:            r.msgs,
-				stats:           r.stats,
-				isolationLevel:  r.config.IsolationLevel,
-				maxAttempts:     r.config.MaxAttempts,
-			}).run(ctx, offset)
-		}(ctx, partition, offset, &r.join)
+	for tp, offset := range offsetsByPartition {
+		go r.runReader(ctx, r.version, tp, offset)
 	}
 }
 
+// runReader runs the reader of a partition until ctx is cancelled, the caller
+// must have added it to r.join.
+func (r *Reader) runReader(ctx context.Context, version int64, tp topicPartition, offset int64) {
+	defer r.join.Done()
+
+	(&reader{
+		dialer:          r.config.Dialer,
+		logger:          r.config.Logger,
+		errorLogger:     r.config.ErrorLogger,
+		brokers:         r.config.Brokers,
+		topic:           tp.topic,
+		partition:       tp.partition,
+		minBytes:        r.config.MinBytes,
+		maxBytes:        r.config.MaxBytes,
+		maxWait:         r.config.MaxWait,
+		backoffDelayMin: r.config.ReadBackoffMin,
+		backoffDelayMax: r.config.ReadBackoffMax,
+		version:         version,
+		msgs:            r.msgs,
+		stats:           r.stats,
+		isolationLevel:  r.config.IsolationLevel,
+		maxAttempts:     r.config.MaxAttempts,
+		rackID:          r.config.RackID,
+		concurrency:     r.decompressionConcurrency(),
+		paused:          &r.paused,
+		positions:       &r.positions,
+		startTime:       r.startOffsetAt(),
+		offsetReset:     r.config.OffsetOutOfRangePolicy,
+		end:             r.config.EndOffset,
+		endTime:         r.config.EndTime,
+		skipBroken:      r.config.SkipBrokenMessages,
+		onBroken:        r.config.OnBrokenMessage,
+		backoffPolicy:   r.config.Backoff,
+		onError:         r.config.OnError,
+		catchUp:         -1,
+	}).run(ctx, offset)
+}
+
 // A reader reads messages from kafka and produces them on its channels, it's
 // used as an way to asynchronously fetch messages while the main program reads
 // them using the high level reader API.
@@ -1119,13 +2507,310 @@ type reader struct {
 	stats           *readerStats
 	isolationLevel  IsolationLevel
 	maxAttempts     int
+	rackID          string
+	concurrency     int
+	paused          *pausedPartitions
+	positions       *partitionPositions
+	startTime       time.Time
+	offsetReset     OffsetOutOfRangePolicy
+	skipBroken      bool
+	onBroken        func(string, int, int64, error)
+	backoffPolicy   func(int, error) time.Duration
+	onError         func(error)
+
+	// end is the offset at which the reader stops, or a negative value if it
+	// only stops at endTime or never. It is set to the configured EndOffset,
+	// and resolved to an absolute offset by the first initialization when
+	// endKnown is false.
+	end      int64
+	endTime  time.Time
+	endKnown bool
+
+	// catchUp is the high watermark of the partition when the reader first
+	// initialized, or -1 until then. caughtUp is set once the reader reached
+	// it and notified the parent reader.
+	catchUp  int64
+	caughtUp bool
+
+	// leader is the ID of the partition leader found by the last call to
+	// initialize, and preferred the read replica that the last fetch
+	// response suggested. The reader fetches from replica until the time
+	// replicaExpires when it is not nil.
+	leader         int
+	preferred      int
+	replica        *Broker
+	replicaExpires time.Time
+}
+
+func (r *reader) topicPartition() topicPartition {
+	return topicPartition{topic: r.topic, partition: r.partition}
+}
+
+// assignedPartition is the reader of a partition cooperatively assigned to a
+// Reader, see Reader.assign.
+type assignedPartition struct {
+	version int64
+	cancel  context.CancelFunc
+	done    chan struct{}
 }
 
 type readerMessage struct {
 	version   int64
+	topic     string
+	partition int
 	message   Message
-	watermark int64
+	watermark int64 // the offset that the lag of the message is measured from
 	error     error
+	end       bool // the reader of the partition reached its end
+	fatal     bool // the reader of the partition stopped after error
+	caughtUp  bool // the reader of the partition reached its high watermark
+}
+
+func (m readerMessage) topicPartition() topicPartition {
+	return topicPartition{topic: m.topic, partition: m.partition}
+}
+
+// pausedPartitions is the set of partitions paused on a Reader, it is shared
+// with the readers of the partitions which stop fetching while paused. The
+// partitions paused with an empty topic are paused in all the topics, see
+// anyTopic.
+type pausedPartitions struct {
+	mutex  sync.Mutex
+	paused map[topicPartition]chan struct{} // closed when the partition is resumed
+}
+
+// TopicPartition identifies a partition of a topic read by a Reader.
+type TopicPartition struct {
+	Topic     string
+	Partition int
+}
+
+// importTopicPartitions returns the partitions passed to PauseTopicPartitions
+// and ResumeTopicPartitions.
+func importTopicPartitions(partitions []TopicPartition) []topicPartition {
+	tps := make([]topicPartition, len(partitions))
+	for i, tp := range partitions {
+		tps[i] = topicPartition{topic: tp.Topic, partition: tp.Partition}
+	}
+	return tps
+}
+
+// anyTopic returns the partitions of all the topics with the given IDs.
+func anyTopic(partitions []int) []topicPartition {
+	tps := make([]topicPartition, len(partitions))
+	for i, partition := range partitions {
+		tps[i] = topicPartition{partition: partition}
+	}
+	return tps
+}
+
+func (p *pausedPartitions) pause(partitions ...topicPartition) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.paused == nil {
+		p.paused = make(map[topicPartition]chan struct{})
+	}
+	for _, tp := range partitions {
+		if _, ok := p.paused[tp]; !ok {
+			p.paused[tp] = make(chan struct{})
+		}
+	}
+}
+
+// resume resumes the partitions, resuming the partitions of all the topics
+// also resumes those which were paused in a single topic.
+func (p *pausedPartitions) resume(partitions ...topicPartition) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, tp := range partitions {
+		for paused, resumed := range p.paused {
+			if paused == tp || (tp.topic == "" && paused.partition == tp.partition) {
+				close(resumed)
+				delete(p.paused, paused)
+			}
+		}
+	}
+}
+
+// resumed returns the channel closed when the partition is resumed, or nil if
+// it is not paused. It must be called with the mutex held.
+func (p *pausedPartitions) resumed(tp topicPartition) chan struct{} {
+	if resumed, ok := p.paused[tp]; ok {
+		return resumed
+	}
+	return p.paused[topicPartition{partition: tp.partition}]
+}
+
+func (p *pausedPartitions) isPaused(tp topicPartition) bool {
+	p.mutex.Lock()
+	paused := p.resumed(tp) != nil
+	p.mutex.Unlock()
+	return paused
+}
+
+func (p *pausedPartitions) list() []int {
+	p.mutex.Lock()
+	seen := make(map[int]bool, len(p.paused))
+	partitions := make([]int, 0, len(p.paused))
+	for tp := range p.paused {
+		if !seen[tp.partition] {
+			seen[tp.partition] = true
+			partitions = append(partitions, tp.partition)
+		}
+	}
+	p.mutex.Unlock()
+	sort.Ints(partitions)
+	return partitions
+}
+
+func (p *pausedPartitions) topicPartitions() []topicPartition {
+	p.mutex.Lock()
+	partitions := make([]topicPartition, 0, len(p.paused))
+	for tp := range p.paused {
+		partitions = append(partitions, tp)
+	}
+	p.mutex.Unlock()
+	sortTopicPartitions(partitions)
+	return partitions
+}
+
+// wait blocks until the partition is not paused, it returns false if the
+// context was canceled first.
+func (p *pausedPartitions) wait(ctx context.Context, tp topicPartition) bool {
+	for {
+		p.mutex.Lock()
+		resumed := p.resumed(tp)
+		p.mutex.Unlock()
+
+		if resumed == nil {
+			return true
+		}
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// partitionPositions tracks the positions of a Reader in its partitions, it is
+// shared with the readers of the partitions which report their fetches.
+type partitionPositions struct {
+	mutex     sync.Mutex
+	positions map[topicPartition]*partitionPosition
+}
+
+// partitionPosition is the position of a Reader in a partition, lagWaterMark is
+// the offset that its lag is measured from: the high watermark, or the last
+// stable offset when the reader reads committed records only.
+type partitionPosition struct {
+	PartitionStats
+	lagWaterMark int64
+}
+
+func (p *partitionPositions) reset() {
+	p.mutex.Lock()
+	p.positions = nil
+	p.mutex.Unlock()
+}
+
+// add starts tracking the partitions, the offsets they are read from are the
+// committed offsets when they are absolute and committed is true.
+func (p *partitionPositions) add(offsetsByPartition map[topicPartition]int64, committed bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.positions == nil {
+		p.positions = make(map[topicPartition]*partitionPosition, len(offsetsByPartition))
+	}
+	for tp, offset := range offsetsByPartition {
+		stats := &partitionPosition{
+			PartitionStats: PartitionStats{
+				Topic:           tp.topic,
+				Partition:       tp.partition,
+				Offset:          -1,
+				CommittedOffset: -1,
+				HighWaterMark:   -1,
+			},
+			lagWaterMark: -1,
+		}
+		if offset >= 0 {
+			stats.Offset = offset
+			if committed {
+				stats.CommittedOffset = offset
+			}
+		}
+		p.positions[tp] = stats
+	}
+}
+
+func (p *partitionPositions) remove(tp topicPartition) {
+	p.mutex.Lock()
+	delete(p.positions, tp)
+	p.mutex.Unlock()
+}
+
+func (p *partitionPositions) update(tp topicPartition, fn func(*partitionPosition)) {
+	p.mutex.Lock()
+	if stats := p.positions[tp]; stats != nil {
+		fn(stats)
+	}
+	p.mutex.Unlock()
+}
+
+// started records the offset that the reader of the partition started from,
+// unless the program already read messages from the partition.
+func (p *partitionPositions) started(tp topicPartition, offset int64) {
+	p.update(tp, func(stats *partitionPosition) {
+		if stats.Offset < 0 {
+			stats.Offset = offset
+		}
+	})
+}
+
+func (p *partitionPositions) consumed(tp topicPartition, offset int64) {
+	p.update(tp, func(stats *partitionPosition) { stats.Offset = offset })
+}
+
+func (p *partitionPositions) committed(tp topicPartition, offset int64) {
+	p.update(tp, func(stats *partitionPosition) { stats.CommittedOffset = offset })
+}
+
+func (p *partitionPositions) fetched(tp topicPartition, t time.Time, highWaterMark, lagWaterMark int64) {
+	p.update(tp, func(stats *partitionPosition) {
+		stats.LastFetch, stats.HighWaterMark, stats.lagWaterMark = t, highWaterMark, lagWaterMark
+	})
+}
+
+func (p *partitionPositions) failed(tp topicPartition, err error) {
+	p.update(tp, func(stats *partitionPosition) { stats.LastError = err })
+}
+
+func (p *partitionPositions) caughtUp(tp topicPartition) {
+	p.update(tp, func(stats *partitionPosition) { stats.CaughtUp = true })
+}
+
+func (p *partitionPositions) snapshot() []PartitionStats {
+	p.mutex.Lock()
+	partitions := make([]PartitionStats, 0, len(p.positions))
+	for _, stats := range p.positions {
+		s := stats.PartitionStats
+		if s.Offset >= 0 && stats.lagWaterMark >= 0 && stats.lagWaterMark > s.Offset {
+			s.Lag = stats.lagWaterMark - s.Offset
+		}
+		partitions = append(partitions, s)
+	}
+	p.mutex.Unlock()
+
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].Topic != partitions[j].Topic {
+			return partitions[i].Topic < partitions[j].Topic
+		}
+		return partitions[i].Partition < partitions[j].Partition
+	})
+	return partitions
 }
 
 func (r *reader) run(ctx context.Context, offset int64) {
@@ -1138,9 +2823,11 @@ func (r *reader) run(ctx context.Context, offset int64) {
 	// be surfaced to the program.
 	// If the reader wasn't retrying then the program would block indefinitely
 	// on a Read call after reading the first error.
+	var err error
+
 	for attempt := 0; true; attempt++ {
 		if attempt != 0 {
-			if !sleep(ctx, backoff(attempt, r.backoffDelayMin, r.backoffDelayMax)) {
+			if !sleep(ctx, r.backoff(attempt, err)) {
 				return
 			}
 		}
@@ -1149,7 +2836,13 @@ func (r *reader) run(ctx context.Context, offset int64) {
 			log.Printf("initializing kafka reader for partition %d of %s starting at offset %d", r.partition, r.topic, offset)
 		})
 
-		conn, start, err := r.initialize(ctx, offset)
+		var conn *Conn
+		var start int64
+
+		conn, start, err = r.initialize(ctx, offset)
+		if err != nil {
+			r.failed(err)
+		}
 		switch err {
 		case nil:
 		case OffsetOutOfRange:
@@ -1161,10 +2854,17 @@ func (r *reader) run(ctx context.Context, offset int64) {
 			})
 			continue
 		default:
+			// A topic which still does not exist after the attempts was not
+			// created by the brokers, auto.create.topics.enable is off.
+			if isFatalReadError(err) || (attempt >= r.maxAttempts && isError(err, UnknownTopicOrPartition)) {
+				r.sendFatal(ctx, err)
+				return
+			}
 			// Wait 4 attempts before reporting the first errors, this helps
 			// mitigate situations where the kafka server is temporarily
-			// unavailable.
-			if attempt >= r.maxAttempts {
+			// unavailable. The offset being out of range is reported right
+			// away since retrying doesn't fix it.
+			if _, outOfRange := err.(*OffsetOutOfRangeError); outOfRange || attempt >= r.maxAttempts {
 				r.sendError(ctx, err)
 			} else {
 				r.stats.errors.observe(1)
@@ -1183,16 +2883,42 @@ func (r *reader) run(ctx context.Context, offset int64) {
 		// Now we're sure to have an absolute offset number, may anything happen
 		// to the connection we know we'll want to restart from this offset.
 		offset = start
+		r.positions.started(r.topicPartition(), start)
 
 		errcount := 0
 	readLoop:
 		for {
-			if !sleep(ctx, backoff(errcount, r.backoffDelayMin, r.backoffDelayMax)) {
+			if !r.caughtUp && r.catchUp >= 0 && offset >= r.catchUp {
+				r.sendCaughtUp(ctx)
+				r.caughtUp = true
+			}
+
+			if r.end >= 0 && offset >= r.end {
 				conn.Close()
+				r.sendEnd(ctx)
 				return
 			}
 
-			switch offset, err = r.read(ctx, offset, conn); err {
+			if !sleep(ctx, r.backoff(errcount, err)) {
+				conn.Close()
+				return
+			}
+
+			if !r.paused.wait(ctx, r.topicPartition()) {
+				conn.Close()
+				return
+			}
+
+			offset, err = r.read(ctx, offset, conn)
+
+			if r.rackID != "" && r.followReplica(conn, err) {
+				// The next call to .initialize will connect to the replica
+				// that the reader now reads from.
+				conn.Close()
+				break readLoop
+			}
+
+			switch err {
 			case nil:
 				errcount = 0
 			case io.EOF:
@@ -1246,10 +2972,12 @@ func (r *reader) run(ctx context.Context, offset int64) {
 
 				switch {
 				case offset < first:
-					r.withErrorLogger(func(log Logger) {
-						log.Printf("the kafka reader is reading before the first offset for partition %d of %s, skipping from offset %d to %d (%d messages)", r.partition, r.topic, offset, first, first-offset)
-					})
-					offset, errcount = first, 0
+					next, err := r.resetOffset(offset, first, last)
+					if err != nil {
+						r.sendError(ctx, err)
+						break
+					}
+					offset, errcount = next, 0
 					continue // retry immediately so we don't keep falling behind due to the backoff
 
 				case offset < last:
@@ -1268,6 +2996,22 @@ func (r *reader) run(ctx context.Context, offset int64) {
 				conn.Close()
 				return
 
+			case ErrEndOfRange:
+				// The batch had a message past EndTime.
+				conn.Close()
+				r.sendEnd(ctx)
+				return
+
+			case InvalidMessage:
+				// The broker could not read the record at the offset from its
+				// log, fetching it again fails the same way.
+				if !r.skipBroken {
+					r.sendError(ctx, err)
+					break
+				}
+				offset, errcount = r.skipBrokenMessage(offset, offset+1, err), 0
+				continue
+
 			case errUnknownCodec:
 				// The compression codec is either unsupported or has not been
 				// imported.  This is a fatal error b/c the reader cannot
@@ -1276,6 +3020,18 @@ func (r *reader) run(ctx context.Context, offset int64) {
 				break readLoop
 
 			default:
+				if broken, ok := err.(*brokenBatchError); ok && r.skipBroken {
+					// The batch closed the connection since the rest of the
+					// response could not be read after the broken batch.
+					offset = r.skipBrokenMessage(broken.offset, broken.lastOffset+1, broken.err)
+					conn.Close()
+					break readLoop
+				}
+				if isFatalReadError(err) {
+					conn.Close()
+					r.sendFatal(ctx, err)
+					return
+				}
 				if _, ok := err.(Error); ok {
 					r.sendError(ctx, err)
 				} else {
@@ -1294,12 +3050,25 @@ func (r *reader) run(ctx context.Context, offset int64) {
 }
 
 func (r *reader) initialize(ctx context.Context, offset int64) (conn *Conn, start int64, err error) {
+	if r.replica != nil && offset >= 0 {
+		if conn, start, err = r.initializeReplica(ctx, offset); err == nil {
+			return
+		}
+		r.withErrorLogger(func(log Logger) {
+			log.Printf("error connecting to replica %d for partition %d of %s, falling back to the leader: %s", r.replica.ID, r.partition, r.topic, err)
+		})
+		r.replica = nil
+	}
+
 	for i := 0; i != len(r.brokers) && conn == nil; i++ {
 		var broker = r.brokers[i]
 		var first, last int64
+		var p Partition
 
 		t0 := time.Now()
-		conn, err = r.dialer.DialLeader(ctx, "tcp", broker, r.topic, r.partition)
+		if p, err = r.dialer.lookupPartition(ctx, "tcp", broker, r.topic, r.partition, false); err == nil {
+			conn, err = r.dialer.DialPartition(ctx, "tcp", broker, p)
+		}
 		t1 := time.Now()
 		r.stats.dials.observe(1)
 		r.stats.dialTime.observeDuration(t1.Sub(t0))
@@ -1307,6 +3076,7 @@ func (r *reader) initialize(ctx context.Context, offset int64) (conn *Conn, star
 		if err != nil {
 			continue
 		}
+		r.leader = p.Leader.ID
 
 		if first, last, err = r.readOffsets(conn); err != nil {
 			conn.Close()
@@ -1314,6 +3084,33 @@ func (r *reader) initialize(ctx context.Context, offset int64) (conn *Conn, star
 			break
 		}
 
+		if r.catchUp < 0 {
+			r.catchUp = last
+		}
+
+		if !r.endKnown {
+			if r.end, err = r.endOffset(conn, last); err != nil {
+				conn.Close()
+				conn = nil
+				break
+			}
+			r.endKnown = true
+		}
+
+		if offset < 0 && !r.startTime.IsZero() {
+			// The consumer group has no commit for the partition, and is
+			// configured to start at a point in time. The offset is -1 when
+			// no messages were written after that time.
+			if offset, err = conn.ReadOffset(r.startTime); err != nil {
+				conn.Close()
+				conn = nil
+				break
+			}
+			if offset < 0 {
+				offset = last
+			}
+		}
+
 		switch {
 		case offset == FirstOffset:
 			offset = first
@@ -1322,7 +3119,11 @@ func (r *reader) initialize(ctx context.Context, offset int64) (conn *Conn, star
 			offset = last
 
 		case offset < first:
-			offset = first
+			if offset, err = r.resetOffset(offset, first, last); err != nil {
+				conn.Close()
+				conn = nil
+				return
+			}
 		}
 
 		r.withLogger(func(log Logger) {
@@ -1341,6 +3142,183 @@ func (r *reader) initialize(ctx context.Context, offset int64) (conn *Conn, star
 	return
 }
 
+// endOffset resolves the end of the partition to an absolute offset, it
+// returns -1 if the reader only stops at the time of a message or never.
+func (r *reader) endOffset(conn *Conn, last int64) (int64, error) {
+	end := r.end
+	switch {
+	case end == LastOffset:
+		end = last
+	case end <= 0:
+		end = -1
+	}
+
+	if !r.endTime.IsZero() {
+		// The offset is -1 when no messages were written after endTime yet,
+		// the time of the messages is checked as they are read.
+		offset, err := conn.ReadOffset(r.endTime)
+		if err != nil {
+			return -1, err
+		}
+		if offset >= 0 && (end < 0 || offset < end) {
+			end = offset
+		}
+	}
+
+	return end, nil
+}
+
+// resetOffset applies the offset out of range policy of the reader to offset,
+// which is before first. It returns the offset to read from, or an error if
+// the policy is OffsetResetError.
+func (r *reader) resetOffset(offset, first, last int64) (int64, error) {
+	next := first
+	switch r.offsetReset {
+	case OffsetResetLatest:
+		next = last
+	case OffsetResetError:
+		return offset, &OffsetOutOfRangeError{
+			Topic:     r.topic,
+			Partition: r.partition,
+			Offset:    offset,
+			First:     first,
+			Last:      last,
+		}
+	}
+	r.withErrorLogger(func(log Logger) {
+		log.Printf("the kafka reader is reading before the first offset for partition %d of %s, skipping from offset %d to %d (%d messages)", r.partition, r.topic, offset, next, next-offset)
+	})
+	r.stats.resets.observe(1)
+	return next, nil
+}
+
+// backoff returns the time to wait for before the retry attempt after err.
+func (r *reader) backoff(attempt int, err error) time.Duration {
+	if r.backoffPolicy != nil && attempt != 0 {
+		return r.backoffPolicy(attempt, err)
+	}
+	return backoff(attempt, r.backoffDelayMin, r.backoffDelayMax)
+}
+
+// failed records that reading the partition failed with err.
+func (r *reader) failed(err error) {
+	r.positions.failed(r.topicPartition(), err)
+	if r.onError != nil {
+		r.onError(err)
+	}
+}
+
+// isFatalReadError returns true if reading a partition failed with an error that
+// retrying does not fix: the credentials or the permissions of the reader were
+// rejected, or the topic is invalid.
+func isFatalReadError(err error) bool {
+	for _, fatal := range []Error{
+		SASLAuthenticationFailed,
+		UnsupportedSASLMechanism,
+		IllegalSASLState,
+		TopicAuthorizationFailed,
+		ClusterAuthorizationFailed,
+		InvalidTopic,
+	} {
+		if isError(err, fatal) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipBrokenMessage reports the broken record or batch at offset, which the
+// reader skips by reading from next instead.
+func (r *reader) skipBrokenMessage(offset, next int64, err error) int64 {
+	r.withErrorLogger(func(log Logger) {
+		log.Printf("the kafka reader is skipping the broken messages of partition %d of %s from offset %d to %d: %s", r.partition, r.topic, offset, next, err)
+	})
+	r.stats.broken.observe(1)
+	if r.onBroken != nil {
+		r.onBroken(r.topic, r.partition, offset, err)
+	}
+	return next
+}
+
+// initializeReplica connects to the read replica of the partition, offset must
+// be absolute since followers do not serve the requests to list offsets.
+func (r *reader) initializeReplica(ctx context.Context, offset int64) (*Conn, int64, error) {
+	t0 := time.Now()
+	conn, err := r.dialer.DialPartition(ctx, "tcp", "", Partition{
+		Topic:  r.topic,
+		ID:     r.partition,
+		Leader: *r.replica,
+	})
+	t1 := time.Now()
+	r.stats.dials.observe(1)
+	r.stats.dialTime.observeDuration(t1.Sub(t0))
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.withLogger(func(log Logger) {
+		log.Printf("the kafka reader for partition %d of %s is reading from replica %d at offset %d", r.partition, r.topic, r.replica.ID, offset)
+	})
+
+	start, err := conn.Seek(offset, SeekAbsolute|SeekDontCheck)
+	if err != nil {
+		conn.Close()
+		return nil, 0, err
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, start, nil
+}
+
+// followReplica updates the replica that the reader fetches from after a read
+// from conn returned err, it returns true if the reader must reconnect. The
+// reader goes back to the leader when reading from the replica fails or its
+// preference expired, and moves to the replica suggested by the leader after
+// a successful read.
+func (r *reader) followReplica(conn *Conn, err error) bool {
+	if r.replica != nil {
+		switch err {
+		case nil, io.EOF, RequestTimedOut, context.Canceled:
+			if time.Now().Before(r.replicaExpires) {
+				return false
+			}
+			r.withLogger(func(log Logger) {
+				log.Printf("the preference for replica %d of partition %d of %s expired, reconnecting to the leader", r.replica.ID, r.partition, r.topic)
+			})
+		default:
+			r.withErrorLogger(func(log Logger) {
+				log.Printf("failed to read from replica %d for partition %d of %s, falling back to the leader: %s", r.replica.ID, r.partition, r.topic, err)
+			})
+		}
+		r.replica = nil
+		return true
+	}
+
+	if (err != nil && err != io.EOF) || r.preferred < 0 || r.preferred == r.leader {
+		return false
+	}
+
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	brokers, err := conn.Brokers()
+	conn.SetDeadline(time.Time{})
+
+	if err != nil {
+		r.withErrorLogger(func(log Logger) {
+			log.Printf("error looking up replica %d for partition %d of %s, reading from the leader: %s", r.preferred, r.partition, r.topic, err)
+		})
+		return false
+	}
+
+	for _, b := range brokers {
+		if b.ID == r.preferred {
+			r.replica, r.replicaExpires = &b, time.Now().Add(readReplicaTTL)
+			return true
+		}
+	}
+	return false
+}
+
 func (r *reader) read(ctx context.Context, offset int64, conn *Conn) (int64, error) {
 	r.stats.fetches.observe(1)
 	r.stats.offset.observe(offset)
@@ -1349,15 +3327,29 @@ func (r *reader) read(ctx context.Context, offset int64, conn *Conn) (int64, err
 	conn.SetReadDeadline(t0.Add(r.maxWait))
 
 	batch := conn.ReadBatchWith(ReadBatchConfig{
-		MinBytes:       r.minBytes,
-		MaxBytes:       r.maxBytes,
-		IsolationLevel: r.isolationLevel,
+		MinBytes:                 r.minBytes,
+		MaxBytes:                 r.maxBytes,
+		IsolationLevel:           r.isolationLevel,
+		RackID:                   r.rackID,
+		DecompressionConcurrency: r.concurrency,
 	})
 	highWaterMark := batch.HighWaterMark()
+	r.preferred = batch.readReplica
+
+	// A read_committed fetch stops at the last stable offset, the lag is
+	// measured from there since the records past it are not readable yet.
+	lagWaterMark := highWaterMark
+	if r.isolationLevel == ReadCommitted && batch.lastStable >= 0 {
+		lagWaterMark = batch.lastStable
+	}
 
 	t1 := time.Now()
 	r.stats.waitTime.observeDuration(t1.Sub(t0))
 
+	if err := batch.Err(); err == nil || err == io.EOF {
+		r.positions.fetched(r.topicPartition(), t1, highWaterMark, lagWaterMark)
+	}
+
 	var msg Message
 	var err error
 	var size int64
@@ -1374,6 +3366,17 @@ func (r *reader) read(ctx context.Context, offset int64, conn *Conn) (int64, err
 		}
 
 		if msg, err = batch.ReadMessage(); err != nil {
+			// The batch may have skipped control records or records of
+			// aborted transactions after the last message it returned.
+			if next := batch.Offset(); next > offset {
+				offset = next
+			}
+			batch.Close()
+			break
+		}
+
+		if (r.end >= 0 && msg.Offset >= r.end) || (!r.endTime.IsZero() && !msg.Time.Before(r.endTime)) {
+			offset, err = msg.Offset, ErrEndOfRange
 			batch.Close()
 			break
 		}
@@ -1382,14 +3385,14 @@ func (r *reader) read(ctx context.Context, offset int64, conn *Conn) (int64, err
 		r.stats.messages.observe(1)
 		r.stats.bytes.observe(n)
 
-		if err = r.sendMessage(ctx, msg, highWaterMark); err != nil {
+		if err = r.sendMessage(ctx, msg, lagWaterMark); err != nil {
 			batch.Close()
 			break
 		}
 
 		offset = msg.Offset + 1
 		r.stats.offset.observe(offset)
-		r.stats.lag.observe(highWaterMark - offset)
+		r.stats.lag.observe(lagWaterMark - offset)
 
 		size++
 		bytes += n
@@ -1401,6 +3404,12 @@ func (r *reader) read(ctx context.Context, offset int64, conn *Conn) (int64, err
 	r.stats.readTime.observeDuration(t2.Sub(t1))
 	r.stats.fetchSize.observe(size)
 	r.stats.fetchBytes.observe(bytes)
+
+	switch err {
+	case nil, io.EOF, context.Canceled, RequestTimedOut, ErrEndOfRange:
+	default:
+		r.failed(err)
+	}
 	return offset, err
 }
 
@@ -1411,16 +3420,51 @@ func (r *reader) readOffsets(conn *Conn) (first, last int64, err error) {
 
 func (r *reader) sendMessage(ctx context.Context, msg Message, watermark int64) error {
 	select {
-	case r.msgs <- readerMessage{version: r.version, message: msg, watermark: watermark}:
+	case r.msgs <- readerMessage{version: r.version, topic: r.topic, partition: r.partition, message: msg, watermark: watermark}:
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
 	}
 }
 
+// sendCaughtUp notifies the parent reader that the partition reached the
+// high watermark it had when the reader started.
+func (r *reader) sendCaughtUp(ctx context.Context) {
+	r.withLogger(func(log Logger) {
+		log.Printf("the kafka reader for partition %d of %s caught up to offset %d", r.partition, r.topic, r.catchUp)
+	})
+	select {
+	case r.msgs <- readerMessage{version: r.version, topic: r.topic, partition: r.partition, message: Message{Topic: r.topic, Partition: r.partition}, caughtUp: true}:
+	case <-ctx.Done():
+	}
+}
+
+// sendEnd notifies the parent reader that the partition reached its end.
+func (r *reader) sendEnd(ctx context.Context) {
+	r.withLogger(func(log Logger) {
+		log.Printf("the kafka reader for partition %d of %s reached its end", r.partition, r.topic)
+	})
+	select {
+	case r.msgs <- readerMessage{version: r.version, topic: r.topic, partition: r.partition, message: Message{Topic: r.topic, Partition: r.partition}, end: true}:
+	case <-ctx.Done():
+	}
+}
+
+// sendFatal reports an error that retrying does not fix to the parent reader,
+// the reader of the partition stops after it.
+func (r *reader) sendFatal(ctx context.Context, err error) {
+	r.withErrorLogger(func(log Logger) {
+		log.Printf("the kafka reader for partition %d of %s stopped after a fatal error: %s", r.partition, r.topic, err)
+	})
+	select {
+	case r.msgs <- readerMessage{version: r.version, topic: r.topic, partition: r.partition, error: err, fatal: true}:
+	case <-ctx.Done():
+	}
+}
+
 func (r *reader) sendError(ctx context.Context, err error) error {
 	select {
-	case r.msgs <- readerMessage{version: r.version, error: err}:
+	case r.msgs <- readerMessage{version: r.version, topic: r.topic, partition: r.partition, error: err}:
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
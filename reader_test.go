@@ -1,14 +1,22 @@
 package kafka
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"math/rand"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
 )
 
 func TestReader(t *testing.T) {
@@ -57,6 +65,11 @@ func TestReader(t *testing.T) {
 			scenario: "reading from an out-of-range offset waits until the context is cancelled",
 			function: testReaderOutOfRangeGetsCanceled,
 		},
+
+		{
+			scenario: "pausing the partition stops reading messages until it is resumed",
+			function: testReaderPauseResume,
+		},
 	}
 
 	for _, test := range tests {
@@ -89,6 +102,28 @@ func testReaderReadCanceled(t *testing.T, ctx context.Context, r *Reader) {
 	}
 }
 
+func testReaderPauseResume(t *testing.T, ctx context.Context, r *Reader) {
+	r.Pause(0)
+	prepareReader(t, ctx, r, makeTestSequence(3)...)
+
+	timeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if _, err := r.ReadMessage(timeout); err != context.DeadlineExceeded {
+		t.Fatalf("expected reading a paused partition to block until the context expires, got %v", err)
+	}
+
+	r.Resume(0)
+	for i := 0; i != 3; i++ {
+		m, err := r.ReadMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Offset != int64(i) {
+			t.Errorf("expected message at offset %d, got %d", i, m.Offset)
+		}
+	}
+}
+
 func testReaderReadMessages(t *testing.T, ctx context.Context, r *Reader) {
 	const N = 1000
 	prepareReader(t, ctx, r, makeTestSequence(N)...)
@@ -332,6 +367,86 @@ func TestReaderOnNonZeroPartition(t *testing.T) {
 	}
 }
 
+func TestReaderReadCommitted(t *testing.T) {
+	if !ktesting.KafkaIsAtLeast("0.11.0") {
+		t.Skip("transactions require kafka 0.11.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	w := newTestWriter(WriterConfig{
+		Topic:              topic,
+		TransactionalID:    makeTopic(),
+		TransactionTimeout: 10 * time.Second,
+		BatchTimeout:       100 * time.Millisecond,
+	})
+	defer w.Close()
+
+	for _, txn := range []struct {
+		values    []string
+		committed bool
+	}{
+		{values: []string{"a", "b"}, committed: true},
+		{values: []string{"aborted-1", "aborted-2"}, committed: false},
+		{values: []string{"c"}, committed: true},
+	} {
+		if err := w.BeginTxn(ctx); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range txn.values {
+			if err := w.WriteMessages(ctx, Message{Value: []byte(v)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var err error
+		if txn.committed {
+			err = w.CommitTxn(ctx)
+		} else {
+			err = w.AbortTxn(ctx)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewReader(ReaderConfig{
+		Brokers:        []string{"localhost:9092"},
+		Topic:          topic,
+		MinBytes:       1,
+		MaxBytes:       10e6,
+		MaxWait:        100 * time.Millisecond,
+		IsolationLevel: ReadCommitted,
+	})
+	defer r.Close()
+
+	// The records of the aborted transaction and the control records which
+	// mark the end of each transaction are skipped.
+	for _, expected := range []string{"a", "b", "c"} {
+		m, err := r.ReadMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(m.Value) != expected {
+			t.Errorf("expected message %q, got %q at offset %d", expected, m.Value, m.Offset)
+		}
+	}
+
+	// Only the commit marker of the last transaction may be left past the
+	// offset of the reader.
+	lag, err := r.ReadLag(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lag > 1 {
+		t.Errorf("expected the lag to be measured from the last stable offset, got %d", lag)
+	}
+}
+
 func testReaderSetsTopicAndPartition(t *testing.T, ctx context.Context, r *Reader) {
 	const N = 3
 	prepareReader(t, ctx, r, makeTestSequence(N)...)
@@ -706,6 +821,12 @@ func TestReaderConsumerGroup(t *testing.T) {
 			function:   testReaderConsumerGroupRebalance,
 		},
 
+		{
+			scenario:   "consumer group rebalance hooks",
+			partitions: 1,
+			function:   testReaderConsumerGroupRebalanceHooks,
+		},
+
 		{
 			scenario:   "consumer group rebalance across topics",
 			partitions: 3,
@@ -932,6 +1053,106 @@ func testReaderConsumerGroupRebalance(t *testing.T, ctx context.Context, r *Read
 	}
 }
 
+func testReaderConsumerGroupRebalanceHooks(t *testing.T, ctx context.Context, r *Reader) {
+	r.Close()
+
+	writer := NewWriter(WriterConfig{
+		Brokers: r.config.Brokers,
+		Topic:   r.config.Topic,
+		Dialer:  r.config.Dialer,
+	})
+	if err := writer.WriteMessages(ctx, makeTestSequence(1)...); err != nil {
+		t.Fatalf("bad write messages: %v", err)
+	}
+	writer.Close()
+
+	var (
+		mutex    sync.Mutex
+		assigned [][]PartitionAssignment
+		revoked  [][]int
+		commits  []error
+		last     Message
+		r2       *Reader
+	)
+
+	config := r.config
+	config.OnPartitionsAssigned = func(ctx context.Context, assignments []PartitionAssignment) {
+		mutex.Lock()
+		assigned = append(assigned, assignments)
+		mutex.Unlock()
+	}
+	config.OnPartitionsRevoked = func(ctx context.Context, partitions []TopicPartition) {
+		ids := []int{}
+		for _, p := range partitions {
+			ids = append(ids, p.Partition)
+		}
+		mutex.Lock()
+		revoked = append(revoked, ids)
+		msg := last
+		mutex.Unlock()
+
+		if msg.Topic != "" {
+			err := r2.CommitMessages(ctx, msg)
+			mutex.Lock()
+			commits = append(commits, err)
+			mutex.Unlock()
+		}
+	}
+
+	r2 = NewReader(config)
+	defer r2.Close()
+
+	msg, err := r2.FetchMessage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mutex.Lock()
+	last = msg
+	mutex.Unlock()
+
+	// a new member joining the group revokes the partition of the first one,
+	// which commits the message it read in the generation that ends.
+	r3 := NewReader(r.config)
+	defer r3.Close()
+
+	for {
+		mutex.Lock()
+		n := len(commits)
+		mutex.Unlock()
+		if n != 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("the partition was never revoked")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	r2.Close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if commits[0] != nil {
+		t.Errorf("committing the messages when the partitions are revoked failed: %v", commits[0])
+	}
+	if len(assigned) != len(revoked) {
+		t.Fatalf("expected the partitions of every generation to be revoked, got %d assignments and %d revocations", len(assigned), len(revoked))
+	}
+	if partitions := revoked[0]; !reflect.DeepEqual(partitions, []int{0}) {
+		t.Errorf("expected partition 0 to be revoked, got %v", partitions)
+	}
+	for i, assignments := range assigned {
+		partitions := []int{}
+		for _, a := range assignments {
+			partitions = append(partitions, a.ID)
+		}
+		if !reflect.DeepEqual(partitions, revoked[i]) {
+			t.Errorf("generation %d: expected the assigned partitions %v to be revoked, got %v", i, partitions, revoked[i])
+		}
+	}
+}
+
 func testReaderConsumerGroupRebalanceAcrossTopics(t *testing.T, ctx context.Context, r *Reader) {
 	// create a second reader that shares the groupID, but reads from a different topic
 	topic2 := makeTopic()
@@ -1056,16 +1277,16 @@ func TestOffsetStash(t *testing.T) {
 			Given:    offsetStash{},
 			Messages: []Message{newMessage(0, 0)},
 			Expected: offsetStash{
-				topic: {0: 1},
+				topic: {0: {Partition: 0, Offset: 1}},
 			},
 		},
 		"ignores earlier offsets": {
 			Given: offsetStash{
-				topic: {0: 2},
+				topic: {0: {Partition: 0, Offset: 2}},
 			},
 			Messages: []Message{newMessage(0, 0)},
 			Expected: offsetStash{
-				topic: {0: 2},
+				topic: {0: {Partition: 0, Offset: 2}},
 			},
 		},
 		"uses latest offset": {
@@ -1076,7 +1297,7 @@ func TestOffsetStash(t *testing.T) {
 				newMessage(0, 1),
 			},
 			Expected: offsetStash{
-				topic: {0: 4},
+				topic: {0: {Partition: 0, Offset: 4}},
 			},
 		},
 		"uses latest offset, across multiple topics": {
@@ -1090,8 +1311,8 @@ func TestOffsetStash(t *testing.T) {
 			},
 			Expected: offsetStash{
 				topic: {
-					0: 4,
-					1: 7,
+					0: {Partition: 0, Offset: 4},
+					1: {Partition: 1, Offset: 7},
 				},
 			},
 		},
@@ -1124,6 +1345,52 @@ func (m *mockOffsetCommitter) offsetCommit(request offsetCommitRequestV2) (offse
 	return offsetCommitResponseV2{}, nil
 }
 
+func TestReaderFollowReplica(t *testing.T) {
+	newReader := func(expires time.Time) *reader {
+		return &reader{
+			rackID:         "rack-a",
+			leader:         1,
+			preferred:      -1,
+			replica:        &Broker{ID: 2},
+			replicaExpires: expires,
+		}
+	}
+
+	tests := []struct {
+		scenario  string
+		expires   time.Time
+		err       error
+		reconnect bool
+	}{
+		{scenario: "keep reading from the replica", expires: time.Now().Add(time.Minute), err: io.EOF},
+		{scenario: "keep reading from the replica after a timeout", expires: time.Now().Add(time.Minute), err: RequestTimedOut},
+		{scenario: "go back to the leader after an error", expires: time.Now().Add(time.Minute), err: OffsetOutOfRange, reconnect: true},
+		{scenario: "go back to the leader after the preference expired", expires: time.Now().Add(-time.Second), err: io.EOF, reconnect: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			r := newReader(test.expires)
+
+			if reconnect := r.followReplica(nil, test.err); reconnect != test.reconnect {
+				t.Errorf("expected reconnect to be %t, got %t", test.reconnect, reconnect)
+			}
+			if following := r.replica != nil; following == test.reconnect {
+				t.Errorf("expected following the replica to be %t, got %t", !test.reconnect, following)
+			}
+		})
+	}
+
+	// Reading from the leader without a suggested replica, or with the leader
+	// itself suggested, does not look up the brokers.
+	for _, preferred := range []int{-1, 1} {
+		r := &reader{rackID: "rack-a", leader: 1, preferred: preferred}
+		if r.followReplica(nil, io.EOF) {
+			t.Errorf("reconnecting with the preferred read replica %d", preferred)
+		}
+	}
+}
+
 func TestValidateReader(t *testing.T) {
 	tests := []struct {
 		config       ReaderConfig
@@ -1136,6 +1403,25 @@ func TestValidateReader(t *testing.T) {
 		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", Partition: 1, MinBytes: -1}, errorOccured: true},
 		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", Partition: 1, MinBytes: 5, MaxBytes: -1}, errorOccured: true},
 		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", Partition: 1, MinBytes: 5, MaxBytes: 6}, errorOccured: false},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", GroupID: "group1", CommitMode: CommitOnFetchAck}, errorOccured: false},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", GroupID: "group1", CommitMode: -1}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", OffsetOutOfRangePolicy: OffsetResetError}, errorOccured: false},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", OffsetOutOfRangePolicy: -1}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", EndOffset: LastOffset}, errorOccured: false},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", EndOffset: FirstOffset}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", Partitions: []int{0, 1}}, errorOccured: false},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", Partitions: []int{0, 0}}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", Partitions: []int{-1}}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", Partition: 1, Partitions: []int{0, 1}}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", GroupID: "group1", Partitions: []int{0, 1}}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", GroupID: "group1", CommitMaxAttempts: 5, CommitBackoffMin: time.Millisecond}, errorOccured: false},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", GroupID: "group1", CommitMaxAttempts: -1}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", GroupID: "group1", CommitBackoffMax: -1}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, GroupID: "group1", GroupTopicPattern: regexp.MustCompile("^topic")}, errorOccured: false},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, GroupTopicPattern: regexp.MustCompile("^topic")}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, Topic: "topic1", GroupID: "group1", GroupTopicPattern: regexp.MustCompile("^topic")}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, GroupID: "group1", GroupTopicPattern: regexp.MustCompile("^topic"), GroupTopicRefreshInterval: -1}, errorOccured: true},
+		{config: ReaderConfig{Brokers: []string{"broker1"}, GroupID: "group1", GroupTopicPattern: regexp.MustCompile("^topic"), OnPartitionsRevoked: func(context.Context, []TopicPartition) {}}, errorOccured: false},
 	}
 	for _, test := range tests {
 		err := test.config.Validate()
@@ -1148,8 +1434,96 @@ func TestValidateReader(t *testing.T) {
 	}
 }
 
+func TestReaderResetOffset(t *testing.T) {
+	tests := []struct {
+		policy OffsetOutOfRangePolicy
+		offset int64
+		resets int64
+	}{
+		{policy: OffsetResetEarliest, offset: 10, resets: 1},
+		{policy: OffsetResetLatest, offset: 20, resets: 1},
+		{policy: OffsetResetError, offset: 5, resets: 0},
+	}
+
+	for _, test := range tests {
+		r := &reader{topic: "a", partition: 1, offsetReset: test.policy, stats: &readerStats{}}
+
+		offset, err := r.resetOffset(5, 10, 20)
+		if offset != test.offset {
+			t.Errorf("policy %d: expected offset %d, got %d", test.policy, test.offset, offset)
+		}
+		if resets := r.stats.resets.snapshot(); resets != test.resets {
+			t.Errorf("policy %d: expected %d resets, got %d", test.policy, test.resets, resets)
+		}
+
+		if test.policy != OffsetResetError {
+			if err != nil {
+				t.Errorf("policy %d: %v", test.policy, err)
+			}
+			continue
+		}
+		expected := &OffsetOutOfRangeError{Topic: "a", Partition: 1, Offset: 5, First: 10, Last: 20}
+		if !reflect.DeepEqual(err, expected) {
+			t.Errorf("expected %#v, got %#v", expected, err)
+		}
+		if !isError(err, OffsetOutOfRange) {
+			t.Errorf("expected the error to wrap OffsetOutOfRange, got %v", err)
+		}
+	}
+}
+
+func TestReaderOffsetOutOfRangeError(t *testing.T) {
+	if !ktesting.KafkaIsAtLeast("0.11.0") {
+		t.Skip("delete records requires kafka 0.11.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	w := newTestWriter(WriterConfig{Topic: topic})
+	if err := w.WriteMessages(ctx, makeTestSequence(10)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	c := NewClient("localhost:9092")
+	if _, err := c.DeleteRecords(ctx, DeleteRecordsRequest{
+		Topics: map[string][]DeleteRecordsRequestPartition{topic: {{Partition: 0, Offset: 5}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(ReaderConfig{
+		Brokers:                []string{"localhost:9092"},
+		Topic:                  topic,
+		MaxWait:                100 * time.Millisecond,
+		OffsetOutOfRangePolicy: OffsetResetError,
+	})
+	defer r.Close()
+
+	if err := r.SetOffset(2); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := r.ReadMessage(ctx)
+	e, ok := err.(*OffsetOutOfRangeError)
+	if !ok {
+		t.Fatalf("expected an offset out of range error, got %v", err)
+	}
+	if e.Offset != 2 || e.First != 5 || e.Last != 10 {
+		t.Errorf("expected offset 2 out of the range 5 to 10, got %+v", e)
+	}
+	if resets := r.Stats().OffsetResets; resets != 0 {
+		t.Errorf("expected no resets, got %d", resets)
+	}
+}
+
 func TestCommitOffsetsWithRetry(t *testing.T) {
-	offsets := offsetStash{"topic": {0: 0}}
+	offsets := offsetStash{"topic": {0: {Partition: 0, Offset: 0}}}
 
 	tests := map[string]struct {
 		Fails       int
@@ -1188,7 +1562,7 @@ func TestCommitOffsetsWithRetry(t *testing.T) {
 				logError: func(func(Logger)) {},
 			}
 
-			r := &Reader{stctx: context.Background()}
+			r := &Reader{stctx: context.Background(), stats: &readerStats{}}
 			err := r.commitOffsetsWithRetry(gen, offsets, defaultCommitRetries)
 			switch {
 			case test.HasError && err == nil:
@@ -1200,6 +1574,150 @@ func TestCommitOffsetsWithRetry(t *testing.T) {
 	}
 }
 
+func TestCommitOffsetsWithRetryFailure(t *testing.T) {
+	offsets := offsetStash{
+		"topic": {
+			1: {Partition: 1, Offset: 4},
+			0: {Partition: 0, Offset: 2},
+		},
+		"other": {
+			0: {Partition: 0, Offset: 7},
+		},
+	}
+
+	count := 0
+	gen := &Generation{
+		conn: mockCoordinator{
+			offsetCommitFunc: func(offsetCommitRequestV2) (offsetCommitResponseV2, error) {
+				count++
+				return offsetCommitResponseV2{}, RebalanceInProgress
+			},
+		},
+		done:     make(chan struct{}),
+		log:      func(func(Logger)) {},
+		logError: func(func(Logger)) {},
+	}
+
+	var failed map[string][]OffsetCommit
+	var failure error
+	r := &Reader{
+		config: ReaderConfig{
+			Topic:             "topic",
+			CommitMaxAttempts: 2,
+			CommitBackoffMin:  time.Millisecond,
+			CommitBackoffMax:  time.Millisecond,
+			OnCommitError: func(offsets map[string][]OffsetCommit, err error) {
+				failed, failure = offsets, err
+			},
+		},
+		stctx: context.Background(),
+		stats: &readerStats{},
+	}
+
+	err := r.commitOffsetsWithRetry(gen, offsets, r.config.commitAttempts())
+	if err != RebalanceInProgress {
+		t.Errorf("expected %v, got %v", RebalanceInProgress, err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 attempts, got %d", count)
+	}
+	if failure != RebalanceInProgress {
+		t.Errorf("expected OnCommitError to be called with %v, got %v", RebalanceInProgress, failure)
+	}
+	expected := map[string][]OffsetCommit{
+		"topic": {{Partition: 0, Offset: 2}, {Partition: 1, Offset: 4}},
+		"other": {{Partition: 0, Offset: 7}},
+	}
+	if !reflect.DeepEqual(expected, failed) {
+		t.Errorf("expected OnCommitError to be called with %+v, got %+v", expected, failed)
+	}
+	if n := r.stats.commitErrs.snapshot(); n != 1 {
+		t.Errorf("expected 1 commit error, got %d", n)
+	}
+}
+
+func TestOffsetStashMetadata(t *testing.T) {
+	offsets := offsetStash{}
+	offsets.merge(makeCommitsWithMetadata("a", Message{Topic: "topic", Partition: 0, Offset: 1}))
+	offsets.merge(makeCommitsWithMetadata("b", Message{Topic: "topic", Partition: 0, Offset: 0}))
+	offsets.merge(makeCommitsWithMetadata("c", Message{Topic: "topic", Partition: 1, Offset: 4}))
+	offsets.merge(makeCommitsWithMetadata("d", Message{Topic: "topic", Partition: 1, Offset: 4}))
+
+	expected := offsetStash{
+		"topic": {
+			0: {Partition: 0, Offset: 2, Metadata: "a"},
+			1: {Partition: 1, Offset: 5, Metadata: "d"},
+		},
+	}
+	if !reflect.DeepEqual(expected, offsets) {
+		t.Errorf("expected %v; got %v", expected, offsets)
+	}
+}
+
+func TestCommitOffsetsWithMetadata(t *testing.T) {
+	tests := map[string]struct {
+		Metadata string
+		Err      error
+	}{
+		"metadata is committed": {
+			Metadata: "host-1:checkpoint-42",
+		},
+		"metadata is too large": {
+			Metadata: strings.Repeat("x", 5000),
+			Err: &OffsetMetadataTooLargeError{
+				GroupID:   "group",
+				Topic:     "topic",
+				Partition: 1,
+				Size:      5000,
+			},
+		},
+	}
+
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			count := 0
+			gen := &Generation{
+				GroupID: "group",
+				conn: mockCoordinator{
+					offsetCommitFunc: func(req offsetCommitRequestV2) (offsetCommitResponseV2, error) {
+						count++
+						for _, topic := range req.Topics {
+							for _, partition := range topic.Partitions {
+								if len(partition.Metadata) > 4096 {
+									return offsetCommitResponseV2{}, OffsetMetadataTooLarge
+								}
+								if partition.Partition == 1 && partition.Metadata != test.Metadata {
+									t.Errorf("bad metadata: expected %q; got %q", test.Metadata, partition.Metadata)
+								}
+							}
+						}
+						return offsetCommitResponseV2{}, nil
+					},
+				},
+				done:     make(chan struct{}),
+				log:      func(func(Logger)) {},
+				logError: func(func(Logger)) {},
+			}
+
+			offsets := offsetStash{}
+			offsets.merge(makeCommits(Message{Topic: "topic", Partition: 0, Offset: 1}))
+			offsets.merge(makeCommitsWithMetadata(test.Metadata, Message{Topic: "topic", Partition: 1, Offset: 2}))
+
+			r := &Reader{stctx: context.Background(), stats: &readerStats{}}
+			err := r.commitOffsetsWithRetry(gen, offsets, defaultCommitRetries)
+			if !reflect.DeepEqual(test.Err, err) {
+				t.Errorf("bad err: expected %v; got %v", test.Err, err)
+			}
+			if count != 1 {
+				t.Errorf("expected the offsets to be committed once, got %d attempts", count)
+			}
+			if err != nil && !isError(err, OffsetMetadataTooLarge) {
+				t.Errorf("expected the error to wrap OffsetMetadataTooLarge")
+			}
+		})
+	}
+}
+
 // Test that a reader won't continually rebalance when there are more consumers
 // than partitions in a group.
 // https://github.com/segmentio/kafka-go/issues/200
@@ -1235,6 +1753,40 @@ func TestRebalanceTooManyConsumers(t *testing.T) {
 	}
 }
 
+func TestReaderConsumerGroupStartOffsetAt(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	// the first half of the messages were produced two hours ago.
+	msgs := makeTestSequence(6)
+	for i := range msgs[:3] {
+		msgs[i].Time = msgs[i].Time.Add(-2 * time.Hour)
+	}
+
+	r := NewReader(ReaderConfig{
+		Brokers:       []string{"localhost:9092"},
+		Topic:         topic,
+		GroupID:       makeGroupID(),
+		MaxWait:       time.Second,
+		StartOffsetAt: time.Now().Add(-time.Hour),
+	})
+	defer r.Close()
+	prepareReader(t, ctx, r, msgs...)
+
+	m, err := r.ReadMessage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Offset != 3 {
+		t.Errorf("expected the group to start at the first message produced after the start time at offset 3, got %d", m.Offset)
+	}
+}
+
 func TestConsumerGroupWithMissingTopic(t *testing.T) {
 	t.Parallel()
 	t.Skip("this test doesn't work when the cluster is configured to auto-create topics")
@@ -1313,3 +1865,1011 @@ func getOffsets(t *testing.T, config ReaderConfig) offsetFetchResponseV1 {
 
 	return offsets
 }
+
+func TestReaderRebalanceHookTimeout(t *testing.T) {
+	r := &Reader{}
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	expired := make(chan error, 1)
+	start := time.Now()
+	r.callRebalanceHook("OnPartitionsRevoked", 50*time.Millisecond, func(ctx context.Context) {
+		<-ctx.Done()
+		expired <- ctx.Err()
+		<-unblock
+	})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the reader to stop waiting for the hook after the rebalance timeout, waited %s", elapsed)
+	}
+	if err := <-expired; err != context.DeadlineExceeded {
+		t.Errorf("expected the context of the hook to expire, got %v", err)
+	}
+}
+
+func TestReaderPauseHoldsMessages(t *testing.T) {
+	r := &Reader{
+		msgs:    make(chan readerMessage, 10),
+		resumed: make(chan struct{}, 1),
+		version: 1,
+	}
+	message := func(partition int, offset int64) readerMessage {
+		return readerMessage{version: 1, partition: partition, message: Message{Partition: partition, Offset: offset}}
+	}
+
+	r.Pause(1, 2)
+	r.Resume(2)
+	if paused := r.Paused(); !reflect.DeepEqual(paused, []int{1}) {
+		t.Errorf("expected partition 1 to be paused, got %v", paused)
+	}
+
+	r.msgs <- message(1, 0)
+	r.msgs <- message(0, 0)
+	r.msgs <- message(1, 1)
+
+	ctx := context.Background()
+	if m, err := r.FetchMessage(ctx); err != nil || m.Partition != 0 {
+		t.Fatalf("expected the message of partition 0, got %+v (%v)", m, err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.FetchMessage(timeout); err != context.DeadlineExceeded {
+		t.Fatalf("expected fetching with only paused partitions to block, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		r.Resume(1)
+	}()
+
+	for i := int64(0); i != 2; i++ {
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Partition != 1 || m.Offset != i {
+			t.Errorf("expected the held message of partition 1 at offset %d, got partition %d at offset %d", i, m.Partition, m.Offset)
+		}
+	}
+	if paused := r.Paused(); len(paused) != 0 {
+		t.Errorf("expected no partitions to be paused, got %v", paused)
+	}
+}
+
+func TestReaderFetchMessages(t *testing.T) {
+	r := &Reader{
+		msgs:    make(chan readerMessage, 10),
+		resumed: make(chan struct{}, 1),
+		version: 1,
+	}
+	message := func(version int64, offset int64) readerMessage {
+		return readerMessage{version: version, message: Message{Offset: offset}}
+	}
+	offsets := func(msgs []Message) []int64 {
+		var offsets []int64
+		for _, m := range msgs {
+			offsets = append(offsets, m.Offset)
+		}
+		return offsets
+	}
+	fetch := func(ctx context.Context, max int, expected ...int64) {
+		t.Helper()
+		msgs, err := r.FetchMessages(ctx, max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found := offsets(msgs); !reflect.DeepEqual(expected, found) {
+			t.Errorf("expected messages at offsets %v, got %v", expected, found)
+		}
+	}
+
+	ctx := context.Background()
+	if _, err := r.FetchMessages(ctx, 0); err == nil {
+		t.Error("expected fetching zero messages to fail")
+	}
+
+	for i := int64(0); i != 3; i++ {
+		r.msgs <- message(1, i)
+	}
+	fetch(ctx, 2, 0, 1)
+	fetch(ctx, 10, 2)
+
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.FetchMessages(timeout, 10); err != context.DeadlineExceeded {
+		t.Fatalf("expected fetching without messages to block, got %v", err)
+	}
+
+	// errors are returned by the call following the messages read before
+	// them.
+	r.msgs <- message(1, 3)
+	r.msgs <- readerMessage{version: 1, error: io.ErrShortBuffer}
+	r.msgs <- message(1, 4)
+	fetch(ctx, 10, 3)
+	if _, err := r.FetchMessages(ctx, 10); err != io.ErrShortBuffer {
+		t.Fatalf("expected the error of the reader, got %v", err)
+	}
+	fetch(ctx, 10, 4)
+
+	// the messages of a new generation are returned by the next call.
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		time.Sleep(10 * time.Millisecond)
+		r.mutex.Lock()
+		r.version = 2
+		r.mutex.Unlock()
+		r.msgs <- message(1, 5)
+		r.msgs <- message(2, 0)
+		r.msgs <- message(1, 6)
+		r.msgs <- message(2, 1)
+	}()
+	fetch(ctx, 10, 5)
+	<-sent
+	fetch(ctx, 10, 0, 1)
+}
+
+func TestReaderEndOfRange(t *testing.T) {
+	r := &Reader{
+		config:  ReaderConfig{EndOffset: LastOffset},
+		msgs:    make(chan readerMessage, 10),
+		resumed: make(chan struct{}, 1),
+		version: 1,
+		ended:   map[topicPartition]bool{{partition: 0}: false, {partition: 1}: false},
+	}
+	message := func(partition int, offset int64) readerMessage {
+		return readerMessage{version: 1, partition: partition, message: Message{Partition: partition, Offset: offset}}
+	}
+	end := func(partition int) readerMessage {
+		return readerMessage{version: 1, partition: partition, message: Message{Partition: partition}, end: true}
+	}
+	fetch := func(ctx context.Context, offset int64) {
+		t.Helper()
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Offset != offset {
+			t.Errorf("expected the message at offset %d, got %d", offset, m.Offset)
+		}
+	}
+
+	ctx := context.Background()
+	r.msgs <- message(0, 0)
+	r.msgs <- end(0)
+	r.msgs <- message(1, 0)
+	fetch(ctx, 0)
+	fetch(ctx, 0)
+
+	// the reader keeps waiting for the partitions which did not end.
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := r.FetchMessage(timeout); err != context.DeadlineExceeded {
+		t.Fatalf("expected fetching to block until all partitions ended, got %v", err)
+	}
+
+	// the end of a paused partition is held with its messages.
+	r.Pause(1)
+	r.msgs <- message(1, 1)
+	r.msgs <- end(1)
+	timeout, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := r.FetchMessage(timeout); err != context.DeadlineExceeded {
+		t.Fatalf("expected fetching to block while the partition is paused, got %v", err)
+	}
+	r.Resume(1)
+	fetch(ctx, 1)
+
+	for i := 0; i != 2; i++ {
+		if _, err := r.FetchMessage(ctx); err != ErrEndOfRange {
+			t.Fatalf("expected the end of the range, got %v", err)
+		}
+	}
+}
+
+func TestReaderEndOffset(t *testing.T) {
+	tests := []struct {
+		end      int64
+		expected int64
+	}{
+		{end: 0, expected: -1},
+		{end: LastOffset, expected: 42},
+		{end: 10, expected: 10},
+	}
+	for _, test := range tests {
+		r := &reader{end: test.end}
+		if end, err := r.endOffset(nil, 42); err != nil || end != test.expected {
+			t.Errorf("end offset %d: expected %d, got %d (%v)", test.end, test.expected, end, err)
+		}
+	}
+}
+
+func TestReaderReadUntilLastOffset(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	w := newTestWriter(WriterConfig{Topic: topic})
+	if err := w.WriteMessages(ctx, makeTestSequence(5)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	r := NewReader(ReaderConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     topic,
+		MaxWait:   100 * time.Millisecond,
+		EndOffset: LastOffset,
+	})
+	defer r.Close()
+
+	for i := int64(0); i != 5; i++ {
+		m, err := r.ReadMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Offset != i {
+			t.Errorf("expected the message at offset %d, got %d", i, m.Offset)
+		}
+	}
+	if _, err := r.ReadMessage(ctx); err != ErrEndOfRange {
+		t.Errorf("expected the end of the range after the last message, got %v", err)
+	}
+}
+
+func TestReaderSetPartitionOffset(t *testing.T) {
+	r := NewReader(ReaderConfig{
+		Brokers:    []string{"localhost:9092"},
+		Topic:      "topic",
+		Partitions: []int{0, 1, 2},
+	})
+	defer r.Close()
+
+	if err := r.SetPartitionOffset(1, 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetPartitionOffset(3, 42); err == nil {
+		t.Error("expected an error setting the offset of a partition that the reader does not read")
+	}
+
+	r.mutex.Lock()
+	offsets := r.copyOffsets()
+	r.mutex.Unlock()
+	expected := map[topicPartition]int64{
+		{topic: "topic", partition: 0}: FirstOffset,
+		{topic: "topic", partition: 1}: 42,
+		{topic: "topic", partition: 2}: FirstOffset,
+	}
+	if !reflect.DeepEqual(expected, offsets) {
+		t.Errorf("expected offsets %v, got %v", expected, offsets)
+	}
+
+	if offset := r.Offset(); offset != -1 {
+		t.Errorf("expected the offset of a reader of multiple partitions to be -1, got %d", offset)
+	}
+}
+
+func TestReaderPartitions(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	w := newTestWriter(WriterConfig{Topic: topic, Balancer: &RoundRobin{}})
+	if err := w.WriteMessages(ctx, makeTestSequence(6)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	r := NewReader(ReaderConfig{
+		Brokers:    []string{"localhost:9092"},
+		Topic:      topic,
+		Partitions: []int{0, 1, 2},
+		MaxWait:    100 * time.Millisecond,
+		EndOffset:  LastOffset,
+	})
+	defer r.Close()
+
+	counts := map[int]int{}
+	for {
+		m, err := r.ReadMessage(ctx)
+		if err == ErrEndOfRange {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[m.Partition]++
+	}
+	if expected := map[int]int{0: 2, 1: 2, 2: 2}; !reflect.DeepEqual(expected, counts) {
+		t.Errorf("expected the messages of each partition to be read, got %v", counts)
+	}
+}
+
+func TestReaderCaughtUp(t *testing.T) {
+	r := &Reader{
+		msgs:     make(chan readerMessage, 10),
+		resumed:  make(chan struct{}, 1),
+		version:  1,
+		caughtUp: map[topicPartition]bool{{partition: 0}: false, {partition: 1}: false},
+	}
+	message := func(partition int, offset int64) readerMessage {
+		return readerMessage{version: 1, partition: partition, message: Message{Partition: partition, Offset: offset}}
+	}
+	caughtUp := func(partition int) readerMessage {
+		return readerMessage{version: 1, partition: partition, message: Message{Partition: partition}, caughtUp: true}
+	}
+	closed := func(ch <-chan struct{}) bool {
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	ch := r.CaughtUp()
+	for _, m := range []readerMessage{message(0, 0), caughtUp(0), message(1, 0), caughtUp(1), message(1, 1)} {
+		r.msgs <- m
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i, expected := range []int64{0, 0, 1} {
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Offset != expected {
+			t.Errorf("expected the message at offset %d, got %d", expected, m.Offset)
+		}
+		if done := closed(ch); done != (i == 2) {
+			t.Errorf("after message %d: expected the reader to have caught up to be %t, got %t", i, i == 2, done)
+		}
+	}
+
+	// a partition added to the reader re-arms the notification, which is
+	// closed again once the partition is removed.
+	r.mutex.Lock()
+	r.caughtUp[topicPartition{partition: 2}] = false
+	r.rearmCaughtUp()
+	r.mutex.Unlock()
+
+	ch = r.CaughtUp()
+	if closed(ch) {
+		t.Error("expected the reader not to have caught up with the new partition")
+	}
+
+	r.mutex.Lock()
+	delete(r.caughtUp, topicPartition{partition: 2})
+	r.checkCaughtUp()
+	r.mutex.Unlock()
+
+	if !closed(ch) {
+		t.Error("expected the reader to have caught up once the new partition was removed")
+	}
+}
+
+func TestReaderCaughtUpToHighWatermark(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	w := newTestWriter(WriterConfig{Topic: topic})
+	if err := w.WriteMessages(ctx, makeTestSequence(5)...); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	r := NewReader(ReaderConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   topic,
+		MaxWait: 100 * time.Millisecond,
+	})
+	defer r.Close()
+
+	for i := 0; i != 5; i++ {
+		if _, err := r.ReadMessage(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the notification is delivered by the next call reading messages.
+	readCtx, cancelRead := context.WithTimeout(ctx, time.Second)
+	defer cancelRead()
+	go r.ReadMessage(readCtx)
+
+	select {
+	case <-r.CaughtUp():
+	case <-ctx.Done():
+		t.Fatal("the reader did not catch up with the messages of the partition")
+	}
+}
+
+func TestReaderPartitionStats(t *testing.T) {
+	r := &Reader{
+		config:  ReaderConfig{Topic: "topic", GroupID: "group"},
+		msgs:    make(chan readerMessage, 10),
+		resumed: make(chan struct{}, 1),
+		version: 1,
+		stctx:   context.Background(),
+	}
+	p0 := topicPartition{topic: "topic", partition: 0}
+	p1 := topicPartition{topic: "topic", partition: 1}
+	r.positions.add(map[topicPartition]int64{p0: 5, p1: LastOffset}, true)
+
+	fetchTime := time.Now()
+	r.positions.fetched(p0, fetchTime, 20, 18)
+	r.positions.started(p1, 30)
+	r.positions.fetched(p1, fetchTime, 30, 30)
+	r.positions.failed(p1, NotLeaderForPartition)
+
+	r.msgs <- readerMessage{version: 1, topic: "topic", partition: 0, message: Message{Topic: "topic", Partition: 0, Offset: 5}}
+	if _, err := r.FetchMessage(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := &Generation{
+		conn: mockCoordinator{
+			offsetCommitFunc: func(offsetCommitRequestV2) (offsetCommitResponseV2, error) {
+				return offsetCommitResponseV2{}, nil
+			},
+		},
+		done:     make(chan struct{}),
+		log:      func(func(Logger)) {},
+		logError: func(func(Logger)) {},
+	}
+	if err := r.commitOffsetsWithRetry(gen, offsetStash{"topic": {0: {Partition: 0, Offset: 6}}}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []PartitionStats{
+		{Topic: "topic", Partition: 0, Offset: 6, CommittedOffset: 6, HighWaterMark: 20, Lag: 12, LastFetch: fetchTime},
+		{Topic: "topic", Partition: 1, Offset: 30, CommittedOffset: -1, HighWaterMark: 30, LastFetch: fetchTime, LastError: NotLeaderForPartition},
+	}
+	if found := r.PartitionStats(); !reflect.DeepEqual(expected, found) {
+		t.Errorf("expected %+v, got %+v", expected, found)
+	}
+
+	// the positions of revoked partitions are dropped.
+	r.positions.remove(p0)
+	if found := r.PartitionStats(); len(found) != 1 || found[0].Partition != 1 {
+		t.Errorf("expected the stats of partition 1 only, got %+v", found)
+	}
+}
+
+func TestReaderBackoff(t *testing.T) {
+	failure := errors.New("failed")
+
+	var called []error
+	r := &reader{
+		backoffDelayMin: 100 * time.Millisecond,
+		backoffDelayMax: time.Second,
+		positions:       &partitionPositions{},
+		onError:         func(err error) { called = append(called, err) },
+	}
+	if d := r.backoff(2, failure); d != 400*time.Millisecond {
+		t.Errorf("expected the default backoff to be 400ms, got %s", d)
+	}
+
+	r.backoffPolicy = func(attempt int, err error) time.Duration {
+		if err != failure {
+			t.Errorf("expected the backoff policy to be called with the error, got %v", err)
+		}
+		return time.Duration(attempt) * time.Millisecond
+	}
+	if d := r.backoff(0, nil); d != 0 {
+		t.Errorf("expected no backoff before the first attempt, got %s", d)
+	}
+	if d := r.backoff(3, failure); d != 3*time.Millisecond {
+		t.Errorf("expected the backoff policy to be used, got %s", d)
+	}
+
+	r.failed(failure)
+	if !reflect.DeepEqual(called, []error{failure}) {
+		t.Errorf("expected OnError to be called with the error, got %v", called)
+	}
+}
+
+func TestIsFatalReadError(t *testing.T) {
+	tests := []struct {
+		err   error
+		fatal bool
+	}{
+		{err: SASLAuthenticationFailed, fatal: true},
+		{err: TopicAuthorizationFailed, fatal: true},
+		{err: &RetryError{Attempts: 2, Err: InvalidTopic}, fatal: true},
+		{err: UnknownTopicOrPartition, fatal: false},
+		{err: NotLeaderForPartition, fatal: false},
+		{err: io.ErrUnexpectedEOF, fatal: false},
+	}
+	for _, test := range tests {
+		if fatal := isFatalReadError(test.err); fatal != test.fatal {
+			t.Errorf("%v: expected fatal to be %t, got %t", test.err, test.fatal, fatal)
+		}
+	}
+}
+
+func TestReaderFatalError(t *testing.T) {
+	r := &Reader{
+		msgs:    make(chan readerMessage, 10),
+		resumed: make(chan struct{}, 1),
+		version: 1,
+	}
+	r.msgs <- readerMessage{version: 1, message: Message{Offset: 0}}
+	r.msgs <- readerMessage{version: 1, error: SASLAuthenticationFailed, fatal: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := r.FetchMessage(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the error keeps being returned after the reader of the partition
+	// stopped.
+	for i := 0; i < 2; i++ {
+		if _, err := r.FetchMessage(ctx); err != SASLAuthenticationFailed {
+			t.Errorf("expected the fatal error, got %v", err)
+		}
+	}
+}
+
+func TestReaderInterceptors(t *testing.T) {
+	errBad := errors.New("bad message")
+	decode := func(ctx context.Context, msg *Message) error {
+		for _, h := range msg.Headers {
+			if h.Key == "encoding" && string(h.Value) == "upper" {
+				msg.Value = bytes.ToUpper(msg.Value)
+			}
+		}
+		return nil
+	}
+	validate := func(ctx context.Context, msg *Message) error {
+		if string(msg.Value) == "BAD" {
+			return errBad
+		}
+		return nil
+	}
+
+	newReader := func(handler func(context.Context, Message, error) error) *Reader {
+		r := &Reader{
+			config: ReaderConfig{
+				Interceptors:      []ReaderInterceptor{decode, validate},
+				BadMessageHandler: handler,
+			},
+			msgs:    make(chan readerMessage, 10),
+			resumed: make(chan struct{}, 1),
+			version: 1,
+		}
+		for i, value := range []string{"a", "bad", "c"} {
+			r.msgs <- readerMessage{version: 1, message: Message{
+				Offset:  int64(i),
+				Value:   []byte(value),
+				Headers: []Header{{Key: "encoding", Value: []byte("upper")}},
+			}}
+		}
+		return r
+	}
+	fetch := func(r *Reader, value string) {
+		t.Helper()
+		m, err := r.FetchMessage(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(m.Value) != value {
+			t.Errorf("expected the value %q, got %q", value, m.Value)
+		}
+	}
+
+	t.Run("errors are returned without a bad message handler", func(t *testing.T) {
+		r := newReader(nil)
+		fetch(r, "A")
+		_, err := r.FetchMessage(context.Background())
+		e, ok := err.(*ReaderInterceptorError)
+		if !ok {
+			t.Fatalf("expected an interceptor error, got %v", err)
+		}
+		if e.Interceptor != 1 || e.Message.Offset != 1 || string(e.Message.Value) != "BAD" || e.Err != errBad {
+			t.Errorf("unexpected interceptor error: %+v", e)
+		}
+		fetch(r, "C")
+	})
+
+	t.Run("the bad message handler skips messages", func(t *testing.T) {
+		var bad []int64
+		r := newReader(func(ctx context.Context, msg Message, err error) error {
+			if err != errBad {
+				t.Errorf("expected the error of the interceptor, got %v", err)
+			}
+			bad = append(bad, msg.Offset)
+			return nil
+		})
+		fetch(r, "A")
+		fetch(r, "C")
+		if !reflect.DeepEqual(bad, []int64{1}) {
+			t.Errorf("expected the message at offset 1 to be handled as bad, got %v", bad)
+		}
+	})
+
+	t.Run("the errors of the bad message handler are returned", func(t *testing.T) {
+		errHandler := errors.New("dead letter queue unavailable")
+		r := newReader(func(context.Context, Message, error) error { return errHandler })
+		fetch(r, "A")
+		if _, err := r.FetchMessage(context.Background()); err != errHandler {
+			t.Errorf("expected the error of the bad message handler, got %v", err)
+		}
+		fetch(r, "C")
+	})
+}
+
+func TestReaderCommitOnFetchAck(t *testing.T) {
+	var mutex sync.Mutex
+	var commits int
+	committed := map[int32]int64{}
+	gen := &Generation{
+		conn: mockCoordinator{
+			offsetCommitFunc: func(req offsetCommitRequestV2) (offsetCommitResponseV2, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				commits++
+				for _, topic := range req.Topics {
+					for _, partition := range topic.Partitions {
+						committed[partition.Partition] = partition.Offset
+					}
+				}
+				return offsetCommitResponseV2{}, nil
+			},
+		},
+		done:     make(chan struct{}),
+		log:      func(func(Logger)) {},
+		logError: func(func(Logger)) {},
+	}
+
+	r := &Reader{
+		config: ReaderConfig{
+			GroupID:        "group",
+			CommitMode:     CommitOnFetchAck,
+			CommitInterval: time.Hour,
+		},
+		msgs:    make(chan readerMessage, 10),
+		resumed: make(chan struct{}, 1),
+		commits: make(chan commitRequest),
+		version: 1,
+		stctx:   context.Background(),
+	}
+	message := func(version int64, offset int64) readerMessage {
+		return readerMessage{version: version, message: Message{Topic: "topic", Offset: offset}}
+	}
+	// runGeneration runs the commit loop of a generation until the returned
+	// function is called, which waits for the final commit.
+	runGeneration := func() func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			r.commitLoop(ctx, gen)
+		}()
+		return func() {
+			cancel()
+			<-done
+		}
+	}
+	read := func(expected int64) {
+		t.Helper()
+		m, err := r.ReadMessage(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Offset != expected {
+			t.Errorf("expected the message at offset %d, got %d", expected, m.Offset)
+		}
+	}
+
+	endGeneration := runGeneration()
+	for i := int64(0); i != 3; i++ {
+		r.msgs <- message(1, i)
+		read(i)
+	}
+
+	// the messages acknowledged by the next calls are committed when the
+	// generation ends, the last one is not.
+	endGeneration()
+	mutex.Lock()
+	if commits != 1 || !reflect.DeepEqual(committed, map[int32]int64{0: 2}) {
+		t.Errorf("expected offset 2 to be committed once, got %v after %d commits", committed, commits)
+	}
+	mutex.Unlock()
+
+	// the message of the previous generation is not committed in the next
+	// one, another member may consume its partition.
+	r.mutex.Lock()
+	r.version = 2
+	r.mutex.Unlock()
+
+	endGeneration = runGeneration()
+	r.msgs <- message(2, 10)
+	read(10)
+	endGeneration()
+
+	mutex.Lock()
+	if commits != 1 {
+		t.Errorf("expected the message of the previous generation not to be committed, got %v after %d commits", committed, commits)
+	}
+	mutex.Unlock()
+}
+
+func TestPausedPartitionsWait(t *testing.T) {
+	p := &pausedPartitions{}
+	tp := topicPartition{topic: "topic", partition: 0}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if !p.wait(ctx, tp) {
+		t.Error("expected waiting on a partition which is not paused to return immediately")
+	}
+
+	p.pause(tp)
+	resumed := make(chan bool)
+	go func() { resumed <- p.wait(ctx, tp) }()
+	p.resume(tp)
+	if !<-resumed {
+		t.Error("expected the wait to end when the partition is resumed")
+	}
+
+	p.pause(tp)
+	go func() { resumed <- p.wait(ctx, tp) }()
+	cancel()
+	if <-resumed {
+		t.Error("expected the wait to fail when the context is canceled")
+	}
+}
+
+func TestPausedPartitionsAnyTopic(t *testing.T) {
+	p := &pausedPartitions{}
+	a0 := topicPartition{topic: "a", partition: 0}
+	b0 := topicPartition{topic: "b", partition: 0}
+	b1 := topicPartition{topic: "b", partition: 1}
+
+	p.pause(anyTopic([]int{0})...)
+	if !p.isPaused(a0) || !p.isPaused(b0) || p.isPaused(b1) {
+		t.Error("expected partition 0 to be paused in all the topics only")
+	}
+
+	p.pause(b1)
+	if found := p.list(); !reflect.DeepEqual(found, []int{0, 1}) {
+		t.Errorf("expected partitions 0 and 1 to be listed, got %v", found)
+	}
+
+	// the partitions paused in all the topics stay paused when a partition
+	// of a single topic is resumed.
+	p.resume(b0)
+	if !p.isPaused(b0) {
+		t.Error("expected partition 0 to stay paused in topic b")
+	}
+
+	resumed := make(chan bool)
+	go func() { resumed <- p.wait(context.Background(), b1) }()
+	p.resume(anyTopic([]int{0, 1})...)
+	if !<-resumed {
+		t.Error("expected the wait to end when the partition is resumed in all the topics")
+	}
+	if p.isPaused(a0) || p.isPaused(b1) || len(p.list()) != 0 {
+		t.Errorf("expected no partitions to be paused, got %v", p.list())
+	}
+}
+
+func TestReaderPauseTopicPartitions(t *testing.T) {
+	var logs []string
+	r := &Reader{config: ReaderConfig{
+		GroupTopicPattern: regexp.MustCompile("^topic"),
+		Logger: LoggerFunc(func(msg string, args ...interface{}) {
+			logs = append(logs, fmt.Sprintf(msg, args...))
+		}),
+	}}
+
+	r.PauseTopicPartitions(TopicPartition{Topic: "topic-b", Partition: 1}, TopicPartition{Topic: "topic-a", Partition: 2})
+	r.Pause(0)
+
+	expected := []TopicPartition{{Partition: 0}, {Topic: "topic-a", Partition: 2}, {Topic: "topic-b", Partition: 1}}
+	if found := r.PausedTopicPartitions(); !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected the paused partitions to be %+v, got %+v", expected, found)
+	}
+	if !r.paused.isPaused(topicPartition{topic: "topic-b", partition: 1}) || r.paused.isPaused(topicPartition{topic: "topic-a", partition: 1}) {
+		t.Error("expected partition 1 to be paused in topic-b only")
+	}
+
+	r.ResumeTopicPartitions(TopicPartition{Topic: "topic-b", Partition: 1}, TopicPartition{Partition: 0})
+	expected = []TopicPartition{{Topic: "topic-a", Partition: 2}}
+	if found := r.PausedTopicPartitions(); !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected the paused partitions to be %+v, got %+v", expected, found)
+	}
+
+	for _, log := range logs {
+		if !strings.Contains(log, "the topics matching ^topic") {
+			t.Errorf("expected the log to name the pattern of the reader, got %q", log)
+		}
+	}
+}
+
+func TestReaderCooperativeRebalance(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		assigned [][]int
+		revoked  [][]int
+	)
+	r := NewReader(ReaderConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   "test",
+		OnPartitionsAssigned: func(ctx context.Context, assignments []PartitionAssignment) {
+			partitions := []int{}
+			for _, a := range assignments {
+				partitions = append(partitions, a.ID)
+			}
+			sort.Ints(partitions)
+			mutex.Lock()
+			assigned = append(assigned, partitions)
+			mutex.Unlock()
+		},
+		OnPartitionsRevoked: func(ctx context.Context, partitions []TopicPartition) {
+			ids := []int{}
+			for _, p := range partitions {
+				ids = append(ids, p.Partition)
+			}
+			mutex.Lock()
+			revoked = append(revoked, ids)
+			mutex.Unlock()
+		},
+	})
+	defer r.Close()
+
+	cg := &ConsumerGroup{config: ConsumerGroupConfig{RebalanceTimeout: time.Second}}
+
+	// generation runs a generation assigning the partitions, and returns the
+	// readers of the partitions once the generation has ended.
+	generation := func(end bool, partitions ...int) map[int]*assignedPartition {
+		gen := &Generation{
+			Assignments: map[string][]PartitionAssignment{"test": {}},
+			Protocol:    CooperativeRebalance,
+			conn:        mockCoordinator{},
+			done:        make(chan struct{}),
+			log:         func(func(Logger)) {},
+			logError:    func(func(Logger)) {},
+		}
+		for _, p := range partitions {
+			gen.Assignments["test"] = append(gen.Assignments["test"], PartitionAssignment{ID: p, Offset: FirstOffset})
+		}
+
+		r.runCooperative(cg, gen)
+		if end {
+			gen.close()
+		} else {
+			// the generation ends by itself when partitions are revoked.
+			select {
+			case <-gen.done:
+				gen.close()
+			case <-time.After(5 * time.Second):
+				t.Fatal("the generation revoking partitions did not end")
+			}
+		}
+
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		readers := make(map[int]*assignedPartition, len(r.assigned))
+		for tp, a := range r.assigned {
+			readers[tp.partition] = a
+		}
+		return readers
+	}
+
+	first := generation(true, 0, 1, 2)
+	second := generation(false, 0, 1)
+	third := generation(true, 0, 1, 3)
+
+	for _, p := range []int{0, 1} {
+		if first[p] != second[p] || first[p] != third[p] {
+			t.Errorf("the reader of partition %d was restarted", p)
+		}
+	}
+	if _, ok := second[2]; ok {
+		t.Error("the reader of the revoked partition 2 is still running")
+	}
+
+	stale := first[2]
+	if r.isAssigned(readerMessage{version: stale.version, topic: "test", partition: 2}) {
+		t.Error("the messages of the revoked partition 2 are still delivered")
+	}
+	if !r.isAssigned(readerMessage{version: first[0].version, topic: "test", partition: 0}) {
+		t.Error("the messages of partition 0 are not delivered anymore")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if want := [][]int{{0, 1, 2}, {3}}; !reflect.DeepEqual(assigned, want) {
+		t.Errorf("expected the partitions %v to be assigned, got %v", want, assigned)
+	}
+	if want := [][]int{{2}}; !reflect.DeepEqual(revoked, want) {
+		t.Errorf("expected the partitions %v to be revoked, got %v", want, revoked)
+	}
+}
+
+func TestReaderAssignedTopics(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		assigned []PartitionAssignment
+	)
+	r := NewReader(ReaderConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   "events.a",
+		OnPartitionsAssigned: func(ctx context.Context, assignments []PartitionAssignment) {
+			mutex.Lock()
+			assigned = append(assigned, assignments...)
+			mutex.Unlock()
+		},
+	})
+	defer r.Close()
+
+	cg := &ConsumerGroup{config: ConsumerGroupConfig{RebalanceTimeout: time.Second}}
+
+	generation := func(assignments map[string][]PartitionAssignment) *Generation {
+		gen := &Generation{
+			Assignments: assignments,
+			Protocol:    CooperativeRebalance,
+			conn:        mockCoordinator{},
+			done:        make(chan struct{}),
+			log:         func(func(Logger)) {},
+			logError:    func(func(Logger)) {},
+		}
+		r.runCooperative(cg, gen)
+		return gen
+	}
+
+	a0 := topicPartition{topic: "events.a", partition: 0}
+	b0 := topicPartition{topic: "events.b", partition: 0}
+
+	generation(map[string][]PartitionAssignment{
+		"events.a": {{ID: 0, Offset: FirstOffset}},
+		"events.b": {{ID: 0, Offset: FirstOffset}},
+	}).close()
+
+	r.mutex.Lock()
+	first := r.assigned[a0]
+	if len(r.assigned) != 2 || first == nil || r.assigned[b0] == nil {
+		t.Errorf("expected partition 0 of both topics to be assigned, got %+v", r.assigned)
+	}
+	r.mutex.Unlock()
+
+	// the partition of the topic which is not assigned anymore is revoked,
+	// the partition with the same number in the other topic keeps being read.
+	gen := generation(map[string][]PartitionAssignment{
+		"events.a": {{ID: 0, Offset: FirstOffset}},
+	})
+	select {
+	case <-gen.done:
+		gen.close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("the generation revoking partitions did not end")
+	}
+
+	if r.isAssigned(readerMessage{version: first.version, topic: "events.b", partition: 0}) {
+		t.Error("the messages of the revoked topic are still delivered")
+	}
+	if !r.isAssigned(readerMessage{version: first.version, topic: "events.a", partition: 0}) {
+		t.Error("the messages of the partition which stays assigned are not delivered anymore")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	want := []PartitionAssignment{
+		{Topic: "events.a", ID: 0, Offset: FirstOffset},
+		{Topic: "events.b", ID: 0, Offset: FirstOffset},
+	}
+	if !reflect.DeepEqual(assigned, want) {
+		t.Errorf("expected the partitions %+v to be assigned, got %+v", want, assigned)
+	}
+}
@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// RecordReader is the interface of the streams of records written by
+// Writer.WriteRecords. ReadRecord returns the next record of the stream, or
+// io.EOF once the stream has no more records.
+type RecordReader interface {
+	ReadRecord() (*Record, error)
+}
+
+// Record is a message read from a RecordReader, whose key and value are read
+// from io.Readers instead of being held in memory.
+//
+// The key and value are read once, when the record is queued to be written, and
+// closed after being read if they implement io.Closer. A nil Key or Value
+// writes a null key or value.
+type Record struct {
+	Topic string
+
+	// Partition is the partition that the record is written to when the
+	// writer has ExplicitPartitions, it is ignored otherwise.
+	Partition int
+
+	Time    time.Time
+	Headers []Header
+	Key     io.Reader
+	Value   io.Reader
+}
+
+// message reads the key and value of the record, and returns the message that
+// the writer writes for it.
+func (r *Record) message() (msg Message, err error) {
+	msg.Topic, msg.Partition, msg.Time, msg.Headers = r.Topic, r.Partition, r.Time, r.Headers
+
+	if msg.Key, err = readRecordBytes(r.Key); err != nil {
+		return msg, err
+	}
+	msg.Value, err = readRecordBytes(r.Value)
+	return msg, err
+}
+
+// readRecordBytes reads r to the end, readers which know their length (like
+// bytes.Reader or strings.Reader) are read without growing the buffer.
+func readRecordBytes(r io.Reader) ([]byte, error) {
+	if r == nil {
+		return nil, nil
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	if l, ok := r.(interface{ Len() int }); ok {
+		b := make([]byte, l.Len())
+		_, err := io.ReadFull(r, b)
+		return b, err
+	}
+	return ioutil.ReadAll(r)
+}
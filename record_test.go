@@ -0,0 +1,133 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// sliceRecordReader is a RecordReader which returns n records, and counts the
+// records that were read.
+type sliceRecordReader struct {
+	topic string
+	read  int
+	n     int
+}
+
+func (r *sliceRecordReader) ReadRecord() (*Record, error) {
+	if r.read == r.n {
+		return nil, io.EOF
+	}
+	r.read++
+	return &Record{
+		Topic: r.topic,
+		Key:   bytes.NewReader([]byte(strconv.Itoa(r.read))),
+		Value: &closingReader{Reader: bytes.NewBufferString("value-" + strconv.Itoa(r.read))},
+	}, nil
+}
+
+// closingReader records whether it was closed.
+type closingReader struct {
+	io.Reader
+	closed int
+}
+
+func (r *closingReader) Close() error {
+	r.closed++
+	return nil
+}
+
+func TestRecordMessage(t *testing.T) {
+	value := &closingReader{Reader: bytes.NewBufferString("world")}
+	r := &Record{Topic: "a", Key: bytes.NewReader([]byte("hello")), Value: value}
+
+	msg, err := r.message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Topic != "a" || string(msg.Key) != "hello" || string(msg.Value) != "world" {
+		t.Errorf("unexpected message read from the record: %+v", msg)
+	}
+	if value.closed != 1 {
+		t.Errorf("expected the value to be closed once, got %d", value.closed)
+	}
+
+	msg, err = (&Record{}).message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Key != nil || msg.Value != nil {
+		t.Errorf("expected a record without key and value to produce null ones, got %+v", msg)
+	}
+
+	failure := errors.New("failure")
+	if _, err := (&Record{Value: &failingReader{err: failure}}).message(); err != failure {
+		t.Errorf("expected the error of the value reader, got %v", err)
+	}
+}
+
+// failingReader is an io.Reader which always fails with err.
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestWriterWriteRecordsInvalid(t *testing.T) {
+	w := NewWriter(WriterConfig{
+		Brokers:   []string{"localhost:9092"},
+		Topic:     "a",
+		BatchSize: 2,
+	})
+	defer w.Close()
+
+	// The records have a topic while the writer has one as well, the first
+	// batch fails to be queued and no more records are read.
+	records := &sliceRecordReader{topic: "b", n: 10}
+	if err := w.WriteRecords(context.Background(), records); err == nil {
+		t.Error("expected the records to be rejected")
+	}
+	if records.read != 2 {
+		t.Errorf("expected a single batch of 2 records to be read, got %d records", records.read)
+	}
+}
+
+func testWriterWriteRecords(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	offset, err := readOffset(topic, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := newTestWriter(WriterConfig{
+		Topic:         topic,
+		BatchSize:     3,
+		QueueCapacity: 2,
+	})
+	defer w.Close()
+
+	records := &sliceRecordReader{n: 10}
+	if err := w.WriteRecords(context.Background(), records); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := readPartition(topic, 0, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 10 {
+		t.Fatalf("expected 10 messages, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if key := strconv.Itoa(i + 1); string(msg.Key) != key {
+			t.Errorf("message %d: expected key %s, got %s", i, key, msg.Key)
+		}
+	}
+}
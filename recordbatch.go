@@ -5,6 +5,10 @@ import (
 	"time"
 )
 
+// transactionalFlag is the bit set in the attributes of record batches that
+// are part of a transaction.
+const transactionalFlag int16 = 1 << 4
+
 const recordBatchHeaderSize int32 = 0 +
 	8 + // base offset
 	4 + // batch length
@@ -58,6 +62,12 @@ type recordBatch struct {
 	attributes int16
 	msgs       []Message
 
+	// producer parameters, all set to -1 unless the batch is produced by an
+	// idempotent or transactional producer
+	producerID    int64
+	producerEpoch int16
+	baseSequence  int32
+
 	// parameters calculated during init
 	compressed *bytes.Buffer
 	size       int32
@@ -65,8 +75,11 @@ type recordBatch struct {
 
 func newRecordBatch(codec CompressionCodec, msgs ...Message) (r *recordBatch, err error) {
 	r = &recordBatch{
-		codec: codec,
-		msgs:  msgs,
+		codec:         codec,
+		msgs:          msgs,
+		producerID:    -1,
+		producerEpoch: -1,
+		baseSequence:  -1,
 	}
 	if r.codec == nil {
 		r.size = recordBatchSize(r.msgs...)
@@ -76,18 +89,31 @@ func newRecordBatch(codec CompressionCodec, msgs ...Message) (r *recordBatch, er
 	return
 }
 
+// setProducer stamps the batch with the id and epoch of the producer, and the
+// sequence number of its first record.
+func (r *recordBatch) setProducer(producerID int64, producerEpoch int16, baseSequence int32) {
+	r.producerID = producerID
+	r.producerEpoch = producerEpoch
+	r.baseSequence = baseSequence
+}
+
+// setTransactional marks the batch as part of a transaction.
+func (r *recordBatch) setTransactional() {
+	r.attributes |= transactionalFlag
+}
+
 func (r *recordBatch) writeTo(wb *writeBuffer) {
 	wb.writeInt32(r.size)
 
 	baseTime := r.msgs[0].Time
 	lastTime := r.msgs[len(r.msgs)-1].Time
 	if r.compressed != nil {
-		wb.writeRecordBatch(r.attributes, r.size, len(r.msgs), baseTime, lastTime, func(wb *writeBuffer) {
+		wb.writeRecordBatch(r.attributes, r.size, len(r.msgs), baseTime, lastTime, r.producerID, r.producerEpoch, r.baseSequence, func(wb *writeBuffer) {
 			wb.Write(r.compressed.Bytes())
 		})
 		releaseBuffer(r.compressed)
 	} else {
-		wb.writeRecordBatch(r.attributes, r.size, len(r.msgs), baseTime, lastTime, func(wb *writeBuffer) {
+		wb.writeRecordBatch(r.attributes, r.size, len(r.msgs), baseTime, lastTime, r.producerID, r.producerEpoch, r.baseSequence, func(wb *writeBuffer) {
 			for i, msg := range r.msgs {
 				wb.writeRecord(0, r.msgs[0].Time, int64(i), msg)
 			}
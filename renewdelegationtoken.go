@@ -0,0 +1,139 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"time"
+)
+
+// RenewDelegationTokenRequest represents a request sent to a kafka cluster to
+// extend the expiry time of a delegation token.
+type RenewDelegationTokenRequest struct {
+	// HMAC identifies the token to renew.
+	HMAC []byte
+
+	// RenewPeriod is the duration after which the token expires, counted
+	// from the time of the request. The broker default
+	// (delegation.token.expiry.time.ms) applies when it is zero.
+	RenewPeriod time.Duration
+}
+
+// RenewDelegationTokenResponse represents the response to a
+// RenewDelegationTokenRequest.
+type RenewDelegationTokenResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// broker.
+	Throttle time.Duration
+
+	// ExpiryTime is the new expiry time of the token, it never goes past
+	// the max time of the token.
+	ExpiryTime time.Time
+}
+
+// RenewDelegationToken sends a RenewDelegationToken request to the kafka
+// cluster. The API was introduced in kafka 1.1 (KIP-48).
+func (c *Client) RenewDelegationToken(ctx context.Context, req RenewDelegationTokenRequest) (*RenewDelegationTokenResponse, error) {
+	request := renewDelegationTokenRequestV1{
+		HMAC:          req.HMAC,
+		RenewPeriodMS: -1,
+	}
+	if req.RenewPeriod > 0 {
+		request.RenewPeriodMS = int64(req.RenewPeriod / time.Millisecond)
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.renewDelegationToken(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenewDelegationTokenResponse{
+		Throttle:   duration(response.ThrottleTimeMS),
+		ExpiryTime: timestampToTime(response.ExpiryTimestampMS),
+	}, nil
+}
+
+// renewDelegationToken extends the expiry time of the delegation token of the
+// request.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_RenewDelegationToken
+func (c *Conn) renewDelegationToken(request renewDelegationTokenRequestV1) (delegationTokenExpiryResponseV1, error) {
+	var response delegationTokenExpiryResponseV1
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(renewDelegationToken, v1, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return delegationTokenExpiryResponseV1{}, err
+	}
+	if response.ErrorCode != 0 {
+		return delegationTokenExpiryResponseV1{}, Error(response.ErrorCode)
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_RenewDelegationToken
+type renewDelegationTokenRequestV1 struct {
+	HMAC []byte
+
+	// RenewPeriodMS is -1 to use the broker default.
+	RenewPeriodMS int64
+}
+
+func (t renewDelegationTokenRequestV1) size() int32 {
+	return sizeofBytes(t.HMAC) +
+		sizeofInt64(t.RenewPeriodMS)
+}
+
+func (t renewDelegationTokenRequestV1) writeTo(wb *writeBuffer) {
+	wb.writeBytes(t.HMAC)
+	wb.writeInt64(t.RenewPeriodMS)
+}
+
+// delegationTokenExpiryResponseV1 is the response to both RenewDelegationToken
+// and ExpireDelegationToken requests, which share the same layout.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_RenewDelegationToken
+type delegationTokenExpiryResponseV1 struct {
+	ErrorCode         int16
+	ExpiryTimestampMS int64
+	ThrottleTimeMS    int32
+}
+
+func (t delegationTokenExpiryResponseV1) size() int32 {
+	return sizeofInt16(t.ErrorCode) +
+		sizeofInt64(t.ExpiryTimestampMS) +
+		sizeofInt32(t.ThrottleTimeMS)
+}
+
+func (t delegationTokenExpiryResponseV1) writeTo(wb *writeBuffer) {
+	wb.writeInt16(t.ErrorCode)
+	wb.writeInt64(t.ExpiryTimestampMS)
+	wb.writeInt32(t.ThrottleTimeMS)
+}
+
+func (t *delegationTokenExpiryResponseV1) readFrom(r *bufio.Reader, size int) (remain int, err error) {
+	if remain, err = readInt16(r, size, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readInt64(r, remain, &t.ExpiryTimestampMS); err != nil {
+		return
+	}
+	if remain, err = readInt32(r, remain, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,191 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+)
+
+// ResetConsumerGroupOffsetsRequest represents a request to reset the offsets
+// committed by a consumer group.
+type ResetConsumerGroupOffsetsRequest struct {
+	// GroupID is the ID of the group to reset the offsets of.
+	GroupID string
+
+	// Topics holds the partitions to reset, indexed by topic name. All the
+	// partitions of a topic are reset when its list of partitions is empty.
+	Topics map[string][]int
+
+	// Timestamp is FirstOffset, LastOffset, or a timestamp in milliseconds,
+	// in which case partitions are reset to the earliest offset whose
+	// timestamp is greater than or equal to it, or to their last offset if
+	// they have no such message.
+	Timestamp int64
+
+	// When DryRun is true, the offsets that the partitions would be reset to
+	// are returned but not committed.
+	DryRun bool
+}
+
+// ResetConsumerGroupOffsetsResponse represents the response to a
+// ResetConsumerGroupOffsetsRequest.
+type ResetConsumerGroupOffsetsResponse struct {
+	// Topics holds the result of resetting the offset of each partition,
+	// indexed by topic name and sorted by partition.
+	Topics map[string][]ResetConsumerGroupOffsetsPartition
+}
+
+// ResetConsumerGroupOffsetsPartition carries the result of resetting the
+// offset of a partition.
+type ResetConsumerGroupOffsetsPartition struct {
+	Partition int
+
+	// Offset is the offset that the partition was reset to, which is the
+	// offset of the next message that the group consumes. It is -1 if the
+	// offset could not be looked up.
+	Offset int64
+
+	// Error is set to a non-nil value if the offset of the partition could
+	// not be looked up or committed, the committed offset of the partition is
+	// left unchanged in that case.
+	Error error
+}
+
+// ResetConsumerGroupOffsets resets the offsets committed by a consumer group
+// to the first or last offsets of partitions, or to the offsets of the first
+// messages written at or after a point in time.
+//
+// The offsets can only be reset while the group has no active members, the
+// method fails without committing any offset if it does. The method requires
+// kafka 2.1 or above.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) ResetConsumerGroupOffsets(ctx context.Context, req ResetConsumerGroupOffsetsRequest) (*ResetConsumerGroupOffsetsResponse, error) {
+	groups, err := c.DescribeGroups(ctx, DescribeGroupsRequest{GroupIDs: []string{req.GroupID}})
+	if err != nil {
+		return nil, err
+	}
+
+	group := groups.Groups[0]
+	if group.Error != nil {
+		return nil, group.Error
+	}
+	// Groups that never committed offsets are reported as Dead.
+	if group.GroupState != "Empty" && group.GroupState != "Dead" {
+		return nil, fmt.Errorf("the offsets of group %s cannot be reset while it has active members (state %s)", req.GroupID, group.GroupState)
+	}
+
+	topics, err := c.topicPartitions(ctx, req.Topics)
+	if err != nil {
+		return nil, err
+	}
+
+	listOffsets := ListOffsetsRequest{Topics: make(map[string][]OffsetRequest, len(topics))}
+	for topic, partitions := range topics {
+		for _, p := range partitions {
+			listOffsets.Topics[topic] = append(listOffsets.Topics[topic], OffsetRequest{
+				Partition: p,
+				Timestamp: req.Timestamp,
+			})
+		}
+	}
+
+	offsets, err := c.ListOffsets(ctx, listOffsets)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Timestamp != FirstOffset && req.Timestamp != LastOffset {
+		if err := c.resetLateOffsets(ctx, offsets); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &ResetConsumerGroupOffsetsResponse{
+		Topics: make(map[string][]ResetConsumerGroupOffsetsPartition, len(offsets.Topics)),
+	}
+
+	commit := OffsetCommitRequest{
+		GroupID:      req.GroupID,
+		GenerationID: -1,
+		Topics:       make(map[string][]OffsetCommit),
+	}
+
+	for topic, partitions := range offsets.Topics {
+		reset := make([]ResetConsumerGroupOffsetsPartition, len(partitions))
+		for i, p := range partitions {
+			reset[i] = ResetConsumerGroupOffsetsPartition{
+				Partition: p.Partition,
+				Offset:    p.Offset,
+				Error:     p.Error,
+			}
+			if p.Error == nil {
+				commit.Topics[topic] = append(commit.Topics[topic], OffsetCommit{
+					Partition: p.Partition,
+					Offset:    p.Offset,
+				})
+			}
+		}
+		res.Topics[topic] = reset
+	}
+
+	if req.DryRun || len(commit.Topics) == 0 {
+		return res, nil
+	}
+
+	committed, err := c.OffsetCommit(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	for topic, partitions := range committed.Topics {
+		errs := make(map[int]error, len(partitions))
+		for _, p := range partitions {
+			errs[p.Partition] = p.Error
+		}
+		for i, p := range res.Topics[topic] {
+			if err, ok := errs[p.Partition]; ok && err != nil {
+				res.Topics[topic][i].Error = err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// resetLateOffsets replaces the offsets of the partitions which had no message
+// at or after the looked up timestamp with the last offsets of the partitions.
+func (c *Client) resetLateOffsets(ctx context.Context, offsets *ListOffsetsResponse) error {
+	late := ListOffsetsRequest{Topics: make(map[string][]OffsetRequest)}
+
+	for topic, partitions := range offsets.Topics {
+		for _, p := range partitions {
+			if p.Error == nil && p.Offset < 0 {
+				late.Topics[topic] = append(late.Topics[topic], LastOffsetOf(p.Partition))
+			}
+		}
+	}
+
+	if len(late.Topics) == 0 {
+		return nil
+	}
+
+	last, err := c.ListOffsets(ctx, late)
+	if err != nil {
+		return err
+	}
+
+	for topic, partitions := range last.Topics {
+		found := make(map[int]ListOffsetsResponsePartition, len(partitions))
+		for _, p := range partitions {
+			found[p.Partition] = p
+		}
+		for i, p := range offsets.Topics[topic] {
+			if f, ok := found[p.Partition]; ok {
+				offsets.Topics[topic][i] = f
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
+)
+
+func testClientResetConsumerGroupOffsets(t *testing.T, ctx context.Context, c *Client) {
+	if !ktesting.KafkaIsAtLeast("2.1.0") {
+		t.Skip("committing offsets without a group generation requires kafka 2.1.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	groupID := makeGroupID()
+	createTopic(t, topic, 2)
+
+	produced, err := c.Produce(ctx, ProduceRequest{
+		Topic:     topic,
+		Partition: 0,
+		Messages:  makeTestSequence(3),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if produced.Error != nil {
+		t.Fatal(produced.Error)
+	}
+
+	if _, err := c.OffsetCommit(ctx, OffsetCommitRequest{
+		GroupID:      groupID,
+		GenerationID: -1,
+		Topics:       map[string][]OffsetCommit{topic: {{Partition: 0, Offset: 1}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	committedOffsets := func() map[int]int64 {
+		res, err := c.OffsetFetch(ctx, OffsetFetchRequest{GroupID: groupID, Topics: map[string][]int{topic: {0, 1}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets := make(map[int]int64)
+		for _, p := range res.Topics[topic] {
+			offsets[p.Partition] = p.CommittedOffset
+		}
+		return offsets
+	}
+
+	checkReset := func(res *ResetConsumerGroupOffsetsResponse, expected map[int]int64) {
+		partitions := res.Topics[topic]
+		if len(partitions) != len(expected) {
+			t.Fatalf("expected %d partitions to be reset, got %d", len(expected), len(partitions))
+		}
+		for _, p := range partitions {
+			if p.Error != nil {
+				t.Errorf("resetting the offset of partition %d failed: %v", p.Partition, p.Error)
+			}
+			if p.Offset != expected[p.Partition] {
+				t.Errorf("expected partition %d to be reset to offset %d, got %d", p.Partition, expected[p.Partition], p.Offset)
+			}
+		}
+	}
+
+	res, err := c.ResetConsumerGroupOffsets(ctx, ResetConsumerGroupOffsetsRequest{
+		GroupID:   groupID,
+		Topics:    map[string][]int{topic: nil},
+		Timestamp: LastOffset,
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReset(res, map[int]int64{0: 3, 1: 0})
+
+	if offsets := committedOffsets(); offsets[0] != 1 || offsets[1] != -1 {
+		t.Errorf("a dry run changed the committed offsets of the group: %v", offsets)
+	}
+
+	res, err = c.ResetConsumerGroupOffsets(ctx, ResetConsumerGroupOffsetsRequest{
+		GroupID:   groupID,
+		Topics:    map[string][]int{topic: {0}},
+		Timestamp: LastOffset,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReset(res, map[int]int64{0: 3})
+
+	if offsets := committedOffsets(); offsets[0] != 3 || offsets[1] != -1 {
+		t.Errorf("expected the committed offset of partition 0 to be reset to 3, got %v", offsets)
+	}
+}
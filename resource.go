@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ResourceType identifies the kind of kafka resource targeted by admin
+// requests such as DescribeConfigs.
+//
+// See https://github.com/apache/kafka/blob/trunk/clients/src/main/java/org/apache/kafka/common/resource/ResourceType.java
+type ResourceType int8
+
+const (
+	ResourceTypeUnknown         ResourceType = 0
+	ResourceTypeAny             ResourceType = 1
+	ResourceTypeTopic           ResourceType = 2
+	ResourceTypeGroup           ResourceType = 3
+	ResourceTypeBroker          ResourceType = 4
+	ResourceTypeTransactionalID ResourceType = 5
+	ResourceTypeDelegationToken ResourceType = 6
+
+	// ResourceTypeBrokerLogger is only valid for config APIs, it designates
+	// the log4j loggers of a broker.
+	ResourceTypeBrokerLogger ResourceType = 8
+)
+
+// ResourceTypeCluster shares its value with ResourceTypeBroker, config APIs
+// refer to brokers while ACL APIs refer to the cluster.
+const ResourceTypeCluster = ResourceTypeBroker
+
+// brokerBound returns true if requests about resources of this type must be
+// sent directly to the broker that the resource name designates.
+func (t ResourceType) brokerBound() bool {
+	return t == ResourceTypeBroker || t == ResourceTypeBrokerLogger
+}
+
+// configResourceRoutes groups the indexes of n config resources by the ID of
+// the broker that the requests about them must be sent to. Resources that any
+// broker can handle are grouped under -1.
+func configResourceRoutes(n int, resource func(int) (ResourceType, string)) (map[int][]int, error) {
+	routes := map[int][]int{}
+
+	for i := 0; i < n; i++ {
+		broker := -1
+		if typ, name := resource(i); typ.brokerBound() && name != "" {
+			id, err := strconv.Atoi(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid broker id in config resource: %q", name)
+			}
+			broker = id
+		}
+		routes[broker] = append(routes[broker], i)
+	}
+
+	return routes, nil
+}
@@ -100,3 +100,37 @@ func testConnect(t *testing.T, mechanism sasl.Mechanism, success bool) {
 		t.Errorf("should not have logged in correctly")
 	}
 }
+
+func TestDelegationToken(t *testing.T) {
+	if !ktesting.KafkaIsAtLeast("1.1.0") {
+		t.Skip("delegation tokens require kafka 1.1.0 or newer")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mech, _ := scram.Mechanism(scram.SHA256, "adminscram", "admin-secret-256")
+	client := kafka.NewClientWith(kafka.ClientConfig{
+		Brokers: []string{saslTestConnect},
+		Dialer:  &kafka.Dialer{SASLMechanism: mech},
+	})
+
+	res, err := client.CreateDelegationToken(ctx, kafka.CreateDelegationTokenRequest{})
+	if err == kafka.DelegationTokenAuthDisabled {
+		t.Skip("the brokers are not configured with a delegation token master key")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := scram.TokenMechanism(scram.SHA256, res.Token.TokenID, res.Token.HMAC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testConnect(t, token, true)
+
+	if _, err := client.ExpireDelegationToken(ctx, kafka.ExpireDelegationTokenRequest{HMAC: res.Token.HMAC}); err != nil {
+		t.Fatal(err)
+	}
+	testConnect(t, token, false)
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/segmentio/kafka-go/sasl"
 	"github.com/xdg/scram"
+	"github.com/xdg/stringprep"
+	"golang.org/x/crypto/pbkdf2"
 )
 
 // Algorithm determines the hash function used by SCRAM to protect the user's
@@ -89,3 +91,15 @@ func (s *session) Next(ctx context.Context, challenge []byte) (bool, []byte, err
 	str, err := s.convo.Step(string(challenge))
 	return s.convo.Done(), []byte(str), err
 }
+
+// SaltedPassword derives the salted password of SCRAM from the provided
+// credentials, as defined by RFC 5802. It is the value stored by kafka for
+// SCRAM users, and may be used to create or update the credentials of a user
+// with kafka.Client.AlterUserScramCredentials.
+func SaltedPassword(algo Algorithm, password string, salt []byte, iterations int) ([]byte, error) {
+	prepped, err := stringprep.SASLprep.Prepare(password)
+	if err != nil {
+		return nil, err
+	}
+	return pbkdf2.Key([]byte(prepped), salt, iterations, algo.Hash().Size(), algo.Hash), nil
+}
@@ -0,0 +1,38 @@
+package scram
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"testing"
+
+	"github.com/xdg/scram"
+)
+
+func TestSaltedPassword(t *testing.T) {
+	for _, algo := range []Algorithm{SHA256, SHA512} {
+		t.Run(algo.Name(), func(t *testing.T) {
+			salt := []byte("salt")
+
+			saltedPassword, err := SaltedPassword(algo, "pencil", salt, 4096)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client, err := scram.HashGeneratorFcn(algo.Hash).NewClient("user", "pencil", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := client.GetStoredCredentials(scram.KeyFactors{Salt: string(salt), Iters: 4096})
+
+			// The stored key is H(HMAC(SaltedPassword, "Client Key")).
+			mac := hmac.New(algo.Hash, saltedPassword)
+			mac.Write([]byte("Client Key"))
+			h := algo.Hash()
+			h.Write(mac.Sum(nil))
+
+			if storedKey := h.Sum(nil); !bytes.Equal(storedKey, expected.StoredKey) {
+				t.Errorf("stored key mismatch: expected %x, got %x", expected.StoredKey, storedKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package scram
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/kafka-go/sasl"
+)
+
+type tokenMechanism struct {
+	algo     Algorithm
+	tokenID  string
+	password string
+}
+
+type tokenSession struct {
+	mech            *tokenMechanism
+	nonce           string
+	clientFirstBare string
+	serverSignature []byte
+}
+
+// gs2Header is the header of the client messages, delegation tokens do not
+// support channel binding nor authorization identities.
+const gs2Header = "n,,"
+
+// TokenMechanism returns a new sasl.Mechanism that authenticates with a
+// delegation token, as created by kafka.Client.CreateDelegationToken. The
+// algorithm must match a SCRAM mechanism enabled on the brokers.
+//
+// Delegation tokens were added to Kafka in 1.1.0 (KIP-48). The mechanism
+// sends the tokenauth=true SCRAM extension so the brokers look up the
+// credentials of the token instead of the ones of a user.
+func TokenMechanism(algo Algorithm, tokenID string, hmac []byte) (sasl.Mechanism, error) {
+	if tokenID == "" {
+		return nil, errors.New("token id must not be empty")
+	}
+	return &tokenMechanism{
+		algo:     algo,
+		tokenID:  tokenID,
+		password: base64.StdEncoding.EncodeToString(hmac),
+	}, nil
+}
+
+func (m *tokenMechanism) Name() string {
+	return m.algo.Name()
+}
+
+func (m *tokenMechanism) Start(ctx context.Context) (sasl.StateMachine, []byte, error) {
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	s := &tokenSession{
+		mech:  m,
+		nonce: base64.StdEncoding.EncodeToString(nonce),
+	}
+	s.clientFirstBare = fmt.Sprintf("n=%s,r=%s,tokenauth=true", encodeName(m.tokenID), s.nonce)
+	return s, []byte(gs2Header + s.clientFirstBare), nil
+}
+
+func (s *tokenSession) Next(ctx context.Context, challenge []byte) (bool, []byte, error) {
+	if s.serverSignature == nil {
+		msg, err := s.clientFinal(string(challenge))
+		return false, []byte(msg), err
+	}
+	return true, nil, s.verifyServerFinal(string(challenge))
+}
+
+func (s *tokenSession) clientFinal(serverFirst string) (string, error) {
+	fields := parseFields(serverFirst)
+
+	nonce := fields["r"]
+	if !strings.HasPrefix(nonce, s.nonce) {
+		return "", errors.New("server nonce did not extend client nonce")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(fields["s"])
+	if err != nil {
+		return "", fmt.Errorf("invalid salt in server first message: %v", err)
+	}
+
+	iterations, err := strconv.Atoi(fields["i"])
+	if err != nil {
+		return "", fmt.Errorf("invalid iteration count in server first message: %v", err)
+	}
+
+	saltedPassword, err := SaltedPassword(s.mech.algo, s.mech.password, salt, iterations)
+	if err != nil {
+		return "", err
+	}
+
+	clientFinalWithoutProof := fmt.Sprintf("c=%s,r=%s", base64.StdEncoding.EncodeToString([]byte(gs2Header)), nonce)
+	authMessage := s.clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+
+	clientKey := s.hmac(saltedPassword, "Client Key")
+	h := s.mech.algo.Hash()
+	h.Write(clientKey)
+	storedKey := h.Sum(nil)
+
+	proof := s.hmac(storedKey, authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+
+	s.serverSignature = s.hmac(s.hmac(saltedPassword, "Server Key"), authMessage)
+	return clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+func (s *tokenSession) verifyServerFinal(serverFinal string) error {
+	fields := parseFields(serverFinal)
+
+	if e, ok := fields["e"]; ok {
+		return fmt.Errorf("server error: %s", e)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(fields["v"])
+	if err != nil {
+		return fmt.Errorf("invalid server signature: %v", err)
+	}
+	if !hmac.Equal(signature, s.serverSignature) {
+		return errors.New("server validation failed")
+	}
+	return nil
+}
+
+func (s *tokenSession) hmac(key []byte, data string) []byte {
+	mac := hmac.New(s.mech.algo.Hash, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// parseFields splits a SCRAM message into its attributes, indexed by name.
+func parseFields(msg string) map[string]string {
+	fields := make(map[string]string)
+	for _, f := range strings.Split(msg, ",") {
+		if i := strings.IndexByte(f, '='); i > 0 {
+			fields[f[:i]] = f[i+1:]
+		}
+	}
+	return fields
+}
+
+// encodeName escapes the characters of a SCRAM user name as defined by
+// RFC 5802.
+func encodeName(s string) string {
+	return strings.NewReplacer("=", "=3D", ",", "=2C").Replace(s)
+}
@@ -0,0 +1,65 @@
+package scram
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/xdg/scram"
+)
+
+func TestTokenMechanism(t *testing.T) {
+	for _, algo := range []Algorithm{SHA256, SHA512} {
+		t.Run(algo.Name(), func(t *testing.T) {
+			const tokenID = "token-id"
+			hmac := []byte("token-hmac")
+
+			hashGen := scram.HashGeneratorFcn(algo.Hash)
+			client, err := hashGen.NewClient(tokenID, base64.StdEncoding.EncodeToString(hmac), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			credentials := client.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+
+			server, err := hashGen.NewServer(func(name string) (scram.StoredCredentials, error) {
+				if name != tokenID {
+					t.Errorf("expected user %q, got %q", tokenID, name)
+				}
+				return credentials, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			convo := server.NewConversation()
+
+			mech, err := TokenMechanism(algo, tokenID, hmac)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			sess, msg, err := mech.Start(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(string(msg), ",tokenauth=true") {
+				t.Errorf("expected the tokenauth extension in %q", msg)
+			}
+
+			for done := false; !done; {
+				challenge, err := convo.Step(string(msg))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if done, msg, err = sess.Next(ctx, []byte(challenge)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !convo.Valid() {
+				t.Error("expected the server to authenticate the token")
+			}
+		})
+	}
+}
@@ -44,6 +44,14 @@ func sizeofInt64(_ int64) int32 {
 	return 8
 }
 
+func sizeofUUID(_ [16]byte) int32 {
+	return 16
+}
+
+func sizeofFloat64(_ float64) int32 {
+	return 8
+}
+
 func sizeofString(s string) int32 {
 	return 2 + int32(len(s))
 }
@@ -78,3 +86,51 @@ func sizeofInt32Array(a []int32) int32 {
 func sizeofStringArray(a []string) int32 {
 	return sizeofArray(len(a), func(i int) int32 { return sizeofString(a[i]) })
 }
+
+func sizeofUnsignedVarInt(u uint64) int32 {
+	n := int32(1)
+	for u >= 0x80 {
+		u >>= 7
+		n++
+	}
+	return n
+}
+
+func sizeofCompactString(s string) int32 {
+	return sizeofUnsignedVarInt(uint64(len(s))+1) + int32(len(s))
+}
+
+func sizeofCompactNullableString(s *string) int32 {
+	if s == nil {
+		return 1
+	}
+	return sizeofCompactString(*s)
+}
+
+func sizeofCompactBytes(b []byte) int32 {
+	if b == nil {
+		return 1
+	}
+	return sizeofUnsignedVarInt(uint64(len(b))+1) + int32(len(b))
+}
+
+func sizeofCompactArray(n int, f func(int) int32) int32 {
+	s := sizeofUnsignedVarInt(uint64(n + 1))
+	for i := 0; i != n; i++ {
+		s += f(i)
+	}
+	return s
+}
+
+func sizeofCompactInt32Array(a []int32) int32 {
+	return sizeofUnsignedVarInt(uint64(len(a)+1)) + (4 * int32(len(a)))
+}
+
+func sizeofCompactStringArray(a []string) int32 {
+	return sizeofCompactArray(len(a), func(i int) int32 { return sizeofCompactString(a[i]) })
+}
+
+// sizeofTaggedFields returns the size of an empty set of tagged fields.
+func sizeofTaggedFields() int32 {
+	return 1
+}
@@ -14,10 +14,18 @@ func init() {
 
 // Framing is an enumeration type used to enable or disable xerial framing of
 // snappy messages.
+//
+// The framing only applies to the messages written by the codec, readers detect
+// the xerial header and decode both framed messages and raw snappy blocks.
 type Framing int
 
 const (
+	// Framed writes the xerial framing used by the Java client, which every
+	// consumer understands.
 	Framed Framing = iota
+
+	// Unframed writes raw snappy blocks, for interoperability with consumers
+	// which do not support the xerial framing.
 	Unframed
 )
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"io"
+	"io/ioutil"
 	"testing"
 
 	goxerialsnappy "github.com/eapache/go-xerial-snappy"
@@ -163,3 +164,92 @@ func TestXerialWriterAgainstGoXerialSnappy(t *testing.T) {
 		t.Error("data mismatch")
 	}
 }
+
+var (
+	// snappyFixtureData is the content of the snappy fixtures.
+	snappyFixtureData = "kafka-go snappy fixture, kafka-go snappy fixture, second xerial frame"
+
+	// rawSnappyFixture holds a raw snappy block, like the ones written by the
+	// producers which do not use the xerial framing, of the first 50 bytes of
+	// snappyFixtureData.
+	rawSnappyFixture = []byte{
+		0x32, 0x60, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x2d, 0x67, 0x6f, 0x20, 0x73,
+		0x6e, 0x61, 0x70, 0x70, 0x79, 0x20, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72,
+		0x65, 0x2c, 0x20, 0x62, 0x19, 0x00,
+	}
+
+	// xerialSnappyFixture holds snappyFixtureData in two xerial frames, like
+	// the messages written by the Java client.
+	xerialSnappyFixture = []byte{
+		// magic, version and minimum compatible version
+		0x82, 0x53, 0x4e, 0x41, 0x50, 0x50, 0x59, 0x00,
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+		// first frame
+		0x00, 0x00, 0x00, 0x1e,
+		0x32, 0x60, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x2d, 0x67, 0x6f, 0x20, 0x73,
+		0x6e, 0x61, 0x70, 0x70, 0x79, 0x20, 0x66, 0x69, 0x78, 0x74, 0x75, 0x72,
+		0x65, 0x2c, 0x20, 0x62, 0x19, 0x00,
+		// second frame
+		0x00, 0x00, 0x00, 0x15,
+		0x13, 0x48, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x20, 0x78, 0x65, 0x72,
+		0x69, 0x61, 0x6c, 0x20, 0x66, 0x72, 0x61, 0x6d, 0x65,
+	}
+)
+
+func TestCompressionCodecReadFixtures(t *testing.T) {
+	tests := []struct {
+		scenario string
+		input    []byte
+		output   string
+	}{
+		{
+			scenario: "raw snappy",
+			input:    rawSnappyFixture,
+			output:   snappyFixtureData[:50],
+		},
+		{
+			scenario: "xerial framing",
+			input:    xerialSnappyFixture,
+			output:   snappyFixtureData,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			// The framing of the codec only applies to the write path, both
+			// codecs decode either format.
+			for _, framing := range []Framing{Framed, Unframed} {
+				r := NewCompressionCodecFraming(framing).NewReader(bytes.NewReader(test.input))
+				b, err := ioutil.ReadAll(simpleReader{r})
+				r.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != test.output {
+					t.Errorf("expected %q, got %q", test.output, b)
+				}
+			}
+		})
+	}
+}
+
+func TestCompressionCodecWriteFraming(t *testing.T) {
+	write := func(framing Framing, data string) []byte {
+		b := new(bytes.Buffer)
+		w := NewCompressionCodecFraming(framing).NewWriter(b)
+		if _, err := io.WriteString(w, data); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return b.Bytes()
+	}
+
+	if b := write(Unframed, snappyFixtureData[:50]); !bytes.Equal(b, rawSnappyFixture) {
+		t.Errorf("expected the unframed codec to write a raw snappy block:\n%x\n%x", rawSnappyFixture, b)
+	}
+	if b := write(Framed, snappyFixtureData[:50]); !bytes.Equal(b, xerialSnappyFixture[:20+len(rawSnappyFixture)]) {
+		t.Errorf("expected the framed codec to write a single xerial frame:\n%x\n%x", xerialSnappyFixture[:20+len(rawSnappyFixture)], b)
+	}
+}
@@ -3,8 +3,184 @@ package kafka
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
 )
 
+// SyncGroupRequest represents a request sent to the coordinator of a group to
+// complete a rebalance. The leader of the group sends the assignments of all
+// members, the other members send no assignments.
+type SyncGroupRequest struct {
+	// GroupID is the ID of the group.
+	GroupID string
+
+	// GenerationID, MemberID, and GroupInstanceID identify the member, as
+	// returned by JoinGroup.
+	GenerationID    int
+	MemberID        string
+	GroupInstanceID string
+
+	// Assignments holds the assignments of the members of the group, it must
+	// only be set by the leader.
+	Assignments []SyncGroupRequestAssignment
+}
+
+// SyncGroupRequestAssignment is the assignment of a member of a group. For
+// consumer groups, the assignment is an encoded GroupProtocolAssignment.
+type SyncGroupRequestAssignment struct {
+	MemberID   string
+	Assignment []byte
+}
+
+// SyncGroupResponse represents the response to a SyncGroupRequest.
+type SyncGroupResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+
+	// Error is set to a non-nil value if the assignment could not be
+	// retrieved, for example RebalanceInProgress if the group started a new
+	// rebalance and the member must rejoin it.
+	Error error
+
+	// Assignment is the assignment of the member, as sent by the leader.
+	Assignment []byte
+}
+
+// SyncGroup sends a sync group request to the coordinator of the group. The
+// coordinator responds to the members once the leader has sent the
+// assignments. The method requires kafka 2.3 or above.
+func (c *Client) SyncGroup(ctx context.Context, req SyncGroupRequest) (*SyncGroupResponse, error) {
+	conn, err := c.groupCoordinator(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	request := syncGroupRequestV3{
+		GroupID:          req.GroupID,
+		GenerationID:     int32(req.GenerationID),
+		MemberID:         req.MemberID,
+		GroupInstanceID:  emptyToNullable(req.GroupInstanceID),
+		GroupAssignments: make([]syncGroupRequestGroupAssignmentV0, len(req.Assignments)),
+	}
+
+	for i, a := range req.Assignments {
+		request.GroupAssignments[i] = syncGroupRequestGroupAssignmentV0{
+			MemberID:          a.MemberID,
+			MemberAssignments: a.Assignment,
+		}
+	}
+
+	response, err := conn.syncGroupV3(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SyncGroupResponse{
+		Throttle:   duration(response.ThrottleTimeMS),
+		Assignment: response.MemberAssignments,
+	}
+	if response.ErrorCode != 0 {
+		res.Error = Error(response.ErrorCode)
+	}
+
+	return res, nil
+}
+
+// GroupProtocolAssignment is the assignment that the leader of a consumer
+// group sends to a member, as defined by the consumer protocol.
+type GroupProtocolAssignment struct {
+	Version int
+
+	// Topics holds the partitions assigned to the member, indexed by topic
+	// name.
+	Topics map[string][]int
+
+	// UserData is arbitrary data interpreted by the balancer.
+	UserData []byte
+}
+
+// Bytes returns the encoded form of the assignment.
+func (a GroupProtocolAssignment) Bytes() []byte {
+	assignment := groupAssignment{
+		Version:  int16(a.Version),
+		Topics:   make(map[string][]int32, len(a.Topics)),
+		UserData: a.UserData,
+	}
+
+	for topic, partitions := range a.Topics {
+		p := make([]int32, len(partitions))
+		for i, partition := range partitions {
+			p[i] = int32(partition)
+		}
+		assignment.Topics[topic] = p
+	}
+
+	return assignment.bytes()
+}
+
+// DecodeGroupProtocolAssignment decodes the assignment of a member of a
+// consumer group, partitions are sorted in ascending order. An empty slice,
+// which members receive while the group is rebalancing, decodes to an empty
+// assignment.
+func DecodeGroupProtocolAssignment(b []byte) (GroupProtocolAssignment, error) {
+	if len(b) == 0 {
+		return GroupProtocolAssignment{}, nil
+	}
+
+	assignment := groupAssignment{}
+	if remain, err := (&assignment).readFrom(bufio.NewReader(bytes.NewReader(b)), len(b)); err != nil {
+		return GroupProtocolAssignment{}, err
+	} else if remain != 0 {
+		return GroupProtocolAssignment{}, fmt.Errorf("%d unexpected bytes after the member assignments", remain)
+	}
+
+	res := GroupProtocolAssignment{
+		Version:  int(assignment.Version),
+		Topics:   make(map[string][]int, len(assignment.Topics)),
+		UserData: assignment.UserData,
+	}
+
+	for topic, partitions := range assignment.Topics {
+		p := make([]int, len(partitions))
+		for i, partition := range partitions {
+			p[i] = int(partition)
+		}
+		sort.Ints(p)
+		res.Topics[topic] = p
+	}
+
+	return res, nil
+}
+
+// syncGroupV3 sends a sync group request, the broker must be the coordinator
+// of the group. Unlike syncGroup, the error code of the response does not
+// cause the method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_SyncGroup
+func (c *Conn) syncGroupV3(request syncGroupRequestV3) (syncGroupResponseV3, error) {
+	var response syncGroupResponseV3
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(syncGroup, v3, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return syncGroupResponseV3{}, err
+	}
+
+	return response, nil
+}
+
 type groupAssignment struct {
 	Version  int16
 	Topics   map[string][]int32
@@ -137,3 +313,62 @@ func (t *syncGroupResponseV0) readFrom(r *bufio.Reader, sz int) (remain int, err
 	}
 	return
 }
+
+// syncGroupRequestV3 has the same layout than syncGroupRequestV0 with the
+// group instance ID of static members after the member ID.
+type syncGroupRequestV3 struct {
+	GroupID          string
+	GenerationID     int32
+	MemberID         string
+	GroupInstanceID  *string
+	GroupAssignments []syncGroupRequestGroupAssignmentV0
+}
+
+func (t syncGroupRequestV3) size() int32 {
+	return sizeofString(t.GroupID) +
+		sizeofInt32(t.GenerationID) +
+		sizeofString(t.MemberID) +
+		sizeofNullableString(t.GroupInstanceID) +
+		sizeofArray(len(t.GroupAssignments), func(i int) int32 { return t.GroupAssignments[i].size() })
+}
+
+func (t syncGroupRequestV3) writeTo(wb *writeBuffer) {
+	wb.writeString(t.GroupID)
+	wb.writeInt32(t.GenerationID)
+	wb.writeString(t.MemberID)
+	wb.writeNullableString(t.GroupInstanceID)
+	wb.writeArray(len(t.GroupAssignments), func(i int) { t.GroupAssignments[i].writeTo(wb) })
+}
+
+// syncGroupResponseV3 has the same layout than syncGroupResponseV0, preceded
+// by the throttle time.
+type syncGroupResponseV3 struct {
+	ThrottleTimeMS    int32
+	ErrorCode         int16
+	MemberAssignments []byte
+}
+
+func (t syncGroupResponseV3) size() int32 {
+	return sizeofInt32(t.ThrottleTimeMS) +
+		sizeofInt16(t.ErrorCode) +
+		sizeofBytes(t.MemberAssignments)
+}
+
+func (t syncGroupResponseV3) writeTo(wb *writeBuffer) {
+	wb.writeInt32(t.ThrottleTimeMS)
+	wb.writeInt16(t.ErrorCode)
+	wb.writeBytes(t.MemberAssignments)
+}
+
+func (t *syncGroupResponseV3) readFrom(r *bufio.Reader, sz int) (remain int, err error) {
+	if remain, err = readInt32(r, sz, &t.ThrottleTimeMS); err != nil {
+		return
+	}
+	if remain, err = readInt16(r, remain, &t.ErrorCode); err != nil {
+		return
+	}
+	if remain, err = readBytes(r, remain, &t.MemberAssignments); err != nil {
+		return
+	}
+	return
+}
@@ -108,3 +108,77 @@ func BenchmarkSyncGroupResponseV0(t *testing.B) {
 		}
 	}
 }
+
+func TestSyncGroupResponseV3(t *testing.T) {
+	item := syncGroupResponseV3{
+		ThrottleTimeMS:    1,
+		ErrorCode:         2,
+		MemberAssignments: []byte("blah"),
+	}
+
+	b := bytes.NewBuffer(nil)
+	w := &writeBuffer{w: b}
+	item.writeTo(w)
+
+	if int32(b.Len()) != item.size() {
+		t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+	}
+
+	var found syncGroupResponseV3
+	remain, err := (&found).readFrom(bufio.NewReader(b), b.Len())
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if remain != 0 {
+		t.Errorf("expected 0 remain, got %v", remain)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Error("expected item and found to be the same")
+		t.FailNow()
+	}
+}
+
+func TestSyncGroupRequestV3Size(t *testing.T) {
+	instanceID := "c"
+
+	for _, item := range []syncGroupRequestV3{
+		{GroupID: "a"},
+		{
+			GroupID:          "a",
+			GenerationID:     1,
+			MemberID:         "b",
+			GroupInstanceID:  &instanceID,
+			GroupAssignments: []syncGroupRequestGroupAssignmentV0{{MemberID: "b", MemberAssignments: []byte("d")}},
+		},
+	} {
+		b := bytes.NewBuffer(nil)
+		w := &writeBuffer{w: b}
+		item.writeTo(w)
+
+		if int32(b.Len()) != item.size() {
+			t.Errorf("expected %d bytes, got %d", item.size(), b.Len())
+		}
+	}
+}
+
+func TestGroupProtocolAssignment(t *testing.T) {
+	item := GroupProtocolAssignment{
+		Version:  1,
+		Topics:   map[string][]int{"a": {0, 2}, "b": {1}},
+		UserData: []byte("c"),
+	}
+
+	found, err := DecodeGroupProtocolAssignment(item.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(item, found) {
+		t.Errorf("expected %+v, got %+v", item, found)
+	}
+
+	if found, err := DecodeGroupProtocolAssignment(nil); err != nil || !reflect.DeepEqual(found, GroupProtocolAssignment{}) {
+		t.Errorf("expected an empty assignment, got %+v (%v)", found, err)
+	}
+}
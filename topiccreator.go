@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// topicCreator creates the topics that a Writer writes to when they do not
+// exist, see WriterConfig.TopicAutoCreate. The creation of a topic is shared by
+// all the messages which failed because the topic did not exist, so the topic
+// is created once however many goroutines are writing to it.
+type topicCreator struct {
+	client  *Client
+	config  TopicConfig
+	timeout time.Duration
+
+	mutex     sync.Mutex
+	creations map[string]*topicCreation
+}
+
+// topicCreation is the creation of a topic, err is set when done is closed.
+type topicCreation struct {
+	done chan struct{}
+	err  error
+}
+
+// create returns the creation of topic which is in progress, or starts a new
+// one. Once the topic is created and ready, created is called before done is
+// closed.
+func (c *topicCreator) create(topic string, created func(topic string)) *topicCreation {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if creation := c.creations[topic]; creation != nil {
+		return creation
+	}
+
+	if c.creations == nil {
+		c.creations = make(map[string]*topicCreation)
+	}
+	creation := &topicCreation{done: make(chan struct{})}
+	c.creations[topic] = creation
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+
+		if creation.err = c.createTopic(ctx, topic); creation.err == nil {
+			created(topic)
+		}
+
+		// The creation is forgotten once it completed, the topic is created
+		// again if it is deleted later on.
+		c.mutex.Lock()
+		delete(c.creations, topic)
+		c.mutex.Unlock()
+		close(creation.done)
+	}()
+
+	return creation
+}
+
+// createTopic creates topic with the configuration of the creator, and waits
+// for it to be ready. The topic may have been created concurrently by another
+// program, which is not an error.
+func (c *topicCreator) createTopic(ctx context.Context, topic string) error {
+	config := c.config
+	config.Topic = topic
+
+	res, err := c.client.CreateTopics(ctx, CreateTopicsRequest{
+		Topics: []TopicConfig{config},
+	})
+	if err != nil {
+		return &TopicCreationError{Topic: topic, Err: err}
+	}
+
+	for _, t := range res.Topics {
+		if t.Error != nil && t.Error != TopicAlreadyExists {
+			return &TopicCreationError{Topic: topic, Err: t.Error, ErrorMessage: t.ErrorMessage}
+		}
+	}
+
+	if err := c.client.WaitForTopics(ctx, topic); err != nil {
+		return &TopicCreationError{Topic: topic, Err: err}
+	}
+	return nil
+}
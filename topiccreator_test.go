@@ -0,0 +1,73 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTopicCreator(t *testing.T) {
+	c := &topicCreator{
+		client:  NewClientWith(ClientConfig{Brokers: []string{"localhost:1"}}),
+		config:  TopicConfig{NumPartitions: 1, ReplicationFactor: 1},
+		timeout: 100 * time.Millisecond,
+	}
+
+	created := make(chan string, 2)
+	creation := c.create("a", func(topic string) { created <- topic })
+	if c.create("a", func(topic string) { created <- topic }) != creation {
+		t.Error("the concurrent creations of a topic were not shared")
+	}
+	if c.create("b", func(topic string) { created <- topic }) == creation {
+		t.Error("the creations of different topics were shared")
+	}
+
+	<-creation.done
+
+	e, ok := creation.err.(*TopicCreationError)
+	if !ok || e.Topic != "a" {
+		t.Fatalf("expected the creation of topic a to fail with a TopicCreationError, got %v", creation.err)
+	}
+	select {
+	case topic := <-created:
+		t.Errorf("topic %s was reported as created", topic)
+	default:
+	}
+	if c.create("a", func(string) {}) == creation {
+		t.Error("a completed creation was reused")
+	}
+}
+
+func testWriterTopicAutoCreate(t *testing.T) {
+	topic := makeTopic()
+
+	w := newTestWriter(WriterConfig{
+		Topic: topic,
+		TopicAutoCreate: &TopicConfig{
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
+	})
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := w.WriteMessages(ctx, makeTestSequence(4)...); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := DialLeader(ctx, "tcp", "localhost:9092", topic, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partitions) != 2 {
+		t.Errorf("expected the topic to be created with 2 partitions, got %d", len(partitions))
+	}
+}
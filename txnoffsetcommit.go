@@ -0,0 +1,154 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// TxnOffsetCommitRequest represents a request sent to a kafka cluster to
+// commit offsets of a consumer group as part of a transaction.
+type TxnOffsetCommitRequest struct {
+	// TransactionalID, ProducerID, and ProducerEpoch identify the
+	// transactional producer, as returned by InitProducerID.
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int
+
+	// GroupID is the ID of the group to commit the offsets of, it must have
+	// been added to the transaction with AddOffsetsToTxn.
+	GroupID string
+
+	// Topics holds the offsets to commit, indexed by topic name.
+	Topics map[string][]OffsetCommit
+}
+
+// TxnOffsetCommitResponse represents the response to a TxnOffsetCommitRequest.
+type TxnOffsetCommitResponse struct {
+	// Throttle is the duration for which the request was throttled by the
+	// coordinator.
+	Throttle time.Duration
+
+	// Topics holds the result of committing the offset of each partition,
+	// indexed by topic name.
+	Topics map[string][]OffsetCommitPartition
+}
+
+// TxnOffsetCommit commits offsets of a consumer group as part of a
+// transaction, the offsets become visible when the transaction is committed.
+// Unlike the other transactional requests, it is sent to the coordinator of
+// the group rather than the one of the transactional id. The API was
+// introduced in kafka 0.11.
+//
+// Errors that apply to a single partition are reported on the partition and
+// do not cause the method to fail.
+func (c *Client) TxnOffsetCommit(ctx context.Context, req TxnOffsetCommitRequest) (*TxnOffsetCommitResponse, error) {
+	request := txnOffsetCommitRequestV0{
+		TransactionalID: req.TransactionalID,
+		GroupID:         req.GroupID,
+		ProducerID:      req.ProducerID,
+		ProducerEpoch:   int16(req.ProducerEpoch),
+		Topics:          make([]offsetCommitRequestV2Topic, 0, len(req.Topics)),
+	}
+
+	for topic, commits := range req.Topics {
+		t := offsetCommitRequestV2Topic{
+			Topic:      topic,
+			Partitions: make([]offsetCommitRequestV2Partition, len(commits)),
+		}
+		for i, commit := range commits {
+			t.Partitions[i] = offsetCommitRequestV2Partition{
+				Partition: int32(commit.Partition),
+				Offset:    commit.Offset,
+				Metadata:  commit.Metadata,
+			}
+		}
+		request.Topics = append(request.Topics, t)
+	}
+
+	sort.Slice(request.Topics, func(i, j int) bool {
+		return request.Topics[i].Topic < request.Topics[j].Topic
+	})
+
+	conn, err := c.groupCoordinator(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := conn.txnOffsetCommit(request)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &TxnOffsetCommitResponse{
+		Throttle: duration(response.ThrottleTimeMS),
+		Topics:   make(map[string][]OffsetCommitPartition, len(response.Topics)),
+	}
+
+	for _, t := range response.Topics {
+		partitions := make([]OffsetCommitPartition, len(t.PartitionResponses))
+		for i, p := range t.PartitionResponses {
+			partitions[i] = OffsetCommitPartition{Partition: int(p.Partition)}
+			if p.ErrorCode != 0 {
+				partitions[i].Error = Error(p.ErrorCode)
+			}
+		}
+		res.Topics[t.Topic] = partitions
+	}
+
+	return res, nil
+}
+
+// txnOffsetCommit commits offsets of a group as part of a transaction, the
+// broker must be the coordinator of the group. The response has the same
+// layout than the response to OffsetCommit v5.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_TxnOffsetCommit
+func (c *Conn) txnOffsetCommit(request txnOffsetCommitRequestV0) (offsetCommitResponseV5, error) {
+	var response offsetCommitResponseV5
+
+	err := c.writeOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(txnOffsetCommit, v0, id, request)
+		},
+		func(deadline time.Time, size int) error {
+			return expectZeroSize(func() (remain int, err error) {
+				return (&response).readFrom(&c.rbuf, size)
+			}())
+		},
+	)
+	if err != nil {
+		return offsetCommitResponseV5{}, err
+	}
+
+	return response, nil
+}
+
+// See http://kafka.apache.org/protocol.html#The_Messages_TxnOffsetCommit
+type txnOffsetCommitRequestV0 struct {
+	TransactionalID string
+	GroupID         string
+	ProducerID      int64
+	ProducerEpoch   int16
+
+	// Topics have the same layout than in OffsetCommit v2, the metadata is a
+	// nullable string but is never sent as null.
+	Topics []offsetCommitRequestV2Topic
+}
+
+func (t txnOffsetCommitRequestV0) size() int32 {
+	return sizeofString(t.TransactionalID) +
+		sizeofString(t.GroupID) +
+		sizeofInt64(t.ProducerID) +
+		sizeofInt16(t.ProducerEpoch) +
+		sizeofArray(len(t.Topics), func(i int) int32 { return t.Topics[i].size() })
+}
+
+func (t txnOffsetCommitRequestV0) writeTo(wb *writeBuffer) {
+	wb.writeString(t.TransactionalID)
+	wb.writeString(t.GroupID)
+	wb.writeInt64(t.ProducerID)
+	wb.writeInt16(t.ProducerEpoch)
+	wb.writeArray(len(t.Topics), func(i int) { t.Topics[i].writeTo(wb) })
+}
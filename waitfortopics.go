@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// waitForTopicsBackoffMin/Max bound the time WaitForTopics waits between
+	// two polls of the cluster metadata.
+	waitForTopicsBackoffMin = 20 * time.Millisecond
+	waitForTopicsBackoffMax = 1 * time.Second
+)
+
+// WaitForTopics waits until all partitions of the topics have a leader in the
+// metadata served by every broker of the cluster. Because topic creation is
+// asynchronous, it is useful right after creating topics, before producing or
+// consuming. The metadata is polled with exponential backoff and jitter.
+//
+// The method returns an error naming the topics and partitions that were not
+// ready if ctx is done before they all become ready.
+func (c *Client) WaitForTopics(ctx context.Context, topics ...string) error {
+	if len(topics) == 0 {
+		return nil
+	}
+
+	var notReady []string
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		if notReady, err = c.unreadyPartitions(ctx, topics); err == nil && len(notReady) == 0 {
+			return nil
+		}
+
+		if !sleep(ctx, jitterBackoff(attempt, waitForTopicsBackoffMin, waitForTopicsBackoffMax)) {
+			break
+		}
+	}
+
+	if len(notReady) == 0 {
+		return fmt.Errorf("waiting for topics %s: %v (last error: %v)", strings.Join(topics, ", "), ctx.Err(), err)
+	}
+	return fmt.Errorf("waiting for topics %s: %v (not ready: %s)", strings.Join(topics, ", "), ctx.Err(), strings.Join(notReady, ", "))
+}
+
+// unreadyPartitions returns descriptions of the topics and partitions which
+// are not ready in the metadata of at least one broker of the cluster.
+func (c *Client) unreadyPartitions(ctx context.Context, topics []string) ([]string, error) {
+	brokers, err := c.brokerList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	notReady := make(map[string]struct{})
+
+	for _, b := range brokers {
+		conn, err := c.dialBroker(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := conn.metadataV1(topics)
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range metadata.Topics {
+			switch {
+			case t.TopicErrorCode != 0:
+				notReady[fmt.Sprintf("topic %s (%s)", t.TopicName, Error(t.TopicErrorCode).Title())] = struct{}{}
+			case len(t.Partitions) == 0:
+				notReady[fmt.Sprintf("topic %s (no partitions)", t.TopicName)] = struct{}{}
+			}
+			for _, p := range t.Partitions {
+				switch {
+				case p.PartitionErrorCode != 0:
+					notReady[fmt.Sprintf("partition %d of topic %s (%s)", p.PartitionID, t.TopicName, Error(p.PartitionErrorCode).Title())] = struct{}{}
+				case p.Leader < 0:
+					notReady[fmt.Sprintf("partition %d of topic %s (no leader)", p.PartitionID, t.TopicName)] = struct{}{}
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(notReady))
+	for name := range notReady {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// metadataV1 returns the metadata of the topics, unlike ReadPartitions the
+// errors of topics and partitions are reported in the response and do not
+// cause the method to fail.
+//
+// See http://kafka.apache.org/protocol.html#The_Messages_Metadata
+func (c *Conn) metadataV1(topics []string) (metadataResponseV1, error) {
+	var response metadataResponseV1
+
+	err := c.readOperation(
+		func(deadline time.Time, id int32) error {
+			return c.writeRequest(metadata, v1, id, topicMetadataRequestV1(topics))
+		},
+		func(deadline time.Time, size int) error {
+			return c.readResponse(size, &response)
+		},
+	)
+	if err != nil {
+		return metadataResponseV1{}, err
+	}
+
+	return response, nil
+}
+
+// jitterBackoff returns a random duration between half and all of the
+// exponential backoff of the attempt, capped at max.
+func jitterBackoff(attempt int, min time.Duration, max time.Duration) time.Duration {
+	d := max
+	if attempt < 32 {
+		if b := min << uint(attempt); b > 0 && b < max {
+			d = b
+		}
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
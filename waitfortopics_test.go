@@ -0,0 +1,44 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestJitterBackoff(t *testing.T) {
+	const min, max = 10 * time.Millisecond, time.Second
+
+	for attempt, expected := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			if d := jitterBackoff(attempt, min, max); d < expected/2 || d > expected {
+				t.Fatalf("expected attempt %d to back off between %s and %s, got %s", attempt, expected/2, expected, d)
+			}
+		}
+	}
+
+	for _, attempt := range []int{10, 40, 100} {
+		if d := jitterBackoff(attempt, min, max); d < max/2 || d > max {
+			t.Errorf("expected attempt %d to back off between %s and %s, got %s", attempt, max/2, max, d)
+		}
+	}
+}
+
+func testClientWaitForTopics(t *testing.T, ctx context.Context, c *Client) {
+	topics := []string{makeTopic(), makeTopic()}
+	for _, topic := range topics {
+		createTopic(t, topic, 3)
+	}
+
+	if err := c.WaitForTopics(ctx, topics...); err != nil {
+		t.Fatal(err)
+	}
+
+	leaders, err := c.partitionLeaders(ctx, topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaders) != 6 {
+		t.Errorf("expected 6 partitions with a leader, got %d", len(leaders))
+	}
+}
@@ -0,0 +1,373 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// defaultWatchMetadataInterval is the interval at which WatchMetadata polls
+// the cluster metadata when none is given.
+const defaultWatchMetadataInterval = 10 * time.Second
+
+// MetadataDiff carries the changes observed between two polls of the cluster
+// metadata by Client.WatchMetadata.
+type MetadataDiff struct {
+	// Time is when the metadata was polled.
+	Time time.Time
+
+	// Changes holds the changes since the previous poll. Broker changes come
+	// first, followed by the change of controller and the changes of
+	// partitions ordered by topic and partition.
+	Changes []MetadataChange
+
+	// Err is set to a non-nil value if polling the metadata failed, Changes
+	// is empty in that case and the next poll is compared to the last one
+	// that succeeded.
+	Err error
+}
+
+// MetadataChange is the interface implemented by the types describing a
+// change of the cluster metadata, which are ControllerChanged, BrokerAdded,
+// BrokerRemoved, PartitionAdded, PartitionRemoved, LeaderChanged, IsrShrank,
+// and IsrExpanded.
+type MetadataChange interface {
+	metadataChange()
+}
+
+// ControllerChanged is the change of the broker acting as controller of the
+// cluster.
+type ControllerChanged struct {
+	Previous   Broker
+	Controller Broker
+}
+
+// BrokerAdded is a broker joining the cluster.
+type BrokerAdded struct {
+	Broker Broker
+}
+
+// BrokerRemoved is a broker leaving the cluster.
+type BrokerRemoved struct {
+	Broker Broker
+}
+
+// PartitionAdded is a partition appearing in the metadata, either because it
+// was added to a topic or because its topic was created.
+type PartitionAdded struct {
+	Partition Partition
+}
+
+// PartitionRemoved is a partition disappearing from the metadata, usually
+// because its topic was deleted.
+type PartitionRemoved struct {
+	Topic     string
+	Partition int
+}
+
+// LeaderChanged is the change of the leader of a partition. The leader is the
+// zero value of Broker while the partition has no leader.
+type LeaderChanged struct {
+	Topic     string
+	Partition int
+	Previous  Broker
+	Leader    Broker
+}
+
+// IsrShrank is the removal of replicas from the in-sync replicas of a
+// partition.
+type IsrShrank struct {
+	Topic     string
+	Partition int
+
+	// Removed holds the replicas that fell out of sync, and Isr the in-sync
+	// replicas after the change.
+	Removed []Broker
+	Isr     []Broker
+}
+
+// IsrExpanded is the addition of replicas to the in-sync replicas of a
+// partition.
+type IsrExpanded struct {
+	Topic     string
+	Partition int
+
+	// Added holds the replicas that caught up, and Isr the in-sync replicas
+	// after the change.
+	Added []Broker
+	Isr   []Broker
+}
+
+func (ControllerChanged) metadataChange() {}
+func (BrokerAdded) metadataChange()       {}
+func (BrokerRemoved) metadataChange()     {}
+func (PartitionAdded) metadataChange()    {}
+func (PartitionRemoved) metadataChange()  {}
+func (LeaderChanged) metadataChange()     {}
+func (IsrShrank) metadataChange()         {}
+func (IsrExpanded) metadataChange()       {}
+
+// WatchMetadata polls the metadata of the cluster every interval, which
+// defaults to 10 seconds, and delivers the changes of brokers, controller, and
+// partitions on the returned channel. Only the partitions of the topics are
+// watched, or the partitions of all the topics of the cluster when topics is
+// empty.
+//
+// The method returns an error if the first poll of the metadata fails,
+// otherwise polls that observe no change are not delivered, and polls that
+// fail are delivered with their error. The channel is closed when ctx is
+// done. Like WaitForTopics, polling the metadata of topics that do not exist
+// creates them on brokers configured with auto.create.topics.enable.
+func (c *Client) WatchMetadata(ctx context.Context, topics []string, interval time.Duration) (<-chan MetadataDiff, error) {
+	if interval <= 0 {
+		interval = defaultWatchMetadataInterval
+	}
+	if len(topics) == 0 {
+		topics = nil
+	}
+
+	snapshot, err := c.metadataSnapshot(ctx, topics, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make(chan MetadataDiff)
+
+	go func() {
+		defer close(diffs)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			diff := MetadataDiff{Time: time.Now()}
+
+			next, err := c.metadataSnapshot(ctx, topics, snapshot)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				diff.Err = err
+			} else {
+				diff.Changes = diffMetadata(snapshot, next)
+				snapshot = next
+			}
+
+			if diff.Err == nil && len(diff.Changes) == 0 {
+				continue
+			}
+
+			select {
+			case diffs <- diff:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return diffs, nil
+}
+
+// metadataSnapshot is the state of the cluster metadata that WatchMetadata
+// compares between polls.
+type metadataSnapshot struct {
+	controller int32
+	brokers    map[int32]Broker
+	partitions map[topicPartition]partitionMetadataV1
+}
+
+// metadataSnapshot polls the metadata of the topics. The partitions of topics
+// that could not be retrieved for another reason than not existing are
+// carried over from prev.
+func (c *Client) metadataSnapshot(ctx context.Context, topics []string, prev *metadataSnapshot) (*metadataSnapshot, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	metadata, err := conn.metadataV1(topics)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &metadataSnapshot{
+		controller: metadata.ControllerID,
+		brokers:    make(map[int32]Broker, len(metadata.Brokers)),
+		partitions: make(map[topicPartition]partitionMetadataV1),
+	}
+
+	for _, b := range metadata.Brokers {
+		s.brokers[b.NodeID] = Broker{
+			Host: b.Host,
+			Port: int(b.Port),
+			ID:   int(b.NodeID),
+			Rack: b.Rack,
+		}
+	}
+
+	for _, t := range metadata.Topics {
+		if t.TopicErrorCode != 0 {
+			if Error(t.TopicErrorCode) != UnknownTopicOrPartition && prev != nil {
+				for key, p := range prev.partitions {
+					if key.topic == t.TopicName {
+						s.partitions[key] = p
+					}
+				}
+			}
+			continue
+		}
+		for _, p := range t.Partitions {
+			s.partitions[topicPartition{topic: t.TopicName, partition: int(p.PartitionID)}] = p
+		}
+	}
+
+	return s, nil
+}
+
+// broker returns the broker of the given ID, which only carries the ID if the
+// broker is not part of the snapshot, or the zero value of Broker if the ID is
+// negative.
+func (s *metadataSnapshot) broker(id int32) Broker {
+	if id < 0 {
+		return Broker{}
+	}
+	if b, ok := s.brokers[id]; ok {
+		return b
+	}
+	return Broker{ID: int(id)}
+}
+
+func (s *metadataSnapshot) brokerList(ids []int32) []Broker {
+	brokers := make([]Broker, len(ids))
+	for i, id := range ids {
+		brokers[i] = s.broker(id)
+	}
+	return brokers
+}
+
+// diffMetadata returns the changes between two snapshots of the metadata.
+func diffMetadata(prev, next *metadataSnapshot) []MetadataChange {
+	changes := []MetadataChange{}
+
+	for _, id := range sortedBrokerIDs(next.brokers) {
+		if _, ok := prev.brokers[id]; !ok {
+			changes = append(changes, BrokerAdded{Broker: next.brokers[id]})
+		}
+	}
+
+	for _, id := range sortedBrokerIDs(prev.brokers) {
+		if _, ok := next.brokers[id]; !ok {
+			changes = append(changes, BrokerRemoved{Broker: prev.brokers[id]})
+		}
+	}
+
+	if prev.controller != next.controller {
+		changes = append(changes, ControllerChanged{
+			Previous:   prev.broker(prev.controller),
+			Controller: next.broker(next.controller),
+		})
+	}
+
+	keys := make([]topicPartition, 0, len(next.partitions))
+	for key := range next.partitions {
+		keys = append(keys, key)
+	}
+	for key := range prev.partitions {
+		if _, ok := next.partitions[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].topic != keys[j].topic {
+			return keys[i].topic < keys[j].topic
+		}
+		return keys[i].partition < keys[j].partition
+	})
+
+	for _, key := range keys {
+		p, inPrev := prev.partitions[key]
+		n, inNext := next.partitions[key]
+
+		switch {
+		case !inPrev:
+			changes = append(changes, PartitionAdded{
+				Partition: Partition{
+					Topic:    key.topic,
+					Leader:   next.broker(n.Leader),
+					Replicas: next.brokerList(n.Replicas),
+					Isr:      next.brokerList(n.Isr),
+					ID:       key.partition,
+				},
+			})
+			continue
+		case !inNext:
+			changes = append(changes, PartitionRemoved{Topic: key.topic, Partition: key.partition})
+			continue
+		}
+
+		if p.Leader != n.Leader {
+			changes = append(changes, LeaderChanged{
+				Topic:     key.topic,
+				Partition: key.partition,
+				Previous:  prev.broker(p.Leader),
+				Leader:    next.broker(n.Leader),
+			})
+		}
+
+		if removed := int32Difference(p.Isr, n.Isr); len(removed) != 0 {
+			changes = append(changes, IsrShrank{
+				Topic:     key.topic,
+				Partition: key.partition,
+				Removed:   prev.brokerList(removed),
+				Isr:       next.brokerList(n.Isr),
+			})
+		}
+
+		if added := int32Difference(n.Isr, p.Isr); len(added) != 0 {
+			changes = append(changes, IsrExpanded{
+				Topic:     key.topic,
+				Partition: key.partition,
+				Added:     next.brokerList(added),
+				Isr:       next.brokerList(n.Isr),
+			})
+		}
+	}
+
+	return changes
+}
+
+func sortedBrokerIDs(brokers map[int32]Broker) []int32 {
+	ids := make([]int32, 0, len(brokers))
+	for id := range brokers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// int32Difference returns the values of a which are not in b, in the order of
+// a.
+func int32Difference(a, b []int32) []int32 {
+	var diff []int32
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if x == y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, x)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,118 @@
+package kafka
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffMetadata(t *testing.T) {
+	b1 := Broker{Host: "b1", Port: 9092, ID: 1}
+	b2 := Broker{Host: "b2", Port: 9092, ID: 2}
+	b3 := Broker{Host: "b3", Port: 9092, ID: 3}
+
+	prev := &metadataSnapshot{
+		controller: 1,
+		brokers:    map[int32]Broker{1: b1, 2: b2},
+		partitions: map[topicPartition]partitionMetadataV1{
+			{topic: "a", partition: 0}: {PartitionID: 0, Leader: 1, Replicas: []int32{1, 2}, Isr: []int32{1, 2}},
+			{topic: "a", partition: 1}: {PartitionID: 1, Leader: 2, Replicas: []int32{2, 1}, Isr: []int32{2, 1}},
+			{topic: "b", partition: 0}: {PartitionID: 0, Leader: 1, Replicas: []int32{1}, Isr: []int32{1}},
+		},
+	}
+
+	next := &metadataSnapshot{
+		controller: 3,
+		brokers:    map[int32]Broker{1: b1, 3: b3},
+		partitions: map[topicPartition]partitionMetadataV1{
+			{topic: "a", partition: 0}: {PartitionID: 0, Leader: 1, Replicas: []int32{1, 2}, Isr: []int32{1}},
+			{topic: "a", partition: 1}: {PartitionID: 1, Leader: 1, Replicas: []int32{2, 1}, Isr: []int32{1}},
+			{topic: "a", partition: 2}: {PartitionID: 2, Leader: -1, Replicas: []int32{3}, Isr: []int32{}},
+			{topic: "c", partition: 0}: {PartitionID: 0, Leader: 3, Replicas: []int32{3, 1}, Isr: []int32{3, 1}},
+		},
+	}
+
+	changes := diffMetadata(prev, next)
+
+	expected := []MetadataChange{
+		BrokerAdded{Broker: b3},
+		BrokerRemoved{Broker: b2},
+		ControllerChanged{Previous: b1, Controller: b3},
+		IsrShrank{Topic: "a", Partition: 0, Removed: []Broker{b2}, Isr: []Broker{b1}},
+		LeaderChanged{Topic: "a", Partition: 1, Previous: b2, Leader: b1},
+		IsrShrank{Topic: "a", Partition: 1, Removed: []Broker{b2}, Isr: []Broker{b1}},
+		PartitionAdded{Partition: Partition{Topic: "a", Leader: Broker{}, Replicas: []Broker{b3}, Isr: []Broker{}, ID: 2}},
+		PartitionRemoved{Topic: "b", Partition: 0},
+		PartitionAdded{Partition: Partition{Topic: "c", Leader: b3, Replicas: []Broker{b3, b1}, Isr: []Broker{b3, b1}, ID: 0}},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("metadata changes mismatch:\nexpected: %+v\nfound:    %+v", expected, changes)
+	}
+
+	if changes := diffMetadata(next, next); len(changes) != 0 {
+		t.Errorf("expected no changes between identical snapshots, got %+v", changes)
+	}
+
+	back := diffMetadata(next, prev)
+	found := false
+	for _, change := range back {
+		if e, ok := change.(IsrExpanded); ok && e.Topic == "a" && e.Partition == 0 {
+			found = reflect.DeepEqual(e.Added, []Broker{b2}) && reflect.DeepEqual(e.Isr, []Broker{b1, b2})
+		}
+	}
+	if !found {
+		t.Errorf("expected the in-sync replicas of partition 0 of topic a to expand, got %+v", back)
+	}
+}
+
+func testClientWatchMetadata(t *testing.T, ctx context.Context, c *Client) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	if err := c.WaitForTopics(ctx, topic); err != nil {
+		t.Fatal(err)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	diffs, err := c.WatchMetadata(watchCtx, []string{topic}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.CreatePartitions(ctx, CreatePartitionsRequest{
+		Topics: []TopicPartitionsConfig{{Topic: topic, Count: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Topics[0].Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for added := false; !added; {
+		select {
+		case diff, ok := <-diffs:
+			if !ok {
+				t.Fatal("the channel was closed before the partition was added")
+			}
+			if diff.Err != nil {
+				t.Log(diff.Err)
+			}
+			for _, change := range diff.Changes {
+				if p, ok := change.(PartitionAdded); ok && p.Partition.Topic == topic && p.Partition.ID == 1 {
+					added = true
+				}
+			}
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	cancel()
+	for range diffs {
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"hash/crc32"
 	"io"
+	"math"
 	"time"
 )
 
@@ -34,6 +35,14 @@ func (wb *writeBuffer) writeInt64(i int64) {
 	wb.Write(wb.b[:8])
 }
 
+func (wb *writeBuffer) writeUUID(u [16]byte) {
+	wb.Write(u[:])
+}
+
+func (wb *writeBuffer) writeFloat64(f float64) {
+	wb.writeInt64(int64(math.Float64bits(f)))
+}
+
 func (wb *writeBuffer) writeVarInt(i int64) {
 	u := uint64((i << 1) ^ (i >> 63))
 	n := 0
@@ -123,6 +132,74 @@ func (wb *writeBuffer) writeInt32Array(a []int32) {
 	wb.writeArray(len(a), func(i int) { wb.writeInt32(a[i]) })
 }
 
+func (wb *writeBuffer) writeUnsignedVarInt(u uint64) {
+	n := 0
+
+	for u >= 0x80 && n < len(wb.b) {
+		wb.b[n] = byte(u) | 0x80
+		u >>= 7
+		n++
+	}
+
+	if n < len(wb.b) {
+		wb.b[n] = byte(u)
+		n++
+	}
+
+	wb.Write(wb.b[:n])
+}
+
+// The compact encodings are used by the flexible versions of the kafka
+// protocol (KIP-482), lengths are written as unsigned varints holding the
+// length plus one, zero designates null values.
+
+func (wb *writeBuffer) writeCompactString(s string) {
+	wb.writeUnsignedVarInt(uint64(len(s)) + 1)
+	wb.WriteString(s)
+}
+
+func (wb *writeBuffer) writeCompactNullableString(s *string) {
+	if s == nil {
+		wb.writeUnsignedVarInt(0)
+	} else {
+		wb.writeCompactString(*s)
+	}
+}
+
+func (wb *writeBuffer) writeCompactBytes(b []byte) {
+	if b == nil {
+		wb.writeUnsignedVarInt(0)
+	} else {
+		wb.writeUnsignedVarInt(uint64(len(b)) + 1)
+		wb.Write(b)
+	}
+}
+
+func (wb *writeBuffer) writeCompactArrayLen(n int) {
+	wb.writeUnsignedVarInt(uint64(n + 1))
+}
+
+func (wb *writeBuffer) writeCompactArray(n int, f func(int)) {
+	wb.writeCompactArrayLen(n)
+	for i := 0; i < n; i++ {
+		f(i)
+	}
+}
+
+func (wb *writeBuffer) writeCompactStringArray(a []string) {
+	wb.writeCompactArray(len(a), func(i int) { wb.writeCompactString(a[i]) })
+}
+
+func (wb *writeBuffer) writeCompactInt32Array(a []int32) {
+	wb.writeCompactArray(len(a), func(i int) { wb.writeInt32(a[i]) })
+}
+
+// writeTaggedFields writes an empty set of tagged fields, which terminates
+// structures of the flexible versions of the kafka protocol.
+func (wb *writeBuffer) writeTaggedFields() {
+	wb.writeUnsignedVarInt(0)
+}
+
 func (wb *writeBuffer) write(a interface{}) {
 	switch v := a.(type) {
 	case int8:
@@ -243,10 +320,12 @@ func (wb *writeBuffer) writeFetchRequestV5(correlationID int32, clientID, topic
 	return wb.Flush()
 }
 
-func (wb *writeBuffer) writeFetchRequestV10(correlationID int32, clientID, topic string, partition int32, offset int64, minBytes, maxBytes int, maxWait time.Duration, isolationLevel int8) error {
+// writeFetchRequestV10 writes fetch requests of version 10 and 11, the rack ID
+// is only part of the request from version 11.
+func (wb *writeBuffer) writeFetchRequestV10(correlationID int32, clientID, topic string, partition int32, offset int64, minBytes, maxBytes int, maxWait time.Duration, isolationLevel int8, version apiVersion, rackID string) error {
 	h := requestHeader{
 		ApiKey:        int16(fetch),
-		ApiVersion:    int16(v10),
+		ApiVersion:    int16(version),
 		CorrelationID: correlationID,
 		ClientID:      clientID,
 	}
@@ -268,6 +347,10 @@ func (wb *writeBuffer) writeFetchRequestV10(correlationID int32, clientID, topic
 		4 + // partition max bytes
 		4 // forgotten topics data
 
+	if version >= v11 {
+		h.Size += sizeofString(rackID)
+	}
+
 	h.writeTo(wb)
 	wb.writeInt32(-1) // replica ID
 	wb.writeInt32(milliseconds(maxWait))
@@ -292,6 +375,10 @@ func (wb *writeBuffer) writeFetchRequestV10(correlationID int32, clientID, topic
 	// forgotten topics array
 	wb.writeArrayLen(0) // forgotten topics not supported yet
 
+	if version >= v11 {
+		wb.writeString(rackID)
+	}
+
 	return wb.Flush()
 }
 
@@ -454,14 +541,11 @@ func (wb *writeBuffer) writeProduceRequestV7(correlationID int32, clientID, topi
 	return wb.Flush()
 }
 
-func (wb *writeBuffer) writeRecordBatch(attributes int16, size int32, count int, baseTime, lastTime time.Time, write func(*writeBuffer)) {
+func (wb *writeBuffer) writeRecordBatch(attributes int16, size int32, count int, baseTime, lastTime time.Time, producerID int64, producerEpoch int16, baseSequence int32, write func(*writeBuffer)) {
 	var (
 		baseTimestamp   = timestamp(baseTime)
 		lastTimestamp   = timestamp(lastTime)
 		lastOffsetDelta = int32(count - 1)
-		producerID      = int64(-1)    // default producer id for now
-		producerEpoch   = int16(-1)    // default producer epoch for now
-		baseSequence    = int32(-1)    // default base sequence
 		recordCount     = int32(count) // record count
 		writerBackup    = wb.w
 	)
@@ -469,7 +553,7 @@ func (wb *writeBuffer) writeRecordBatch(attributes int16, size int32, count int,
 	// dry run to compute the checksum
 	cw := &crc32Writer{table: crc32.MakeTable(crc32.Castagnoli)}
 	wb.w = cw
-	cw.writeInt16(attributes) // attributes, timestamp type 0 - create time, not part of a transaction, no control messages
+	cw.writeInt16(attributes) // attributes, timestamp type 0 - create time, no control messages
 	cw.writeInt32(lastOffsetDelta)
 	cw.writeInt64(baseTimestamp)
 	cw.writeInt64(lastTimestamp)
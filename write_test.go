@@ -49,6 +49,8 @@ func TestWriteVarInt(t *testing.T) {
 func TestWriteOptimizations(t *testing.T) {
 	t.Parallel()
 	t.Run("writeFetchRequestV2", testWriteFetchRequestV2)
+	t.Run("writeFetchRequestV10", testWriteFetchRequestV10)
+	t.Run("writeFetchRequestV11", testWriteFetchRequestV11)
 	t.Run("writeListOffsetRequestV1", testWriteListOffsetRequestV1)
 	t.Run("writeProduceRequestV2", testWriteProduceRequestV2)
 }
@@ -84,6 +86,32 @@ func testWriteFetchRequestV2(t *testing.T) {
 	)
 }
 
+func testWriteFetchRequestV10(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := &writeBuffer{w: b}
+	w.writeFetchRequestV10(testCorrelationID, testClientID, testTopic, testPartition, 42, 10, 1000, 100*time.Millisecond, 1, v10, "rack")
+
+	if size := makeInt32(b.Bytes()[:4]); int(size)+4 != b.Len() {
+		t.Errorf("expected a request of %d bytes, got %d", size+4, b.Len())
+	}
+	if bytes.Contains(b.Bytes(), []byte("rack")) {
+		t.Error("the rack ID was written to a v10 request")
+	}
+}
+
+func testWriteFetchRequestV11(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := &writeBuffer{w: b}
+	w.writeFetchRequestV10(testCorrelationID, testClientID, testTopic, testPartition, 42, 10, 1000, 100*time.Millisecond, 1, v11, "rack")
+
+	if size := makeInt32(b.Bytes()[:4]); int(size)+4 != b.Len() {
+		t.Errorf("expected a request of %d bytes, got %d", size+4, b.Len())
+	}
+	if !bytes.HasSuffix(b.Bytes(), []byte("\x00\x04rack")) {
+		t.Error("the rack ID was not written at the end of the request")
+	}
+}
+
 func testWriteListOffsetRequestV1(t *testing.T) {
 	const time = -1
 	testWriteOptimization(t,
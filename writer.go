@@ -6,14 +6,17 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"net"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 )
 
 // The Writer type provides the implementation of a producer of kafka messages
-// that automatically distributes messages across partitions of a single topic
-// using a configurable balancing policy.
+// that automatically distributes messages across partitions of a topic using a
+// configurable balancing policy. The topic is either configured on the writer,
+// or set on each message when the writer has none.
 //
 // Instances of Writer are safe to use concurrently from multiple goroutines.
 type Writer struct {
@@ -29,6 +32,32 @@ type Writer struct {
 	// writer stats are all made of atomic values, no need for synchronization.
 	// Use a pointer to ensure 64-bit alignment of the values.
 	stats *writerStats
+
+	// completions delivers the results of batches to the Completion callback,
+	// it is nil when the writer has none.
+	completions *completionQueue
+
+	// inflight counts the messages passed to WriteMessages which were not
+	// written or failed yet.
+	inflight *inflightMessages
+
+	// producer is the producer state shared by the partition writers of an
+	// idempotent or transactional writer, it is nil otherwise.
+	producer *idempotentProducer
+
+	// txn is held for reading by the calls to WriteMessages on a transactional
+	// writer, and for writing by the calls that begin or end transactions, so
+	// the messages of concurrent writes are all part of the same transaction.
+	txn sync.RWMutex
+
+	// creator creates the topics which do not exist, it is nil unless the
+	// writer was configured with TopicAutoCreate.
+	creator *topicCreator
+
+	// refreshes receives the topics whose metadata must be refreshed because
+	// a batch failed on a stale leader, it is nil unless the writer was
+	// configured with MetadataRefreshOnError.
+	refreshes chan string
 }
 
 // WriterConfig is a configuration type used to create new instances of Writer.
@@ -42,8 +71,10 @@ type WriterConfig struct {
 
 	// The topic that the writer will produce messages to.
 	//
-	// This field is required, attempting to create a writer with an empty topic
-	// will panic.
+	// If empty, the messages are produced to the topic set on each of them in
+	// Message.Topic, and the batches of messages are formed and balanced per
+	// topic. WriteMessages fails if the topic is set on both the writer and a
+	// message, or on neither of them.
 	Topic string
 
 	// The dialer used by the writer to establish connections to the kafka
@@ -62,6 +93,20 @@ type WriterConfig struct {
 	// The default is to try at most 10 times.
 	MaxAttempts int
 
+	// The writer waits before retrying to write messages, for attempt² ×
+	// WriteBackoffMin on the nth retry, up to WriteBackoffMax. The messages
+	// which failed with an error that is not temporary, for example
+	// MessageSizeTooLarge or InvalidTopic, are not retried.
+	//
+	// The defaults are to wait at least 100ms and at most 1s.
+	WriteBackoffMin time.Duration
+	WriteBackoffMax time.Duration
+
+	// If not nil, Backoff returns the time to wait for before the given retry
+	// attempt, starting from 1 for the first retry, instead of computing it
+	// from WriteBackoffMin and WriteBackoffMax.
+	Backoff func(attempt int) time.Duration
+
 	// A hint on the capacity of the writer's internal message queue.
 	//
 	// The default is to use a queue capacity of 100 messages.
@@ -74,11 +119,29 @@ type WriterConfig struct {
 	BatchSize int
 
 	// Limit the maximum size of a request in bytes before being sent to
-	// a partition.
+	// a partition. The batch of a partition is sent as soon as it reaches
+	// BatchSize messages or BatchBytes bytes of uncompressed messages, or when
+	// BatchTimeout expires, whichever comes first. A message larger than
+	// BatchBytes is sent in a batch of its own.
 	//
 	// The default is to use a kafka default value of 1048576.
 	BatchBytes int
 
+	// Limit on the size of a single message in bytes, WriteMessages fails
+	// with a MessageTooLargeError when passed a larger message. The size of a
+	// message includes its headers and the overhead of the record batch that
+	// it is written in, which is what the brokers compare to their
+	// message.max.bytes setting.
+	//
+	// The batches of a partition are also kept under MaxMessageBytes, and a
+	// batch that the brokers reject as too large once compressed is split in
+	// two batches written separately instead of failing. Idempotent and
+	// transactional writers do not split batches, since their batches keep
+	// their sequence numbers across attempts.
+	//
+	// The default is to use the larger of 1048576 and BatchBytes.
+	MaxMessageBytes int
+
 	// Time limit on how often incomplete message batches will be flushed to
 	// kafka.
 	//
@@ -102,6 +165,23 @@ type WriterConfig struct {
 	// The default is to refresh partitions every 15 seconds.
 	RebalanceInterval time.Duration
 
+	// MetadataTTL is how long the writer uses the metadata of the topics that
+	// it writes to before refreshing it in the background. Besides the new
+	// partitions, the refreshes pick up the partitions whose leader moved to
+	// another broker, the writer reconnects to their new leader before
+	// writing the next batches instead of waiting for the old leader to
+	// reject them.
+	//
+	// The default is to use RebalanceInterval.
+	MetadataTTL time.Duration
+
+	// Setting this flag to true makes the writer refresh the metadata of a
+	// topic as soon as a batch failed because the leader of its partition
+	// moved, so the other partitions whose leader moved as well, for example
+	// when a broker is shut down, reconnect to their new leader without
+	// failing first.
+	MetadataRefreshOnError bool
+
 	// Connections that were idle for this duration will not be reused.
 	//
 	// Defaults to 9 minutes.
@@ -114,14 +194,47 @@ type WriterConfig struct {
 
 	// Setting this flag to true causes the WriteMessages method to never block.
 	// It also means that errors are ignored since the caller will not receive
-	// the returned value. Use this only if you don't care about guarantees of
-	// whether the messages were written to kafka.
+	// the returned value, unless the program sets Completion to be notified of
+	// the batches that failed. Use this only if you don't care about
+	// guarantees of whether the messages were written to kafka, or with
+	// Completion and Flush to control them.
 	Async bool
 
 	// CompressionCodec set the codec to be used to compress Kafka messages.
 	// Note that messages are allowed to overwrite the compression codec individually.
+	//
+	// The codecs are compressing at their default level, the codec packages
+	// have constructors for codecs with a different one, for example
+	// gzip.NewCompressionCodecLevel or zstd.NewCompressionCodecWith, and
+	// zstd.NewCompressionCodecWithDictionary returns a codec compressing with
+	// a dictionary.
 	CompressionCodec
 
+	// Setting this flag to true makes the writer produce messages as an
+	// idempotent producer, which guarantees that retrying a batch does not
+	// write duplicates of its messages. The writer obtains a producer id from
+	// the cluster and numbers the messages written to each partition, the
+	// broker then discards batches that it has already written.
+	//
+	// Idempotent writers require kafka 0.11 or above, and RequiredAcks to be
+	// left to its default of -1.
+	Idempotent bool
+
+	// Setting TransactionalID makes the writer a transactional producer, which
+	// writes messages within transactions delimited by the BeginTxn, CommitTxn,
+	// and AbortTxn methods. The messages of a transaction are atomically made
+	// visible to read_committed consumers when it is committed, or discarded
+	// when it is aborted. Transactional writers are idempotent, and fence the
+	// older writers which were created with the same transactional id.
+	//
+	// Transactional writers require kafka 0.11 or above.
+	TransactionalID string
+
+	// TransactionTimeout is the time the transaction coordinator waits for an
+	// open transaction to be committed or aborted before aborting it (default
+	// to 1 minute). It is only used by transactional writers.
+	TransactionTimeout time.Duration
+
 	// If not nil, specifies a logger used to report internal changes within the
 	// writer.
 	Logger Logger
@@ -130,7 +243,130 @@ type WriterConfig struct {
 	// back to using Logger instead.
 	ErrorLogger Logger
 
-	newPartitionWriter func(partition int, config WriterConfig, stats *writerStats) partitionWriter
+	// If not nil, the writer creates the topics that it writes to when they
+	// do not exist, with the number of partitions, replication factor, and
+	// configuration entries of TopicAutoCreate, its Topic field is ignored.
+	// The writer waits for the topics to be ready before writing the messages
+	// that failed because their topic did not exist, which are not retried if
+	// the topic cannot be created, for example because the program is not
+	// allowed to, they fail with a TopicCreationError.
+	//
+	// Unlike the auto.create.topics.enable setting of the brokers, this lets
+	// the program choose how topics are created. It requires kafka 2.4 or
+	// above, the creation of a topic gives up after WriteTimeout.
+	TopicAutoCreate *TopicConfig
+
+	// Setting this flag to true makes WriteMessages set the Partition and
+	// Offset of the messages passed to it to the partition and offset that
+	// they were written at once they were written, and their Time to their
+	// timestamp in kafka: the time that the broker appended them at when their
+	// topic uses the LogAppendTime timestamp type, or the time that they were
+	// written at if they had none. The Offset of the messages whose offset is
+	// unknown, for example the messages of a retried idempotent batch, is set
+	// to -1. The messages which failed to be written are left unchanged.
+	//
+	// The messages are not updated by asynchronous writers, or when the
+	// writer has Interceptors.
+	UpdateMessages bool
+
+	// Setting this flag to true makes the writer write the messages whose
+	// Partition is zero or more to that partition instead of the one chosen
+	// by the Balancer, the messages with a negative Partition are still
+	// balanced. A message written to a partition that its topic does not
+	// have fails with a PartitionNotFoundError, and is not retried.
+	//
+	// Since the zero value of Partition is a valid partition, the programs
+	// using this flag must set the Partition of the messages that the
+	// Balancer routes to -1.
+	ExplicitPartitions bool
+
+	// Setting this flag to true makes the writer collect statistics for each
+	// partition that it writes to, which are reported in the Partitions field
+	// of WriterStats.
+	PartitionStats bool
+
+	// If not nil, Completion is called with the messages of each batch once
+	// the batch was written, or failed to be written with err. The messages
+	// of a batch that was written carry the topic, partition, and offset
+	// that they were written at.
+	//
+	// Completion is called from a goroutine of its own, in the order in which
+	// the batches completed, so a slow callback does not delay the writes.
+	// All the calls are made by the time Close returns. When WriteMessages is
+	// synchronous, the messages of a failed batch may be retried and reported
+	// to Completion again, asynchronous writes are never retried.
+	Completion func(messages []Message, err error)
+
+	// Interceptors are called in order by WriteMessages, each with the
+	// messages returned by the previous one, before the messages are
+	// validated, balanced, and batched. They may add headers to the
+	// messages, rewrite their keys, or return a different list of messages.
+	// WriteMessages fails with an InterceptorError without writing any
+	// message if one of the interceptors returns an error. The indexes of the
+	// WriteErrors returned by WriteMessages refer to the list returned by the
+	// last interceptor.
+	//
+	// The first interceptor receives a copy of the slice passed to
+	// WriteMessages, but the keys, values, and headers of the messages still
+	// share their memory with the caller, interceptors must not modify them
+	// in place.
+	Interceptors []WriterInterceptor
+
+	// If not nil, Limiter limits the rate at which messages are written,
+	// WriteMessages waits on it once for each message before queuing it, and
+	// again when the message is retried. The limiters of the
+	// golang.org/x/time/rate package satisfy the interface, for example
+	// rate.NewLimiter(1000, 100) limits the writer to 1000 messages per second.
+	Limiter Limiter
+
+	// If not nil, BytesLimiter limits the rate at which bytes of messages are
+	// written, WriteMessages waits on it for the size of each message before
+	// queuing it. rate.Limiter satisfies the interface, its burst must not be
+	// lower than MaxMessageBytes or the larger messages can never be written.
+	BytesLimiter BytesLimiter
+
+	// If not nil, DeadLetter is called by WriteMessages with the messages
+	// which failed with an error that retrying would not fix, like
+	// MessageSizeTooLarge or InvalidRecord, instead of failing the call. A
+	// message which failed with other messages of its batch is first retried
+	// in a batch of its own, so a single invalid message does not send the
+	// rest of its batch to DeadLetter. The messages that DeadLetter returned
+	// nil for are reported as written, the others fail with the error that
+	// it returned.
+	//
+	// The messages which are larger than MaxMessageBytes are passed to
+	// DeadLetter with a MessageTooLargeError. Asynchronous writes report the
+	// failed batches to Completion instead.
+	DeadLetter DeadLetterHandler
+
+	newPartitionWriter func(topic string, partition int, config WriterConfig, stats *writerStats) partitionWriter
+}
+
+// WriterInterceptor is the signature of the functions called by a Writer on the
+// messages passed to WriteMessages, see WriterConfig.Interceptors.
+type WriterInterceptor func(ctx context.Context, msgs []Message) ([]Message, error)
+
+// DeadLetterHandler is the signature of the functions called by a Writer on the
+// messages which could not be written, see WriterConfig.DeadLetter. A handler
+// may for example write the message to another topic with a second Writer.
+type DeadLetterHandler func(ctx context.Context, msg Message, err error) error
+
+// Limiter is the interface of the message rate limiters of a Writer, see
+// WriterConfig.Limiter.
+//
+// Wait blocks until the limiter allows one more message to be written, or
+// returns an error if ctx is done first.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// BytesLimiter is the interface of the byte rate limiters of a Writer, see
+// WriterConfig.BytesLimiter.
+//
+// WaitN blocks until the limiter allows n more bytes to be written, or returns
+// an error if ctx is done first or n can never be allowed.
+type BytesLimiter interface {
+	WaitN(ctx context.Context, n int) error
 }
 
 // WriterStats is a data structure returned by a call to Writer.Stats that
@@ -142,6 +378,17 @@ type WriterStats struct {
 	Bytes      int64 `metric:"kafka.writer.message.bytes"   type:"counter"`
 	Rebalances int64 `metric:"kafka.writer.rebalance.count" type:"counter"`
 	Errors     int64 `metric:"kafka.writer.error.count"     type:"counter"`
+	Throttles  int64 `metric:"kafka.writer.throttle.count"  type:"counter"`
+
+	// DeadLetters is the number of messages passed to WriterConfig.DeadLetter.
+	DeadLetters int64 `metric:"kafka.writer.dead_letter.count" type:"counter"`
+
+	// MetadataRefreshes is the number of times that the metadata of a topic
+	// was refreshed, and StaleLeaderRetries the number of batches which failed
+	// because they were written to a broker which was not the leader of their
+	// partition anymore.
+	MetadataRefreshes  int64 `metric:"kafka.writer.metadata.refresh.count" type:"counter"`
+	StaleLeaderRetries int64 `metric:"kafka.writer.stale_leader.count"     type:"counter"`
 
 	DialTime   DurationStats `metric:"kafka.writer.dial.seconds"`
 	WriteTime  DurationStats `metric:"kafka.writer.write.seconds"`
@@ -150,6 +397,12 @@ type WriterStats struct {
 	BatchSize  SummaryStats  `metric:"kafka.writer.batch.size"`
 	BatchBytes SummaryStats  `metric:"kafka.writer.batch.bytes"`
 
+	// ThrottleTime is the time that the batches were delayed by because the
+	// broker that they were written to throttled the previous writes, and
+	// Throttles the number of delayed batches. Brokers older than kafka 2.1
+	// delay their responses instead, which shows in WriteTime.
+	ThrottleTime DurationStats `metric:"kafka.writer.throttle.seconds"`
+
 	MaxAttempts       int64         `metric:"kafka.writer.attempts.max"       type:"gauge"`
 	MaxBatchSize      int64         `metric:"kafka.writer.batch.max"          type:"gauge"`
 	BatchTimeout      time.Duration `metric:"kafka.writer.batch.timeout"      type:"gauge"`
@@ -162,7 +415,37 @@ type WriterStats struct {
 	QueueCapacity     int64         `metric:"kafka.writer.queue.capacity"     type:"gauge"`
 
 	ClientID string `tag:"client_id"`
-	Topic    string `tag:"topic"`
+
+	// Topic is the topic configured on the writer, it is empty when the
+	// writer produces messages to the topics set on them.
+	Topic string `tag:"topic"`
+
+	// Partitions holds the statistics of each partition that the writer wrote
+	// to, sorted by topic and partition. It is only set when the writer was
+	// configured with PartitionStats.
+	Partitions []WriterPartitionStats
+}
+
+// WriterPartitionStats exposes details about the writes of a Writer to a
+// partition.
+type WriterPartitionStats struct {
+	Messages int64 `metric:"kafka.writer.message.count" type:"counter"`
+	Bytes    int64 `metric:"kafka.writer.message.bytes" type:"counter"`
+	Errors   int64 `metric:"kafka.writer.error.count"   type:"counter"`
+
+	// Retries is the number of attempts made to write the batches of an
+	// idempotent writer after their first attempt failed. The batches of other
+	// writers are retried by WriteMessages, possibly to other partitions.
+	Retries int64 `metric:"kafka.writer.retries.count" type:"counter"`
+
+	// QueueTime is the time from the first message of a batch being received
+	// by the partition until the batch is written, and WriteTime is the time
+	// taken to write the batch and receive its acknowledgement.
+	QueueTime DurationStats `metric:"kafka.writer.queue.seconds"`
+	WriteTime DurationStats `metric:"kafka.writer.write.seconds"`
+
+	Topic     string `tag:"topic"`
+	Partition string `tag:"partition"`
 }
 
 // writerStats is a struct that contains statistics on a writer.
@@ -183,6 +466,99 @@ type writerStats struct {
 	retries        summary
 	batchSize      summary
 	batchSizeBytes summary
+	throttles      counter
+	throttleTime   summary
+	refreshes      counter
+	staleLeaders   counter
+	deadLetters    counter
+
+	// partitions is nil unless the writer collects per-partition statistics.
+	partitions *partitionStatsMap
+}
+
+// partitionStats holds the statistics of the writes to a partition.
+type partitionStats struct {
+	messages  counter
+	bytes     counter
+	errors    counter
+	retries   counter
+	queueTime summary
+	writeTime summary
+}
+
+// observe records the outcome of the write of a batch to the partition.
+func (s *partitionStats) observe(batch []Message, writeTime time.Duration, err error) {
+	s.writeTime.observeDuration(writeTime)
+	if err != nil {
+		s.errors.observe(1)
+		return
+	}
+	for _, m := range batch {
+		s.messages.observe(1)
+		s.bytes.observe(int64(len(m.Key) + len(m.Value)))
+	}
+}
+
+// partitionStatsMap holds the statistics of the partitions of a writer, which
+// remain after the partition writers are closed. The methods are no-ops on a
+// nil map.
+type partitionStatsMap struct {
+	mutex      sync.Mutex
+	partitions map[topicPartition]*partitionStats
+}
+
+func (m *partitionStatsMap) get(topic string, partition int) *partitionStats {
+	if m == nil {
+		return nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := topicPartition{topic: topic, partition: partition}
+	stats := m.partitions[key]
+	if stats == nil {
+		stats = &partitionStats{queueTime: makeSummary(), writeTime: makeSummary()}
+		if m.partitions == nil {
+			m.partitions = make(map[topicPartition]*partitionStats)
+		}
+		m.partitions[key] = stats
+	}
+	return stats
+}
+
+func (m *partitionStatsMap) snapshot() []WriterPartitionStats {
+	if m == nil {
+		return nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	keys := make([]topicPartition, 0, len(m.partitions))
+	for key := range m.partitions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].topic != keys[j].topic {
+			return keys[i].topic < keys[j].topic
+		}
+		return keys[i].partition < keys[j].partition
+	})
+
+	snapshot := make([]WriterPartitionStats, len(keys))
+	for i, key := range keys {
+		stats := m.partitions[key]
+		snapshot[i] = WriterPartitionStats{
+			Messages:  stats.messages.snapshot(),
+			Bytes:     stats.bytes.snapshot(),
+			Errors:    stats.errors.snapshot(),
+			Retries:   stats.retries.snapshot(),
+			QueueTime: stats.queueTime.snapshotDuration(),
+			WriteTime: stats.writeTime.snapshotDuration(),
+			Topic:     key.topic,
+			Partition: strconv.Itoa(key.partition),
+		}
+	}
+	return snapshot
 }
 
 // Validate method validates WriterConfig properties.
@@ -192,8 +568,8 @@ func (config *WriterConfig) Validate() error {
 		return errors.New("cannot create a kafka writer with an empty list of brokers")
 	}
 
-	if len(config.Topic) == 0 {
-		return errors.New("cannot create a kafka writer with an empty topic")
+	if (config.Idempotent || config.TransactionalID != "") && config.RequiredAcks != 0 && config.RequiredAcks != -1 {
+		return errors.New("cannot create an idempotent kafka writer which does not wait for all replicas to acknowledge writes")
 	}
 
 	return nil
@@ -214,16 +590,18 @@ func NewWriter(config WriterConfig) *Writer {
 		config.Balancer = &RoundRobin{}
 	}
 
-	if config.newPartitionWriter == nil {
-		config.newPartitionWriter = func(partition int, config WriterConfig, stats *writerStats) partitionWriter {
-			return newWriter(partition, config, stats)
-		}
-	}
-
 	if config.MaxAttempts == 0 {
 		config.MaxAttempts = 10
 	}
 
+	if config.WriteBackoffMin == 0 {
+		config.WriteBackoffMin = 100 * time.Millisecond
+	}
+
+	if config.WriteBackoffMax == 0 {
+		config.WriteBackoffMax = 1 * time.Second
+	}
+
 	if config.QueueCapacity == 0 {
 		config.QueueCapacity = 100
 	}
@@ -237,6 +615,13 @@ func NewWriter(config WriterConfig) *Writer {
 		config.BatchBytes = 1048576
 	}
 
+	if config.MaxMessageBytes == 0 {
+		config.MaxMessageBytes = 1048576
+		if config.BatchBytes > config.MaxMessageBytes {
+			config.MaxMessageBytes = config.BatchBytes
+		}
+	}
+
 	if config.BatchTimeout == 0 {
 		config.BatchTimeout = 1 * time.Second
 	}
@@ -252,20 +637,69 @@ func NewWriter(config WriterConfig) *Writer {
 	if config.RebalanceInterval == 0 {
 		config.RebalanceInterval = 15 * time.Second
 	}
+
+	if config.MetadataTTL == 0 {
+		config.MetadataTTL = config.RebalanceInterval
+	}
 	if config.IdleConnTimeout == 0 {
 		config.IdleConnTimeout = 9 * time.Minute
 	}
 
+	var completions *completionQueue
+	if config.Completion != nil {
+		completions = newCompletionQueue(config.Completion)
+	}
+	inflight := &inflightMessages{}
+	throttles := &brokerThrottles{}
+
+	var refreshes chan string
+	if config.MetadataRefreshOnError {
+		refreshes = make(chan string, 1)
+	}
+
+	var producer *idempotentProducer
+	if config.Idempotent || config.TransactionalID != "" {
+		producer = &idempotentProducer{
+			client:             NewClientWith(ClientConfig{Brokers: config.Brokers, Dialer: config.Dialer}),
+			timeout:            config.WriteTimeout,
+			transactionalID:    config.TransactionalID,
+			transactionTimeout: config.TransactionTimeout,
+		}
+	}
+
+	if config.newPartitionWriter == nil {
+		config.newPartitionWriter = func(topic string, partition int, config WriterConfig, stats *writerStats) partitionWriter {
+			return newWriter(topic, partition, config, stats, producer, completions, inflight, throttles, refreshes)
+		}
+	}
+
 	w := &Writer{
 		config: config,
 		msgs:   make(chan writerMessage, config.QueueCapacity),
 		done:   make(chan struct{}),
 		stats: &writerStats{
-			dialTime:  makeSummary(),
-			writeTime: makeSummary(),
-			waitTime:  makeSummary(),
-			retries:   makeSummary(),
+			dialTime:     makeSummary(),
+			writeTime:    makeSummary(),
+			waitTime:     makeSummary(),
+			retries:      makeSummary(),
+			throttleTime: makeSummary(),
 		},
+		completions: completions,
+		inflight:    inflight,
+		producer:    producer,
+		refreshes:   refreshes,
+	}
+
+	if config.PartitionStats {
+		w.stats.partitions = &partitionStatsMap{}
+	}
+
+	if config.TopicAutoCreate != nil {
+		w.creator = &topicCreator{
+			client:  NewClientWith(ClientConfig{Brokers: config.Brokers, Dialer: config.Dialer}),
+			config:  *config.TopicAutoCreate,
+			timeout: config.WriteTimeout,
+		}
 	}
 
 	w.join.Add(1)
@@ -274,7 +708,9 @@ func NewWriter(config WriterConfig) *Writer {
 }
 
 // WriteMessages writes a batch of messages to the kafka topic configured on this
-// writer.
+// writer, or to the topics set on the messages when the writer has no topic.
+// The method fails without writing any message if one of them has no topic to
+// be written to, or has a topic while the writer has one as well.
 //
 // Unless the writer was configured to write messages asynchronously, the method
 // blocks until all messages have been written, or until the maximum number of
@@ -289,8 +725,15 @@ func NewWriter(config WriterConfig) *Writer {
 // best way to achieve good batching behavior is to share one Writer amongst
 // multiple go routines.
 //
-// When the method returns an error, there's no way to know yet which messages
-// have succeeded of failed.
+// When some of the messages failed to be written, the method returns a
+// WriteErrors holding the outcome of each message, which tells the program
+// which messages to retry. Asynchronous writes report the failed batches to the
+// Completion callback instead. The method returns ctx.Err() when the context
+// is cancelled, and io.ErrClosedPipe when the writer is closed, before the
+// messages were written.
+//
+// A transactional writer only accepts messages while a transaction is in
+// progress, and a transaction in which WriteMessages failed must be aborted.
 //
 // The context passed as first argument may also be used to asynchronously
 // cancel the operation. Note that in this case there are no guarantees made on
@@ -298,62 +741,186 @@ func NewWriter(config WriterConfig) *Writer {
 // whole batch failed and re-write the messages later (which could then cause
 // duplicates).
 func (w *Writer) WriteMessages(ctx context.Context, msgs ...Message) error {
+	return w.write(ctx, msgs, nil)
+}
+
+// write is the implementation of WriteMessages, queued is closed once the first
+// attempt at writing the messages was queued, or when write fails before that.
+func (w *Writer) write(ctx context.Context, msgs []Message, queued chan<- struct{}) error {
+	if queued != nil {
+		defer func() {
+			if queued != nil {
+				close(queued)
+			}
+		}()
+	}
+
 	if len(msgs) == 0 {
 		return nil
 	}
 
-	var err error
-	var res chan error
+	// The messages passed by the program are only updated when they are
+	// the ones being written.
+	var update []Message
+	if w.config.UpdateMessages && !w.config.Async && len(w.config.Interceptors) == 0 {
+		update = msgs
+	}
+
+	if len(w.config.Interceptors) != 0 {
+		var err error
+		if msgs, err = w.intercept(ctx, msgs); err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+	}
+
+	for i, msg := range msgs {
+		switch {
+		case w.config.Topic != "" && msg.Topic != "":
+			return fmt.Errorf("kafka.(*Writer).WriteMessages: message %d has topic %s but the writer was configured with topic %s, the topic must only be set on one of them", i, msg.Topic, w.config.Topic)
+		case w.config.Topic == "" && msg.Topic == "":
+			return fmt.Errorf("kafka.(*Writer).WriteMessages: message %d has no topic and the writer was not configured with one", i)
+		}
+	}
+
+	if w.config.TransactionalID != "" {
+		w.txn.RLock()
+		defer w.txn.RUnlock()
+
+		if !w.producer.inTransaction() {
+			return errNoTransaction
+		}
+	}
+
+	// The messages of each attempt are reported at their index in the list
+	// passed to WriteMessages, so errs holds the outcome of every message
+	// after the retries.
+	var res chan writerResult
+	var errs WriteErrors
+	indexes := make([]int, len(msgs))
+	for i := range indexes {
+		indexes[i] = i
+	}
 	if !w.config.Async {
-		res = make(chan error, len(msgs))
+		res = make(chan writerResult, len(msgs))
+		errs = make(WriteErrors, len(msgs))
+	}
+
+	// alone is set for the messages which are retried in batches of their
+	// own before being passed to the dead letter handler.
+	var alone []bool
+	all := msgs
+	if w.config.DeadLetter != nil && !w.config.Async && w.config.TransactionalID == "" {
+		alone = make([]bool, len(msgs))
 	}
 	t0 := time.Now()
 
 	for attempt := 0; attempt < w.config.MaxAttempts; attempt++ {
-		w.mutex.RLock()
-
-		if w.closed {
-			w.mutex.RUnlock()
-			return io.ErrClosedPipe
-		}
+		sent := 0
 
 		for i, msg := range msgs {
-			if int(msg.size()) > w.config.BatchBytes {
+			size := int(msg.totalSize())
+
+			if size > w.config.MaxMessageBytes && alone != nil {
+				tooLarge := MessageTooLargeError{Message: msg, Index: indexes[i], Size: size}
+				errs[indexes[i]] = w.deadLetter(ctx, msg, tooLarge)
+				continue
+			}
+
+			if size > w.config.MaxMessageBytes {
 				err := MessageTooLargeError{
 					Message:   msg,
 					Remaining: msgs[i+1:],
+					Index:     indexes[i],
+					Size:      size,
+				}
+				if i != 0 {
+					w.producer.fail(err)
 				}
-				w.mutex.RUnlock()
 				return err
 			}
+
+			// The limiters are waited on without holding the mutex, so a
+			// rate limited write does not prevent the writer from closing.
+			if err := w.limit(ctx, msg); err != nil {
+				w.producer.fail(err)
+				return err
+			}
+
+			w.mutex.RLock()
+
+			if w.closed {
+				w.mutex.RUnlock()
+				return io.ErrClosedPipe
+			}
+
+			// The partition and offset set on messages that were written
+			// before are not used, the writer assigns the partition unless
+			// the messages carry their own.
+			if !w.config.ExplicitPartitions {
+				msg.Partition = 0
+			}
+			msg.Offset = 0
+
+			w.inflight.add(1)
 			select {
 			case w.msgs <- writerMessage{
-				msg: msg,
-				res: res,
+				msg:   msg,
+				res:   res,
+				index: indexes[i],
+				alone: alone != nil && alone[indexes[i]],
 			}:
+				sent++
 			case <-ctx.Done():
+				// Part of the messages may have been queued already, the
+				// transaction would not hold all of them.
+				w.producer.fail(ctx.Err())
+				w.inflight.done(1)
 				w.mutex.RUnlock()
 				return ctx.Err()
 			}
+
+			w.mutex.RUnlock()
 		}
 
-		w.mutex.RUnlock()
+		if queued != nil {
+			close(queued)
+			queued = nil
+		}
 
 		if w.config.Async {
 			break
 		}
 
 		var retry []Message
+		var retryIndexes []int
 
-		for i := 0; i != len(msgs); i++ {
+		for i := 0; i != sent; i++ {
 			select {
-			case e := <-res:
-				if e != nil {
-					if we, ok := e.(*writerError); ok {
+			case r := <-res:
+				errs[r.index] = r.err
+				if r.err == nil && update != nil {
+					m := &update[r.index]
+					m.Partition, m.Offset, m.Time = r.partition, r.offset, r.time
+				}
+				if r.err != nil {
+					if we, ok := r.err.(*writerError); ok {
 						w.stats.retries.observe(1)
-						retry, err = append(retry, we.msg), we.err
-					} else {
-						err = e
+						retry = append(retry, we.msg)
+						retryIndexes = append(retryIndexes, r.index)
+						errs[r.index] = we.err
+					} else if alone != nil && isPermanent(r.err) {
+						if !alone[r.index] && attempt < w.config.MaxAttempts-1 {
+							// The message may have failed because of another
+							// message of its batch.
+							alone[r.index] = true
+							retry = append(retry, all[r.index])
+							retryIndexes = append(retryIndexes, r.index)
+						} else {
+							errs[r.index] = w.deadLetter(ctx, all[r.index], r.err)
+						}
 					}
 				}
 			case <-ctx.Done():
@@ -361,175 +928,725 @@ func (w *Writer) WriteMessages(ctx context.Context, msgs ...Message) error {
 			}
 		}
 
-		if msgs = retry; len(msgs) == 0 {
+		if w.creator != nil {
+			retry, retryIndexes = w.createTopics(ctx, retry, retryIndexes, errs)
+		}
+
+		// The messages which are not retried keep the error of their last
+		// attempt in errs.
+		if msgs, indexes = retry, retryIndexes; len(msgs) == 0 || attempt == w.config.MaxAttempts-1 {
 			break
 		}
 
-		timer := time.NewTimer(backoff(attempt+1, 100*time.Millisecond, 1*time.Second))
+		timer := time.NewTimer(w.config.backoff(attempt + 1))
 		select {
 		case <-timer.C:
-			// Only clear the error (so we retry the loop) if we have more retries, otherwise
-			// we risk silencing the error.
-			if attempt < w.config.MaxAttempts-1 {
-				err = nil
-			}
 		case <-ctx.Done():
-			err = ctx.Err()
+			timer.Stop()
+			return ctx.Err()
 		case <-w.done:
-			err = io.ErrClosedPipe
+			timer.Stop()
+			return io.ErrClosedPipe
 		}
 		timer.Stop()
-
-		if err != nil {
-			break
-		}
 	}
 	w.stats.writeTime.observeDuration(time.Since(t0))
-	return err
+	if errs.Count() != 0 {
+		return errs
+	}
+	return nil
 }
 
-// Stats returns a snapshot of the writer stats since the last time the method
-// was called, or since the writer was created if it is called for the first
-// time.
+// WriteRecords writes the records read from records until it returns io.EOF,
+// without holding the whole stream in memory. The records are grouped in
+// batches of BatchSize records or BatchBytes bytes, which are written like the
+// messages passed to WriteMessages, and the records of each partition are
+// written in the order they were read.
 //
-// A typical use of this method is to spawn a goroutine that will periodically
-// call Stats on a kafka writer and report the metrics to a stats collection
-// system.
-func (w *Writer) Stats() WriterStats {
-	return WriterStats{
-		Dials:             w.stats.dials.snapshot(),
-		Writes:            w.stats.writes.snapshot(),
-		Messages:          w.stats.messages.snapshot(),
-		Bytes:             w.stats.bytes.snapshot(),
-		Rebalances:        w.stats.rebalances.snapshot(),
-		Errors:            w.stats.errors.snapshot(),
-		DialTime:          w.stats.dialTime.snapshotDuration(),
-		WriteTime:         w.stats.writeTime.snapshotDuration(),
-		WaitTime:          w.stats.waitTime.snapshotDuration(),
-		Retries:           w.stats.retries.snapshot(),
-		BatchSize:         w.stats.batchSize.snapshot(),
-		BatchBytes:        w.stats.batchSizeBytes.snapshot(),
-		MaxAttempts:       int64(w.config.MaxAttempts),
-		MaxBatchSize:      int64(w.config.BatchSize),
-		BatchTimeout:      w.config.BatchTimeout,
-		ReadTimeout:       w.config.ReadTimeout,
-		WriteTimeout:      w.config.WriteTimeout,
-		RebalanceInterval: w.config.RebalanceInterval,
-		RequiredAcks:      int64(w.config.RequiredAcks),
-		Async:             w.config.Async,
-		QueueLength:       int64(len(w.msgs)),
-		QueueCapacity:     int64(cap(w.msgs)),
-		ClientID:          w.config.Dialer.ClientID,
-		Topic:             w.config.Topic,
+// At most QueueCapacity batches are being written at any time, reading more
+// records blocks until one of them completed, so a slow cluster slows down the
+// reading of the stream instead of growing the memory of the program.
+//
+// The method stops reading records once a batch failed, and returns the error
+// of the first record which could not be written, or the error returned by the
+// RecordReader. Asynchronous writes report the failed batches to the Completion
+// callback instead.
+func (w *Writer) WriteRecords(ctx context.Context, records RecordReader) error {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var writeErr, readErr error
+	sem := make(chan struct{}, w.config.QueueCapacity)
+	first := -1
+
+	// fail keeps the error of the earliest batch, the batches complete in any
+	// order.
+	fail := func(batch int, err error) {
+		if errs, ok := err.(WriteErrors); ok {
+			for _, e := range errs {
+				if e != nil {
+					err = e
+					break
+				}
+			}
+		}
+		mutex.Lock()
+		if first < 0 || batch < first {
+			first, writeErr = batch, err
+		}
+		mutex.Unlock()
 	}
-}
-
-// Close flushes all buffered messages and closes the writer. The call to Close
-// aborts any concurrent calls to WriteMessages, which then return with the
-// io.ErrClosedPipe error.
-func (w *Writer) Close() (err error) {
-	w.mutex.Lock()
 
-	if !w.closed {
-		w.closed = true
-		close(w.msgs)
-		close(w.done)
+	failed := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return first >= 0
 	}
 
-	w.mutex.Unlock()
-	w.join.Wait()
-	return
-}
+read:
+	for batch := 0; readErr == nil && !failed(); batch++ {
+		msgs := make([]Message, 0, w.config.BatchSize)
 
-func (w *Writer) run() {
-	defer w.join.Done()
+		for size := 0; len(msgs) < w.config.BatchSize && size < w.config.BatchBytes; {
+			r, err := records.ReadRecord()
+			if err != nil {
+				readErr = err
+				break
+			}
+			msg, err := r.message()
+			if err != nil {
+				readErr = err
+				break
+			}
+			msgs, size = append(msgs, msg), size+int(msg.size())
+		}
 
-	ticker := time.NewTicker(w.config.RebalanceInterval)
-	defer ticker.Stop()
+		if len(msgs) == 0 {
+			break
+		}
 
-	var rebalance = true
-	var writers = make(map[int]partitionWriter)
-	var partitions []int
-	var err error
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			readErr = ctx.Err()
+			break read
+		}
 
-	for {
-		if rebalance {
-			w.stats.rebalances.observe(1)
-			rebalance = false
+		// The next batch is only read once the records of this one were
+		// queued, which keeps them in order.
+		queued := make(chan struct{})
+		wg.Add(1)
+		go func(batch int, msgs []Message) {
+			defer wg.Done()
+			defer func() { <-sem }()
 
-			var newPartitions []int
-			var oldPartitions = partitions
+			if err := w.write(ctx, msgs, queued); err != nil {
+				fail(batch, err)
+			}
+		}(batch, msgs)
+		<-queued
+	}
 
-			if newPartitions, err = w.partitions(); err == nil {
-				for _, partition := range diffp(oldPartitions, newPartitions) {
-					w.close(writers[partition])
-					delete(writers, partition)
-				}
+	wg.Wait()
 
-				for _, partition := range diffp(newPartitions, oldPartitions) {
-					writers[partition] = w.open(partition)
-				}
+	switch {
+	case writeErr != nil:
+		return writeErr
+	case readErr != io.EOF:
+		return readErr
+	}
+	return nil
+}
 
-				partitions = newPartitions
-			}
+// deadLetter passes msg to the dead letter handler of the writer, and returns
+// the error that msg fails with, which is nil if the handler accepted it.
+func (w *Writer) deadLetter(ctx context.Context, msg Message, err error) error {
+	w.stats.deadLetters.observe(1)
+	return w.config.DeadLetter(ctx, msg, err)
+}
+
+// Preconnect looks up the partitions of topics, or of the topic of the writer if
+// none are given, and connects to the leaders of the partitions ahead of the
+// first write, so the messages written next do not wait for the metadata of
+// their topic to be fetched or for the connections to be established and
+// authenticated.
+//
+// The partitions which are already connected are left untouched, the method
+// can be called again to reconnect those which failed. The brokers which could
+// not be connected to are returned with their error, the method only fails if
+// the partitions of a topic could not be looked up, or if ctx is done before
+// all the connections were established.
+func (w *Writer) Preconnect(ctx context.Context, topics ...string) (map[string]error, error) {
+	if len(topics) == 0 {
+		if w.config.Topic == "" {
+			return nil, errors.New("kafka.(*Writer).Preconnect: no topics to connect to and the writer was not configured with one")
 		}
+		topics = []string{w.config.Topic}
+	}
 
-		select {
-		case wm, ok := <-w.msgs:
-			if !ok {
-				for _, writer := range writers {
-					w.close(writer)
-				}
-				return
-			}
+	req := &preconnect{topics: topics, res: make(chan []partitionConnect, 1)}
 
-			if len(partitions) != 0 {
-				selectedPartition := w.config.Balancer.Balance(wm.msg, partitions...)
-				writers[selectedPartition].messages() <- wm
-			} else {
-				// No partitions were found because the topic doesn't exist.
+	w.mutex.RLock()
+	if w.closed {
+		w.mutex.RUnlock()
+		return nil, io.ErrClosedPipe
+	}
+	select {
+	case w.msgs <- writerMessage{preconnect: req}:
+	case <-ctx.Done():
+		w.mutex.RUnlock()
+		return nil, ctx.Err()
+	}
+	w.mutex.RUnlock()
+
+	var connects []partitionConnect
+	select {
+	case connects = <-req.res:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var failed map[string]error
+	var err error
+
+	for _, c := range connects {
+		select {
+		case e := <-c.err:
+			switch {
+			case e == nil:
+			case c.addr == "":
 				if err == nil {
-					err = fmt.Errorf("failed to find any partitions for topic %s", w.config.Topic)
+					err = e
 				}
-				if wm.res != nil {
-					wm.res <- &writerError{msg: wm.msg, err: err}
+			default:
+				if failed == nil {
+					failed = make(map[string]error)
+				}
+				if _, ok := failed[c.addr]; !ok {
+					failed[c.addr] = e
 				}
 			}
-
-		case <-ticker.C:
-			rebalance = true
+		case <-ctx.Done():
+			return failed, ctx.Err()
 		}
 	}
-}
 
-func (w *Writer) partitions() (partitions []int, err error) {
-	for _, broker := range shuffledStrings(w.config.Brokers) {
-		var conn *Conn
-		var plist []Partition
+	return failed, err
+}
 
-		if conn, err = w.config.Dialer.Dial("tcp", broker); err != nil {
-			continue
+// createTopics creates the topics of the messages which failed because their
+// topic did not exist, and returns the messages to retry with their indexes.
+// The messages whose topic could not be created are not retried, their error
+// is set in errs.
+func (w *Writer) createTopics(ctx context.Context, msgs []Message, indexes []int, errs WriteErrors) ([]Message, []int) {
+	creations := make(map[string]*topicCreation)
+
+	for i, msg := range msgs {
+		if errs[indexes[i]] == UnknownTopicOrPartition {
+			topic := msg.Topic
+			if topic == "" {
+				topic = w.config.Topic
+			}
+			if creations[topic] == nil {
+				creations[topic] = w.creator.create(topic, w.topicCreated)
+			}
 		}
+	}
 
-		conn.SetReadDeadline(time.Now().Add(w.config.ReadTimeout))
-		plist, err = conn.ReadPartitions(w.config.Topic)
-		conn.Close()
+	if len(creations) == 0 {
+		return msgs, indexes
+	}
 
-		if err == nil {
-			partitions = make([]int, len(plist))
-			for i, p := range plist {
-				partitions[i] = p.ID
-			}
-			break
+	for _, creation := range creations {
+		select {
+		case <-creation.done:
+		case <-ctx.Done():
+			// The messages are not retried since the backoff before the next
+			// attempt is interrupted as well.
+			return msgs, indexes
 		}
 	}
 
-	sort.Ints(partitions)
+	var retry []Message
+	var retryIndexes []int
+
+	for i, msg := range msgs {
+		topic := msg.Topic
+		if topic == "" {
+			topic = w.config.Topic
+		}
+		if creation := creations[topic]; creation != nil && creation.err != nil {
+			errs[indexes[i]] = creation.err
+			continue
+		}
+		retry, retryIndexes = append(retry, msg), append(retryIndexes, indexes[i])
+	}
+
+	return retry, retryIndexes
+}
+
+// fail reports the failure of a message which could not be routed to the
+// writer of a partition.
+func (w *Writer) fail(wm writerMessage, err error) {
+	if wm.res != nil {
+		wm.respond(writeFailure(wm.msg, err))
+	}
+	w.producer.fail(err)
+	w.completions.push([]Message{wm.msg}, err)
+	w.inflight.done(1)
+}
+
+// topicCreated asks the goroutine routing the messages to refresh the
+// partitions of topic after it was created. Since it goes through the message
+// queue, the partitions are refreshed before the messages written after the
+// topic was created are routed.
+func (w *Writer) topicCreated(topic string) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if !w.closed {
+		w.msgs <- writerMessage{created: topic}
+	}
+}
+
+// backoff returns the time to wait for before the retry attempt.
+func (config *WriterConfig) backoff(attempt int) time.Duration {
+	if config.Backoff != nil {
+		return config.Backoff(attempt)
+	}
+	return backoff(attempt, config.WriteBackoffMin, config.WriteBackoffMax)
+}
+
+// limit waits on the limiters of the writer before msg is queued.
+func (w *Writer) limit(ctx context.Context, msg Message) error {
+	if w.config.Limiter != nil {
+		if err := w.config.Limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if w.config.BytesLimiter != nil {
+		if err := w.config.BytesLimiter.WaitN(ctx, int(msg.size())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// intercept passes the messages through the interceptors of the writer.
+func (w *Writer) intercept(ctx context.Context, msgs []Message) ([]Message, error) {
+	msgs = append([]Message(nil), msgs...)
+
+	for i, interceptor := range w.config.Interceptors {
+		var err error
+		if msgs, err = interceptor(ctx, msgs); err != nil {
+			e, ok := err.(*InterceptorError)
+			if !ok {
+				e = &InterceptorError{Index: -1, Err: err}
+			}
+			e.Interceptor = i
+			return nil, e
+		}
+	}
+
+	return msgs, nil
+}
+
+// Stats returns a snapshot of the writer stats since the last time the method
+// was called, or since the writer was created if it is called for the first
+// time.
+//
+// A typical use of this method is to spawn a goroutine that will periodically
+// call Stats on a kafka writer and report the metrics to a stats collection
+// system.
+func (w *Writer) Stats() WriterStats {
+	return WriterStats{
+		Dials:              w.stats.dials.snapshot(),
+		Writes:             w.stats.writes.snapshot(),
+		Messages:           w.stats.messages.snapshot(),
+		Bytes:              w.stats.bytes.snapshot(),
+		Rebalances:         w.stats.rebalances.snapshot(),
+		Errors:             w.stats.errors.snapshot(),
+		Throttles:          w.stats.throttles.snapshot(),
+		MetadataRefreshes:  w.stats.refreshes.snapshot(),
+		StaleLeaderRetries: w.stats.staleLeaders.snapshot(),
+		DeadLetters:        w.stats.deadLetters.snapshot(),
+		DialTime:           w.stats.dialTime.snapshotDuration(),
+		WriteTime:          w.stats.writeTime.snapshotDuration(),
+		WaitTime:           w.stats.waitTime.snapshotDuration(),
+		Retries:            w.stats.retries.snapshot(),
+		BatchSize:          w.stats.batchSize.snapshot(),
+		BatchBytes:         w.stats.batchSizeBytes.snapshot(),
+		ThrottleTime:       w.stats.throttleTime.snapshotDuration(),
+		MaxAttempts:        int64(w.config.MaxAttempts),
+		MaxBatchSize:       int64(w.config.BatchSize),
+		BatchTimeout:       w.config.BatchTimeout,
+		ReadTimeout:        w.config.ReadTimeout,
+		WriteTimeout:       w.config.WriteTimeout,
+		RebalanceInterval:  w.config.RebalanceInterval,
+		RequiredAcks:       int64(w.config.RequiredAcks),
+		Async:              w.config.Async,
+		QueueLength:        int64(len(w.msgs)),
+		QueueCapacity:      int64(cap(w.msgs)),
+		ClientID:           w.config.Dialer.ClientID,
+		Topic:              w.config.Topic,
+		Partitions:         w.stats.partitions.snapshot(),
+	}
+}
+
+// Flush sends the messages buffered by the writer without waiting for their
+// batches to be full or for the batch timeout, and blocks until all the
+// messages passed to WriteMessages so far were either written or failed, or
+// until ctx is done. It is mostly useful with asynchronous writes, to make
+// sure that no message is left behind before shutting down. The messages that
+// failed are not reported by Flush, they are reported to the Completion
+// callback.
+func (w *Writer) Flush(ctx context.Context) error {
+	w.mutex.RLock()
+
+	if w.closed {
+		w.mutex.RUnlock()
+		return io.ErrClosedPipe
+	}
+
+	select {
+	case w.msgs <- writerMessage{flush: true}:
+	case <-ctx.Done():
+		w.mutex.RUnlock()
+		return ctx.Err()
+	}
+
+	w.mutex.RUnlock()
+
+	select {
+	case <-w.inflight.empty():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// BeginTxn begins a transaction on a writer configured with a TransactionalID.
+// The messages passed to WriteMessages until the transaction is committed or
+// aborted are all part of the transaction, including the messages of calls
+// made concurrently by multiple goroutines. The calls to WriteMessages which
+// are still running block BeginTxn, CommitTxn, and AbortTxn until they return.
+//
+// The first transaction initializes the producer id of the writer, which
+// fences the older writers that were using the same transactional id.
+func (w *Writer) BeginTxn(ctx context.Context) error {
+	if w.config.TransactionalID == "" {
+		return errNotTransactional
+	}
+	w.txn.Lock()
+	defer w.txn.Unlock()
+	return w.producer.begin(ctx)
+}
+
+// CommitTxn flushes the messages of the current transaction and commits it.
+//
+// The transaction cannot be committed if messages failed to be written as
+// part of it, CommitTxn then returns an error and the program must call
+// AbortTxn. When the commit request itself fails, the outcome is unknown and
+// the program may call CommitTxn again or abort the transaction.
+func (w *Writer) CommitTxn(ctx context.Context) error {
+	return w.endTxn(ctx, true)
+}
+
+// AbortTxn flushes the messages of the current transaction and aborts it, the
+// messages are discarded and never made visible to read_committed consumers.
+// The next transaction starts with a new producer epoch.
+func (w *Writer) AbortTxn(ctx context.Context) error {
+	return w.endTxn(ctx, false)
+}
+
+func (w *Writer) endTxn(ctx context.Context, commit bool) error {
+	if w.config.TransactionalID == "" {
+		return errNotTransactional
+	}
+	w.txn.Lock()
+	defer w.txn.Unlock()
+
+	if !w.producer.inTransaction() {
+		return errNoTransaction
+	}
+	if err := w.Flush(ctx); err != nil {
+		return err
+	}
+	return w.producer.end(ctx, commit)
+}
+
+// SendOffsetsToTransaction commits the offsets of the consumer group within the
+// current transaction, which makes them visible together with the messages of
+// the transaction when it is committed, and discards them if it is aborted.
+// This is how programs consuming from and producing to kafka process messages
+// exactly once. The offsets are the offsets of the next messages to consume,
+// indexed by topic.
+//
+// The transaction should be aborted if the offsets could not be committed.
+func (w *Writer) SendOffsetsToTransaction(ctx context.Context, offsets map[string][]OffsetCommit, groupID string) error {
+	if w.config.TransactionalID == "" {
+		return errNotTransactional
+	}
+	w.txn.RLock()
+	defer w.txn.RUnlock()
+	return w.producer.sendOffsets(ctx, offsets, groupID)
+}
+
+// Close flushes all buffered messages and closes the writer. The call to Close
+// aborts any concurrent calls to WriteMessages, which then return with the
+// io.ErrClosedPipe error.
+//
+// Close blocks until all the buffered messages were written or failed, use
+// Shutdown to bound the time it waits for them.
+func (w *Writer) Close() (err error) {
+	_, err = w.Shutdown(context.Background())
 	return
 }
 
-func (w *Writer) open(partition int) partitionWriter {
-	return w.config.newPartitionWriter(partition, w.config, w.stats)
+// Shutdown closes the writer like Close does, but gives up waiting for the
+// buffered messages to be written when ctx is done. The method then returns
+// the number of messages passed to WriteMessages which were neither written
+// nor failed yet, along with the error of ctx. It returns zero and a nil error
+// if all the messages completed, the messages that failed are reported by the
+// calls to WriteMessages, or to the Completion callback.
+//
+// After Shutdown gave up, the messages that are still buffered keep being
+// written in the background until they are written or run out of attempts,
+// and the Completion callback is still called with their outcome.
+func (w *Writer) Shutdown(ctx context.Context) (int, error) {
+	w.mutex.Lock()
+
+	if !w.closed {
+		w.closed = true
+		close(w.msgs)
+		close(w.done)
+	}
+
+	w.mutex.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		w.join.Wait()
+		w.completions.close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		return 0, nil
+	case <-ctx.Done():
+		select {
+		case <-closed:
+			return 0, nil
+		default:
+			return w.inflight.pending(), ctx.Err()
+		}
+	}
+}
+
+func (w *Writer) run() {
+	defer w.join.Done()
+
+	ticker := time.NewTicker(w.config.MetadataTTL)
+	defer ticker.Stop()
+
+	var rebalance = true
+	var writers = make(map[topicPartition]partitionWriter)
+	var partitions = make(map[string][]int)
+	var metadata = make(map[string][]Partition)
+	var leaders = make(map[topicPartition]int)
+	var errs = make(map[string]error)
+	var balancer, _ = w.config.Balancer.(PartitionBalancer)
+
+	// The topics of the writer are its configured topic, or the topics of the
+	// messages written so far, which are discovered as messages arrive.
+	if w.config.Topic != "" {
+		partitions[w.config.Topic] = nil
+	}
+
+	refresh := func(topic string) {
+		var newMetadata []Partition
+		var oldPartitions = partitions[topic]
+		var err error
+
+		if newMetadata, err = w.partitions(topic); err == nil {
+			newPartitions := make([]int, len(newMetadata))
+			for i, p := range newMetadata {
+				newPartitions[i] = p.ID
+			}
+
+			for _, partition := range diffp(oldPartitions, newPartitions) {
+				key := topicPartition{topic: topic, partition: partition}
+				w.close(writers[key])
+				delete(writers, key)
+			}
+
+			for _, partition := range diffp(newPartitions, oldPartitions) {
+				writers[topicPartition{topic: topic, partition: partition}] = w.open(topic, partition)
+			}
+
+			for _, p := range newMetadata {
+				key := topicPartition{topic: topic, partition: p.ID}
+				if leader, ok := leaders[key]; ok && leader != p.Leader.ID {
+					// The connection of the partition writer goes to the
+					// previous leader, which would reject the next batch.
+					writers[key].messages() <- writerMessage{reconnect: true}
+				}
+				leaders[key] = p.Leader.ID
+			}
+			for _, partition := range diffp(oldPartitions, newPartitions) {
+				delete(leaders, topicPartition{topic: topic, partition: partition})
+			}
+
+			partitions[topic] = newPartitions
+			metadata[topic] = newMetadata
+		} else if _, ok := partitions[topic]; !ok {
+			// The lookup of a topic which failed is only retried on the next
+			// rebalance, instead of on every message written to it.
+			partitions[topic] = nil
+		}
+
+		errs[topic] = err
+		w.stats.refreshes.observe(1)
+	}
+
+	for {
+		if rebalance {
+			w.stats.rebalances.observe(1)
+			rebalance = false
+
+			for topic := range partitions {
+				refresh(topic)
+			}
+		}
+
+		select {
+		case wm, ok := <-w.msgs:
+			if !ok {
+				for _, writer := range writers {
+					w.close(writer)
+				}
+				return
+			}
+
+			if wm.flush {
+				for _, writer := range writers {
+					writer.messages() <- wm
+				}
+				continue
+			}
+
+			if wm.created != "" {
+				refresh(wm.created)
+				continue
+			}
+
+			if wm.preconnect != nil {
+				var connects []partitionConnect
+
+				for _, topic := range wm.preconnect.topics {
+					refresh(topic)
+
+					if len(partitions[topic]) == 0 {
+						err := errs[topic]
+						if err == nil {
+							err = fmt.Errorf("failed to find any partitions for topic %s", topic)
+						}
+						ch := make(chan error, 1)
+						ch <- err
+						connects = append(connects, partitionConnect{err: ch})
+						continue
+					}
+
+					for _, p := range metadata[topic] {
+						ch := make(chan error, 1)
+						writers[topicPartition{topic: topic, partition: p.ID}].messages() <- writerMessage{connect: ch}
+						connects = append(connects, partitionConnect{
+							addr: net.JoinHostPort(p.Leader.Host, strconv.Itoa(p.Leader.Port)),
+							err:  ch,
+						})
+					}
+				}
+
+				wm.preconnect.res <- connects
+				continue
+			}
+
+			topic := wm.msg.Topic
+			if topic == "" {
+				topic = w.config.Topic
+			}
+			if _, ok := partitions[topic]; !ok {
+				refresh(topic)
+			}
+
+			if topicPartitions := partitions[topic]; len(topicPartitions) != 0 {
+				var selectedPartition int
+				if w.config.ExplicitPartitions && wm.msg.Partition >= 0 {
+					selectedPartition = wm.msg.Partition
+					if _, ok := writers[topicPartition{topic: topic, partition: selectedPartition}]; !ok {
+						w.fail(wm, &PartitionNotFoundError{
+							Topic:      topic,
+							Partition:  selectedPartition,
+							Partitions: len(topicPartitions),
+						})
+						continue
+					}
+				} else if balancer != nil {
+					selectedPartition = balancer.BalancePartitions(wm.msg, metadata[topic])
+				} else {
+					selectedPartition = w.config.Balancer.Balance(wm.msg, topicPartitions...)
+				}
+				writers[topicPartition{topic: topic, partition: selectedPartition}].messages() <- wm
+			} else {
+				// No partitions were found because the topic doesn't exist.
+				err := errs[topic]
+				if err == nil {
+					err = fmt.Errorf("failed to find any partitions for topic %s", topic)
+				}
+				if err == UnknownTopicOrPartition && w.creator != nil {
+					// The creation is started right away so the topic is
+					// created even if the messages are not retried.
+					w.creator.create(topic, w.topicCreated)
+				}
+				w.fail(wm, err)
+			}
+
+		case topic := <-w.refreshes:
+			refresh(topic)
+
+		case <-ticker.C:
+			rebalance = true
+		}
+	}
+}
+
+// partitions returns the partitions of topic, sorted by ID.
+func (w *Writer) partitions(topic string) (partitions []Partition, err error) {
+	for _, broker := range shuffledStrings(w.config.Brokers) {
+		var conn *Conn
+
+		if conn, err = w.config.Dialer.Dial("tcp", broker); err != nil {
+			continue
+		}
+
+		conn.SetReadDeadline(time.Now().Add(w.config.ReadTimeout))
+		partitions, err = conn.ReadPartitions(topic)
+		conn.Close()
+
+		if err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].ID < partitions[j].ID })
+	return
+}
+
+func (w *Writer) open(topic string, partition int) partitionWriter {
+	return w.config.newPartitionWriter(topic, partition, w.config, w.stats)
 }
 
 func (w *Writer) close(writer partitionWriter) {
@@ -560,6 +1677,7 @@ type writer struct {
 	partition       int
 	requiredAcks    int
 	batchSize       int
+	batchBytes      int
 	maxMessageBytes int
 	batchTimeout    time.Duration
 	writeTimeout    time.Duration
@@ -571,16 +1689,48 @@ type writer struct {
 	codec           CompressionCodec
 	logger          Logger
 	errorLogger     Logger
+
+	// When the writer is idempotent, producer is the source of the producer id
+	// and epoch shared by all partitions, and producerID, producerEpoch, and
+	// sequence are the partition state: the sequence number of the next batch
+	// is only valid for the producer id and epoch that it was assigned with.
+	producer      *idempotentProducer
+	producerID    int64
+	producerEpoch int16
+	sequence      int32
+	maxAttempts   int
+	backoff       func(attempt int) time.Duration
+
+	completions *completionQueue
+	inflight    *inflightMessages
+
+	// observer is the balancer of the Writer when it needs to be told about
+	// the completed batches, nil otherwise.
+	observer BatchObserver
+
+	// pstats holds the statistics of the partition, it is nil unless the
+	// Writer collects per-partition statistics.
+	pstats *partitionStats
+
+	// throttles is shared by the partition writers of the Writer to delay
+	// the batches to the brokers which throttled the previous ones.
+	throttles *brokerThrottles
+
+	// refreshes receives the topic of the partition when a batch failed on a
+	// stale leader, it is nil unless the Writer refreshes the metadata on
+	// errors.
+	refreshes chan<- string
 }
 
-func newWriter(partition int, config WriterConfig, stats *writerStats) *writer {
+func newWriter(topic string, partition int, config WriterConfig, stats *writerStats, producer *idempotentProducer, completions *completionQueue, inflight *inflightMessages, throttles *brokerThrottles, refreshes chan<- string) *writer {
 	w := &writer{
 		brokers:         config.Brokers,
-		topic:           config.Topic,
+		topic:           topic,
 		partition:       partition,
 		requiredAcks:    config.RequiredAcks,
 		batchSize:       config.BatchSize,
-		maxMessageBytes: config.BatchBytes,
+		batchBytes:      config.BatchBytes,
+		maxMessageBytes: config.MaxMessageBytes,
 		batchTimeout:    config.BatchTimeout,
 		writeTimeout:    config.WriteTimeout,
 		idleConnTimeout: config.IdleConnTimeout,
@@ -590,7 +1740,18 @@ func newWriter(partition int, config WriterConfig, stats *writerStats) *writer {
 		codec:           config.CompressionCodec,
 		logger:          config.Logger,
 		errorLogger:     config.ErrorLogger,
+		producer:        producer,
+		producerID:      -1,
+		producerEpoch:   -1,
+		maxAttempts:     config.MaxAttempts,
+		backoff:         config.backoff,
+		completions:     completions,
+		inflight:        inflight,
+		throttles:       throttles,
+		refreshes:       refreshes,
 	}
+	w.observer, _ = config.Balancer.(BatchObserver)
+	w.pstats = stats.partitions.get(topic, partition)
 	w.join.Add(1)
 	go w.run()
 	return w
@@ -630,9 +1791,12 @@ func (w *writer) run() {
 	var conn *Conn
 	var done bool
 	var batch = make([]Message, 0, w.batchSize)
-	var resch = make([](chan<- error), 0, w.batchSize)
+	var resch = make([]writerMessage, 0, w.batchSize)
 	var lastMsg writerMessage
+	var hasLastMsg bool
+	var batchStart time.Time
 	var batchSizeBytes int
+	var batchRecordBytes int
 	var idleConnDeadline time.Time
 
 	defer func() {
@@ -643,47 +1807,78 @@ func (w *writer) run() {
 
 	for !done {
 		var mustFlush bool
-		// lstMsg gets set when the next message would put the maxMessageBytes  over the limit.
+		// lstMsg gets set when the next message would put the batch over the batchBytes limit.
 		// If a lstMsg exists we need to add it to the batch so we don't lose it.
-		if len(lastMsg.msg.Value) != 0 {
+		if hasLastMsg {
+			if len(batch) == 0 {
+				batchStart = time.Now()
+			}
 			batch = append(batch, lastMsg.msg)
 			if lastMsg.res != nil {
-				resch = append(resch, lastMsg.res)
+				resch = append(resch, writerMessage{res: lastMsg.res, index: lastMsg.index})
 			}
 			batchSizeBytes += int(lastMsg.msg.size())
-			lastMsg = writerMessage{}
+			batchRecordBytes = lastMsg.msg.recordBytes(batchRecordBytes)
+			alone := lastMsg.alone
+			lastMsg, hasLastMsg = writerMessage{}, false
 			if !batchTimerRunning {
 				batchTimer.Reset(w.batchTimeout)
 				batchTimerRunning = true
 			}
+			// A message larger than batchBytes is sent alone.
+			mustFlush = alone || batchSizeBytes >= w.batchBytes
 		}
-		select {
-		case wm, ok := <-w.msgs:
-			if !ok {
-				done, mustFlush = true, true
-			} else {
-				if int(wm.msg.size())+batchSizeBytes > w.maxMessageBytes {
-					// If the size of the current message puts us over the maxMessageBytes limit,
-					// store the message but don't send it in this batch.
+		if !mustFlush {
+			select {
+			case wm, ok := <-w.msgs:
+				if !ok {
+					done, mustFlush = true, true
+				} else if wm.flush {
 					mustFlush = true
-					lastMsg = wm
+				} else if wm.reconnect {
+					if conn != nil {
+						conn.Close()
+						conn = nil
+					}
 					break
+				} else if wm.connect != nil {
+					var err error
+					if conn == nil {
+						if conn, err = w.dial(); err == nil {
+							idleConnDeadline = time.Now().Add(w.idleConnTimeout)
+						}
+					}
+					wm.connect <- err
+					break
+				} else {
+					if len(batch) != 0 && (wm.alone || int(wm.msg.size())+batchSizeBytes > w.batchBytes || wm.msg.recordBytes(batchRecordBytes) > w.maxMessageBytes) {
+						// If the size of the current message puts us over the batchBytes limit,
+						// the record batch over the limit of the brokers, or the message must be
+						// sent alone, store the message but don't send it in this batch.
+						mustFlush = true
+						lastMsg, hasLastMsg = wm, true
+						break
+					}
+					if len(batch) == 0 {
+						batchStart = time.Now()
+					}
+					batch = append(batch, wm.msg)
+					if wm.res != nil {
+						resch = append(resch, writerMessage{res: wm.res, index: wm.index})
+					}
+					batchSizeBytes += int(wm.msg.size())
+					batchRecordBytes = wm.msg.recordBytes(batchRecordBytes)
+					mustFlush = wm.alone || len(batch) >= w.batchSize || batchSizeBytes >= w.batchBytes
 				}
-				batch = append(batch, wm.msg)
-				if wm.res != nil {
-					resch = append(resch, wm.res)
+				if !batchTimerRunning {
+					batchTimer.Reset(w.batchTimeout)
+					batchTimerRunning = true
 				}
-				batchSizeBytes += int(wm.msg.size())
-				mustFlush = len(batch) >= w.batchSize || batchSizeBytes >= w.maxMessageBytes
-			}
-			if !batchTimerRunning {
-				batchTimer.Reset(w.batchTimeout)
-				batchTimerRunning = true
-			}
 
-		case <-batchTimer.C:
-			mustFlush = true
-			batchTimerRunning = false
+			case <-batchTimer.C:
+				mustFlush = true
+				batchTimerRunning = false
+			}
 		}
 
 		if mustFlush {
@@ -701,6 +1896,12 @@ func (w *writer) run() {
 			if len(batch) == 0 {
 				continue
 			}
+			if w.observer != nil {
+				w.observer.BatchCompleted(w.partition)
+			}
+			if w.pstats != nil {
+				w.pstats.queueTime.observeDuration(time.Since(batchStart))
+			}
 			var err error
 			if conn, err = w.write(conn, batch, resch); err != nil {
 				if conn != nil {
@@ -714,11 +1915,12 @@ func (w *writer) run() {
 			}
 
 			for i := range resch {
-				resch[i] = nil
+				resch[i] = writerMessage{}
 			}
 			batch = batch[:0]
 			resch = resch[:0]
 			batchSizeBytes = 0
+			batchRecordBytes = 0
 		}
 	}
 }
@@ -737,38 +1939,100 @@ func (w *writer) dial() (conn *Conn, err error) {
 	return
 }
 
-func (w *writer) write(conn *Conn, batch []Message, resch [](chan<- error)) (ret *Conn, err error) {
+func (w *writer) write(conn *Conn, batch []Message, resch []writerMessage) (ret *Conn, err error) {
 	w.stats.writes.observe(1)
-	if conn == nil {
+	if conn == nil && w.producer == nil {
+		// Idempotent writes dial the partition leader themselves, as part of
+		// the attempts to write the batch.
 		if conn, err = w.dial(); err != nil {
 			w.stats.errors.observe(1)
+			if w.pstats != nil {
+				w.pstats.errors.observe(1)
+			}
 			w.withErrorLogger(func(logger Logger) {
 				logger.Printf("error dialing kafka brokers for topic %s (partition %d): %s", w.topic, w.partition, err)
 			})
-			for i, res := range resch {
-				res <- &writerError{msg: batch[i], err: err}
+			for i, wm := range resch {
+				wm.respond(writeFailure(batch[i], err))
 			}
+			w.complete(batch, -1, err)
 			return
 		}
 	}
 
+	var offset int64
 	t0 := time.Now()
-	conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
-	if _, err = conn.WriteCompressedMessages(w.codec, batch...); err != nil {
+	if w.producer != nil {
+		conn, offset, err = w.writeIdempotent(conn, batch)
+	} else {
+		var appendTime time.Time
+		var throttle time.Duration
+		w.throttle(conn)
+		conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+		_, _, offset, appendTime, throttle, err = conn.writeCompressedMessages(w.codec, batch...)
+		w.throttles.set(conn.RemoteAddr().String(), throttle)
+		if err == nil && appendTime.UnixNano() >= 0 {
+			setLogAppendTime(batch, appendTime)
+		}
+		if err == MessageSizeTooLarge && len(batch) > 1 {
+			// The batch fits under MaxMessageBytes uncompressed, but may
+			// not once compressed, or the topic has a lower limit. Its
+			// halves are written separately, the messages only fail
+			// when they are too large on their own.
+			n := len(batch) / 2
+			var head, tail []writerMessage
+			if len(resch) != 0 {
+				head, tail = resch[:n], resch[n:]
+			}
+			conn, err = w.write(conn, batch[:n], head)
+			if err != nil && conn != nil {
+				conn.Close()
+				conn = nil
+			}
+			var tailErr error
+			if conn, tailErr = w.write(conn, batch[n:], tail); tailErr != nil {
+				err = tailErr
+			}
+			return conn, err
+		}
+	}
+	if w.pstats != nil {
+		w.pstats.observe(batch, time.Since(t0), err)
+	}
+	if err != nil {
+		// The transaction is failed before the batch completes, so a Flush
+		// returning when the last batch completed observes the failure.
+		w.producer.fail(err)
+	}
+	w.complete(batch, offset, err)
+	if err != nil {
+		if isStaleLeader(err) {
+			w.staleLeader()
+		}
 		w.stats.errors.observe(1)
 		w.withErrorLogger(func(logger Logger) {
 			logger.Printf("error writing messages to %s (partition %d): %s", w.topic, w.partition, err)
 		})
-		for i, res := range resch {
-			res <- &writerError{msg: batch[i], err: err}
+		for i, wm := range resch {
+			if w.producer != nil {
+				// Idempotent writes were already retried, the messages
+				// must not be retried in a different batch.
+				wm.respond(err)
+			} else {
+				wm.respond(writeFailure(batch[i], err))
+			}
 		}
 	} else {
 		for _, m := range batch {
 			w.stats.messages.observe(1)
 			w.stats.bytes.observe(int64(len(m.Key) + len(m.Value)))
 		}
-		for _, res := range resch {
-			res <- nil
+		for i, wm := range resch {
+			msgOffset := int64(-1)
+			if offset >= 0 {
+				msgOffset = offset + int64(i)
+			}
+			wm.written(w.partition, msgOffset, batch[i].Time)
 		}
 	}
 	t1 := time.Now()
@@ -779,9 +2043,766 @@ func (w *writer) write(conn *Conn, batch []Message, resch [](chan<- error)) (ret
 	return
 }
 
+// complete reports the batch to the Completion callback of the writer. The
+// messages are copied since the batch is reused, they are numbered from offset
+// if the batch was written at a known offset.
+func (w *writer) complete(batch []Message, offset int64, err error) {
+	defer w.inflight.done(len(batch))
+
+	if w.completions == nil {
+		return
+	}
+
+	msgs := make([]Message, len(batch))
+	for i, msg := range batch {
+		msg.Topic, msg.Partition, msg.Offset = w.topic, w.partition, -1
+		if err == nil && offset >= 0 {
+			msg.Offset = offset + int64(i)
+		}
+		msgs[i] = msg
+	}
+
+	w.completions.push(msgs, err)
+}
+
+// writeIdempotent writes the batch as an idempotent producer. Unlike other
+// writes, failed attempts are retried here rather than by WriteMessages so the
+// batch keeps its content and sequence numbers, which is what allows the
+// broker to detect duplicates. The returned connection is nil if conn had to
+// be closed, the returned offset is the offset of the first message of the
+// batch, or -1 if it is unknown.
+func (w *writer) writeIdempotent(conn *Conn, batch []Message) (*Conn, int64, error) {
+	writeTime := time.Now()
+	for i := range batch {
+		if batch[i].Time.IsZero() {
+			batch[i].Time = writeTime
+		}
+	}
+
+	var err error
+	var offset int64
+	var written bool
+	for attempt := 0; attempt < w.maxAttempts; attempt++ {
+		if attempt != 0 {
+			if w.pstats != nil {
+				w.pstats.retries.observe(1)
+			}
+			time.Sleep(w.backoff(attempt))
+		}
+
+		if conn == nil {
+			if conn, err = w.dial(); err != nil {
+				continue
+			}
+		}
+
+		var producerID int64
+		var producerEpoch int16
+		if producerID, producerEpoch, err = w.producer.get(); err != nil {
+			if w.producer.transactionalID != "" {
+				// The producer of a transactional writer is only obtained
+				// when the transaction begins, this is not a transient error.
+				return conn, -1, err
+			}
+			continue
+		}
+		if producerID != w.producerID || producerEpoch != w.producerEpoch {
+			// The sequence numbers restart from zero when the producer id or
+			// epoch change.
+			w.producerID, w.producerEpoch, w.sequence = producerID, producerEpoch, 0
+		}
+
+		if err = w.producer.addPartition(w.topic, w.partition); err != nil {
+			// The coordinator reports concurrent transactions while it is
+			// still completing the previous transaction of the producer.
+			if err == ConcurrentTransactions || isRetriable(err) {
+				continue
+			}
+			return conn, -1, err
+		}
+
+		var throttle time.Duration
+		w.throttle(conn)
+		conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+		offset, throttle, err = w.produce(conn, batch)
+		w.throttles.set(conn.RemoteAddr().String(), throttle)
+		written = true
+
+		switch err {
+		case nil, DuplicateSequenceNumber:
+			// A duplicate means that a previous attempt was written even if
+			// its response was lost, the offset it was written at is unknown.
+			if err != nil {
+				offset = -1
+			}
+			w.sequence += int32(len(batch))
+			return conn, offset, nil
+		case OutOfOrderSequenceNumber, UnknownProducerId, InvalidProducerEpoch:
+			// The broker lost track of the batches of the producer, or the
+			// producer was fenced, the only way to recover is to start over
+			// with a new producer id.
+			w.producer.reset(producerID, producerEpoch)
+			if w.producer.transactionalID != "" {
+				// A transaction does not survive a change of producer id or
+				// epoch, it can only be aborted.
+				return conn, -1, err
+			}
+			continue
+		}
+
+		if isStaleLeader(err) {
+			// The next attempt must look up the new leader of the partition.
+			w.staleLeader()
+			conn.Close()
+			conn = nil
+		} else if _, ok := err.(Error); !ok {
+			// Errors that are not kafka errors come from the connection, which
+			// cannot be reused.
+			conn.Close()
+			conn = nil
+		} else if !isTemporary(err) {
+			break
+		}
+	}
+
+	if written {
+		// The outcome of the last attempt is unknown, the batches written
+		// after it must not rely on its sequence numbers.
+		w.producer.reset(w.producerID, w.producerEpoch)
+	}
+	return conn, -1, err
+}
+
+// produce writes the batch to the partition leader that conn is connected to,
+// stamped with the producer state of the partition, and returns the offset of
+// the first message of the batch and the time that the broker throttles the
+// writer for.
+func (w *writer) produce(conn *Conn, batch []Message) (int64, time.Duration, error) {
+	recordBatch, err := newRecordBatch(w.codec, batch...)
+	if err != nil {
+		return -1, 0, err
+	}
+	recordBatch.setProducer(w.producerID, w.producerEpoch, w.sequence)
+	if w.producer.transactionalID != "" {
+		recordBatch.setTransactional()
+	}
+
+	response, err := conn.produce(produceRequestV3{
+		TransactionalID: emptyToNullable(w.producer.transactionalID),
+		RequiredAcks:    -1,
+		Topics: []produceRequestTopicV3{{
+			TopicName: w.topic,
+			Partitions: []produceRequestPartitionV3{{
+				Partition:   int32(w.partition),
+				RecordBatch: recordBatch,
+			}},
+		}},
+	})
+	if err != nil {
+		return -1, 0, err
+	}
+
+	// Only the v8 responses expect the client to apply the throttle time,
+	// the brokers delay the v3 responses instead.
+	var throttle time.Duration
+	if version, _ := conn.negotiateVersion(produce, v3, v8); version == v8 {
+		throttle = time.Duration(response.ThrottleTimeMS) * time.Millisecond
+	}
+
+	offset := int64(-1)
+	for _, t := range response.Topics {
+		for _, p := range t.Partitions {
+			if p.ErrorCode != 0 {
+				return -1, throttle, Error(p.ErrorCode)
+			}
+			offset = p.Offset
+			if p.Timestamp >= 0 {
+				setLogAppendTime(batch, time.Unix(0, p.Timestamp*int64(time.Millisecond)))
+			}
+		}
+	}
+	return offset, throttle, nil
+}
+
+// setLogAppendTime sets the time of the messages of a batch written to a topic
+// which uses the LogAppendTime timestamp type to the time that the broker
+// appended the batch at. The brokers return a timestamp of -1 for the topics
+// which use CreateTime, the messages keep their time then.
+func setLogAppendTime(batch []Message, t time.Time) {
+	for i := range batch {
+		batch[i].Time = t
+	}
+}
+
+// staleLeader records that a batch was rejected because the broker that it was
+// written to was not the leader of the partition anymore, and asks the Writer
+// to refresh the metadata of the topic if it does so on errors.
+func (w *writer) staleLeader() {
+	w.stats.staleLeaders.observe(1)
+
+	select {
+	case w.refreshes <- w.topic:
+	default:
+		// The refreshes are best effort, a refresh may already be pending or
+		// the Writer may not refresh the metadata on errors. The partition
+		// writer looks up the leader when it reconnects regardless.
+	}
+}
+
+// isStaleLeader reports whether err is returned by a broker which is not, or
+// not yet, the leader of the partition that it was sent to.
+func isStaleLeader(err error) bool {
+	switch err {
+	case NotLeaderForPartition, LeaderNotAvailable, FencedLeaderEpoch, UnknownLeaderEpoch:
+		return true
+	}
+	return false
+}
+
+// throttle delays the write of a batch on conn while the broker that conn is
+// connected to throttles the writer.
+func (w *writer) throttle(conn *Conn) {
+	if d := w.throttles.delay(conn.RemoteAddr().String()); d > 0 {
+		w.stats.throttles.observe(1)
+		w.stats.throttleTime.observeDuration(d)
+		time.Sleep(d)
+	}
+}
+
+// idempotentProducer holds the producer id and epoch shared by the partition
+// writers of an idempotent Writer, and the state of the current transaction
+// when the Writer is transactional.
+type idempotentProducer struct {
+	client             *Client
+	timeout            time.Duration
+	transactionalID    string
+	transactionTimeout time.Duration
+
+	mutex sync.Mutex
+	id    int64
+	epoch int16
+	valid bool
+
+	// inTxn is true while a transaction is open, partitions holds the
+	// partitions added to it, and pending is true once the coordinator was
+	// told about a partition or consumer group of the transaction. txnErr is
+	// the first error that caused messages of the transaction to be lost, the
+	// transaction can then only be aborted.
+	inTxn      bool
+	pending    bool
+	partitions map[topicPartition]struct{}
+	txnErr     error
+}
+
+var (
+	errNoTransaction         = errors.New("kafka.(*Writer): the writer is transactional and no transaction is in progress, BeginTxn must be called first")
+	errTransactionInProgress = errors.New("kafka.(*Writer): a transaction is already in progress")
+	errNotTransactional      = errors.New("kafka.(*Writer): transactions require the writer to be configured with a TransactionalID")
+)
+
+// get returns the producer id and epoch, a new producer id is obtained from
+// the cluster if there is none yet or it was reset. The producer id of a
+// transactional producer is obtained by begin instead, get then fails if no
+// transaction is in progress or if the transaction failed.
+func (p *idempotentProducer) get() (int64, int16, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.transactionalID != "" {
+		switch {
+		case !p.inTxn:
+			return -1, -1, errNoTransaction
+		case p.txnErr != nil:
+			return -1, -1, p.txnErr
+		case !p.valid:
+			return -1, -1, errors.New("kafka.(*Writer): the producer id of the transaction was discarded, the transaction must be aborted")
+		}
+		return p.id, p.epoch, nil
+	}
+
+	if !p.valid {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		defer cancel()
+
+		res, err := p.client.InitProducerID(ctx, InitProducerIDRequest{})
+		if err != nil {
+			return -1, -1, err
+		}
+		p.id, p.epoch, p.valid = res.ProducerID, int16(res.ProducerEpoch), true
+	}
+
+	return p.id, p.epoch, nil
+}
+
+// reset discards the producer id and epoch if they are still the current ones,
+// so partition writers that observe the same failure concurrently only cause
+// a single new producer id to be obtained.
+func (p *idempotentProducer) reset(id int64, epoch int16) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.valid && p.id == id && p.epoch == epoch {
+		p.valid = false
+	}
+}
+
+// inTransaction returns true if p is transactional and a transaction is in
+// progress. The method returns false on a nil producer.
+func (p *idempotentProducer) inTransaction() bool {
+	if p == nil {
+		return false
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.inTxn
+}
+
+// fail records that messages of the current transaction could not be written.
+// It is a no-op on a nil or non-transactional producer.
+func (p *idempotentProducer) fail(err error) {
+	if p == nil || p.transactionalID == "" {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.inTxn && p.txnErr == nil {
+		p.txnErr = err
+	}
+}
+
+// begin opens a transaction. A new producer id is obtained from the
+// transaction coordinator when the producer has none, which bumps the epoch of
+// the transactional id, fences the older producers which were using it, and
+// aborts the transaction that they may have left open.
+func (p *idempotentProducer) begin(ctx context.Context) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.inTxn {
+		return errTransactionInProgress
+	}
+
+	for attempt := 0; !p.valid; attempt++ {
+		res, err := p.client.InitProducerID(ctx, InitProducerIDRequest{
+			TransactionalID:    p.transactionalID,
+			TransactionTimeout: p.transactionTimeout,
+		})
+		switch err {
+		case nil:
+			p.id, p.epoch, p.valid = res.ProducerID, int16(res.ProducerEpoch), true
+		case ConcurrentTransactions:
+			// The previous transaction of the transactional id is still
+			// being completed by the coordinator.
+			if !sleep(ctx, backoff(attempt+1, 100*time.Millisecond, 1*time.Second)) {
+				return ctx.Err()
+			}
+		default:
+			return err
+		}
+	}
+
+	p.inTxn, p.pending, p.partitions, p.txnErr = true, false, make(map[topicPartition]struct{}), nil
+	return nil
+}
+
+// addPartition adds the partition to the current transaction, which must be
+// done before producing to it. It is a no-op on a non-transactional producer,
+// or if the partition was already added.
+func (p *idempotentProducer) addPartition(topic string, partition int) error {
+	if p.transactionalID == "" {
+		return nil
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	key := topicPartition{topic: topic, partition: partition}
+	if _, ok := p.partitions[key]; ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	res, err := p.client.AddPartitionsToTxn(ctx, AddPartitionsToTxnRequest{
+		TransactionalID: p.transactionalID,
+		ProducerID:      p.id,
+		ProducerEpoch:   int(p.epoch),
+		Topics:          map[string][]int{topic: {partition}},
+	})
+	if err != nil {
+		return err
+	}
+	for _, r := range res.Topics[topic] {
+		if r.Error != nil {
+			return r.Error
+		}
+	}
+
+	p.partitions[key], p.pending = struct{}{}, true
+	return nil
+}
+
+// sendOffsets commits the offsets of the consumer group within the current
+// transaction.
+func (p *idempotentProducer) sendOffsets(ctx context.Context, offsets map[string][]OffsetCommit, groupID string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	switch {
+	case !p.inTxn:
+		return errNoTransaction
+	case p.txnErr != nil:
+		return p.txnErr
+	}
+
+	if _, err := p.client.AddOffsetsToTxn(ctx, AddOffsetsToTxnRequest{
+		TransactionalID: p.transactionalID,
+		ProducerID:      p.id,
+		ProducerEpoch:   int(p.epoch),
+		GroupID:         groupID,
+	}); err != nil {
+		return err
+	}
+	p.pending = true
+
+	res, err := p.client.TxnOffsetCommit(ctx, TxnOffsetCommitRequest{
+		TransactionalID: p.transactionalID,
+		ProducerID:      p.id,
+		ProducerEpoch:   int(p.epoch),
+		GroupID:         groupID,
+		Topics:          offsets,
+	})
+	if err != nil {
+		return err
+	}
+	for topic, partitions := range res.Topics {
+		for _, r := range partitions {
+			if r.Error != nil {
+				return fmt.Errorf("kafka.(*Writer).SendOffsetsToTransaction: committing the offset of partition %d of topic %s failed: %v", r.Partition, topic, r.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// end commits or aborts the current transaction. A transaction which failed
+// cannot be committed. Aborting discards the producer id, the next transaction
+// starts with a new epoch, which also recovers from the errors that made the
+// producer id unusable.
+func (p *idempotentProducer) end(ctx context.Context, commit bool) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.inTxn {
+		return errNoTransaction
+	}
+
+	if commit && p.txnErr != nil {
+		return fmt.Errorf("kafka.(*Writer).CommitTxn: the transaction cannot be committed because messages failed to be written, it must be aborted: %v", p.txnErr)
+	}
+
+	var err error
+	if p.pending && p.valid {
+		// The coordinator knows nothing of a transaction which did not write
+		// anything, there is nothing to commit or abort.
+		_, err = p.client.EndTxn(ctx, EndTxnRequest{
+			TransactionalID: p.transactionalID,
+			ProducerID:      p.id,
+			ProducerEpoch:   int(p.epoch),
+			Committed:       commit,
+		})
+	}
+
+	if commit {
+		if err != nil {
+			// The outcome of the commit is unknown, it may be retried or the
+			// transaction aborted.
+			return err
+		}
+	} else {
+		p.valid = false
+	}
+
+	p.inTxn, p.pending, p.partitions, p.txnErr = false, false, nil, nil
+	return err
+}
+
+// completionQueue delivers the results of batches to the Completion callback of
+// a Writer from a goroutine of its own. The queue is unbounded so the partition
+// writers never wait on the callback, programs bound it by limiting the number
+// of messages that they have in flight. The methods are no-ops on a nil queue.
+type completionQueue struct {
+	callback func([]Message, error)
+
+	mutex       sync.Mutex
+	completions []completion
+	closed      bool
+	signal      chan struct{}
+	done        chan struct{}
+}
+
+type completion struct {
+	msgs []Message
+	err  error
+}
+
+func newCompletionQueue(callback func([]Message, error)) *completionQueue {
+	q := &completionQueue{
+		callback: callback,
+		signal:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *completionQueue) push(msgs []Message, err error) {
+	if q == nil {
+		return
+	}
+
+	q.mutex.Lock()
+	q.completions = append(q.completions, completion{msgs: msgs, err: err})
+	q.mutex.Unlock()
+
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// close waits for the queued completions to be delivered, nothing must be
+// pushed to the queue after it was closed.
+func (q *completionQueue) close() {
+	if q == nil {
+		return
+	}
+
+	q.mutex.Lock()
+	closed := q.closed
+	q.closed = true
+	q.mutex.Unlock()
+
+	if !closed {
+		select {
+		case q.signal <- struct{}{}:
+		default:
+			// A signal is already pending, the closed queue is seen when
+			// the goroutine wakes up for it.
+		}
+	}
+	<-q.done
+}
+
+func (q *completionQueue) run() {
+	defer close(q.done)
+
+	for range q.signal {
+		for {
+			q.mutex.Lock()
+			completions, closed := q.completions, q.closed
+			q.completions = nil
+			q.mutex.Unlock()
+
+			if len(completions) == 0 {
+				if closed {
+					return
+				}
+				break
+			}
+
+			for _, c := range completions {
+				q.callback(c.msgs, c.err)
+			}
+		}
+	}
+}
+
+// inflightMessages counts the messages which are queued or being written by a
+// Writer. The methods are no-ops on a nil value.
+type inflightMessages struct {
+	mutex sync.Mutex
+	count int
+	zero  chan struct{}
+}
+
+func (f *inflightMessages) add(n int) {
+	if f == nil {
+		return
+	}
+	f.mutex.Lock()
+	if f.count == 0 {
+		f.zero = make(chan struct{})
+	}
+	f.count += n
+	f.mutex.Unlock()
+}
+
+func (f *inflightMessages) done(n int) {
+	if f == nil {
+		return
+	}
+	f.mutex.Lock()
+	if f.count -= n; f.count == 0 {
+		close(f.zero)
+	}
+	f.mutex.Unlock()
+}
+
+// pending returns the number of messages in flight.
+func (f *inflightMessages) pending() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.count
+}
+
+// empty returns a channel which is closed once no messages are in flight.
+func (f *inflightMessages) empty() <-chan struct{} {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.count == 0 {
+		zero := make(chan struct{})
+		close(zero)
+		return zero
+	}
+	return f.zero
+}
+
+// brokerThrottles holds the times until which the brokers throttle the writes
+// of a Writer, indexed by broker address. Since kafka 2.1, a broker which
+// throttles a client because it exceeded its quota responds right away and
+// expects the client to wait for the throttle time before sending it another
+// request.
+type brokerThrottles struct {
+	mutex sync.Mutex
+	until map[string]time.Time
+}
+
+// set records that the broker at addr throttles the writer for d.
+func (t *brokerThrottles) set(addr string, d time.Duration) {
+	if t == nil || d <= 0 {
+		return
+	}
+
+	until := time.Now().Add(d)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.until == nil {
+		t.until = make(map[string]time.Time)
+	}
+	if until.After(t.until[addr]) {
+		t.until[addr] = until
+	}
+}
+
+// delay returns the time left until the broker at addr stops throttling the
+// writer.
+func (t *brokerThrottles) delay(addr string) time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	until, ok := t.until[addr]
+	if !ok {
+		return 0
+	}
+	d := time.Until(until)
+	if d <= 0 {
+		delete(t.until, addr)
+		return 0
+	}
+	return d
+}
+
+// writerMessage is a message queued by WriteMessages, or a request to send the
+// batches right away when flush is true.
 type writerMessage struct {
-	msg Message
-	res chan<- error
+	msg   Message
+	res   chan<- writerResult
+	index int
+	flush bool
+
+	// created is set to the name of a topic which was just created, the
+	// message only asks for its partitions to be refreshed.
+	created string
+
+	// reconnect asks the partition writer to close its connection because
+	// the leader of the partition changed.
+	reconnect bool
+
+	// alone asks the partition writer to write the message in a batch of its
+	// own, to tell whether it is the message which made its batch fail.
+	alone bool
+
+	// preconnect asks the goroutine routing the messages to connect the
+	// partition writers of topics, and connect asks a partition writer to
+	// connect to the leader of its partition, see Writer.Preconnect.
+	preconnect *preconnect
+	connect    chan<- error
+}
+
+// preconnect is a request to connect the partition writers of topics, the
+// connections that were started are sent to res.
+type preconnect struct {
+	topics []string
+	res    chan []partitionConnect
+}
+
+// partitionConnect is the connection of a partition writer to the broker at
+// addr, its outcome is sent to err. The addr is empty if the metadata of the
+// topic could not be looked up.
+type partitionConnect struct {
+	addr string
+	err  <-chan error
+}
+
+// respond reports the outcome of the write of the message to the call of
+// WriteMessages that it was passed to, at the index that it was passed at.
+func (wm writerMessage) respond(err error) {
+	wm.res <- writerResult{index: wm.index, err: err, offset: -1}
+}
+
+// written reports that the message was written to the partition at offset,
+// which is -1 if it is unknown, with the time that it was stamped with.
+func (wm writerMessage) written(partition int, offset int64, t time.Time) {
+	wm.res <- writerResult{index: wm.index, partition: partition, offset: offset, time: t}
+}
+
+type writerResult struct {
+	index     int
+	err       error
+	partition int
+	offset    int64
+	time      time.Time
+}
+
+// writeFailure returns the error reported to WriteMessages for msg when it
+// failed to be written with err. The message is retried unless err is a kafka
+// error which is not temporary, since the next attempts would fail the same.
+func writeFailure(msg Message, err error) error {
+	if isPermanent(err) {
+		return err
+	}
+	return &writerError{msg: msg, err: err}
+}
+
+// isPermanent returns true if err is an error that retrying the write would not
+// fix.
+func isPermanent(err error) bool {
+	switch e := err.(type) {
+	case Error:
+		return !e.Temporary()
+	case *PartitionNotFoundError:
+		return true
+	}
+	return false
 }
 
 type writerError struct {
@@ -5,9 +5,14 @@ import (
 	"errors"
 	"io"
 	"math"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	ktesting "github.com/segmentio/kafka-go/testing"
 )
 
 func TestWriter(t *testing.T) {
@@ -27,6 +32,11 @@ func TestWriter(t *testing.T) {
 			function: testWriterRoundRobin1,
 		},
 
+		{
+			scenario: "writing messages with repeated headers through a writer preserves them",
+			function: testWriterHeaders,
+		},
+
 		{
 			scenario: "running out of max attempts should return an error",
 			function: testWriterMaxAttemptsErr,
@@ -47,6 +57,84 @@ func TestWriter(t *testing.T) {
 			scenario: "writing messsages with a small batch byte size",
 			function: testWriterSmallBatchBytes,
 		},
+		{
+			scenario: "splitting the batches larger than the max message bytes",
+			function: testWriterMaxBatchBytes,
+		},
+		{
+			scenario: "splitting the batches that the broker rejects as too large",
+			function: testWriterSplitRejectedBatches,
+		},
+		{
+			scenario: "writing messages with an idempotent writer",
+			function: testWriterIdempotent,
+		},
+		{
+			scenario: "writing messages to the topics set on each message",
+			function: testWriterMessageTopics,
+		},
+		{
+			scenario: "reporting the offsets of written messages to the completion callback",
+			function: testWriterCompletion,
+		},
+		{
+			scenario: "flushing the messages of an asynchronous writer",
+			function: testWriterFlush,
+		},
+		{
+			scenario: "committing and aborting the transactions of a transactional writer",
+			function: testWriterTransaction,
+		},
+		{
+			scenario: "cancelling a write returns the error of the context",
+			function: testWriterCancel,
+		},
+
+		{
+			scenario: "giving up shutting down a writer when the context expires",
+			function: testWriterShutdown,
+		},
+		{
+			scenario: "reporting the outcome of each message when a write partially fails",
+			function: testWriterWriteErrors,
+		},
+		{
+			scenario: "reporting the permanent failures of a write whose other messages were retried",
+			function: testWriterRetryKeepsFailures,
+		},
+
+		{
+			scenario: "giving up retrying messages when the context is canceled during the backoff",
+			function: testWriterBackoffCancel,
+		},
+		{
+			scenario: "setting the partition and offset of the messages after writing them",
+			function: testWriterUpdateMessages,
+		},
+		{
+			scenario: "routing messages with the metadata of the partitions",
+			function: testWriterPartitionBalancer,
+		},
+		{
+			scenario: "creating the topic of the writer when it does not exist",
+			function: testWriterTopicAutoCreate,
+		},
+		{
+			scenario: "writing a stream of records",
+			function: testWriterWriteRecords,
+		},
+		{
+			scenario: "passing the messages which cannot be written to a dead letter handler",
+			function: testWriterDeadLetter,
+		},
+		{
+			scenario: "writing messages to the partitions set on them",
+			function: testWriterExplicitPartitions,
+		},
+		{
+			scenario: "connecting to the leaders of the partitions ahead of the first write",
+			function: testWriterPreconnect,
+		},
 	}
 
 	for _, test := range tests {
@@ -120,14 +208,55 @@ func testWriterRoundRobin1(t *testing.T) {
 	}
 }
 
+func testWriterHeaders(t *testing.T) {
+	const topic = "test-writer-headers"
+
+	createTopic(t, topic, 1)
+	offset, err := readOffset(topic, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := newTestWriter(WriterConfig{
+		Topic: topic,
+	})
+	defer w.Close()
+
+	msgs := []Message{
+		{Value: []byte("0"), Headers: []Header{{Key: "hop", Value: []byte("a")}, {Key: "hop", Value: []byte("b")}}},
+		{Value: []byte("1")},
+		{Value: []byte("2"), Headers: []Header{{Key: "hop", Value: []byte("c")}, {Key: "trace", Value: []byte("1")}, {Key: "hop", Value: []byte("d")}}},
+	}
+	if err := w.WriteMessages(context.Background(), msgs...); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readPartition(topic, 0, offset)
+	if err != nil {
+		t.Fatal("error reading partition", err)
+	}
+	if len(read) != len(msgs) {
+		t.Fatal("bad messages in partition", read)
+	}
+	for i, m := range read {
+		if !reflect.DeepEqual(headerPairs(msgs[i].Headers), headerPairs(m.Headers)) {
+			t.Errorf("bad headers of message %d: %+v != %+v", i, m.Headers, msgs[i].Headers)
+		}
+	}
+}
+
 func TestValidateWriter(t *testing.T) {
 	tests := []struct {
 		config       WriterConfig
 		errorOccured bool
 	}{
 		{config: WriterConfig{}, errorOccured: true},
-		{config: WriterConfig{Brokers: []string{"broker1", "broker2"}}, errorOccured: true},
+		{config: WriterConfig{Brokers: []string{"broker1", "broker2"}}, errorOccured: false},
 		{config: WriterConfig{Brokers: []string{"broker1"}, Topic: "topic1"}, errorOccured: false},
+		{config: WriterConfig{Brokers: []string{"broker1"}, Topic: "topic1", Idempotent: true}, errorOccured: false},
+		{config: WriterConfig{Brokers: []string{"broker1"}, Topic: "topic1", Idempotent: true, RequiredAcks: 1}, errorOccured: true},
+		{config: WriterConfig{Brokers: []string{"broker1"}, Topic: "topic1", TransactionalID: "txn1"}, errorOccured: false},
+		{config: WriterConfig{Brokers: []string{"broker1"}, Topic: "topic1", TransactionalID: "txn1", RequiredAcks: 1}, errorOccured: true},
 	}
 	for _, test := range tests {
 		err := test.config.Validate()
@@ -140,6 +269,94 @@ func TestValidateWriter(t *testing.T) {
 	}
 }
 
+func TestWriterMessageTopic(t *testing.T) {
+	tests := []struct {
+		scenario string
+		topic    string
+		msg      Message
+	}{
+		{scenario: "topic set on both the writer and the message", topic: "a", msg: Message{Topic: "b"}},
+		{scenario: "topic set on neither the writer nor the message", msg: Message{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			w := newTestWriter(WriterConfig{Topic: test.topic})
+			defer w.Close()
+
+			first := Message{Value: []byte("first")}
+			if test.topic == "" {
+				first.Topic = "c"
+			}
+
+			err := w.WriteMessages(context.Background(), first, test.msg)
+			if err == nil || !strings.Contains(err.Error(), "message 1") {
+				t.Errorf("expected an error on message 1, got %v", err)
+			}
+			if stats := w.Stats(); stats.Messages != 0 {
+				t.Errorf("expected no messages to be written, got %d", stats.Messages)
+			}
+		})
+	}
+}
+
+func TestCompletionQueue(t *testing.T) {
+	var nilQueue *completionQueue
+	nilQueue.push([]Message{{}}, nil)
+	nilQueue.close()
+
+	release := make(chan struct{})
+	var reported []string
+
+	q := newCompletionQueue(func(msgs []Message, err error) {
+		<-release
+		for _, m := range msgs {
+			reported = append(reported, string(m.Value))
+		}
+	})
+
+	// Pushing must not wait for the callback, which is blocked until all the
+	// completions were queued.
+	for i := 0; i < 10; i++ {
+		q.push([]Message{{Value: []byte(strconv.Itoa(i))}}, nil)
+	}
+	close(release)
+	q.close()
+	q.close()
+
+	expected := []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Errorf("expected the completions to be reported in order before close returned, got %q", reported)
+	}
+}
+
+func TestInflightMessages(t *testing.T) {
+	f := &inflightMessages{}
+
+	select {
+	case <-f.empty():
+	default:
+		t.Fatal("expected no messages to be in flight")
+	}
+
+	f.add(2)
+	empty := f.empty()
+
+	f.done(1)
+	select {
+	case <-empty:
+		t.Fatal("the messages in flight were reported done too early")
+	default:
+	}
+
+	f.done(1)
+	select {
+	case <-empty:
+	default:
+		t.Fatal("expected no messages to be in flight after they were done")
+	}
+}
+
 type fakeWriter struct {
 	attempts int
 }
@@ -151,9 +368,9 @@ func (f *fakeWriter) messages() chan<- writerMessage {
 		for {
 			msg := <-ch
 			f.attempts++
-			msg.res <- &writerError{
+			msg.respond(&writerError{
 				err: errors.New("bad attempt"),
-			}
+			})
 		}
 	}()
 
@@ -162,6 +379,55 @@ func (f *fakeWriter) messages() chan<- writerMessage {
 
 func (f *fakeWriter) close() {}
 
+// scriptedWriter is a partitionWriter which fails each attempt at writing a
+// message with the error that fail returns for its value, and writes it when
+// fail returns nil.
+type scriptedWriter struct {
+	mutex    sync.Mutex
+	attempts map[string]int
+	fail     func(value string, attempt int) error
+}
+
+func (s *scriptedWriter) messages() chan<- writerMessage {
+	ch := make(chan writerMessage, 1)
+
+	go func() {
+		for wm := range ch {
+			if wm.res == nil {
+				continue
+			}
+			s.mutex.Lock()
+			if s.attempts == nil {
+				s.attempts = make(map[string]int)
+			}
+			attempt := s.attempts[string(wm.msg.Value)]
+			s.attempts[string(wm.msg.Value)]++
+			s.mutex.Unlock()
+
+			if err := s.fail(string(wm.msg.Value), attempt); err != nil {
+				wm.respond(writeFailure(wm.msg, err))
+			} else {
+				wm.written(wm.msg.Partition, int64(attempt), time.Now())
+			}
+		}
+	}()
+
+	return ch
+}
+
+func (s *scriptedWriter) close() {}
+
+// stuckWriter is a partitionWriter which never writes its messages, and blocks
+// closing it until release is closed.
+type stuckWriter struct {
+	msgs    chan writerMessage
+	release chan struct{}
+}
+
+func (s *stuckWriter) messages() chan<- writerMessage { return s.msgs }
+
+func (s *stuckWriter) close() { <-s.release }
+
 func testWriterMaxAttemptsErr(t *testing.T) {
 	const topic = "test-writer-2"
 	const maxAttempts = 3
@@ -173,7 +439,7 @@ func testWriterMaxAttemptsErr(t *testing.T) {
 		Topic:       topic,
 		MaxAttempts: maxAttempts,
 		Balancer:    &RoundRobin{},
-		newPartitionWriter: func(p int, config WriterConfig, stats *writerStats) partitionWriter {
+		newPartitionWriter: func(topic string, p int, config WriterConfig, stats *writerStats) partitionWriter {
 			return fw
 		},
 	})
@@ -201,8 +467,9 @@ func testWriterMaxBytes(t *testing.T) {
 
 	createTopic(t, topic, 1)
 	w := newTestWriter(WriterConfig{
-		Topic:      topic,
-		BatchBytes: 25,
+		Topic:           topic,
+		BatchBytes:      25,
+		MaxMessageBytes: 75,
 	})
 	defer w.Close()
 
@@ -233,6 +500,9 @@ func testWriterMaxBytes(t *testing.T) {
 				t.Errorf("unxpected returned message. Expected: %s, Got %s", firstMsg, e.Message.Value)
 				return
 			}
+			if e.Index != 0 || e.Size != 80 {
+				t.Errorf("expected message 0 of 80 bytes to be too large, got message %d of %d bytes", e.Index, e.Size)
+			}
 			if len(e.Remaining) != 1 {
 				t.Error("expected remaining errors; found none")
 				return
@@ -389,6 +659,91 @@ func testWriterBatchSize(t *testing.T) {
 	}
 }
 
+func testWriterMaxBatchBytes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+	offset, err := readOffset(topic, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each message fits under MaxMessageBytes, but a record batch holding
+	// both of them does not.
+	w := newTestWriter(WriterConfig{
+		Topic:           topic,
+		MaxMessageBytes: 100,
+		BatchTimeout:    50 * time.Millisecond,
+	})
+	defer w.Close()
+
+	if err := w.WriteMessages(ctx, Message{Value: []byte("Hi")}, Message{Value: []byte("By")}); err != nil {
+		t.Fatal(err)
+	}
+	if ws := w.Stats(); ws.Writes != 2 {
+		t.Errorf("expected the messages to be written in 2 batches, got %d", ws.Writes)
+	}
+
+	msgs, err := readPartition(topic, 0, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("expected 2 messages in the partition, got %d", len(msgs))
+	}
+}
+
+func testWriterSplitRejectedBatches(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	topic := makeTopic()
+	conn, err := Dial("tcp", "localhost:9092")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Each message fits under the limit of the topic, but a record batch
+	// holding both of them does not, while the writer would accept it.
+	if err := conn.CreateTopics(TopicConfig{
+		Topic:             topic,
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+		ConfigEntries:     []ConfigEntry{{ConfigName: "max.message.bytes", ConfigValue: "200"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	offset, err := readOffset(topic, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := newTestWriter(WriterConfig{
+		Topic:        topic,
+		BatchTimeout: 50 * time.Millisecond,
+	})
+	defer w.Close()
+
+	value := []byte(strings.Repeat("x", 80))
+	if err := w.WriteMessages(ctx, Message{Value: value}, Message{Value: value}); err != nil {
+		t.Fatal(err)
+	}
+	if ws := w.Stats(); ws.Writes != 3 {
+		t.Errorf("expected the rejected batch to be written in 2 halves, got %d writes", ws.Writes)
+	}
+
+	msgs, err := readPartition(topic, 0, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("expected 2 messages in the partition, got %d", len(msgs))
+	}
+}
+
 func testWriterSmallBatchBytes(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -439,3 +794,1188 @@ func testWriterSmallBatchBytes(t *testing.T) {
 		t.Error("bad messages in partition", msgs)
 	}
 }
+
+func testWriterIdempotent(t *testing.T) {
+	if !ktesting.KafkaIsAtLeast("0.11.0") {
+		t.Skip("idempotent writes require kafka 0.11.0 or newer")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	topic := makeTopic()
+	createTopic(t, topic, 2)
+
+	w := newTestWriter(WriterConfig{
+		Topic:        topic,
+		BatchSize:    3,
+		BatchTimeout: 50 * time.Millisecond,
+		Balancer:     &RoundRobin{},
+		Idempotent:   true,
+	})
+	defer w.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := w.WriteMessages(ctx, makeTestSequence(6)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for partition := 0; partition < 2; partition++ {
+		msgs, err := readPartition(topic, partition, 0)
+		if err != nil {
+			t.Fatal("error reading partition", err)
+		}
+		if len(msgs) != 9 {
+			t.Errorf("expected 9 messages in partition %d, got %d", partition, len(msgs))
+		}
+	}
+}
+
+func TestIdempotentProducerReset(t *testing.T) {
+	p := &idempotentProducer{id: 1, epoch: 2, valid: true}
+
+	p.reset(1, 1)
+	if !p.valid {
+		t.Error("resetting an older producer epoch must not discard the current one")
+	}
+
+	p.reset(0, 2)
+	if !p.valid {
+		t.Error("resetting a different producer id must not discard the current one")
+	}
+
+	p.reset(1, 2)
+	if p.valid {
+		t.Error("resetting the current producer id and epoch must discard them")
+	}
+}
+
+type batchObserverFunc func(int)
+
+func (f batchObserverFunc) Balance(msg Message, partitions ...int) int { return partitions[0] }
+
+func (f batchObserverFunc) BatchCompleted(partition int) { f(partition) }
+
+func TestWriterBatchObserver(t *testing.T) {
+	completed := make(chan int, 1)
+
+	w := newWriter("a", 2, WriterConfig{
+		// Nothing listens on the port, the batch fails to be written after
+		// it completed.
+		Brokers:       []string{"localhost:1"},
+		Dialer:        DefaultDialer,
+		Balancer:      batchObserverFunc(func(partition int) { completed <- partition }),
+		BatchSize:     2,
+		BatchBytes:    1048576,
+		BatchTimeout:  time.Minute,
+		QueueCapacity: 10,
+		MaxAttempts:   1,
+	}, &writerStats{
+		dialTime:  makeSummary(),
+		writeTime: makeSummary(),
+		waitTime:  makeSummary(),
+		retries:   makeSummary(),
+	}, nil, nil, nil, nil, nil)
+	defer w.close()
+
+	w.messages() <- writerMessage{msg: Message{Value: []byte("a")}}
+	select {
+	case p := <-completed:
+		t.Fatalf("the batch of partition %d completed before it was full", p)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	w.messages() <- writerMessage{msg: Message{Value: []byte("b")}}
+	select {
+	case p := <-completed:
+		if p != 2 {
+			t.Errorf("expected the batch of partition 2 to complete, got partition %d", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the balancer was not told that the batch completed")
+	}
+}
+
+func TestWriterLargeMessage(t *testing.T) {
+	completed := make(chan int, 2)
+
+	w := newWriter("a", 0, WriterConfig{
+		Brokers:       []string{"localhost:1"},
+		Dialer:        DefaultDialer,
+		Balancer:      batchObserverFunc(func(partition int) { completed <- partition }),
+		BatchSize:     100,
+		BatchBytes:    100,
+		BatchTimeout:  time.Minute,
+		QueueCapacity: 10,
+		MaxAttempts:   1,
+	}, &writerStats{
+		dialTime:  makeSummary(),
+		writeTime: makeSummary(),
+		waitTime:  makeSummary(),
+		retries:   makeSummary(),
+	}, nil, nil, nil, nil, nil)
+	defer w.close()
+
+	w.messages() <- writerMessage{msg: Message{Value: []byte("a")}}
+
+	// The message larger than BatchBytes completes the batch of the first
+	// message, and is sent in a batch of its own without waiting for the
+	// batch timeout.
+	w.messages() <- writerMessage{msg: Message{Value: make([]byte, 200)}}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-completed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected 2 batches to complete, got %d", i)
+		}
+	}
+}
+
+func TestWriterPartitionStats(t *testing.T) {
+	stats := &writerStats{
+		dialTime:   makeSummary(),
+		writeTime:  makeSummary(),
+		waitTime:   makeSummary(),
+		retries:    makeSummary(),
+		partitions: &partitionStatsMap{},
+	}
+
+	w := newWriter("a", 2, WriterConfig{
+		Brokers:       []string{"localhost:1"},
+		Dialer:        DefaultDialer,
+		BatchSize:     2,
+		BatchBytes:    1048576,
+		BatchTimeout:  time.Minute,
+		QueueCapacity: 10,
+		MaxAttempts:   1,
+	}, stats, nil, nil, nil, nil, nil)
+	defer w.close()
+
+	res := make(chan writerResult, 2)
+	w.messages() <- writerMessage{msg: Message{Value: []byte("a")}, res: res}
+	w.messages() <- writerMessage{msg: Message{Value: []byte("b")}, res: res, index: 1}
+	for i := 0; i < 2; i++ {
+		if r := <-res; r.err == nil {
+			t.Fatal("expected the batch to fail to be written")
+		}
+	}
+
+	stats.partitions.get("a", 10).messages.observe(1)
+	stats.partitions.get("0", 0).messages.observe(1)
+
+	snapshot := stats.partitions.snapshot()
+	if len(snapshot) != 3 {
+		t.Fatalf("expected the statistics of 3 partitions, got %d", len(snapshot))
+	}
+	for i, key := range []topicPartition{{"0", 0}, {"a", 2}, {"a", 10}} {
+		if s := snapshot[i]; s.Topic != key.topic || s.Partition != strconv.Itoa(key.partition) {
+			t.Errorf("expected partition %d of topic %s at index %d, got partition %s of topic %s", key.partition, key.topic, i, s.Partition, s.Topic)
+		}
+	}
+	if s := snapshot[1]; s.Errors != 1 || s.Messages != 0 || s.QueueTime.Max < 0 {
+		t.Errorf("expected 1 error and the queue time of the failed batch, got %+v", s)
+	}
+
+	if s := stats.partitions.snapshot()[1]; s.Errors != 0 {
+		t.Errorf("expected the statistics to be reset by the snapshot, got %+v", s)
+	}
+}
+
+func TestWriterInterceptors(t *testing.T) {
+	header := func(key string) WriterInterceptor {
+		return func(ctx context.Context, msgs []Message) ([]Message, error) {
+			for i := range msgs {
+				msgs[i].Headers = append(msgs[i].Headers, Header{Key: key})
+			}
+			return msgs, nil
+		}
+	}
+	rejected := errors.New("rejected")
+
+	w := newTestWriter(WriterConfig{
+		Topic: "a",
+		Interceptors: []WriterInterceptor{
+			header("1"),
+			header("2"),
+			func(ctx context.Context, msgs []Message) ([]Message, error) {
+				for i, msg := range msgs {
+					if string(msg.Key) == "bad" {
+						return nil, &InterceptorError{Index: i, Err: rejected}
+					}
+				}
+				return msgs, nil
+			},
+		},
+	})
+	defer w.Close()
+
+	msgs := []Message{{Key: []byte("good")}, {Key: []byte("good")}}
+	intercepted, err := w.intercept(context.Background(), msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, msg := range intercepted {
+		if len(msg.Headers) != 2 || msg.Headers[0].Key != "1" || msg.Headers[1].Key != "2" {
+			t.Errorf("message %d: expected the headers to be added in the order of the interceptors, got %+v", i, msg.Headers)
+		}
+	}
+	for i, msg := range msgs {
+		if len(msg.Headers) != 0 {
+			t.Errorf("message %d: the messages of the caller were modified", i)
+		}
+	}
+
+	err = w.WriteMessages(context.Background(), Message{Key: []byte("good")}, Message{Key: []byte("bad")})
+	if e, ok := err.(*InterceptorError); !ok || e.Interceptor != 2 || e.Index != 1 {
+		t.Errorf("expected interceptor 2 to reject message 1, got %v", err)
+	}
+	if !errors.Is(err, rejected) {
+		t.Errorf("expected %v to wrap the error of the interceptor", err)
+	}
+
+	w = newTestWriter(WriterConfig{
+		Topic: "a",
+		Interceptors: []WriterInterceptor{
+			func(ctx context.Context, msgs []Message) ([]Message, error) {
+				return nil, rejected
+			},
+		},
+	})
+	defer w.Close()
+
+	err = w.WriteMessages(context.Background(), Message{})
+	if e, ok := err.(*InterceptorError); !ok || e.Interceptor != 0 || e.Index != -1 || e.Err != rejected {
+		t.Errorf("expected the error of interceptor 0 to be wrapped, got %v", err)
+	}
+}
+
+type countingLimiter struct {
+	waits int
+	bytes int
+	err   error
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	return l.err
+}
+
+func (l *countingLimiter) WaitN(ctx context.Context, n int) error {
+	l.bytes += n
+	return l.err
+}
+
+func TestWriterLimiter(t *testing.T) {
+	limiter := &countingLimiter{}
+	w := newTestWriter(WriterConfig{
+		Topic:        "a",
+		Limiter:      limiter,
+		BytesLimiter: limiter,
+	})
+	defer w.Close()
+
+	msgs := []Message{{Value: []byte("hello")}, {Key: []byte("a"), Value: []byte("world")}}
+	for _, msg := range msgs {
+		if err := w.limit(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if limiter.waits != 2 {
+		t.Errorf("expected 2 waits on the message limiter, got %d", limiter.waits)
+	}
+	if size := int(msgs[0].size() + msgs[1].size()); limiter.bytes != size {
+		t.Errorf("expected to wait for %d bytes, got %d", size, limiter.bytes)
+	}
+
+	limiter.err = errors.New("limited")
+	if err := w.WriteMessages(context.Background(), msgs...); err != limiter.err {
+		t.Errorf("expected the error of the limiter, got %v", err)
+	}
+	if n := w.inflight.pending(); n != 0 {
+		t.Errorf("expected no message to be queued, got %d", n)
+	}
+}
+
+func TestBrokerThrottles(t *testing.T) {
+	var throttles brokerThrottles
+
+	if d := throttles.delay("a"); d != 0 {
+		t.Errorf("expected no delay before any throttling, got %s", d)
+	}
+
+	throttles.set("a", time.Minute)
+	throttles.set("a", time.Millisecond)
+	throttles.set("b", 0)
+
+	if d := throttles.delay("a"); d <= time.Second || d > time.Minute {
+		t.Errorf("expected the longest throttle time to be kept, got a delay of %s", d)
+	}
+	if d := throttles.delay("b"); d != 0 {
+		t.Errorf("expected no delay for a broker which did not throttle, got %s", d)
+	}
+
+	throttles.set("c", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if d := throttles.delay("c"); d != 0 {
+		t.Errorf("expected no delay once the throttle time elapsed, got %s", d)
+	}
+
+	var none *brokerThrottles
+	none.set("a", time.Minute)
+	if d := none.delay("a"); d != 0 {
+		t.Errorf("expected a nil set of throttles to never delay writes, got %s", d)
+	}
+}
+
+func TestWriterBackoff(t *testing.T) {
+	config := WriterConfig{
+		WriteBackoffMin: 10 * time.Millisecond,
+		WriteBackoffMax: 50 * time.Millisecond,
+	}
+	for attempt, expected := range []time.Duration{0, 10 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond} {
+		if d := config.backoff(attempt); d != expected {
+			t.Errorf("attempt %d: expected a backoff of %s, got %s", attempt, expected, d)
+		}
+	}
+
+	config.Backoff = func(attempt int) time.Duration { return time.Duration(attempt) * time.Second }
+	if d := config.backoff(3); d != 3*time.Second {
+		t.Errorf("expected the backoff function to be used, got %s", d)
+	}
+
+	msg := Message{Value: []byte("hello")}
+	for _, err := range []error{NotLeaderForPartition, RequestTimedOut, io.ErrUnexpectedEOF} {
+		if _, ok := writeFailure(msg, err).(*writerError); !ok {
+			t.Errorf("expected messages failing with %v to be retried", err)
+		}
+	}
+	for _, err := range []error{MessageSizeTooLarge, InvalidTopic} {
+		if e := writeFailure(msg, err); e != err {
+			t.Errorf("expected messages failing with %v not to be retried, got %v", err, e)
+		}
+	}
+}
+
+func TestWriterStaleLeader(t *testing.T) {
+	for _, err := range []error{NotLeaderForPartition, LeaderNotAvailable, FencedLeaderEpoch} {
+		if !isStaleLeader(err) {
+			t.Errorf("expected %v to be reported by a stale leader", err)
+		}
+	}
+	for _, err := range []error{RequestTimedOut, NotEnoughReplicas, io.EOF} {
+		if isStaleLeader(err) {
+			t.Errorf("expected %v not to be reported by a stale leader", err)
+		}
+	}
+
+	refreshes := make(chan string, 1)
+	w := &writer{topic: "a", stats: &writerStats{}, refreshes: refreshes}
+	w.staleLeader()
+	w.staleLeader()
+
+	if n := w.stats.staleLeaders.snapshot(); n != 2 {
+		t.Errorf("expected 2 stale leaders, got %d", n)
+	}
+	if topic := <-refreshes; topic != "a" {
+		t.Errorf("expected a refresh of topic a, got %s", topic)
+	}
+	select {
+	case <-refreshes:
+		t.Error("the refreshes were not coalesced while one was pending")
+	default:
+	}
+
+	// Partition writers of writers which do not refresh the metadata on errors
+	// have no channel to send the refreshes to.
+	w.refreshes = nil
+	w.staleLeader()
+}
+
+func TestWriterTransactionState(t *testing.T) {
+	ctx := context.Background()
+
+	w := newTestWriter(WriterConfig{Topic: "a"})
+	defer w.Close()
+
+	if err := w.BeginTxn(ctx); err != errNotTransactional {
+		t.Errorf("expected beginning a transaction on a non-transactional writer to fail, got %v", err)
+	}
+
+	w = newTestWriter(WriterConfig{Topic: "a", TransactionalID: "b"})
+	defer w.Close()
+
+	if err := w.WriteMessages(ctx, Message{Value: []byte("a")}); err != errNoTransaction {
+		t.Errorf("expected writing outside of a transaction to fail, got %v", err)
+	}
+	if err := w.CommitTxn(ctx); err != errNoTransaction {
+		t.Errorf("expected committing without a transaction to fail, got %v", err)
+	}
+	if err := w.AbortTxn(ctx); err != errNoTransaction {
+		t.Errorf("expected aborting without a transaction to fail, got %v", err)
+	}
+}
+
+func TestIdempotentProducerTransaction(t *testing.T) {
+	ctx := context.Background()
+	p := &idempotentProducer{transactionalID: "a", id: 1, epoch: 2, valid: true}
+
+	if _, _, err := p.get(); err != errNoTransaction {
+		t.Errorf("expected the producer id to be unavailable outside of a transaction, got %v", err)
+	}
+
+	p.fail(errors.New("lost"))
+	if err := p.begin(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.begin(ctx); err != errTransactionInProgress {
+		t.Errorf("expected beginning a second transaction to fail, got %v", err)
+	}
+	if id, epoch, err := p.get(); err != nil || id != 1 || epoch != 2 {
+		t.Errorf("expected producer 1 at epoch 2, got producer %d at epoch %d (%v)", id, epoch, err)
+	}
+
+	// Nothing was written in the transaction, committing it does not need to
+	// reach the coordinator.
+	if err := p.end(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if !p.valid || p.inTxn {
+		t.Error("committing a transaction must keep the producer id and close the transaction")
+	}
+
+	if err := p.begin(ctx); err != nil {
+		t.Fatal(err)
+	}
+	lost := errors.New("lost")
+	p.fail(lost)
+	p.fail(errors.New("lost again"))
+
+	if _, _, err := p.get(); err != lost {
+		t.Errorf("expected the first failure of the transaction, got %v", err)
+	}
+	if err := p.end(ctx, true); err == nil {
+		t.Error("a failed transaction must not be committed")
+	}
+	if !p.inTxn {
+		t.Error("the failed transaction was closed when committing it")
+	}
+	if err := p.end(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if p.valid || p.inTxn || p.txnErr != nil {
+		t.Error("aborting a transaction must discard the producer id and the state of the transaction")
+	}
+}
+
+func testWriterMessageTopics(t *testing.T) {
+	topics := []string{makeTopic(), makeTopic()}
+	offsets := make([]int64, len(topics))
+
+	for i, topic := range topics {
+		createTopic(t, topic, 1)
+		offset, err := readOffset(topic, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets[i] = offset
+	}
+
+	w := newTestWriter(WriterConfig{
+		BatchTimeout: 100 * time.Millisecond,
+	})
+	defer w.Close()
+
+	msgs := []Message{
+		{Topic: topics[0], Value: []byte("a0")},
+		{Topic: topics[1], Value: []byte("b0")},
+		{Topic: topics[0], Value: []byte("a1")},
+	}
+	if err := w.WriteMessages(context.Background(), msgs...); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{{"a0", "a1"}, {"b0"}}
+
+	for i, topic := range topics {
+		found, err := readPartition(topic, 0, offsets[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		values := make([]string, len(found))
+		for j, m := range found {
+			values[j] = string(m.Value)
+		}
+		if !reflect.DeepEqual(values, expected[i]) {
+			t.Errorf("expected %q to be written to %s, got %q", expected[i], topic, values)
+		}
+	}
+}
+
+func testWriterCompletion(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 2)
+
+	offsets := make([]int64, 2)
+	for partition := range offsets {
+		offset, err := readOffset(topic, partition)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets[partition] = offset
+	}
+
+	mutex := sync.Mutex{}
+	written := make(map[string]Message)
+
+	w := newTestWriter(WriterConfig{
+		Topic:        topic,
+		Async:        true,
+		Balancer:     &RoundRobin{},
+		BatchTimeout: 100 * time.Millisecond,
+		Completion: func(msgs []Message, err error) {
+			if err != nil {
+				t.Errorf("writing %d messages failed: %v", len(msgs), err)
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, m := range msgs {
+				written[string(m.Value)] = m
+			}
+		},
+	})
+
+	msgs := makeTestSequence(10)
+	if err := w.WriteMessages(context.Background(), msgs...); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(written) != len(msgs) {
+		t.Fatalf("expected %d messages to be reported, got %d", len(msgs), len(written))
+	}
+
+	for partition, offset := range offsets {
+		found, err := readPartition(topic, partition, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range found {
+			reported := written[string(m.Value)]
+			if reported.Topic != topic || reported.Partition != partition || reported.Offset != m.Offset {
+				t.Errorf("message %q was read at %s/%d/%d but reported at %s/%d/%d",
+					m.Value, topic, partition, m.Offset, reported.Topic, reported.Partition, reported.Offset)
+			}
+		}
+	}
+}
+
+func testWriterFlush(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	offset, err := readOffset(topic, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := newTestWriter(WriterConfig{
+		Topic: topic,
+		Async: true,
+		// The batches would not be sent before the end of the test if the
+		// writer waited for them to time out.
+		BatchTimeout: time.Minute,
+	})
+	defer w.Close()
+
+	if err := w.WriteMessages(context.Background(), makeTestSequence(3)...); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := readPartition(topic, 0, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 3 {
+		t.Errorf("expected 3 messages to be written after flushing, got %d", len(msgs))
+	}
+}
+
+func testWriterTransaction(t *testing.T) {
+	if !ktesting.KafkaIsAtLeast("0.11.0") {
+		t.Skip("transactions require kafka 0.11.0 or newer")
+		return
+	}
+
+	topic := makeTopic()
+	createTopic(t, topic, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	w := newTestWriter(WriterConfig{
+		Topic:              topic,
+		TransactionalID:    makeTopic(),
+		TransactionTimeout: 10 * time.Second,
+		BatchTimeout:       100 * time.Millisecond,
+	})
+	defer w.Close()
+
+	for i, committed := range []bool{true, false, true} {
+		if err := w.BeginTxn(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		// Concurrent writes are all part of the transaction.
+		errs := make(chan error, 2)
+		for j := 0; j < 2; j++ {
+			go func() { errs <- w.WriteMessages(ctx, makeTestSequence(2)...) }()
+		}
+		for j := 0; j < 2; j++ {
+			if err := <-errs; err != nil {
+				t.Fatalf("transaction %d: %v", i, err)
+			}
+		}
+
+		var err error
+		if committed {
+			err = w.CommitTxn(ctx)
+		} else {
+			err = w.AbortTxn(ctx)
+		}
+		if err != nil {
+			t.Fatalf("transaction %d: %v", i, err)
+		}
+	}
+
+	c := NewClient("localhost:9092")
+	count := 0
+
+	for partition := 0; partition < 2; partition++ {
+		res, err := c.Fetch(ctx, FetchRequest{
+			Topic:          topic,
+			Partition:      partition,
+			MaxWait:        100 * time.Millisecond,
+			IsolationLevel: ReadCommitted,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		count += len(res.Messages)
+	}
+
+	// Only the messages of the two committed transactions are visible.
+	if count != 8 {
+		t.Errorf("expected 8 committed messages, got %d", count)
+	}
+}
+
+func testWriterCancel(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	stuck := &stuckWriter{
+		msgs:    make(chan writerMessage, 10),
+		release: make(chan struct{}),
+	}
+	close(stuck.release)
+
+	w := newTestWriter(WriterConfig{
+		Topic: topic,
+		newPartitionWriter: func(topic string, p int, config WriterConfig, stats *writerStats) partitionWriter {
+			return stuck
+		},
+	})
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := w.WriteMessages(ctx, makeTestSequence(3)...); err != context.DeadlineExceeded {
+		t.Errorf("expected the write to fail with the error of the context, got %v", err)
+	}
+}
+
+func testWriterShutdown(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	stuck := &stuckWriter{
+		msgs:    make(chan writerMessage, 10),
+		release: make(chan struct{}),
+	}
+
+	w := newTestWriter(WriterConfig{
+		Topic: topic,
+		Async: true,
+		newPartitionWriter: func(topic string, p int, config WriterConfig, stats *writerStats) partitionWriter {
+			return stuck
+		},
+	})
+
+	if err := w.WriteMessages(context.Background(), makeTestSequence(3)...); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	undelivered, err := w.Shutdown(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected the shutdown to give up when the context expired, got %v", err)
+	}
+	if undelivered != 3 {
+		t.Errorf("expected 3 undelivered messages, got %d", undelivered)
+	}
+
+	close(stuck.release)
+
+	if _, err := w.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+// partialWriter is a partitionWriter which fails to write the messages with a
+// key of "bad", and writes the others.
+type partialWriter struct {
+	msgs chan writerMessage
+}
+
+func (p *partialWriter) messages() chan<- writerMessage { return p.msgs }
+
+func (p *partialWriter) close() {}
+
+func (p *partialWriter) run() {
+	for wm := range p.msgs {
+		if string(wm.msg.Key) == "bad" {
+			wm.respond(&writerError{msg: wm.msg, err: NotEnoughReplicas})
+		} else {
+			wm.respond(nil)
+		}
+	}
+}
+
+func testWriterWriteErrors(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	pw := &partialWriter{msgs: make(chan writerMessage, 10)}
+	go pw.run()
+	defer close(pw.msgs)
+
+	w := newTestWriter(WriterConfig{
+		Topic:       topic,
+		MaxAttempts: 2,
+		newPartitionWriter: func(topic string, p int, config WriterConfig, stats *writerStats) partitionWriter {
+			return pw
+		},
+	})
+	defer w.Close()
+
+	err := w.WriteMessages(context.Background(),
+		Message{Key: []byte("good")},
+		Message{Key: []byte("bad")},
+		Message{Key: []byte("good")},
+		Message{Key: []byte("bad")},
+	)
+
+	errs, ok := err.(WriteErrors)
+	if !ok {
+		t.Fatalf("expected WriteErrors, got %v", err)
+	}
+	if len(errs) != 4 {
+		t.Fatalf("expected the outcome of 4 messages, got %d", len(errs))
+	}
+	if failed := errs.Failed(); !reflect.DeepEqual(failed, []int{1, 3}) {
+		t.Errorf("expected messages 1 and 3 to fail, got %v", failed)
+	}
+	if errs[1] != NotEnoughReplicas {
+		t.Errorf("expected message 1 to fail with NotEnoughReplicas, got %v", errs[1])
+	}
+}
+
+// poisonWriter is a partitionWriter which fails the batches holding a message
+// with a key of "bad" with InvalidRecord. The messages that are not written
+// alone are treated as sharing their batch with a bad message.
+type poisonWriter struct {
+	msgs chan writerMessage
+}
+
+func (p *poisonWriter) messages() chan<- writerMessage { return p.msgs }
+
+func (p *poisonWriter) close() {}
+
+func (p *poisonWriter) run() {
+	for wm := range p.msgs {
+		if !wm.alone || string(wm.msg.Key) == "bad" {
+			wm.respond(InvalidRecord)
+		} else {
+			wm.respond(nil)
+		}
+	}
+}
+
+func testWriterDeadLetter(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	pw := &poisonWriter{msgs: make(chan writerMessage, 10)}
+	go pw.run()
+	defer close(pw.msgs)
+
+	var deadLetters []Message
+	var deadErrs []error
+	w := newTestWriter(WriterConfig{
+		Topic:           topic,
+		MaxMessageBytes: 100,
+		WriteBackoffMin: time.Millisecond,
+		DeadLetter: func(ctx context.Context, msg Message, err error) error {
+			deadLetters, deadErrs = append(deadLetters, msg), append(deadErrs, err)
+			return nil
+		},
+		newPartitionWriter: func(topic string, p int, config WriterConfig, stats *writerStats) partitionWriter {
+			return pw
+		},
+	})
+	defer w.Close()
+
+	err := w.WriteMessages(context.Background(),
+		Message{Key: []byte("good")},
+		Message{Key: []byte("bad")},
+		Message{Key: []byte("large"), Value: make([]byte, 200)},
+		Message{Key: []byte("good")},
+	)
+	if err != nil {
+		t.Fatalf("expected the handled messages to not fail the write, got %v", err)
+	}
+
+	if len(deadLetters) != 2 {
+		t.Fatalf("expected 2 dead letters, got %d", len(deadLetters))
+	}
+	if string(deadLetters[0].Key) != "large" {
+		t.Errorf("expected the large message to be passed to the handler first, got %s", deadLetters[0].Key)
+	}
+	if _, ok := deadErrs[0].(MessageTooLargeError); !ok {
+		t.Errorf("expected a MessageTooLargeError, got %v", deadErrs[0])
+	}
+	if string(deadLetters[1].Key) != "bad" || deadErrs[1] != InvalidRecord {
+		t.Errorf("expected the bad message to fail with InvalidRecord, got %s with %v", deadLetters[1].Key, deadErrs[1])
+	}
+	if stats := w.Stats(); stats.DeadLetters != 2 {
+		t.Errorf("expected 2 dead letters in the stats, got %d", stats.DeadLetters)
+	}
+}
+
+func testWriterExplicitPartitions(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 3)
+
+	offset, err := readOffset(topic, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := newTestWriter(WriterConfig{
+		Topic:              topic,
+		Balancer:           &RoundRobin{},
+		ExplicitPartitions: true,
+		UpdateMessages:     true,
+	})
+	defer w.Close()
+
+	msgs := []Message{
+		{Partition: 2, Value: []byte("explicit")},
+		{Partition: -1, Value: []byte("balanced")},
+		{Partition: 5, Value: []byte("missing")},
+	}
+	err = w.WriteMessages(context.Background(), msgs...)
+
+	errs, ok := err.(WriteErrors)
+	if !ok {
+		t.Fatalf("expected WriteErrors, got %v", err)
+	}
+	if failed := errs.Failed(); !reflect.DeepEqual(failed, []int{2}) {
+		t.Fatalf("expected message 2 to fail, got %v", failed)
+	}
+	if e, ok := errs[2].(*PartitionNotFoundError); !ok || e.Partition != 5 || e.Partitions != 3 {
+		t.Errorf("expected a PartitionNotFoundError for partition 5 of 3, got %v", errs[2])
+	}
+	if msgs[0].Partition != 2 {
+		t.Errorf("expected the explicit message to be written to partition 2, got %d", msgs[0].Partition)
+	}
+	if msgs[1].Partition < 0 {
+		t.Errorf("the balanced message was not written to a partition")
+	}
+
+	written, err := readPartition(topic, 2, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) == 0 || string(written[0].Value) != "explicit" {
+		t.Errorf("expected the explicit message in partition 2, got %v", written)
+	}
+
+	// the message of a missing partition is reported when the balanced
+	// message of the same call is retried.
+	sw := &scriptedWriter{fail: func(value string, attempt int) error {
+		if attempt == 0 {
+			return NotLeaderForPartition
+		}
+		return nil
+	}}
+	retrying := newTestWriter(WriterConfig{
+		Topic:              topic,
+		Balancer:           &RoundRobin{},
+		ExplicitPartitions: true,
+		Backoff:            func(int) time.Duration { return time.Millisecond },
+		newPartitionWriter: func(topic string, p int, config WriterConfig, stats *writerStats) partitionWriter {
+			return sw
+		},
+	})
+	defer retrying.Close()
+
+	err = retrying.WriteMessages(context.Background(),
+		Message{Partition: 5, Value: []byte("missing")},
+		Message{Partition: -1, Value: []byte("retried")},
+	)
+
+	errs, ok = err.(WriteErrors)
+	if !ok {
+		t.Fatalf("expected WriteErrors, got %v", err)
+	}
+	if failed := errs.Failed(); !reflect.DeepEqual(failed, []int{0}) {
+		t.Fatalf("expected message 0 to fail, got %v", failed)
+	}
+	if e, ok := errs[0].(*PartitionNotFoundError); !ok || e.Partition != 5 {
+		t.Errorf("expected a PartitionNotFoundError for partition 5, got %v", errs[0])
+	}
+}
+
+func TestWriterPreconnectInvalid(t *testing.T) {
+	w := newTestWriter(WriterConfig{})
+
+	if _, err := w.Preconnect(context.Background()); err == nil {
+		t.Error("expected preconnecting a writer without topic to fail")
+	}
+
+	w.Close()
+	if _, err := w.Preconnect(context.Background(), "a"); err != io.ErrClosedPipe {
+		t.Errorf("expected preconnecting a closed writer to fail with io.ErrClosedPipe, got %v", err)
+	}
+}
+
+func testWriterPreconnect(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 2)
+
+	w := newTestWriter(WriterConfig{
+		Topic:    topic,
+		Balancer: &RoundRobin{},
+	})
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Connecting again leaves the connections of the first call in place.
+	for i := 0; i < 2; i++ {
+		failed, err := w.Preconnect(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(failed) != 0 {
+			t.Fatalf("expected all the brokers to be connected to, got %v", failed)
+		}
+	}
+	if stats := w.Stats(); stats.Dials != 2 {
+		t.Errorf("expected 2 dials to the leaders of the partitions, got %d", stats.Dials)
+	}
+
+	if err := w.WriteMessages(ctx, makeTestSequence(2)...); err != nil {
+		t.Fatal(err)
+	}
+	if stats := w.Stats(); stats.Dials != 0 {
+		t.Errorf("expected the messages to be written on the preconnected connections, got %d dials", stats.Dials)
+	}
+}
+
+func testWriterBackoffCancel(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	fw := &fakeWriter{}
+	w := newTestWriter(WriterConfig{
+		Topic:       topic,
+		MaxAttempts: 3,
+		Backoff:     func(int) time.Duration { return time.Minute },
+		newPartitionWriter: func(topic string, p int, config WriterConfig, stats *writerStats) partitionWriter {
+			return fw
+		},
+	})
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := w.WriteMessages(ctx, Message{Value: []byte("Hello World!")})
+	if err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the write to return when the context expired, it took %s", elapsed)
+	}
+	if fw.attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", fw.attempts)
+	}
+}
+
+func testWriterRetryKeepsFailures(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	sw := &scriptedWriter{fail: func(value string, attempt int) error {
+		switch {
+		case value == "permanent":
+			return MessageSizeTooLarge
+		case value == "retried" && attempt == 0:
+			return NotLeaderForPartition
+		}
+		return nil
+	}}
+	w := newTestWriter(WriterConfig{
+		Topic:       topic,
+		MaxAttempts: 3,
+		Backoff:     func(int) time.Duration { return time.Millisecond },
+		newPartitionWriter: func(topic string, p int, config WriterConfig, stats *writerStats) partitionWriter {
+			return sw
+		},
+	})
+	defer w.Close()
+
+	err := w.WriteMessages(context.Background(),
+		Message{Value: []byte("permanent")},
+		Message{Value: []byte("retried")},
+	)
+
+	errs, ok := err.(WriteErrors)
+	if !ok {
+		t.Fatalf("expected WriteErrors, got %v", err)
+	}
+	if failed := errs.Failed(); !reflect.DeepEqual(failed, []int{0}) {
+		t.Fatalf("expected message 0 to fail, got %v", failed)
+	}
+	if errs[0] != MessageSizeTooLarge {
+		t.Errorf("expected message 0 to fail with %v, got %v", MessageSizeTooLarge, errs[0])
+	}
+
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	if n := sw.attempts["retried"]; n != 2 {
+		t.Errorf("expected message 1 to be written on its second attempt, got %d attempts", n)
+	}
+	if n := sw.attempts["permanent"]; n != 1 {
+		t.Errorf("expected message 0 not to be retried, got %d attempts", n)
+	}
+}
+
+func testWriterUpdateMessages(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 1)
+
+	w := newTestWriter(WriterConfig{
+		Topic:          topic,
+		UpdateMessages: true,
+	})
+	defer w.Close()
+
+	msgs := makeTestSequence(3)
+	if err := w.WriteMessages(context.Background(), msgs...); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, msg := range msgs {
+		if msg.Partition != 0 || msg.Offset != int64(i) {
+			t.Errorf("message %d: expected to be written at offset %d of partition 0, got offset %d of partition %d", i, i, msg.Offset, msg.Partition)
+		}
+		if msg.Time.IsZero() {
+			t.Errorf("message %d: the time of the message was not set", i)
+		}
+	}
+
+	// The messages can be written again, the writer ignores their partition
+	// and offset.
+	if err := w.WriteMessages(context.Background(), msgs...); err != nil {
+		t.Fatal(err)
+	}
+	for i, msg := range msgs {
+		if msg.Offset != int64(i+3) {
+			t.Errorf("message %d: expected to be written again at offset %d, got %d", i, i+3, msg.Offset)
+		}
+	}
+}
+
+// metadataBalancer is a PartitionBalancer which records the partitions that it
+// was called with, and routes all messages to the last one.
+type metadataBalancer struct {
+	partitions []Partition
+}
+
+func (b *metadataBalancer) Balance(msg Message, partitions ...int) int {
+	panic("the writer called Balance on a PartitionBalancer")
+}
+
+func (b *metadataBalancer) BalancePartitions(msg Message, partitions []Partition) int {
+	b.partitions = partitions
+	return partitions[len(partitions)-1].ID
+}
+
+func testWriterPartitionBalancer(t *testing.T) {
+	topic := makeTopic()
+	createTopic(t, topic, 3)
+
+	balancer := &metadataBalancer{}
+	w := newTestWriter(WriterConfig{
+		Topic:          topic,
+		Balancer:       balancer,
+		UpdateMessages: true,
+	})
+	defer w.Close()
+
+	msgs := makeTestSequence(2)
+	if err := w.WriteMessages(context.Background(), msgs...); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(balancer.partitions) != 3 {
+		t.Fatalf("expected the balancer to receive 3 partitions, got %d", len(balancer.partitions))
+	}
+	for i, p := range balancer.partitions {
+		if p.ID != i || p.Topic != topic {
+			t.Errorf("expected partition %d of topic %s at index %d, got partition %d of topic %s", i, topic, i, p.ID, p.Topic)
+		}
+		if p.Leader.Host == "" || len(p.Replicas) == 0 {
+			t.Errorf("the metadata of partition %d is missing its leader or replicas: %+v", p.ID, p)
+		}
+	}
+	for i, msg := range msgs {
+		if msg.Partition != 2 {
+			t.Errorf("message %d: expected to be written to partition 2, got %d", i, msg.Partition)
+		}
+	}
+}